@@ -0,0 +1,100 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The planner prints the nodes that Karpenter would launch for a workload, along with their costs, so that NodePool
+// designs can be evaluated before they're deployed. It schedules the workload with the same scheduling library as the
+// provisioner against a simulated, empty cluster.
+//
+//	go run ./cmd/planner -nodepools ./nodepools -pods workload.yaml -instance-types instance-types.json
+//
+// The instance types can be exported from the fake cloud provider with -export-instance-types, or taken from the
+// instanceTypes of a scheduling snapshot of a real cluster.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+func main() {
+	nodePoolDir := flag.String("nodepools", "", "Directory of YAML files that contain the NodePools to launch nodes from")
+	podsPath := flag.String("pods", "", "YAML file that contains the Pods and Deployments to schedule")
+	instanceTypesPath := flag.String("instance-types", "", "JSON file that contains the instance types to launch, either as a list that every NodePool can launch or as a map of NodePool name to list")
+	exportInstanceTypes := flag.Bool("export-instance-types", false, "Print the instance types of the fake cloud provider as JSON and exit")
+	flag.Parse()
+
+	// The provisioner logs its scheduling decisions, which would drown out the plan
+	log.SetLogger(logr.Discard())
+	ctx := options.ToContext(context.Background(), test.Options())
+	if *exportInstanceTypes {
+		if err := exportFakeInstanceTypes(ctx); err != nil {
+			exit(err)
+		}
+		return
+	}
+	if *nodePoolDir == "" || *podsPath == "" || *instanceTypesPath == "" {
+		exit(fmt.Errorf("-nodepools, -pods and -instance-types are required"))
+	}
+	nodePools, err := loadNodePools(*nodePoolDir)
+	if err != nil {
+		exit(err)
+	}
+	pods, err := loadPods(*podsPath)
+	if err != nil {
+		exit(err)
+	}
+	instanceTypes, err := loadInstanceTypes(*instanceTypesPath, nodePools)
+	if err != nil {
+		exit(err)
+	}
+	results, err := plan(ctx, nodePools, pods, instanceTypes)
+	if err != nil {
+		exit(err)
+	}
+	if err := printPlan(os.Stdout, results); err != nil {
+		exit(err)
+	}
+}
+
+func exportFakeInstanceTypes(ctx context.Context) error {
+	instanceTypes, err := fake.NewCloudProvider().GetInstanceTypes(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("getting instance types, %w", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) provisioning.SnapshotInstanceType {
+		return provisioning.NewSnapshotInstanceType(it)
+	}))
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,256 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test/replay"
+)
+
+// loadNodePools reads the NodePools from every YAML file in the directory. The NodePools are assumed to be ready,
+// since there's no cluster to validate them or their NodeClasses against.
+func loadNodePools(dir string) ([]*v1.NodePool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.y*ml"))
+	if err != nil {
+		return nil, fmt.Errorf("listing nodepool files, %w", err)
+	}
+	var nodePools []*v1.NodePool
+	for _, file := range files {
+		docs, err := readDocuments(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if kind, err := kindOf(doc); err != nil || kind != "NodePool" {
+				continue
+			}
+			nodePool := &v1.NodePool{}
+			if err := yaml.UnmarshalStrict(doc, nodePool); err != nil {
+				return nil, fmt.Errorf("decoding nodepool in %s, %w", file, err)
+			}
+			nodePool.UID = uuid.NewUUID()
+			nodePool.StatusConditions().SetTrue(v1.ConditionTypeValidationSucceeded)
+			nodePool.StatusConditions().SetTrue(v1.ConditionTypeNodeClassReady)
+			nodePool.StatusConditions().SetTrue(v1.ConditionTypeLaunchesHealthy)
+			nodePools = append(nodePools, nodePool)
+		}
+	}
+	if len(nodePools) == 0 {
+		return nil, fmt.Errorf("no nodepools found in %s", dir)
+	}
+	return nodePools, nil
+}
+
+// loadPods reads the pending pods from the Pods and Deployments in the YAML file. Each replica of a Deployment is
+// scheduled as a separate pod.
+func loadPods(path string) ([]*corev1.Pod, error) {
+	docs, err := readDocuments(path)
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for _, doc := range docs {
+		kind, err := kindOf(doc)
+		if err != nil {
+			return nil, fmt.Errorf("decoding workload in %s, %w", path, err)
+		}
+		switch kind {
+		case "":
+			continue
+		case "Pod":
+			pod := &corev1.Pod{}
+			if err := yaml.UnmarshalStrict(doc, pod); err != nil {
+				return nil, fmt.Errorf("decoding pod in %s, %w", path, err)
+			}
+			pods = append(pods, pendingPod(pod))
+		case "Deployment":
+			deployment := &appsv1.Deployment{}
+			if err := yaml.UnmarshalStrict(doc, deployment); err != nil {
+				return nil, fmt.Errorf("decoding deployment in %s, %w", path, err)
+			}
+			for i := range lo.FromPtrOr(deployment.Spec.Replicas, 1) {
+				pods = append(pods, pendingPod(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("%s-%d", deployment.Name, i),
+						Namespace:   deployment.Namespace,
+						Labels:      deployment.Spec.Template.Labels,
+						Annotations: deployment.Spec.Template.Annotations,
+					},
+					Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+				}))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported workload kind %q in %s", kind, path)
+		}
+	}
+	return pods, nil
+}
+
+// pendingPod resets the pod to a pending pod that the scheduler failed to schedule, which the provisioner considers
+// for provisioning
+func pendingPod(pod *corev1.Pod) *corev1.Pod {
+	pod.Namespace = lo.Ternary(pod.Namespace == "", "default", pod.Namespace)
+	pod.UID = uuid.NewUUID()
+	pod.Spec.NodeName = ""
+	pod.Status = corev1.PodStatus{
+		Phase:      corev1.PodPending,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Reason: corev1.PodReasonUnschedulable, Status: corev1.ConditionFalse}},
+	}
+	return pod
+}
+
+// loadInstanceTypes reads the instance types that each NodePool can launch. The file either contains a list of
+// instance types that every NodePool can launch, or a map of NodePool name to instance types.
+func loadInstanceTypes(path string, nodePools []*v1.NodePool) (map[string][]provisioning.SnapshotInstanceType, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading instance types, %w", err)
+	}
+	var instanceTypes []provisioning.SnapshotInstanceType
+	if err := json.Unmarshal(raw, &instanceTypes); err == nil {
+		return lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, []provisioning.SnapshotInstanceType) {
+			return np.Name, instanceTypes
+		}), nil
+	}
+	instanceTypesByNodePool := map[string][]provisioning.SnapshotInstanceType{}
+	if err := json.Unmarshal(raw, &instanceTypesByNodePool); err != nil {
+		return nil, fmt.Errorf("decoding instance types, %w", err)
+	}
+	return instanceTypesByNodePool, nil
+}
+
+// plan schedules the pods against an empty cluster and returns the NodeClaims that the provisioner would launch
+func plan(ctx context.Context, nodePools []*v1.NodePool, pods []*corev1.Pod, instanceTypes map[string][]provisioning.SnapshotInstanceType) (scheduling.Results, error) {
+	env, err := replay.New(ctx, &provisioning.Snapshot{
+		Time:          metav1.Now(),
+		Synced:        true,
+		NodePools:     nodePools,
+		InstanceTypes: instanceTypes,
+		PendingPods:   pods,
+	})
+	if err != nil {
+		return scheduling.Results{}, fmt.Errorf("building simulation, %w", err)
+	}
+	results, err := env.Provision(ctx)
+	if err != nil {
+		return scheduling.Results{}, fmt.Errorf("scheduling pods, %w", err)
+	}
+	return results, nil
+}
+
+// printPlan prints the cheapest launch of each NodeClaim, the total cost of the NodeClaims and the pods that couldn't
+// be scheduled
+func printPlan(out io.Writer, results scheduling.Results) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODEPOOL\tINSTANCE TYPE\tCAPACITY TYPE\tZONE\tPODS\tPRICE")
+	total := 0.0
+	for _, nc := range results.NewNodeClaims {
+		it, offering, ok := cheapestLaunch(nc)
+		if !ok {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t%d\t-\n", nc.NodePoolName, len(nc.Pods))
+			continue
+		}
+		total += offering.Price
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%.4f\n", nc.NodePoolName, it.Name, offering.Requirements.Get(v1.CapacityTypeLabelKey).Any(), offering.Requirements.Get(corev1.LabelTopologyZone).Any(), len(nc.Pods), offering.Price)
+	}
+	fmt.Fprintf(w, "\nTotal: %d nodes, %.4f per hour\n", len(results.NewNodeClaims), total)
+	if len(results.PodErrors) > 0 {
+		fmt.Fprintln(w, "\nPOD\tERROR")
+		podErrors := lo.Entries(results.PodErrors)
+		sort.Slice(podErrors, func(i, j int) bool {
+			return podKey(podErrors[i].Key) < podKey(podErrors[j].Key)
+		})
+		for _, e := range podErrors {
+			fmt.Fprintf(w, "%s\t%s\n", podKey(e.Key), e.Value)
+		}
+	}
+	return w.Flush()
+}
+
+// cheapestLaunch returns the instance type and offering that the NodeClaim is most likely to be launched with
+func cheapestLaunch(nc *scheduling.NodeClaim) (*cloudprovider.InstanceType, cloudprovider.Offering, bool) {
+	var cheapest *cloudprovider.InstanceType
+	var offering cloudprovider.Offering
+	for _, it := range nc.InstanceTypeOptions {
+		offerings := it.Offerings.Available().Compatible(nc.Requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		if o := offerings.Cheapest(); cheapest == nil || o.Price < offering.Price {
+			cheapest, offering = it, o
+		}
+	}
+	return cheapest, offering, cheapest != nil
+}
+
+func podKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// readDocuments splits the YAML file into its documents, skipping empty documents
+func readDocuments(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s, %w", path, err)
+	}
+	defer f.Close()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	var docs [][]byte
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s, %w", path, err)
+		}
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+func kindOf(doc []byte) (string, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+		return "", err
+	}
+	return typeMeta.Kind, nil
+}
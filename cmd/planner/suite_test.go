@@ -0,0 +1,161 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestPlanner(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Planner")
+}
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+})
+
+const nodePoolYAML = `
+apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: on-demand
+spec:
+  template:
+    spec:
+      nodeClassRef:
+        group: karpenter.test.sh
+        kind: TestNodeClass
+        name: default
+      requirements:
+        - key: karpenter.sh/capacity-type
+          operator: In
+          values: ["on-demand"]
+`
+
+const workloadYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  selector:
+    matchLabels: {app: web}
+  template:
+    metadata:
+      labels: {app: web}
+    spec:
+      containers:
+        - name: web
+          image: nginx
+          resources:
+            requests: {cpu: "1"}
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: huge
+  namespace: batch
+spec:
+  containers:
+    - name: huge
+      image: nginx
+      resources:
+        requests: {cpu: "1000"}
+`
+
+var _ = Describe("Planner", func() {
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "nodepools"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "nodepools", "on-demand.yaml"), []byte(nodePoolYAML), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "workload.yaml"), []byte(workloadYAML), 0600)).To(Succeed())
+		instanceTypes, err := fake.NewCloudProvider().GetInstanceTypes(ctx, nil)
+		Expect(err).ToNot(HaveOccurred())
+		raw, err := json.Marshal(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) provisioning.SnapshotInstanceType {
+			return provisioning.NewSnapshotInstanceType(it)
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "instance-types.json"), raw, 0600)).To(Succeed())
+	})
+	It("should load ready NodePools from the directory", func() {
+		nodePools, err := loadNodePools(filepath.Join(dir, "nodepools"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodePools).To(HaveLen(1))
+		Expect(nodePools[0].Name).To(Equal("on-demand"))
+		Expect(nodePools[0].StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should error if the directory doesn't contain NodePools", func() {
+		_, err := loadNodePools(dir)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should load a pending pod for each replica of a Deployment", func() {
+		pods, err := loadPods(filepath.Join(dir, "workload.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lo.Map(pods, func(p *corev1.Pod, _ int) string { return podKey(p) })).To(ConsistOf("default/web-0", "default/web-1", "default/web-2", "batch/huge"))
+	})
+	It("should apply a list of instance types to every NodePool", func() {
+		nodePools, err := loadNodePools(filepath.Join(dir, "nodepools"))
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes, err := loadInstanceTypes(filepath.Join(dir, "instance-types.json"), nodePools)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveKey("on-demand"))
+		Expect(instanceTypes["on-demand"]).ToNot(BeEmpty())
+	})
+	It("should print the nodes to launch and the pods that can't schedule", func() {
+		nodePools, err := loadNodePools(filepath.Join(dir, "nodepools"))
+		Expect(err).ToNot(HaveOccurred())
+		pods, err := loadPods(filepath.Join(dir, "workload.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes, err := loadInstanceTypes(filepath.Join(dir, "instance-types.json"), nodePools)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := plan(ctx, nodePools, pods, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].Pods).To(HaveLen(3))
+		Expect(results.PodErrors).To(HaveLen(1))
+
+		out := &bytes.Buffer{}
+		Expect(printPlan(out, results)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("on-demand"))
+		Expect(out.String()).To(ContainSubstring("Total: 1 nodes"))
+		Expect(out.String()).To(ContainSubstring("batch/huge"))
+	})
+})
@@ -21,6 +21,12 @@ tools() {
     go install github.com/onsi/ginkgo/v2/ginkgo@latest
     go install github.com/rhysd/actionlint/cmd/actionlint@latest
     go install github.com/mattn/goveralls@latest
+    go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.3
+    go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
+
+    if ! command -v protoc >/dev/null; then
+        echo "protoc is required to generate the state server's gRPC stubs. Install it from https://github.com/protocolbuffers/protobuf/releases."
+    fi
 
     if ! echo "$PATH" | grep -q "${GOPATH:-undefined}/bin\|$HOME/go/bin"; then
         echo "Go workspace's \"bin\" directory is not in PATH. Run 'export PATH=\"\$PATH:\${GOPATH:-\$HOME/go}/bin\"'."
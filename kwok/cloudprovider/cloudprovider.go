@@ -126,6 +126,11 @@ func (c CloudProvider) GetSupportedNodeClasses() []status.Object {
 	return []status.Object{&v1alpha1.KWOKNodeClass{}}
 }
 
+// Return nothing since KWOK nodes don't consume any exhaustible capacity.
+func (c CloudProvider) GetCapacityPools(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.CapacityPool, error) {
+	return nil, nil
+}
+
 func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
 	return []cloudprovider.RepairPolicy{}
 }
@@ -33,7 +33,7 @@ func main() {
 	}
 
 	cloudProvider := kwok.NewCloudProvider(ctx, op.GetClient(), instanceTypes)
-	clusterState := state.NewCluster(op.Clock, op.GetClient(), cloudProvider)
+	clusterState := state.NewCluster(op.Clock, op.GetClient(), cloudProvider, op.EventRecorder)
 	op.
 		WithControllers(ctx, controllers.NewControllers(
 			ctx,
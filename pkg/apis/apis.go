@@ -34,8 +34,17 @@ var (
 	NodePoolCRD []byte
 	//go:embed crds/karpenter.sh_nodeclaims.yaml
 	NodeClaimCRD []byte
-	CRDs         = []*apiextensionsv1.CustomResourceDefinition{
+	//go:embed crds/karpenter.sh_nodeprovenances.yaml
+	NodeProvenanceCRD []byte
+	//go:embed crds/karpenter.sh_disruptionplans.yaml
+	DisruptionPlanCRD []byte
+	//go:embed crds/karpenter.sh_readinessgates.yaml
+	ReadinessGateCRD []byte
+	CRDs             = []*apiextensionsv1.CustomResourceDefinition{
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodePoolCRD),
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodeClaimCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodeProvenanceCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](DisruptionPlanCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](ReadinessGateCRD),
 	}
 )
@@ -41,6 +41,24 @@ const (
 	NodeInitializedLabelKey = apis.Group + "/initialized"
 	NodeRegisteredLabelKey  = apis.Group + "/registered"
 	CapacityTypeLabelKey    = apis.Group + "/capacity-type"
+	// DisruptionCordonedLabelKey is the disruption reason that Karpenter cordoned a node for, when the node's NodePool
+	// only cordons the nodes that it disrupts for the reason
+	DisruptionCordonedLabelKey = apis.Group + "/disruption-cordoned"
+	// EphemeralStorageLabelKey is the ephemeral storage capacity of the node in whole GiB
+	EphemeralStorageLabelKey = apis.Group + "/ephemeral-storage"
+	// HookLabelKey is the name of the lifecycle hook that a Job and its pods were created for. Pods with the label
+	// aren't provisioned for, so that hooks don't launch nodes of their own.
+	HookLabelKey = apis.Group + "/hook"
+	// CapabilityLabelDomain is the domain of the labels of the boolean capabilities of instance types, e.g.
+	// capability.karpenter.sh/nested-virtualization. Nodes are labeled "true" for each capability of their instance
+	// type, and instance types without a capability are treated as "false".
+	CapabilityLabelDomain = "capability." + apis.Group
+)
+
+// Values of capability labels
+const (
+	CapabilityTrue  = "true"
+	CapabilityFalse = "false"
 )
 
 // Karpenter specific annotations
@@ -50,11 +68,53 @@ const (
 	NodePoolHashAnnotationKey                  = apis.Group + "/nodepool-hash"
 	NodePoolHashVersionAnnotationKey           = apis.Group + "/nodepool-hash-version"
 	NodeClaimTerminationTimestampAnnotationKey = apis.Group + "/nodeclaim-termination-timestamp"
+	NodeClaimSchedulingDecisionAnnotationKey   = apis.Group + "/scheduling-decision"
+	DrainDaemonSetPodsAnnotationKey            = apis.Group + "/drain-daemonset-pods"
+	EmptyDirProtectionAnnotationKey            = apis.Group + "/emptydir-protection"
+	NodeClaimIdempotencyKeyAnnotationKey       = apis.Group + "/idempotency-key"
+	NodeClaimTerminationReasonAnnotationKey    = apis.Group + "/termination-reason"
+	NodePoolForceAnnotationKey                 = apis.Group + "/force"
+	CapacityTypeFallbackAnnotationKey          = apis.Group + "/capacity-type-fallback"
+	ReplaceableAnnotationKey                   = apis.Group + "/replaceable"
+	ExpirationPausedAnnotationKey              = apis.Group + "/expiration-paused"
+	DisruptionProtectionAnnotationKey          = apis.Group + "/disruption-protection"
+	DisruptionConfirmedAnnotationKey           = apis.Group + "/disruption-confirmed"
+	DisruptionLockAnnotationKey                = apis.Group + "/disruption-lock"
+	DisruptionLockExpirationAnnotationKey      = apis.Group + "/disruption-lock-expiration"
+	PreferExistingAnnotationKey                = apis.Group + "/prefer-existing"
+	PreferenceRelaxationAnnotationKey          = apis.Group + "/preference-relaxation"
+	IPFamilyAnnotationKey                      = apis.Group + "/ip-family"
+	// DrainReadinessTimeoutAnnotationKey opts a node in to waiting, after it's tainted for termination, for an external
+	// agent to annotate it with the DrainReadyAnnotationKey before its pods are drained. Its value is the longest
+	// duration to wait. Karpenter marks the start of the wait with the DrainReadinessRequestedAnnotationKey.
+	DrainReadinessTimeoutAnnotationKey   = apis.Group + "/drain-readiness-timeout"
+	DrainReadinessRequestedAnnotationKey = apis.Group + "/drain-readiness-requested"
+	DrainReadyAnnotationKey              = apis.Group + "/drain-ready"
+)
+
+// PreferExistingRequired is the value of the PreferExistingAnnotationKey that restricts a pod to existing and in-flight
+// capacity. Karpenter never launches a node for these pods.
+const PreferExistingRequired = "required"
+
+// PreferenceRelaxationDisabled is the value of the PreferenceRelaxationAnnotationKey that treats the preferences of a
+// pod as requirements. Pods whose preferences can't be satisfied are reported as unschedulable instead of relaxed.
+const PreferenceRelaxationDisabled = "disabled"
+
+// IP families that pods are assigned addresses from, which the pods capacity of instance types can depend on. NodePools
+// can set the IPFamilyAnnotationKey to override the IP family of the cluster for their nodes.
+const (
+	IPFamilyIPv4      = "IPv4"
+	IPFamilyIPv6      = "IPv6"
+	IPFamilyDualStack = "DualStack"
 )
 
 // Karpenter specific finalizers
 const (
 	TerminationFinalizer = apis.Group + "/termination"
+	// DisruptionProtectionFinalizer is added to nodes that host pods with the karpenter.sh/disruption-protection
+	// annotation. Voluntary terminations of these nodes wait for the karpenter.sh/disruption-confirmed annotation on the
+	// node before the node is drained.
+	DisruptionProtectionFinalizer = apis.Group + "/disruption-protection"
 )
 
 var (
@@ -84,9 +144,15 @@ var (
 		v1.LabelArchStable,
 		v1.LabelOSStable,
 		CapacityTypeLabelKey,
+		EphemeralStorageLabelKey,
 		v1.LabelWindowsBuild,
 	)
 
+	// WellKnownLabelDomains are domains that belong to the RestrictedLabelDomains but whose labels are all well known.
+	WellKnownLabelDomains = sets.New(
+		CapabilityLabelDomain,
+	)
+
 	// RestrictedLabels are labels that should not be used
 	// because they may interfere with the internal provisioning logic.
 	RestrictedLabels = sets.New(
@@ -104,9 +170,14 @@ var (
 	}
 )
 
+// IsWellKnownLabel returns true if the label is one of the WellKnownLabels or belongs to the WellKnownLabelDomains.
+func IsWellKnownLabel(key string) bool {
+	return WellKnownLabels.Has(key) || WellKnownLabelDomains.Has(GetLabelDomain(key))
+}
+
 // IsRestrictedLabel returns an error if the label is restricted.
 func IsRestrictedLabel(key string) error {
-	if WellKnownLabels.Has(key) {
+	if IsWellKnownLabel(key) {
 		return nil
 	}
 	if IsRestrictedNodeLabel(key) {
@@ -119,7 +190,7 @@ func IsRestrictedLabel(key string) error {
 // They are either known labels that will be injected by cloud providers,
 // or label domain managed by other software (e.g., kops.k8s.io managed by kOps).
 func IsRestrictedNodeLabel(key string) bool {
-	if WellKnownLabels.Has(key) {
+	if IsWellKnownLabel(key) {
 		return true
 	}
 	labelDomain := GetLabelDomain(key)
@@ -143,6 +214,16 @@ func GetLabelDomain(key string) string {
 	return ""
 }
 
+// CapabilityLabelKey returns the label of the instance type capability with the name
+func CapabilityLabelKey(name string) string {
+	return fmt.Sprintf("%s/%s", CapabilityLabelDomain, name)
+}
+
+// IsCapabilityLabel returns true if the label is the label of an instance type capability
+func IsCapabilityLabel(key string) bool {
+	return GetLabelDomain(key) == CapabilityLabelDomain
+}
+
 func NodeClassLabelKey(gk schema.GroupKind) string {
 	return fmt.Sprintf("%s/%s", gk.Group, strings.ToLower(gk.Kind))
 }
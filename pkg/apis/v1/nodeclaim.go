@@ -23,6 +23,32 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// TemporaryTaint is a taint that's removed from the node by Karpenter once it expires. The taint expires after
+// ExpireAfter has passed since the node registered or once the node has the Condition, whichever comes first.
+type TemporaryTaint struct {
+	v1.Taint `json:",inline"`
+	// ExpireAfter is the duration after the node registers that the taint is removed
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+	// Condition is the node condition that the taint is removed once the node has
+	// +optional
+	Condition *TemporaryTaintCondition `json:"condition,omitempty"`
+}
+
+// TemporaryTaintCondition is a node condition that expires a TemporaryTaint
+type TemporaryTaintCondition struct {
+	// Type of the node condition
+	// +required
+	Type v1.NodeConditionType `json:"type"`
+	// Status of the node condition. The taint is removed once the condition has this status.
+	// +kubebuilder:validation:Enum:={"True","False","Unknown"}
+	// +kubebuilder:default:="True"
+	// +optional
+	Status v1.ConditionStatus `json:"status,omitempty"`
+}
+
 // NodeClaimSpec describes the desired state of the NodeClaim
 type NodeClaimSpec struct {
 	// Taints will be applied to the NodeClaim's node.
@@ -34,8 +60,16 @@ type NodeClaimSpec struct {
 	// purposes in that pods are not required to tolerate a StartupTaint in order to have nodes provisioned for them.
 	// +optional
 	StartupTaints []v1.Taint `json:"startupTaints,omitempty"`
+	// TemporaryTaints are taints that are applied to nodes when they register and removed by Karpenter once they expire,
+	// e.g. to keep regular workloads off of nodes while they warm up. Like StartupTaints, TemporaryTaints are ignored for
+	// provisioning purposes in that pods are not required to tolerate a TemporaryTaint in order to have nodes provisioned
+	// for them.
+	// +kubebuilder:validation:XValidation:message="temporaryTaints must set expireAfter or condition",rule="self.all(x, has(x.expireAfter) || has(x.condition))"
+	// +optional
+	TemporaryTaints []TemporaryTaint `json:"temporaryTaints,omitempty"`
 	// Requirements are layered with GetLabels and applied to every node.
 	// +kubebuilder:validation:XValidation:message="requirements with operator 'In' must have a value defined",rule="self.all(x, x.operator == 'In' ? x.values.size() != 0 : true)"
+	// +kubebuilder:validation:XValidation:message="requirements with operator 'Exists' or 'DoesNotExist' must not have values defined",rule="self.all(x, (x.operator == 'Exists' || x.operator == 'DoesNotExist') ? !has(x.values) || x.values.size() == 0 : true)"
 	// +kubebuilder:validation:XValidation:message="requirements operator 'Gt' or 'Lt' must have a single positive integer value",rule="self.all(x, (x.operator == 'Gt' || x.operator == 'Lt') ? (x.values.size() == 1 && int(x.values[0]) >= 0) : true)"
 	// +kubebuilder:validation:XValidation:message="requirements with 'minValues' must have at least that many values specified in the 'values' field",rule="self.all(x, (x.operator == 'In' && has(x.minValues)) ? x.values.size() >= x.minValues : true)"
 	// +kubebuilder:validation:MaxItems:=100
@@ -154,3 +188,45 @@ type NodeClaimList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []NodeClaim `json:"items"`
 }
+
+// TerminationReason is the reason that a NodeClaim and its Node were deleted. It's recorded on the NodeClaim with the
+// karpenter.sh/termination-reason annotation by the controller that initiates the deletion.
+type TerminationReason string
+
+const (
+	TerminationReasonConsolidation TerminationReason = "Consolidation"
+	TerminationReasonDrift         TerminationReason = "Drift"
+	TerminationReasonExpiration    TerminationReason = "Expiration"
+	TerminationReasonManual        TerminationReason = "Manual"
+	TerminationReasonInterruption  TerminationReason = "Interruption"
+	TerminationReasonUnhealthy     TerminationReason = "Unhealthy"
+	TerminationReasonLaunchFailed  TerminationReason = "LaunchFailed"
+)
+
+// IsVoluntary returns whether the termination was chosen by Karpenter or a user, rather than forced by a failure of the
+// underlying instance
+func (r TerminationReason) IsVoluntary() bool {
+	switch r {
+	case TerminationReasonInterruption, TerminationReasonUnhealthy, TerminationReasonLaunchFailed:
+		return false
+	default:
+		return true
+	}
+}
+
+// TerminationReasonForDisruption returns the TerminationReason for a NodeClaim disrupted for the DisruptionReason
+func TerminationReasonForDisruption(reason DisruptionReason) TerminationReason {
+	if reason == DisruptionReasonDrifted {
+		return TerminationReasonDrift
+	}
+	return TerminationReasonConsolidation
+}
+
+// TerminationReason returns the reason that the NodeClaim was deleted. NodeClaims that weren't annotated by a Karpenter
+// controller were deleted by a user or another client, so they're reported as manual terminations.
+func (in *NodeClaim) TerminationReason() TerminationReason {
+	if reason, ok := in.Annotations[NodeClaimTerminationReasonAnnotationKey]; ok && reason != "" {
+		return TerminationReason(reason)
+	}
+	return TerminationReasonManual
+}
@@ -31,6 +31,7 @@ const (
 	ConditionTypeInstanceTerminating  = "InstanceTerminating"
 	ConditionTypeConsistentStateFound = "ConsistentStateFound"
 	ConditionTypeDisruptionReason     = "DisruptionReason"
+	ConditionTypeOrphaned             = "Orphaned"
 )
 
 // NodeClaimStatus defines the observed state of NodeClaim
@@ -58,6 +59,27 @@ type NodeClaimStatus struct {
 	// is also considered as removed.
 	// +optional
 	LastPodEventTime metav1.Time `json:"lastPodEventTime,omitempty"`
+	// LastPodEvictionTime is the last time that a pod was evicted from the node while it was draining
+	// +optional
+	LastPodEvictionTime metav1.Time `json:"lastPodEvictionTime,omitempty"`
+	// Drain reports the progress of draining the node and what it's waiting on while the NodeClaim is terminating
+	// +optional
+	Drain *DrainStatus `json:"drain,omitempty"`
+}
+
+// DrainStatus reports the progress of draining a node, so that a node that is stuck draining can be diagnosed
+// without access to the logs
+type DrainStatus struct {
+	// RemainingPods is the number of pods that are waiting to be evicted from the node
+	RemainingPods int32 `json:"remainingPods"`
+	// TerminatingPods is the number of pods that have been evicted from the node and are terminating
+	TerminatingPods int32 `json:"terminatingPods"`
+	// BlockingPod is the namespace/name of the pod whose eviction failed the most times, e.g. because of a PDB
+	// +optional
+	BlockingPod string `json:"blockingPod,omitempty"`
+	// BlockingPDB is the namespace/name of the PodDisruptionBudget that rejects evicting the BlockingPod
+	// +optional
+	BlockingPDB string `json:"blockingPDB,omitempty"`
 }
 
 func (in *NodeClaim) StatusConditions() status.ConditionSet {
@@ -18,6 +18,7 @@ package v1
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/samber/lo"
@@ -61,7 +62,8 @@ type taintKeyEffect struct {
 
 func (in *NodeClaimTemplateSpec) validateTaints() (errs error) {
 	existing := map[taintKeyEffect]struct{}{}
-	errs = multierr.Combine(validateTaintsField(in.Taints, existing, "taints"), validateTaintsField(in.StartupTaints, existing, "startupTaints"))
+	errs = multierr.Combine(validateTaintsField(in.Taints, existing, "taints"), validateTaintsField(in.StartupTaints, existing, "startupTaints"),
+		validateTaintsField(lo.Map(in.TemporaryTaints, func(t TemporaryTaint, _ int) v1.Taint { return t.Taint }), existing, "temporaryTaints"))
 	return errs
 }
 
@@ -110,6 +112,104 @@ func (in *NodeClaimTemplateSpec) validateRequirements() (errs error) {
 	return errs
 }
 
+// validateRequirementsConflicts rejects keys that have multiple requirements which no value can satisfy together, e.g.
+// "In [a]" and "NotIn [a]", as a NodePool with them would never launch nodes for any pod.
+func (in *NodeClaimTemplateSpec) validateRequirementsConflicts() (errs error) {
+	byKey := lo.GroupBy(in.Requirements, func(r NodeSelectorRequirementWithMinValues) string {
+		if normalized, ok := NormalizedLabels[r.Key]; ok {
+			return normalized
+		}
+		return r.Key
+	})
+	for _, key := range sets.List(sets.KeySet(byKey)) {
+		if len(byKey[key]) < 2 {
+			continue
+		}
+		if err := newKeyRequirements(byKey[key]).validate(key); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("invalid value: %w in requirements", err))
+		}
+	}
+	return errs
+}
+
+// keyRequirements is the combination of the requirements for a single key
+type keyRequirements struct {
+	// values are the values that are allowed by every In requirement, nil if there are no In requirements
+	values       sets.Set[string]
+	excluded     sets.Set[string]
+	exists       bool
+	doesNotExist bool
+	bounded      bool
+	greaterThan  int64
+	lessThan     int64
+}
+
+func newKeyRequirements(requirements []NodeSelectorRequirementWithMinValues) *keyRequirements {
+	k := &keyRequirements{excluded: sets.New[string](), greaterThan: math.MinInt64, lessThan: math.MaxInt64}
+	for _, requirement := range requirements {
+		switch requirement.Operator {
+		case v1.NodeSelectorOpIn:
+			k.exists = true
+			if k.values == nil {
+				k.values = sets.New(requirement.Values...)
+			} else {
+				k.values = k.values.Intersection(sets.New(requirement.Values...))
+			}
+		case v1.NodeSelectorOpNotIn:
+			k.excluded.Insert(requirement.Values...)
+		case v1.NodeSelectorOpExists:
+			k.exists = true
+		case v1.NodeSelectorOpDoesNotExist:
+			k.doesNotExist = true
+		case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+			k.withBound(requirement)
+		}
+	}
+	return k
+}
+
+// withBound narrows the range of integer values, ignoring invalid values as they're reported by ValidateRequirement
+func (k *keyRequirements) withBound(requirement NodeSelectorRequirementWithMinValues) {
+	if len(requirement.Values) != 1 {
+		return
+	}
+	value, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+	if err != nil {
+		return
+	}
+	k.exists = true
+	k.bounded = true
+	if requirement.Operator == v1.NodeSelectorOpGt {
+		k.greaterThan = max(k.greaterThan, value)
+	} else {
+		k.lessThan = min(k.lessThan, value)
+	}
+}
+
+func (k *keyRequirements) validate(key string) error {
+	if k.exists && k.doesNotExist {
+		return fmt.Errorf("key %s is required to both exist and not exist", key)
+	}
+	if k.bounded && k.lessThan-k.greaterThan <= 1 {
+		return fmt.Errorf("key %s has no integer value greater than %d and less than %d", key, k.greaterThan, k.lessThan)
+	}
+	if k.values != nil && !lo.SomeBy(k.values.UnsortedList(), k.allows) {
+		return fmt.Errorf("key %s has no value that satisfies all of its requirements", key)
+	}
+	return nil
+}
+
+func (k *keyRequirements) allows(value string) bool {
+	if k.excluded.Has(value) {
+		return false
+	}
+	if !k.bounded {
+		return true
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	return err == nil && v > k.greaterThan && v < k.lessThan
+}
+
 func ValidateRequirement(requirement NodeSelectorRequirementWithMinValues) error { //nolint:gocyclo
 	var errs error
 	if normalized, ok := NormalizedLabels[requirement.Key]; ok {
@@ -132,11 +232,22 @@ func ValidateRequirement(requirement NodeSelectorRequirementWithMinValues) error
 	if requirement.Operator == v1.NodeSelectorOpIn && len(requirement.Values) == 0 {
 		errs = multierr.Append(errs, fmt.Errorf("key %s with operator %s must have a value defined", requirement.Key, requirement.Operator))
 	}
+	if (requirement.Operator == v1.NodeSelectorOpExists || requirement.Operator == v1.NodeSelectorOpDoesNotExist) && len(requirement.Values) != 0 {
+		errs = multierr.Append(errs, fmt.Errorf("key %s with operator %s must not have values defined", requirement.Key, requirement.Operator))
+	}
 
 	if requirement.Operator == v1.NodeSelectorOpIn && requirement.MinValues != nil && len(requirement.Values) < lo.FromPtr(requirement.MinValues) {
 		errs = multierr.Append(errs, fmt.Errorf("key %s with operator %s must have at least minimum number of values defined in 'values' field", requirement.Key, requirement.Operator))
 	}
 
+	if IsCapabilityLabel(requirement.Key) {
+		if requirement.Operator == v1.NodeSelectorOpGt || requirement.Operator == v1.NodeSelectorOpLt {
+			errs = multierr.Append(errs, fmt.Errorf("key %s is a capability and doesn't support operator %s", requirement.Key, requirement.Operator))
+		}
+		if invalid := lo.Without(requirement.Values, CapabilityTrue, CapabilityFalse); len(invalid) != 0 {
+			errs = multierr.Append(errs, fmt.Errorf("key %s is a capability and must have values of %q or %q, got %v", requirement.Key, CapabilityTrue, CapabilityFalse, invalid))
+		}
+	}
 	if requirement.Operator == v1.NodeSelectorOpGt || requirement.Operator == v1.NodeSelectorOpLt {
 		if len(requirement.Values) != 1 {
 			errs = multierr.Append(errs, fmt.Errorf("key %s with operator %s must have a single positive integer value", requirement.Key, requirement.Operator))
@@ -47,6 +47,39 @@ type NodePoolSpec struct {
 	// Limits define a set of bounds for provisioning capacity.
 	// +optional
 	Limits Limits `json:"limits,omitempty"`
+	// ZoneLimits define a set of bounds for provisioning capacity in individual zones, e.g. for zones with their
+	// own quotas or a limited number of IP addresses in their subnets. They apply in addition to Limits.
+	// +listType=map
+	// +listMapKey=zone
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	ZoneLimits []ZoneLimit `json:"zoneLimits,omitempty"`
+	// ZonePreferences bias the placement of new nodes towards the zones with the highest weights when the pods that
+	// they're launched for can run in any of the NodePool's zones, e.g. to prefer a zone for its cost or latency.
+	// Zones without a preference have a weight of 0. Less preferred zones are still used when none of the instance
+	// types are available in the preferred zones, and preferences never override scheduling constraints.
+	// +listType=map
+	// +listMapKey=zone
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	ZonePreferences []ZonePreference `json:"zonePreferences,omitempty"`
+	// PriorityClassNames are the PriorityClasses of the pods that capacity is launched for by this NodePool, e.g. to
+	// only launch spot capacity for low priority pods. Pods with one of these PriorityClasses are only provisioned for
+	// by the NodePools that list their PriorityClass, while pods with other PriorityClasses can still be provisioned
+	// for by this NodePool.
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MinLength=1
+	// +optional
+	PriorityClassNames []string `json:"priorityClassNames,omitempty"`
+	// PodSelector restricts the pods that capacity is launched for by this NodePool to the pods whose labels it selects,
+	// e.g. to dedicate a NodePool to a team without tainting its nodes. The values of matchLabels and of In and NotIn
+	// expressions may contain * wildcards, e.g. team-*. Pods are only restricted from new capacity, so they can still
+	// schedule to the NodePool's existing nodes. The NodePool launches capacity for all pods if unset.
+	// +kubebuilder:validation:XValidation:message="podSelector operator must be In, NotIn, Exists or DoesNotExist",rule="!has(self.matchExpressions) || self.matchExpressions.all(x, x.operator in ['In', 'NotIn', 'Exists', 'DoesNotExist'])"
+	// +kubebuilder:validation:XValidation:message="podSelector expressions with operator 'In' or 'NotIn' must have a value defined",rule="!has(self.matchExpressions) || self.matchExpressions.all(x, (x.operator == 'In' || x.operator == 'NotIn') ? (has(x.values) && x.values.size() != 0) : true)"
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
 	// Weight is the priority given to the nodepool during scheduling. A higher
 	// numerical weight indicates that this nodepool will be ordered
 	// ahead of other nodepools with lower weights. A nodepool with no weight
@@ -55,6 +88,70 @@ type NodePoolSpec struct {
 	// +kubebuilder:validation:Maximum:=100
 	// +optional
 	Weight *int32 `json:"weight,omitempty"`
+	// FailurePolicy configures how the NodePool backs off after repeatedly failing to launch NodeClaims.
+	// If omitted, the NodePool keeps launching NodeClaims regardless of previous failures.
+	// +optional
+	FailurePolicy *FailurePolicy `json:"failurePolicy,omitempty"`
+	// Packing configures how densely pods are packed onto the new nodes that the NodePool launches, e.g. to leave
+	// headroom on nodes without inflating the resource requests of pods. Existing nodes aren't affected.
+	// +optional
+	Packing *Packing `json:"packing,omitempty"`
+	// CapacityTypeFallback is the policy of a spot-only NodePool for launching on-demand capacity when spot capacity is
+	// unavailable for every compatible instance type. NodeClaims that fall back to on-demand capacity are annotated with
+	// the policy. If omitted, the NodePool never falls back to on-demand capacity.
+	// +kubebuilder:validation:Enum:={OnDemand,OnDemandUntilSpot}
+	// +optional
+	CapacityTypeFallback *CapacityTypeFallback `json:"capacityTypeFallback,omitempty"`
+}
+
+// CapacityTypeFallback is a policy for launching on-demand capacity when spot capacity is unavailable
+type CapacityTypeFallback string
+
+const (
+	// CapacityTypeFallbackOnDemand launches on-demand capacity when spot capacity is unavailable for every compatible
+	// instance type and keeps the on-demand nodes until they are disrupted for other reasons
+	CapacityTypeFallbackOnDemand CapacityTypeFallback = "OnDemand"
+	// CapacityTypeFallbackOnDemandUntilSpot launches on-demand capacity when spot capacity is unavailable for every
+	// compatible instance type and lets consolidation replace the on-demand nodes once spot capacity is available again
+	CapacityTypeFallbackOnDemandUntilSpot CapacityTypeFallback = "OnDemandUntilSpot"
+)
+
+// Packing defines targets that the scheduler packs pods onto new nodes up to. Packing less densely trades the cost of
+// more nodes for headroom, e.g. for pods that burst above their requests.
+type Packing struct {
+	// MaxPodsPerNode is the maximum number of pods that are scheduled onto a new node, not counting DaemonSet pods.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	MaxPodsPerNode *int32 `json:"maxPodsPerNode,omitempty"`
+	// TargetUtilization is the percentage of the allocatable CPU and memory of a new node that pods, including
+	// DaemonSet pods, are scheduled up to.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	TargetUtilization *int32 `json:"targetUtilization,omitempty"`
+}
+
+// FailurePolicy defines a circuit breaker for the NodePool. Once MaxConsecutiveFailures launches fail in a row,
+// the NodePool is temporarily disabled for scheduling. The backoff starts at InitialBackoff and doubles each time
+// a launch fails after the NodePool is re-enabled, up to MaxBackoff. A successful launch resets the backoff.
+type FailurePolicy struct {
+	// MaxConsecutiveFailures is the number of launches that must fail in a row before the NodePool is disabled.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=3
+	// +optional
+	MaxConsecutiveFailures int32 `json:"maxConsecutiveFailures,omitempty"`
+	// InitialBackoff is how long the NodePool is disabled the first time that MaxConsecutiveFailures is reached.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="1m"
+	// +optional
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+	// MaxBackoff is the longest that the NodePool is disabled for.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="30m"
+	// +optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
 }
 
 type Disruption struct {
@@ -81,6 +178,38 @@ type Disruption struct {
 	// +kubebuilder:validation:MaxItems=50
 	// +optional
 	Budgets []Budget `json:"budgets,omitempty" hash:"ignore"`
+	// Rollout controls how Karpenter replaces the nodes that have drifted from the NodePool's template
+	// +optional
+	Rollout *Rollout `json:"rollout,omitempty"`
+	// ProtectLongRunningPods stops Karpenter from expiring or drifting nodes that host pods which have been running
+	// for longer than this duration. These nodes are only disrupted once they're approved with the
+	// karpenter.sh/disruption-confirmed annotation. If omitted, running pods don't protect their nodes.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	ProtectLongRunningPods *metav1.Duration `json:"protectLongRunningPods,omitempty"`
+	// CordonReasons is a list of disruption reasons for which Karpenter only cordons the nodes that it would disrupt,
+	// and labels them with the karpenter.sh/disruption-cordoned label, rather than replacing and deleting them. The
+	// cordoned nodes are left to another controller or to an operator to drain and delete, and count against the
+	// NodePool's disruption budgets until they're deleted.
+	// +kubebuilder:validation:MaxItems=3
+	// +listType=set
+	// +optional
+	CordonReasons []DisruptionReason `json:"cordonReasons,omitempty"`
+}
+
+// Rollout controls the replacement of drifted nodes. The progress of the rollout is reported in the NodePool's status.
+type Rollout struct {
+	// Paused stops Karpenter from replacing drifted nodes until the rollout is resumed. Paused rollouts don't affect
+	// any other disruption methods.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// MaxUnavailable is the maximum number of the NodePool's nodes that can be unavailable while drifted nodes are
+	// replaced, either as a number of nodes or as a percentage of the NodePool's nodes. MaxUnavailable is applied
+	// in addition to the budgets of the Drifted reason, so the most restrictive of them is used.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +optional
+	MaxUnavailable *string `json:"maxUnavailable,omitempty"`
 }
 
 // Budget defines when Karpenter will restrict the
@@ -153,6 +282,30 @@ func (l Limits) ExceededBy(resources v1.ResourceList) error {
 	return nil
 }
 
+// ZoneLimit defines the bounds for provisioning capacity in a single zone.
+type ZoneLimit struct {
+	// Zone is the value of the topology.kubernetes.io/zone label of the nodes that the limits apply to.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Zone string `json:"zone"`
+	// Limits define the bounds for provisioning capacity in the zone.
+	// +required
+	Limits Limits `json:"limits"`
+}
+
+// ZonePreference defines the weight of a zone when choosing the zone that a new node is launched into.
+type ZonePreference struct {
+	// Zone is the value of the topology.kubernetes.io/zone label of the nodes that the preference applies to.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Zone string `json:"zone"`
+	// Weight is the preference for the zone. Nodes are launched into the available zones with the highest weight.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +required
+	Weight int32 `json:"weight"`
+}
+
 type NodeClaimTemplate struct {
 	ObjectMeta `json:"metadata,omitempty"`
 	// +required
@@ -172,6 +325,13 @@ type NodeClaimTemplateSpec struct {
 	// purposes in that pods are not required to tolerate a StartupTaint in order to have nodes provisioned for them.
 	// +optional
 	StartupTaints []v1.Taint `json:"startupTaints,omitempty"`
+	// TemporaryTaints are taints that are applied to nodes when they register and removed by Karpenter once they expire,
+	// e.g. to keep regular workloads off of nodes while they warm up. Like StartupTaints, TemporaryTaints are ignored for
+	// provisioning purposes in that pods are not required to tolerate a TemporaryTaint in order to have nodes provisioned
+	// for them.
+	// +kubebuilder:validation:XValidation:message="temporaryTaints must set expireAfter or condition",rule="self.all(x, has(x.expireAfter) || has(x.condition))"
+	// +optional
+	TemporaryTaints []TemporaryTaint `json:"temporaryTaints,omitempty"`
 	// Requirements are layered with GetLabels and applied to every node.
 	// +kubebuilder:validation:XValidation:message="requirements with operator 'In' must have a value defined",rule="self.all(x, x.operator == 'In' ? x.values.size() != 0 : true)"
 	// +kubebuilder:validation:XValidation:message="requirements operator 'Gt' or 'Lt' must have a single positive integer value",rule="self.all(x, (x.operator == 'Gt' || x.operator == 'Lt') ? (x.values.size() == 1 && int(x.values[0]) >= 0) : true)"
@@ -223,6 +383,7 @@ func (in *NodeClaimTemplate) ToNodeClaim() *NodeClaim {
 		Spec: NodeClaimSpec{
 			Taints:                 in.Spec.Taints,
 			StartupTaints:          in.Spec.StartupTaints,
+			TemporaryTaints:        in.Spec.TemporaryTaints,
 			Requirements:           in.Spec.Requirements,
 			NodeClassRef:           in.Spec.NodeClassRef,
 			TerminationGracePeriod: in.Spec.TerminationGracePeriod,
@@ -290,6 +451,11 @@ type NodePoolList struct {
 	Items           []NodePool `json:"items"`
 }
 
+// CordonsFor returns true if the nodes that are disrupted for the reason should only be cordoned
+func (in *NodePool) CordonsFor(reason DisruptionReason) bool {
+	return lo.Contains(in.Spec.Disruption.CordonReasons, reason)
+}
+
 // MustGetAllowedDisruptions calls GetAllowedDisruptionsByReason if the error is not nil. This reduces the
 // amount of state that the disruption controller must reconcile, while allowing the GetAllowedDisruptionsByReason()
 // to bubble up any errors in validation.
@@ -314,6 +480,15 @@ func (in *NodePool) GetAllowedDisruptionsByReason(c clock.Clock, numNodes int, r
 			allowedNodes = lo.Min([]int{allowedNodes, val})
 		}
 	}
+	if rollout := in.Spec.Disruption.Rollout; reason == DisruptionReasonDrifted && rollout != nil && rollout.MaxUnavailable != nil {
+		val, err := intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(GetIntStrFromValue(*rollout.MaxUnavailable)), numNodes, true)
+		if err != nil {
+			// If the value is incorrectly formatted, fail closed, since we don't know what they want here.
+			multiErr = multierr.Append(multiErr, err)
+			val = 0
+		}
+		allowedNodes = lo.Min([]int{allowedNodes, val})
+	}
 	return allowedNodes, multiErr
 }
 
@@ -173,7 +173,23 @@ var _ = Describe("Budgets", func() {
 			Expect(err).To(BeNil())
 			Expect(underutilizedAllowedDisruption).To(Equal(10))
 		})
+		It("should apply the rollout's maxUnavailable to drift", func() {
+			nodePool.Spec.Disruption.Rollout = &Rollout{MaxUnavailable: lo.ToPtr("2%")}
 
+			driftedAllowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonDrifted)
+			Expect(err).To(BeNil())
+			Expect(driftedAllowedDisruption).To(Equal(2))
+			emptyAllowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonEmpty)
+			Expect(err).To(BeNil())
+			Expect(emptyAllowedDisruption).To(Equal(10))
+		})
+		It("should use the drift budgets when they're more restrictive than the rollout's maxUnavailable", func() {
+			nodePool.Spec.Disruption.Rollout = &Rollout{MaxUnavailable: lo.ToPtr("50")}
+
+			driftedAllowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonDrifted)
+			Expect(err).To(BeNil())
+			Expect(driftedAllowedDisruption).To(Equal(5))
+		})
 	})
 
 	Context("AllowedDisruptions", func() {
@@ -19,6 +19,7 @@ package v1
 import (
 	"github.com/awslabs/operatorpkg/status"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -27,6 +28,23 @@ const (
 	ConditionTypeValidationSucceeded = "ValidationSucceeded"
 	// ConditionTypeNodeClassReady = "NodeClassReady" condition indicates that underlying nodeClass was resolved and is reporting as Ready
 	ConditionTypeNodeClassReady = "NodeClassReady"
+	// ConditionTypeLaunchesHealthy = "LaunchesHealthy" condition indicates that the NodePool hasn't been disabled by
+	// its FailurePolicy after repeatedly failing to launch NodeClaims
+	ConditionTypeLaunchesHealthy = "LaunchesHealthy"
+	// ConditionTypeRequirementsValid = "RequirementsValid" condition summarizes the validation of the NodePool's
+	// requirements, including combinations of requirements that no node could satisfy
+	ConditionTypeRequirementsValid = "RequirementsValid"
+	// ConditionTypeDegraded = "Degraded" condition indicates that the NodePool can't reliably launch NodeClaims, so the
+	// scheduler prefers healthy NodePools over it regardless of their weight. It's set by the cloud provider or by
+	// Karpenter after recent launch failures.
+	ConditionTypeDegraded = "Degraded"
+	// ConditionTypeZonallySkewed = "ZonallySkewed" condition indicates that the difference between the number of the
+	// NodePool's nodes in its most and least populated zones exceeds the configured threshold
+	ConditionTypeZonallySkewed = "ZonallySkewed"
+	// ConditionTypeImpactAccepted = "ImpactAccepted" condition indicates that the NodePool's spec doesn't put its
+	// current usage above its limits or drift all of its NodeClaims, or that the impact was accepted with the
+	// karpenter.sh/force annotation. Drifted NodeClaims aren't replaced while the condition is false.
+	ConditionTypeImpactAccepted = "ImpactAccepted"
 )
 
 // NodePoolStatus defines the observed state of NodePool
@@ -34,15 +52,81 @@ type NodePoolStatus struct {
 	// Resources is the list of resources that have been provisioned.
 	// +optional
 	Resources v1.ResourceList `json:"resources,omitempty"`
+	// Remaining is the list of resources that can still be provisioned before reaching the NodePool's limits.
+	// +optional
+	Remaining v1.ResourceList `json:"remaining,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
+	// ConsecutiveLaunchFailures is the number of NodeClaim launches for this NodePool that have failed since the
+	// last successful launch
+	// +optional
+	ConsecutiveLaunchFailures int32 `json:"consecutiveLaunchFailures,omitempty"`
+	// LastLaunchFailureTime is the time that a NodeClaim launch for this NodePool last failed
+	// +optional
+	LastLaunchFailureTime *metav1.Time `json:"lastLaunchFailureTime,omitempty"`
+	// Zones is the resource usage and remaining capacity of each zone that has ZoneLimits.
+	// +listType=map
+	// +listMapKey=zone
+	// +optional
+	Zones []ZoneStatus `json:"zones,omitempty"`
+	// Rollout is the progress of replacing the NodePool's drifted nodes
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+}
+
+// RolloutStatus defines the progress of replacing the nodes of a NodePool that have drifted from its template
+type RolloutStatus struct {
+	// TemplateVersion is the hash of the NodePool's template that nodes are being rolled out to
+	// +optional
+	TemplateVersion string `json:"templateVersion,omitempty"`
+	// Nodes is the number of the NodePool's nodes that aren't being deleted
+	// +optional
+	Nodes int32 `json:"nodes"`
+	// UpdatedNodes is the number of the NodePool's nodes that are launched from the TemplateVersion and haven't
+	// drifted from their NodeClass
+	// +optional
+	UpdatedNodes int32 `json:"updatedNodes"`
+	// DriftedNodes is the number of the NodePool's nodes that have drifted and are waiting to be replaced
+	// +optional
+	DriftedNodes int32 `json:"driftedNodes"`
+	// DisruptingNodes is the number of the NodePool's nodes that are being disrupted or deleted
+	// +optional
+	DisruptingNodes int32 `json:"disruptingNodes"`
+	// ReplacedNodes is the number of the NodePool's updated nodes that have been launched since the rollout started
+	// +optional
+	ReplacedNodes int32 `json:"replacedNodes"`
+	// StartTime is when the NodePool last started to have nodes that aren't updated. It's unset once all nodes are updated.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// EstimatedCompletionTime is when the rollout is expected to complete, extrapolated from how long it took to replace
+	// the nodes that have been replaced so far in waves of the size that the NodePool's disruption budgets currently
+	// allow. It's unset if no nodes have been replaced yet, or if the rollout is paused or blocked by the budgets.
+	// +optional
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+	// Paused is whether the rollout is paused
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ZoneStatus defines the observed resource usage of a NodePool in a zone with ZoneLimits
+type ZoneStatus struct {
+	// Zone is the zone that the resources are provisioned in.
+	// +required
+	Zone string `json:"zone"`
+	// Resources is the list of resources that have been provisioned in the zone.
+	// +optional
+	Resources v1.ResourceList `json:"resources,omitempty"`
+	// Remaining is the list of resources that can still be provisioned in the zone before reaching its limits.
+	// +optional
+	Remaining v1.ResourceList `json:"remaining,omitempty"`
 }
 
 func (in *NodePool) StatusConditions() status.ConditionSet {
 	return status.NewReadyConditions(
 		ConditionTypeValidationSucceeded,
 		ConditionTypeNodeClassReady,
+		ConditionTypeLaunchesHealthy,
 	).For(in)
 }
 
@@ -25,7 +25,14 @@ import (
 
 // RuntimeValidate will be used to validate any part of the CRD that can not be validated at CRD creation
 func (in *NodePool) RuntimeValidate() (errs error) {
-	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist())
+	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.RequirementsValidate())
+	return errs
+}
+
+// RequirementsValidate validates the requirements of the NodePool, including combinations of requirements that can't
+// be satisfied by any node
+func (in *NodePool) RequirementsValidate() (errs error) {
+	errs = multierr.Combine(in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.Spec.validateRequirementsConflicts(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist())
 	return errs
 }
 
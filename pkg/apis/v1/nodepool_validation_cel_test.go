@@ -386,10 +386,11 @@ var _ = Describe("CEL/Validation", func() {
 		It("should allow supported ops", func() {
 			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}}},
-				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpGt, Values: []string{"1"}}},
-				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpLt, Values: []string{"1"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/gt", Operator: v1.NodeSelectorOpGt, Values: []string{"1"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/lt", Operator: v1.NodeSelectorOpLt, Values: []string{"1"}}},
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn}},
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpExists}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/does-not-exist", Operator: v1.NodeSelectorOpDoesNotExist}},
 			}
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 			Expect(nodePool.RuntimeValidate()).To(Succeed())
@@ -448,6 +449,26 @@ var _ = Describe("CEL/Validation", func() {
 				nodePool = oldNodePool.DeepCopy()
 			}
 		})
+		It("should allow capability labels", func() {
+			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapabilityLabelKey("nested-virtualization"), Operator: v1.NodeSelectorOpIn, Values: []string{CapabilityTrue}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapabilityLabelKey("sgx"), Operator: v1.NodeSelectorOpNotIn, Values: []string{CapabilityTrue}}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail for capability labels with values other than true or false", func() {
+			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapabilityLabelKey("nested-virtualization"), Operator: v1.NodeSelectorOpIn, Values: []string{"yes"}}},
+			}
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail for capability labels with the Gt or Lt operators", func() {
+			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapabilityLabelKey("nested-virtualization"), Operator: v1.NodeSelectorOpGt, Values: []string{"1"}}},
+			}
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
 		It("should allow non-empty set after removing overlapped value", func() {
 			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test", "foo"}}},
@@ -477,6 +498,53 @@ var _ = Describe("CEL/Validation", func() {
 				Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
 			}
 		})
+		It("should fail for Exists or DoesNotExist with values", func() {
+			for _, op := range []v1.NodeSelectorOperator{v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist} {
+				nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: op, Values: []string{"test"}}},
+				}
+				Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+				Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+				Expect(nodePool.RequirementsValidate()).ToNot(Succeed())
+			}
+		})
+		DescribeTable("should fail at runtime for requirements on the same key that conflict", func(requirements ...v1.NodeSelectorRequirement) {
+			nodePool.Spec.Template.Spec.Requirements = lo.Map(requirements, func(r v1.NodeSelectorRequirement, _ int) NodeSelectorRequirementWithMinValues {
+				return NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: r}
+			})
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+			Expect(nodePool.RequirementsValidate()).ToNot(Succeed())
+		},
+			Entry("In and NotIn the same values",
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpNotIn, Values: []string{"test"}}),
+			Entry("In disjoint values",
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"foo"}}),
+			Entry("Exists and DoesNotExist",
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpExists},
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpDoesNotExist}),
+			Entry("In and DoesNotExist",
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+				v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpDoesNotExist}),
+			Entry("Gt and Lt without an integer between them",
+				v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpGt, Values: []string{"5"}},
+				v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpLt, Values: []string{"6"}}),
+			Entry("In values outside of Gt",
+				v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpIn, Values: []string{"1", "2"}},
+				v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpGt, Values: []string{"2"}}),
+		)
+		It("should allow requirements on the same key that can be satisfied together", func() {
+			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpIn, Values: []string{"1", "5"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpGt, Values: []string{"2"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpLt, Values: []string{"10"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "test.com/test", Operator: v1.NodeSelectorOpExists}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+			Expect(nodePool.RequirementsValidate()).To(Succeed())
+		})
 		It("should error when minValues is negative", func() {
 			nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"insance-type-1"}}, MinValues: lo.ToPtr(-1)},
@@ -620,6 +688,54 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
 		})
 	})
+	Context("Packing", func() {
+		It("should succeed with packing targets", func() {
+			nodePool.Spec.Packing = &Packing{MaxPodsPerNode: lo.ToPtr(int32(20)), TargetUtilization: lo.ToPtr(int32(85))}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail with a max pods per node that isn't positive", func() {
+			nodePool.Spec.Packing = &Packing{MaxPodsPerNode: lo.ToPtr(int32(0))}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail with a target utilization that isn't a percentage", func() {
+			nodePool.Spec.Packing = &Packing{TargetUtilization: lo.ToPtr(int32(101))}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
+	Context("CapacityTypeFallback", func() {
+		It("should succeed with a known fallback policy", func() {
+			nodePool.Spec.CapacityTypeFallback = lo.ToPtr(CapacityTypeFallbackOnDemandUntilSpot)
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail with an unknown fallback policy", func() {
+			nodePool.Spec.CapacityTypeFallback = lo.ToPtr(CapacityTypeFallback("Reserved"))
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
+	Context("PodSelector", func() {
+		It("should succeed with a pod selector", func() {
+			nodePool.Spec.PodSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "data-*"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev"}},
+					{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail with an unknown operator", func() {
+			nodePool.Spec.PodSelector = &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Gt", Values: []string{"1"}}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail with an In expression without values", func() {
+			nodePool.Spec.PodSelector = &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpIn}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
 	Context("NodeClassRef", func() {
 		It("should fail to mutate group", func() {
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
@@ -0,0 +1,125 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DisruptionPlanSpec selects the nodes that a plan is computed for
+type DisruptionPlanSpec struct {
+	// NodePools limits the plan to the nodes of the given NodePools. The plan covers the nodes of all NodePools if unset.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	NodePools []string `json:"nodePools,omitempty"`
+}
+
+// DisruptionPlanStatus is what Karpenter would disrupt at the time that the plan was computed
+type DisruptionPlanStatus struct {
+	// PlannedTime is when the plan was computed. Plans are computed once, so a new DisruptionPlan has to be created
+	// to preview the disruption of the cluster at a later time.
+	// +optional
+	PlannedTime *metav1.Time `json:"plannedTime,omitempty"`
+	// Commands are the commands that each disruption method would execute, in the order that the methods are attempted.
+	// Karpenter only executes the command of the first method that computes one in each disruption loop, while expired
+	// NodeClaims are deleted independently of the disruption loop.
+	// +optional
+	Commands []DisruptionPlanCommand `json:"commands,omitempty"`
+}
+
+// DisruptionPlanCommand is a command that a disruption method would execute
+type DisruptionPlanCommand struct {
+	// Reason is why the candidates would be disrupted, e.g. Drifted, Empty, Underutilized or Expired
+	// +required
+	Reason string `json:"reason"`
+	// ConsolidationType is the type of consolidation that computed the command, if any, e.g. single or multi
+	// +optional
+	ConsolidationType string `json:"consolidationType,omitempty"`
+	// Decision is whether the candidates would be deleted or replaced
+	// +required
+	Decision string `json:"decision"`
+	// Candidates are the NodeClaims that would be disrupted
+	// +required
+	Candidates []DisruptionPlanCandidate `json:"candidates"`
+	// Replacements are the NodeClaims that would be launched to replace the candidates
+	// +optional
+	Replacements []DisruptionPlanReplacement `json:"replacements,omitempty"`
+	// Savings is the estimated hourly cost that the command would save, as reported by the cloud provider
+	// +optional
+	Savings string `json:"savings,omitempty"`
+}
+
+// DisruptionPlanCandidate is a NodeClaim that would be disrupted
+type DisruptionPlanCandidate struct {
+	// NodeClaim is the name of the NodeClaim
+	// +required
+	NodeClaim string `json:"nodeClaim"`
+	// Node is the name of the node that registered for the NodeClaim
+	// +optional
+	Node string `json:"node,omitempty"`
+	// NodePool is the name of the NodePool that launched the NodeClaim
+	// +optional
+	NodePool string `json:"nodePool,omitempty"`
+	// InstanceType is the instance type of the NodeClaim
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// CapacityType is the capacity type of the NodeClaim
+	// +optional
+	CapacityType string `json:"capacityType,omitempty"`
+	// Zone is the zone of the NodeClaim
+	// +optional
+	Zone string `json:"zone,omitempty"`
+}
+
+// DisruptionPlanReplacement is a NodeClaim that would be launched to replace the candidates
+type DisruptionPlanReplacement struct {
+	// NodePool is the name of the NodePool that the replacement would be launched for
+	// +required
+	NodePool string `json:"nodePool"`
+	// InstanceTypes are the cheapest instance types that the replacement could be launched as
+	// +optional
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// Price is the estimated hourly cost of the cheapest instance type that the replacement could be launched as
+	// +optional
+	Price string `json:"price,omitempty"`
+}
+
+// DisruptionPlan asks Karpenter to compute what it would disrupt right now without disrupting anything. Karpenter
+// publishes the candidates, replacements and estimated savings of each disruption method in the plan's status.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=disruptionplans,scope=Cluster,categories=karpenter
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="NodePools",type="string",JSONPath=".spec.nodePools",description=""
+// +kubebuilder:printcolumn:name="Planned",type="date",JSONPath=".status.plannedTime",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type DisruptionPlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	// +optional
+	Spec   DisruptionPlanSpec   `json:"spec,omitempty"`
+	Status DisruptionPlanStatus `json:"status,omitempty"`
+}
+
+// DisruptionPlanList contains a list of DisruptionPlans
+// +kubebuilder:object:root=true
+type DisruptionPlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DisruptionPlan `json:"items"`
+}
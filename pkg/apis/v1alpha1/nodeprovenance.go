@@ -0,0 +1,103 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeProvenanceSpec records how a NodeClaim was launched
+type NodeProvenanceSpec struct {
+	// NodeClaim is the name of the NodeClaim that the record was created for
+	// +required
+	NodeClaim string `json:"nodeClaim"`
+	// NodePool is the name of the NodePool that launched the NodeClaim
+	// +optional
+	NodePool string `json:"nodePool,omitempty"`
+	// NodePoolHash is the hash of the NodePool's template that the NodeClaim was launched from
+	// +optional
+	NodePoolHash string `json:"nodePoolHash,omitempty"`
+	// ProviderID is the ID of the instance that was launched
+	// +optional
+	ProviderID string `json:"providerID,omitempty"`
+	// InstanceType is the instance type that was launched
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// Zone is the zone that the instance was launched in
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// CapacityType is the capacity type that the instance was launched with
+	// +optional
+	CapacityType string `json:"capacityType,omitempty"`
+	// Price is the price of the instance's offering at launch, as reported by the cloud provider
+	// +optional
+	Price string `json:"price,omitempty"`
+	// ImageID is an identifier for the image that the instance was launched with
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+	// LaunchTime is when the instance was launched
+	// +required
+	LaunchTime metav1.Time `json:"launchTime"`
+}
+
+// NodeProvenanceStatus records what happened to the NodeClaim after it was launched
+type NodeProvenanceStatus struct {
+	// NodeName is the name of the node that registered for the NodeClaim
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// TerminationReason is why the NodeClaim was terminated
+	// +optional
+	TerminationReason string `json:"terminationReason,omitempty"`
+	// TerminationTime is when the NodeClaim was terminated. The record is deleted once the retention period has
+	// passed since the termination time.
+	// +optional
+	TerminationTime *metav1.Time `json:"terminationTime,omitempty"`
+}
+
+// NodeProvenance is a record of a NodeClaim that was launched by Karpenter. It outlives the NodeClaim so that the
+// capacity that was launched can be audited after it is terminated.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=nodeprovenances,scope=Cluster,categories=karpenter
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="NodeClaim",type="string",JSONPath=".spec.nodeClaim",description=""
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.instanceType",description=""
+// +kubebuilder:printcolumn:name="Capacity",type="string",JSONPath=".spec.capacityType",description=""
+// +kubebuilder:printcolumn:name="Zone",type="string",JSONPath=".spec.zone",description=""
+// +kubebuilder:printcolumn:name="Launched",type="date",JSONPath=".spec.launchTime",description=""
+// +kubebuilder:printcolumn:name="Terminated",type="date",JSONPath=".status.terminationTime",description=""
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.terminationReason",description=""
+// +kubebuilder:printcolumn:name="NodePool",type="string",JSONPath=".spec.nodePool",priority=1,description=""
+// +kubebuilder:printcolumn:name="Price",type="string",JSONPath=".spec.price",priority=1,description=""
+// +kubebuilder:printcolumn:name="ImageID",type="string",JSONPath=".spec.imageID",priority=1,description=""
+// +kubebuilder:printcolumn:name="ID",type="string",JSONPath=".spec.providerID",priority=1,description=""
+type NodeProvenance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	// +required
+	Spec   NodeProvenanceSpec   `json:"spec"`
+	Status NodeProvenanceStatus `json:"status,omitempty"`
+}
+
+// NodeProvenanceList contains a list of NodeProvenances
+// +kubebuilder:object:root=true
+type NodeProvenanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeProvenance `json:"items"`
+}
@@ -0,0 +1,58 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReadinessGateSpec selects the nodes that are gated and the node condition that declares them ready
+type ReadinessGateSpec struct {
+	// NodeSelector selects the nodes that the gate applies to by their labels. The gate applies to all of the nodes that
+	// Karpenter launches if unset.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// ConditionType is the type of the node condition that the external controller sets to True once the node is ready,
+	// e.g. NetworkReady for a CNI or StorageReady for a CSI driver.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=316
+	// +required
+	ConditionType string `json:"conditionType"`
+}
+
+// ReadinessGate allows an external controller, such as a CNI, storage or security agent, to declare when the nodes
+// that Karpenter launches are ready. Karpenter doesn't initialize a NodeClaim until the node condition of every gate that
+// applies to its node is True, so the node isn't considered for scheduling simulations and consolidation until then.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=readinessgates,scope=Cluster,categories=karpenter
+// +kubebuilder:printcolumn:name="Condition",type="string",JSONPath=".spec.conditionType",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type ReadinessGate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec ReadinessGateSpec `json:"spec"`
+}
+
+// ReadinessGateList contains a list of ReadinessGates
+// +kubebuilder:object:root=true
+type ReadinessGateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReadinessGate `json:"items"`
+}
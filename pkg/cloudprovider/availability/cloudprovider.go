@@ -0,0 +1,226 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package availability
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+const (
+	// UnavailableOfferingTTL is how long an offering is considered unavailable after the first launch that failed
+	// with insufficient capacity
+	UnavailableOfferingTTL = 3 * time.Minute
+	// MaxUnavailableOfferingTTL bounds how long an offering is considered unavailable after repeated launch failures
+	MaxUnavailableOfferingTTL = 30 * time.Minute
+
+	zoneLabel = "zone"
+)
+
+var UnavailableOfferings = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "unavailable_offerings",
+		Help:      "The number of offerings that are considered unavailable since a launch failed due to insufficient capacity. Labeled by zone and capacity type.",
+	},
+	[]string{zoneLabel, metrics.CapacityTypeLabel},
+)
+
+type unavailableOffering struct {
+	// failures is the number of consecutive launch failures for the offering, used to back off the offering for longer
+	// when it keeps failing
+	failures  int
+	expiresAt time.Time
+}
+
+// CloudProvider implements cloudprovider.CloudProvider
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+
+// CloudProvider records the offerings that failed to launch with insufficient capacity and marks them as unavailable
+// in the instance types that it returns, so that subsequent scheduling rounds don't immediately retry the same
+// offerings. An offering is considered unavailable for UnavailableOfferingTTL, which doubles for each consecutive
+// failure of the offering up to MaxUnavailableOfferingTTL. An offering's failures decay once it has been available
+// again for MaxUnavailableOfferingTTL.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+
+	clock clock.Clock
+
+	mu        sync.RWMutex
+	offerings map[cloudprovider.OfferingKey]*unavailableOffering
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all method calls to the argument,
+// `cloudProvider`, and mark offerings that fail to launch with insufficient capacity as unavailable.
+func Decorate(cloudProvider cloudprovider.CloudProvider, clk clock.Clock) *CloudProvider {
+	return &CloudProvider{
+		CloudProvider: cloudProvider,
+		clock:         clk,
+		offerings:     map[cloudprovider.OfferingKey]*unavailableOffering{},
+	}
+}
+
+// Unwrap returns the decorated CloudProvider
+func (c *CloudProvider) Unwrap() cloudprovider.CloudProvider {
+	return c.CloudProvider
+}
+
+func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
+	created, err := c.CloudProvider.Create(ctx, nodeClaim)
+	if cloudprovider.IsInsufficientCapacityError(err) {
+		offerings := cloudprovider.InsufficientCapacityOfferings(err)
+		if len(offerings) == 0 {
+			offerings = offeringsForNodeClaim(nodeClaim)
+		}
+		c.MarkUnavailable(offerings...)
+		log.FromContext(ctx).V(1).WithValues("offerings", len(offerings)).Info("marked offerings as unavailable due to insufficient capacity")
+	}
+	return created, err
+}
+
+func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
+	instanceTypes, err := c.CloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prune(c.clock.Now())
+	if len(c.offerings) == 0 {
+		return instanceTypes, nil
+	}
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		if !lo.ContainsBy(it.Offerings, func(o cloudprovider.Offering) bool { return o.Available && c.isUnavailable(offeringKey(it, o)) }) {
+			return it
+		}
+		// The instance types may be shared with other callers (e.g. when cached), so we copy rather than mutate them
+		copied := it.Copy()
+		copied.Offerings = lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) cloudprovider.Offering {
+			o.Available = o.Available && !c.isUnavailable(offeringKey(it, o))
+			return o
+		})
+		return copied
+	}), nil
+}
+
+// MarkUnavailable marks the given offerings as unavailable
+func (c *CloudProvider) MarkUnavailable(offerings ...cloudprovider.OfferingKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	for _, key := range offerings {
+		o, ok := c.offerings[key]
+		// Offerings that have been available again for long enough start backing off from the initial TTL
+		if !ok || now.Sub(o.expiresAt) > MaxUnavailableOfferingTTL {
+			o = &unavailableOffering{}
+			c.offerings[key] = o
+		}
+		o.failures++
+		o.expiresAt = now.Add(backoff(o.failures))
+	}
+	c.prune(now)
+}
+
+// IsUnavailable returns true if the offering is currently considered unavailable
+func (c *CloudProvider) IsUnavailable(key cloudprovider.OfferingKey) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isUnavailable(key)
+}
+
+func (c *CloudProvider) isUnavailable(key cloudprovider.OfferingKey) bool {
+	o, ok := c.offerings[key]
+	return ok && c.clock.Now().Before(o.expiresAt)
+}
+
+// prune removes offerings whose failures have decayed and updates the metrics for the unavailable offerings
+func (c *CloudProvider) prune(now time.Time) {
+	counts := map[[2]string]int{}
+	for key, o := range c.offerings {
+		if now.Sub(o.expiresAt) > MaxUnavailableOfferingTTL {
+			delete(c.offerings, key)
+			continue
+		}
+		if now.Before(o.expiresAt) {
+			counts[[2]string{key.Zone, key.CapacityType}]++
+		}
+	}
+	UnavailableOfferings.Reset()
+	for key, count := range counts {
+		UnavailableOfferings.Set(float64(count), map[string]string{
+			zoneLabel:                 key[0],
+			metrics.CapacityTypeLabel: key[1],
+		})
+	}
+}
+
+// backoff returns how long an offering is unavailable for after the given number of consecutive failures
+func backoff(failures int) time.Duration {
+	ttl := UnavailableOfferingTTL
+	for i := 1; i < failures && ttl < MaxUnavailableOfferingTTL; i++ {
+		ttl *= 2
+	}
+	return lo.Min([]time.Duration{ttl, MaxUnavailableOfferingTTL})
+}
+
+func offeringKey(it *cloudprovider.InstanceType, o cloudprovider.Offering) cloudprovider.OfferingKey {
+	return cloudprovider.OfferingKey{
+		InstanceType: it.Name,
+		Zone:         o.Requirements.Get(corev1.LabelTopologyZone).Any(),
+		CapacityType: o.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+	}
+}
+
+// offeringsForNodeClaim returns every offering that the NodeClaim could have launched with. If the NodeClaim's
+// requirements don't constrain the instance type, zone and capacity type to a finite set of values, no offerings are
+// returned since we can't tell which offerings had insufficient capacity.
+func offeringsForNodeClaim(nodeClaim *v1.NodeClaim) []cloudprovider.OfferingKey {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	var values [3][]string
+	for i, key := range []string{corev1.LabelInstanceTypeStable, corev1.LabelTopologyZone, v1.CapacityTypeLabelKey} {
+		if requirements.Get(key).Operator() != corev1.NodeSelectorOpIn {
+			return nil
+		}
+		values[i] = requirements.Get(key).Values()
+	}
+	var offerings []cloudprovider.OfferingKey
+	for _, instanceType := range values[0] {
+		for _, zone := range values[1] {
+			for _, capacityType := range values[2] {
+				offerings = append(offerings, cloudprovider.OfferingKey{InstanceType: instanceType, Zone: zone, CapacityType: capacityType})
+			}
+		}
+	}
+	return offerings
+}
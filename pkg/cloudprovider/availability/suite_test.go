@@ -0,0 +1,161 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package availability_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clock "k8s.io/utils/clock/testing"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/availability"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/overhead"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/storage"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestAvailability(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Availability")
+}
+
+var _ = Describe("Availability", func() {
+	var fakeClock *clock.FakeClock
+	var fakeCloudProvider *fake.CloudProvider
+	var cloudProvider *availability.CloudProvider
+	var offering cloudprovider.OfferingKey
+
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		fakeCloudProvider = fake.NewCloudProvider()
+		fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "test-instance-type"})}
+		cloudProvider = availability.Decorate(fakeCloudProvider, fakeClock)
+		offering = cloudprovider.OfferingKey{InstanceType: "test-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeSpot}
+	})
+
+	// availableOfferings returns the keys of the available offerings of the instance types returned by the cloudprovider
+	availableOfferings := func() []cloudprovider.OfferingKey {
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		return lo.FlatMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) []cloudprovider.OfferingKey {
+			return lo.Map(it.Offerings.Available(), func(o cloudprovider.Offering, _ int) cloudprovider.OfferingKey {
+				return cloudprovider.OfferingKey{
+					InstanceType: it.Name,
+					Zone:         o.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					CapacityType: o.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+				}
+			})
+		})
+	}
+
+	It("should mark the offerings reported by an insufficient capacity error as unavailable", func() {
+		Expect(availableOfferings()).To(ContainElement(offering))
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("test error"), offering)
+		_, err := cloudProvider.Create(ctx, test.NodeClaim())
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+
+		offerings := availableOfferings()
+		Expect(offerings).ToNot(ContainElement(offering))
+		Expect(offerings).ToNot(BeEmpty())
+	})
+	It("should derive the unavailable offerings from the NodeClaim's requirements", func() {
+		nodeClaim := test.NodeClaim(v1.NodeClaim{Spec: v1.NodeClaimSpec{Requirements: []v1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-instance-type"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}}},
+		}}})
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("test error"))
+		_, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(HaveOccurred())
+
+		offerings := availableOfferings()
+		Expect(offerings).ToNot(ContainElement(offering))
+		Expect(offerings).ToNot(ContainElement(cloudprovider.OfferingKey{InstanceType: "test-instance-type", Zone: "test-zone-2", CapacityType: v1.CapacityTypeSpot}))
+		Expect(offerings).To(ContainElement(cloudprovider.OfferingKey{InstanceType: "test-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand}))
+	})
+	It("should not mark offerings as unavailable when the NodeClaim's requirements aren't finite", func() {
+		before := availableOfferings()
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("test error"))
+		_, err := cloudProvider.Create(ctx, test.NodeClaim())
+		Expect(err).To(HaveOccurred())
+		Expect(availableOfferings()).To(ConsistOf(before))
+	})
+	It("should not mark offerings as unavailable for other launch errors", func() {
+		fakeCloudProvider.NextCreateErr = cloudprovider.NewNodeClassNotReadyError(fmt.Errorf("test error"))
+		_, err := cloudProvider.Create(ctx, test.NodeClaim())
+		Expect(err).To(HaveOccurred())
+		Expect(cloudProvider.IsUnavailable(offering)).To(BeFalse())
+	})
+	It("should make offerings available again once they expire", func() {
+		cloudProvider.MarkUnavailable(offering)
+		Expect(availableOfferings()).ToNot(ContainElement(offering))
+		fakeClock.Step(availability.UnavailableOfferingTTL + time.Second)
+		Expect(availableOfferings()).To(ContainElement(offering))
+	})
+	It("should keep offerings that fail repeatedly unavailable for longer", func() {
+		cloudProvider.MarkUnavailable(offering)
+		fakeClock.Step(availability.UnavailableOfferingTTL + time.Second)
+		cloudProvider.MarkUnavailable(offering)
+		fakeClock.Step(availability.UnavailableOfferingTTL + time.Second)
+		Expect(cloudProvider.IsUnavailable(offering)).To(BeTrue())
+		fakeClock.Step(availability.UnavailableOfferingTTL)
+		Expect(cloudProvider.IsUnavailable(offering)).To(BeFalse())
+	})
+	It("should not modify the instance types returned by the underlying cloudprovider", func() {
+		cloudProvider.MarkUnavailable(offering)
+		Expect(availableOfferings()).ToNot(ContainElement(offering))
+		Expect(fakeCloudProvider.InstanceTypes[0].Offerings.Available()).To(HaveLen(len(fakeCloudProvider.InstanceTypes[0].Offerings)))
+	})
+	It("should expose the cost model of the underlying cloudprovider", func() {
+		_, ok := cloudprovider.GetCostModel(cloudProvider)
+		Expect(ok).To(BeFalse())
+
+		costModelCloudProvider := fake.NewCostModelCloudProvider(fakeCloudProvider)
+		costModel, ok := cloudprovider.GetCostModel(availability.Decorate(costModelCloudProvider, fakeClock))
+		Expect(ok).To(BeTrue())
+		Expect(costModel).To(BeIdenticalTo(costModelCloudProvider))
+	})
+	It("should preserve the capabilities of instance types through the decorator chain", func() {
+		fakeCloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name:         "test-instance-type",
+			Capabilities: sets.New("nested-virtualization"),
+		})}
+		model, err := overhead.Parse("memory=1Gi")
+		Expect(err).ToNot(HaveOccurred())
+		decorated := availability.Decorate(storage.Decorate(overhead.Decorate(fakeCloudProvider, model)), fakeClock)
+		instanceTypes, err := decorated.GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Capabilities.UnsortedList()).To(ConsistOf("nested-virtualization"))
+		Expect(instanceTypes[0].Requirements.Has(v1.EphemeralStorageLabelKey)).To(BeTrue())
+		Expect(instanceTypes[0].Overhead.KubeReserved.Memory().String()).To(Equal("1Gi"))
+	})
+})
@@ -0,0 +1,171 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	resultLabel = "result"
+	resultHit   = "hit"
+	resultMiss  = "miss"
+)
+
+var InstanceTypeCacheRequestsTotal = opmetrics.NewPrometheusCounter(
+	crmetrics.Registry,
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "instance_type_cache_requests_total",
+		Help:      "The number of instance type requests that were served by the instance type cache. Labeled by whether the request was a cache hit or miss.",
+	},
+	[]string{resultLabel},
+)
+
+// ChangeTracker can optionally be implemented by a CloudProvider to push invalidations of the cached instance types,
+// e.g. when the provider refreshes the pricing or availability of its offerings. Instance types that were cached
+// before the returned time are considered stale.
+type ChangeTracker interface {
+	InstanceTypesChangedAt() time.Time
+}
+
+// GetChangeTracker returns the ChangeTracker of the CloudProvider, or of any CloudProvider that it decorates
+func GetChangeTracker(cloudProvider cloudprovider.CloudProvider) (ChangeTracker, bool) {
+	for cloudProvider != nil {
+		if tracker, ok := cloudProvider.(ChangeTracker); ok {
+			return tracker, true
+		}
+		decorator, ok := cloudProvider.(interface {
+			Unwrap() cloudprovider.CloudProvider
+		})
+		if !ok {
+			break
+		}
+		cloudProvider = decorator.Unwrap()
+	}
+	return nil, false
+}
+
+// CloudProvider implements cloudprovider.CloudProvider
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+
+type entry struct {
+	uid           types.UID
+	generation    int64
+	instanceTypes []*cloudprovider.InstanceType
+	cachedAt      time.Time
+}
+
+// CloudProvider memoizes the instance types returned by GetInstanceTypes for each NodePool. Cached instance types are
+// served until the TTL expires, the NodePool's generation changes, the cache is explicitly invalidated, or the wrapped
+// CloudProvider reports a change through the ChangeTracker interface. The returned instance types are shared between
+// callers and must be treated as read-only.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+
+	clock clock.Clock
+	ttl   time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]entry // NodePool name -> cached instance types
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all method calls to the argument,
+// `cloudProvider`, and cache the results of GetInstanceTypes for the given TTL.
+func Decorate(cloudProvider cloudprovider.CloudProvider, clk clock.Clock, ttl time.Duration) *CloudProvider {
+	return &CloudProvider{
+		CloudProvider: cloudProvider,
+		clock:         clk,
+		ttl:           ttl,
+		entries:       map[string]entry{},
+	}
+}
+
+// Unwrap returns the decorated CloudProvider
+func (c *CloudProvider) Unwrap() cloudprovider.CloudProvider {
+	return c.CloudProvider
+}
+
+func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
+	if instanceTypes, ok := c.get(nodePool); ok {
+		InstanceTypeCacheRequestsTotal.Inc(map[string]string{resultLabel: resultHit})
+		return instanceTypes, nil
+	}
+	InstanceTypeCacheRequestsTotal.Inc(map[string]string{resultLabel: resultMiss})
+	cachedAt := c.clock.Now()
+	instanceTypes, err := c.CloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Prune expired entries so that the instance types of deleted NodePools aren't held onto indefinitely
+	for name, e := range c.entries {
+		if c.clock.Since(e.cachedAt) > c.ttl {
+			delete(c.entries, name)
+		}
+	}
+	c.entries[nodePool.Name] = entry{
+		uid:           nodePool.UID,
+		generation:    nodePool.Generation,
+		instanceTypes: instanceTypes,
+		cachedAt:      cachedAt,
+	}
+	return instanceTypes, nil
+}
+
+func (c *CloudProvider) get(nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[nodePool.Name]
+	if !ok || e.uid != nodePool.UID || e.generation != nodePool.Generation || c.clock.Since(e.cachedAt) > c.ttl {
+		return nil, false
+	}
+	if tracker, ok := GetChangeTracker(c.CloudProvider); ok && e.cachedAt.Before(tracker.InstanceTypesChangedAt()) {
+		return nil, false
+	}
+	return e.instanceTypes, true
+}
+
+// Invalidate removes the cached instance types for the NodePools with the given names. If no names are passed, the
+// cached instance types for all NodePools are removed.
+func (c *CloudProvider) Invalidate(nodePoolNames ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(nodePoolNames) == 0 {
+		c.entries = map[string]entry{}
+		return
+	}
+	for _, name := range nodePoolNames {
+		delete(c.entries, name)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clock "k8s.io/utils/clock/testing"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/cache"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestCache(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cache")
+}
+
+// countingCloudProvider counts the calls made to GetInstanceTypes and reports changes to its instance types
+type countingCloudProvider struct {
+	*fake.CloudProvider
+	calls     int
+	changedAt time.Time
+}
+
+func (c *countingCloudProvider) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
+	c.calls++
+	return c.CloudProvider.GetInstanceTypes(ctx, nodePool)
+}
+
+func (c *countingCloudProvider) InstanceTypesChangedAt() time.Time {
+	return c.changedAt
+}
+
+// decoratingCloudProvider decorates a CloudProvider without overriding any of its methods
+type decoratingCloudProvider struct {
+	cloudprovider.CloudProvider
+}
+
+func (d *decoratingCloudProvider) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
+var _ = Describe("Cache", func() {
+	var fakeClock *clock.FakeClock
+	var underlying *countingCloudProvider
+	var cloudProvider *cache.CloudProvider
+	var nodePool *v1.NodePool
+
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		underlying = &countingCloudProvider{CloudProvider: fake.NewCloudProvider()}
+		cloudProvider = cache.Decorate(underlying, fakeClock, time.Minute)
+		nodePool = test.NodePool()
+		nodePool.Generation = 1
+	})
+
+	It("should serve instance types from the cache", func() {
+		first, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).To(Equal(first))
+		Expect(underlying.calls).To(Equal(1))
+	})
+	It("should cache instance types separately for each NodePool", func() {
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+	It("should refresh instance types once the TTL has expired", func() {
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		fakeClock.Step(2 * time.Minute)
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+	It("should refresh instance types when the NodePool changes", func() {
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		nodePool.Generation++
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+	It("should refresh instance types when the cache is invalidated", func() {
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		cloudProvider.Invalidate(nodePool.Name)
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		cloudProvider.Invalidate()
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(3))
+	})
+	It("should refresh instance types when the cloudprovider reports a change", func() {
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		fakeClock.Step(time.Second)
+		underlying.changedAt = fakeClock.Now()
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+	It("should refresh instance types when a decorated cloudprovider reports a change", func() {
+		cloudProvider = cache.Decorate(&decoratingCloudProvider{CloudProvider: underlying}, fakeClock, time.Minute)
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		fakeClock.Step(time.Second)
+		underlying.changedAt = fakeClock.Now()
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+	It("should not cache errors", func() {
+		underlying.ErrorsForNodePool[nodePool.Name] = fmt.Errorf("failed")
+		_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).To(HaveOccurred())
+		delete(underlying.ErrorsForNodePool, nodePool.Name)
+		_, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(underlying.calls).To(Equal(2))
+	})
+})
@@ -0,0 +1,57 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// decorator implements CloudProvider
+var _ cloudprovider.CloudProvider = (*decorator)(nil)
+
+type decorator struct {
+	cloudprovider.CloudProvider
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all read-only method calls to the argument,
+// `cloudProvider`, and will log, rather than perform, any calls that would launch or terminate instances. This is
+// used when running in dry-run mode so that controllers can evaluate what they would do without mutating capacity.
+func Decorate(cloudProvider cloudprovider.CloudProvider) cloudprovider.CloudProvider {
+	return &decorator{cloudProvider}
+}
+
+// Unwrap returns the decorated CloudProvider
+func (d *decorator) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
+func (d *decorator) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
+	log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name)).Info("dry-run, skipping instance launch")
+	return nil, cloudprovider.NewCreateError(fmt.Errorf("instance launches are disabled in dry-run mode"), "Instance launches are disabled in dry-run mode")
+}
+
+func (d *decorator) Delete(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name), "provider-id", nodeClaim.Status.ProviderID).Info("dry-run, skipping instance termination")
+	return nil
+}
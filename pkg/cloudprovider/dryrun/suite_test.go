@@ -0,0 +1,66 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/dryrun"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestDryRun(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DryRun")
+}
+
+var _ = Describe("DryRun", func() {
+	var fakeCloudProvider *fake.CloudProvider
+	var cloudProvider cloudprovider.CloudProvider
+
+	BeforeEach(func() {
+		fakeCloudProvider = fake.NewCloudProvider()
+		cloudProvider = dryrun.Decorate(fakeCloudProvider)
+	})
+
+	It("should not launch instances", func() {
+		_, err := cloudProvider.Create(ctx, test.NodeClaim())
+		Expect(err).To(HaveOccurred())
+		Expect(cloudprovider.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		Expect(fakeCloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should not terminate instances", func() {
+		Expect(cloudProvider.Delete(ctx, test.NodeClaim())).To(Succeed())
+		Expect(fakeCloudProvider.DeleteCalls).To(BeEmpty())
+	})
+	It("should delegate read-only calls to the underlying cloudprovider", func() {
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		Expect(cloudProvider.Name()).To(Equal(fakeCloudProvider.Name()))
+	})
+})
@@ -61,6 +61,9 @@ type CloudProvider struct {
 	Drifted                   cloudprovider.DriftReason
 	NodeClassGroupVersionKind []schema.GroupVersionKind
 	RepairPolicy              []cloudprovider.RepairPolicy
+	CapacityPools             []*cloudprovider.CapacityPool
+	// PriceSchedule changes the prices of the instance types as the clock advances
+	PriceSchedule *PriceSchedule
 }
 
 func NewCloudProvider() *CloudProvider {
@@ -87,6 +90,8 @@ func (c *CloudProvider) Reset() {
 	c.NextGetErr = nil
 	c.DeleteCalls = []*v1.NodeClaim{}
 	c.GetCalls = nil
+	c.CapacityPools = nil
+	c.PriceSchedule = nil
 	c.Drifted = "drifted"
 	c.NodeClassGroupVersionKind = []schema.GroupVersionKind{
 		{
@@ -118,10 +123,19 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v
 	if len(c.CreateCalls) > c.AllowedCreateCalls {
 		return &v1.NodeClaim{}, fmt.Errorf("erroring as number of AllowedCreateCalls has been exceeded")
 	}
+	// Return the in-progress launch if we've already launched for this idempotency key
+	if key, ok := nodeClaim.Annotations[v1.NodeClaimIdempotencyKeyAnnotationKey]; ok {
+		if created, ok := lo.Find(lo.Values(c.CreatedNodeClaims), func(nc *v1.NodeClaim) bool {
+			return nc.Annotations[v1.NodeClaimIdempotencyKeyAnnotationKey] == key
+		}); ok {
+			return created.DeepCopy(), nil
+		}
+	}
 	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	np := &v1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}}
 	instanceTypes := lo.Filter(lo.Must(c.GetInstanceTypes(ctx, np)), func(i *cloudprovider.InstanceType, _ int) bool {
 		return reqs.IsCompatible(i.Requirements, scheduling.AllowUndefinedWellKnownLabels) &&
+			i.CapabilityRequirements(reqs).Intersects(reqs) == nil &&
 			i.Offerings.Available().HasCompatible(reqs) &&
 			resources.Fits(nodeClaim.Spec.Resources.Requests, i.Allocatable())
 	})
@@ -133,7 +147,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v
 	})
 	instanceType := instanceTypes[0]
 	// Labels
-	labels := map[string]string{}
+	labels := instanceType.CapabilityLabels()
 	for key, requirement := range instanceType.Requirements {
 		if requirement.Operator() == corev1.NodeSelectorOpIn {
 			labels[key] = requirement.Values()[0]
@@ -196,10 +210,12 @@ func (c *CloudProvider) GetInstanceTypes(_ context.Context, np *v1.NodePool) ([]
 		}
 
 		if v, ok := c.InstanceTypesForNodePool[np.Name]; ok {
+			c.PriceSchedule.apply(v)
 			return v, nil
 		}
 	}
 	if c.InstanceTypes != nil {
+		c.PriceSchedule.apply(c.InstanceTypes)
 		return c.InstanceTypes, nil
 	}
 	return []*cloudprovider.InstanceType{
@@ -267,6 +283,13 @@ func (c *CloudProvider) IsDrifted(context.Context, *v1.NodeClaim) (cloudprovider
 	return c.Drifted, nil
 }
 
+func (c *CloudProvider) GetCapacityPools(context.Context, *v1.NodePool) ([]*cloudprovider.CapacityPool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.CapacityPools, nil
+}
+
 func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
 	return c.RepairPolicy
 }
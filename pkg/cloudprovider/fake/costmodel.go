@@ -0,0 +1,74 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+var _ cloudprovider.CostModel = (*CostModelCloudProvider)(nil)
+
+// CostModelCloudProvider is a fake CloudProvider that implements the CostModel interface. Costs that aren't overridden
+// are the prices of the offerings.
+type CostModelCloudProvider struct {
+	*CloudProvider
+	// NodeClaimCosts overrides the costs of NodeClaims by name
+	NodeClaimCosts map[string]float64
+	// LaunchCosts overrides the costs of launching instance types by name
+	LaunchCosts map[string]float64
+}
+
+func NewCostModelCloudProvider(cloudProvider *CloudProvider) *CostModelCloudProvider {
+	return &CostModelCloudProvider{
+		CloudProvider:  cloudProvider,
+		NodeClaimCosts: map[string]float64{},
+		LaunchCosts:    map[string]float64{},
+	}
+}
+
+func (c *CostModelCloudProvider) NodeClaimCost(ctx context.Context, nodeClaim *v1.NodeClaim) (float64, error) {
+	if cost, ok := c.NodeClaimCosts[nodeClaim.Name]; ok {
+		return cost, nil
+	}
+	instanceTypes, err := c.GetInstanceTypes(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, it := range instanceTypes {
+		if it.Name != nodeClaim.Labels[corev1.LabelInstanceTypeStable] {
+			continue
+		}
+		if offerings := it.Offerings.Compatible(scheduling.NewLabelRequirements(nodeClaim.Labels)); len(offerings) > 0 {
+			return offerings.Cheapest().Price, nil
+		}
+	}
+	return 0, fmt.Errorf("no offering found for nodeclaim %s", nodeClaim.Name)
+}
+
+func (c *CostModelCloudProvider) LaunchCost(_ context.Context, instanceType *cloudprovider.InstanceType, reqs scheduling.Requirements) (float64, error) {
+	if cost, ok := c.LaunchCosts[instanceType.Name]; ok {
+		return cost, nil
+	}
+	return instanceType.Offerings.Available().WorstLaunchPrice(reqs), nil
+}
@@ -119,10 +119,13 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 	}
 
 	return &cloudprovider.InstanceType{
-		Name:         options.Name,
-		Requirements: requirements,
-		Offerings:    options.Offerings,
-		Capacity:     options.Resources,
+		Name:            options.Name,
+		Requirements:    requirements,
+		Offerings:       options.Offerings,
+		Capacity:        options.Resources,
+		SharedResources: options.SharedResources,
+		Slices:          options.Slices,
+		Capabilities:    options.Capabilities,
 		Overhead: &cloudprovider.InstanceTypeOverhead{
 			KubeReserved: corev1.ResourceList{
 				corev1.ResourceCPU:    resource.MustParse("100m"),
@@ -198,6 +201,9 @@ type InstanceTypeOptions struct {
 	Architecture     string
 	OperatingSystems sets.Set[string]
 	Resources        corev1.ResourceList
+	SharedResources  corev1.ResourceList
+	Slices           []cloudprovider.Slice
+	Capabilities     sets.Set[string]
 }
 
 func PriceFromResources(resources corev1.ResourceList) float64 {
@@ -0,0 +1,113 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// PriceChange sets the price of the offerings of an instance type that are compatible with the requirements, e.g. its
+// spot offerings in a zone, once the clock reaches At
+type PriceChange struct {
+	At           time.Time
+	InstanceType string
+	// Requirements select the offerings whose price changes. All of the offerings change if they're nil.
+	Requirements scheduling.Requirements
+	Price        float64
+}
+
+// PriceSchedule changes the prices of the instance types of a fake CloudProvider over simulated time, e.g. to test
+// how consolidation reacts to spot prices that move. Prices are set when the instance types are retrieved, so the
+// instance types of the CloudProvider have the prices of the latest changes that the clock has reached.
+type PriceSchedule struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	changes []PriceChange
+}
+
+func NewPriceSchedule(clk clock.Clock) *PriceSchedule {
+	return &PriceSchedule{clock: clk}
+}
+
+// Add schedules the price changes
+func (p *PriceSchedule) Add(changes ...PriceChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.changes = append(p.changes, changes...)
+	sort.SliceStable(p.changes, func(i, j int) bool { return p.changes[i].At.Before(p.changes[j].At) })
+}
+
+// After schedules the price of the offerings of an instance type that are compatible with the requirements to change
+// after the duration has passed on the clock
+func (p *PriceSchedule) After(d time.Duration, instanceType string, requirements scheduling.Requirements, price float64) {
+	p.Add(PriceChange{At: p.clock.Now().Add(d), InstanceType: instanceType, Requirements: requirements, Price: price})
+}
+
+// Next returns when the next price change that the clock hasn't reached yet is scheduled for
+func (p *PriceSchedule) Next() (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock.Now()
+	change, ok := lo.Find(p.changes, func(c PriceChange) bool { return c.At.After(now) })
+	return change.At, ok
+}
+
+// Advance steps the fake clock to the next price change, returning false if there are no more price changes
+func (p *PriceSchedule) Advance(clk *clocktesting.FakeClock) bool {
+	next, ok := p.Next()
+	if !ok {
+		return false
+	}
+	clk.SetTime(next)
+	return true
+}
+
+// apply sets the prices of the instance types' offerings to the prices of the changes that the clock has reached.
+// Changes are applied in the order that they're scheduled for, so the latest change to an offering's price wins.
+func (p *PriceSchedule) apply(instanceTypes []*cloudprovider.InstanceType) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock.Now()
+	for _, change := range p.changes {
+		if change.At.After(now) {
+			break
+		}
+		for _, it := range instanceTypes {
+			if it.Name != change.InstanceType {
+				continue
+			}
+			for i := range it.Offerings {
+				if change.Requirements == nil || change.Requirements.IsCompatible(it.Offerings[i].Requirements, scheduling.AllowUndefinedWellKnownLabels) {
+					it.Offerings[i].Price = change.Price
+				}
+			}
+		}
+	}
+}
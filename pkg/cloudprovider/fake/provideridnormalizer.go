@@ -0,0 +1,54 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"strings"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+var _ cloudprovider.ProviderIDNormalizer = (*ProviderIDNormalizerCloudProvider)(nil)
+var _ cloudprovider.ProviderIDNormalizer = (*InstanceIDNormalizerCloudProvider)(nil)
+
+// ProviderIDNormalizerCloudProvider is a fake CloudProvider that implements the ProviderIDNormalizer interface by
+// ignoring the case of provider IDs
+type ProviderIDNormalizerCloudProvider struct {
+	*CloudProvider
+}
+
+func NewProviderIDNormalizerCloudProvider(cloudProvider *CloudProvider) *ProviderIDNormalizerCloudProvider {
+	return &ProviderIDNormalizerCloudProvider{CloudProvider: cloudProvider}
+}
+
+func (c *ProviderIDNormalizerCloudProvider) NormalizeProviderID(providerID string) string {
+	return strings.ToLower(providerID)
+}
+
+// InstanceIDNormalizerCloudProvider is a fake CloudProvider that implements the ProviderIDNormalizer interface by
+// reducing provider IDs to their instance ID
+type InstanceIDNormalizerCloudProvider struct {
+	*CloudProvider
+}
+
+func NewInstanceIDNormalizerCloudProvider(cloudProvider *CloudProvider) *InstanceIDNormalizerCloudProvider {
+	return &InstanceIDNormalizerCloudProvider{CloudProvider: cloudProvider}
+}
+
+func (c *InstanceIDNormalizerCloudProvider) NormalizeProviderID(providerID string) string {
+	return cloudprovider.InstanceID(providerID)
+}
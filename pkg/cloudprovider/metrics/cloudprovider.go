@@ -93,6 +93,11 @@ func Decorate(cloudProvider cloudprovider.CloudProvider) cloudprovider.CloudProv
 	return &decorator{cloudProvider}
 }
 
+// Unwrap returns the decorated CloudProvider
+func (d *decorator) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
 func (d *decorator) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
 	method := "Create"
 	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
@@ -143,6 +148,16 @@ func (d *decorator) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool)
 	return instanceType, err
 }
 
+func (d *decorator) GetCapacityPools(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.CapacityPool, error) {
+	method := "GetCapacityPools"
+	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	capacityPools, err := d.CloudProvider.GetCapacityPools(ctx, nodePool)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+	}
+	return capacityPools, err
+}
+
 func (d *decorator) IsDrifted(ctx context.Context, nodeClaim *v1.NodeClaim) (cloudprovider.DriftReason, error) {
 	method := "IsDrifted"
 	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
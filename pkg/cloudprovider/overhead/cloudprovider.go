@@ -0,0 +1,220 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overhead
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// Term is the amount of a resource that is reserved on a node, either as a fixed quantity or as a percentage of the
+// node's capacity
+type Term struct {
+	Quantity *resource.Quantity
+	Percent  float64
+}
+
+// Reserved returns the amount of the resource that the term reserves on a node with the given capacity
+func (t Term) Reserved(capacity resource.Quantity) resource.Quantity {
+	if t.Quantity != nil {
+		return t.Quantity.DeepCopy()
+	}
+	// Byte quantities, e.g. memory, are reserved in whole bytes
+	if capacity.Format == resource.BinarySI {
+		return *resource.NewQuantity(int64(math.Ceil(float64(capacity.Value())*t.Percent/100)), capacity.Format)
+	}
+	return *resource.NewMilliQuantity(int64(math.Ceil(float64(capacity.MilliValue())*t.Percent/100)), capacity.Format)
+}
+
+// Model is the overhead that is reserved on every node for kubelet and system daemons. Each resource reserves the
+// largest of its terms.
+type Model map[corev1.ResourceName][]Term
+
+// Parse parses a model from a comma separated list of resource reservations, e.g. "cpu=max(500m,6%),memory=1Gi".
+// A reservation is a quantity, a percentage of the node's capacity, or the max of several of these.
+func Parse(str string) (Model, error) {
+	model := Model{}
+	for _, reservation := range splitTopLevel(str) {
+		if strings.TrimSpace(reservation) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(reservation, "=")
+		if !ok {
+			return nil, fmt.Errorf("parsing reservation %q, expected <resource>=<reservation>", reservation)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if _, ok := model[corev1.ResourceName(name)]; ok {
+			return nil, fmt.Errorf("parsing reservation %q, resource %q is reserved more than once", reservation, name)
+		}
+		terms, err := parseTerms(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing reservation %q, %w", reservation, err)
+		}
+		model[corev1.ResourceName(name)] = terms
+	}
+	return model, nil
+}
+
+func parseTerms(value string) ([]Term, error) {
+	inner, ok := strings.CutPrefix(value, "max(")
+	if !ok {
+		term, err := parseTerm(value)
+		if err != nil {
+			return nil, err
+		}
+		return []Term{term}, nil
+	}
+	if inner, ok = strings.CutSuffix(inner, ")"); !ok {
+		return nil, fmt.Errorf("missing closing parenthesis")
+	}
+	var terms []Term
+	for _, s := range strings.Split(inner, ",") {
+		term, err := parseTerm(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func parseTerm(s string) (Term, error) {
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(p, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return Term{}, fmt.Errorf("invalid percentage %q", s)
+		}
+		return Term{Percent: percent}, nil
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return Term{}, fmt.Errorf("invalid quantity %q, %w", s, err)
+	}
+	if q.Sign() < 0 {
+		return Term{}, fmt.Errorf("invalid quantity %q, must not be negative", s)
+	}
+	return Term{Quantity: &q}, nil
+}
+
+// splitTopLevel splits the string on commas that aren't within parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Reserved returns the resources that the model reserves on a node with the given capacity. Resources that the node
+// doesn't have any capacity for aren't reserved.
+func (m Model) Reserved(capacity corev1.ResourceList) corev1.ResourceList {
+	reserved := corev1.ResourceList{}
+	for name, terms := range m {
+		c, ok := capacity[name]
+		if !ok {
+			continue
+		}
+		for _, t := range terms {
+			if r := t.Reserved(c); r.Cmp(reserved[name]) > 0 {
+				reserved[name] = r
+			}
+		}
+	}
+	return reserved
+}
+
+// CloudProvider implements cloudprovider.CloudProvider
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+
+// CloudProvider replaces the overhead that is reported by the wrapped CloudProvider with the overhead of the model for
+// the resources that the model reserves, so that the allocatable resources of simulated nodes match the kubelet
+// reservations that operators configure themselves. The eviction threshold that is reported by the wrapped
+// CloudProvider is still subtracted from allocatable.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+
+	model Model
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all method calls to the argument,
+// `cloudProvider`, and apply the overhead model to the instance types returned by GetInstanceTypes.
+func Decorate(cloudProvider cloudprovider.CloudProvider, model Model) *CloudProvider {
+	return &CloudProvider{
+		CloudProvider: cloudProvider,
+		model:         model,
+	}
+}
+
+// Unwrap returns the decorated CloudProvider
+func (c *CloudProvider) Unwrap() cloudprovider.CloudProvider {
+	return c.CloudProvider
+}
+
+func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
+	instanceTypes, err := c.CloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		// The instance types may be shared with other callers, so we copy rather than mutate them
+		copied := it.Copy()
+		copied.Overhead = c.overhead(it)
+		return copied
+	}), nil
+}
+
+func (c *CloudProvider) overhead(it *cloudprovider.InstanceType) *cloudprovider.InstanceTypeOverhead {
+	overhead := &cloudprovider.InstanceTypeOverhead{}
+	if it.Overhead != nil {
+		overhead.KubeReserved = it.Overhead.KubeReserved.DeepCopy()
+		overhead.SystemReserved = it.Overhead.SystemReserved.DeepCopy()
+		overhead.EvictionThreshold = it.Overhead.EvictionThreshold
+	}
+	if overhead.KubeReserved == nil {
+		overhead.KubeReserved = corev1.ResourceList{}
+	}
+	// The model reserves the combined kube and system reserved resources, which are attributed to kubelet
+	for name := range c.model {
+		delete(overhead.KubeReserved, name)
+		delete(overhead.SystemReserved, name)
+	}
+	for name, q := range c.model.Reserved(it.Capacity) {
+		overhead.KubeReserved[name] = q
+	}
+	return overhead
+}
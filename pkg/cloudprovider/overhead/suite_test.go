@@ -0,0 +1,133 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overhead_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/overhead"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestOverhead(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Overhead")
+}
+
+var _ = Describe("Overhead", func() {
+	Context("Parse", func() {
+		It("should parse quantities, percentages and max reservations", func() {
+			model, err := overhead.Parse("cpu=max(500m, 6%), memory=1Gi, ephemeral-storage=10%")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model).To(HaveLen(3))
+			Expect(model[corev1.ResourceCPU]).To(HaveLen(2))
+			Expect(model[corev1.ResourceMemory]).To(HaveLen(1))
+			Expect(model[corev1.ResourceEphemeralStorage]).To(Equal([]overhead.Term{{Percent: 10}}))
+		})
+		It("should parse an empty model", func() {
+			model, err := overhead.Parse("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model).To(BeEmpty())
+		})
+		DescribeTable("should fail to parse invalid models",
+			func(str string) {
+				_, err := overhead.Parse(str)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("missing reservation", "cpu"),
+			Entry("invalid quantity", "cpu=lots"),
+			Entry("negative quantity", "cpu=-1"),
+			Entry("invalid percentage", "cpu=150%"),
+			Entry("unclosed max", "cpu=max(500m,6%"),
+			Entry("duplicate resource", "cpu=1,cpu=2"),
+		)
+	})
+	Context("Reserved", func() {
+		It("should reserve the largest term of each resource", func() {
+			model, err := overhead.Parse("cpu=max(500m,6%),memory=max(1Gi,10%)")
+			Expect(err).ToNot(HaveOccurred())
+			small := model.Reserved(corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			})
+			Expect(small.Cpu().String()).To(Equal("500m"))
+			Expect(small.Memory().String()).To(Equal("1Gi"))
+			large := model.Reserved(corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("16"),
+				corev1.ResourceMemory: resource.MustParse("64Gi"),
+			})
+			Expect(large.Cpu().String()).To(Equal("960m"))
+			Expect(large.Memory().Value()).To(BeNumerically("==", 6871947674))
+		})
+		It("should not reserve resources that the node doesn't have", func() {
+			model, err := overhead.Parse("nvidia.com/gpu=1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.Reserved(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")})).To(BeEmpty())
+		})
+	})
+	Context("CloudProvider", func() {
+		var underlying *fake.CloudProvider
+		var instanceType *cloudprovider.InstanceType
+
+		BeforeEach(func() {
+			underlying = fake.NewCloudProvider()
+			instanceType = fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "overhead-instance-type",
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("16"),
+					corev1.ResourceMemory: resource.MustParse("64Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+			})
+			instanceType.Overhead.SystemReserved = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}
+			instanceType.Overhead.EvictionThreshold = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")}
+			underlying.InstanceTypes = []*cloudprovider.InstanceType{instanceType}
+		})
+
+		It("should replace the reported overhead of the reserved resources", func() {
+			cloudProvider := overhead.Decorate(underlying, overhead.Model{corev1.ResourceCPU: {{Percent: 6}}})
+			its, err := cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(its).To(HaveLen(1))
+			allocatable := its[0].Allocatable()
+			Expect(allocatable.Cpu().String()).To(Equal("15040m"))
+			// Memory isn't reserved by the model, so the reported overhead and eviction threshold still apply
+			Expect(allocatable.Memory().Value()).To(BeNumerically("==", 64*1024*1024*1024-110*1024*1024))
+		})
+		It("should not modify the instance types of the wrapped cloudprovider", func() {
+			cloudProvider := overhead.Decorate(underlying, overhead.Model{corev1.ResourceCPU: {{Percent: 50}}})
+			_, err := cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceType.Overhead.KubeReserved.Cpu().String()).To(Equal("100m"))
+			Expect(instanceType.Overhead.SystemReserved.Cpu().String()).To(Equal("200m"))
+			allocatable := instanceType.Allocatable()
+			Expect(allocatable.Cpu().String()).To(Equal("15700m"))
+		})
+	})
+})
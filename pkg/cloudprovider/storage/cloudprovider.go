@@ -0,0 +1,76 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// LabelValue returns the value of the ephemeral storage label for the given capacity, which is the ephemeral storage
+// capacity in whole GiB so that it can be selected with the Gt and Lt operators
+func LabelValue(capacity corev1.ResourceList) string {
+	return strconv.FormatInt(capacity.StorageEphemeral().Value()/(1<<30), 10)
+}
+
+// CloudProvider implements cloudprovider.CloudProvider
+var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+
+// CloudProvider adds a requirement on the ephemeral storage label to the instance types that it returns, so that pods
+// and NodePools can require a minimum amount of local disk, e.g. karpenter.sh/ephemeral-storage Gt 499 for at least
+// 500GiB. Instance types that already define the requirement are returned as is.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all method calls to the argument,
+// `cloudProvider`, and add the ephemeral storage requirement to the instance types returned by GetInstanceTypes.
+func Decorate(cloudProvider cloudprovider.CloudProvider) *CloudProvider {
+	return &CloudProvider{
+		CloudProvider: cloudProvider,
+	}
+}
+
+// Unwrap returns the decorated CloudProvider
+func (c *CloudProvider) Unwrap() cloudprovider.CloudProvider {
+	return c.CloudProvider
+}
+
+func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
+	instanceTypes, err := c.CloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		if it.Requirements.Has(v1.EphemeralStorageLabelKey) {
+			return it
+		}
+		// The instance types may be shared with other callers, so we copy rather than mutate them
+		requirements := scheduling.NewRequirements(it.Requirements.Values()...)
+		requirements.Add(scheduling.NewRequirement(v1.EphemeralStorageLabelKey, corev1.NodeSelectorOpIn, LabelValue(it.Capacity)))
+		copied := it.Copy()
+		copied.Requirements = requirements
+		return copied
+	}), nil
+}
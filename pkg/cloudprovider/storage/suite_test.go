@@ -0,0 +1,103 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/storage"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestStorage(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Storage")
+}
+
+var _ = Describe("Storage", func() {
+	var underlying *fake.CloudProvider
+	var small, large *cloudprovider.InstanceType
+
+	BeforeEach(func() {
+		underlying = fake.NewCloudProvider()
+		small = fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "small-disk",
+			Resources: corev1.ResourceList{
+				corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+			},
+		})
+		large = fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "large-disk",
+			Resources: corev1.ResourceList{
+				corev1.ResourceEphemeralStorage: resource.MustParse("1900Gi"),
+			},
+		})
+		underlying.InstanceTypes = []*cloudprovider.InstanceType{small, large}
+	})
+
+	It("should label instance types with their ephemeral storage capacity in GiB", func() {
+		its, err := storage.Decorate(underlying).GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(its).To(HaveLen(2))
+		Expect(its[0].Requirements.Get(v1.EphemeralStorageLabelKey).Values()).To(ConsistOf("100"))
+		Expect(its[1].Requirements.Get(v1.EphemeralStorageLabelKey).Values()).To(ConsistOf("1900"))
+	})
+	It("should only select instance types with enough ephemeral storage", func() {
+		its, err := storage.Decorate(underlying).GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		requirements := scheduling.NewRequirements(scheduling.NewRequirement(v1.EphemeralStorageLabelKey, corev1.NodeSelectorOpGt, "499"))
+		Expect(its[0].Requirements.Compatible(requirements)).ToNot(Succeed())
+		Expect(its[1].Requirements.Compatible(requirements)).To(Succeed())
+	})
+	It("should not modify the instance types of the wrapped cloudprovider", func() {
+		_, err := storage.Decorate(underlying).GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(small.Requirements.Has(v1.EphemeralStorageLabelKey)).To(BeFalse())
+	})
+	It("should keep the requirement of instance types that already define it", func() {
+		large.Requirements.Add(scheduling.NewRequirement(v1.EphemeralStorageLabelKey, corev1.NodeSelectorOpIn, "1800"))
+		its, err := storage.Decorate(underlying).GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(its[1]).To(BeIdenticalTo(large))
+	})
+	It("should preserve the pods capacities of instance types by IP family", func() {
+		small.PodsByIPFamily = map[string]resource.Quantity{v1.IPFamilyIPv6: resource.MustParse("250")}
+		its, err := storage.Decorate(underlying).GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(its[0]).ToNot(BeIdenticalTo(small))
+		Expect(its[0].PodsByIPFamily).To(HaveKey(v1.IPFamilyIPv6))
+		Expect(its[0].ForIPFamily(v1.IPFamilyIPv6).Capacity.Pods().Value()).To(BeNumerically("==", 250))
+	})
+	It("should round the label value down to whole GiB", func() {
+		Expect(storage.LabelValue(corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("500G")})).To(Equal("465"))
+		Expect(storage.LabelValue(corev1.ResourceList{})).To(Equal("0"))
+	})
+})
@@ -22,12 +22,14 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -52,10 +54,29 @@ type RepairPolicy struct {
 	TolerationDuration time.Duration
 }
 
+// CapacityPool is an exhaustible pool of capacity in a zone, e.g. the IP addresses of the subnets in the zone. Every
+// node that launches into the zone consumes PerNode from the pool, and every pod that schedules to the node and doesn't
+// use the host network consumes PerPod. Zones whose pool would be exhausted are avoided when launching nodes, rather than
+// launching nodes that fail to get capacity from the pool later, e.g. when the CNI allocates IP addresses to pods.
+type CapacityPool struct {
+	// Name identifies the pool. NodePools that return pools with the same name share their capacity.
+	Name string
+	// Zone is the zone that the pool provides capacity in. A NodePool is expected to have at most one pool per zone.
+	Zone string
+	// Remaining is the capacity that is left in the pool
+	Remaining int64
+	// PerNode is the capacity that a node consumes from the pool when it's launched
+	PerNode int64
+	// PerPod is the capacity that a pod consumes from the pool when it schedules to a node
+	PerPod int64
+}
+
 // CloudProvider interface is implemented by cloud providers to support provisioning.
 type CloudProvider interface {
 	// Create launches a NodeClaim with the given resource requests and requirements and returns a hydrated
-	// NodeClaim back with resolved NodeClaim labels for the launched NodeClaim
+	// NodeClaim back with resolved NodeClaim labels for the launched NodeClaim. Create may be called more than once for
+	// the same NodeClaim (e.g. after a leader failover), so implementations should use the karpenter.sh/idempotency-key
+	// annotation to return the in-progress launch rather than launching duplicate capacity.
 	Create(context.Context, *v1.NodeClaim) (*v1.NodeClaim, error)
 	// Delete removes a NodeClaim from the cloudprovider by its provider id
 	Delete(context.Context, *v1.NodeClaim) error
@@ -71,6 +92,9 @@ type CloudProvider interface {
 	// IsDrifted returns whether a NodeClaim has drifted from the provisioning requirements
 	// it is tied to.
 	IsDrifted(context.Context, *v1.NodeClaim) (DriftReason, error)
+	// GetCapacityPools returns the exhaustible pools of capacity that nodes launched for the nodepool consume,
+	// e.g. the IP addresses of its subnets in each zone. CloudProviders without such limits return no pools.
+	GetCapacityPools(context.Context, *v1.NodePool) ([]*CapacityPool, error)
 	// RepairPolicy is for CloudProviders to define a set Unhealthy condition for Karpenter
 	// to monitor on the node.
 	RepairPolicies() []RepairPolicy
@@ -81,6 +105,64 @@ type CloudProvider interface {
 	GetSupportedNodeClasses() []status.Object
 }
 
+// CostModel can optionally be implemented by a CloudProvider to return the effective cost of capacity, e.g. accounting
+// for reserved instances, savings plans or committed use discounts, rather than the price of its offerings. Consolidation
+// compares these costs when replacing nodes so that it doesn't replace capacity that's already paid for.
+type CostModel interface {
+	// NodeClaimCost returns the effective hourly cost of a launched NodeClaim
+	NodeClaimCost(context.Context, *v1.NodeClaim) (float64, error)
+	// LaunchCost returns the effective hourly cost of launching the instance type with the given requirements
+	LaunchCost(context.Context, *InstanceType, scheduling.Requirements) (float64, error)
+}
+
+// GetCostModel returns the CostModel of the CloudProvider if it implements one, looking through any decorators that
+// wrap it
+func GetCostModel(cloudProvider CloudProvider) (CostModel, bool) {
+	for cloudProvider != nil {
+		if costModel, ok := cloudProvider.(CostModel); ok {
+			return costModel, true
+		}
+		decorator, ok := cloudProvider.(interface{ Unwrap() CloudProvider })
+		if !ok {
+			break
+		}
+		cloudProvider = decorator.Unwrap()
+	}
+	return nil, false
+}
+
+// ProviderIDNormalizer can optionally be implemented by a CloudProvider whose provider IDs may be reported in different
+// formats for the NodeClaim and the Node of the same instance, e.g. with a different case or different path segments.
+// Cluster state uses it to link NodeClaims to their Nodes when their provider IDs differ.
+type ProviderIDNormalizer interface {
+	// NormalizeProviderID returns the canonical form of the provider ID, which is equal for all of the formats that the
+	// provider ID of an instance may be reported in
+	NormalizeProviderID(providerID string) string
+}
+
+// InstanceID returns the last path segment of the provider ID, lower-cased. CloudProviders that report the provider ID
+// of an instance with different prefixes, but always end it with the instance ID, can opt in to linking NodeClaims to
+// their Nodes by their instance ID by returning it from NormalizeProviderID.
+func InstanceID(providerID string) string {
+	return strings.ToLower(providerID[strings.LastIndex(providerID, "/")+1:])
+}
+
+// GetProviderIDNormalizer returns the ProviderIDNormalizer of the CloudProvider if it implements one, looking through
+// any decorators that wrap it
+func GetProviderIDNormalizer(cloudProvider CloudProvider) (ProviderIDNormalizer, bool) {
+	for cloudProvider != nil {
+		if normalizer, ok := cloudProvider.(ProviderIDNormalizer); ok {
+			return normalizer, true
+		}
+		decorator, ok := cloudProvider.(interface{ Unwrap() CloudProvider })
+		if !ok {
+			break
+		}
+		cloudProvider = decorator.Unwrap()
+	}
+	return nil, false
+}
+
 // InstanceType describes the properties of a potential node (either concrete attributes of an instance of this type
 // or supported options in the case of arrays)
 type InstanceType struct {
@@ -96,6 +178,23 @@ type InstanceType struct {
 	// Overhead is the amount of resource overhead expected to be used by kubelet and any other system daemons outside
 	// of Kubernetes.
 	Overhead *InstanceTypeOverhead
+	// SharedResources are the extended resources that are provided by devices that pods can share, e.g. the memory of
+	// GPUs, mapped to the capacity of a single device. Capacity contains the total of each shared resource across all
+	// devices, and each pod's request for a shared resource must be satisfied by a single device.
+	SharedResources corev1.ResourceList
+	// Slices are the virtual nodes that each machine of the instance type is partitioned into, e.g. the MIG slices of
+	// its GPUs or its dedicated CPU pools. The capacity of the slices must be included in Capacity. When NodeSlicing is
+	// enabled, each pod's requests for the resources of the slices must be satisfied by a single slice.
+	Slices []Slice
+	// Capabilities are the names of the boolean capabilities of the instance type that pods may require, e.g.
+	// nested-virtualization, sgx or smt-off. Pods require a capability with the v1.CapabilityLabelKey of its name, and
+	// nodes must be labeled "true" for each capability of their instance type. Instance types lack every capability
+	// that isn't in the set, so capabilities must not be defined in Requirements.
+	Capabilities sets.Set[string]
+	// PodsByIPFamily are the pods capacities of the instance type for the IP families whose pods capacity differs from
+	// the pods in Capacity, e.g. when nodes can run more pods in IPv6 clusters since pod addresses aren't limited by
+	// the number of addresses of the instance's network interfaces
+	PodsByIPFamily map[string]resource.Quantity
 
 	once        sync.Once
 	allocatable corev1.ResourceList
@@ -103,8 +202,72 @@ type InstanceType struct {
 
 type InstanceTypes []*InstanceType
 
+// Slice is a partition of a machine's capacity that pods are scheduled onto as if it were a node of its own
+type Slice struct {
+	// Name identifies the slice within the instance type, e.g. the MIG profile of a GPU slice
+	Name string
+	// Capacity is the capacity of the slice. Resources that aren't in the capacity of any of the instance type's slices
+	// are scheduled onto the machine as a whole.
+	Capacity corev1.ResourceList
+}
+
+// SliceCapacities returns the capacity of each of the instance type's slices
+func (i *InstanceType) SliceCapacities() []corev1.ResourceList {
+	return lo.Map(i.Slices, func(s Slice, _ int) corev1.ResourceList { return s.Capacity })
+}
+
 // precompute is used to ensure we only compute the allocatable resources onces as its called many times
 // and the operation is fairly expensive.
+// CapabilityRequirements returns the requirements of the instance type for the capability labels of the requirements,
+// which are "true" for the capabilities that the instance type has and "false" otherwise
+func (i *InstanceType) CapabilityRequirements(requirements scheduling.Requirements) scheduling.Requirements {
+	capabilities := scheduling.NewRequirements()
+	for key := range requirements.Keys() {
+		if v1.IsCapabilityLabel(key) {
+			capabilities.Add(scheduling.NewRequirement(key, corev1.NodeSelectorOpIn,
+				lo.Ternary(i.Capabilities.Has(strings.TrimPrefix(key, v1.CapabilityLabelDomain+"/")), v1.CapabilityTrue, v1.CapabilityFalse)))
+		}
+	}
+	return capabilities
+}
+
+// CapabilityLabels returns the labels of the instance type's capabilities
+func (i *InstanceType) CapabilityLabels() map[string]string {
+	return lo.SliceToMap(sets.List(i.Capabilities), func(name string) (string, string) {
+		return v1.CapabilityLabelKey(name), v1.CapabilityTrue
+	})
+}
+
+// ForIPFamily returns the instance type with the pods capacity of the IP family. The instance type is returned as is when
+// its pods capacity doesn't depend on the IP family.
+func (i *InstanceType) ForIPFamily(ipFamily string) *InstanceType {
+	pods, ok := i.PodsByIPFamily[ipFamily]
+	if !ok || pods.Equal(i.Capacity[corev1.ResourcePods]) {
+		return i
+	}
+	copied := i.Copy()
+	copied.Capacity = i.Capacity.DeepCopy()
+	copied.Capacity[corev1.ResourcePods] = pods
+	return copied
+}
+
+// Copy returns a shallow copy of the instance type whose allocatable resources are computed anew, so that decorators
+// can replace some of its fields without mutating an instance type that's shared with other callers. Fields that are
+// added to InstanceType must be copied here, so that they aren't dropped by the decorators.
+func (i *InstanceType) Copy() *InstanceType {
+	return &InstanceType{
+		Name:            i.Name,
+		Requirements:    i.Requirements,
+		Offerings:       i.Offerings,
+		Capacity:        i.Capacity,
+		Overhead:        i.Overhead,
+		SharedResources: i.SharedResources,
+		Slices:          i.Slices,
+		Capabilities:    i.Capabilities,
+		PodsByIPFamily:  i.PodsByIPFamily,
+	}
+}
+
 func (i *InstanceType) precompute() {
 	i.allocatable = resources.Subtract(i.Capacity, i.Overhead.Total())
 }
@@ -339,14 +502,25 @@ func IgnoreNodeClaimNotFoundError(err error) error {
 	return err
 }
 
+// OfferingKey identifies a single offering of an instance type
+type OfferingKey struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+}
+
 // InsufficientCapacityError is an error type returned by CloudProviders when a launch fails due to a lack of capacity from NodeClaim requirements
 type InsufficientCapacityError struct {
 	error
+	// Offerings are the offerings that were found to have insufficient capacity. CloudProviders may leave this empty,
+	// in which case the offerings are derived from the NodeClaim's requirements.
+	Offerings []OfferingKey
 }
 
-func NewInsufficientCapacityError(err error) *InsufficientCapacityError {
+func NewInsufficientCapacityError(err error, offerings ...OfferingKey) *InsufficientCapacityError {
 	return &InsufficientCapacityError{
-		error: err,
+		error:     err,
+		Offerings: offerings,
 	}
 }
 
@@ -362,6 +536,15 @@ func IsInsufficientCapacityError(err error) bool {
 	return errors.As(err, &icErr)
 }
 
+// InsufficientCapacityOfferings returns the offerings that were reported as having insufficient capacity by the error
+func InsufficientCapacityOfferings(err error) []OfferingKey {
+	var icErr *InsufficientCapacityError
+	if !errors.As(err, &icErr) {
+		return nil
+	}
+	return icErr.Offerings
+}
+
 // NodeClassNotReadyError is an error type returned by CloudProviders when a NodeClass that is used by the launch process doesn't have all its resolved fields
 type NodeClassNotReadyError struct {
 	error
@@ -31,6 +31,11 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/availability"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/cache"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/dryrun"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/overhead"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/storage"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
 	metricsnode "sigs.k8s.io/karpenter/pkg/controllers/metrics/node"
@@ -38,23 +43,36 @@ import (
 	metricspod "sigs.k8s.io/karpenter/pkg/controllers/metrics/pod"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/health"
 	nodehydration "sigs.k8s.io/karpenter/pkg/controllers/node/hydration"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/protection"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/temporarytaint"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
+	nodeclaimaccuracy "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/accuracy"
 	nodeclaimconsistency "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/consistency"
 	nodeclaimdisruption "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/disruption"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/expiration"
 	nodeclaimgarbagecollection "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/garbagecollection"
 	nodeclaimhydration "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/hydration"
 	nodeclaimlifecycle "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
+	nodeclaimorphan "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/orphan"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/podevents"
+	nodeclaimprovenance "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/provenance"
 	nodepoolcounter "sigs.k8s.io/karpenter/pkg/controllers/nodepool/counter"
+	nodepoolfailurepolicy "sigs.k8s.io/karpenter/pkg/controllers/nodepool/failurepolicy"
 	nodepoolhash "sigs.k8s.io/karpenter/pkg/controllers/nodepool/hash"
+	nodepoolimpact "sigs.k8s.io/karpenter/pkg/controllers/nodepool/impact"
 	nodepoolreadiness "sigs.k8s.io/karpenter/pkg/controllers/nodepool/readiness"
+	nodepoolrollout "sigs.k8s.io/karpenter/pkg/controllers/nodepool/rollout"
+	nodepoolskew "sigs.k8s.io/karpenter/pkg/controllers/nodepool/skew"
+	nodepoolutilization "sigs.k8s.io/karpenter/pkg/controllers/nodepool/utilization"
 	nodepoolvalidation "sigs.k8s.io/karpenter/pkg/controllers/nodepool/validation"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	stateconsistency "sigs.k8s.io/karpenter/pkg/controllers/state/consistency"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	stateserver "sigs.k8s.io/karpenter/pkg/controllers/state/server"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/hooks"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
@@ -67,6 +85,25 @@ func NewControllers(
 	cloudProvider cloudprovider.CloudProvider,
 	cluster *state.Cluster,
 ) []controller.Controller {
+	if options.FromContext(ctx).DryRun {
+		cloudProvider = dryrun.Decorate(cloudProvider)
+	}
+	// The overhead is applied before caching so that cached instance types already have the configured overhead
+	if model := lo.Must(overhead.Parse(options.FromContext(ctx).NodeOverhead)); len(model) > 0 {
+		cloudProvider = overhead.Decorate(cloudProvider, model)
+	}
+	cloudProvider = storage.Decorate(cloudProvider)
+	if ttl := options.FromContext(ctx).InstanceTypeCacheTTL; ttl > 0 {
+		cloudProvider = cache.Decorate(cloudProvider, clock, ttl)
+	}
+	// Offerings that fail to launch with insufficient capacity are marked unavailable on top of any cached instance types
+	cloudProvider = availability.Decorate(cloudProvider, clock)
+	// Hooks call out to external systems, so they aren't run in dry-run mode
+	var lifecycleHooks []hooks.Hook
+	if !options.FromContext(ctx).DryRun {
+		lifecycleHooks = lo.Must(hooks.Load(options.FromContext(ctx).LifecycleHooksConfig))
+	}
+	hookRunner := hooks.NewRunner(clock, kubeClient, recorder, lifecycleHooks...)
 	p := provisioning.NewProvisioner(kubeClient, recorder, cloudProvider, cluster, clock)
 	evictionQueue := terminator.NewQueue(kubeClient, recorder)
 	disruptionQueue := orchestration.NewQueue(kubeClient, recorder, cluster, clock, p)
@@ -74,29 +111,41 @@ func NewControllers(
 	controllers := []controller.Controller{
 		p, evictionQueue, disruptionQueue,
 		disruption.NewController(clock, kubeClient, p, cloudProvider, recorder, cluster, disruptionQueue),
+		disruption.NewPlanController(clock, kubeClient, p, cloudProvider, recorder, cluster, disruptionQueue),
 		provisioning.NewPodController(kubeClient, p, cluster),
 		provisioning.NewNodeController(kubeClient, p),
 		nodepoolhash.NewController(kubeClient, cloudProvider),
-		expiration.NewController(clock, kubeClient, cloudProvider),
+		expiration.NewController(clock, kubeClient, cloudProvider, recorder),
 		informer.NewDaemonSetController(kubeClient, cluster),
 		informer.NewNodeController(kubeClient, cluster),
+		informer.NewLeaseController(kubeClient, cluster),
 		informer.NewPodController(kubeClient, cluster),
+		informer.NewVolumeController(kubeClient, cluster),
 		informer.NewNodePoolController(kubeClient, cloudProvider, cluster),
 		informer.NewNodeClaimController(kubeClient, cloudProvider, cluster),
-		termination.NewController(clock, kubeClient, cloudProvider, terminator.NewTerminator(clock, kubeClient, evictionQueue, recorder), recorder),
+		stateconsistency.NewController(kubeClient, mgr.GetAPIReader(), cloudProvider, cluster),
+		termination.NewController(clock, kubeClient, cloudProvider, terminator.NewTerminator(clock, kubeClient, evictionQueue, recorder), recorder, hookRunner),
 		metricspod.NewController(kubeClient, cluster),
 		metricsnodepool.NewController(kubeClient, cloudProvider),
 		metricsnode.NewController(cluster),
 		nodepoolreadiness.NewController(kubeClient, cloudProvider),
-		nodepoolcounter.NewController(kubeClient, cloudProvider, cluster),
+		nodepoolcounter.NewController(kubeClient, cloudProvider, cluster, recorder),
 		nodepoolvalidation.NewController(kubeClient, cloudProvider),
+		nodepoolimpact.NewController(kubeClient, cloudProvider, cluster),
+		nodepoolfailurepolicy.NewController(clock, kubeClient, cloudProvider, recorder),
+		nodepoolutilization.NewController(clock, kubeClient, cluster, recorder),
+		nodepoolrollout.NewController(clock, kubeClient, cloudProvider),
 		podevents.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimconsistency.NewController(clock, kubeClient, cloudProvider, recorder),
-		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, recorder),
+		nodeclaimaccuracy.NewController(clock, kubeClient, cloudProvider, recorder),
+		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, recorder, hookRunner),
 		nodeclaimgarbagecollection.NewController(clock, kubeClient, cloudProvider),
+		nodeclaimorphan.NewController(clock, kubeClient, cloudProvider, cluster, p, recorder),
 		nodeclaimdisruption.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimhydration.NewController(kubeClient, cloudProvider),
 		nodehydration.NewController(kubeClient, cloudProvider),
+		temporarytaint.NewController(kubeClient, cloudProvider, clock),
+		protection.NewController(kubeClient, cloudProvider),
 		status.NewController[*v1.NodeClaim](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics, status.WithLabels(append(lo.Map(cloudProvider.GetSupportedNodeClasses(), func(obj status.Object, _ int) string { return v1.NodeClassLabelKey(object.GVK(obj).GroupKind()) }), v1.NodePoolLabelKey)...)),
 		status.NewController[*v1.NodePool](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		status.NewGenericObjectController[*corev1.Node](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.WithLabels(append(lo.Map(cloudProvider.GetSupportedNodeClasses(), func(obj status.Object, _ int) string { return v1.NodeClassLabelKey(object.GVK(obj).GroupKind()) }), v1.NodePoolLabelKey, v1.NodeInitializedLabelKey)...)),
@@ -107,5 +156,23 @@ func NewControllers(
 		controllers = append(controllers, health.NewController(kubeClient, cloudProvider, clock, recorder))
 	}
 
+	// NodeProvenances are only recorded when they're retained for some period after their NodeClaim is terminated
+	if options.FromContext(ctx).NodeProvenanceRetention > 0 {
+		controllers = append(controllers,
+			nodeclaimprovenance.NewController(kubeClient, cloudProvider),
+			nodeclaimprovenance.NewGarbageCollectionController(clock, kubeClient),
+		)
+	}
+
+	// Zonal skew is only analyzed when a threshold is configured to report it against
+	if options.FromContext(ctx).ZonalSkewThreshold > 0 {
+		controllers = append(controllers, nodepoolskew.NewController(kubeClient, cloudProvider, cluster))
+	}
+
+	// External schedulers opt in to the cluster state API by configuring the port that it's served on
+	if options.FromContext(ctx).StateServerPort > 0 {
+		controllers = append(controllers, stateserver.NewServer(cluster))
+	}
+
 	return controllers
 }
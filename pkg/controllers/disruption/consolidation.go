@@ -25,6 +25,8 @@ import (
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -60,10 +62,15 @@ type consolidation struct {
 	cloudProvider          cloudprovider.CloudProvider
 	recorder               events.Recorder
 	lastConsolidationState time.Time
+	// costModel is set if the CloudProvider returns effective costs that are compared instead of offering prices
+	costModel cloudprovider.CostModel
+	// validationTTL is how long commands are held before validating that they still work
+	validationTTL time.Duration
 }
 
 func MakeConsolidation(clock clock.Clock, cluster *state.Cluster, kubeClient client.Client, provisioner *provisioning.Provisioner,
 	cloudProvider cloudprovider.CloudProvider, recorder events.Recorder, queue *orchestration.Queue) consolidation {
+	costModel, _ := cloudprovider.GetCostModel(cloudProvider)
 	return consolidation{
 		queue:         queue,
 		clock:         clock,
@@ -72,6 +79,8 @@ func MakeConsolidation(clock clock.Clock, cluster *state.Cluster, kubeClient cli
 		provisioner:   provisioner,
 		cloudProvider: cloudProvider,
 		recorder:      recorder,
+		costModel:     costModel,
+		validationTTL: consolidationTTL,
 	}
 }
 
@@ -86,7 +95,7 @@ func (c *consolidation) markConsolidated() {
 }
 
 // ShouldDisrupt is a predicate used to filter candidates
-func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
+func (c *consolidation) ShouldDisrupt(ctx context.Context, cn *Candidate) bool {
 	// We need the following to know what the price of the instance for price comparison. If one of these doesn't exist, we can't
 	// compute consolidation decisions for this candidate.
 	// 1. Instance Type
@@ -115,10 +124,39 @@ func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
 		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodePool %q has non-empty consolidation disabled", cn.nodePool.Name))...)
 		return false
 	}
+	// Don't remove the capacity that a workload was recently scaled up to, since its autoscaler is likely to demand it again
+	if pod := c.scaledUpPod(ctx, cn); pod != nil {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("Pod %q was recently scaled up", client.ObjectKeyFromObject(pod)))...)
+		return false
+	}
 	// return true if consolidatable
 	return cn.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
 }
 
+// scaledUpPod returns a pod of the candidate that was created within the consolidation scale up window by an owner that
+// already existed before the window. These are pods that a workload autoscaler, e.g. an HPA or VPA, recently scaled up
+// to or recreated, as opposed to the pods of new workloads or rollouts.
+func (c *consolidation) scaledUpPod(ctx context.Context, cn *Candidate) *corev1.Pod {
+	window := options.FromContext(ctx).ConsolidationScaleUpWindow
+	if window == 0 {
+		return nil
+	}
+	since := c.clock.Now().Add(-window)
+	pod, _ := lo.Find(cn.reschedulablePods, func(p *corev1.Pod) bool {
+		owner := metav1.GetControllerOf(p)
+		if owner == nil || p.CreationTimestamp.Time.Before(since) {
+			return false
+		}
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind))
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: owner.Name}, obj); err != nil {
+			return false
+		}
+		return obj.CreationTimestamp.Time.Before(since)
+	})
+	return pod
+}
+
 // sortCandidates sorts candidates by disruption cost (where the lowest disruption cost is first) and returns the result
 func (c *consolidation) sortCandidates(candidates []*Candidate) []*Candidate {
 	sort.Slice(candidates, func(i int, j int) bool {
@@ -168,11 +206,24 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 
 	// get the current node price based on the offering
 	// fallback if we can't find the specific zonal pricing data
-	candidatePrice, err := getCandidatePrices(candidates)
+	candidatePrice, err := c.getCandidatePrices(ctx, candidates)
 	if err != nil {
 		return Command{}, pscheduling.Results{}, fmt.Errorf("getting offering price from candidate node, %w", err)
 	}
 
+	// Nodes that fell back to on-demand capacity are only replaced with spot capacity if their NodePool's fallback policy allows it
+	if lo.ContainsBy(candidates, func(cn *Candidate) bool {
+		return cn.NodeClaim.Annotations[v1.CapacityTypeFallbackAnnotationKey] == string(v1.CapacityTypeFallbackOnDemand)
+	}) {
+		if !results.NewNodeClaims[0].Requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeOnDemand) {
+			if len(candidates) == 1 {
+				c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace a node that fell back to on-demand with a spot node")...)
+			}
+			return Command{}, pscheduling.Results{}, nil
+		}
+		results.NewNodeClaims[0].Requirements.Add(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeOnDemand))
+	}
+
 	allExistingAreSpot := true
 	for _, cn := range candidates {
 		if cn.capacityType != v1.CapacityTypeSpot {
@@ -193,7 +244,7 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	// If we use this directly for spot-to-spot consolidation, we are bound to get repeated consolidations because the strategy that chooses to launch the spot instance from the list does
 	// it based on availability and price which could result in selection/launch of non-lowest priced instance in the list. So, we would keep repeating this loop till we get to lowest priced instance
 	// causing churns and landing onto lower available spot instance ultimately resulting in higher interruptions.
-	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, candidatePrice)
+	results.NewNodeClaims[0], err = c.removeInstanceTypeOptionsByCost(ctx, results.NewNodeClaims[0], candidatePrice)
 
 	if err != nil {
 		if len(candidates) == 1 {
@@ -246,7 +297,7 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 
 	// filterByPrice returns the instanceTypes that are lower priced than the current candidate and any error that indicates the input couldn't be filtered.
 	var err error
-	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, candidatePrice)
+	results.NewNodeClaims[0], err = c.removeInstanceTypeOptionsByCost(ctx, results.NewNodeClaims[0], candidatePrice)
 	if err != nil {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Filtering by price: %v", err))...)
@@ -304,14 +355,61 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 }
 
 // getCandidatePrices returns the sum of the prices of the given candidates
-func getCandidatePrices(candidates []*Candidate) (float64, error) {
+func (c *consolidation) getCandidatePrices(ctx context.Context, candidates []*Candidate) (float64, error) {
 	var price float64
-	for _, c := range candidates {
-		compatibleOfferings := c.instanceType.Offerings.Compatible(scheduling.NewLabelRequirements(c.StateNode.Labels()))
-		if len(compatibleOfferings) == 0 {
-			return 0.0, fmt.Errorf("unable to determine offering for %s/%s/%s", c.instanceType.Name, c.capacityType, c.zone)
+	for _, cn := range candidates {
+		p, err := c.candidatePrice(ctx, cn)
+		if err != nil {
+			return 0.0, err
 		}
-		price += compatibleOfferings.Cheapest().Price
+		price += p
 	}
 	return price, nil
 }
+
+// candidatePrice returns the effective cost of the candidate from the CostModel if the CloudProvider implements one, and
+// otherwise the price of the cheapest offering that's compatible with the candidate
+func (c *consolidation) candidatePrice(ctx context.Context, cn *Candidate) (float64, error) {
+	if c.costModel != nil {
+		cost, err := c.costModel.NodeClaimCost(ctx, cn.NodeClaim)
+		if err != nil {
+			return 0.0, fmt.Errorf("getting cost of nodeclaim %s, %w", cn.NodeClaim.Name, err)
+		}
+		return cost, nil
+	}
+	compatibleOfferings := cn.instanceType.Offerings.Compatible(scheduling.NewLabelRequirements(cn.StateNode.Labels()))
+	if len(compatibleOfferings) == 0 {
+		return 0.0, fmt.Errorf("unable to determine offering for %s/%s/%s", cn.instanceType.Name, cn.capacityType, cn.zone)
+	}
+	return compatibleOfferings.Cheapest().Price, nil
+}
+
+// removeInstanceTypeOptionsByCost removes the instance type options of the replacement that don't cost less than
+// maxPrice
+func (c *consolidation) removeInstanceTypeOptionsByCost(ctx context.Context, nodeClaim *pscheduling.NodeClaim, maxPrice float64) (*pscheduling.NodeClaim, error) {
+	costs, err := c.launchCosts(ctx, nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	return nodeClaim.RemoveInstanceTypeOptionsByCostAndMinValues(nodeClaim.Requirements, maxPrice, func(it *cloudprovider.InstanceType) float64 {
+		return costs[it.Name]
+	})
+}
+
+// launchCosts returns the cost of launching each of the instance type options of the replacement by name. Costs come
+// from the CostModel if the CloudProvider implements one, and otherwise from the prices of the offerings.
+func (c *consolidation) launchCosts(ctx context.Context, nodeClaim *pscheduling.NodeClaim) (map[string]float64, error) {
+	costs := map[string]float64{}
+	for _, it := range nodeClaim.InstanceTypeOptions {
+		if c.costModel == nil {
+			costs[it.Name] = it.Offerings.Available().WorstLaunchPrice(nodeClaim.Requirements)
+			continue
+		}
+		cost, err := c.costModel.LaunchCost(ctx, it, nodeClaim.Requirements)
+		if err != nil {
+			return nil, fmt.Errorf("getting launch cost of instance type %s, %w", it.Name, err)
+		}
+		costs[it.Name] = cost
+	}
+	return costs, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package disruption_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -29,6 +30,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -177,6 +179,71 @@ var _ = Describe("Consolidation", func() {
 			Expect(recorder.Calls("Unconsolidatable")).To(Equal(4))
 		})
 	})
+	Context("Scale Up Window", func() {
+		var rs *appsv1.ReplicaSet
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			rs = test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pod = test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+		})
+		It("should not consolidate a node with a pod that its workload was recently scaled up to", func() {
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			fakeClock.SetTime(pod.CreationTimestamp.Time)
+			// The workload existed long before the window, when the pod was created
+			kubeClient := ownerCreationClient{Client: env.Client, name: rs.Name, creationTimestamp: metav1.NewTime(pod.CreationTimestamp.Add(-time.Hour))}
+
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationScaleUpWindow: lo.ToPtr(time.Minute)}))
+			ExpectSingletonReconciled(ctx, disruption.NewController(fakeClock, kubeClient, prov, cloudProvider, recorder, cluster, queue))
+			// single and multi node consolidation each fire the event for the node and the nodeclaim
+			Expect(recorder.Calls("Unconsolidatable")).To(Equal(4))
+			ExpectTaintedNodeCount(ctx, env.Client, 0)
+		})
+		It("should consolidate a node with a pod that its workload was scaled up to before the window", func() {
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			fakeClock.SetTime(pod.CreationTimestamp.Time)
+			kubeClient := ownerCreationClient{Client: env.Client, name: rs.Name, creationTimestamp: metav1.NewTime(pod.CreationTimestamp.Add(-time.Hour))}
+			// The window ends before consolidation runs
+			fakeClock.Step(2 * time.Minute)
+
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationScaleUpWindow: lo.ToPtr(time.Minute)}))
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruption.NewController(fakeClock, kubeClient, prov, cloudProvider, recorder, cluster, queue))
+			wg.Wait()
+			Expect(recorder.Calls("Unconsolidatable")).To(Equal(0))
+		})
+		It("should consolidate a node with the pods of a new workload", func() {
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationScaleUpWindow: lo.ToPtr(time.Minute)}))
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+			Expect(recorder.Calls("Unconsolidatable")).To(Equal(0))
+		})
+	})
 	Context("Metrics", func() {
 		It("should correctly report eligible nodes", func() {
 			pod := test.Pod(test.PodOptions{
@@ -867,6 +934,152 @@ var _ = Describe("Consolidation", func() {
 			Expect(singleConsolidation.IsConsolidated()).To(BeFalse())
 		})
 	})
+	Context("Cost Model", func() {
+		var costModelCloudProvider *fake.CostModelCloudProvider
+		var costModelDisruptionController *disruption.Controller
+		BeforeEach(func() {
+			costModelCloudProvider = fake.NewCostModelCloudProvider(cloudProvider)
+			costModelDisruptionController = disruption.NewController(fakeClock, env.Client, prov, costModelCloudProvider, recorder, cluster, queue)
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			ExpectApplied(ctx, env.Client, rs, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			fakeClock.Step(10 * time.Minute)
+		})
+		It("should replace a node with a cheaper node when its effective cost is its offering price", func() {
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectSingletonReconciled(ctx, costModelDisruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Name).ToNot(Equal(nodeClaim.Name))
+			ExpectNotFound(ctx, env.Client, nodeClaim, node)
+		})
+		It("should not replace a node that's already paid for", func() {
+			costModelCloudProvider.NodeClaimCosts[nodeClaim.Name] = 0
+
+			ExpectSingletonReconciled(ctx, costModelDisruptionController)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, node)
+		})
+		It("should not replace a node with instance types whose effective launch cost is higher", func() {
+			for _, it := range cloudProvider.InstanceTypes {
+				costModelCloudProvider.LaunchCosts[it.Name] = mostExpensiveOffering.Price
+			}
+
+			ExpectSingletonReconciled(ctx, costModelDisruptionController)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, node)
+		})
+	})
+	Context("Price Changes", func() {
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			newInstanceType := func(name string, price float64) *cloudprovider.InstanceType {
+				return fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: name,
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:  resource.MustParse("4"),
+						corev1.ResourcePods: resource.MustParse("10"),
+					},
+					Offerings: []cloudprovider.Offering{{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{
+							v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+							corev1.LabelTopologyZone: "test-zone-1",
+						}),
+						Price:     price,
+						Available: true,
+					}},
+				})
+			}
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{newInstanceType("current", 1.0), newInstanceType("alternative", 2.0)}
+			cloudProvider.PriceSchedule = fake.NewPriceSchedule(fakeClock)
+			for _, labels := range []map[string]string{nodeClaim.Labels, node.Labels} {
+				labels[corev1.LabelInstanceTypeStable] = "current"
+				labels[v1.CapacityTypeLabelKey] = v1.CapacityTypeOnDemand
+				labels[corev1.LabelTopologyZone] = "test-zone-1"
+			}
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pod = test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			})
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			// the node is the cheapest that the pod fits on, so it isn't consolidated until prices move
+			ExpectSingletonReconciled(ctx, disruptionController)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should replace a node once its price rises above a cheaper alternative", func() {
+			cloudProvider.PriceSchedule.After(time.Hour, "current", nil, 3.0)
+			Expect(cloudProvider.PriceSchedule.Advance(fakeClock)).To(BeTrue())
+			cluster.MarkUnconsolidated()
+
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+			ExpectSingletonReconciled(ctx, queue)
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...).Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf("alternative"))
+			ExpectNotFound(ctx, env.Client, nodeClaim, node)
+		})
+		It("should not replace a node when its price rises but stays below the alternatives", func() {
+			cloudProvider.PriceSchedule.After(time.Hour, "current", nil, 1.5)
+			Expect(cloudProvider.PriceSchedule.Advance(fakeClock)).To(BeTrue())
+			Expect(cloudProvider.PriceSchedule.Advance(fakeClock)).To(BeFalse())
+			cluster.MarkUnconsolidated()
+
+			ExpectSingletonReconciled(ctx, disruptionController)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectTaintedNodeCount(ctx, env.Client, 0)
+		})
+	})
 	Context("Replace", func() {
 		DescribeTable("can replace node",
 			func(spotToSpot bool) {
@@ -2562,6 +2775,128 @@ var _ = Describe("Consolidation", func() {
 			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
 			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
 		})
+		It("can delete nodes, considers karpenter.sh/emptydir-protection on pods", func() {
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			// Protect this pod's scratch data from being disrupted with karpenter.sh/emptydir-protection
+			pods[2].Annotations = lo.Assign(pods[2].Annotations, map[string]string{v1.EmptyDirProtectionAnnotationKey: "4h"})
+			pods[2].Spec.Volumes = append(pods[2].Spec.Volumes, corev1.Volume{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodePool)
+			ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+
+			// bind pods to node
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[1])
+
+			// inform cluster state about nodes and nodeClaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{nodes[0], nodes[1]}, []*v1.NodeClaim{nodeClaims[0], nodeClaims[1]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0])
+
+			// we should delete the node without the protected pod
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
+		It("can delete nodes, considers job pods that are about to finish", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{JobCompletionWindow: lo.ToPtr(10 * time.Minute)}))
+			rs := test.ReplicaSet()
+			job := &batchv1.Job{
+				ObjectMeta: test.ObjectMeta(),
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers:    []corev1.Container{{Name: "job", Image: "job"}},
+						},
+					},
+				},
+			}
+			ExpectApplied(ctx, env.Client, rs, job)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(job), job)).To(Succeed())
+
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			// This pod's active deadline elapses within the job completion window once the clock is stepped
+			pods[2].OwnerReferences = []metav1.OwnerReference{
+				{
+					APIVersion:         "batch/v1",
+					Kind:               "Job",
+					Name:               job.Name,
+					UID:                job.UID,
+					Controller:         lo.ToPtr(true),
+					BlockOwnerDeletion: lo.ToPtr(true),
+				},
+			}
+			pods[2].Spec.ActiveDeadlineSeconds = lo.ToPtr(int64((65 * time.Minute).Seconds()))
+			pods[2].Status.StartTime = &metav1.Time{Time: fakeClock.Now().Add(-50 * time.Minute)}
+
+			ExpectApplied(ctx, env.Client, pods[0], pods[1], pods[2], nodePool)
+			ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+
+			// bind pods to node
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[1])
+
+			// inform cluster state about nodes and nodeClaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{nodes[0], nodes[1]}, []*v1.NodeClaim{nodeClaims[0], nodeClaims[1]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0])
+
+			// we should delete the node without the job pod that's about to finish
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
 		It("does not consolidate nodes with karpenter.sh/do-not-disrupt on pods when the NodePool's TerminationGracePeriod is not nil", func() {
 			// create our RS so we can link a pod to it
 			rs := test.ReplicaSet()
@@ -4380,3 +4715,21 @@ var _ = Describe("Consolidation", func() {
 		})
 	})
 })
+
+// ownerCreationClient reports a creation timestamp for the object with the given name, since the API server sets
+// creation timestamps when objects are created
+type ownerCreationClient struct {
+	client.Client
+	name              string
+	creationTimestamp metav1.Time
+}
+
+func (c ownerCreationClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	if key.Name == c.name {
+		obj.SetCreationTimestamp(c.creationTimestamp)
+	}
+	return nil
+}
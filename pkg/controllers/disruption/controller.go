@@ -28,8 +28,10 @@ import (
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,6 +41,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
@@ -62,6 +65,7 @@ type Controller struct {
 	methods       []Method
 	mu            sync.Mutex
 	lastRun       map[string]time.Time
+	dampening     dampening
 }
 
 // pollingPeriod that we inspect cluster to look for opportunities to disrupt
@@ -70,8 +74,6 @@ const pollingPeriod = 10 * time.Second
 func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provisioning.Provisioner,
 	cp cloudprovider.CloudProvider, recorder events.Recorder, cluster *state.Cluster, queue *orchestration.Queue,
 ) *Controller {
-	c := MakeConsolidation(clk, cluster, kubeClient, provisioner, cp, recorder, queue)
-
 	return &Controller{
 		queue:         queue,
 		clock:         clk,
@@ -81,16 +83,28 @@ func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provi
 		recorder:      recorder,
 		cloudProvider: cp,
 		lastRun:       map[string]time.Time{},
-		methods: []Method{
-			// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
-			NewDrift(kubeClient, cluster, provisioner, recorder),
-			// Delete any empty NodeClaims as there is zero cost in terms of disruption.
-			NewEmptiness(c),
-			// Attempt to identify multiple NodeClaims that we can consolidate simultaneously to reduce pod churn
-			NewMultiNodeConsolidation(c),
-			// And finally fall back our single NodeClaim consolidation to further reduce cluster cost.
-			NewSingleNodeConsolidation(c),
-		},
+		methods:       NewMethods(clk, cluster, kubeClient, provisioner, cp, recorder, queue),
+	}
+}
+
+// NewMethods returns the disruption methods in the order that they're attempted. Only the first method that computes
+// a command disrupts nodes.
+func NewMethods(clk clock.Clock, cluster *state.Cluster, kubeClient client.Client, provisioner *provisioning.Provisioner,
+	cp cloudprovider.CloudProvider, recorder events.Recorder, queue *orchestration.Queue,
+) []Method {
+	return newMethods(MakeConsolidation(clk, cluster, kubeClient, provisioner, cp, recorder, queue))
+}
+
+func newMethods(c consolidation) []Method {
+	return []Method{
+		// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
+		NewDrift(c.clock, c.kubeClient, c.cluster, c.provisioner, c.recorder),
+		// Delete any empty NodeClaims as there is zero cost in terms of disruption.
+		NewEmptiness(c),
+		// Attempt to identify multiple NodeClaims that we can consolidate simultaneously to reduce pod churn
+		NewMultiNodeConsolidation(c),
+		// And finally fall back our single NodeClaim consolidation to further reduce cluster cost.
+		NewSingleNodeConsolidation(c),
 	}
 }
 
@@ -121,9 +135,10 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
 
-	// Karpenter taints nodes with a karpenter.sh/disruption taint as part of the disruption process while it progresses in memory.
-	// If Karpenter restarts or fails with an error during a disruption action, some nodes can be left tainted.
-	// Idempotently remove this taint from candidates that are not in the orchestration queue before continuing.
+	// Karpenter taints and locks nodes with a karpenter.sh/disruption taint and a karpenter.sh/disruption-lock annotation as
+	// part of the disruption process while it progresses in memory. If Karpenter restarts or fails with an error during a
+	// disruption action, some nodes can be left tainted and locked. Idempotently remove the taint and the lock from
+	// candidates that are not in the orchestration queue before continuing.
 	outdatedNodes := lo.Filter(c.cluster.Nodes(), func(s *state.StateNode, _ int) bool {
 		return !c.queue.HasAny(s.ProviderID()) && !s.Deleted()
 	})
@@ -139,6 +154,17 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		}
 		return reconcile.Result{}, fmt.Errorf("removing %s condition from nodeclaims, %w", v1.ConditionTypeDisruptionReason, err)
 	}
+	if err := state.RequireDisruptionLock(ctx, c.kubeClient, "", time.Time{}, outdatedNodes...); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("unlocking nodes for disruption, %w", err)
+	}
+
+	// Pause voluntary disruption while the churn from recent disruptions settles
+	if c.dampened(ctx) {
+		return reconcile.Result{RequeueAfter: pollingPeriod}, nil
+	}
 
 	// Attempt different disruption methods. We'll only let one method perform an action
 	for _, m := range c.methods {
@@ -164,22 +190,28 @@ func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, erro
 		metrics.ReasonLabel:    strings.ToLower(string(disruption.Reason())),
 		consolidationTypeLabel: disruption.ConsolidationType(),
 	})()
-	candidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue)
+	candidates, blocked, err := getCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue)
 	if err != nil {
 		return false, fmt.Errorf("determining candidates, %w", err)
 	}
+	// Nodes that were cordoned for this reason are waiting for another controller or an operator to delete them
+	candidates = lo.Reject(candidates, func(c *Candidate, _ int) bool {
+		return c.Labels()[v1.DisruptionCordonedLabelKey] == string(disruption.Reason())
+	})
 	EligibleNodes.Set(float64(len(candidates)), map[string]string{
 		metrics.ReasonLabel: strings.ToLower(string(disruption.Reason())),
 	})
 
 	// If there are no candidates, move to the next disruption
 	if len(candidates) == 0 {
+		blocked.record(strings.ToLower(string(disruption.Reason())))
 		return false, nil
 	}
 	disruptionBudgetMapping, err := BuildDisruptionBudgetMapping(ctx, c.cluster, c.clock, c.kubeClient, c.cloudProvider, c.recorder, disruption.Reason())
 	if err != nil {
 		return false, fmt.Errorf("building disruption budgets, %w", err)
 	}
+	c.recordBlockedByBudget(disruption, blocked, disruptionBudgetMapping, candidates)
 	// Determine the disruption action
 	cmd, schedulingResults, err := disruption.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
 	if err != nil {
@@ -188,6 +220,14 @@ func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, erro
 	if cmd.Decision() == NoOpDecision {
 		return false, nil
 	}
+	// NodePools can ask for the nodes that are disrupted for this reason to only be cordoned, in which case none of the
+	// command's replacements are launched. The command's other candidates are reconsidered in the next loop.
+	if cordoned := lo.Filter(cmd.candidates, func(c *Candidate, _ int) bool { return c.nodePool.CordonsFor(disruption.Reason()) }); len(cordoned) > 0 {
+		if err := c.cordon(ctx, disruption, cordoned...); err != nil {
+			return false, fmt.Errorf("cordoning candidates, %w", err)
+		}
+		return true, nil
+	}
 
 	// Attempt to disrupt
 	if err := c.executeCommand(ctx, disruption, cmd, schedulingResults); err != nil {
@@ -205,7 +245,7 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	log.FromContext(ctx).WithValues("command-id", commandID, "reason", strings.ToLower(string(m.Reason()))).Info(fmt.Sprintf("disrupting nodeclaim(s) via %s", cmd))
 
 	// Cordon the old nodes before we launch the replacements to prevent new pods from scheduling to the old nodes
-	if err := c.MarkDisrupted(ctx, m, cmd.candidates...); err != nil {
+	if err := c.MarkDisrupted(ctx, m, string(commandID), cmd.candidates...); err != nil {
 		return fmt.Errorf("marking disrupted (command-id: %s), %w", commandID, err)
 	}
 
@@ -238,14 +278,79 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	}
 
 	// An action is only performed and pods/nodes are only disrupted after a successful add to the queue
+	c.dampening.record(c.clock.Now(), len(cmd.candidates))
 	DecisionsPerformedTotal.Inc(map[string]string{
 		decisionLabel:          string(cmd.Decision()),
 		metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
 		consolidationTypeLabel: m.ConsolidationType(),
 	})
+	for _, nodePool := range lo.Uniq(lo.Map(cmd.candidates, func(c *Candidate, _ int) string { return c.nodePool.Name })) {
+		NodePoolDisruptionDecisionsTotal.Inc(map[string]string{
+			metrics.NodePoolLabel:  nodePool,
+			metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
+			consolidationTypeLabel: m.ConsolidationType(),
+			decisionLabel:          executedDecision,
+		})
+	}
 	return nil
 }
 
+// cordon marks the candidates as unschedulable and labels them with the disruption reason, leaving their deletion to
+// another controller or an operator
+func (c *Controller) cordon(ctx context.Context, m Method, candidates ...*Candidate) error {
+	reason := string(m.Reason())
+	if err := multierr.Combine(lo.Map(candidates, func(candidate *Candidate, _ int) error {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKeyFromObject(candidate.Node), node); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		stored := node.DeepCopy()
+		node.Spec.Unschedulable = true
+		node.Labels = lo.Assign(node.Labels, map[string]string{v1.DisruptionCordonedLabelKey: reason})
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		// Update cluster state immediately so that the cordoned node counts against the NodePool's disruption budgets
+		// in the next loop
+		if err := c.cluster.UpdateNode(ctx, node); err != nil {
+			return fmt.Errorf("updating cluster state, %w", err)
+		}
+		log.FromContext(ctx).WithValues("Node", klog.KObj(node), "reason", strings.ToLower(reason)).Info("cordoned node")
+		c.recorder.Publish(disruptionevents.Cordoned(node, candidate.NodeClaim, reason)...)
+		return nil
+	})...); err != nil {
+		return err
+	}
+	DecisionsPerformedTotal.Inc(map[string]string{
+		decisionLabel:          string(CordonDecision),
+		metrics.ReasonLabel:    strings.ToLower(reason),
+		consolidationTypeLabel: m.ConsolidationType(),
+	})
+	return nil
+}
+
+// recordBlockedByBudget records the candidates that can't be disrupted because their NodePool's disruption budget
+// doesn't allow any more disruptions, along with the candidates that were blocked by other causes
+func (c *Controller) recordBlockedByBudget(m Method, blocked blockedCandidates, disruptionBudgetMapping map[string]int, candidates []*Candidate) {
+	for _, candidate := range candidates {
+		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+			blocked.add(candidate.nodePool.Name, blockedByBudget)
+		}
+	}
+	blocked.record(strings.ToLower(string(m.Reason())))
+	for nodePool, causes := range blocked {
+		if causes[blockedByBudget] == 0 {
+			continue
+		}
+		NodePoolDisruptionDecisionsTotal.Inc(map[string]string{
+			metrics.NodePoolLabel:  nodePool,
+			metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
+			consolidationTypeLabel: m.ConsolidationType(),
+			decisionLabel:          blockedDecision,
+		})
+	}
+}
+
 // createReplacementNodeClaims creates replacement NodeClaims
 func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method, cmd Command) ([]string, error) {
 	nodeClaimNames, err := c.provisioner.CreateNodeClaims(ctx, cmd.replacements, provisioning.WithReason(strings.ToLower(string(m.Reason()))))
@@ -259,13 +364,16 @@ func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method,
 	return nodeClaimNames, nil
 }
 
-func (c *Controller) MarkDisrupted(ctx context.Context, m Method, candidates ...*Candidate) error {
+func (c *Controller) MarkDisrupted(ctx context.Context, m Method, commandID string, candidates ...*Candidate) error {
 	stateNodes := lo.Map(candidates, func(c *Candidate, _ int) *state.StateNode {
 		return c.StateNode
 	})
 	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, true, stateNodes...); err != nil {
 		return fmt.Errorf("tainting nodes with %s: %w", pretty.Taint(v1.DisruptedNoScheduleTaint), err)
 	}
+	if err := state.RequireDisruptionLock(ctx, c.kubeClient, commandID, c.clock.Now().Add(orchestration.MaxRetryDuration), stateNodes...); err != nil {
+		return fmt.Errorf("locking nodes for disruption, %w", err)
+	}
 
 	providerIDs := lo.Map(candidates, func(c *Candidate, _ int) string { return c.ProviderID() })
 	c.cluster.MarkForDeletion(providerIDs...)
@@ -278,7 +386,7 @@ func (c *Controller) MarkDisrupted(ctx context.Context, m Method, candidates ...
 			return client.IgnoreNotFound(err)
 		}
 		stored := nodeClaim.DeepCopy()
-		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionReason, v1.ConditionTypeDisruptionReason, string(m.Reason()))
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionReason, string(orchestration.PhaseWaitingForReplacements), string(m.Reason()))
 		return client.IgnoreNotFound(c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)))
 	})...)
 }
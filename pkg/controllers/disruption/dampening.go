@@ -0,0 +1,90 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// dampening tracks the nodes that were recently disrupted so that voluntary disruption can be paused once too many of
+// the cluster's nodes were disrupted within a window. Disrupting nodes reschedules their pods, which can cause workload
+// autoscalers to scale out and back in, which in turn creates new consolidation opportunities. Pausing disruption
+// until the churn drops breaks this feedback loop.
+type dampening struct {
+	mu sync.Mutex
+	// disruptedAt are the times that nodes were disrupted at, oldest first
+	disruptedAt []time.Time
+}
+
+// record records that the number of nodes were disrupted at the time
+func (d *dampening) record(now time.Time, nodes int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for range nodes {
+		d.disruptedAt = append(d.disruptedAt, now)
+	}
+}
+
+// disrupted returns the number of nodes that were disrupted within the window, forgetting those that weren't
+func (d *dampening) disrupted(now time.Time, window time.Duration) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, i, _ := lo.FindIndexOf(d.disruptedAt, func(t time.Time) bool { return now.Sub(t) < window })
+	if i < 0 {
+		i = len(d.disruptedAt)
+	}
+	d.disruptedAt = d.disruptedAt[i:]
+	return len(d.disruptedAt)
+}
+
+// dampened returns true if more than the dampening threshold of the cluster's managed nodes were disrupted within the
+// dampening window, in which case voluntary disruption is paused
+func (c *Controller) dampened(ctx context.Context) bool {
+	threshold, window := options.FromContext(ctx).DisruptionDampeningThreshold, options.FromContext(ctx).DisruptionDampeningWindow
+	if threshold == 0 {
+		Dampened.Set(0, nil)
+		return false
+	}
+	disrupted := c.dampening.disrupted(c.clock.Now(), window)
+	nodes := lo.CountBy(c.cluster.Nodes(), func(n *state.StateNode) bool { return n.Managed() })
+	dampened := nodes > 0 && disrupted*100 > threshold*nodes
+	Dampened.Set(lo.Ternary[float64](dampened, 1, 0), nil)
+	if !dampened {
+		return false
+	}
+	log.FromContext(ctx).WithValues("disrupted", disrupted, "nodes", nodes, "window", window).V(1).Info("pausing voluntary disruption, too many nodes were recently disrupted")
+	nodePools, err := nodepoolutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	if err != nil {
+		log.FromContext(ctx).Error(fmt.Errorf("listing nodepools, %w", err), "failed publishing disruption dampening events")
+		return true
+	}
+	for _, nodePool := range nodePools {
+		c.recorder.Publish(disruptionevents.NodePoolDisruptionDampened(nodePool, disrupted, nodes, window))
+	}
+	return true
+}
@@ -19,8 +19,10 @@ package disruption
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
@@ -31,18 +33,22 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	disruptionutils "sigs.k8s.io/karpenter/pkg/utils/disruption"
 )
 
 // Drift is a subreconciler that deletes drifted candidates.
 type Drift struct {
+	clock       clock.Clock
 	kubeClient  client.Client
 	cluster     *state.Cluster
 	provisioner *provisioning.Provisioner
 	recorder    events.Recorder
 }
 
-func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Drift {
+func NewDrift(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Drift {
 	return &Drift{
+		clock:       clk,
 		kubeClient:  kubeClient,
 		cluster:     cluster,
 		provisioner: provisioner,
@@ -52,7 +58,23 @@ func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *pro
 
 // ShouldDisrupt is a predicate used to filter candidates
 func (d *Drift) ShouldDisrupt(ctx context.Context, c *Candidate) bool {
-	return c.NodeClaim.StatusConditions().Get(string(d.Reason())).IsTrue()
+	// Drifted nodes aren't replaced while the NodePool's rollout is paused
+	if rollout := c.nodePool.Spec.Disruption.Rollout; rollout != nil && rollout.Paused {
+		return false
+	}
+	if !c.NodeClaim.StatusConditions().Get(string(d.Reason())).IsTrue() {
+		return false
+	}
+	// Drifted nodes aren't replaced while the NodePool's spec has an impact that hasn't been accepted
+	if options.FromContext(ctx).FeatureGates.NodePoolImpact && c.nodePool.StatusConditions().Get(v1.ConditionTypeImpactAccepted).IsFalse() {
+		return false
+	}
+	// Drifted nodes that host long-running pods aren't replaced until they're approved
+	if disruptionutils.IsAwaitingApproval(d.clock, c.nodePool, c.Annotations(), c.reschedulablePods) {
+		d.recorder.Publish(disruptionevents.Blocked(c.Node, c.NodeClaim, fmt.Sprintf("Node hosts long-running pods and requires the %s annotation", v1.DisruptionConfirmedAnnotationKey))...)
+		return false
+	}
+	return true
 }
 
 // ComputeCommand generates a disruption command given candidates
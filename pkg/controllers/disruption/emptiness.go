@@ -94,10 +94,12 @@ func (e *Emptiness) ComputeCommand(ctx context.Context, disruptionBudgetMapping
 	// Empty Node Consolidation doesn't use Validation as we get to take advantage of cluster.IsNodeNominated.  This
 	// lets us avoid a scheduling simulation (which is performed periodically while pending pods exist and drives
 	// cluster.IsNodeNominated already).
-	select {
-	case <-ctx.Done():
-		return Command{}, scheduling.Results{}, errors.New("interrupted")
-	case <-e.clock.After(consolidationTTL):
+	if e.validationTTL > 0 {
+		select {
+		case <-ctx.Done():
+			return Command{}, scheduling.Results{}, errors.New("interrupted")
+		case <-e.clock.After(e.validationTTL):
+		}
 	}
 
 	v := NewValidation(e.clock, e.cluster, e.kubeClient, e.provisioner, e.cloudProvider, e.recorder, e.queue, e.Reason())
@@ -67,6 +67,51 @@ func Terminating(node *corev1.Node, nodeClaim *v1.NodeClaim, reason string) []ev
 	}
 }
 
+// Cordoned is an event that informs the user that a Node was cordoned rather than disrupted, since its NodePool only
+// cordons the nodes that it disrupts for the reason
+func Cordoned(node *corev1.Node, nodeClaim *v1.NodeClaim, reason string) []events.Event {
+	return []events.Event{
+		{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionCordoned",
+			Message:        fmt.Sprintf("Cordoned Node: %s", cases.Title(language.Und, cases.NoLower).String(reason)),
+			DedupeValues:   []string{string(node.UID), reason},
+		},
+		{
+			InvolvedObject: nodeClaim,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionCordoned",
+			Message:        fmt.Sprintf("Cordoned NodeClaim: %s", cases.Title(language.Und, cases.NoLower).String(reason)),
+			DedupeValues:   []string{string(nodeClaim.UID), reason},
+		},
+	}
+}
+
+// Cancelled is an event that informs the user that an in-flight disruption of a NodeClaim/Node combination was
+// cancelled since the NodeClaim/Node became ineligible for disruption
+func Cancelled(node *corev1.Node, nodeClaim *v1.NodeClaim, msg string) (evs []events.Event) {
+	if node != nil {
+		evs = append(evs, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionCancelled",
+			Message:        fmt.Sprintf("Cancelled disruption: %s", msg),
+			DedupeValues:   []string{string(node.UID)},
+		})
+	}
+	if nodeClaim != nil {
+		evs = append(evs, events.Event{
+			InvolvedObject: nodeClaim,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionCancelled",
+			Message:        fmt.Sprintf("Cancelled disruption: %s", msg),
+			DedupeValues:   []string{string(nodeClaim.UID)},
+		})
+	}
+	return evs
+}
+
 // Unconsolidatable is an event that informs the user that a NodeClaim/Node combination cannot be consolidated
 // due to the state of the NodeClaim/Node or due to some state of the pods that are scheduled to the NodeClaim/Node
 func Unconsolidatable(node *corev1.Node, nodeClaim *v1.NodeClaim, msg string) []events.Event {
@@ -136,3 +181,16 @@ func NodePoolBlocked(nodePool *v1.NodePool) events.Event {
 		DedupeTimeout: 1 * time.Minute,
 	}
 }
+
+// NodePoolDisruptionDampened is an event that informs the user that voluntary disruption is paused since too many nodes
+// were recently disrupted
+func NodePoolDisruptionDampened(nodePool *v1.NodePool, disrupted, nodes int, window time.Duration) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DisruptionDampened",
+		Message:        fmt.Sprintf("Voluntary disruption is paused since %d of %d nodes were disrupted in the last %s", disrupted, nodes, window),
+		DedupeValues:   []string{string(nodePool.UID)},
+		DedupeTimeout:  1 * time.Minute,
+	}
+}
@@ -144,20 +144,33 @@ func instanceTypesAreSubset(lhs []*cloudprovider.InstanceType, rhs []*cloudprovi
 func GetCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
 	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
 ) ([]*Candidate, error) {
+	candidates, _, err := getCandidates(ctx, cluster, kubeClient, recorder, clk, cloudProvider, shouldDisrupt, disruptionClass, queue)
+	return candidates, err
+}
+
+// getCandidates returns the candidates like GetCandidates, along with the nodes of each nodepool that couldn't be
+// candidates because of a cause that users control
+func getCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
+	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
+) ([]*Candidate, blockedCandidates, error) {
 	nodePoolMap, nodePoolToInstanceTypesMap, err := BuildNodePoolMap(ctx, kubeClient, cloudProvider)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	pdbs, err := pdb.NewLimits(ctx, clk, kubeClient)
 	if err != nil {
-		return nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+		return nil, nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
 	}
+	blocked := newBlockedCandidates(lo.Keys(nodePoolMap)...)
 	candidates := lo.FilterMap(cluster.Nodes(), func(n *state.StateNode, _ int) (*Candidate, bool) {
 		cn, e := NewCandidate(ctx, kubeClient, recorder, clk, n, pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue, disruptionClass)
+		if cause, ok := state.GetDisruptionBlockedCause(e); ok {
+			blocked.add(n.Labels()[v1.NodePoolLabelKey], string(cause))
+		}
 		return cn, e == nil
 	})
 	// Filter only the valid candidates that we should disrupt
-	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDisrupt(ctx, c) }), nil
+	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDisrupt(ctx, c) }), blocked, nil
 }
 
 // BuildNodePoolMap builds a provName -> nodePool map and a provName -> instanceName -> instance type map
@@ -223,7 +236,9 @@ func BuildDisruptionBudgetMapping(ctx context.Context, cluster *state.Cluster, c
 		// If the node satisfies one of the following, we subtract it from the allowed disruptions.
 		// 1. Has a NotReady conditiion
 		// 2. Is marked as disrupting
-		if cond := nodeutils.GetCondition(node.Node, corev1.NodeReady); cond.Status != corev1.ConditionTrue || node.MarkedForDeletion() {
+		// 3. Was cordoned for disruption, and is waiting to be deleted
+		if cond := nodeutils.GetCondition(node.Node, corev1.NodeReady); cond.Status != corev1.ConditionTrue || node.MarkedForDeletion() ||
+			node.Labels()[v1.DisruptionCordonedLabelKey] != "" {
 			disrupting[nodePool]++
 		}
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 )
 
@@ -28,8 +29,26 @@ const (
 	voluntaryDisruptionSubsystem = "voluntary_disruption"
 	decisionLabel                = "decision"
 	consolidationTypeLabel       = "consolidation_type"
+	causeLabel                   = "cause"
+
+	executedDecision = "executed"
+	blockedDecision  = "blocked"
+
+	// blockedByBudget is the cause for candidates that can't be disrupted because their NodePool's disruption budget
+	// doesn't allow any more disruptions
+	blockedByBudget = "budget"
 )
 
+// blockedCauses are all the causes that candidates can be blocked by
+var blockedCauses = []string{
+	blockedByBudget,
+	string(state.DisruptionBlockedByPDB),
+	string(state.DisruptionBlockedByDoNotDisrupt),
+	string(state.DisruptionBlockedByNomination),
+	string(state.DisruptionBlockedByLocalVolume),
+	string(state.DisruptionBlockedByJob),
+}
+
 func init() {
 	ConsolidationTimeoutsTotal.Add(0, map[string]string{consolidationTypeLabel: MultiNodeConsolidationType})
 	ConsolidationTimeoutsTotal.Add(0, map[string]string{consolidationTypeLabel: SingleNodeConsolidationType})
@@ -87,4 +106,68 @@ var (
 		},
 		[]string{metrics.NodePoolLabel, metrics.ReasonLabel},
 	)
+	NodePoolDisruptionDecisionsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodePoolSubsystem,
+			Name:      "disruption_decisions_total",
+			Help:      "Number of disruption decisions for a given NodePool. A decision is blocked when candidates of the NodePool couldn't be disrupted because its disruption budget was exhausted. Labeled by NodePool, disruption reason, consolidation type and whether the decision was executed or blocked.",
+		},
+		[]string{metrics.NodePoolLabel, metrics.ReasonLabel, consolidationTypeLabel, decisionLabel},
+	)
+	NodePoolBlockedCandidates = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodePoolSubsystem,
+			Name:      "disruption_blocked_candidates",
+			Help:      "Number of nodes for a given NodePool that are blocked from being disrupted. Labeled by NodePool, disruption reason and the cause that blocks disruption, which is one of budget, pdb, do_not_disrupt or nomination.",
+		},
+		[]string{metrics.NodePoolLabel, metrics.ReasonLabel, causeLabel},
+	)
+	Dampened = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: voluntaryDisruptionSubsystem,
+			Name:      "dampened",
+			Help:      "Whether voluntary disruption is paused since more than the disruption dampening threshold of nodes were disrupted within the dampening window. 1 if paused, 0 otherwise.",
+		},
+		[]string{},
+	)
 )
+
+// blockedCandidates counts the nodes of each NodePool that are blocked from being disrupted by each cause
+type blockedCandidates map[string]map[string]int
+
+func newBlockedCandidates(nodePools ...string) blockedCandidates {
+	b := blockedCandidates{}
+	for _, np := range nodePools {
+		b[np] = map[string]int{}
+	}
+	return b
+}
+
+func (b blockedCandidates) add(nodePool string, cause string) {
+	if nodePool == "" {
+		return
+	}
+	if _, ok := b[nodePool]; !ok {
+		b[nodePool] = map[string]int{}
+	}
+	b[nodePool][cause]++
+}
+
+// record sets the blocked candidates for the disruption reason, including the causes that don't block any nodes
+func (b blockedCandidates) record(reason string) {
+	for nodePool, causes := range b {
+		for _, cause := range blockedCauses {
+			NodePoolBlockedCandidates.Set(float64(causes[cause]), map[string]string{
+				metrics.NodePoolLabel: nodePool,
+				metrics.ReasonLabel:   reason,
+				causeLabel:            cause,
+			})
+		}
+	}
+}
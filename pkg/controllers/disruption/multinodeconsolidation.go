@@ -29,7 +29,6 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
-	scheduler "sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
 const MultiNodeConsolidationTimeoutDuration = 1 * time.Minute
@@ -95,7 +94,7 @@ func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 		return cmd, scheduling.Results{}, nil
 	}
 
-	if err := NewValidation(m.clock, m.cluster, m.kubeClient, m.provisioner, m.cloudProvider, m.recorder, m.queue, m.Reason()).IsValid(ctx, cmd, consolidationTTL); err != nil {
+	if err := NewValidation(m.clock, m.cluster, m.kubeClient, m.provisioner, m.cloudProvider, m.recorder, m.queue, m.Reason()).IsValid(ctx, cmd, m.validationTTL); err != nil {
 		if IsValidationError(err) {
 			log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning multi-node consolidation attempt due to pod churn, command is no longer valid, %s", cmd))
 			return Command{}, scheduling.Results{}, nil
@@ -144,7 +143,7 @@ func (m *MultiNodeConsolidation) firstNConsolidationOption(ctx context.Context,
 		// required
 		replacementHasValidInstanceTypes := false
 		if cmd.Decision() == ReplaceDecision {
-			cmd.replacements[0].InstanceTypeOptions, err = filterOutSameType(cmd.replacements[0], candidatesToConsolidate)
+			cmd.replacements[0].InstanceTypeOptions, err = m.filterOutSameType(ctx, cmd.replacements[0], candidatesToConsolidate)
 			replacementHasValidInstanceTypes = len(cmd.replacements[0].InstanceTypeOptions) > 0 && err == nil
 		}
 
@@ -177,22 +176,22 @@ func (m *MultiNodeConsolidation) firstNConsolidationOption(ctx context.Context,
 // This code sees that t3a.small is the cheapest type in both lists and filters it and anything more expensive out
 // leaving the valid consolidation:
 // NodeClaims=[t3a.2xlarge, t3a.2xlarge, t3a.small] -> 1 of t3a.nano
-func filterOutSameType(newNodeClaim *scheduling.NodeClaim, consolidate []*Candidate) ([]*cloudprovider.InstanceType, error) {
+func (m *MultiNodeConsolidation) filterOutSameType(ctx context.Context, newNodeClaim *scheduling.NodeClaim, consolidate []*Candidate) ([]*cloudprovider.InstanceType, error) {
 	existingInstanceTypes := sets.New[string]()
 	pricesByInstanceType := map[string]float64{}
 
 	// get the price of the cheapest node that we currently are considering deleting indexed by instance type
 	for _, c := range consolidate {
 		existingInstanceTypes.Insert(c.instanceType.Name)
-		compatibleOfferings := c.instanceType.Offerings.Compatible(scheduler.NewLabelRequirements(c.StateNode.Labels()))
-		if len(compatibleOfferings) == 0 {
+		p, err := m.candidatePrice(ctx, c)
+		if err != nil {
 			continue
 		}
 		existingPrice, ok := pricesByInstanceType[c.instanceType.Name]
 		if !ok {
 			existingPrice = math.MaxFloat64
 		}
-		if p := compatibleOfferings.Cheapest().Price; p < existingPrice {
+		if p < existingPrice {
 			pricesByInstanceType[c.instanceType.Name] = p
 		}
 	}
@@ -209,7 +208,7 @@ func filterOutSameType(newNodeClaim *scheduling.NodeClaim, consolidate []*Candid
 		}
 	}
 	// swallow the error since we don't allow min values to impact reschedulability in multi node claim
-	newNodeClaim, err := newNodeClaim.RemoveInstanceTypeOptionsByPriceAndMinValues(newNodeClaim.Requirements, maxPrice)
+	newNodeClaim, err := m.removeInstanceTypeOptionsByCost(ctx, newNodeClaim, maxPrice)
 	if err != nil {
 		return nil, err
 	}
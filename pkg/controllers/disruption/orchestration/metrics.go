@@ -28,6 +28,7 @@ const (
 	voluntaryDisruptionSubsystem = "voluntary_disruption"
 	consolidationTypeLabel       = "consolidation_type"
 	decisionLabel                = "decision"
+	phaseLabel                   = "phase"
 )
 
 var (
@@ -41,4 +42,24 @@ var (
 		},
 		[]string{decisionLabel, metrics.ReasonLabel, consolidationTypeLabel},
 	)
+	DisruptionQueueCancellationsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: voluntaryDisruptionSubsystem,
+			Name:      "queue_cancellations_total",
+			Help:      "The number of times that an enqueued disruption decision was cancelled because a candidate became ineligible for disruption. Labeled by disruption method.",
+		},
+		[]string{decisionLabel, metrics.ReasonLabel, consolidationTypeLabel},
+	)
+	DisruptionQueueCommands = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: voluntaryDisruptionSubsystem,
+			Name:      "queue_commands",
+			Help:      "The number of disruption decisions that are currently in the queue. Labeled by disruption method and the phase of the decision.",
+		},
+		[]string{phaseLabel, decisionLabel, metrics.ReasonLabel},
+	)
 )
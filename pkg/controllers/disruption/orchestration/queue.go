@@ -27,6 +27,7 @@ import (
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -44,13 +45,29 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
 const (
-	queueBaseDelay   = 1 * time.Second
-	queueMaxDelay    = 10 * time.Second
-	maxRetryDuration = 10 * time.Minute
+	queueBaseDelay = 1 * time.Second
+	queueMaxDelay  = 10 * time.Second
+	// MaxRetryDuration is how long a command is retried for before it times out. The disruption locks of the command's
+	// candidates expire after the same duration.
+	MaxRetryDuration = 10 * time.Minute
+)
+
+// Phase is the step of a disruption command that is currently being executed. The phase of a command is surfaced as
+// the reason of the DisruptionReason condition on each of its candidates' NodeClaims.
+type Phase string
+
+const (
+	// PhaseWaitingForReplacements is the phase in which the command is waiting for its replacements to initialize. A
+	// command can only be cancelled in this phase.
+	PhaseWaitingForReplacements Phase = "WaitingForReplacements"
+	// PhaseTerminatingCandidates is the phase in which the command is deleting its candidates
+	PhaseTerminatingCandidates Phase = "TerminatingCandidates"
 )
 
 type Command struct {
@@ -60,6 +77,7 @@ type Command struct {
 	id                types.UID           // used for log tracking
 	reason            v1.DisruptionReason // used for metrics
 	consolidationType string              // used for metrics
+	phase             Phase
 	lastError         error
 }
 
@@ -89,6 +107,14 @@ func (c *Command) Reason() string {
 		lo.Ternary(len(c.Replacements) > 0, "replace", "delete"))
 }
 
+func (c *Command) Phase() Phase {
+	return c.phase
+}
+
+func (c *Command) Candidates() []*state.StateNode {
+	return c.candidates
+}
+
 type UnrecoverableError struct {
 	error
 }
@@ -105,6 +131,24 @@ func IsUnrecoverableError(err error) bool {
 	return errors.As(err, &unrecoverableError)
 }
 
+// CancelledError is returned when a command is cancelled because one of its candidates became ineligible for
+// disruption while the command was waiting for its replacements
+type CancelledError struct {
+	error
+}
+
+func NewCancelledError(err error) *CancelledError {
+	return &CancelledError{error: err}
+}
+
+func IsCancelledError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var cancelledError *CancelledError
+	return errors.As(err, &cancelledError)
+}
+
 type Queue struct {
 	workqueue.RateLimitingInterface
 
@@ -150,6 +194,7 @@ func NewCommand(replacements []string, candidates []*state.StateNode, id types.U
 		reason:            reason,
 		consolidationType: consolidationType,
 		id:                id,
+		phase:             PhaseWaitingForReplacements,
 	}
 }
 
@@ -162,6 +207,7 @@ func (q *Queue) Register(_ context.Context, m manager.Manager) error {
 
 func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	ctx = injection.WithControllerName(ctx, "disruption.queue")
+	q.updateMetrics()
 
 	// Check if the queue is empty. client-go recommends not using this function to gate the subsequent
 	// get call, but since we're popping items off the queue synchronously retrying, there should be
@@ -178,9 +224,10 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	cmd := item.(*Command)
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("command-id", string(cmd.id)))
 
-	if err := q.waitOrTerminate(ctx, cmd); err != nil {
+	err := q.waitOrTerminate(ctx, cmd)
+	if err != nil {
 		// If recoverable, re-queue and try again.
-		if !IsUnrecoverableError(err) {
+		if !IsUnrecoverableError(err) && !IsCancelledError(err) {
 			// store the error that is causing us to fail, so we can bubble it up later if this times out.
 			cmd.lastError = err
 			// mark this item as done processing. This is necessary so that the RLI is able to add the item back in.
@@ -188,28 +235,51 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			q.RateLimitingInterface.AddRateLimited(cmd)
 			return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 		}
-		// If the command failed, bail on the action.
-		// 1. Emit metrics for launch failures
-		// 2. Ensure cluster state no longer thinks these nodes are deleting
+		// If the command failed or was cancelled, bail on the action.
+		// 1. Emit metrics for launch failures or the cancellation
+		// 2. Ensure cluster state no longer thinks these nodes are deleting, and unlock them
 		// 3. Remove it from the Queue's internal data structure
-		failedLaunches := lo.Filter(cmd.Replacements, func(r Replacement, _ int) bool {
-			return !r.Initialized
-		})
-		DisruptionQueueFailuresTotal.Add(float64(len(failedLaunches)), map[string]string{
-			decisionLabel:          cmd.Decision(),
-			metrics.ReasonLabel:    pretty.ToSnakeCase(string(cmd.reason)),
-			consolidationTypeLabel: cmd.consolidationType,
-		})
-		multiErr := multierr.Combine(err, cmd.lastError, state.RequireNoScheduleTaint(ctx, q.kubeClient, false, cmd.candidates...))
-		multiErr = multierr.Combine(multiErr, state.ClearNodeClaimsCondition(ctx, q.kubeClient, v1.ConditionTypeDisruptionReason, cmd.candidates...))
-		// Log the error
-		log.FromContext(ctx).WithValues("nodes", strings.Join(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string {
+		// Replacements that were already launched for a cancelled command are left to be consolidated.
+		if IsCancelledError(err) {
+			DisruptionQueueCancellationsTotal.Inc(map[string]string{
+				decisionLabel:          cmd.Decision(),
+				metrics.ReasonLabel:    pretty.ToSnakeCase(string(cmd.reason)),
+				consolidationTypeLabel: cmd.consolidationType,
+			})
+			for _, candidate := range cmd.candidates {
+				q.recorder.Publish(disruptionevents.Cancelled(candidate.Node, candidate.NodeClaim, err.Error())...)
+			}
+		} else {
+			failedLaunches := lo.Filter(cmd.Replacements, func(r Replacement, _ int) bool {
+				return !r.Initialized
+			})
+			DisruptionQueueFailuresTotal.Add(float64(len(failedLaunches)), map[string]string{
+				decisionLabel:          cmd.Decision(),
+				metrics.ReasonLabel:    pretty.ToSnakeCase(string(cmd.reason)),
+				consolidationTypeLabel: cmd.consolidationType,
+			})
+		}
+		multiErr := multierr.Combine(state.RequireNoScheduleTaint(ctx, q.kubeClient, false, cmd.candidates...),
+			state.ClearNodeClaimsCondition(ctx, q.kubeClient, v1.ConditionTypeDisruptionReason, cmd.candidates...),
+			state.RequireDisruptionLock(ctx, q.kubeClient, "", time.Time{}, cmd.candidates...))
+		nodes := strings.Join(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string {
 			return s.Name()
-		}), ",")).Error(multiErr, "failed terminating nodes while executing a disruption command")
+		}), ",")
+		if IsCancelledError(err) {
+			log.FromContext(ctx).WithValues("nodes", nodes, "reason", err.Error()).Info("cancelled disruption command")
+			if multiErr != nil {
+				log.FromContext(ctx).WithValues("nodes", nodes).Error(multiErr, "failed cleaning up cancelled disruption command")
+			}
+		} else {
+			log.FromContext(ctx).WithValues("nodes", nodes).Error(multierr.Combine(err, cmd.lastError, multiErr),
+				"failed terminating nodes while executing a disruption command")
+		}
 	}
 	// If command is complete, remove command from queue.
 	q.Remove(cmd)
-	log.FromContext(ctx).V(1).Info("command succeeded")
+	if err == nil {
+		log.FromContext(ctx).V(1).Info("command succeeded")
+	}
 	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 }
 
@@ -219,9 +289,19 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 // timed out, this will return false.
 // nolint:gocyclo
 func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
-	if q.clock.Since(cmd.timeAdded) > maxRetryDuration {
+	if q.clock.Since(cmd.timeAdded) > MaxRetryDuration {
 		return NewUnrecoverableError(fmt.Errorf("command reached timeout after %s", q.clock.Since(cmd.timeAdded)))
 	}
+	// Once we've started terminating candidates, the command can no longer be cancelled
+	if cmd.phase == PhaseWaitingForReplacements {
+		reason, err := q.cancellationReason(ctx, cmd)
+		if err != nil {
+			return fmt.Errorf("validating candidates, %w", err)
+		}
+		if reason != "" {
+			return NewCancelledError(errors.New(reason))
+		}
+	}
 	waitErrs := make([]error, len(cmd.Replacements))
 	for i := range cmd.Replacements {
 		// If we know the node claim is Initialized, no need to check again.
@@ -259,11 +339,14 @@ func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
 	// All replacements have been provisioned.
 	// All we need to do now is get a successful delete call for each node claim,
 	// then the termination controller will handle the eventual deletion of the nodes.
+	if err := q.setPhase(ctx, cmd, PhaseTerminatingCandidates); err != nil {
+		return fmt.Errorf("updating command phase, %w", err)
+	}
 	var multiErr error
 	for i := range cmd.candidates {
 		candidate := cmd.candidates[i]
 		q.recorder.Publish(disruptionevents.Terminating(candidate.Node, candidate.NodeClaim, cmd.Reason())...)
-		if err := q.kubeClient.Delete(ctx, candidate.NodeClaim); err != nil {
+		if err := nodeclaimutils.Delete(ctx, q.kubeClient, candidate.NodeClaim, v1.TerminationReasonForDisruption(cmd.reason)); err != nil {
 			multiErr = multierr.Append(multiErr, client.IgnoreNotFound(err))
 		} else {
 			metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
@@ -281,6 +364,74 @@ func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
 	return nil
 }
 
+// cancellationReason returns a non-empty reason if any of the command's candidates have become ineligible for disruption
+// since the command was added to the queue. Candidates become ineligible when they're nominated for a pending pod or
+// when a pod with the do-not-disrupt annotation lands on them after the command was added.
+func (q *Queue) cancellationReason(ctx context.Context, cmd *Command) (string, error) {
+	for _, candidate := range cmd.candidates {
+		if q.cluster.IsNodeNominated(candidate.ProviderID()) {
+			return fmt.Sprintf("node %q was nominated for a pending pod", candidate.Name()), nil
+		}
+		pods, err := candidate.Pods(ctx, q.kubeClient)
+		if err != nil {
+			return "", fmt.Errorf("getting pods from node, %w", err)
+		}
+		if pod, ok := lo.Find(pods, func(p *corev1.Pod) bool {
+			return !podutils.IsDisruptable(p) && !podutils.IsForceEvicted(ctx, p) && p.CreationTimestamp.Time.After(cmd.timeAdded)
+		}); ok {
+			return fmt.Sprintf("pod %q with the %q annotation scheduled to node %q", client.ObjectKeyFromObject(pod), v1.DoNotDisruptAnnotationKey, candidate.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// setPhase transitions the command to the given phase and reflects the phase on the DisruptionReason condition of
+// each of the candidates' NodeClaims
+func (q *Queue) setPhase(ctx context.Context, cmd *Command, phase Phase) error {
+	if cmd.phase == phase {
+		return nil
+	}
+	var errs error
+	for _, candidate := range cmd.candidates {
+		nodeClaim := &v1.NodeClaim{}
+		if err := q.kubeClient.Get(ctx, client.ObjectKeyFromObject(candidate.NodeClaim), nodeClaim); err != nil {
+			errs = multierr.Append(errs, client.IgnoreNotFound(err))
+			continue
+		}
+		condition := nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionReason)
+		if !condition.IsTrue() {
+			continue
+		}
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionReason, string(phase), condition.Message)
+		errs = multierr.Append(errs, client.IgnoreNotFound(q.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored))))
+	}
+	if errs != nil {
+		return errs
+	}
+	cmd.phase = phase
+	return nil
+}
+
+// updateMetrics sets the number of commands in the queue by their current phase
+func (q *Queue) updateMetrics() {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	DisruptionQueueCommands.Reset()
+	counts := map[[3]string]int{}
+	for _, cmd := range lo.Uniq(lo.Values(q.providerIDToCommand)) {
+		counts[[3]string{string(cmd.phase), cmd.Decision(), pretty.ToSnakeCase(string(cmd.reason))}]++
+	}
+	for key, count := range counts {
+		DisruptionQueueCommands.Set(float64(count), map[string]string{
+			phaseLabel:          key[0],
+			decisionLabel:       key[1],
+			metrics.ReasonLabel: key[2],
+		})
+	}
+}
+
 // Add adds commands to the Queue
 // Each command added to the queue should already be validated and ready for execution.
 func (q *Queue) Add(cmd *Command) error {
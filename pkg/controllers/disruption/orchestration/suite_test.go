@@ -81,7 +81,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	fakeClock = clock.NewFakeClock(time.Now())
 	cloudProvider = fake.NewCloudProvider()
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	recorder = test.NewEventRecorder()
@@ -290,6 +290,85 @@ var _ = Describe("Queue", func() {
 			// And expect the nodeClaim and node to be deleted
 			ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
 		})
+		It("should reflect the phase of the command on the candidates' disruption condition", func() {
+			nodeClaim1.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionReason, string(orchestration.PhaseWaitingForReplacements), "test-method")
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
+			Expect(queue.Add(cmd)).To(BeNil())
+			ExpectSingletonReconciled(ctx, queue)
+			Expect(cmd.Phase()).To(Equal(orchestration.PhaseWaitingForReplacements))
+			ExpectMetricGaugeValue(orchestration.DisruptionQueueCommands, 1, map[string]string{"phase": string(orchestration.PhaseWaitingForReplacements), "decision": "replace"})
+
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController,
+				[]*corev1.Node{replacementNode}, []*v1.NodeClaim{replacementNodeClaim})
+			ExpectSingletonReconciled(ctx, queue)
+			Expect(cmd.Phase()).To(Equal(orchestration.PhaseTerminatingCandidates))
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			condition := nodeClaim1.StatusConditions().Get(v1.ConditionTypeDisruptionReason)
+			Expect(condition.Reason).To(Equal(string(orchestration.PhaseTerminatingCandidates)))
+			Expect(condition.Message).To(Equal("test-method"))
+		})
+		Context("Cancellation", func() {
+			BeforeEach(func() {
+				// Pods' creation timestamps have a granularity of seconds, so make sure that the command is added before
+				// any of the pods are created
+				fakeClock.SetTime(time.Now().Add(-time.Minute))
+			})
+			It("should cancel a command when a do-not-disrupt pod schedules to a candidate", func() {
+				ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+				stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+				cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
+				Expect(queue.Add(cmd)).To(BeNil())
+
+				pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"}}})
+				ExpectApplied(ctx, env.Client, pod)
+				ExpectManualBinding(ctx, env.Client, pod, node1)
+
+				ExpectSingletonReconciled(ctx, queue)
+				Expect(queue.HasAny(stateNode.ProviderID())).To(BeFalse())
+				node1 = ExpectNodeExists(ctx, env.Client, node1.Name)
+				Expect(node1.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+				Expect(recorder.Calls("DisruptionCancelled")).To(Equal(2))
+				ExpectExists(ctx, env.Client, nodeClaim1)
+			})
+			It("should not cancel a command for a do-not-disrupt pod that was already on a candidate", func() {
+				pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"}}})
+				ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, pod)
+				ExpectManualBinding(ctx, env.Client, pod, node1)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+				stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+				// the command was added after the pod was created
+				fakeClock.SetTime(time.Now().Add(time.Minute))
+				cmd := orchestration.NewCommand([]string{}, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
+				Expect(queue.Add(cmd)).To(BeNil())
+
+				ExpectSingletonReconciled(ctx, queue)
+				Expect(recorder.Calls("DisruptionCancelled")).To(Equal(0))
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1)
+				ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
+			})
+			It("should cancel a command when a candidate is nominated for a pending pod", func() {
+				ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+				stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+				cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
+				Expect(queue.Add(cmd)).To(BeNil())
+				cluster.NominateNodeForPod(ctx, stateNode.ProviderID())
+
+				ExpectSingletonReconciled(ctx, queue)
+				Expect(queue.HasAny(stateNode.ProviderID())).To(BeFalse())
+				Expect(recorder.Calls("DisruptionCancelled")).To(Equal(2))
+				ExpectExists(ctx, env.Client, nodeClaim1)
+			})
+		})
 		It("should finish two commands in order as replacements are intialized", func() {
 			ncName2 := test.RandomName()
 			replacements2 := []string{ncName2}
@@ -0,0 +1,259 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/expiration"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+)
+
+// ExpiredReason is the reason of the plan's command that deletes expired NodeClaims. Expiration isn't a disruption
+// method, but it's included in plans since it deletes NodeClaims regardless of disruption budgets.
+const ExpiredReason = "Expired"
+
+// maxPlanInstanceTypes is the maximum number of instance types that are listed for each replacement in a plan
+const maxPlanInstanceTypes = 10
+
+// PlanController computes what each disruption method would disrupt for DisruptionPlans and publishes the commands in
+// their status. The commands are computed with the same candidates, budgets and scheduling simulations as the
+// disruption controller, but they're never executed.
+type PlanController struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+	queue         *orchestration.Queue
+}
+
+func NewPlanController(clk clock.Clock, kubeClient client.Client, provisioner *provisioning.Provisioner,
+	cp cloudprovider.CloudProvider, recorder events.Recorder, cluster *state.Cluster, queue *orchestration.Queue,
+) *PlanController {
+	return &PlanController{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		cloudProvider: cp,
+		recorder:      recorder,
+		queue:         queue,
+	}
+}
+
+func (c *PlanController) Reconcile(ctx context.Context, plan *v1alpha1.DisruptionPlan) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+
+	// Plans are only computed once, since they preview the disruption of the cluster at the time they're created
+	if plan.Status.PlannedTime != nil {
+		return reconcile.Result{}, nil
+	}
+	if !c.cluster.Synced(ctx) {
+		log.FromContext(ctx).V(1).Info("waiting on cluster sync")
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	commands, err := c.plan(ctx, plan)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("computing disruption plan, %w", err)
+	}
+	stored := plan.DeepCopy()
+	plan.Status.PlannedTime = lo.ToPtr(metav1.NewTime(c.clock.Now()))
+	plan.Status.Commands = commands
+	if err := c.kubeClient.Status().Patch(ctx, plan, client.MergeFrom(stored)); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// plan computes the command that each disruption method would execute for the nodes of the plan's NodePools, followed
+// by the deletion of any expired NodeClaims
+func (c *PlanController) plan(ctx context.Context, plan *v1alpha1.DisruptionPlan) ([]v1alpha1.DisruptionPlanCommand, error) {
+	// Validation guards against the cluster changing between computing and executing a command, so it's skipped since
+	// the commands of a plan are never executed
+	consolidation := MakeConsolidation(c.clock, c.cluster, c.kubeClient, c.provisioner, c.cloudProvider, c.recorder, c.queue)
+	consolidation.validationTTL = 0
+
+	var commands []v1alpha1.DisruptionPlanCommand
+	for _, m := range newMethods(consolidation) {
+		candidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, m.ShouldDisrupt, m.Class(), c.queue)
+		if err != nil {
+			return nil, fmt.Errorf("determining candidates, %w", err)
+		}
+		candidates = lo.Filter(candidates, func(cn *Candidate, _ int) bool { return inPlan(plan, cn.nodePool.Name) })
+		if len(candidates) == 0 {
+			continue
+		}
+		disruptionBudgetMapping, err := BuildDisruptionBudgetMapping(ctx, c.cluster, c.clock, c.kubeClient, c.cloudProvider, c.recorder, m.Reason())
+		if err != nil {
+			return nil, fmt.Errorf("building disruption budgets, %w", err)
+		}
+		cmd, _, err := m.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
+		if err != nil {
+			return nil, fmt.Errorf("computing disruption decision via reason=%q, %w", strings.ToLower(string(m.Reason())), err)
+		}
+		if cmd.Decision() == NoOpDecision {
+			continue
+		}
+		commands = append(commands, consolidation.planCommand(ctx, m, cmd))
+	}
+	expired, err := c.expiredNodeClaims(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		commands = append(commands, v1alpha1.DisruptionPlanCommand{
+			Reason:   ExpiredReason,
+			Decision: string(DeleteDecision),
+			Candidates: lo.Map(expired, func(nodeClaim *v1.NodeClaim, _ int) v1alpha1.DisruptionPlanCandidate {
+				return v1alpha1.DisruptionPlanCandidate{
+					NodeClaim:    nodeClaim.Name,
+					Node:         nodeClaim.Status.NodeName,
+					NodePool:     nodeClaim.Labels[v1.NodePoolLabelKey],
+					InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+					CapacityType: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+					Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+				}
+			}),
+		})
+	}
+	return commands, nil
+}
+
+// expiredNodeClaims returns the NodeClaims of the plan's NodePools that have passed their expireAfter and would be
+// deleted by the expiration controller, skipping NodeClaims whose expiration is paused
+func (c *PlanController) expiredNodeClaims(ctx context.Context, plan *v1alpha1.DisruptionPlan) ([]*v1.NodeClaim, error) {
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	var expired []*v1.NodeClaim
+	for _, nodeClaim := range nodeClaims {
+		if !nodeClaim.DeletionTimestamp.IsZero() || !inPlan(plan, nodeClaim.Labels[v1.NodePoolLabelKey]) ||
+			nodeClaim.Spec.ExpireAfter.Duration == nil || c.clock.Now().Before(nodeClaim.CreationTimestamp.Add(*nodeClaim.Spec.ExpireAfter.Duration)) {
+			continue
+		}
+		paused, err := expiration.IsPaused(ctx, c.kubeClient, nodeClaim)
+		if err != nil {
+			return nil, err
+		}
+		if !paused {
+			expired = append(expired, nodeClaim)
+		}
+	}
+	return expired, nil
+}
+
+// planCommand converts a command into its representation in a plan. Savings are only estimated if the prices of all
+// the candidates and replacements are known.
+func (c *consolidation) planCommand(ctx context.Context, m Method, cmd Command) v1alpha1.DisruptionPlanCommand {
+	command := v1alpha1.DisruptionPlanCommand{
+		Reason:            string(m.Reason()),
+		ConsolidationType: m.ConsolidationType(),
+		Decision:          string(cmd.Decision()),
+		Candidates: lo.Map(cmd.candidates, func(cn *Candidate, _ int) v1alpha1.DisruptionPlanCandidate {
+			return v1alpha1.DisruptionPlanCandidate{
+				NodeClaim:    cn.NodeClaim.Name,
+				Node:         cn.Node.Name,
+				NodePool:     cn.nodePool.Name,
+				InstanceType: cn.Labels()[corev1.LabelInstanceTypeStable],
+				CapacityType: cn.capacityType,
+				Zone:         cn.zone,
+			}
+		}),
+	}
+	savings, savingsErr := c.getCandidatePrices(ctx, cmd.candidates)
+	for _, replacement := range cmd.replacements {
+		price, err := c.replacementPrice(ctx, replacement)
+		if err != nil {
+			savingsErr = err
+		}
+		savings -= price
+		command.Replacements = append(command.Replacements, v1alpha1.DisruptionPlanReplacement{
+			NodePool: replacement.NodePoolName,
+			InstanceTypes: lo.Map(lo.Slice(replacement.InstanceTypeOptions.OrderByPrice(replacement.Requirements), 0, maxPlanInstanceTypes), func(it *cloudprovider.InstanceType, _ int) string {
+				return it.Name
+			}),
+			Price: lo.Ternary(err == nil, formatPrice(price), ""),
+		})
+	}
+	if savingsErr == nil {
+		command.Savings = formatPrice(savings)
+	}
+	return command
+}
+
+// replacementPrice returns the cost of launching the cheapest instance type option of the replacement
+func (c *consolidation) replacementPrice(ctx context.Context, replacement *pscheduling.NodeClaim) (float64, error) {
+	costs, err := c.launchCosts(ctx, replacement)
+	if err != nil {
+		return 0.0, err
+	}
+	if len(costs) == 0 {
+		return 0.0, fmt.Errorf("no instance type options for replacement")
+	}
+	return lo.Min(lo.Values(costs)), nil
+}
+
+func inPlan(plan *v1alpha1.DisruptionPlan, nodePool string) bool {
+	return len(plan.Spec.NodePools) == 0 || lo.Contains(plan.Spec.NodePools, nodePool)
+}
+
+// formatPrice formats a price, rounding away the error accumulated by summing the prices of several offerings
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(math.Round(price*1e6)/1e6, 'f', -1, 64)
+}
+
+func (c *PlanController) Name() string {
+	return "disruption.plan"
+}
+
+func (c *PlanController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&v1alpha1.DisruptionPlan{}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,218 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Plan", func() {
+	var planController *disruption.PlanController
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var plan *v1alpha1.DisruptionPlan
+
+	BeforeEach(func() {
+		planController = disruption.NewPlanController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue)
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidateAfter:    v1.MustParseNillableDuration("0s"),
+					ConsolidationPolicy: v1.ConsolidationPolicyWhenEmptyOrUnderutilized,
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+		plan = &v1alpha1.DisruptionPlan{ObjectMeta: test.ObjectMeta()}
+	})
+
+	It("should plan to delete empty nodes without deleting them", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		Expect(plan.Status.PlannedTime).ToNot(BeNil())
+		command, ok := lo.Find(plan.Status.Commands, func(c v1alpha1.DisruptionPlanCommand) bool {
+			return c.Reason == string(v1.DisruptionReasonEmpty)
+		})
+		Expect(ok).To(BeTrue())
+		Expect(command.Decision).To(Equal(string(disruption.DeleteDecision)))
+		Expect(command.Candidates).To(ConsistOf(v1alpha1.DisruptionPlanCandidate{
+			NodeClaim:    nodeClaim.Name,
+			Node:         node.Name,
+			NodePool:     nodePool.Name,
+			InstanceType: mostExpensiveInstance.Name,
+			CapacityType: mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+			Zone:         mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+		}))
+		Expect(command.Savings).To(Equal(strconv.FormatFloat(mostExpensiveOffering.Price, 'f', -1, 64)))
+
+		// Nothing is disrupted
+		ExpectExists(ctx, env.Client, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+	})
+	It("should plan to replace underutilized nodes with cheaper nodes", func() {
+		rs := test.ReplicaSet()
+		ExpectApplied(ctx, env.Client, rs)
+		Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         "apps/v1",
+						Kind:               "ReplicaSet",
+						Name:               rs.Name,
+						UID:                rs.UID,
+						Controller:         lo.ToPtr(true),
+						BlockOwnerDeletion: lo.ToPtr(true),
+					},
+				}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod, plan)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		command, ok := lo.Find(plan.Status.Commands, func(c v1alpha1.DisruptionPlanCommand) bool {
+			return c.Reason == string(v1.DisruptionReasonUnderutilized)
+		})
+		Expect(ok).To(BeTrue())
+		Expect(command.Decision).To(Equal(string(disruption.ReplaceDecision)))
+		Expect(command.Candidates).To(HaveLen(1))
+		Expect(command.Candidates[0].NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(command.Replacements).To(HaveLen(1))
+		Expect(command.Replacements[0].NodePool).To(Equal(nodePool.Name))
+		Expect(command.Replacements[0].InstanceTypes).ToNot(BeEmpty())
+		Expect(command.Replacements[0].InstanceTypes).ToNot(ContainElement(mostExpensiveInstance.Name))
+		savings, err := strconv.ParseFloat(command.Savings, 64)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(savings).To(BeNumerically(">", 0))
+
+		// Nothing is launched or disrupted
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should plan to delete expired nodeclaims", func() {
+		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("30s")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		command, ok := lo.Find(plan.Status.Commands, func(c v1alpha1.DisruptionPlanCommand) bool {
+			return c.Reason == disruption.ExpiredReason
+		})
+		Expect(ok).To(BeTrue())
+		Expect(command.Decision).To(Equal(string(disruption.DeleteDecision)))
+		Expect(lo.Map(command.Candidates, func(c v1alpha1.DisruptionPlanCandidate, _ int) string { return c.NodeClaim })).To(ConsistOf(nodeClaim.Name))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should not plan to delete expired nodeclaims whose expiration is paused", func() {
+		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("30s")
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.ExpirationPausedAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		_, ok := lo.Find(plan.Status.Commands, func(c v1alpha1.DisruptionPlanCommand) bool {
+			return c.Reason == disruption.ExpiredReason
+		})
+		Expect(ok).To(BeFalse())
+	})
+	It("should only plan the disruption of the nodes of the plan's nodepools", func() {
+		plan.Spec.NodePools = []string{"other-nodepool"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		Expect(plan.Status.PlannedTime).ToNot(BeNil())
+		Expect(plan.Status.Commands).To(BeEmpty())
+	})
+	It("should not plan the disruption of the nodes of nodepools whose budgets block disruption", func() {
+		nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "0"}}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		Expect(plan.Status.Commands).To(BeEmpty())
+	})
+	It("should not recompute a plan that has already been computed", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, plan)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+		plan = ExpectExists(ctx, env.Client, plan)
+		plannedTime := plan.Status.PlannedTime
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, planController, plan)
+
+		plan = ExpectExists(ctx, env.Client, plan)
+		Expect(plan.Status.PlannedTime.Time).To(BeTemporally("==", plannedTime.Time))
+	})
+})
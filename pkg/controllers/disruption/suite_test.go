@@ -92,7 +92,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	cloudProvider = fake.NewCloudProvider()
 	fakeClock = clock.NewFakeClock(time.Now())
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	recorder = test.NewEventRecorder()
@@ -163,6 +163,8 @@ var _ = AfterEach(func() {
 
 	// Reset the metrics collectors
 	disruption.DecisionsPerformedTotal.Reset()
+	disruption.NodePoolDisruptionDecisionsTotal.Reset()
+	disruption.NodePoolBlockedCandidates.Reset()
 })
 
 var _ = Describe("Simulate Scheduling", func() {
@@ -534,6 +536,12 @@ var _ = Describe("Disruption Taints", func() {
 		})
 		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("Never")
 		node.Spec.Taints = append(node.Spec.Taints, v1.DisruptedNoScheduleTaint)
+		lock := map[string]string{
+			v1.DisruptionLockAnnotationKey:           "command-id",
+			v1.DisruptionLockExpirationAnnotationKey: fakeClock.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+		}
+		node.Annotations = lo.Assign(node.Annotations, lock)
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, lock)
 		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDisruptionReason)
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
 		ExpectManualBinding(ctx, env.Client, pod, node)
@@ -543,12 +551,16 @@ var _ = Describe("Disruption Taints", func() {
 		ExpectSingletonReconciled(ctx, disruptionController)
 		node = ExpectNodeExists(ctx, env.Client, node.Name)
 		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(node.Annotations).ToNot(HaveKey(v1.DisruptionLockAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1.DisruptionLockExpirationAnnotationKey))
 
 		nodeClaims := lo.Filter(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
 			return nc.Status.ProviderID == node.Spec.ProviderID
 		})
 		Expect(nodeClaims).To(HaveLen(1))
 		Expect(nodeClaims[0].StatusConditions().Get(v1.ConditionTypeDisruptionReason)).To(BeNil())
+		Expect(nodeClaims[0].Annotations).ToNot(HaveKey(v1.DisruptionLockAnnotationKey))
+		Expect(nodeClaims[0].Annotations).ToNot(HaveKey(v1.DisruptionLockExpirationAnnotationKey))
 	})
 	It("should add and remove taints from NodeClaims that fail to disrupt", func() {
 		nodePool.Spec.Disruption.ConsolidationPolicy = v1.ConsolidationPolicyWhenEmptyOrUnderutilized
@@ -593,6 +605,13 @@ var _ = Describe("Disruption Taints", func() {
 		Expect(nodeClaims[0].StatusConditions().Get(v1.ConditionTypeDisruptionReason)).ToNot(BeNil())
 		Expect(nodeClaims[0].StatusConditions().Get(v1.ConditionTypeDisruptionReason).IsTrue()).To(BeTrue())
 
+		// The candidate is locked until the command times out
+		Expect(node.Annotations).To(HaveKey(v1.DisruptionLockAnnotationKey))
+		Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.DisruptionLockAnnotationKey, node.Annotations[v1.DisruptionLockAnnotationKey]))
+		Expect(node.Annotations).To(HaveKey(v1.DisruptionLockExpirationAnnotationKey))
+		Expect(disruptionutils.IsLocked(fakeClock, node)).To(BeTrue())
+		Expect(disruptionutils.IsLocked(fakeClock, nodeClaims[0])).To(BeTrue())
+
 		createdNodeClaim := lo.Reject(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
 			return nc.Name == nodeClaim.Name
 		})
@@ -608,12 +627,14 @@ var _ = Describe("Disruption Taints", func() {
 
 		node = ExpectNodeExists(ctx, env.Client, node.Name)
 		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(disruptionutils.IsLocked(fakeClock, node)).To(BeFalse())
 
 		nodeClaims = lo.Filter(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
 			return nc.Status.ProviderID == node.Spec.ProviderID
 		})
 		Expect(nodeClaims).To(HaveLen(1))
 		Expect(nodeClaims[0].StatusConditions().Get(v1.ConditionTypeDisruptionReason)).To(BeNil())
+		Expect(disruptionutils.IsLocked(fakeClock, nodeClaims[0])).To(BeFalse())
 	})
 })
 
@@ -897,6 +918,72 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod))))
 		Expect(recorder.DetectedEvent(fmt.Sprintf(`Pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod)))).To(BeTrue())
 	})
+	It("should not consider candidates that have emptydir-protected pods scheduled until the protection expires", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.EmptyDirProtectionAnnotationKey: "4h",
+				},
+			},
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has an unexpired "karpenter.sh/emptydir-protection" annotation`, client.ObjectKeyFromObject(pod))))
+
+		// The protection is measured from the pod's start, so the node becomes disruptable again once it elapses
+		fakeClock.Step(5 * time.Hour)
+		_, err = disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should consider candidates that have emptydir-protected pods scheduled once the protection is removed", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.EmptyDirProtectionAnnotationKey: "4h",
+				},
+			},
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has an unexpired "karpenter.sh/emptydir-protection" annotation`, client.ObjectKeyFromObject(pod))))
+
+		pod.Annotations = lo.OmitByKeys(pod.Annotations, []string{v1.EmptyDirProtectionAnnotationKey})
+		ExpectApplied(ctx, env.Client, pod)
+		_, err = disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
 	It("should not consider candidates that have do-not-disrupt mirror pods scheduled", func() {
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -935,6 +1022,59 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod))))
 		Expect(recorder.DetectedEvent(fmt.Sprintf(`Pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod)))).To(BeTrue())
 	})
+	Context("Local Volumes", func() {
+		var nodeClaim *v1.NodeClaim
+		var node *corev1.Node
+		var pv *corev1.PersistentVolume
+		var pvc *corev1.PersistentVolumeClaim
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+						corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					},
+				},
+			})
+			pv = test.PersistentVolume(test.PersistentVolumeOptions{UseLocal: true})
+			pvc = test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: pv.Name})
+			pod = test.Pod(test.PodOptions{PersistentVolumeClaims: []string{pvc.Name}})
+		})
+		It("should not consider candidates that have pods with local volumes when local volumes are protected", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProtectLocalVolumes: lo.ToPtr(true)}))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pv, pvc, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			Expect(cluster.Nodes()).To(HaveLen(1))
+			_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q uses local volume %s (%s/%s) without the "karpenter.sh/replaceable=true" annotation`, client.ObjectKeyFromObject(pod), pv.Name, pvc.Namespace, pvc.Name)))
+		})
+		It("should consider candidates that have pods with replaceable local volumes when local volumes are protected", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProtectLocalVolumes: lo.ToPtr(true)}))
+			pvc.Annotations = map[string]string{v1.ReplaceableAnnotationKey: "true"}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pv, pvc, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			Expect(cluster.Nodes()).To(HaveLen(1))
+			_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should consider candidates that have pods with local volumes when local volumes aren't protected", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pv, pvc, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			Expect(cluster.Nodes()).To(HaveLen(1))
+			_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 	It("should not consider candidates that have do-not-disrupt daemonset pods scheduled", func() {
 		daemonSet := test.DaemonSet()
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
@@ -1290,6 +1430,45 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err.Error()).To(Equal(fmt.Sprintf(`pdb %q prevents pod evictions`, client.ObjectKeyFromObject(budget))))
 		Expect(recorder.DetectedEvent(fmt.Sprintf(`Pdb %q prevents pod evictions`, client.ObjectKeyFromObject(budget)))).To(BeTrue())
 	})
+	It("should consider candidates whose pods with fully blocking PDBs or the do-not-disrupt annotation are in force-evict namespaces", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		namespace := test.Namespace()
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ForceEvictNamespaces: lo.ToPtr([]string{namespace.Name})}))
+		podLabels := map[string]string{"test": "value"}
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace.Name,
+				Labels:      podLabels,
+				Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"},
+			},
+		})
+		budget := test.PodDisruptionBudget(test.PDBOptions{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: namespace.Name},
+			Labels:         podLabels,
+			MaxUnavailable: fromInt(0),
+		})
+		ExpectApplied(ctx, env.Client, namespace, nodePool, nodeClaim, node, pod, budget)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		var err error
+		pdbLimits, err = pdb.NewLimits(ctx, fakeClock, env.Client)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err = disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
 	It("should not consider candidates that have fully blocking PDBs on daemonset pods", func() {
 		daemonSet := test.DaemonSet()
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
@@ -1791,6 +1970,80 @@ var _ = Describe("Candidate Filtering", func() {
 	})
 })
 
+var _ = Describe("Disruption Dampening", func() {
+	var nodePool *v1.NodePool
+	var nodeClaims []*v1.NodeClaim
+	var nodes []*corev1.Node
+	BeforeEach(func() {
+		// the dampening window is tracked by the controller, so start each test with a fresh one
+		disruptionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue)
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			DisruptionDampeningThreshold: lo.ToPtr(30),
+			DisruptionDampeningWindow:    lo.ToPtr(10 * time.Minute),
+		}))
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaims, nodes = test.NodeClaimsAndNodes(3, v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: leastExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       leastExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaims[0].StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		// disrupting the first node disrupts more than 30% of the nodes
+		ExpectSingletonReconciled(ctx, disruptionController)
+		ExpectTaintedNodeCount(ctx, env.Client, 1)
+
+		nodeClaims[1].StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		ExpectApplied(ctx, env.Client, nodeClaims[1])
+		ExpectReconcileSucceeded(ctx, nodeClaimStateController, client.ObjectKeyFromObject(nodeClaims[1]))
+	})
+	It("should pause voluntary disruption once too many nodes were disrupted within the window", func() {
+		ExpectSingletonReconciled(ctx, disruptionController)
+		ExpectTaintedNodeCount(ctx, env.Client, 1)
+
+		ExpectMetricGaugeValue(disruption.Dampened, 1, nil)
+		Expect(recorder.Calls("DisruptionDampened")).To(Equal(1))
+		Expect(recorder.DetectedEvent("Voluntary disruption is paused since 1 of 3 nodes were disrupted in the last 10m0s")).To(BeTrue())
+	})
+	It("should resume voluntary disruption once the disruptions are outside of the window", func() {
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		ExpectTaintedNodeCount(ctx, env.Client, 2)
+
+		ExpectMetricGaugeValue(disruption.Dampened, 0, nil)
+		Expect(recorder.Calls("DisruptionDampened")).To(Equal(0))
+	})
+	It("should not pause voluntary disruption when dampening is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionDampeningThreshold: lo.ToPtr(0)}))
+		ExpectSingletonReconciled(ctx, disruptionController)
+		ExpectTaintedNodeCount(ctx, env.Client, 2)
+
+		ExpectMetricGaugeValue(disruption.Dampened, 0, nil)
+		Expect(recorder.Calls("DisruptionDampened")).To(Equal(0))
+	})
+})
+
 var _ = Describe("Metrics", func() {
 	var nodePool *v1.NodePool
 	var labels = map[string]string{
@@ -2022,6 +2275,72 @@ var _ = Describe("Metrics", func() {
 			"consolidation_type": "multi",
 		})
 	})
+	It("should fire nodepool metrics for executed disruption decisions", func() {
+		nodeClaim, node := nodeClaims[0], nodes[0]
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+
+		// inform cluster state about nodes and nodeclaims
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		ExpectMetricCounterValue(disruption.NodePoolDisruptionDecisionsTotal, 1, map[string]string{
+			metrics.NodePoolLabel: nodePool.Name,
+			metrics.ReasonLabel:   "drifted",
+			"decision":            "executed",
+		})
+		ExpectMetricGaugeValue(disruption.NodePoolBlockedCandidates, 0, map[string]string{
+			metrics.NodePoolLabel: nodePool.Name,
+			metrics.ReasonLabel:   "drifted",
+			"cause":               "budget",
+		})
+	})
+	It("should fire nodepool metrics for decisions that are blocked by budgets", func() {
+		nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "0"}}
+		nodeClaim, node := nodeClaims[0], nodes[0]
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+
+		// inform cluster state about nodes and nodeclaims
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		ExpectMetricCounterValue(disruption.NodePoolDisruptionDecisionsTotal, 1, map[string]string{
+			metrics.NodePoolLabel: nodePool.Name,
+			metrics.ReasonLabel:   "drifted",
+			"decision":            "blocked",
+		})
+		ExpectMetricGaugeValue(disruption.NodePoolBlockedCandidates, 1, map[string]string{
+			metrics.NodePoolLabel: nodePool.Name,
+			metrics.ReasonLabel:   "drifted",
+			"cause":               "budget",
+		})
+	})
+	It("should fire nodepool metrics for candidates that are blocked by the do-not-disrupt annotation", func() {
+		nodeClaim, node := nodeClaims[0], nodes[0]
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DoNotDisruptAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+
+		// inform cluster state about nodes and nodeclaims
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		for _, reason := range []string{"empty", "drifted", "underutilized"} {
+			ExpectMetricGaugeValue(disruption.NodePoolBlockedCandidates, 1, map[string]string{
+				metrics.NodePoolLabel: nodePool.Name,
+				metrics.ReasonLabel:   reason,
+				"cause":               "do_not_disrupt",
+			})
+		}
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
 })
 
 func leastExpensiveInstanceWithZone(zone string) *cloudprovider.InstanceType {
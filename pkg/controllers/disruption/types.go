@@ -94,7 +94,7 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 	}
 	// We only care if instanceType in non-empty consolidation to do price-comparison.
 	instanceType := instanceTypeMap[node.Labels()[corev1.LabelInstanceTypeStable]]
-	if pods, err = node.ValidatePodsDisruptable(ctx, kubeClient, pdbs); err != nil {
+	if pods, err = node.ValidatePodsDisruptable(ctx, kubeClient, pdbs, clk); err != nil {
 		// If the NodeClaim has a TerminationGracePeriod set and the disruption class is eventual, the node should be
 		// considered a candidate even if there's a pod that will block eviction. Other error types should still cause
 		// failure creating the candidate.
@@ -127,6 +127,7 @@ var (
 	NoOpDecision    Decision = "no-op"
 	ReplaceDecision Decision = "replace"
 	DeleteDecision  Decision = "delete"
+	CordonDecision  Decision = "cordon"
 )
 
 func (c Command) Decision() Decision {
@@ -140,6 +141,16 @@ func (c Command) Decision() Decision {
 	}
 }
 
+// Candidates are the nodes that the command disrupts
+func (c Command) Candidates() []*Candidate {
+	return c.candidates
+}
+
+// Replacements are the NodeClaims that are launched to replace the candidates
+func (c Command) Replacements() []*scheduling.NodeClaim {
+	return c.replacements
+}
+
 func (c Command) String() string {
 	var buf bytes.Buffer
 	podCount := lo.Reduce(c.candidates, func(_ int, cd *Candidate, _ int) int { return len(cd.reschedulablePods) }, 0)
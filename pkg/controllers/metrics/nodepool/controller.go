@@ -72,6 +72,19 @@ var (
 			nodePoolNameLabel,
 		},
 	)
+	LimitUsage = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodePoolSubsystem,
+			Name:      "limit_usage_percent",
+			Help:      "The percentage of the limits specified on the nodepool that is consumed by the resources that have been provisioned. Labeled by nodepool name and resource type.",
+		},
+		[]string{
+			resourceTypeLabel,
+			nodePoolNameLabel,
+		},
+	)
 )
 
 type Controller struct {
@@ -121,6 +134,13 @@ func buildMetrics(nodePool *v1.NodePool) (res []*metrics.StoreMetric) {
 			})
 		}
 	}
+	for k, v := range nodepoolutils.LimitUsage(nodePool) {
+		res = append(res, &metrics.StoreMetric{
+			GaugeMetric: LimitUsage,
+			Labels:      makeLabels(nodePool, strings.ReplaceAll(strings.ToLower(string(k)), "-", "_")),
+			Value:       100 * v,
+		})
+	}
 	return res
 }
 
@@ -138,9 +158,9 @@ func makeLabels(nodePool *v1.NodePool, resourceTypeName string) prometheus.Label
 	}
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("metrics.nodepool").
-		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsInShardPredicateFuncs(ctx))).
 		Complete(c)
 }
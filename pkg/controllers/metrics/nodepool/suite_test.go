@@ -128,8 +128,36 @@ var _ = Describe("Metrics", func() {
 			Expect(m.GetGauge().GetValue()).To(BeNumerically("~", v.AsApproximateFloat64()))
 		}
 	})
+	It("should update the nodepool limit usage metrics", func() {
+		nodePool.Spec.Limits = v1.Limits{
+			corev1.ResourceCPU:    resource.MustParse("10"),
+			corev1.ResourceMemory: resource.MustParse("10Gi"),
+		}
+		nodePool.Status.Resources = corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("9500m"),
+			corev1.ResourceMemory:           resource.MustParse("5Gi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+		for resourceType, percent := range map[string]float64{"cpu": 95, "memory": 50} {
+			m, found := FindMetricWithLabelValues("karpenter_nodepools_limit_usage_percent", map[string]string{
+				"nodepool":      nodePool.GetName(),
+				"resource_type": resourceType,
+			})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("~", percent))
+		}
+		// Resources without limits have no limit usage
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_limit_usage_percent", map[string]string{
+			"nodepool":      nodePool.GetName(),
+			"resource_type": "ephemeral_storage",
+		})
+		Expect(found).To(BeFalse())
+	})
 	It("should delete the nodepool state metrics on nodepool delete", func() {
-		expectedMetrics := []string{"karpenter_nodepools_limit", "karpenter_nodepools_usage"}
+		expectedMetrics := []string{"karpenter_nodepools_limit", "karpenter_nodepools_usage", "karpenter_nodepools_limit_usage_percent"}
 		nodePool.Spec.Limits = v1.Limits{
 			corev1.ResourceCPU:              resource.MustParse("100"),
 			corev1.ResourceMemory:           resource.MustParse("100Mi"),
@@ -39,6 +39,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
 const (
@@ -53,6 +56,12 @@ const (
 	podHostInstanceType = "instance_type"
 	podPhase            = "phase"
 	podScheduled        = "scheduled"
+	measuredFrom        = "from"
+
+	// measuredFromCreation measures time to capacity from the pod's creation timestamp
+	measuredFromCreation = "creation"
+	// measuredFromAcknowledged measures time to capacity from when Karpenter first saw the pod pending
+	measuredFromAcknowledged = "acknowledged"
 )
 
 var (
@@ -165,6 +174,41 @@ var (
 		},
 		[]string{podName, podNamespace},
 	)
+	// Stage: alpha
+	PodTimeToLaunchSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "time_to_launch_seconds",
+			Help:      "The time from pod creation, or from when Karpenter first saw the pod pending, until the NodeClaim that the pod bound to was launched. Only observed for pods that bound to NodeClaims that were launched after the pod was pending. Labeled by nodepool and the point that the time is measured from.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{metrics.NodePoolLabel, measuredFrom},
+	)
+	// Stage: alpha
+	PodTimeToBindSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "time_to_bind_seconds",
+			Help:      "The time from pod creation, or from when Karpenter first saw the pod pending, until the pod bound to a node that is managed by a nodepool. Labeled by nodepool and the point that the time is measured from.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{metrics.NodePoolLabel, measuredFrom},
+	)
+	// Stage: alpha
+	PodOldestPendingTimeSeconds = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "oldest_pending_time_seconds",
+			Help:      "The time since creation of the oldest pod that is waiting on Karpenter to provision capacity for it. Zero when no pods are waiting.",
+		},
+		[]string{},
+	)
 )
 
 // Controller for the resource
@@ -175,6 +219,8 @@ type Controller struct {
 
 	pendingPods     sets.Set[string]
 	unscheduledPods sets.Set[string]
+	// provisionablePods maps the pods that are waiting on Karpenter to provision capacity to their creation time
+	provisionablePods map[string]time.Time
 }
 
 func labelNames() []string {
@@ -201,6 +247,8 @@ func NewController(kubeClient client.Client, cluster *state.Cluster) *Controller
 		pendingPods:     sets.New[string](),
 		unscheduledPods: sets.New[string](),
 		cluster:         cluster,
+
+		provisionablePods: map[string]time.Time{},
 	}
 }
 
@@ -211,34 +259,19 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	pod := &corev1.Pod{}
 	if err := c.kubeClient.Get(ctx, req.NamespacedName, pod); err != nil {
 		if errors.IsNotFound(err) {
-			c.pendingPods.Delete(req.NamespacedName.String())
-			// Delete the unstarted metric since the pod is deleted
-			PodUnstartedTimeSeconds.Delete(map[string]string{
-				podName:      req.Name,
-				podNamespace: req.Namespace,
-			})
-			PodProvisioningUnstartedTimeSeconds.Delete(map[string]string{
-				podName:      req.Name,
-				podNamespace: req.Namespace,
-			})
-			c.unscheduledPods.Delete(req.NamespacedName.String())
-			// Delete the unbound metric since the pod is deleted
-			PodUnboundTimeSeconds.Delete(map[string]string{
-				podName:      req.Name,
-				podNamespace: req.Namespace,
-			})
-			PodProvisioningUnboundTimeSeconds.Delete(map[string]string{
-				podName:      req.Name,
-				podNamespace: req.Namespace,
-			})
-			PodSchedulingUndecidedTimeSeconds.Delete(map[string]string{
-				podName:      req.Name,
-				podNamespace: req.Namespace,
-			})
-			c.metricStore.Delete(req.NamespacedName.String())
+			c.deletePodMetrics(req.NamespacedName)
 		}
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
+	inShard, err := c.isInShard(ctx, pod)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// Pods that are tracked by another shard are left to it so that their metrics aren't emitted more than once
+	if !inShard {
+		c.deletePodMetrics(req.NamespacedName)
+		return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+	}
 	labels, err := c.makeLabels(ctx, pod)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -253,12 +286,71 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	c.recordPodSchedulingUndecidedMetric(pod)
 	// Get the time for when we Karpenter first thought the pod was schedulable. This should be zero if we didn't simulate for this pod.
 	schedulableTime := c.cluster.PodSchedulingSuccessTime(types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace})
+	// Get the ack time before the startup metric clears the scheduling mappings for pods that have started
+	ackTime := c.cluster.PodAckTime(types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace})
 	c.recordPodStartupMetric(pod, schedulableTime)
-	c.recordPodBoundMetric(pod, schedulableTime)
+	if err = c.recordPodBoundMetric(ctx, pod, schedulableTime, ackTime); err != nil {
+		return reconcile.Result{}, err
+	}
+	if podutils.IsProvisionable(pod) {
+		c.provisionablePods[client.ObjectKeyFromObject(pod).String()] = pod.CreationTimestamp.Time
+	} else {
+		delete(c.provisionablePods, client.ObjectKeyFromObject(pod).String())
+	}
+	c.recordOldestPendingMetric()
 	// Requeue every 30s for pods that are stuck without a state change
 	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
 }
 
+// deletePodMetrics deletes the metrics of a pod that was deleted or that isn't tracked by this shard
+func (c *Controller) deletePodMetrics(nn types.NamespacedName) {
+	c.pendingPods.Delete(nn.String())
+	PodUnstartedTimeSeconds.Delete(map[string]string{
+		podName:      nn.Name,
+		podNamespace: nn.Namespace,
+	})
+	PodProvisioningUnstartedTimeSeconds.Delete(map[string]string{
+		podName:      nn.Name,
+		podNamespace: nn.Namespace,
+	})
+	c.unscheduledPods.Delete(nn.String())
+	PodUnboundTimeSeconds.Delete(map[string]string{
+		podName:      nn.Name,
+		podNamespace: nn.Namespace,
+	})
+	PodProvisioningUnboundTimeSeconds.Delete(map[string]string{
+		podName:      nn.Name,
+		podNamespace: nn.Namespace,
+	})
+	PodSchedulingUndecidedTimeSeconds.Delete(map[string]string{
+		podName:      nn.Name,
+		podNamespace: nn.Namespace,
+	})
+	c.metricStore.Delete(nn.String())
+	delete(c.provisionablePods, nn.String())
+	c.recordOldestPendingMetric()
+}
+
+// isInShard returns true if the pod is tracked by the shard of this Karpenter deployment. When NodePools are sharded,
+// bound pods are tracked by the shard of their node's NodePool, and pending pods are tracked by the shards that have
+// simulated scheduling them.
+func (c *Controller) isInShard(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	if !nodepoolutils.IsSharded(ctx) {
+		return true, nil
+	}
+	if pod.Spec.NodeName == "" {
+		return !c.cluster.PodSchedulingDecisionTime(client.ObjectKeyFromObject(pod)).IsZero(), nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting node, %w", err)
+	}
+	return nodepoolutils.IsOwnerInShard(ctx, c.kubeClient, node), nil
+}
+
 func (c *Controller) recordPodSchedulingUndecidedMetric(pod *corev1.Pod) {
 	nn := client.ObjectKeyFromObject(pod)
 	// If we've made a decision on this pod, delete the metric idempotently and return
@@ -330,7 +422,8 @@ func (c *Controller) recordPodStartupMetric(pod *corev1.Pod, schedulableTime tim
 		}
 	}
 }
-func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.Time) {
+
+func (c *Controller) recordPodBoundMetric(ctx context.Context, pod *corev1.Pod, schedulableTime, ackTime time.Time) error {
 	key := client.ObjectKeyFromObject(pod).String()
 	cond, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool {
 		return c.Type == corev1.PodScheduled
@@ -350,9 +443,13 @@ func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.
 			}
 		}
 		c.unscheduledPods.Insert(key)
-		return
+		return nil
 	}
 	if c.unscheduledPods.Has(key) && ok && cond.Status == corev1.ConditionTrue {
+		// Record the time to capacity first so that the transition is observed again if it fails
+		if err := c.recordPodTimeToCapacityMetrics(ctx, pod, cond.LastTransitionTime.Time, ackTime); err != nil {
+			return err
+		}
 		// Delete the unbound metric since the pod is now bound
 		PodUnboundTimeSeconds.Delete(map[string]string{
 			podName:      pod.Name,
@@ -369,6 +466,65 @@ func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.
 		}
 		c.unscheduledPods.Delete(key)
 	}
+	return nil
+}
+
+// recordPodTimeToCapacityMetrics observes the time that it took for a pod to get capacity from its creation, and from
+// when Karpenter first saw it pending, for pods that bound to nodes that are managed by a nodepool
+func (c *Controller) recordPodTimeToCapacityMetrics(ctx context.Context, pod *corev1.Pod, boundTime, ackTime time.Time) error {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	nodePool, ok := node.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return nil
+	}
+	starts := map[string]time.Time{measuredFromCreation: pod.CreationTimestamp.Time}
+	if !ackTime.IsZero() {
+		starts[measuredFromAcknowledged] = ackTime
+	}
+	for from, start := range starts {
+		PodTimeToBindSeconds.Observe(boundTime.Sub(start).Seconds(), map[string]string{
+			metrics.NodePoolLabel: nodePool,
+			measuredFrom:          from,
+		})
+	}
+	nodeClaim, err := nodeutils.NodeClaimForNode(ctx, c.kubeClient, node)
+	if err != nil {
+		// The NodeClaim may have been deleted since the pod bound
+		if nodeutils.IsNodeClaimNotFoundError(err) || nodeutils.IsDuplicateNodeClaimError(err) {
+			return nil
+		}
+		return err
+	}
+	launched := nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched)
+	if !launched.IsTrue() {
+		return nil
+	}
+	for from, start := range starts {
+		// Pods that bound to capacity that already existed didn't wait on a launch
+		if !launched.LastTransitionTime.After(start) {
+			continue
+		}
+		PodTimeToLaunchSeconds.Observe(launched.LastTransitionTime.Sub(start).Seconds(), map[string]string{
+			metrics.NodePoolLabel: nodePool,
+			measuredFrom:          from,
+		})
+	}
+	return nil
+}
+
+// recordOldestPendingMetric sets the age of the oldest pod that is waiting on Karpenter to provision capacity
+func (c *Controller) recordOldestPendingMetric() {
+	oldest := 0.0
+	for _, created := range c.provisionablePods {
+		oldest = lo.Max([]float64{oldest, time.Since(created).Seconds()})
+	}
+	PodOldestPendingTimeSeconds.Set(oldest, nil)
 }
 
 // makeLabels creates the makeLabels using the current state of the pod
@@ -23,17 +23,22 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/awslabs/operatorpkg/status"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/metrics/pod"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 	. "sigs.k8s.io/karpenter/pkg/utils/testing"
 )
 
@@ -51,15 +56,16 @@ func TestAPIs(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	env = test.NewEnvironment()
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx)))
 	fakeClock = clock.NewFakeClock(time.Now())
 	cloudProvider = fake.NewCloudProvider()
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	podController = pod.NewController(env.Client, cluster)
 })
 
 var _ = AfterEach(func() {
 	cluster.Reset()
+	ExpectCleanedUp(ctx, env.Client)
 })
 
 var _ = AfterSuite(func() {
@@ -379,4 +385,90 @@ var _ = Describe("Pod Metrics", func() {
 		})
 		Expect(found).To(BeFalse())
 	})
+	Context("Time to Capacity", func() {
+		var nodePool *v1.NodePool
+		var nodeClaim *v1.NodeClaim
+		var node *corev1.Node
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+			})
+		})
+		// bind binds the pod to the node at the given time and reconciles it
+		bind := func(p *corev1.Pod, at time.Time) {
+			p.Spec.NodeName = node.Name
+			p.Status.Phase = corev1.PodRunning
+			p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(at)}}
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+		}
+		It("should observe the time to launch and bind for pods that waited on a launch", func() {
+			p := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, p)
+			p = ExpectExists(ctx, env.Client, p)
+			cluster.AckPods(p)
+			ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+			launchTime := p.CreationTimestamp.Add(time.Minute)
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeLaunched)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			nodeClaim.Status.Conditions = lo.Map(nodeClaim.Status.Conditions, func(c status.Condition, _ int) status.Condition {
+				c.LastTransitionTime = metav1.NewTime(launchTime)
+				return c
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			bind(p, p.CreationTimestamp.Add(2*time.Minute))
+
+			for _, from := range []string{"creation", "acknowledged"} {
+				launched, found := FindMetricWithLabelValues("karpenter_pods_time_to_launch_seconds", map[string]string{"nodepool": nodePool.Name, "from": from})
+				Expect(found).To(BeTrue())
+				Expect(launched.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+				bound, found := FindMetricWithLabelValues("karpenter_pods_time_to_bind_seconds", map[string]string{"nodepool": nodePool.Name, "from": from})
+				Expect(found).To(BeTrue())
+				Expect(bound.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+			}
+			bound, _ := FindMetricWithLabelValues("karpenter_pods_time_to_bind_seconds", map[string]string{"nodepool": nodePool.Name, "from": "creation"})
+			Expect(bound.GetHistogram().GetSampleSum()).To(BeNumerically("~", time.Minute*2/time.Second, 1))
+		})
+		It("should not observe the time to launch for pods that bound to existing capacity", func() {
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeLaunched)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			nodeClaim.Status.Conditions = lo.Map(nodeClaim.Status.Conditions, func(c status.Condition, _ int) status.Condition {
+				c.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+				return c
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+
+			p := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+			p = ExpectExists(ctx, env.Client, p)
+			bind(p, p.CreationTimestamp.Add(time.Minute))
+
+			_, found := FindMetricWithLabelValues("karpenter_pods_time_to_launch_seconds", map[string]string{"nodepool": nodePool.Name})
+			Expect(found).To(BeFalse())
+			_, found = FindMetricWithLabelValues("karpenter_pods_time_to_bind_seconds", map[string]string{"nodepool": nodePool.Name, "from": "creation"})
+			Expect(found).To(BeTrue())
+			_, found = FindMetricWithLabelValues("karpenter_pods_time_to_bind_seconds", map[string]string{"nodepool": nodePool.Name, "from": "acknowledged"})
+			Expect(found).To(BeFalse())
+		})
+		It("should track the age of the oldest pending pod", func() {
+			pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod()}
+			for _, p := range pods {
+				ExpectApplied(ctx, env.Client, p)
+				ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+			}
+			oldest, found := FindMetricWithLabelValues("karpenter_pods_oldest_pending_time_seconds", nil)
+			Expect(found).To(BeTrue())
+			Expect(oldest.GetGauge().GetValue()).To(BeNumerically(">", 0))
+
+			for _, p := range pods {
+				ExpectDeleted(ctx, env.Client, p)
+				ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+			}
+			oldest, _ = FindMetricWithLabelValues("karpenter_pods_oldest_pending_time_seconds", nil)
+			Expect(oldest.GetGauge().GetValue()).To(BeZero())
+		})
+	})
 })
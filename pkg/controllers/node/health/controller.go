@@ -42,6 +42,8 @@ import (
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
@@ -65,10 +67,10 @@ func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudPr
 	}
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("node.health").
-		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
 
@@ -130,7 +132,7 @@ func (c *Controller) deleteNodeClaim(ctx context.Context, nodeClaim *v1.NodeClai
 	if !nodeClaim.DeletionTimestamp.IsZero() {
 		return reconcile.Result{}, nil
 	}
-	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+	if err := nodeclaimutils.Delete(ctx, c.kubeClient, nodeClaim, v1.TerminationReasonUnhealthy); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
 	// The deletion timestamp has successfully been set for the Node, update relevant metrics.
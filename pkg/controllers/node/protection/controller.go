@@ -0,0 +1,152 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protection
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// Controller adds the disruption protection finalizer to nodes that host pods with the karpenter.sh/disruption-protection
+// annotation, either on the pod or on the StatefulSet that owns it, and removes it once the node no longer needs it
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController constructs a controller instance
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "node.protection")
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef(node.Namespace, node.Name)))
+
+	// Pod events are mapped to the node that they're bound to, so the node isn't filtered by the watch predicate
+	if !nodeutils.IsManaged(node, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	protected, err := c.hostsProtectedPods(ctx, node)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	stored := node.DeepCopy()
+	switch {
+	// Finalizers can't be added to a node once it's deleting. The finalizer is kept until the protected pods are
+	// drained from the node, or until Karpenter finishes terminating the node.
+	case !node.DeletionTimestamp.IsZero():
+		if !protected || !controllerutil.ContainsFinalizer(node, v1.TerminationFinalizer) {
+			controllerutil.RemoveFinalizer(node, v1.DisruptionProtectionFinalizer)
+		}
+	case protected:
+		controllerutil.AddFinalizer(node, v1.DisruptionProtectionFinalizer)
+	default:
+		controllerutil.RemoveFinalizer(node, v1.DisruptionProtectionFinalizer)
+	}
+	if equality.Semantic.DeepEqual(stored, node) {
+		return reconcile.Result{}, nil
+	}
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the finalizer list
+	if err = c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching disruption protection finalizer, %w", err))
+	}
+	log.FromContext(ctx).WithValues("protected", controllerutil.ContainsFinalizer(node, v1.DisruptionProtectionFinalizer)).V(1).Info("updated disruption protection finalizer")
+	return reconcile.Result{}, nil
+}
+
+// hostsProtectedPods returns whether any active pod on the node has disruption protection
+func (c *Controller) hostsProtectedPods(ctx context.Context, node *corev1.Node) (bool, error) {
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return false, fmt.Errorf("listing pods on node, %w", err)
+	}
+	for _, pod := range pods {
+		if podutils.IsTerminal(pod) || podutils.IsTerminating(pod) {
+			continue
+		}
+		protected, err := c.hasDisruptionProtection(ctx, pod)
+		if err != nil {
+			return false, err
+		}
+		if protected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasDisruptionProtection returns whether the pod or the StatefulSet that owns it has the
+// karpenter.sh/disruption-protection annotation
+func (c *Controller) hasDisruptionProtection(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	if podutils.HasDisruptionProtection(pod) {
+		return true, nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" {
+		return false, nil
+	}
+	statefulSet := &appsv1.StatefulSet{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, statefulSet); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting statefulset, %w", err)
+	}
+	return statefulSet.Annotations[v1.DisruptionProtectionAnnotationKey] == "true", nil
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("node.protection").
+		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
+		Watches(&corev1.Pod{}, nodeutils.PodEventHandler()).
+		// Annotating a StatefulSet protects the nodes of the pods that it already owns
+		Watches(&appsv1.StatefulSet{}, nodeutils.StatefulSetEventHandler(m.GetClient()), builder.WithPredicates(predicate.AnnotationChangedPredicate{})).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protection_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/protection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var protectionController *protection.Controller
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Protection")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx), test.NodeClaimProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+
+	cloudProvider = fake.NewCloudProvider()
+	protectionController = protection.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Protection", func() {
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{v1.TerminationFinalizer}}})
+	})
+
+	It("should add the disruption protection finalizer to nodes that host protected pods", func() {
+		pod := test.Pod(test.PodOptions{
+			NodeName:   node.Name,
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).To(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should add the disruption protection finalizer to nodes that host pods of protected statefulsets", func() {
+		statefulSet := test.StatefulSet(test.StatefulSetOptions{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}},
+		})
+		ExpectApplied(ctx, env.Client, statefulSet)
+		pod := test.Pod(test.PodOptions{
+			NodeName: node.Name,
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: statefulSet.Namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "StatefulSet",
+					Name:       statefulSet.Name,
+					UID:        statefulSet.UID,
+					Controller: lo.ToPtr(true),
+				}},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).To(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should add the disruption protection finalizer to nodes once the statefulset of their pods is protected", func() {
+		statefulSet := test.StatefulSet()
+		ExpectApplied(ctx, env.Client, statefulSet)
+		pod := test.Pod(test.PodOptions{
+			NodeName: node.Name,
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: statefulSet.Namespace,
+				Labels:    statefulSet.Spec.Selector.MatchLabels,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "StatefulSet",
+					Name:       statefulSet.Name,
+					UID:        statefulSet.UID,
+					Controller: lo.ToPtr(true),
+				}},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).ToNot(ContainElement(v1.DisruptionProtectionFinalizer))
+
+		// Annotate the statefulset after its pod is bound, and reconcile the nodes that the watch enqueues
+		stored := statefulSet.DeepCopy()
+		statefulSet.Annotations = map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, statefulSet)
+		queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		defer queue.ShutDown()
+		nodeutils.StatefulSetEventHandler(env.Client).Update(ctx, event.UpdateEvent{ObjectOld: stored, ObjectNew: statefulSet}, queue)
+		Expect(queue.Len()).To(Equal(1))
+		req, _ := queue.Get()
+		Expect(req.Name).To(Equal(node.Name))
+		ExpectReconcileSucceeded(ctx, reconcile.AsReconciler(env.Client, protectionController), req.NamespacedName)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).To(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should not add the disruption protection finalizer to nodes that don't host protected pods", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).ToNot(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should remove the disruption protection finalizer once the protected pods are gone", func() {
+		node.Finalizers = append(node.Finalizers, v1.DisruptionProtectionFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).ToNot(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should keep the disruption protection finalizer on a deleting node that hosts protected pods", func() {
+		node.Finalizers = append(node.Finalizers, v1.DisruptionProtectionFinalizer)
+		pod := test.Pod(test.PodOptions{
+			NodeName:   node.Name,
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		Expect(env.Client.Delete(ctx, node)).To(Succeed())
+		node = ExpectExists(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).To(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+	It("should remove the disruption protection finalizer once Karpenter has terminated the node", func() {
+		node.Finalizers = []string{v1.DisruptionProtectionFinalizer}
+		pod := test.Pod(test.PodOptions{
+			NodeName:   node.Name,
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+		Expect(env.Client.Delete(ctx, node)).To(Succeed())
+		node = ExpectExists(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should ignore nodes that aren't managed by Karpenter", func() {
+		node = test.Node(test.NodeOptions{ProviderID: test.RandomProviderID()})
+		pod := test.Pod(test.PodOptions{
+			NodeName:   node.Name,
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DisruptionProtectionAnnotationKey: "true"}},
+		})
+		ExpectApplied(ctx, env.Client, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, protectionController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Finalizers).ToNot(ContainElement(v1.DisruptionProtectionFinalizer))
+	})
+})
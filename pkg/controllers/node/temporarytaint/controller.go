@@ -0,0 +1,143 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package temporarytaint
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller removes the temporary taints of a NodeClaim from its node once they expire
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController constructs a controller instance
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, clock clock.Clock) *Controller {
+	return &Controller{
+		clock:         clock,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "node.temporarytaint")
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef(node.Namespace, node.Name)))
+
+	if !node.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	nodeClaim, err := nodeutils.NodeClaimForNode(ctx, c.kubeClient, node)
+	if err != nil {
+		return reconcile.Result{}, nodeutils.IgnoreNodeClaimNotFoundError(nodeutils.IgnoreDuplicateNodeClaimError(err))
+	}
+	// Temporary taints are added to the node when it registers, so they can't expire before then
+	registered := nodeClaim.StatusConditions().Get(v1.ConditionTypeRegistered)
+	if len(nodeClaim.Spec.TemporaryTaints) == 0 || !registered.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+
+	expired, requeueAfter := c.expiredTaints(node, nodeClaim, registered.LastTransitionTime.Time)
+	if len(expired) == 0 {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	stored := node.DeepCopy()
+	node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool {
+		_, found := lo.Find(expired, func(taint v1.TemporaryTaint) bool { return taint.MatchTaint(&t) })
+		return found
+	})
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the taint list
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing temporary taints, %w", err))
+	}
+	for _, taint := range expired {
+		log.FromContext(ctx).WithValues("Taint", taint.ToString()).V(1).Info("removed expired temporary taint")
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// expiredTaints returns the temporary taints on the node that have expired, along with the time until the next
+// temporary taint expires
+func (c *Controller) expiredTaints(node *corev1.Node, nodeClaim *v1.NodeClaim, registeredTime time.Time) ([]v1.TemporaryTaint, time.Duration) {
+	var expired []v1.TemporaryTaint
+	var requeueAfter time.Duration
+	for _, taint := range nodeClaim.Spec.TemporaryTaints {
+		if _, found := lo.Find(node.Spec.Taints, func(t corev1.Taint) bool { return taint.MatchTaint(&t) }); !found {
+			continue
+		}
+		remaining, ok := c.expiresAfter(node, taint, registeredTime)
+		if ok {
+			expired = append(expired, taint)
+		} else if remaining > 0 && (requeueAfter == 0 || remaining < requeueAfter) {
+			requeueAfter = remaining
+		}
+	}
+	return expired, requeueAfter
+}
+
+// expiresAfter returns whether the temporary taint has expired. If it hasn't, it also returns the time until the taint
+// expires, which is 0 if the taint only expires once the node has the taint's condition.
+func (c *Controller) expiresAfter(node *corev1.Node, taint v1.TemporaryTaint, registeredTime time.Time) (time.Duration, bool) {
+	if taint.Condition != nil {
+		status := lo.Ternary(taint.Condition.Status == "", corev1.ConditionTrue, taint.Condition.Status)
+		if _, found := lo.Find(node.Status.Conditions, func(cond corev1.NodeCondition) bool {
+			return cond.Type == taint.Condition.Type && cond.Status == status
+		}); found {
+			return 0, true
+		}
+	}
+	if taint.ExpireAfter == nil {
+		return 0, false
+	}
+	remaining := registeredTime.Add(taint.ExpireAfter.Duration).Sub(c.clock.Now())
+	return remaining, remaining <= 0
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("node.temporarytaint").
+		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
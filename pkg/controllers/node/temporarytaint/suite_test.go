@@ -0,0 +1,160 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package temporarytaint_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/temporarytaint"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var temporaryTaintController *temporarytaint.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TemporaryTaint")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx), test.NodeClaimProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+
+	cloudProvider = fake.NewCloudProvider()
+	temporaryTaintController = temporarytaint.NewController(env.Client, cloudProvider, fakeClock)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("TemporaryTaint", func() {
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	warmupTaint := corev1.Taint{Key: "example.com/warmup", Effect: corev1.TaintEffectNoSchedule}
+	otherTaint := corev1.Taint{Key: "example.com/other", Effect: corev1.TaintEffectNoSchedule}
+
+	BeforeEach(func() {
+		fakeClock.SetTime(time.Now())
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			Spec: v1.NodeClaimSpec{
+				Taints: []corev1.Taint{otherTaint},
+				TemporaryTaints: []v1.TemporaryTaint{{
+					Taint:       warmupTaint,
+					ExpireAfter: &metav1.Duration{Duration: 10 * time.Minute},
+				}},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeRegistered)
+		node.Spec.Taints = []corev1.Taint{otherTaint, warmupTaint}
+	})
+
+	It("should not remove a temporary taint before it expires", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		result := ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 10*time.Minute, time.Second))
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElements(warmupTaint, otherTaint))
+	})
+	It("should remove a temporary taint once it expires", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		fakeClock.Step(10 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(warmupTaint))
+		Expect(node.Spec.Taints).To(ContainElement(otherTaint))
+	})
+	It("should remove a temporary taint once the node has its condition", func() {
+		nodeClaim.Spec.TemporaryTaints[0].ExpireAfter = nil
+		nodeClaim.Spec.TemporaryTaints[0].Condition = &v1.TemporaryTaintCondition{Type: "WarmedUp", Status: corev1.ConditionTrue}
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(warmupTaint))
+
+		node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: "WarmedUp", Status: corev1.ConditionTrue})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(warmupTaint))
+	})
+	It("should remove a temporary taint once the node has its condition before it expires", func() {
+		nodeClaim.Spec.TemporaryTaints[0].Condition = &v1.TemporaryTaintCondition{Type: "WarmedUp", Status: corev1.ConditionTrue}
+		node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: "WarmedUp", Status: corev1.ConditionTrue})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(warmupTaint))
+	})
+	It("should not remove a temporary taint from a node that hasn't registered", func() {
+		nodeClaim.StatusConditions().SetUnknown(v1.ConditionTypeRegistered)
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(warmupTaint))
+	})
+	It("should requeue for the temporary taint that expires first", func() {
+		nodeClaim.Spec.TemporaryTaints = append(nodeClaim.Spec.TemporaryTaints, v1.TemporaryTaint{
+			Taint:       otherTaint,
+			ExpireAfter: &metav1.Duration{Duration: 5 * time.Minute},
+		})
+		nodeClaim.Spec.Taints = nil
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		result := ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 5*time.Minute, time.Second))
+
+		fakeClock.Step(5 * time.Minute)
+		result = ExpectObjectReconciled(ctx, env.Client, temporaryTaintController, node)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 5*time.Minute, time.Second))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(lo.Map(node.Spec.Taints, func(t corev1.Taint, _ int) string { return t.Key })).To(ContainElement(warmupTaint.Key))
+		Expect(lo.Map(node.Spec.Taints, func(t corev1.Taint, _ int) string { return t.Key })).ToNot(ContainElement(otherTaint.Key))
+	})
+})
@@ -46,11 +46,13 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/hooks"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
-	"sigs.k8s.io/karpenter/pkg/utils/termination"
+	terminationutil "sigs.k8s.io/karpenter/pkg/utils/termination"
 	volumeutil "sigs.k8s.io/karpenter/pkg/utils/volume"
 )
 
@@ -61,16 +63,18 @@ type Controller struct {
 	cloudProvider cloudprovider.CloudProvider
 	terminator    *terminator.Terminator
 	recorder      events.Recorder
+	hooks         *hooks.Runner
 }
 
 // NewController constructs a controller instance
-func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, terminator *terminator.Terminator, recorder events.Recorder) *Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, terminator *terminator.Terminator, recorder events.Recorder, hookRunner *hooks.Runner) *Controller {
 	return &Controller{
 		clock:         clk,
 		kubeClient:    kubeClient,
 		cloudProvider: cloudProvider,
 		terminator:    terminator,
 		recorder:      recorder,
+		hooks:         hookRunner,
 	}
 }
 
@@ -100,18 +104,60 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 	if err = c.deleteAllNodeClaims(ctx, nodeClaims...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("deleting nodeclaims, %w", err)
 	}
+	// The NodeClaim records the reason that it was deleted. If the Node was deleted directly, the NodeClaim is deleted
+	// without a reason above, so the termination is attributed to a manual deletion.
+	reason := v1.TerminationReasonManual
+	if len(nodeClaims) > 0 {
+		reason = nodeClaims[0].TerminationReason()
+	}
+	c.recorder.Publish(terminatorevents.NodeTerminating(node, reason))
 
 	nodeTerminationTime, err := c.nodeTerminationTime(node, nodeClaims...)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
+	// Voluntary terminations of nodes that host pods with disruption protection wait for an operator or an external
+	// controller to confirm the disruption before the node is tainted and drained. The confirmation is skipped once the
+	// node's TerminationGracePeriod has elapsed so that it can't block the node's termination.
+	if reason.IsVoluntary() && nodeutils.IsAwaitingDisruptionConfirmation(node) && (nodeTerminationTime == nil || c.clock.Now().Before(*nodeTerminationTime)) {
+		c.recorder.Publish(terminatorevents.NodeAwaitingDisruptionConfirmation(node, reason))
+		// The node is reconciled again when it's annotated, or once its TerminationGracePeriod elapses
+		if nodeTerminationTime == nil {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: nodeTerminationTime.Sub(c.clock.Now())}, nil
+	}
 	if err = c.terminator.Taint(ctx, node, v1.DisruptedNoScheduleTaint); err != nil {
 		if errors.IsConflict(err) {
 			return reconcile.Result{Requeue: true}, nil
 		}
 		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("tainting node with %s, %w", pretty.Taint(v1.DisruptedNoScheduleTaint), err))
 	}
+	// Hooks and drain readiness are skipped once the node's TerminationGracePeriod has elapsed so that they can't block
+	// its termination
+	if nodeTerminationTime == nil || c.clock.Now().Before(*nodeTerminationTime) {
+		requeueAfter, err := c.awaitDrainReadiness(ctx, node)
+		if err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("requesting drain readiness, %w", err))
+		}
+		if requeueAfter > 0 {
+			// The node is reconciled again when it's annotated, or once the wait times out
+			return reconcile.Result{RequeueAfter: requeueAfter}, nil
+		}
+		if err = c.hooks.Run(ctx, hooks.PreDrain, lo.FirstOrEmpty(nodeClaims), node); err != nil {
+			if hooks.IsPendingError(err) {
+				return reconcile.Result{RequeueAfter: hooks.PendingRequeueInterval}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	if err = c.recordLocalVolumesLost(ctx, node); err != nil {
+		return reconcile.Result{}, fmt.Errorf("recording local volumes, %w", err)
+	}
 	if err = c.terminator.Drain(ctx, node, nodeTerminationTime); err != nil {
 		if !terminator.IsNodeDrainError(err) {
 			return reconcile.Result{}, fmt.Errorf("draining node, %w", err)
@@ -125,7 +171,7 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 		if nodeutils.GetCondition(node, corev1.NodeReady).Status != corev1.ConditionTrue {
 			if _, err = c.cloudProvider.Get(ctx, node.Spec.ProviderID); err != nil {
 				if cloudprovider.IsNodeClaimNotFoundError(err) {
-					return reconcile.Result{}, c.removeFinalizer(ctx, node)
+					return reconcile.Result{}, c.removeFinalizer(ctx, node, reason)
 				}
 				return reconcile.Result{}, fmt.Errorf("getting nodeclaim, %w", err)
 			}
@@ -133,9 +179,9 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 
 		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
 	}
-	NodesDrainedTotal.Inc(map[string]string{
+	NodesDrainedTotal.Inc(lo.Assign(terminationutil.ReasonLabels(reason), map[string]string{
 		metrics.NodePoolLabel: node.Labels[v1.NodePoolLabelKey],
-	})
+	}))
 	// In order for Pods associated with PersistentVolumes to smoothly migrate from the terminating Node, we wait
 	// for VolumeAttachments of drain-able Pods to be cleaned up before terminating Node and removing its finalizer.
 	// However, if TerminationGracePeriod is configured for Node, and we are past that period, we will skip waiting.
@@ -153,7 +199,7 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 		return reconcile.Result{}, fmt.Errorf("deleting nodeclaims, %w", err)
 	}
 	for _, nodeClaim := range nodeClaims {
-		isInstanceTerminated, err := termination.EnsureTerminated(ctx, c.kubeClient, nodeClaim, c.cloudProvider)
+		isInstanceTerminated, err := terminationutil.EnsureTerminated(ctx, c.kubeClient, nodeClaim, c.cloudProvider)
 		if err != nil {
 			// 404 = the nodeClaim no longer exists
 			if errors.IsNotFound(err) {
@@ -169,12 +215,43 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
 		}
 	}
-	if err := c.removeFinalizer(ctx, node); err != nil {
+	if err := c.removeFinalizer(ctx, node, reason); err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{}, nil
 }
 
+// awaitDrainReadiness returns how much longer to wait for an external agent to signal that the node is ready to be
+// drained, e.g. once it's been deregistered from its load balancers. Only nodes with a valid
+// karpenter.sh/drain-readiness-timeout annotation wait. The start of the wait is recorded on the node with the
+// karpenter.sh/drain-readiness-requested annotation, which agents can watch for to begin their work.
+func (c *Controller) awaitDrainReadiness(ctx context.Context, node *corev1.Node) (time.Duration, error) {
+	value, ok := node.Annotations[v1.DrainReadinessTimeoutAnnotationKey]
+	if !ok || node.Annotations[v1.DrainReadyAnnotationKey] == "true" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.FromContext(ctx).Error(fmt.Errorf("invalid %s %q", v1.DrainReadinessTimeoutAnnotationKey, value), "ignoring drain readiness")
+		return 0, nil
+	}
+	requested, err := time.Parse(time.RFC3339, node.Annotations[v1.DrainReadinessRequestedAnnotationKey])
+	if err != nil {
+		stored := node.DeepCopy()
+		requested = c.clock.Now()
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DrainReadinessRequestedAnnotationKey: requested.Format(time.RFC3339)})
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			return 0, err
+		}
+		c.recorder.Publish(terminatorevents.NodeAwaitingDrainReadiness(node, timeout))
+	}
+	if remaining := requested.Add(timeout).Sub(c.clock.Now()); remaining > 0 {
+		return remaining, nil
+	}
+	c.recorder.Publish(terminatorevents.NodeDrainReadinessTimedOut(node, timeout))
+	return 0, nil
+}
+
 func (c *Controller) deleteAllNodeClaims(ctx context.Context, nodeClaims ...*v1.NodeClaim) error {
 	for _, nodeClaim := range nodeClaims {
 		// If we still get the NodeClaim, but it's already marked as terminating, we don't need to call Delete again
@@ -187,6 +264,27 @@ func (c *Controller) deleteAllNodeClaims(ctx context.Context, nodeClaims ...*v1.
 	return nil
 }
 
+// recordLocalVolumesLost publishes an event that identifies the local volumes on the node, since their data is lost
+// when the node terminates
+func (c *Controller) recordLocalVolumesLost(ctx context.Context, node *corev1.Node) error {
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return err
+	}
+	var localVolumes []string
+	for _, p := range pods {
+		lvs, err := volumeutil.GetLocalVolumes(ctx, c.kubeClient, p)
+		if err != nil {
+			return err
+		}
+		localVolumes = append(localVolumes, lo.Map(lvs, func(lv volumeutil.LocalVolume, _ int) string { return lv.String() })...)
+	}
+	if len(localVolumes) > 0 {
+		c.recorder.Publish(terminatorevents.NodeLocalVolumesLost(node, lo.Uniq(localVolumes)))
+	}
+	return nil
+}
+
 func (c *Controller) ensureVolumesDetached(ctx context.Context, node *corev1.Node) (volumesDetached bool, err error) {
 	volumeAttachments, err := nodeutils.GetVolumeAttachments(ctx, c.kubeClient, node)
 	if err != nil {
@@ -239,7 +337,7 @@ func filterVolumeAttachments(ctx context.Context, kubeClient client.Client, node
 	return filteredVolumeAttachments, nil
 }
 
-func (c *Controller) removeFinalizer(ctx context.Context, n *corev1.Node) error {
+func (c *Controller) removeFinalizer(ctx context.Context, n *corev1.Node, reason v1.TerminationReason) error {
 	stored := n.DeepCopy()
 	controllerutil.RemoveFinalizer(n, v1.TerminationFinalizer)
 	if !equality.Semantic.DeepEqual(stored, n) {
@@ -251,20 +349,18 @@ func (c *Controller) removeFinalizer(ctx context.Context, n *corev1.Node) error
 			return client.IgnoreNotFound(fmt.Errorf("removing finalizer, %w", err))
 		}
 
-		metrics.NodesTerminatedTotal.Inc(map[string]string{
+		labels := lo.Assign(terminationutil.ReasonLabels(reason), map[string]string{
 			metrics.NodePoolLabel: n.Labels[v1.NodePoolLabelKey],
 		})
+		metrics.NodesTerminatedTotal.Inc(labels)
+		terminator.NodesDrainPods.DeletePartialMatch(map[string]string{terminator.NodeNameLabel: n.Name})
 
 		// We use stored.DeletionTimestamp since the api-server may give back a node after the patch without a deletionTimestamp
-		DurationSeconds.Observe(time.Since(stored.DeletionTimestamp.Time).Seconds(), map[string]string{
-			metrics.NodePoolLabel: n.Labels[v1.NodePoolLabelKey],
-		})
+		DurationSeconds.Observe(time.Since(stored.DeletionTimestamp.Time).Seconds(), labels)
 
-		NodeLifetimeDurationSeconds.Observe(time.Since(n.CreationTimestamp.Time).Seconds(), map[string]string{
-			metrics.NodePoolLabel: n.Labels[v1.NodePoolLabelKey],
-		})
+		NodeLifetimeDurationSeconds.Observe(time.Since(n.CreationTimestamp.Time).Seconds(), labels)
 
-		log.FromContext(ctx).Info("deleted node")
+		log.FromContext(ctx).WithValues("reason", reason).Info("deleted node")
 	}
 	return nil
 }
@@ -285,10 +381,10 @@ func (c *Controller) nodeTerminationTime(node *corev1.Node, nodeClaims ...*v1.No
 	return &expirationTime, nil
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("node.termination").
-		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
 		WithOptions(
 			controller.Options{
 				RateLimiter: workqueue.NewTypedMaxOfRateLimiter[reconcile.Request](
@@ -35,10 +35,10 @@ var (
 			Namespace:  metrics.Namespace,
 			Subsystem:  metrics.NodeSubsystem,
 			Name:       "termination_duration_seconds",
-			Help:       "The time taken between a node's deletion request and the removal of its finalizer",
+			Help:       "The time taken between a node's deletion request and the removal of its finalizer. Labeled by the owning nodepool and the reason the node was terminated.",
 			Objectives: metrics.SummaryObjectives(),
 		},
-		[]string{metrics.NodePoolLabel},
+		[]string{metrics.NodePoolLabel, metrics.ReasonLabel, metrics.VoluntaryLabel},
 	)
 	NodesDrainedTotal = opmetrics.NewPrometheusCounter(
 		crmetrics.Registry,
@@ -46,9 +46,9 @@ var (
 			Namespace: metrics.Namespace,
 			Subsystem: metrics.NodeSubsystem,
 			Name:      "drained_total",
-			Help:      "The total number of nodes drained by Karpenter",
+			Help:      "The total number of nodes drained by Karpenter. Labeled by the owning nodepool and the reason the node was terminated.",
 		},
-		[]string{metrics.NodePoolLabel},
+		[]string{metrics.NodePoolLabel, metrics.ReasonLabel, metrics.VoluntaryLabel},
 	)
 	NodeLifetimeDurationSeconds = opmetrics.NewPrometheusHistogram(
 		crmetrics.Registry,
@@ -56,7 +56,7 @@ var (
 			Namespace: metrics.Namespace,
 			Subsystem: metrics.NodeSubsystem,
 			Name:      "lifetime_duration_seconds",
-			Help:      "The lifetime duration of the nodes since creation.",
+			Help:      "The lifetime duration of the nodes since creation. Labeled by the owning nodepool and the reason the node was terminated.",
 			Buckets: []float64{
 
 				(time.Minute * 15).Seconds(),
@@ -84,6 +84,6 @@ var (
 				(dayDuration * 30).Seconds(),
 			},
 		},
-		[]string{metrics.NodePoolLabel},
+		[]string{metrics.NodePoolLabel, metrics.ReasonLabel, metrics.VoluntaryLabel},
 	)
 )
@@ -18,6 +18,7 @@ package termination_test
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -36,7 +37,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
+	"sigs.k8s.io/karpenter/pkg/hooks"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
@@ -69,7 +72,7 @@ var _ = BeforeSuite(func() {
 	cloudProvider = fake.NewCloudProvider()
 	recorder = test.NewEventRecorder()
 	queue = terminator.NewTestingQueue(env.Client, recorder)
-	terminationController = termination.NewController(fakeClock, env.Client, cloudProvider, terminator.NewTerminator(fakeClock, env.Client, queue, recorder), recorder)
+	terminationController = termination.NewController(fakeClock, env.Client, cloudProvider, terminator.NewTerminator(fakeClock, env.Client, queue, recorder), recorder, hooks.NewRunner(fakeClock, env.Client, recorder))
 })
 
 var _ = AfterSuite(func() {
@@ -82,8 +85,10 @@ var _ = Describe("Termination", func() {
 	var nodePool *v1.NodePool
 
 	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
 		fakeClock.SetTime(time.Now())
 		cloudProvider.Reset()
+		recorder.Reset()
 		*queue = lo.FromPtr(terminator.NewTestingQueue(env.Client, recorder))
 
 		nodePool = test.NodePool()
@@ -190,6 +195,52 @@ var _ = Describe("Termination", func() {
 			node = ExpectNodeExists(ctx, env.Client, node.Name)
 			Expect(node.Labels[corev1.LabelNodeExcludeBalancers]).Should(Equal("karpenter"))
 		})
+		It("should delete pods in force-evict namespaces once all other pods have been evicted", func() {
+			namespace := test.Namespace()
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ForceEvictNamespaces: lo.ToPtr([]string{namespace.Name})}))
+			labels := map[string]string{"foo": "bar"}
+			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podForceEvict := test.Pod(test.PodOptions{
+				NodeName: node.Name,
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       namespace.Name,
+					Labels:          labels,
+					Annotations:     map[string]string{v1.DoNotDisruptAnnotationKey: "true"},
+					OwnerReferences: defaultOwnerRefs,
+				},
+			})
+			// Neither the fully blocking PDB nor the do-not-disrupt annotation block the deletion of the pod
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				ObjectMeta:     metav1.ObjectMeta{Namespace: namespace.Name},
+				Labels:         labels,
+				MaxUnavailable: lo.ToPtr(intstr.FromInt(0)),
+			})
+			ExpectApplied(ctx, env.Client, namespace, node, nodeClaim, podEvict, podForceEvict, pdb)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(node, podForceEvict)).To(BeFalse())
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Expect podEvict to be evicting while the pod in the force-evict namespace keeps running
+			EventuallyExpectTerminating(ctx, env.Client, podEvict)
+			podForceEvict = ExpectPodExists(ctx, env.Client, podForceEvict.Name, podForceEvict.Namespace)
+			Expect(podForceEvict.DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectDeleted(ctx, env.Client, podEvict)
+
+			// Expect the pod in the force-evict namespace to be deleted once all other pods have been evicted
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			EventuallyExpectTerminating(ctx, env.Client, podForceEvict)
+			ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectDeleted(ctx, env.Client, podForceEvict)
+
+			// Reconcile twice, once to set the NodeClaim to terminating, another to check the instance termination status (and delete the node).
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+		})
 		It("should not evict pods that tolerate karpenter disruption taint with equal operator", func() {
 			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
 			podSkip := test.Pod(test.PodOptions{
@@ -252,6 +303,83 @@ var _ = Describe("Termination", func() {
 			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should report the drain progress of the node", func() {
+			pods := test.Pods(2, test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, pods[0], pods[1])
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectMetricGaugeValue(terminator.NodesDrainPods, 0, map[string]string{terminator.NodeNameLabel: node.Name, terminator.DrainStateLabel: "evicted"})
+			ExpectMetricGaugeValue(terminator.NodesDrainPods, 2, map[string]string{terminator.NodeNameLabel: node.Name, terminator.DrainStateLabel: "remaining"})
+
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, pods[0], pods[1])
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectMetricGaugeValue(terminator.NodesDrainPods, 2, map[string]string{terminator.NodeNameLabel: node.Name, terminator.DrainStateLabel: "evicted"})
+			ExpectMetricGaugeValue(terminator.NodesDrainPods, 0, map[string]string{terminator.NodeNameLabel: node.Name, terminator.DrainStateLabel: "remaining"})
+			Expect(recorder.Calls("DrainProgress")).To(Equal(2))
+
+			// The drain progress is removed once the node is deleted
+			ExpectDeleted(ctx, env.Client, pods[0], pods[1])
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+			_, found := FindMetricWithLabelValues("karpenter_nodes_drain_pods", map[string]string{terminator.NodeNameLabel: node.Name})
+			Expect(found).To(BeFalse())
+		})
+		It("should report the drain progress in the NodeClaim status", func() {
+			pods := test.Pods(2, test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, pods[0], pods[1])
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Status.Drain).To(Equal(&v1.DrainStatus{RemainingPods: 2}))
+			Expect(nodeClaim.Status.LastPodEvictionTime.IsZero()).To(BeTrue())
+
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, pods[0], pods[1])
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Status.Drain).To(Equal(&v1.DrainStatus{TerminatingPods: 2}))
+			Expect(nodeClaim.Status.LastPodEvictionTime.IsZero()).To(BeFalse())
+		})
+		It("should report the pod and PDB that are blocking the drain in the NodeClaim status", func() {
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				Labels:         labelSelector,
+				MaxUnavailable: &intstr.IntOrString{IntVal: 0},
+			})
+			podNoEvict := test.Pod(test.PodOptions{
+				NodeName: node.Name,
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:          labelSelector,
+					OwnerReferences: defaultOwnerRefs,
+				},
+				Phase: corev1.PodRunning,
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podNoEvict, pdb)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			// Attempt to evict the pod, but fail to do so
+			ExpectSingletonReconciled(ctx, queue)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Status.Drain).To(Equal(&v1.DrainStatus{
+				RemainingPods: 1,
+				BlockingPod:   client.ObjectKeyFromObject(podNoEvict).String(),
+				BlockingPDB:   client.ObjectKeyFromObject(pdb).String(),
+			}))
+			Expect(nodeClaim.Status.LastPodEvictionTime.IsZero()).To(BeTrue())
+		})
 		It("should evict pods that tolerate the node.kubernetes.io/unschedulable taint", func() {
 			podEvict := test.Pod(test.PodOptions{
 				NodeName:    node.Name,
@@ -447,6 +575,107 @@ var _ = Describe("Termination", func() {
 			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should evict daemonset pods that opted into draining after all other pods", func() {
+			daemonSet := test.DaemonSet(test.DaemonSetOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DrainDaemonSetPodsAnnotationKey: "true"}}})
+			ExpectApplied(ctx, env.Client, daemonSet)
+
+			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podDaemon := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{
+					APIVersion:         "apps/v1",
+					Kind:               "DaemonSet",
+					Name:               daemonSet.Name,
+					UID:                daemonSet.UID,
+					Controller:         lo.ToPtr(true),
+					BlockOwnerDeletion: lo.ToPtr(true),
+				}}},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podEvict, podDaemon)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(node, podDaemon)).To(BeFalse())
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Expect podEvict to be evicting, and delete it
+			EventuallyExpectTerminating(ctx, env.Client, podEvict)
+			ExpectDeleted(ctx, env.Client, podEvict)
+
+			// Expect the daemonset pod to be evicted as the final wave
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(node, podDaemon)).To(BeTrue())
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, podDaemon)
+			ExpectDeleted(ctx, env.Client, podDaemon)
+
+			// Reconcile to delete node
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			// Reconcile twice, once to set the NodeClaim to terminating, another to check the instance termination status (and delete the node).
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+		})
+		It("should not wait for daemonset pods that opted into draining but can't be disrupted", func() {
+			daemonSet := test.DaemonSet(test.DaemonSetOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.DrainDaemonSetPodsAnnotationKey: "true"}}})
+			ExpectApplied(ctx, env.Client, daemonSet)
+			podDaemon := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion:         "apps/v1",
+						Kind:               "DaemonSet",
+						Name:               daemonSet.Name,
+						UID:                daemonSet.UID,
+						Controller:         lo.ToPtr(true),
+						BlockOwnerDeletion: lo.ToPtr(true),
+					}},
+				},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podDaemon)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(node, podDaemon)).To(BeFalse())
+
+			// The node is terminated with the daemonset pod still running
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+			ExpectExists(ctx, env.Client, podDaemon)
+		})
+		It("should not evict daemonset pods that tolerate the karpenter disruption taint without opting into draining", func() {
+			daemonSet := test.DaemonSet()
+			ExpectApplied(ctx, env.Client, daemonSet)
+			podDaemon := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{
+					APIVersion:         "apps/v1",
+					Kind:               "DaemonSet",
+					Name:               daemonSet.Name,
+					UID:                daemonSet.UID,
+					Controller:         lo.ToPtr(true),
+					BlockOwnerDeletion: lo.ToPtr(true),
+				}}},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podDaemon)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(node, podDaemon)).To(BeFalse())
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+		})
 		It("should evict non-critical pods first", func() {
 			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
 			podNodeCritical := test.Pod(test.PodOptions{NodeName: node.Name, PriorityClassName: "system-node-critical", ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
@@ -484,6 +713,33 @@ var _ = Describe("Termination", func() {
 			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should evict pods with a lower deletion cost first", func() {
+			podHighCost := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: defaultOwnerRefs,
+				Annotations:     map[string]string{corev1.PodDeletionCost: "100"},
+			}})
+			podLowCost := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: defaultOwnerRefs,
+				Annotations:     map[string]string{corev1.PodDeletionCost: "-100"},
+			}})
+			podNoCost := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podHighCost, podLowCost, podNoCost)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			// Expect the pods to be evicted in order of their deletion cost
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, podLowCost)
+			Expect(ExpectExists(ctx, env.Client, podNoCost).DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, podNoCost)
+			Expect(ExpectExists(ctx, env.Client, podHighCost).DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, podHighCost)
+		})
 		It("should not evict static pods", func() {
 			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
 			ExpectApplied(ctx, env.Client, node, nodeClaim, podEvict)
@@ -814,6 +1070,22 @@ var _ = Describe("Termination", func() {
 				ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 				ExpectNotFound(ctx, env.Client, node)
 			})
+			It("should publish an event identifying the local volumes that are lost", func() {
+				pv := test.PersistentVolume(test.PersistentVolumeOptions{UseLocal: true})
+				pvc := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: pv.Name})
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta:             metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+					PersistentVolumeClaims: []string{pvc.Name},
+				})
+				ExpectApplied(ctx, env.Client, node, nodeClaim, nodePool, pv, pvc, pod)
+				ExpectManualBinding(ctx, env.Client, pod, node)
+				Expect(env.Client.Delete(ctx, node)).To(Succeed())
+
+				ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+				ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+				Expect(recorder.Calls("LocalVolumesLost")).To(Equal(1))
+				Expect(recorder.DetectedEvent(fmt.Sprintf("Terminating node loses the data on local volumes %s (%s/%s)", pv.Name, pvc.Namespace, pvc.Name))).To(BeTrue())
+			})
 			It("should wait for volume attachments until the nodeclaim's termination grace period expires", func() {
 				va := test.VolumeAttachment(test.VolumeAttachmentOptions{
 					NodeName:   node.Name,
@@ -836,6 +1108,105 @@ var _ = Describe("Termination", func() {
 			})
 		})
 	})
+	Context("Disruption Protection", func() {
+		BeforeEach(func() {
+			node.Finalizers = append(node.Finalizers, v1.DisruptionProtectionFinalizer)
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimTerminationReasonAnnotationKey: string(v1.TerminationReasonDrift)})
+		})
+		It("should not taint or drain a protected node until its disruption is confirmed", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+			Expect(recorder.Calls("AwaitingDisruptionConfirmation")).To(Equal(1))
+		})
+		It("should taint and drain a protected node once its disruption is confirmed", func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DisruptionConfirmedAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+			Expect(recorder.Calls("AwaitingDisruptionConfirmation")).To(Equal(0))
+		})
+		It("should not wait for confirmation when the termination is involuntary", func() {
+			nodeClaim.Annotations[v1.NodeClaimTerminationReasonAnnotationKey] = string(v1.TerminationReasonUnhealthy)
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		})
+		It("should not wait for confirmation once the node's TerminationGracePeriod has elapsed", func() {
+			nodeClaim.Annotations[v1.NodeClaimTerminationTimestampAnnotationKey] = fakeClock.Now().Add(-time.Minute).Format(time.RFC3339)
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		})
+	})
+	Context("Drain Readiness", func() {
+		BeforeEach(func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DrainReadinessTimeoutAnnotationKey: "5m"})
+		})
+		It("should not drain a node until it's ready to be drained", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainReadinessRequestedAnnotationKey, fakeClock.Now().Format(time.RFC3339)))
+			Expect(recorder.Calls("AwaitingDrainReadiness")).To(Equal(1))
+		})
+		It("should terminate a node once it's ready to be drained", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			node.Annotations[v1.DrainReadyAnnotationKey] = "true"
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+		})
+		It("should terminate a node once the wait for drain readiness times out", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			fakeClock.Step(6 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+			Expect(recorder.Calls("DrainReadinessTimedOut")).To(Equal(1))
+		})
+		It("should not wait for drain readiness when the timeout is invalid", func() {
+			node.Annotations[v1.DrainReadinessTimeoutAnnotationKey] = "soon"
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectNotFound(ctx, env.Client, node)
+			Expect(recorder.Calls("AwaitingDrainReadiness")).To(Equal(0))
+		})
+	})
 	Context("Metrics", func() {
 		It("should fire the terminationSummary metric when deleting nodes", func() {
 			ExpectApplied(ctx, env.Client, node, nodeClaim)
@@ -874,6 +1245,31 @@ var _ = Describe("Termination", func() {
 			Expect(ok).To(BeTrue())
 			Expect(lo.FromPtr(m.GetHistogram().SampleCount)).To(BeNumerically("==", 1))
 		})
+		It("should label termination metrics with a manual reason when the node is deleted directly", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			labels := map[string]string{"nodepool": node.Labels[v1.NodePoolLabelKey], "reason": "manual", "voluntary": "true"}
+			ExpectMetricCounterValue(termination.NodesDrainedTotal, 1, labels)
+			ExpectMetricCounterValue(metrics.NodesTerminatedTotal, 1, labels)
+			Expect(recorder.DetectedEvent("Terminating node, reason: Manual, voluntary: true")).To(BeTrue())
+		})
+		It("should label termination metrics with the reason recorded on the nodeclaim", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimTerminationReasonAnnotationKey: string(v1.TerminationReasonUnhealthy)})
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			labels := map[string]string{"nodepool": node.Labels[v1.NodePoolLabelKey], "reason": "unhealthy", "voluntary": "false"}
+			ExpectMetricCounterValue(termination.NodesDrainedTotal, 1, labels)
+			ExpectMetricCounterValue(metrics.NodesTerminatedTotal, 1, labels)
+			Expect(recorder.DetectedEvent("Terminating node, reason: Unhealthy, voluntary: false")).To(BeTrue())
+		})
 		It("should update the eviction queueDepth metric when reconciling pods", func() {
 			minAvailable := intstr.FromInt32(0)
 			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
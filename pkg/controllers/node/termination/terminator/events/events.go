@@ -18,6 +18,7 @@ package events
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -36,6 +37,16 @@ func EvictPod(pod *corev1.Pod, message string) events.Event {
 	}
 }
 
+func EvictionRetrying(pod *corev1.Pod, attempts int, err error) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "EvictionRetrying",
+		Message:        fmt.Sprintf("Failed evicting pod after %d attempt(s), %s", attempts, err),
+		DedupeValues:   []string{string(pod.UID)},
+	}
+}
+
 func DisruptPodDelete(pod *corev1.Pod, gracePeriodSeconds *int64, nodeGracePeriodTerminationTime *time.Time) events.Event {
 	return events.Event{
 		InvolvedObject: pod,
@@ -46,6 +57,26 @@ func DisruptPodDelete(pod *corev1.Pod, gracePeriodSeconds *int64, nodeGracePerio
 	}
 }
 
+func ForceEvictPodDelete(pod *corev1.Pod) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ForceEvicted",
+		Message:        "Deleting the pod since its namespace is a force-evict namespace. This bypasses the PDB of the pod and the do-not-disrupt annotation.",
+		DedupeValues:   []string{pod.Name},
+	}
+}
+
+func DeletePod(pod *corev1.Pod) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DeletedInsteadOfEvicted",
+		Message:        "Deleting the pod rather than evicting it since its controller is configured to have its pods deleted. This bypasses the PDB of the pod.",
+		DedupeValues:   []string{pod.Name},
+	}
+}
+
 func NodeFailedToDrain(node *corev1.Node, err error) events.Event {
 	return events.Event{
 		InvolvedObject: node,
@@ -56,6 +87,26 @@ func NodeFailedToDrain(node *corev1.Node, err error) events.Event {
 	}
 }
 
+func NodeDrainProgress(node *corev1.Node, evicted, remaining int) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DrainProgress",
+		Message:        fmt.Sprintf("Draining node, %d pod(s) evicted and terminating, %d pod(s) remaining", evicted, remaining),
+		DedupeValues:   []string{node.Name, fmt.Sprint(evicted), fmt.Sprint(remaining)},
+	}
+}
+
+func NodeLocalVolumesLost(node *corev1.Node, localVolumes []string) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "LocalVolumesLost",
+		Message:        fmt.Sprintf("Terminating node loses the data on local volumes %s", strings.Join(localVolumes, ", ")),
+		DedupeValues:   []string{node.Name},
+	}
+}
+
 func NodeTerminationGracePeriodExpiring(node *corev1.Node, terminationTime string) events.Event {
 	return events.Event{
 		InvolvedObject: node,
@@ -75,3 +126,57 @@ func NodeClaimTerminationGracePeriodExpiring(nodeClaim *v1.NodeClaim, terminatio
 		DedupeValues:   []string{nodeClaim.Name},
 	}
 }
+
+func NodeTerminating(node *corev1.Node, reason v1.TerminationReason) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Terminating",
+		Message:        fmt.Sprintf("Terminating node, %s", terminationMessage(reason)),
+		DedupeValues:   []string{string(node.UID)},
+	}
+}
+
+func NodeClaimTerminating(nodeClaim *v1.NodeClaim, reason v1.TerminationReason) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Terminating",
+		Message:        fmt.Sprintf("Terminating nodeclaim, %s", terminationMessage(reason)),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func terminationMessage(reason v1.TerminationReason) string {
+	return fmt.Sprintf("reason: %s, voluntary: %t", reason, reason.IsVoluntary())
+}
+
+func NodeAwaitingDisruptionConfirmation(node *corev1.Node, reason v1.TerminationReason) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "AwaitingDisruptionConfirmation",
+		Message:        fmt.Sprintf("Waiting for the %s annotation before draining node that hosts pods with disruption protection, %s", v1.DisruptionConfirmedAnnotationKey, terminationMessage(reason)),
+		DedupeValues:   []string{string(node.UID)},
+	}
+}
+
+func NodeAwaitingDrainReadiness(node *corev1.Node, timeout time.Duration) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "AwaitingDrainReadiness",
+		Message:        fmt.Sprintf("Waiting up to %s for the %s annotation before draining node", timeout, v1.DrainReadyAnnotationKey),
+		DedupeValues:   []string{string(node.UID)},
+	}
+}
+
+func NodeDrainReadinessTimedOut(node *corev1.Node, timeout time.Duration) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "DrainReadinessTimedOut",
+		Message:        fmt.Sprintf("Draining node without the %s annotation after waiting %s", v1.DrainReadyAnnotationKey, timeout),
+		DedupeValues:   []string{string(node.UID)},
+	}
+}
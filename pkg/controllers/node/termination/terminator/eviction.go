@@ -29,6 +29,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
@@ -44,14 +45,46 @@ import (
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/node"
 )
 
 const (
 	evictionQueueBaseDelay = 100 * time.Millisecond
 	evictionQueueMaxDelay  = 10 * time.Second
+	// evictionBatchSize is the maximum number of pods that are evicted in a single reconcile
+	evictionBatchSize = 50
 )
 
+// Strategy is how the eviction queue removes a pod from its node
+type Strategy string
+
+const (
+	// StrategyEvict evicts the pod through the Eviction API, which respects the pod's PDBs
+	StrategyEvict Strategy = "Evict"
+	// StrategyDelete deletes the pod directly, for pods whose controllers retry evicted pods in ways that conflict with
+	// their own retry logic
+	StrategyDelete Strategy = "Delete"
+)
+
+// StrategyFor returns the strategy that removes the pod from its node, which is selected by the group kind of the pod's
+// controller. Pods whose controllers are configured with --delete-instead-of-evict-owners are deleted.
+func StrategyFor(ctx context.Context, pod *corev1.Pod) Strategy {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return StrategyEvict
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return StrategyEvict
+	}
+	gk := schema.GroupKind{Group: gv.Group, Kind: owner.Kind}
+	if lo.ContainsBy(options.FromContext(ctx).DeleteInsteadOfEvictOwners, func(o string) bool { return schema.ParseGroupKind(o) == gk }) {
+		return StrategyDelete
+	}
+	return StrategyEvict
+}
+
 type NodeDrainError struct {
 	error
 }
@@ -82,11 +115,20 @@ func NewQueueKey(pod *corev1.Pod, providerID string) QueueKey {
 	}
 }
 
+// evictionAttempts tracks the failed eviction attempts for a pod in the queue
+type evictionAttempts struct {
+	count     int
+	lastError error
+}
+
 type Queue struct {
 	workqueue.TypedRateLimitingInterface[QueueKey]
 
-	mu  sync.Mutex
-	set sets.Set[QueueKey]
+	mu       sync.Mutex
+	set      sets.Set[QueueKey]
+	attempts map[QueueKey]*evictionAttempts
+	// lastEvictions are the times that pods were last evicted from each node by provider ID
+	lastEvictions map[string]time.Time
 
 	kubeClient client.Client
 	recorder   events.Recorder
@@ -99,9 +141,11 @@ func NewQueue(kubeClient client.Client, recorder events.Recorder) *Queue {
 			workqueue.TypedRateLimitingQueueConfig[QueueKey]{
 				Name: "eviction.workqueue",
 			}),
-		set:        sets.New[QueueKey](),
-		kubeClient: kubeClient,
-		recorder:   recorder,
+		set:           sets.New[QueueKey](),
+		attempts:      map[QueueKey]*evictionAttempts{},
+		lastEvictions: map[string]time.Time{},
+		kubeClient:    kubeClient,
+		recorder:      recorder,
 	}
 }
 
@@ -109,6 +153,8 @@ func NewTestingQueue(kubeClient client.Client, recorder events.Recorder) *Queue
 	return &Queue{
 		TypedRateLimitingInterface: &controllertest.TypedQueue[QueueKey]{TypedInterface: workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[QueueKey]{Name: "eviction.workqueue"})},
 		set:                        sets.New[QueueKey](),
+		attempts:                   map[QueueKey]*evictionAttempts{},
+		lastEvictions:              map[string]time.Time{},
 		kubeClient:                 kubeClient,
 		recorder:                   recorder,
 	}
@@ -121,12 +167,15 @@ func (q *Queue) Register(_ context.Context, m manager.Manager) error {
 		Complete(singleton.AsReconciler(q))
 }
 
-// Add adds pods to the Queue
+// Add adds pods to the Queue. Pods that are already being deleted aren't added since evicting them is a no-op.
 func (q *Queue) Add(node *corev1.Node, pods ...*corev1.Pod) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	for _, pod := range pods {
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
 		qk := NewQueueKey(pod, node.Spec.ProviderID)
 		if !q.set.Has(qk) {
 			q.set.Insert(qk)
@@ -150,45 +199,142 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	if q.TypedRateLimitingInterface.Len() == 0 {
 		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
 	}
-	// Get pod from queue. This waits until queue is non-empty.
-	item, shutdown := q.TypedRateLimitingInterface.Get()
-	if shutdown {
+	// Get a batch of pods from the queue so that the pods of a node are evicted together rather than interleaved
+	// with the pods of other nodes that are draining at the same time
+	var items []QueueKey
+	for len(items) < evictionBatchSize && q.TypedRateLimitingInterface.Len() > 0 {
+		item, shutdown := q.TypedRateLimitingInterface.Get()
+		if shutdown {
+			break
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
 		return reconcile.Result{}, fmt.Errorf("EvictionQueue is broken and has shutdown")
 	}
+	batches := lo.GroupBy(items, func(item QueueKey) string { return item.providerID })
+	for _, providerID := range lo.Uniq(lo.Map(items, func(item QueueKey, _ int) string { return item.providerID })) {
+		for _, item := range batches[providerID] {
+			q.evict(ctx, item)
+		}
+	}
+	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
+}
 
+// evict evicts a pod that was popped from the queue and requeues it if the eviction failed
+func (q *Queue) evict(ctx context.Context, item QueueKey) {
 	defer q.TypedRateLimitingInterface.Done(item)
 
-	// Evict the pod
 	if q.Evict(ctx, item) {
 		q.TypedRateLimitingInterface.Forget(item)
 		q.mu.Lock()
 		q.set.Delete(item)
+		NodesEvictionAttempts.Observe(float64(lo.FromPtr(q.attempts[item]).count+1), nil)
+		delete(q.attempts, item)
 		q.mu.Unlock()
-		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
+		return
 	}
-
 	// Requeue pod if eviction failed
 	q.TypedRateLimitingInterface.AddRateLimited(item)
-	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
+}
+
+// Attempts returns the number of failed eviction attempts for the pod and the error from the last failed attempt
+func (q *Queue) Attempts(key QueueKey) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	attempts, ok := q.attempts[key]
+	if !ok {
+		return 0, nil
+	}
+	return attempts.count, attempts.lastError
+}
+
+// Blocking returns the pod of the node with the provider ID whose eviction failed the most times, along with the error
+// from its last failed attempt
+func (q *Queue) Blocking(providerID string) (QueueKey, error, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var blocking QueueKey
+	var attempts *evictionAttempts
+	for key, a := range q.attempts {
+		if key.providerID != providerID {
+			continue
+		}
+		if attempts == nil || a.count > attempts.count || (a.count == attempts.count && key.String() < blocking.String()) {
+			blocking, attempts = key, a
+		}
+	}
+	if attempts == nil {
+		return QueueKey{}, nil, false
+	}
+	return blocking, attempts.lastError, true
+}
+
+// LastEviction returns the last time that a pod was evicted from the node with the provider ID
+func (q *Queue) LastEviction(providerID string) (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, ok := q.lastEvictions[providerID]
+	return t, ok
+}
+
+// ForgetEvictions stops tracking the evictions from the node with the provider ID once it has drained
+func (q *Queue) ForgetEvictions(providerID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.lastEvictions, providerID)
+}
+
+// recordFailure records a failed eviction attempt for the pod
+func (q *Queue) recordFailure(key QueueKey, err error) {
+	q.mu.Lock()
+	attempts, ok := q.attempts[key]
+	if !ok {
+		attempts = &evictionAttempts{}
+		q.attempts[key] = attempts
+	}
+	attempts.count++
+	attempts.lastError = err
+	count := attempts.count
+	q.mu.Unlock()
+	q.recorder.Publish(terminatorevents.EvictionRetrying(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      key.Name,
+		Namespace: key.Namespace,
+		UID:       key.UID,
+	}}, count, err))
 }
 
 // Evict returns true if successful eviction call, and false if there was an eviction-related error
 func (q *Queue) Evict(ctx context.Context, key QueueKey) bool {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Pod", klog.KRef(key.Namespace, key.Name)))
+	// The pod may have started deleting after it was added to the queue (e.g. a previous eviction succeeded, or the pod
+	// was deleted by its owner). Evicting it again is a no-op, and for PDB protected pods would only spin API calls
+	// against the eviction API, so consider it evicted.
+	pod := &corev1.Pod{}
+	if err := q.kubeClient.Get(ctx, key.NamespacedName, pod); err == nil && pod.UID == key.UID {
+		if !pod.DeletionTimestamp.IsZero() {
+			return true
+		}
+		if StrategyFor(ctx, pod) == StrategyDelete {
+			return q.delete(ctx, key, pod)
+		}
+	}
 	evictionMessage, err := evictionReason(ctx, key, q.kubeClient)
 	if err != nil {
 		// XXX(cmcavoy): this should be unreachable, but we log it if it happens
 		log.FromContext(ctx).V(1).Error(err, "failed looking up pod eviction reason")
 	}
-	if err := q.kubeClient.SubResource("eviction").Create(ctx,
-		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}},
-		&policyv1.Eviction{
-			DeleteOptions: &metav1.DeleteOptions{
-				Preconditions: &metav1.Preconditions{
-					UID: lo.ToPtr(key.UID),
-				},
-			},
-		}); err != nil {
+	// Pre-check the pod's PDBs with a dry-run eviction. Evictions that would violate a PDB are retried with backoff
+	// without making the eviction request, which would otherwise be rejected with a 429 on every attempt.
+	if err := q.createEviction(ctx, key, metav1.DryRunAll); apierrors.IsTooManyRequests(err) {
+		q.recordPDBViolation(key, err)
+		return false
+	}
+	if err := q.createEviction(ctx, key); err != nil {
 		var apiStatus apierrors.APIStatus
 		if errors.As(err, &apiStatus) {
 			code := apiStatus.Status().Code
@@ -204,20 +350,63 @@ func (q *Queue) Evict(ctx context.Context, key QueueKey) bool {
 			return true
 		}
 		if apierrors.IsTooManyRequests(err) { // 429 - PDB violation
-			q.recorder.Publish(terminatorevents.NodeFailedToDrain(&corev1.Node{ObjectMeta: metav1.ObjectMeta{
-				Name:      key.Name,
-				Namespace: key.Namespace,
-			}}, fmt.Errorf("evicting pod %s/%s violates a PDB", key.Namespace, key.Name)))
+			q.recordPDBViolation(key, err)
 			return false
 		}
 		log.FromContext(ctx).Error(err, "failed evicting pod")
+		q.recordFailure(key, err)
 		return false
 	}
 	NodesEvictionRequestsTotal.Inc(map[string]string{CodeLabel: "200"})
+	q.mu.Lock()
+	q.lastEvictions[key.providerID] = time.Now()
+	q.mu.Unlock()
 	q.recorder.Publish(terminatorevents.EvictPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}, evictionMessage))
 	return true
 }
 
+// delete deletes a pod whose owner conflicts with eviction, rather than evicting it. Deleting the pod bypasses its PDBs.
+func (q *Queue) delete(ctx context.Context, key QueueKey, pod *corev1.Pod) bool {
+	if err := q.kubeClient.Delete(ctx, pod, client.Preconditions{UID: lo.ToPtr(key.UID)}); err != nil {
+		// 404 - The pod no longer exists
+		// 409 - The pod exists, but it is not the same pod that was added to the queue
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			return true
+		}
+		log.FromContext(ctx).Error(err, "failed deleting pod")
+		q.recordFailure(key, err)
+		return false
+	}
+	q.mu.Lock()
+	q.lastEvictions[key.providerID] = time.Now()
+	q.mu.Unlock()
+	q.recorder.Publish(terminatorevents.DeletePod(pod))
+	return true
+}
+
+// createEviction creates an eviction for the pod, which is only validated and not persisted when dryRun is set
+func (q *Queue) createEviction(ctx context.Context, key QueueKey, dryRun ...string) error {
+	return q.kubeClient.SubResource("eviction").Create(ctx,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}},
+		&policyv1.Eviction{
+			DeleteOptions: &metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{
+					UID: lo.ToPtr(key.UID),
+				},
+				DryRun: dryRun,
+			},
+		})
+}
+
+// recordPDBViolation records a failed eviction attempt for a pod whose eviction was rejected by a PDB
+func (q *Queue) recordPDBViolation(key QueueKey, err error) {
+	q.recorder.Publish(terminatorevents.NodeFailedToDrain(&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:      key.Name,
+		Namespace: key.Namespace,
+	}}, fmt.Errorf("evicting pod %s/%s violates a PDB", key.Namespace, key.Name)))
+	q.recordFailure(key, err)
+}
+
 func evictionReason(ctx context.Context, key QueueKey, kubeClient client.Client) (string, error) {
 	nodeClaim, err := node.NodeClaimForNode(ctx, kubeClient, &corev1.Node{Spec: corev1.NodeSpec{ProviderID: key.providerID}})
 	if err != nil {
@@ -27,6 +27,10 @@ import (
 const (
 	// CodeLabel for eviction request
 	CodeLabel = "code"
+	// NodeNameLabel for drain progress
+	NodeNameLabel = "node_name"
+	// DrainStateLabel for drain progress, either "evicted" or "remaining"
+	DrainStateLabel = "state"
 )
 
 var NodesEvictionRequestsTotal = opmetrics.NewPrometheusCounter(
@@ -39,3 +43,26 @@ var NodesEvictionRequestsTotal = opmetrics.NewPrometheusCounter(
 	},
 	[]string{CodeLabel},
 )
+
+var NodesEvictionAttempts = opmetrics.NewPrometheusHistogram(
+	crmetrics.Registry,
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: metrics.NodeSubsystem,
+		Name:      "eviction_attempts",
+		Help:      "The number of eviction requests made by Karpenter for a pod before it was successfully evicted",
+		Buckets:   []float64{1, 2, 3, 5, 10, 25, 50, 100, 250, 500},
+	},
+	[]string{},
+)
+
+var NodesDrainPods = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: metrics.NodeSubsystem,
+		Name:      "drain_pods",
+		Help:      "The number of pods on a draining node that have been evicted and are terminating, or that remain to be evicted",
+	},
+	[]string{NodeNameLabel, DrainStateLabel},
+)
@@ -27,6 +27,7 @@ import (
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -128,6 +129,13 @@ var _ = Describe("Eviction/Queue", func() {
 			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeFalse())
 			Expect(recorder.Calls("FailedDraining")).To(Equal(1))
 		})
+		It("should not make the eviction request when a dry-run eviction is blocked by a PDB", func() {
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeFalse())
+			_, found := FindMetricWithLabelValues("karpenter_nodes_eviction_requests_total", map[string]string{terminator.CodeLabel: "429"})
+			Expect(found).To(BeFalse())
+			ExpectPodExists(ctx, env.Client, pod.Name, pod.Namespace)
+		})
 		It("should fail when two PDBs refer to the same pod", func() {
 			pdb2 := test.PodDisruptionBudget(test.PDBOptions{
 				Labels:         testLabels,
@@ -137,6 +145,62 @@ var _ = Describe("Eviction/Queue", func() {
 			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeFalse())
 			ExpectMetricCounterValue(terminator.NodesEvictionRequestsTotal, 1, map[string]string{terminator.CodeLabel: "500"})
 		})
+		It("should succeed without calling the eviction API when the pod is already being deleted", func() {
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			ExpectDeletionTimestampSet(ctx, env.Client, pod)
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeTrue())
+			Expect(recorder.Calls("FailedDraining")).To(Equal(0))
+		})
+		It("should not add pods that are already being deleted to the queue", func() {
+			pod.DeletionTimestamp = lo.ToPtr(metav1.Now())
+			queue.Add(node, pod)
+			Expect(queue.Has(node, pod)).To(BeFalse())
+		})
+		It("should track the number of failed eviction attempts and the last error", func() {
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			key := terminator.NewQueueKey(pod, node.Spec.ProviderID)
+			Expect(queue.Evict(ctx, key)).To(BeFalse())
+			Expect(queue.Evict(ctx, key)).To(BeFalse())
+			attempts, err := queue.Attempts(key)
+			Expect(attempts).To(Equal(2))
+			Expect(apierrors.IsTooManyRequests(err)).To(BeTrue())
+			Expect(recorder.Calls("EvictionRetrying")).To(Equal(2))
+		})
+		It("should report the pod that is blocking the node's drain", func() {
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			key := terminator.NewQueueKey(pod, node.Spec.ProviderID)
+			Expect(queue.Evict(ctx, key)).To(BeFalse())
+			blocking, err, ok := queue.Blocking(node.Spec.ProviderID)
+			Expect(ok).To(BeTrue())
+			Expect(blocking).To(Equal(key))
+			Expect(apierrors.IsTooManyRequests(err)).To(BeTrue())
+			_, _, ok = queue.Blocking("987654321")
+			Expect(ok).To(BeFalse())
+		})
+		It("should track the last time that a pod was evicted from the node", func() {
+			ExpectApplied(ctx, env.Client, pod)
+			_, ok := queue.LastEviction(node.Spec.ProviderID)
+			Expect(ok).To(BeFalse())
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeTrue())
+			_, ok = queue.LastEviction(node.Spec.ProviderID)
+			Expect(ok).To(BeTrue())
+			queue.ForgetEvictions(node.Spec.ProviderID)
+			_, ok = queue.LastEviction(node.Spec.ProviderID)
+			Expect(ok).To(BeFalse())
+		})
+		It("should evict the pods of multiple nodes in a single reconcile", func() {
+			otherNode := test.Node(test.NodeOptions{ProviderID: "987654321"})
+			pods := test.Pods(4)
+			ExpectApplied(ctx, env.Client, pods[0], pods[1], pods[2], pods[3])
+			queue.Add(node, pods[0], pods[1])
+			queue.Add(otherNode, pods[2], pods[3])
+			ExpectSingletonReconciled(ctx, queue)
+			EventuallyExpectTerminating(ctx, env.Client, lo.Map(pods, func(p *corev1.Pod, _ int) client.Object { return p })...)
+			ExpectMetricCounterValue(terminator.NodesEvictionRequestsTotal, 4, map[string]string{terminator.CodeLabel: "200"})
+			for _, p := range pods {
+				Expect(queue.Has(node, p) || queue.Has(otherNode, p)).To(BeFalse())
+			}
+		})
 		It("should ensure that calling Evict() is valid while making Add() calls", func() {
 			cancelCtx, cancel := context.WithCancel(ctx)
 			wg := sync.WaitGroup{}
@@ -165,6 +229,46 @@ var _ = Describe("Eviction/Queue", func() {
 		})
 	})
 
+	Context("Delete Strategy", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DeleteInsteadOfEvictOwners: lo.ToPtr([]string{"Workflow.argoproj.io"})}))
+			pod.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: "argoproj.io/v1alpha1",
+				Kind:       "Workflow",
+				Name:       "workflow",
+				UID:        uuid.NewUUID(),
+				Controller: lo.ToPtr(true),
+			}}
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should select the strategy by the group kind of the pod's controller", func() {
+			Expect(terminator.StrategyFor(ctx, pod)).To(Equal(terminator.StrategyDelete))
+			pod.OwnerReferences[0].APIVersion = "argoproj.io/v1"
+			Expect(terminator.StrategyFor(ctx, pod)).To(Equal(terminator.StrategyDelete))
+			pod.OwnerReferences[0].Kind = "WorkflowTemplate"
+			Expect(terminator.StrategyFor(ctx, pod)).To(Equal(terminator.StrategyEvict))
+			pod.OwnerReferences = nil
+			Expect(terminator.StrategyFor(ctx, pod)).To(Equal(terminator.StrategyEvict))
+		})
+		It("should delete rather than evict pods whose controllers are configured to be deleted", func() {
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeTrue())
+			ExpectNotFound(ctx, env.Client, pod)
+			Expect(recorder.Calls("DeletedInsteadOfEvicted")).To(Equal(1))
+			Expect(recorder.Calls("FailedDraining")).To(Equal(0))
+			_, found := FindMetricWithLabelValues("karpenter_nodes_eviction_requests_total", map[string]string{terminator.CodeLabel: "200"})
+			Expect(found).To(BeFalse())
+		})
+		It("should evict pods whose controllers aren't configured to be deleted", func() {
+			pod.OwnerReferences[0].Kind = "WorkflowTemplate"
+			ExpectApplied(ctx, env.Client, pdb, pod)
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod, node.Spec.ProviderID))).To(BeFalse())
+			ExpectPodExists(ctx, env.Client, pod.Name, pod.Namespace)
+			Expect(recorder.Calls("FailedDraining")).To(Equal(1))
+		})
+	})
 	Context("Pod Deletion API", func() {
 		It("should not delete a pod with no nodeTerminationTime", func() {
 			ExpectApplied(ctx, env.Client, pod)
@@ -19,19 +19,24 @@ package terminator
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
 	"sigs.k8s.io/karpenter/pkg/events"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -104,8 +109,19 @@ func (t *Terminator) Drain(ctx context.Context, node *corev1.Node, nodeGracePeri
 	if err := t.DeleteExpiringPods(ctx, podsToDelete, nodeGracePeriodExpirationTime); err != nil {
 		return fmt.Errorf("deleting expiring pods, %w", err)
 	}
+	if err := t.recordDrainProgress(ctx, node, pods); err != nil {
+		return fmt.Errorf("recording drain progress, %w", err)
+	}
+	// Pods in force-evict namespaces are deleted once all other pods have been evicted
+	forceEvictedPods, pods := lo.FilterReject(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsForceEvicted(ctx, p) })
+	daemonSetPods, err := t.daemonSetPodsToDrain(ctx, node, pods)
+	if err != nil {
+		return fmt.Errorf("resolving daemonset pods to drain, %w", err)
+	}
 	// Monitor pods in pod groups that either haven't been evicted or are actively evicting
-	podGroups := t.groupPodsByPriority(lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) }))
+	podGroups := t.groupPodsByPriority(lo.Filter(pods, func(p *corev1.Pod, _ int) bool {
+		return podutil.IsWaitingEviction(p, t.clock) && !lo.Contains(daemonSetPods, p)
+	}))
 	for _, group := range podGroups {
 		if len(group) > 0 {
 			// Only add pods to the eviction queue that haven't been evicted yet
@@ -113,9 +129,118 @@ func (t *Terminator) Drain(ctx context.Context, node *corev1.Node, nodeGracePeri
 			return NewNodeDrainError(fmt.Errorf("%d pods are waiting to be evicted", lo.SumBy(podGroups, func(pods []*corev1.Pod) int { return len(pods) })))
 		}
 	}
+	// DaemonSet pods that have opted into draining are evicted as a final wave, once all other pods have terminated
+	if len(daemonSetPods) > 0 {
+		t.evictionQueue.Add(node, lo.Filter(daemonSetPods, func(p *corev1.Pod, _ int) bool { return podutil.IsActive(p) })...)
+		return NewNodeDrainError(fmt.Errorf("%d daemonset pods are waiting to be evicted", len(daemonSetPods)))
+	}
+	if err := t.deleteForceEvictedPods(ctx, forceEvictedPods); err != nil {
+		return err
+	}
+	t.evictionQueue.ForgetEvictions(node.Spec.ProviderID)
+	return nil
+}
+
+// recordDrainProgress records the number of pods on the node that have been evicted and are terminating, and the number
+// of pods that remain to be evicted. The progress is also reported in the status of the node's NodeClaim, along with
+// the last time that a pod was evicted and the pod that has failed to be evicted the most, so that drains that are
+// stuck can be diagnosed without searching through events.
+func (t *Terminator) recordDrainProgress(ctx context.Context, node *corev1.Node, pods []*corev1.Pod) error {
+	pods = lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) })
+	evicted := lo.CountBy(pods, podutil.IsTerminating)
+	remaining := len(pods) - evicted
+	NodesDrainPods.Set(float64(evicted), map[string]string{NodeNameLabel: node.Name, DrainStateLabel: "evicted"})
+	NodesDrainPods.Set(float64(remaining), map[string]string{NodeNameLabel: node.Name, DrainStateLabel: "remaining"})
+	if len(pods) > 0 {
+		t.recorder.Publish(terminatorevents.NodeDrainProgress(node, evicted, remaining))
+	}
+
+	nodeClaim, err := nodeutils.NodeClaimForNode(ctx, t.kubeClient, node)
+	if err != nil {
+		return nodeutils.IgnoreNodeClaimNotFoundError(nodeutils.IgnoreDuplicateNodeClaimError(err))
+	}
+	stored := nodeClaim.DeepCopy()
+	drain := &v1.DrainStatus{RemainingPods: int32(remaining), TerminatingPods: int32(evicted)}
+	if key, evictionErr, ok := t.evictionQueue.Blocking(node.Spec.ProviderID); ok {
+		if pod, found := lo.Find(pods, func(p *corev1.Pod) bool { return p.UID == key.UID && !podutil.IsTerminating(p) }); found {
+			drain.BlockingPod = key.String()
+			if apierrors.IsTooManyRequests(evictionErr) {
+				limits, err := pdb.NewLimits(ctx, t.clock, t.kubeClient)
+				if err != nil {
+					return fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+				}
+				if pdbKey, evictable := limits.CanEvictPods([]*corev1.Pod{pod}); !evictable {
+					drain.BlockingPDB = pdbKey.String()
+				}
+			}
+		}
+	}
+	nodeClaim.Status.Drain = drain
+	if lastEviction, ok := t.evictionQueue.LastEviction(node.Spec.ProviderID); ok && lastEviction.After(nodeClaim.Status.LastPodEvictionTime.Time) {
+		nodeClaim.Status.LastPodEvictionTime = metav1.NewTime(lastEviction)
+	}
+	if equality.Semantic.DeepEqual(nodeClaim, stored) {
+		return nil
+	}
+	if err := t.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
 	return nil
 }
 
+// deleteForceEvictedPods deletes the pods in force-evict namespaces that are waiting to be removed from the node. The
+// pods are deleted rather than evicted, which bypasses their PDBs and do-not-disrupt annotations.
+func (t *Terminator) deleteForceEvictedPods(ctx context.Context, pods []*corev1.Pod) error {
+	pods = lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) })
+	if len(pods) == 0 {
+		return nil
+	}
+	for _, pod := range lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return !podutil.IsTerminating(p) }) {
+		t.recorder.Publish(terminatorevents.ForceEvictPodDelete(pod))
+		if err := t.kubeClient.Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) { // ignore 404, not a problem
+			return fmt.Errorf("deleting pod, %w", err)
+		}
+		log.FromContext(ctx).WithValues("namespace", pod.Namespace, "name", pod.Name).V(1).Info("deleting pod in force-evict namespace")
+	}
+	return NewNodeDrainError(fmt.Errorf("%d pods in force-evict namespaces are waiting to be deleted", len(pods)))
+}
+
+// daemonSetPodsToDrain returns the DaemonSet pods on the node that have opted into being drained through the
+// karpenter.sh/drain-daemonset-pods annotation on either the node or their DaemonSet. DaemonSet pods typically tolerate
+// the karpenter.sh/disrupted taint and are left running until the instance is terminated; opting in allows DaemonSets
+// such as log shippers to shut down in an orderly fashion before the node is deleted. Pods that are created after the
+// node started terminating are ignored since the DaemonSet controller will continue to replace the pods that we evict.
+// Pods with the karpenter.sh/do-not-disrupt annotation are left running until the instance is terminated.
+func (t *Terminator) daemonSetPodsToDrain(ctx context.Context, node *corev1.Node, pods []*corev1.Pod) ([]*corev1.Pod, error) {
+	nodeOptedIn := node.Annotations[v1.DrainDaemonSetPodsAnnotationKey] == "true"
+	optedIn := map[client.ObjectKey]bool{}
+	var daemonSetPods []*corev1.Pod
+	for _, p := range pods {
+		if !podutil.IsOwnedByDaemonSet(p) || podutil.IsTerminal(p) || podutil.IsStuckTerminating(p, t.clock) || podutil.HasDoNotDisrupt(p) {
+			continue
+		}
+		if !node.DeletionTimestamp.IsZero() && p.CreationTimestamp.After(node.DeletionTimestamp.Time) {
+			continue
+		}
+		if !nodeOptedIn {
+			owner, _ := lo.Find(p.OwnerReferences, func(o metav1.OwnerReference) bool { return o.Kind == "DaemonSet" })
+			key := client.ObjectKey{Namespace: p.Namespace, Name: owner.Name}
+			if _, ok := optedIn[key]; !ok {
+				daemonSet := &appsv1.DaemonSet{}
+				if err := t.kubeClient.Get(ctx, key, daemonSet); client.IgnoreNotFound(err) != nil {
+					return nil, fmt.Errorf("getting daemonset, %w", err)
+				}
+				optedIn[key] = daemonSet.Annotations[v1.DrainDaemonSetPodsAnnotationKey] == "true"
+			}
+			if !optedIn[key] {
+				continue
+			}
+		}
+		daemonSetPods = append(daemonSetPods, p)
+	}
+	return daemonSetPods, nil
+}
+
 func (t *Terminator) groupPodsByPriority(pods []*corev1.Pod) [][]*corev1.Pod {
 	// 1. Prioritize noncritical pods, non-daemon pods https://kubernetes.io/docs/concepts/architecture/nodes/#graceful-node-shutdown
 	var nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon []*corev1.Pod
@@ -134,7 +259,13 @@ func (t *Terminator) groupPodsByPriority(pods []*corev1.Pod) [][]*corev1.Pod {
 			}
 		}
 	}
-	return [][]*corev1.Pod{nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon}
+	groups := [][]*corev1.Pod{nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon}
+	// 2. Within each group, evict the pods with the lowest controller.kubernetes.io/pod-deletion-cost first, matching the
+	// order that their controllers scale them down in
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return podutil.DeletionCost(group[i]) < podutil.DeletionCost(group[j]) })
+	}
+	return groups
 }
 
 func (t *Terminator) DeleteExpiringPods(ctx context.Context, pods []*corev1.Pod, nodeGracePeriodTerminationTime *time.Time) error {
@@ -0,0 +1,161 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accuracy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+const (
+	instanceTypeLabel = "instance_type"
+	resourceTypeLabel = "resource_type"
+	kindLabel         = "kind"
+
+	capacityKind    = "capacity"
+	allocatableKind = "allocatable"
+
+	// threshold is the relative difference between the actual and predicted resources of a node that is reported
+	// through an event
+	threshold = 0.05
+	// window is how long after a NodeClaim initializes that its node is compared. NodeClaims that initialized before
+	// the window, e.g. before the controller restarted, aren't compared again.
+	window = 10 * time.Minute
+)
+
+var ResourcePredictionErrorRatio = opmetrics.NewPrometheusHistogram(
+	crmetrics.Registry,
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: metrics.NodeClaimSubsystem,
+		Name:      "resource_prediction_error_ratio",
+		Help:      "The relative difference between the resources that the kubelet reported when a node initialized and the resources that were predicted for its NodeClaim, i.e. (actual - predicted) / predicted. Negative values mean fewer resources were available than were simulated. Labeled by nodepool, instance type, resource type and whether capacity or allocatable was compared.",
+		Buckets:   []float64{-0.5, -0.25, -0.1, -0.05, -0.02, -0.01, 0, 0.01, 0.02, 0.05, 0.1, 0.25, 0.5},
+	},
+	[]string{metrics.NodePoolLabel, instanceTypeLabel, resourceTypeLabel, kindLabel},
+)
+
+// Controller compares the capacity and allocatable resources that were predicted for a NodeClaim with the resources
+// that the kubelet reports once its node initializes, so that systemic misestimates in scheduling simulations, e.g.
+// of memory overhead or max pods, are caught early
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+	compared      *cache.Cache
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+		compared:      cache.New(window, time.Minute),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.accuracy")
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) || !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	initialized := nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized)
+	if !initialized.IsTrue() || c.clock.Since(initialized.LastTransitionTime.Time) > window {
+		return reconcile.Result{}, nil
+	}
+	if _, ok := c.compared.Get(string(nodeClaim.UID)); ok {
+		return reconcile.Result{}, nil
+	}
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, nodeclaimutils.IgnoreDuplicateNodeError(nodeclaimutils.IgnoreNodeNotFoundError(err))
+	}
+	c.compared.SetDefault(string(nodeClaim.UID), struct{}{})
+
+	var mismatches []string
+	mismatches = append(mismatches, c.compare(nodeClaim, capacityKind, nodeClaim.Status.Capacity, node.Status.Capacity)...)
+	mismatches = append(mismatches, c.compare(nodeClaim, allocatableKind, nodeClaim.Status.Allocatable, node.Status.Allocatable)...)
+	if len(mismatches) > 0 {
+		c.recorder.Publish(ResourcePredictionMismatchEvent(nodeClaim, strings.Join(mismatches, ", ")))
+	}
+	return reconcile.Result{}, nil
+}
+
+// compare observes the error of each predicted resource and returns a description of the errors that exceed the
+// threshold
+func (c *Controller) compare(nodeClaim *v1.NodeClaim, kind string, predicted, actual corev1.ResourceList) []string {
+	var mismatches []string
+	names := lo.Keys(predicted)
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		p, a := predicted[name], actual[name]
+		if p.IsZero() {
+			continue
+		}
+		ratio := (a.AsApproximateFloat64() - p.AsApproximateFloat64()) / p.AsApproximateFloat64()
+		ResourcePredictionErrorRatio.Observe(ratio, map[string]string{
+			metrics.NodePoolLabel: nodeClaim.Labels[v1.NodePoolLabelKey],
+			instanceTypeLabel:     nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+			resourceTypeLabel:     string(name),
+			kindLabel:             kind,
+		})
+		if math.Abs(ratio) > threshold {
+			mismatches = append(mismatches, fmt.Sprintf("predicted %s %s of %s but found %s (%+0.1f%%)", kind, name, p.String(), a.String(), ratio*100))
+		}
+	}
+	return mismatches
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.accuracy").
+		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
+		Watches(
+			&corev1.Node{},
+			nodeclaimutils.NodeEventHandler(c.kubeClient, c.cloudProvider),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,175 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accuracy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/accuracy"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var accuracyController *accuracy.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cp *fake.CloudProvider
+var recorder *test.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Accuracy")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(
+		test.WithCRDs(apis.CRDs...),
+		test.WithCRDs(v1alpha1.CRDs...),
+		test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx), test.NodeProviderIDFieldIndexer(ctx)),
+	)
+	ctx = options.ToContext(ctx, test.Options())
+	cp = fake.NewCloudProvider()
+	recorder = test.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	fakeClock.SetTime(time.Now())
+	recorder.Reset()
+	accuracy.ResourcePredictionErrorRatio.Reset()
+	accuracyController = accuracy.NewController(fakeClock, env.Client, cp, recorder)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Accuracy", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+					v1.NodeInitializedLabelKey:     "true",
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("16"),
+					corev1.ResourceMemory: resource.MustParse("64Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("15"),
+					corev1.ResourceMemory: resource.MustParse("60Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+			},
+		})
+		node.Status.Capacity = nodeClaim.Status.Capacity.DeepCopy()
+		node.Status.Allocatable = nodeClaim.Status.Allocatable.DeepCopy()
+	})
+
+	It("should observe the prediction error of initialized nodes", func() {
+		node.Status.Allocatable[corev1.ResourceMemory] = resource.MustParse("59Gi")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+
+		m, found := FindMetricWithLabelValues("karpenter_nodeclaims_resource_prediction_error_ratio", map[string]string{
+			"nodepool":      nodePool.Name,
+			"instance_type": "default-instance-type",
+			"resource_type": "memory",
+			"kind":          "allocatable",
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+		Expect(m.GetHistogram().GetSampleSum()).To(BeNumerically("~", -1.0/60, 0.0001))
+		// The misestimate is within the threshold, so it isn't reported through an event
+		Expect(recorder.Calls("ResourcePredictionMismatch")).To(Equal(0))
+	})
+	It("should publish an event when the prediction error exceeds the threshold", func() {
+		node.Status.Allocatable[corev1.ResourcePods] = resource.MustParse("58")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+
+		Expect(recorder.Calls("ResourcePredictionMismatch")).To(Equal(1))
+		Expect(recorder.DetectedEvent("predicted allocatable pods of 110 but found 58 (-47.3%)")).To(BeTrue())
+	})
+	It("should only compare a NodeClaim once", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+
+		m, found := FindMetricWithLabelValues("karpenter_nodeclaims_resource_prediction_error_ratio", map[string]string{
+			"nodepool":      nodePool.Name,
+			"resource_type": "cpu",
+			"kind":          "capacity",
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+	})
+	It("should not compare NodeClaims that haven't initialized", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+
+		_, found := FindMetricWithLabelValues("karpenter_nodeclaims_resource_prediction_error_ratio", map[string]string{
+			"nodepool": nodePool.Name,
+		})
+		Expect(found).To(BeFalse())
+	})
+	It("should not compare NodeClaims that initialized before the comparison window", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, accuracyController, nodeClaim)
+
+		_, found := FindMetricWithLabelValues("karpenter_nodeclaims_resource_prediction_error_ratio", map[string]string{
+			"nodepool": nodePool.Name,
+		})
+		Expect(found).To(BeFalse())
+	})
+})
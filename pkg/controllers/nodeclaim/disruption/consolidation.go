@@ -59,8 +59,10 @@ func (c *Consolidation) Reconcile(ctx context.Context, nodePool *v1.NodePool, no
 	// If the lastPodEvent is zero, use the time that the nodeclaim was initialized, as that's when Karpenter recognizes that pods could have started scheduling
 	timeToCheck := lo.Ternary(!nodeClaim.Status.LastPodEventTime.IsZero(), nodeClaim.Status.LastPodEventTime.Time, initialized.LastTransitionTime.Time)
 
-	// Consider a node consolidatable by looking at the lastPodEvent status field on the nodeclaim.
-	if c.clock.Since(timeToCheck) < lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration) {
+	// Consider a node consolidatable by looking at the lastPodEvent status field on the nodeclaim. NodeClaims that no pod
+	// was ever bound to after they were launched for pods are consolidatable right away.
+	orphaned := nodeClaim.StatusConditions().Get(v1.ConditionTypeOrphaned).IsTrue() && nodeClaim.Status.LastPodEventTime.IsZero()
+	if !orphaned && c.clock.Since(timeToCheck) < lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration) {
 		if hasConsolidatableCondition {
 			_ = nodeClaim.StatusConditions().Clear(v1.ConditionTypeConsolidatable)
 			log.FromContext(ctx).V(1).Info("removing consolidatable status condition")
@@ -113,6 +113,27 @@ var _ = Describe("Underutilized", func() {
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeTrue())
 	})
+	It("should mark orphaned NodeClaims as consolidatable without waiting for consolidateAfter", func() {
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("1h")
+		nodeClaim.Status.LastPodEventTime.Time = time.Time{}
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeOrphaned)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		fakeClock.SetTime(nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized).LastTransitionTime.Time)
+
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeTrue())
+	})
+	It("should not mark orphaned NodeClaims as consolidatable once a pod was bound to them", func() {
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("1h")
+		nodeClaim.Status.LastPodEventTime.Time = fakeClock.Now()
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeOrphaned)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeFalse())
+	})
 	It("should remove the status condition from the nodeClaim when lastPodEvent is too recent", func() {
 		nodeClaim.Status.LastPodEventTime.Time = fakeClock.Now()
 		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
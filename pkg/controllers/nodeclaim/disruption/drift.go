@@ -144,6 +144,10 @@ func areStaticFieldsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) clou
 func areRequirementsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) cloudprovider.DriftReason {
 	nodepoolReq := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
 	nodeClaimReq := scheduling.NewLabelRequirements(nodeClaim.Labels)
+	// NodeClaims that fell back to on-demand capacity are expected to violate the capacity type requirement of the NodePool
+	if _, ok := nodeClaim.Annotations[v1.CapacityTypeFallbackAnnotationKey]; ok && nodeClaim.Labels[v1.CapacityTypeLabelKey] == v1.CapacityTypeOnDemand {
+		delete(nodepoolReq, v1.CapacityTypeLabelKey)
+	}
 
 	// Every nodepool requirement is compatible with the NodeClaim label set
 	if nodeClaimReq.Compatible(nodepoolReq) != nil {
@@ -18,9 +18,13 @@ package expiration
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -31,8 +35,12 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	disruptionutils "sigs.k8s.io/karpenter/pkg/utils/disruption"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
 // Expiration is a nodeclaim controller that deletes expired nodeclaims based on expireAfter
@@ -40,14 +48,16 @@ type Controller struct {
 	clock         clock.Clock
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
 }
 
 // NewController constructs a nodeclaim disruption controller
-func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
 	return &Controller{
 		clock:         clk,
 		kubeClient:    kubeClient,
 		cloudProvider: cloudProvider,
+		recorder:      recorder,
 	}
 }
 
@@ -69,11 +79,37 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 		// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
 		return reconcile.Result{RequeueAfter: expirationTime.Sub(c.clock.Now())}, nil
 	}
-	// 3. Otherwise, if the NodeClaim is expired we can forcefully expire the nodeclaim (by deleting it)
-	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+	// 3. If the NodeClaim or its Node has the karpenter.sh/expiration-paused annotation, skip its expiration until the
+	// annotation is removed
+	paused, err := IsPaused(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		log.FromContext(ctx).V(1).Info("skipping expiration of expired nodeclaim", "annotation", v1.ExpirationPausedAnnotationKey)
+		c.recorder.Publish(ExpirationPausedEvent(nodeClaim))
+		NodeClaimsExpirationPausedTotal.Inc(map[string]string{
+			metrics.NodePoolLabel: nodeClaim.Labels[v1.NodePoolLabelKey],
+		})
+		return reconcile.Result{}, nil
+	}
+	// 4. If the NodeClaim's Node hosts pods that are protected by the NodePool's protectLongRunningPods, skip its
+	// expiration until the Node is approved with the karpenter.sh/disruption-confirmed annotation
+	awaitingApproval, err := c.isAwaitingApproval(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if awaitingApproval {
+		log.FromContext(ctx).V(1).Info("skipping expiration of expired nodeclaim hosting long-running pods", "annotation", v1.DisruptionConfirmedAnnotationKey)
+		c.recorder.Publish(ExpirationAwaitingApprovalEvent(nodeClaim))
+		// Requeue to expire the NodeClaim once its long-running pods are gone
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	// 5. Otherwise, if the NodeClaim is expired we can forcefully expire the nodeclaim (by deleting it)
+	if err := nodeclaimutils.Delete(ctx, c.kubeClient, nodeClaim, v1.TerminationReasonExpiration); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
-	// 4. The deletion timestamp has successfully been set for the NodeClaim, update relevant metrics.
+	// 6. The deletion timestamp has successfully been set for the NodeClaim, update relevant metrics.
 	log.FromContext(ctx).V(1).Info("deleting expired nodeclaim")
 	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
 		metrics.ReasonLabel:       strings.ToLower(metrics.ExpiredReason),
@@ -87,9 +123,50 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 	return reconcile.Result{}, nil
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+// IsPaused returns whether the expiration of the NodeClaim is paused by the karpenter.sh/expiration-paused annotation
+// on either the NodeClaim or its Node
+func IsPaused(ctx context.Context, kubeClient client.Client, nodeClaim *v1.NodeClaim) (bool, error) {
+	if nodeClaim.Annotations[v1.ExpirationPausedAnnotationKey] == "true" {
+		return true, nil
+	}
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, kubeClient, nodeClaim)
+	if err != nil {
+		if nodeclaimutils.IsNodeNotFoundError(err) || nodeclaimutils.IsDuplicateNodeError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting node for nodeclaim, %w", err)
+	}
+	return node.Annotations[v1.ExpirationPausedAnnotationKey] == "true", nil
+}
+
+// isAwaitingApproval returns whether the NodeClaim's Node hosts pods that have been running for longer than the
+// NodePool's protectLongRunningPods duration without being approved for disruption
+func (c *Controller) isAwaitingApproval(ctx context.Context, nodeClaim *v1.NodeClaim) (bool, error) {
+	nodePool := &v1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}, nodePool); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if nodePool.Spec.Disruption.ProtectLongRunningPods == nil {
+		return false, nil
+	}
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		if nodeclaimutils.IsNodeNotFoundError(err) || nodeclaimutils.IsDuplicateNodeError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting node for nodeclaim, %w", err)
+	}
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return false, fmt.Errorf("listing pods on node, %w", err)
+	}
+	return disruptionutils.IsAwaitingApproval(c.clock, nodePool, lo.Assign(nodeClaim.Annotations, node.Annotations), pods), nil
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodeclaim.expiration").
-		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
+		Watches(&corev1.Node{}, nodeclaimutils.NodeEventHandler(c.kubeClient, c.cloudProvider)).
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
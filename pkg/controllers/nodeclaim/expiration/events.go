@@ -0,0 +1,46 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expiration
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func ExpirationPausedEvent(nodeClaim *v1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ExpirationPaused",
+		Message:        fmt.Sprintf("Skipping expiration of expired NodeClaim due to the %s annotation", v1.ExpirationPausedAnnotationKey),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func ExpirationAwaitingApprovalEvent(nodeClaim *v1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ExpirationAwaitingApproval",
+		Message:        fmt.Sprintf("Skipping expiration of expired NodeClaim hosting long-running pods until its Node has the %s annotation", v1.DisruptionConfirmedAnnotationKey),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
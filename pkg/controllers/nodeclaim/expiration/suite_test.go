@@ -23,6 +23,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clock "k8s.io/utils/clock/testing"
@@ -44,6 +45,7 @@ var expirationController *expiration.Controller
 var env *test.Environment
 var cp *fake.CloudProvider
 var fakeClock *clock.FakeClock
+var recorder *test.EventRecorder
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -56,7 +58,8 @@ var _ = BeforeSuite(func() {
 	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
 	ctx = options.ToContext(ctx, test.Options())
 	cp = fake.NewCloudProvider()
-	expirationController = expiration.NewController(fakeClock, env.Client, cp)
+	recorder = test.NewEventRecorder()
+	expirationController = expiration.NewController(fakeClock, env.Client, cp, recorder)
 })
 
 var _ = AfterSuite(func() {
@@ -70,6 +73,7 @@ var _ = BeforeEach(func() {
 
 var _ = AfterEach(func() {
 	ExpectCleanedUp(ctx, env.Client)
+	recorder.Reset()
 })
 
 var _ = Describe("Expiration", func() {
@@ -87,6 +91,7 @@ var _ = Describe("Expiration", func() {
 			},
 		})
 		metrics.NodeClaimsDisruptedTotal.Reset()
+		expiration.NodeClaimsExpirationPausedTotal.Reset()
 	})
 	Context("Metrics", func() {
 		It("should fire a karpenter_nodeclaims_disrupted_total metric when expired", func() {
@@ -178,6 +183,107 @@ var _ = Describe("Expiration", func() {
 		result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
 		Expect(result.RequeueAfter).To(BeNumerically("~", time.Second*100, time.Second))
 	})
+	Context("Expiration Paused", func() {
+		It("should not expire NodeClaims with the karpenter.sh/expiration-paused annotation", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.ExpirationPausedAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(recorder.Calls("ExpirationPaused")).To(Equal(1))
+			ExpectMetricCounterValue(expiration.NodeClaimsExpirationPausedTotal, 1, map[string]string{
+				"nodepool": nodePool.Name,
+			})
+		})
+		It("should not expire NodeClaims whose Node has the karpenter.sh/expiration-paused annotation", func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.ExpirationPausedAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(recorder.Calls("ExpirationPaused")).To(Equal(1))
+		})
+		It("should expire NodeClaims once the karpenter.sh/expiration-paused annotation is removed", func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.ExpirationPausedAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim)
+
+			delete(node.Annotations, v1.ExpirationPausedAnnotationKey)
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should expire NodeClaims when the karpenter.sh/expiration-paused annotation isn't true", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.ExpirationPausedAnnotationKey: "false"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(recorder.Calls("ExpirationPaused")).To(Equal(0))
+		})
+	})
+	Context("Long-Running Pods", func() {
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			nodePool.Spec.Disruption.ProtectLongRunningPods = &metav1.Duration{Duration: 30 * time.Second}
+			pod = test.Pod(test.PodOptions{NodeName: node.Name})
+			pod.Status.StartTime = &metav1.Time{Time: fakeClock.Now()}
+		})
+		It("should not expire NodeClaims whose Node hosts long-running pods", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			fakeClock.Step(60 * time.Second)
+			result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(recorder.Calls("ExpirationAwaitingApproval")).To(Equal(1))
+		})
+		It("should expire NodeClaims whose Node has the karpenter.sh/disruption-confirmed annotation", func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DisruptionConfirmedAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(recorder.Calls("ExpirationAwaitingApproval")).To(Equal(0))
+		})
+		It("should expire NodeClaims whose pods haven't been running for longer than the threshold", func() {
+			pod.Status.StartTime = &metav1.Time{Time: fakeClock.Now().Add(45 * time.Second)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should expire NodeClaims whose Node only hosts long-running DaemonSet pods", func() {
+			pod.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion:         "apps/v1",
+				Kind:               "DaemonSet",
+				Name:               "daemonset",
+				UID:                "daemonset-uid",
+				Controller:         lo.ToPtr(true),
+				BlockOwnerDeletion: lo.ToPtr(true),
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+	})
 	It("shouldn't expire the same NodeClaim multiple times", func() {
 		nodeClaim.ObjectMeta.Finalizers = append(nodeClaim.ObjectMeta.Finalizers, "test-finalizer")
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
 type Controller struct {
@@ -78,7 +79,8 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	nodeClaims = lo.Filter(nodeClaims, func(n *v1.NodeClaim, _ int) bool {
 		return n.StatusConditions().Get(v1.ConditionTypeRegistered).IsTrue() &&
 			n.DeletionTimestamp.IsZero() &&
-			!cloudProviderProviderIDs.Has(n.Status.ProviderID)
+			!cloudProviderProviderIDs.Has(n.Status.ProviderID) &&
+			nodepoolutils.IsOwnerInShard(ctx, c.kubeClient, n)
 	})
 
 	errs := make([]error, len(nodeClaims))
@@ -95,7 +97,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		if node != nil && nodeutils.GetCondition(node, corev1.NodeReady).Status == corev1.ConditionTrue {
 			return
 		}
-		if err := c.kubeClient.Delete(ctx, nodeClaims[i]); err != nil {
+		if err := nodeclaimutils.Delete(ctx, c.kubeClient, nodeClaims[i], v1.TerminationReasonInterruption); err != nil {
 			errs[i] = client.IgnoreNotFound(err)
 			return
 		}
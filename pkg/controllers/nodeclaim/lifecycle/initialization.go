@@ -19,30 +19,40 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/hooks"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
+// readinessGateRequeueInterval is how often NodeClaims that are waiting on a ReadinessGate are re-checked
+const readinessGateRequeueInterval = time.Minute
+
 type Initialization struct {
 	kubeClient client.Client
+	hooks      *hooks.Runner
 }
 
 // Reconcile checks for initialization based on if:
 // a) its current status is set to Ready
 // b) all the startup taints have been removed from the node
 // c) all extended resources have been registered
+// d) the node conditions of all ReadinessGates that select the node are True
 // This method handles both nil nodepools and nodes without extended resources gracefully.
 func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
 	if cond := nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized); !cond.IsUnknown() {
@@ -76,6 +86,23 @@ func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim)
 		nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeInitialized, "ResourceNotRegistered", fmt.Sprintf("Resource %q was requested but not registered", name))
 		return reconcile.Result{}, nil
 	}
+	gate, ok, err := ReadinessGatesPassed(ctx, i.kubeClient, node)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("checking readiness gates, %w", err)
+	}
+	if !ok {
+		nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeInitialized, "ReadinessGatePending", fmt.Sprintf("ReadinessGate %q is waiting for node condition %q", gate.Name, gate.Spec.ConditionType))
+		// Node conditions trigger a reconcile when they change, but gates that are deleted or no longer select the node don't
+		return reconcile.Result{RequeueAfter: readinessGateRequeueInterval}, nil
+	}
+	if err = i.hooks.Run(ctx, hooks.PostInitialization, nodeClaim, node); err != nil {
+		if hooks.IsPendingError(err) {
+			nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeInitialized, "HookPending", err.Error())
+			return reconcile.Result{RequeueAfter: hooks.PendingRequeueInterval}, nil
+		}
+		nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeInitialized, "HookFailed", err.Error())
+		return reconcile.Result{}, err
+	}
 	stored := node.DeepCopy()
 	node.Labels = lo.Assign(node.Labels, map[string]string{v1.NodeInitializedLabelKey: "true"})
 	if !equality.Semantic.DeepEqual(stored, node) {
@@ -88,6 +115,33 @@ func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim)
 	return reconcile.Result{}, nil
 }
 
+// ReadinessGatesPassed returns true if the node condition of every ReadinessGate that selects the node is True, or
+// the first ReadinessGate whose condition isn't True. ReadinessGates with an invalid node selector are ignored so that
+// a misconfigured gate doesn't block the initialization of every node.
+func ReadinessGatesPassed(ctx context.Context, kubeClient client.Client, node *corev1.Node) (*v1alpha1.ReadinessGate, bool, error) {
+	readinessGateList := &v1alpha1.ReadinessGateList{}
+	if err := kubeClient.List(ctx, readinessGateList); err != nil {
+		return nil, false, fmt.Errorf("listing readiness gates, %w", err)
+	}
+	for i := range readinessGateList.Items {
+		gate := &readinessGateList.Items[i]
+		if gate.Spec.NodeSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(gate.Spec.NodeSelector)
+			if err != nil {
+				log.FromContext(ctx).WithValues("ReadinessGate", klog.KObj(gate)).Error(err, "ignoring readiness gate with invalid node selector")
+				continue
+			}
+			if !selector.Matches(labels.Set(node.Labels)) {
+				continue
+			}
+		}
+		if nodeutils.GetCondition(node, corev1.NodeConditionType(gate.Spec.ConditionType)).Status != corev1.ConditionTrue {
+			return gate, false, nil
+		}
+	}
+	return nil, true, nil
+}
+
 // KnownEphemeralTaintsRemoved validates whether all the ephemeral taints are removed
 func KnownEphemeralTaintsRemoved(node *corev1.Node) (*corev1.Taint, bool) {
 	for _, knownTaint := range scheduling.KnownEphemeralTaints {
@@ -25,6 +25,7 @@ import (
 	cloudproviderapi "k8s.io/cloud-provider/api"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
@@ -655,4 +656,84 @@ var _ = Describe("Initialization", func() {
 		Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeRegistered).Status).To(Equal(metav1.ConditionTrue))
 		Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeInitialized).Status).To(Equal(metav1.ConditionTrue))
 	})
+	Context("Readiness Gates", func() {
+		var nodeClaim *v1.NodeClaim
+		var node *corev1.Node
+
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			node = test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"team": "a"},
+				},
+				ProviderID: nodeClaim.Status.ProviderID,
+				Taints:     []corev1.Taint{v1.UnregisteredNoExecuteTaint},
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+			ExpectMakeNodesReady(ctx, env.Client, node)
+		})
+		It("should not consider the Node to be initialized until the condition of the readiness gate is True", func() {
+			gate := &v1alpha1.ReadinessGate{
+				ObjectMeta: test.ObjectMeta(),
+				Spec:       v1alpha1.ReadinessGateSpec{ConditionType: "NetworkReady"},
+			}
+			ExpectApplied(ctx, env.Client, gate)
+			result := ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+			Expect(result.RequeueAfter).ToNot(BeZero())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			condition := ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeInitialized)
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(condition.Reason).To(Equal("ReadinessGatePending"))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).ToNot(HaveKey(v1.NodeInitializedLabelKey))
+
+			node = ExpectExists(ctx, env.Client, node)
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: "NetworkReady", Status: corev1.ConditionTrue})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeInitialized).Status).To(Equal(metav1.ConditionTrue))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue(v1.NodeInitializedLabelKey, "true"))
+		})
+		It("should not wait on readiness gates that don't select the Node", func() {
+			gate := &v1alpha1.ReadinessGate{
+				ObjectMeta: test.ObjectMeta(),
+				Spec: v1alpha1.ReadinessGateSpec{
+					NodeSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+					ConditionType: "NetworkReady",
+				},
+			}
+			ExpectApplied(ctx, env.Client, gate)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeInitialized).Status).To(Equal(metav1.ConditionTrue))
+		})
+		It("should wait on readiness gates that select the Node", func() {
+			gate := &v1alpha1.ReadinessGate{
+				ObjectMeta: test.ObjectMeta(),
+				Spec: v1alpha1.ReadinessGateSpec{
+					NodeSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					ConditionType: "NetworkReady",
+				},
+			}
+			ExpectApplied(ctx, env.Client, gate)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeInitialized).Status).To(Equal(metav1.ConditionUnknown))
+		})
+	})
 })
@@ -23,23 +23,39 @@ import (
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/storage"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/hooks"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 )
 
+// launchFailedReason is the reason of the Launched condition of NodeClaims whose launch failed and is being retried
+const launchFailedReason = "LaunchFailed"
+
 type Launch struct {
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
 	cache         *cache.Cache // exists due to eventual consistency on the cache
 	recorder      events.Recorder
+	hooks         *hooks.Runner
+	clock         clock.Clock
 }
 
 func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
@@ -60,7 +76,18 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconc
 	if ret, ok := l.cache.Get(string(nodeClaim.UID)); ok {
 		created = ret.(*v1.NodeClaim)
 	} else {
+		if err = l.hooks.Run(ctx, hooks.PreLaunch, nodeClaim, nil); err != nil {
+			if hooks.IsPendingError(err) {
+				nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "HookPending", truncateMessage(err.Error()))
+				return reconcile.Result{RequeueAfter: hooks.PendingRequeueInterval}, nil
+			}
+			nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "HookFailed", truncateMessage(err.Error()))
+			return reconcile.Result{}, err
+		}
+		// Launches are retried until they succeed, so the failures of a NodeClaim are only counted on its first attempt
+		retried := lo.FromPtr(nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched)).Reason == launchFailedReason
 		created, err = l.launchNodeClaim(ctx, nodeClaim)
+		l.recordLaunchResult(ctx, nodeClaim, created != nil && err == nil, err != nil && !retried)
 	}
 	// Either the Node launch failed or the Node was deleted due to InsufficientCapacity/NodeClassNotReady/NotFound
 	if err != nil || created == nil {
@@ -73,14 +100,24 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconc
 }
 
 func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
-	created, err := l.cloudProvider.Create(ctx, nodeClaim)
+	createCtx, span := tracing.Tracer().Start(ctx, "cloudprovider.create", trace.WithAttributes(
+		attribute.String("karpenter.nodeclaim", nodeClaim.Name),
+		attribute.String("karpenter.nodepool", nodeClaim.Labels[v1.NodePoolLabelKey]),
+	))
+	created, err := l.cloudProvider.Create(createCtx, nodeClaim)
+	if created != nil {
+		span.SetAttributes(attribute.String("karpenter.instance_type", created.Labels[corev1.LabelInstanceTypeStable]))
+	}
+	tracing.RecordError(span, err)
+	span.End()
 	if err != nil {
 		switch {
 		case cloudprovider.IsInsufficientCapacityError(err):
+			recordLaunchFailure(nodeClaim, "insufficient_capacity")
 			l.recorder.Publish(InsufficientCapacityErrorEvent(nodeClaim, err))
 			log.FromContext(ctx).Error(err, "failed launching nodeclaim")
 
-			if err = l.kubeClient.Delete(ctx, nodeClaim); err != nil {
+			if err = nodeclaimutils.Delete(ctx, l.kubeClient, nodeClaim, v1.TerminationReasonLaunchFailed); err != nil {
 				return nil, client.IgnoreNotFound(err)
 			}
 			metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
@@ -90,8 +127,9 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 			})
 			return nil, nil
 		case cloudprovider.IsNodeClassNotReadyError(err):
+			recordLaunchFailure(nodeClaim, "nodeclass_not_ready")
 			log.FromContext(ctx).Error(err, "failed launching nodeclaim")
-			if err = l.kubeClient.Delete(ctx, nodeClaim); err != nil {
+			if err = nodeclaimutils.Delete(ctx, l.kubeClient, nodeClaim, v1.TerminationReasonLaunchFailed); err != nil {
 				return nil, client.IgnoreNotFound(err)
 			}
 			metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
@@ -103,9 +141,11 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 		default:
 			var createError *cloudprovider.CreateError
 			if errors.As(err, &createError) {
-				nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "LaunchFailed", createError.ConditionMessage)
+				recordLaunchFailure(nodeClaim, "create_error")
+				nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, launchFailedReason, createError.ConditionMessage)
 			} else {
-				nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "LaunchFailed", truncateMessage(err.Error()))
+				recordLaunchFailure(nodeClaim, "unknown")
+				nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, launchFailedReason, truncateMessage(err.Error()))
 			}
 			return nil, fmt.Errorf("launching nodeclaim, %w", err)
 		}
@@ -119,6 +159,43 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 	return created, nil
 }
 
+// recordLaunchResult tracks the consecutive launch failures of the NodePool that owns the NodeClaim so that its
+// FailurePolicy can temporarily disable it. Launches that were aborted, e.g. due to InsufficientCapacity, are neither
+// successes nor failures, and neither are the launches that the dry-run CloudProvider refuses.
+func (l *Launch) recordLaunchResult(ctx context.Context, nodeClaim *v1.NodeClaim, succeeded, failed bool) {
+	if (!succeeded && !failed) || options.FromContext(ctx).DryRun {
+		return
+	}
+	nodePool := &v1.NodePool{}
+	if err := l.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}, nodePool); err != nil {
+		return
+	}
+	if nodePool.Spec.FailurePolicy == nil && nodePool.Status.ConsecutiveLaunchFailures == 0 {
+		return
+	}
+	stored := nodePool.DeepCopy()
+	if succeeded {
+		nodePool.Status.ConsecutiveLaunchFailures = 0
+		nodePool.Status.LastLaunchFailureTime = nil
+	} else {
+		nodePool.Status.ConsecutiveLaunchFailures++
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(l.clock.Now()))
+	}
+	if equality.Semantic.DeepEqual(stored, nodePool) {
+		return
+	}
+	if err := l.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "failed recording launch result for nodepool")
+	}
+}
+
+func recordLaunchFailure(nodeClaim *v1.NodeClaim, reason string) {
+	NodeClaimsLaunchFailedTotal.Inc(map[string]string{
+		metrics.NodePoolLabel: nodeClaim.Labels[v1.NodePoolLabelKey],
+		metrics.ReasonLabel:   reason,
+	})
+}
+
 func PopulateNodeClaimDetails(nodeClaim, retrieved *v1.NodeClaim) *v1.NodeClaim {
 	// These are ordered in priority order so that user-defined nodeClaim labels and requirements trump retrieved labels
 	// or the static nodeClaim labels
@@ -132,6 +209,11 @@ func PopulateNodeClaimDetails(nodeClaim, retrieved *v1.NodeClaim) *v1.NodeClaim
 	nodeClaim.Status.ImageID = retrieved.Status.ImageID
 	nodeClaim.Status.Allocatable = retrieved.Status.Allocatable
 	nodeClaim.Status.Capacity = retrieved.Status.Capacity
+	// The ephemeral storage label is derived from the capacity unless the CloudProvider resolved it itself, so that
+	// pods that require local disk through the label can schedule to the node
+	if _, ok := nodeClaim.Labels[v1.EphemeralStorageLabelKey]; !ok && nodeClaim.Status.Capacity != nil {
+		nodeClaim.Labels[v1.EphemeralStorageLabelKey] = storage.LabelValue(nodeClaim.Status.Capacity)
+	}
 	return nodeClaim
 }
 
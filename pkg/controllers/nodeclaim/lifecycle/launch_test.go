@@ -17,15 +17,23 @@ limitations under the License.
 package lifecycle_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	nodeclaimlifecycle "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/hooks"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -86,6 +94,27 @@ var _ = Describe("Launch", func() {
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched).Status).To(Equal(metav1.ConditionTrue))
 	})
+	It("should adopt an in-progress launch with the same idempotency key rather than launching again", func() {
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				},
+				Annotations: map[string]string{
+					v1.NodeClaimIdempotencyKeyAnnotationKey: "launch-key",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		// Simulate a previous leader launching the instance without persisting the launch to the NodeClaim
+		inProgress, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Status.ProviderID).To(Equal(inProgress.Status.ProviderID))
+		Expect(cloudProvider.CreatedNodeClaims).To(HaveLen(1))
+	})
 	It("should delete the nodeclaim if InsufficientCapacity is returned from the cloudprovider", func() {
 		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
 		nodeClaim := test.NodeClaim()
@@ -113,4 +142,120 @@ var _ = Describe("Launch", func() {
 		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
 		Expect(condition.Message).To(Equal(conditionMessage))
 	})
+	It("should track consecutive launch failures on NodePools with a failure policy", func() {
+		nodePool.Spec.FailurePolicy = &v1.FailurePolicy{MaxConsecutiveFailures: 3}
+		nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		cloudProvider.NextCreateErr = fmt.Errorf("error launching instance")
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 1))
+		Expect(nodePool.Status.LastLaunchFailureTime).ToNot(BeNil())
+
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 0))
+		Expect(nodePool.Status.LastLaunchFailureTime).To(BeNil())
+	})
+	It("should only count the launch failures of a NodeClaim once", func() {
+		nodePool.Spec.FailurePolicy = &v1.FailurePolicy{MaxConsecutiveFailures: 3}
+		nodeClaims := []*v1.NodeClaim{
+			test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}}),
+			test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}}),
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1])
+		for range 3 {
+			cloudProvider.NextCreateErr = fmt.Errorf("error launching instance")
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaims[0])
+		}
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 1))
+
+		cloudProvider.NextCreateErr = fmt.Errorf("error launching instance")
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaims[1])
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 2))
+	})
+	It("should not track the launch failures of dry-run mode", func() {
+		nodePool.Spec.FailurePolicy = &v1.FailurePolicy{MaxConsecutiveFailures: 3}
+		nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		cloudProvider.NextCreateErr = cloudprovider.NewCreateError(fmt.Errorf("instance launches are disabled in dry-run mode"), "Instance launches are disabled in dry-run mode")
+		_ = ExpectObjectReconcileFailed(options.ToContext(ctx, test.Options(test.OptionsFields{DryRun: lo.ToPtr(true)})), env.Client, nodeClaimController, nodeClaim)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 0))
+	})
+	It("should not track launch failures on NodePools without a failure policy", func() {
+		nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		cloudProvider.NextCreateErr = fmt.Errorf("error launching instance")
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.ConsecutiveLaunchFailures).To(BeNumerically("==", 0))
+	})
+	Context("Hooks", func() {
+		var server *httptest.Server
+		var requests []hooks.Request
+		var statusCode int
+		var hook hooks.Hook
+		BeforeEach(func() {
+			requests = nil
+			statusCode = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := hooks.Request{}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				requests = append(requests, request)
+				w.WriteHeader(statusCode)
+			}))
+			hook = hooks.Hook{Name: "test-hook", Points: []hooks.Point{hooks.PreLaunch}, HTTP: &hooks.HTTPAction{URL: server.URL}}
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		controllerWithHooks := func(hooksToRun ...hooks.Hook) *nodeclaimlifecycle.Controller {
+			recorder := events.NewRecorder(&record.FakeRecorder{})
+			return nodeclaimlifecycle.NewController(fakeClock, env.Client, cloudProvider, recorder, hooks.NewRunner(fakeClock, env.Client, recorder, hooksToRun...))
+		}
+		It("should run pre-launch hooks before launching the NodeClaim", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, controllerWithHooks(hook), nodeClaim)
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Point).To(Equal(hooks.PreLaunch))
+			Expect(requests[0].NodeClaim).To(Equal(nodeClaim.Name))
+			Expect(requests[0].NodePool).To(Equal(nodePool.Name))
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		})
+		It("should not launch the NodeClaim when a pre-launch hook fails", func() {
+			statusCode = http.StatusInternalServerError
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, controllerWithHooks(hook), nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+			Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched).Status).To(Equal(metav1.ConditionUnknown))
+			Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched).Reason).To(Equal("HookFailed"))
+		})
+		It("should launch the NodeClaim when a pre-launch hook that ignores failures fails", func() {
+			statusCode = http.StatusInternalServerError
+			hook.FailurePolicy = hooks.FailurePolicyIgnore
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, controllerWithHooks(hook), nodeClaim)
+
+			Expect(requests).To(HaveLen(1))
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		})
+		It("should not run hooks for other lifecycle points", func() {
+			hook.Points = []hooks.Point{hooks.PreDrain}
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, controllerWithHooks(hook), nodeClaim)
+
+			Expect(requests).To(BeEmpty())
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		})
+	})
 })
@@ -18,8 +18,11 @@ package lifecycle
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
+	"github.com/samber/lo"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -27,6 +30,8 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 )
 
 type Liveness struct {
@@ -46,13 +51,16 @@ func (l *Liveness) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reco
 	if registered == nil {
 		return reconcile.Result{Requeue: true}, nil
 	}
+	if deleted, err := l.deleteFailedLaunch(ctx, nodeClaim); err != nil || deleted {
+		return reconcile.Result{}, err
+	}
 	// If the Registered statusCondition hasn't gone True during the TTL since we first updated it, we should terminate the NodeClaim
 	// NOTE: ttl has to be stored and checked in the same place since l.clock can advance after the check causing a race
 	if ttl := registrationTTL - l.clock.Since(registered.LastTransitionTime.Time); ttl > 0 {
 		return reconcile.Result{RequeueAfter: ttl}, nil
 	}
 	// Delete the NodeClaim if we believe the NodeClaim won't register since we haven't seen the node
-	if err := l.kubeClient.Delete(ctx, nodeClaim); err != nil {
+	if err := nodeclaimutils.Delete(ctx, l.kubeClient, nodeClaim, v1.TerminationReasonLaunchFailed); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
 	log.FromContext(ctx).V(1).WithValues("ttl", registrationTTL).Info("terminating due to registration ttl")
@@ -64,3 +72,57 @@ func (l *Liveness) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reco
 
 	return reconcile.Result{}, nil
 }
+
+// deleteFailedLaunch deletes a NodeClaim whose launch has kept failing for longer than the failed launch retention so
+// that it stops being considered in-flight capacity. The most recently failed NodeClaims are kept for debugging until
+// they exceed the registration TTL. NodeClaims whose launch fails are requeued with backoff, so we don't need to
+// requeue them for the retention.
+func (l *Liveness) deleteFailedLaunch(ctx context.Context, nodeClaim *v1.NodeClaim) (bool, error) {
+	retention := options.FromContext(ctx).FailedLaunchRetention
+	launched := nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched)
+	if retention <= 0 || !isFailedLaunch(nodeClaim) || l.clock.Since(launched.LastTransitionTime.Time) < retention {
+		return false, nil
+	}
+	retained, err := l.retainedForDebugging(ctx, nodeClaim)
+	if err != nil || retained {
+		return false, err
+	}
+	if err := nodeclaimutils.Delete(ctx, l.kubeClient, nodeClaim, v1.TerminationReasonLaunchFailed); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).V(1).WithValues("retention", retention).Info("terminating due to failed launch")
+	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+		metrics.ReasonLabel:       "launch_failed",
+		metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+	})
+	return true, nil
+}
+
+// retainedForDebugging returns whether the NodeClaim is one of the most recently failed NodeClaims
+func (l *Liveness) retainedForDebugging(ctx context.Context, nodeClaim *v1.NodeClaim) (bool, error) {
+	limit := options.FromContext(ctx).FailedLaunchHistoryLimit
+	if limit <= 0 {
+		return false, nil
+	}
+	nodeClaims := &v1.NodeClaimList{}
+	if err := l.kubeClient.List(ctx, nodeClaims); err != nil {
+		return false, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	failed := lo.Filter(nodeClaims.Items, func(nc v1.NodeClaim, _ int) bool {
+		return isFailedLaunch(&nc) && nc.DeletionTimestamp.IsZero()
+	})
+	sort.Slice(failed, func(i, j int) bool {
+		ti, tj := failed[i].StatusConditions().Get(v1.ConditionTypeLaunched).LastTransitionTime, failed[j].StatusConditions().Get(v1.ConditionTypeLaunched).LastTransitionTime
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return failed[i].Name < failed[j].Name
+	})
+	return lo.ContainsBy(lo.Slice(failed, 0, limit), func(nc v1.NodeClaim) bool { return nc.UID == nodeClaim.UID }), nil
+}
+
+func isFailedLaunch(nodeClaim *v1.NodeClaim) bool {
+	launched := nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched)
+	return launched != nil && launched.IsUnknown() && launched.Reason == launchFailedReason
+}
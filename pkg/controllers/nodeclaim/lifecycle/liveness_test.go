@@ -20,12 +20,15 @@ import (
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -144,4 +147,61 @@ var _ = Describe("Liveness", func() {
 		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
+	Context("Failed Launches", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				FailedLaunchRetention:    lo.ToPtr(5 * time.Minute),
+				FailedLaunchHistoryLimit: lo.ToPtr(0),
+			}))
+			cloudProvider.AllowedCreateCalls = 0 // Don't allow Create() calls to succeed
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should delete the NodeClaim when its launch has failed past the failed launch retention", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			fakeClock.Step(time.Minute * 6)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete the NodeClaim before the failed launch retention", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			fakeClock.Step(time.Minute * 4)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+		It("should keep the most recently failed NodeClaims for debugging", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				FailedLaunchRetention:    lo.ToPtr(5 * time.Minute),
+				FailedLaunchHistoryLimit: lo.ToPtr(1),
+			}))
+			nodeClaims := []*v1.NodeClaim{
+				test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}}),
+				test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1])
+			for _, nodeClaim := range nodeClaims {
+				_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			}
+
+			fakeClock.Step(time.Minute * 6)
+			for _, nodeClaim := range nodeClaims {
+				_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+			}
+			deleted := lo.CountBy(nodeClaims, func(nc *v1.NodeClaim) bool {
+				return !ExpectExists(ctx, env.Client, nc).DeletionTimestamp.IsZero()
+			})
+			Expect(deleted).To(Equal(1))
+		})
+	})
 })
@@ -46,3 +46,17 @@ var NodeClaimTerminationDurationSeconds = opmetrics.NewPrometheusHistogram(
 		Buckets:   prometheus.ExponentialBuckets(1, 2, 12)}, //The threshold values generated here are 1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024. 2048
 	[]string{metrics.NodePoolLabel},
 )
+
+var NodeClaimsLaunchFailedTotal = opmetrics.NewPrometheusCounter(
+	crmetrics.Registry,
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: metrics.NodeClaimSubsystem,
+		Name:      "launch_failed_total",
+		Help:      "Number of times that launching a nodeclaim failed. Labeled by nodepool and the reason for the failure.",
+	},
+	[]string{
+		metrics.NodePoolLabel,
+		metrics.ReasonLabel,
+	},
+)
@@ -17,6 +17,8 @@ limitations under the License.
 package lifecycle_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -318,6 +320,41 @@ var _ = Describe("Registration", func() {
 			},
 		))
 	})
+	It("should sync the temporaryTaints to the Node when the Node comes online", func() {
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+			Spec: v1.NodeClaimSpec{
+				TemporaryTaints: []v1.TemporaryTaint{
+					{
+						Taint: corev1.Taint{
+							Key:    "custom-temporary-taint",
+							Effect: corev1.TaintEffectNoSchedule,
+							Value:  "custom-temporary-value",
+						},
+						ExpireAfter: &metav1.Duration{Duration: time.Minute},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID, Taints: []corev1.Taint{v1.UnregisteredNoExecuteTaint}})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+
+		Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{
+			Key:    "custom-temporary-taint",
+			Effect: corev1.TaintEffectNoSchedule,
+			Value:  "custom-temporary-value",
+		}))
+	})
 	It("should not re-sync the startupTaints to the Node when the startupTaints are removed", func() {
 		nodeClaim := test.NodeClaim(v1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,193 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphan
+
+import (
+	"context"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+const (
+	// GracePeriod is how long an initialized NodeClaim is given for the pods that it was launched for to bind to it
+	// before it's considered orphaned
+	GracePeriod = 5 * time.Minute
+	// Window is how long after it's initialized that a NodeClaim is checked for being orphaned. Older NodeClaims are
+	// left to emptiness, since pods may have come and gone on them without us noticing.
+	Window = 30 * time.Minute
+)
+
+// Controller detects NodeClaims that were launched for a set of pods that never bound to them (e.g. because the pods
+// were deleted mid-round or the binding was rejected by an admission webhook). If any of the nominated pods are still
+// pending, scheduling is re-run for them. Otherwise, the NodeClaim is marked as orphaned, which makes it consolidatable
+// without waiting for consolidateAfter, and the disruption controller removes it.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	recorder      events.Recorder
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster,
+	provisioner *provisioning.Provisioner, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.orphan")
+
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	nodeClaims = lo.Filter(nodeClaims, func(nc *v1.NodeClaim, _ int) bool {
+		initialized := nc.StatusConditions().Get(v1.ConditionTypeInitialized)
+		// A pod event means that a pod was bound to the node at some point, so the NodeClaim was used even if none of
+		// the pods that it was launched for are left on it
+		return nc.DeletionTimestamp.IsZero() &&
+			initialized.IsTrue() &&
+			c.clock.Since(initialized.LastTransitionTime.Time) > GracePeriod &&
+			c.clock.Since(initialized.LastTransitionTime.Time) <= GracePeriod+Window &&
+			nc.Status.LastPodEventTime.IsZero() &&
+			!nc.StatusConditions().Get(v1.ConditionTypeOrphaned).IsTrue()
+	})
+	errs := make([]error, len(nodeClaims))
+	workqueue.ParallelizeUntil(ctx, 20, len(nodeClaims), func(i int) {
+		errs[i] = c.reconcile(ctx, nodeClaims[i])
+	})
+	if err = multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+//nolint:gocyclo
+func (c *Controller) reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name)))
+
+	decision, err := nodeclaimutils.GetSchedulingDecision(nodeClaim)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed parsing scheduling decision")
+		return nil
+	}
+	// NodeClaims that weren't launched for pods are never orphaned
+	if decision == nil || len(decision.Pods) == 0 {
+		return nil
+	}
+	if nodeClaim.Annotations[v1.DoNotDisruptAnnotationKey] == "true" || c.cluster.IsNodeNominated(nodeClaim.Status.ProviderID) {
+		return nil
+	}
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return nodeclaimutils.IgnoreDuplicateNodeError(nodeclaimutils.IgnoreNodeNotFoundError(err))
+	}
+	if node.Annotations[v1.DoNotDisruptAnnotationKey] == "true" {
+		return nil
+	}
+	pods, err := nodeutils.GetReschedulablePods(ctx, c.kubeClient, node)
+	if err != nil {
+		return err
+	}
+	if len(pods) > 0 {
+		return nil
+	}
+	var pending []*corev1.Pod
+	for _, nominated := range decision.Pods {
+		pod := &corev1.Pod{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Namespace: nominated.Namespace, Name: nominated.Name}, pod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		// The pod was deleted and recreated with the same name, so it isn't the pod that we launched for
+		if pod.UID != nominated.UID {
+			continue
+		}
+		// At least one of the pods that we launched for bound to the node, so it isn't orphaned
+		if pod.Spec.NodeName == node.Name {
+			return nil
+		}
+		if !podutils.IsScheduled(pod) && !podutils.IsTerminal(pod) && !podutils.IsTerminating(pod) {
+			pending = append(pending, pod)
+		}
+	}
+	if len(pending) > 0 {
+		for _, p := range pending {
+			c.provisioner.Trigger(p.UID)
+		}
+		log.FromContext(ctx).WithValues("pods", len(pending)).Info("retrying scheduling for pods nominated to orphaned nodeclaim")
+		c.recorder.Publish(RetryingNominatedPodsEvent(nodeClaim, len(pending)))
+		return nil
+	}
+	// We only know about a truncated set of the pods that we launched for, so it's possible that one of the pods we
+	// don't know about is still pending. Fall back to emptiness to clean the NodeClaim up.
+	if decision.TotalPods > len(decision.Pods) {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeOrphaned, "PodsNeverBound", "None of the pods that the NodeClaim was launched for bound to it")
+	if err := c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).WithValues(
+		"provider-id", nodeClaim.Status.ProviderID,
+		"nodepool", nodeClaim.Labels[v1.NodePoolLabelKey],
+	).V(1).Info("marking nodeclaim orphaned, none of the pods that it was launched for bound to it")
+	c.recorder.Publish(OrphanedEvent(nodeClaim))
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.orphan").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,46 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphan
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func RetryingNominatedPodsEvent(nodeClaim *v1.NodeClaim, pods int) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "RetryingNominatedPods",
+		Message:        fmt.Sprintf("Retrying scheduling for %d pod(s) that haven't bound to the NodeClaim", pods),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func OrphanedEvent(nodeClaim *v1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Orphaned",
+		Message:        "Marking NodeClaim consolidatable since none of the pods that it was launched for bound to it",
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphan_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/orphan"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var orphanController *orphan.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Orphan")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
+	recorder := events.NewRecorder(&record.FakeRecorder{})
+	prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+	orphanController = orphan.NewController(fakeClock, env.Client, cloudProvider, cluster, prov, recorder)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	fakeClock.SetTime(time.Now())
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+	cluster.Reset()
+})
+
+var _ = Describe("Orphan", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
+	})
+	launchedFor := func(pods ...*corev1.Pod) {
+		Expect(nodeclaimutils.SetSchedulingDecision(nodeClaim, nodeclaimutils.NewSchedulingDecision(pods...))).To(Succeed())
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+	}
+	expectOrphaned := func(orphaned bool) {
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeOrphaned).IsTrue()).To(Equal(orphaned))
+	}
+	It("should mark the NodeClaim orphaned when the pods that it was launched for were deleted", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(true)
+	})
+	It("should mark the NodeClaim orphaned when the pods that it was launched for bound to another node", func() {
+		otherNode := test.Node()
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, otherNode, pod)
+		launchedFor(pod)
+		ExpectManualBinding(ctx, env.Client, pod, otherNode)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(true)
+	})
+	It("should not delete the NodeClaim itself", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should not mark the NodeClaim orphaned when the pods that it was launched for are still pending", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned when a pod that it was launched for bound to it", func() {
+		pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod()}
+		ExpectApplied(ctx, env.Client, pods[0], pods[1])
+		launchedFor(pods...)
+		ExpectManualBinding(ctx, env.Client, pods[0], node)
+		ExpectDeleted(ctx, env.Client, pods[1])
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned when a pod was bound to it at some point", func() {
+		nodeClaim.Status.LastPodEventTime.Time = fakeClock.Now()
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned before the grace period has passed", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned after the window has passed", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		fakeClock.Step(orphan.GracePeriod + orphan.Window + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned when it wasn't launched for any pods", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+	It("should not mark the NodeClaim orphaned when it has the do-not-disrupt annotation", func() {
+		nodeClaim.Annotations = map[string]string{v1.DoNotDisruptAnnotationKey: "true"}
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		launchedFor(pod)
+		ExpectDeleted(ctx, env.Client, pod)
+
+		fakeClock.Step(orphan.GracePeriod + time.Minute)
+		ExpectSingletonReconciled(ctx, orphanController)
+		expectOrphaned(false)
+	})
+})
@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -77,7 +78,7 @@ func (c *Controller) Reconcile(ctx context.Context, pod *corev1.Pod) (reconcile.
 		// if the nodeclaim doesn't exist, or has duplicates, ignore.
 		return reconcile.Result{}, nodeutils.IgnoreDuplicateNodeClaimError(nodeutils.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting nodeclaims for node, %w", err)))
 	}
-	if !nodeclaimutils.IsManaged(nc, c.cloudProvider) {
+	if !nodeclaimutils.IsManaged(nc, c.cloudProvider) || !nodepoolutils.IsOwnerInShard(ctx, c.kubeClient, nc) {
 		return reconcile.Result{}, nil
 	}
 
@@ -0,0 +1,181 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+const (
+	ExpiredReason = "Expired"
+	DeletedReason = "Deleted"
+)
+
+// Controller records a NodeProvenance for each NodeClaim that is launched and updates it as the NodeClaim registers
+// and is terminated. NodeProvenances aren't owned by their NodeClaim so that they're retained after it is deleted.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", klog.KRef(nodeClaim.Namespace, nodeClaim.Name)))
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	// Only NodeClaims that have launched an instance are recorded
+	if nodeClaim.Status.ProviderID == "" {
+		return reconcile.Result{}, nil
+	}
+	provenance := &v1alpha1.NodeProvenance{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Name}, provenance); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("getting nodeprovenance, %w", err)
+		}
+		provenance = c.newProvenance(ctx, nodeClaim)
+		if err = c.kubeClient.Create(ctx, provenance); err != nil {
+			return reconcile.Result{}, client.IgnoreAlreadyExists(err)
+		}
+		log.FromContext(ctx).WithValues("NodeProvenance", klog.KObj(provenance)).V(1).Info("recorded nodeprovenance")
+	}
+	// The NodeProvenance was recorded for an earlier NodeClaim with the same name
+	if provenance.Spec.ProviderID != nodeClaim.Status.ProviderID {
+		return reconcile.Result{}, nil
+	}
+	stored := provenance.DeepCopy()
+	provenance.Status.NodeName = lo.Ternary(nodeClaim.Status.NodeName != "", nodeClaim.Status.NodeName, provenance.Status.NodeName)
+	if !nodeClaim.DeletionTimestamp.IsZero() && provenance.Status.TerminationTime == nil {
+		provenance.Status.TerminationTime = lo.ToPtr(*nodeClaim.DeletionTimestamp)
+		provenance.Status.TerminationReason = terminationReason(nodeClaim)
+	}
+	if !equality.Semantic.DeepEqual(stored, provenance) {
+		if err := c.kubeClient.Status().Patch(ctx, provenance, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) newProvenance(ctx context.Context, nodeClaim *v1.NodeClaim) *v1alpha1.NodeProvenance {
+	return &v1alpha1.NodeProvenance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeClaim.Name,
+			Labels: lo.PickByKeys(nodeClaim.Labels, []string{
+				v1.NodePoolLabelKey,
+				corev1.LabelInstanceTypeStable,
+				corev1.LabelTopologyZone,
+				v1.CapacityTypeLabelKey,
+			}),
+		},
+		Spec: v1alpha1.NodeProvenanceSpec{
+			NodeClaim:    nodeClaim.Name,
+			NodePool:     nodeClaim.Labels[v1.NodePoolLabelKey],
+			NodePoolHash: nodeClaim.Annotations[v1.NodePoolHashAnnotationKey],
+			ProviderID:   nodeClaim.Status.ProviderID,
+			InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+			Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+			CapacityType: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+			Price:        c.price(ctx, nodeClaim),
+			ImageID:      nodeClaim.Status.ImageID,
+			LaunchTime:   lo.Ternary(nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue(), nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).LastTransitionTime, nodeClaim.CreationTimestamp),
+		},
+	}
+}
+
+// price returns the price of the offering that the NodeClaim was launched into. The price isn't recorded if the
+// offering can't be found, e.g. because the NodePool was deleted before the NodeClaim was recorded.
+func (c *Controller) price(ctx context.Context, nodeClaim *v1.NodeClaim) string {
+	nodePool := &v1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}, nodePool); err != nil {
+		return ""
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed listing instance types, nodeprovenance will not include the price")
+		return ""
+	}
+	instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return it.Name == nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+	})
+	if !ok {
+		return ""
+	}
+	offerings := instanceType.Offerings.Compatible(scheduling.NewLabelRequirements(nodeClaim.Labels))
+	if len(offerings) == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(offerings.Cheapest().Price, 'f', -1, 64)
+}
+
+// terminationReason returns why the NodeClaim was deleted, as far as it can be determined from the NodeClaim
+func terminationReason(nodeClaim *v1.NodeClaim) string {
+	if cond := nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionReason); cond.IsTrue() && cond.Message != "" {
+		return cond.Message
+	}
+	if nodeClaim.Spec.ExpireAfter.Duration != nil && !nodeClaim.DeletionTimestamp.Time.Before(nodeClaim.CreationTimestamp.Add(*nodeClaim.Spec.ExpireAfter.Duration)) {
+		return ExpiredReason
+	}
+	return DeletedReason
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.provenance"
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsOwnerInShardPredicateFuncs(ctx, m.GetClient()))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 100,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// GarbageCollectionController deletes NodeProvenances once the retention period has passed since their NodeClaim was
+// terminated. NodeProvenances whose NodeClaim was removed without the termination being recorded are marked as
+// terminated when they're found.
+type GarbageCollectionController struct {
+	clock      clock.Clock
+	kubeClient client.Client
+}
+
+func NewGarbageCollectionController(clk clock.Clock, kubeClient client.Client) *GarbageCollectionController {
+	return &GarbageCollectionController{
+		clock:      clk,
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *GarbageCollectionController) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+
+	provenances := &v1alpha1.NodeProvenanceList{}
+	if err := c.kubeClient.List(ctx, provenances); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeprovenances, %w", err)
+	}
+	nodeClaims := &v1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	providerIDs := lo.SliceToMap(nodeClaims.Items, func(nc v1.NodeClaim) (string, struct{}) { return nc.Status.ProviderID, struct{}{} })
+
+	var errs error
+	for i := range provenances.Items {
+		provenance := &provenances.Items[i]
+		if provenance.Status.TerminationTime == nil {
+			// NodeProvenances that were just recorded may not have their NodeClaim in the cache yet
+			if _, ok := providerIDs[provenance.Spec.ProviderID]; ok || c.clock.Since(provenance.CreationTimestamp.Time) < time.Minute {
+				continue
+			}
+			stored := provenance.DeepCopy()
+			provenance.Status.TerminationTime = lo.ToPtr(metav1.NewTime(c.clock.Now()))
+			provenance.Status.TerminationReason = DeletedReason
+			errs = multierr.Append(errs, client.IgnoreNotFound(c.kubeClient.Status().Patch(ctx, provenance, client.MergeFrom(stored))))
+			continue
+		}
+		if c.clock.Since(provenance.Status.TerminationTime.Time) < options.FromContext(ctx).NodeProvenanceRetention {
+			continue
+		}
+		if err := c.kubeClient.Delete(ctx, provenance); err != nil {
+			errs = multierr.Append(errs, client.IgnoreNotFound(err))
+			continue
+		}
+		log.FromContext(ctx).WithValues("NodeProvenance", klog.KObj(provenance)).V(1).Info("garbage collecting nodeprovenance past its retention period")
+	}
+	if errs != nil {
+		return reconcile.Result{}, errs
+	}
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+func (c *GarbageCollectionController) Name() string {
+	return "nodeclaim.provenance.garbagecollection"
+}
+
+func (c *GarbageCollectionController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,220 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	karpv1alpha1 "sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/provenance"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var provenanceController *provenance.Controller
+var garbageCollectionController *provenance.GarbageCollectionController
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provenance")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeProvenanceRetention: lo.ToPtr(24 * time.Hour)}))
+
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	provenanceController = provenance.NewController(env.Client, cloudProvider)
+	garbageCollectionController = provenance.NewGarbageCollectionController(fakeClock, env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Provenance", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "test-instance-type",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1"}),
+						Price:        0.125,
+						Available:    true,
+					},
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1"}),
+						Price:        0.5,
+						Available:    true,
+					},
+				},
+			}),
+		}
+		nodeClaim = test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "test-instance-type",
+					corev1.LabelTopologyZone:       "test-zone-1",
+					v1.CapacityTypeLabelKey:        v1.CapacityTypeSpot,
+				},
+				Annotations: map[string]string{v1.NodePoolHashAnnotationKey: "test-hash"},
+				Finalizers:  []string{v1.TerminationFinalizer},
+			},
+			Status: v1.NodeClaimStatus{
+				ImageID: "test-image",
+			},
+		})
+	})
+
+	Context("Controller", func() {
+		It("should record a NodeProvenance for a launched NodeClaim", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Spec.NodeClaim).To(Equal(nodeClaim.Name))
+			Expect(record.Spec.NodePool).To(Equal(nodePool.Name))
+			Expect(record.Spec.NodePoolHash).To(Equal("test-hash"))
+			Expect(record.Spec.ProviderID).To(Equal(nodeClaim.Status.ProviderID))
+			Expect(record.Spec.InstanceType).To(Equal("test-instance-type"))
+			Expect(record.Spec.Zone).To(Equal("test-zone-1"))
+			Expect(record.Spec.CapacityType).To(Equal(v1.CapacityTypeSpot))
+			Expect(record.Spec.Price).To(Equal("0.125"))
+			Expect(record.Spec.ImageID).To(Equal("test-image"))
+			Expect(record.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+			Expect(record.Status.TerminationTime).To(BeNil())
+		})
+		It("should not record a NodeProvenance for a NodeClaim that hasn't launched", func() {
+			nodeClaim.Status.ProviderID = ""
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+		})
+		It("should record a NodeProvenance without the price when the NodePool doesn't exist", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Spec.Price).To(BeEmpty())
+		})
+		It("should record the node that registered for the NodeClaim", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+
+			nodeClaim.Status.NodeName = "test-node"
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Status.NodeName).To(Equal("test-node"))
+		})
+		It("should record the termination of the NodeClaim with its disruption reason", func() {
+			nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionReason, "Executing", string(v1.DisruptionReasonDrifted))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+
+			ExpectDeletionTimestampSet(ctx, env.Client, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Status.TerminationTime).ToNot(BeNil())
+			Expect(record.Status.TerminationTime.Time).To(BeTemporally("~", nodeClaim.DeletionTimestamp.Time, time.Second))
+			Expect(record.Status.TerminationReason).To(Equal(string(v1.DisruptionReasonDrifted)))
+		})
+		It("should record the termination of the NodeClaim when it was deleted without being disrupted", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+
+			ExpectDeletionTimestampSet(ctx, env.Client, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Status.TerminationReason).To(Equal(provenance.DeletedReason))
+		})
+	})
+	Context("Garbage Collection", func() {
+		It("should delete NodeProvenances once their retention period has passed", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			ExpectDeletionTimestampSet(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, ExpectExists(ctx, env.Client, nodeClaim))
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			record := &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}}
+
+			fakeClock.SetTime(time.Now().Add(23 * time.Hour))
+			ExpectSingletonReconciled(ctx, garbageCollectionController)
+			ExpectExists(ctx, env.Client, record)
+
+			fakeClock.SetTime(time.Now().Add(25 * time.Hour))
+			ExpectSingletonReconciled(ctx, garbageCollectionController)
+			ExpectNotFound(ctx, env.Client, record)
+		})
+		It("should record the termination of NodeProvenances whose NodeClaim no longer exists", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectDeleted(ctx, env.Client, nodeClaim)
+
+			fakeClock.SetTime(time.Now().Add(time.Hour))
+			ExpectSingletonReconciled(ctx, garbageCollectionController)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Status.TerminationTime).ToNot(BeNil())
+			Expect(record.Status.TerminationReason).To(Equal(provenance.DeletedReason))
+		})
+		It("should not record the termination of NodeProvenances whose NodeClaim exists", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, provenanceController, nodeClaim)
+
+			fakeClock.SetTime(time.Now().Add(time.Hour))
+			ExpectSingletonReconciled(ctx, garbageCollectionController)
+			record := ExpectExists(ctx, env.Client, &karpv1alpha1.NodeProvenance{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Name}})
+			Expect(record.Status.TerminationTime).To(BeNil())
+		})
+	})
+})
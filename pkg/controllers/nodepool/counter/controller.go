@@ -19,8 +19,10 @@ package counter
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -34,16 +36,22 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
+// LimitWarningThreshold is the fraction of any of a NodePool's limits above which the NodePool is notified with an
+// event that it's approaching its limits
+const LimitWarningThreshold = 0.9
+
 // Controller for the resource
 type Controller struct {
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
 	cluster       *state.Cluster
+	recorder      events.Recorder
 }
 
 var ResourceNode = corev1.ResourceName("nodes")
@@ -57,11 +65,12 @@ var BaseResources = corev1.ResourceList{
 }
 
 // NewController is a constructor
-func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster) *Controller {
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, recorder events.Recorder) *Controller {
 	return &Controller{
 		kubeClient:    kubeClient,
 		cloudProvider: cloudProvider,
 		cluster:       cluster,
+		recorder:      recorder,
 	}
 }
 
@@ -80,16 +89,63 @@ func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reco
 	}
 	stored := nodePool.DeepCopy()
 	// Determine resource usage and update nodepool.status.resources
-	nodePool.Status.Resources = c.resourceCountsFor(v1.NodePoolLabelKey, nodePool.Name)
+	nodePool.Status.Resources = c.resourceCountsFor(v1.NodePoolLabelKey, nodePool.Name, "")
+	nodePool.Status.Remaining = remaining(corev1.ResourceList(nodePool.Spec.Limits), nodePool.Status.Resources)
+	nodePool.Status.Zones = c.zoneStatusesFor(nodePool)
 	if !equality.Semantic.DeepEqual(stored, nodePool) {
 		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
 			return reconcile.Result{}, client.IgnoreNotFound(err)
 		}
+		c.notifyApproachingLimits(stored, nodePool)
 	}
 	return reconcile.Result{}, nil
 }
 
-func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string) corev1.ResourceList {
+// notifyApproachingLimits publishes an event for the limits whose usage crossed the warning threshold since the
+// NodePool's status was last updated
+func (c *Controller) notifyApproachingLimits(stored *v1.NodePool, nodePool *v1.NodePool) {
+	previous := nodepoolutils.LimitUsage(stored)
+	crossed := lo.Filter(lo.Entries(nodepoolutils.LimitUsage(nodePool)), func(e lo.Entry[corev1.ResourceName, float64], _ int) bool {
+		return e.Value >= LimitWarningThreshold && previous[e.Key] < LimitWarningThreshold
+	})
+	if len(crossed) == 0 {
+		return
+	}
+	sort.Slice(crossed, func(i, j int) bool { return crossed[i].Key < crossed[j].Key })
+	c.recorder.Publish(ApproachingLimitsEvent(nodePool, lo.Map(crossed, func(e lo.Entry[corev1.ResourceName, float64], _ int) string {
+		return fmt.Sprintf("%s (%d%%)", e.Key, int(e.Value*100))
+	})))
+}
+
+// zoneStatusesFor reports the resources that are provisioned and that remain in each of the NodePool's zones with zone limits
+func (c *Controller) zoneStatusesFor(nodePool *v1.NodePool) []v1.ZoneStatus {
+	return lo.Map(nodePool.Spec.ZoneLimits, func(zl v1.ZoneLimit, _ int) v1.ZoneStatus {
+		res := c.resourceCountsFor(v1.NodePoolLabelKey, nodePool.Name, zl.Zone)
+		return v1.ZoneStatus{
+			Zone:      zl.Zone,
+			Resources: res,
+			Remaining: remaining(corev1.ResourceList(zl.Limits), res),
+		}
+	})
+}
+
+// remaining returns the resources that can still be provisioned before reaching the limits, or nil without limits
+func remaining(limits corev1.ResourceList, res corev1.ResourceList) corev1.ResourceList {
+	if limits == nil {
+		return nil
+	}
+	return lo.MapValues(limits, func(limit resource.Quantity, name corev1.ResourceName) resource.Quantity {
+		remaining := limit.DeepCopy()
+		remaining.Sub(res[name])
+		if remaining.Sign() < 0 {
+			return resource.MustParse("0")
+		}
+		return remaining
+	})
+}
+
+// resourceCountsFor counts the resources of the owner's nodes in the zone, or in all zones if the zone is empty
+func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string, zone string) corev1.ResourceList {
 	res := BaseResources.DeepCopy()
 	nodeCount := 0
 	// Record all resources provisioned by the nodepools, we look at the cluster state nodes as their capacity
@@ -101,7 +157,7 @@ func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string) core
 		if n.MarkedForDeletion() {
 			return true
 		}
-		if n.Labels()[ownerLabel] == ownerName {
+		if n.Labels()[ownerLabel] == ownerName && (zone == "" || n.Labels()[corev1.LabelTopologyZone] == zone) {
 			res = resources.MergeInto(res, n.Capacity())
 			nodeCount += 1
 		}
@@ -111,10 +167,10 @@ func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string) core
 	return res
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodepool.counter").
-		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsInShardPredicateFuncs(ctx))).
 		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
 		Watches(&corev1.Node{}, nodepoolutils.NodeEventHandler()).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
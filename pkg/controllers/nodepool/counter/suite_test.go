@@ -51,6 +51,7 @@ var env *test.Environment
 var cluster *state.Cluster
 var fakeClock *clock.FakeClock
 var cloudProvider *fake.CloudProvider
+var recorder *test.EventRecorder
 var node, node2 *corev1.Node
 
 func TestAPIs(t *testing.T) {
@@ -63,11 +64,12 @@ var _ = BeforeSuite(func() {
 	cloudProvider = fake.NewCloudProvider()
 	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
 	fakeClock = clock.NewFakeClock(time.Now())
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	nodeController = informer.NewNodeController(env.Client, cluster)
 	nodePoolInformerController = informer.NewNodePoolController(env.Client, cloudProvider, cluster)
-	nodePoolController = counter.NewController(env.Client, cloudProvider, cluster)
+	recorder = test.NewEventRecorder()
+	nodePoolController = counter.NewController(env.Client, cloudProvider, cluster, recorder)
 })
 
 var _ = AfterSuite(func() {
@@ -80,6 +82,7 @@ var expected corev1.ResourceList
 
 var _ = Describe("Counter", func() {
 	BeforeEach(func() {
+		recorder.Reset()
 		cloudProvider.InstanceTypes = fake.InstanceTypesAssorted()
 		nodePool = test.NodePool()
 		instanceType := cloudProvider.InstanceTypes[0]
@@ -263,4 +266,65 @@ var _ = Describe("Counter", func() {
 		expected = counter.BaseResources.DeepCopy()
 		Expect(nodePool.Status.Resources).To(BeComparableTo(expected))
 	})
+	It("should report the resources and remaining capacity of zones with zone limits", func() {
+		nodePool.Spec.ZoneLimits = []v1.ZoneLimit{
+			{Zone: "test-zone-1", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("1")}},
+			{Zone: "test-zone-2", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("100m")}},
+		}
+		node.Labels[corev1.LabelTopologyZone] = "test-zone-1"
+		node2.Labels[corev1.LabelTopologyZone] = "test-zone-2"
+		ExpectApplied(ctx, env.Client, nodePool, node, nodeClaim, node2, nodeClaim2)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.Zones).To(HaveLen(2))
+		Expect(nodePool.Status.Zones[0].Zone).To(Equal("test-zone-1"))
+		Expect(nodePool.Status.Zones[0].Resources.Cpu().String()).To(Equal("100m"))
+		Expect(nodePool.Status.Zones[0].Resources[counter.ResourceNode]).To(BeComparableTo(resource.MustParse("1")))
+		Expect(nodePool.Status.Zones[0].Remaining).To(BeComparableTo(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("900m")}))
+		// Zones that are over their limits have no remaining capacity
+		Expect(nodePool.Status.Zones[1].Zone).To(Equal("test-zone-2"))
+		Expect(nodePool.Status.Zones[1].Resources.Cpu().String()).To(Equal("500m"))
+		Expect(nodePool.Status.Zones[1].Remaining).To(BeComparableTo(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0")}))
+	})
+	It("should report the remaining capacity of limits", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("4Gi")}
+		ExpectApplied(ctx, env.Client, nodePool, node, nodeClaim, node2, nodeClaim2)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		// Limits that have been exceeded have no remaining capacity
+		Expect(nodePool.Status.Remaining).To(BeComparableTo(corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("400m"),
+			corev1.ResourceMemory: resource.MustParse("0"),
+		}))
+	})
+	It("should not report the remaining capacity without limits", func() {
+		ExpectApplied(ctx, env.Client, nodePool, node, nodeClaim)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Remaining).To(BeNil())
+	})
+	It("should publish an event when the usage of a limit crosses the warning threshold", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("650m")}
+		ExpectApplied(ctx, env.Client, nodePool, node, nodeClaim)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		Expect(recorder.Calls("ApproachingLimits")).To(Equal(0))
+
+		ExpectApplied(ctx, env.Client, node2, nodeClaim2)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node2}, []*v1.NodeClaim{nodeClaim2})
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		Expect(recorder.Calls("ApproachingLimits")).To(Equal(1))
+
+		// Usage that stays above the threshold isn't notified again
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		Expect(recorder.Calls("ApproachingLimits")).To(Equal(1))
+	})
 })
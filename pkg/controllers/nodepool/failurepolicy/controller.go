@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package failurepolicy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+const (
+	defaultInitialBackoff = time.Minute
+	defaultMaxBackoff     = 30 * time.Minute
+	// degradedWindow is how long a NodePool is considered degraded after a launch failure, unless a launch succeeds
+	degradedWindow = 10 * time.Minute
+	// launchFailuresReason is the reason of the Degraded conditions that are set from the NodePool's launch failures,
+	// which distinguishes them from the Degraded conditions set by the cloud provider
+	launchFailuresReason = "RecentLaunchFailures"
+)
+
+// Controller disables NodePools for a backoff period once their launches have failed more times in a row than their
+// FailurePolicy allows, so that repeated cloud provider errors don't consume provider quota on every batch. NodePools
+// whose launches have recently failed are also marked as degraded, so that pods fall back to other NodePools.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+// NewController is a constructor
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.failurepolicy")
+	stored := nodePool.DeepCopy()
+
+	result := c.setLaunchesHealthyCondition(nodePool)
+	if degraded := c.setDegradedCondition(nodePool); degraded.RequeueAfter > 0 && (result.RequeueAfter == 0 || degraded.RequeueAfter < result.RequeueAfter) {
+		result = degraded
+	}
+
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+		if nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsFalse() && !stored.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsFalse() {
+			c.recorder.Publish(NodePoolLaunchBackoffEvent(nodePool, nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).Message))
+		}
+		if nodePool.StatusConditions().Get(v1.ConditionTypeDegraded).IsTrue() && !stored.StatusConditions().Get(v1.ConditionTypeDegraded).IsTrue() {
+			c.recorder.Publish(NodePoolDegradedEvent(nodePool, nodePool.StatusConditions().Get(v1.ConditionTypeDegraded).Message))
+		}
+	}
+	return result, nil
+}
+
+// setLaunchesHealthyCondition opens the circuit breaker once the NodePool reaches its maximum number of consecutive
+// launch failures and closes it again once the backoff expires. A launch that fails after the NodePool is re-enabled
+// opens the circuit breaker again with a doubled backoff.
+func (c *Controller) setLaunchesHealthyCondition(nodePool *v1.NodePool) reconcile.Result {
+	policy := nodePool.Spec.FailurePolicy
+	failures := nodePool.Status.ConsecutiveLaunchFailures
+	if policy == nil || failures < lo.Max([]int32{policy.MaxConsecutiveFailures, 1}) || nodePool.Status.LastLaunchFailureTime == nil {
+		nodePool.StatusConditions().SetTrue(v1.ConditionTypeLaunchesHealthy)
+		return reconcile.Result{}
+	}
+	backoff := Backoff(policy, failures)
+	cond := nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy)
+	if cond.IsFalse() {
+		if remaining := backoff - c.clock.Since(cond.LastTransitionTime.Time); remaining > 0 {
+			return reconcile.Result{RequeueAfter: remaining}
+		}
+		nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeLaunchesHealthy, "BackoffExpired", "Launch failure backoff expired")
+		return reconcile.Result{}
+	}
+	// The NodePool has already been re-enabled since its last launch failure
+	if cond.IsTrue() && nodePool.Status.LastLaunchFailureTime.Before(&cond.LastTransitionTime) {
+		return reconcile.Result{}
+	}
+	nodePool.StatusConditions().SetFalse(v1.ConditionTypeLaunchesHealthy, "LaunchFailureBackoff",
+		fmt.Sprintf("%d consecutive launch failures, disabling nodepool for %s", failures, backoff))
+	return reconcile.Result{RequeueAfter: backoff}
+}
+
+// setDegradedCondition marks the NodePool as degraded while a launch has failed within the degraded window and no launch
+// has succeeded since. Degraded conditions that are set by the cloud provider are left for the cloud provider to clear.
+func (c *Controller) setDegradedCondition(nodePool *v1.NodePool) reconcile.Result {
+	if cond := nodePool.StatusConditions().Get(v1.ConditionTypeDegraded); cond != nil && cond.Reason != launchFailuresReason {
+		return reconcile.Result{}
+	}
+	var remaining time.Duration
+	if nodePool.Status.ConsecutiveLaunchFailures > 0 && nodePool.Status.LastLaunchFailureTime != nil {
+		remaining = degradedWindow - c.clock.Since(nodePool.Status.LastLaunchFailureTime.Time)
+	}
+	if remaining <= 0 {
+		_ = nodePool.StatusConditions().Clear(v1.ConditionTypeDegraded)
+		return reconcile.Result{}
+	}
+	nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeDegraded, launchFailuresReason,
+		fmt.Sprintf("%d consecutive launch failures, preferring other nodepools", nodePool.Status.ConsecutiveLaunchFailures))
+	return reconcile.Result{RequeueAfter: remaining}
+}
+
+// Backoff returns how long a NodePool is disabled for after the given number of consecutive launch failures. The
+// backoff starts at InitialBackoff and doubles for each failure past MaxConsecutiveFailures, up to MaxBackoff.
+func Backoff(policy *v1.FailurePolicy, failures int32) time.Duration {
+	initial := lo.Ternary(policy.InitialBackoff != nil, lo.FromPtr(policy.InitialBackoff).Duration, defaultInitialBackoff)
+	maxBackoff := lo.Ternary(policy.MaxBackoff != nil, lo.FromPtr(policy.MaxBackoff).Duration, defaultMaxBackoff)
+	exponent := float64(failures - lo.Max([]int32{policy.MaxConsecutiveFailures, 1}))
+	backoff := float64(initial) * math.Pow(2, lo.Max([]float64{exponent, 0}))
+	if backoff > float64(maxBackoff) {
+		return maxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.failurepolicy").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsInShardPredicateFuncs(ctx))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package failurepolicy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/failurepolicy"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var (
+	controller    *failurepolicy.Controller
+	ctx           context.Context
+	env           *test.Environment
+	fakeClock     *clock.FakeClock
+	cloudProvider *fake.CloudProvider
+	recorder      *test.EventRecorder
+	nodePool      *v1.NodePool
+)
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FailurePolicy")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	recorder = test.NewEventRecorder()
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	controller = failurepolicy.NewController(fakeClock, env.Client, cloudProvider, recorder)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = Describe("FailurePolicy", func() {
+	BeforeEach(func() {
+		fakeClock.SetTime(time.Now())
+		recorder.Reset()
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				FailurePolicy: &v1.FailurePolicy{
+					MaxConsecutiveFailures: 3,
+					InitialBackoff:         &metav1.Duration{Duration: time.Minute},
+					MaxBackoff:             &metav1.Duration{Duration: 10 * time.Minute},
+				},
+			},
+		})
+		nodePool.StatusConditions().SetUnknown(v1.ConditionTypeLaunchesHealthy)
+	})
+	It("should mark NodePools without a failure policy as healthy", func() {
+		nodePool.Spec.FailurePolicy = nil
+		nodePool.Status.ConsecutiveLaunchFailures = 10
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsTrue()).To(BeTrue())
+		Expect(nodePool.StatusConditions().IsTrue(status.ConditionReady)).To(BeTrue())
+	})
+	It("should mark NodePools with fewer consecutive failures than the maximum as healthy", func() {
+		nodePool.Status.ConsecutiveLaunchFailures = 2
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsTrue()).To(BeTrue())
+	})
+	It("should disable NodePools that reach the maximum consecutive failures", func() {
+		nodePool.Status.ConsecutiveLaunchFailures = 3
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+		ExpectApplied(ctx, env.Client, nodePool)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("LaunchFailureBackoff"))
+		Expect(nodePool.StatusConditions().IsTrue(status.ConditionReady)).To(BeFalse())
+		Expect(recorder.Calls("LaunchFailureBackoff")).To(Equal(1))
+	})
+	It("should re-enable NodePools once the backoff expires", func() {
+		nodePool.Status.ConsecutiveLaunchFailures = 3
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now().Add(-time.Minute)))
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsFalse()).To(BeTrue())
+
+		fakeClock.Step(2 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsTrue()).To(BeTrue())
+		Expect(nodePool.StatusConditions().IsTrue(status.ConditionReady)).To(BeTrue())
+
+		// The NodePool stays enabled until another launch fails
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsTrue()).To(BeTrue())
+	})
+	It("should disable NodePools with a longer backoff when a launch fails after they're re-enabled", func() {
+		nodePool.Status.ConsecutiveLaunchFailures = 3
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now().Add(-time.Hour)))
+		nodePool.Status.Conditions = lo.Reject(nodePool.Status.Conditions, func(c status.Condition, _ int) bool {
+			return c.Type == v1.ConditionTypeLaunchesHealthy
+		})
+		nodePool.Status.Conditions = append(nodePool.Status.Conditions, status.Condition{
+			Type:               v1.ConditionTypeLaunchesHealthy,
+			Status:             metav1.ConditionTrue,
+			Reason:             "BackoffExpired",
+			Message:            "Launch failure backoff expired",
+			LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-time.Minute)),
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsTrue()).To(BeTrue())
+
+		nodePool.Status.ConsecutiveLaunchFailures = 4
+		nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+		ExpectApplied(ctx, env.Client, nodePool)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(result.RequeueAfter).To(Equal(2 * time.Minute))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeLaunchesHealthy).IsFalse()).To(BeTrue())
+	})
+	It("should double the backoff for each failure up to the maximum backoff", func() {
+		policy := nodePool.Spec.FailurePolicy
+		Expect(failurepolicy.Backoff(policy, 3)).To(Equal(time.Minute))
+		Expect(failurepolicy.Backoff(policy, 4)).To(Equal(2 * time.Minute))
+		Expect(failurepolicy.Backoff(policy, 6)).To(Equal(8 * time.Minute))
+		Expect(failurepolicy.Backoff(policy, 7)).To(Equal(10 * time.Minute))
+		Expect(failurepolicy.Backoff(policy, 100)).To(Equal(10 * time.Minute))
+	})
+	Context("Degraded", func() {
+		It("should mark NodePools with a recent launch failure as degraded", func() {
+			nodePool.Spec.FailurePolicy = nil
+			nodePool.Status.ConsecutiveLaunchFailures = 1
+			nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now().Add(-time.Minute)))
+			ExpectApplied(ctx, env.Client, nodePool)
+			result := ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			Expect(result.RequeueAfter).To(Equal(9 * time.Minute))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			condition := nodePool.StatusConditions().Get(v1.ConditionTypeDegraded)
+			Expect(condition.IsTrue()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("RecentLaunchFailures"))
+			// Degraded NodePools are still ready, they're only deprioritized
+			Expect(nodePool.StatusConditions().IsTrue(status.ConditionReady)).To(BeTrue())
+			Expect(recorder.Calls("Degraded")).To(Equal(1))
+		})
+		It("should clear the degraded condition once the launch failures are no longer recent", func() {
+			nodePool.Status.ConsecutiveLaunchFailures = 1
+			nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDegraded).IsTrue()).To(BeTrue())
+
+			fakeClock.Step(11 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDegraded)).To(BeNil())
+		})
+		It("should clear the degraded condition once a launch succeeds", func() {
+			nodePool.Status.ConsecutiveLaunchFailures = 1
+			nodePool.Status.LastLaunchFailureTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDegraded).IsTrue()).To(BeTrue())
+
+			nodePool.Status.ConsecutiveLaunchFailures = 0
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDegraded)).To(BeNil())
+		})
+		It("should not clear a degraded condition that was set by the cloud provider", func() {
+			nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeDegraded, "InsufficientCapacity", "capacity is constrained")
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			condition := nodePool.StatusConditions().Get(v1.ConditionTypeDegraded)
+			Expect(condition.IsTrue()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("InsufficientCapacity"))
+		})
+	})
+})
@@ -0,0 +1,147 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impact
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// Controller reports the immediate, and likely unintended, impact that a NodePool's spec has on the capacity that
+// the NodePool already manages. The spec has an impact if the NodePool's current usage exceeds its limits or if every
+// NodeClaim that the NodePool owns is incompatible with its requirements, and so drifted. The impact is reported on
+// the ImpactAccepted condition, and drifted NodeClaims aren't replaced until the impact is accepted by setting the
+// karpenter.sh/force annotation to "true". The condition is cleared while the NodePoolImpact feature gate is disabled.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.impact")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	stored := nodePool.DeepCopy()
+	// The condition is cleared when the feature gate is disabled so that a stale condition doesn't block drift
+	if !options.FromContext(ctx).FeatureGates.NodePoolImpact {
+		if err := nodePool.StatusConditions().Clear(v1.ConditionTypeImpactAccepted); err != nil {
+			return reconcile.Result{}, err
+		}
+		return c.patch(ctx, stored, nodePool)
+	}
+	// Usage is computed from cluster state, so it must be synced before we can tell whether the limits are exceeded
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	impact := c.impact(nodePool)
+	switch {
+	case len(impact) == 0:
+		nodePool.StatusConditions().SetTrue(v1.ConditionTypeImpactAccepted)
+	case nodePool.Annotations[v1.NodePoolForceAnnotationKey] == "true":
+		nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeImpactAccepted, "Forced", strings.Join(impact, "; "))
+	default:
+		nodePool.StatusConditions().SetFalse(v1.ConditionTypeImpactAccepted, "ImpactNotAccepted",
+			fmt.Sprintf("%s (set the %s annotation to \"true\" to accept the impact)", strings.Join(impact, "; "), v1.NodePoolForceAnnotationKey))
+	}
+	return c.patch(ctx, stored, nodePool)
+}
+
+func (c *Controller) patch(ctx context.Context, stored, nodePool *v1.NodePool) (reconcile.Result, error) {
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// impact returns a description of each of the immediate effects that the NodePool's spec has on its capacity
+func (c *Controller) impact(nodePool *v1.NodePool) []string {
+	var impact []string
+	usage := corev1.ResourceList{}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	nodeClaims, drifted := 0, 0
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		// Nodes that we are planning to delete aren't counted, consistent with the NodePool's resource counts
+		if n.MarkedForDeletion() || n.Labels()[v1.NodePoolLabelKey] != nodePool.Name {
+			return true
+		}
+		usage = resources.MergeInto(usage, n.Capacity())
+		if n.NodeClaim != nil {
+			nodeClaims++
+			if scheduling.NewLabelRequirements(n.NodeClaim.Labels).Compatible(requirements) != nil {
+				drifted++
+			}
+		}
+		return true
+	})
+	if err := nodePool.Spec.Limits.ExceededBy(usage); err != nil {
+		impact = append(impact, fmt.Sprintf("current usage exceeds the limits, %s", err))
+	}
+	if drifted > 0 && drifted == nodeClaims {
+		impact = append(impact, fmt.Sprintf("all %d nodeclaim(s) are drifted since they're incompatible with the requirements", drifted))
+	}
+	return impact
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.impact").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsInShardPredicateFuncs(ctx))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
+		Watches(&corev1.Node{}, nodepoolutils.NodeEventHandler()).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
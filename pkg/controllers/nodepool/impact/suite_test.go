@@ -0,0 +1,166 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impact_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/impact"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var controller *impact.Controller
+var nodeClaimController *informer.NodeClaimController
+var nodeController *informer.NodeController
+var ctx context.Context
+var env *test.Environment
+var cluster *state.Cluster
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{NodePoolImpact: lo.ToPtr(true)}}))
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Impact")
+}
+
+var _ = BeforeSuite(func() {
+	cloudProvider = fake.NewCloudProvider()
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	cluster = state.NewCluster(clock.NewFakeClock(time.Now()), env.Client, cloudProvider, test.NewEventRecorder())
+	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	controller = impact.NewController(env.Client, cloudProvider, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+})
+
+var _ = Describe("Impact", func() {
+	var nodePool *v1.NodePool
+	var nodeClaims []*v1.NodeClaim
+	var nodes []*corev1.Node
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Template: v1.NodeClaimTemplate{
+					Spec: v1.NodeClaimTemplateSpec{
+						Requirements: []v1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}},
+						},
+					},
+				},
+			},
+		})
+		nodeClaims, nodes = nil, nil
+		for _, zone := range []string{"test-zone-1", "test-zone-2"} {
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name, corev1.LabelTopologyZone: zone}},
+				Status: v1.NodeClaimStatus{
+					ProviderID: test.RandomProviderID(),
+					Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5")},
+				},
+			})
+			nodeClaims = append(nodeClaims, nodeClaim)
+			nodes = append(nodes, node)
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodes[0], nodes[1])
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, nodes, nodeClaims)
+	})
+	It("should accept the impact of a nodepool whose usage is within its limits", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("20")}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeImpactAccepted).IsTrue()).To(BeTrue())
+	})
+	It("should not accept the impact of limits below the usage in cluster state", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("5")}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := ExpectStatusConditionExists(nodePool, v1.ConditionTypeImpactAccepted)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("cpu resource usage of 10 exceeds limit of 5"))
+	})
+	It("should not count nodes that are marked for deletion towards the usage", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("5")}
+		ExpectApplied(ctx, env.Client, nodePool)
+		cluster.MarkForDeletion(nodeClaims[0].Status.ProviderID)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeImpactAccepted).IsTrue()).To(BeTrue())
+	})
+	It("should accept the impact of requirements that drift some of the nodeclaims", func() {
+		nodePool.Spec.Template.Spec.Requirements[0].Values = []string{"test-zone-1"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeImpactAccepted).IsTrue()).To(BeTrue())
+	})
+	It("should not accept the impact of requirements that drift all of the nodeclaims", func() {
+		nodePool.Spec.Template.Spec.Requirements[0].Values = []string{"test-zone-3"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := ExpectStatusConditionExists(nodePool, v1.ConditionTypeImpactAccepted)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("all 2 nodeclaim(s) are drifted"))
+	})
+	It("should clear the condition when the NodePoolImpact feature gate is disabled", func() {
+		nodePool.StatusConditions().SetFalse(v1.ConditionTypeImpactAccepted, "ImpactNotAccepted", "all 2 nodeclaim(s) are drifted")
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(options.ToContext(ctx, test.Options()), env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeImpactAccepted)).To(BeNil())
+	})
+	It("should accept the impact with the force annotation", func() {
+		nodePool.Annotations = map[string]string{v1.NodePoolForceAnnotationKey: "true"}
+		nodePool.Spec.Template.Spec.Requirements[0].Values = []string{"test-zone-3"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := ExpectStatusConditionExists(nodePool, v1.ConditionTypeImpactAccepted)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("Forced"))
+	})
+})
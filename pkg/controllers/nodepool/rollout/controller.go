@@ -0,0 +1,149 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller reports the progress of replacing the nodes of a NodePool that have drifted from its template. Drifted
+// nodes are replaced by the disruption controller, which honors the NodePool's rollout and disruption budgets.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController is a constructor
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.rollout")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider, nodeclaimutils.ForNodePool(nodePool.Name))
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	stored := nodePool.DeepCopy()
+	nodePool.Status.Rollout = RolloutStatus(c.clock, nodePool, nodeClaims)
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// RolloutStatus counts the NodePool's nodes that are updated to its template. NodeClaims that are being deleted aren't
+// counted since they're being replaced, and NodeClaims are only updated once they're launched from the NodePool's
+// current template and haven't drifted from their NodeClass. The rollout starts when the NodePool has nodes that aren't
+// updated, and the updated nodes that are launched after it starts are counted as replaced.
+func RolloutStatus(clk clock.Clock, nodePool *v1.NodePool, nodeClaims []*v1.NodeClaim) *v1.RolloutStatus {
+	templateVersion := nodePool.Hash()
+	disrupting := lo.CountBy(nodeClaims, func(nodeClaim *v1.NodeClaim) bool {
+		return !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionReason).IsTrue()
+	})
+	nodeClaims = lo.Filter(nodeClaims, func(nodeClaim *v1.NodeClaim, _ int) bool { return nodeClaim.DeletionTimestamp.IsZero() })
+	updated := lo.Filter(nodeClaims, func(nodeClaim *v1.NodeClaim, _ int) bool {
+		return nodeClaim.Annotations[v1.NodePoolHashAnnotationKey] == templateVersion &&
+			!nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()
+	})
+	drifted := lo.CountBy(nodeClaims, func(nodeClaim *v1.NodeClaim) bool {
+		return nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()
+	})
+	status := &v1.RolloutStatus{
+		TemplateVersion: templateVersion,
+		Nodes:           int32(len(nodeClaims)), //nolint:gosec
+		UpdatedNodes:    int32(len(updated)),    //nolint:gosec
+		DriftedNodes:    int32(drifted),         //nolint:gosec
+		DisruptingNodes: int32(disrupting),      //nolint:gosec
+		Paused:          nodePool.Spec.Disruption.Rollout != nil && nodePool.Spec.Disruption.Rollout.Paused,
+	}
+	// The rollout is complete once all nodes are updated
+	if status.UpdatedNodes == status.Nodes {
+		return status
+	}
+	status.StartTime = &metav1.Time{Time: clk.Now()}
+	if nodePool.Status.Rollout != nil && nodePool.Status.Rollout.StartTime != nil {
+		status.StartTime = nodePool.Status.Rollout.StartTime
+	}
+	replaced := lo.Filter(updated, func(nodeClaim *v1.NodeClaim, _ int) bool {
+		return !nodeClaim.CreationTimestamp.Before(status.StartTime)
+	})
+	status.ReplacedNodes = int32(len(replaced)) //nolint:gosec
+	status.EstimatedCompletionTime = estimatedCompletionTime(clk, nodePool, status, replaced)
+	return status
+}
+
+// estimatedCompletionTime extrapolates when the rollout will complete from the time it took to replace the nodes that
+// have been replaced so far. Nodes are assumed to be replaced in waves of the size that the NodePool's disruption
+// budgets allow for drift. The estimate is based on the launch time of the last replaced node rather than the current
+// time so that it only changes as nodes are replaced.
+func estimatedCompletionTime(clk clock.Clock, nodePool *v1.NodePool, status *v1.RolloutStatus, replaced []*v1.NodeClaim) *metav1.Time {
+	if status.Paused || len(replaced) == 0 {
+		return nil
+	}
+	allowed, err := nodePool.GetAllowedDisruptionsByReason(clk, int(status.Nodes), v1.DisruptionReasonDrifted)
+	if err != nil || allowed <= 0 {
+		return nil
+	}
+	last := lo.MaxBy(replaced, func(a, b *v1.NodeClaim) bool { return a.CreationTimestamp.After(b.CreationTimestamp.Time) }).CreationTimestamp.Time
+	waves := func(nodes int) int { return (nodes + allowed - 1) / allowed }
+	waveDuration := last.Sub(status.StartTime.Time) / time.Duration(waves(len(replaced)))
+	remaining := int(status.Nodes - status.UpdatedNodes)
+	return &metav1.Time{Time: last.Add(waveDuration * time.Duration(waves(remaining)))}
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.rollout").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider), nodepoolutils.IsInShardPredicateFuncs(ctx))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
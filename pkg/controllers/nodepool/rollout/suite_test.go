@@ -0,0 +1,219 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/rollout"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var (
+	controller    *rollout.Controller
+	ctx           context.Context
+	env           *test.Environment
+	cloudProvider *fake.CloudProvider
+	fakeClock     *clock.FakeClock
+	nodePool      *v1.NodePool
+)
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rollout")
+}
+
+var _ = BeforeSuite(func() {
+	cloudProvider = fake.NewCloudProvider()
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	fakeClock = clock.NewFakeClock(time.Now())
+	controller = rollout.NewController(fakeClock, env.Client, cloudProvider)
+})
+var _ = BeforeEach(func() {
+	fakeClock.SetTime(time.Now())
+})
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = Describe("Rollout", func() {
+	var updated, outdated, drifted *v1.NodeClaim
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaimFor := func(hash string) *v1.NodeClaim {
+			return test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{v1.NodePoolHashAnnotationKey: hash},
+				},
+			})
+		}
+		updated = nodeClaimFor(nodePool.Hash())
+		outdated = nodeClaimFor("outdated-hash")
+		drifted = nodeClaimFor(nodePool.Hash())
+		drifted.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+	})
+	It("should report the number of nodes that are updated to the nodepool's template", func() {
+		ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.TemplateVersion).To(Equal(nodePool.Hash()))
+		Expect(nodePool.Status.Rollout.Nodes).To(BeNumerically("==", 3))
+		Expect(nodePool.Status.Rollout.UpdatedNodes).To(BeNumerically("==", 1))
+		Expect(nodePool.Status.Rollout.DriftedNodes).To(BeNumerically("==", 1))
+	})
+	It("should not count nodes that are being deleted", func() {
+		outdated.Finalizers = []string{v1.TerminationFinalizer}
+		ExpectApplied(ctx, env.Client, nodePool, updated, outdated)
+		ExpectDeletionTimestampSet(ctx, env.Client, outdated)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.Nodes).To(BeNumerically("==", 1))
+		Expect(nodePool.Status.Rollout.UpdatedNodes).To(BeNumerically("==", 1))
+	})
+	It("should not count the nodes of other nodepools", func() {
+		outdated.Labels[v1.NodePoolLabelKey] = "other-nodepool"
+		ExpectApplied(ctx, env.Client, nodePool, updated, outdated)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.Nodes).To(BeNumerically("==", 1))
+	})
+	It("should report whether the rollout is paused", func() {
+		nodePool.Spec.Disruption.Rollout = &v1.Rollout{Paused: true, MaxUnavailable: lo.ToPtr("1")}
+		ExpectApplied(ctx, env.Client, nodePool, updated, outdated)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.Paused).To(BeTrue())
+		Expect(nodePool.Status.Rollout.UpdatedNodes).To(BeNumerically("==", 1))
+	})
+	It("should count all nodes as outdated once the nodepool's template changes", func() {
+		ExpectApplied(ctx, env.Client, nodePool, updated)
+		nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{"new-label": "new-value"})
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.TemplateVersion).To(Equal(nodePool.Hash()))
+		Expect(nodePool.Status.Rollout.UpdatedNodes).To(BeNumerically("==", 0))
+	})
+	It("should report the number of nodes that are being disrupted", func() {
+		outdated.Finalizers = []string{v1.TerminationFinalizer}
+		drifted.StatusConditions().SetTrue(v1.ConditionTypeDisruptionReason)
+		ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+		ExpectDeletionTimestampSet(ctx, env.Client, outdated)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Rollout.DisruptingNodes).To(BeNumerically("==", 2))
+	})
+	Context("Progress", func() {
+		It("should start the rollout when the nodepool has nodes that aren't updated", func() {
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.StartTime).ToNot(BeNil())
+			Expect(nodePool.Status.Rollout.StartTime.Time).To(BeTemporally("~", fakeClock.Now(), time.Second))
+		})
+		It("should not start the rollout when all nodes are updated", func() {
+			ExpectApplied(ctx, env.Client, nodePool, updated)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.StartTime).To(BeNil())
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime).To(BeNil())
+		})
+		It("should count the updated nodes launched since the rollout started as replaced", func() {
+			startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+			nodePool.Status.Rollout = &v1.RolloutStatus{StartTime: &startTime}
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.StartTime.Time).To(BeTemporally("~", startTime.Time, time.Second))
+			Expect(nodePool.Status.Rollout.ReplacedNodes).To(BeNumerically("==", 1))
+		})
+		It("should estimate the completion time in waves of the size that the budgets allow", func() {
+			startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "1"}}
+			nodePool.Status.Rollout = &v1.RolloutStatus{StartTime: &startTime}
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			// The replaced node took one wave of 10 minutes, and the two remaining nodes take a wave each
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime).ToNot(BeNil())
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime.Time).To(BeTemporally("~", time.Now().Add(20*time.Minute), 5*time.Second))
+		})
+		It("should estimate the completion time in a single wave when the budgets allow all nodes to be disrupted", func() {
+			startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "100%"}}
+			nodePool.Status.Rollout = &v1.RolloutStatus{StartTime: &startTime}
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime).ToNot(BeNil())
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime.Time).To(BeTemporally("~", time.Now().Add(10*time.Minute), 5*time.Second))
+		})
+		It("should not estimate the completion time when the budgets block drift", func() {
+			startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "0", Reasons: []v1.DisruptionReason{v1.DisruptionReasonDrifted}}}
+			nodePool.Status.Rollout = &v1.RolloutStatus{StartTime: &startTime}
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.ReplacedNodes).To(BeNumerically("==", 1))
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime).To(BeNil())
+		})
+		It("should not estimate the completion time when the rollout is paused", func() {
+			startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+			nodePool.Spec.Disruption.Rollout = &v1.Rollout{Paused: true, MaxUnavailable: lo.ToPtr("1")}
+			nodePool.Status.Rollout = &v1.RolloutStatus{StartTime: &startTime}
+			ExpectApplied(ctx, env.Client, nodePool, updated, outdated, drifted)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Rollout.EstimatedCompletionTime).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,185 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skew
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+const analysisInterval = time.Minute
+
+// Controller periodically analyzes the distribution of each NodePool's nodes across the zones that it can launch
+// nodes in. NodePools whose most and least populated zones differ by more than the configured threshold are reported
+// with the ZonallySkewed status condition, so that operators can rebalance their capacity.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	metricStore   *metrics.Store
+}
+
+// NewController constructs a controller instance
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		metricStore:   metrics.NewStore(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.skew")
+
+	nodePools, err := nodepoolutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodes := c.nodesByZone()
+	skews := map[string]int{}
+	for _, nodePool := range nodePools {
+		zones, err := c.zones(ctx, nodePool)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		counts := lo.SliceToMap(zones.Union(sets.KeySet(nodes[nodePool.Name])).UnsortedList(), func(zone string) (string, int) {
+			return zone, nodes[nodePool.Name][zone]
+		})
+		skews[nodePool.Name] = Skew(counts)
+		if err := c.setCondition(ctx, nodePool, counts); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	c.metricStore.ReplaceAll(lo.MapEntries(skews, func(nodePool string, skew int) (string, []*metrics.StoreMetric) {
+		return nodePool, []*metrics.StoreMetric{{
+			GaugeMetric: ZonalSkew,
+			Value:       float64(skew),
+			Labels:      map[string]string{metrics.NodePoolLabel: nodePool},
+		}}
+	}))
+	return reconcile.Result{RequeueAfter: analysisInterval}, nil
+}
+
+// nodesByZone counts the initialized nodes of each NodePool in each zone. Nodes that are being deleted aren't counted,
+// since they're about to leave their zone.
+func (c *Controller) nodesByZone() map[string]map[string]int {
+	nodes := map[string]map[string]int{}
+	for _, n := range c.cluster.Nodes() {
+		if !n.Managed() || !n.Initialized() || n.MarkedForDeletion() {
+			continue
+		}
+		nodePool, zone := n.Labels()[v1.NodePoolLabelKey], n.Labels()[corev1.LabelTopologyZone]
+		if nodePool == "" || zone == "" {
+			continue
+		}
+		if _, ok := nodes[nodePool]; !ok {
+			nodes[nodePool] = map[string]int{}
+		}
+		nodes[nodePool][zone]++
+	}
+	return nodes
+}
+
+// zones returns the zones that the NodePool can launch nodes in, which are the zones of the available offerings of
+// its instance types that are compatible with its requirements
+func (c *Controller) zones(ctx context.Context, nodePool *v1.NodePool) (sets.Set[string], error) {
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance types, %w", err)
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	zones := sets.New[string]()
+	for _, it := range instanceTypes {
+		for _, o := range it.Offerings.Available().Compatible(requirements) {
+			if zone := o.Requirements.Get(corev1.LabelTopologyZone).Any(); zone != "" {
+				zones.Insert(zone)
+			}
+		}
+	}
+	return zones, nil
+}
+
+func (c *Controller) setCondition(ctx context.Context, nodePool *v1.NodePool, counts map[string]int) error {
+	stored := nodePool.DeepCopy()
+	if skew, threshold := Skew(counts), options.FromContext(ctx).ZonalSkewThreshold; skew > threshold {
+		nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeZonallySkewed, "SkewExceedsThreshold",
+			fmt.Sprintf("Nodes per zone differ by %d, which exceeds the threshold of %d (%s)", skew, threshold, describe(counts)))
+	} else {
+		nodePool.StatusConditions().SetFalse(v1.ConditionTypeZonallySkewed, "Balanced",
+			fmt.Sprintf("Nodes per zone differ by %d, which is within the threshold of %d", skew, threshold))
+	}
+	if equality.Semantic.DeepEqual(stored, nodePool) {
+		return nil
+	}
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the status condition list
+	if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("patching nodepool status, %w", err)
+	}
+	return nil
+}
+
+// Skew returns the difference between the number of nodes in the most and least populated zones
+func Skew(counts map[string]int) int {
+	if len(counts) == 0 {
+		return 0
+	}
+	values := lo.Values(counts)
+	return lo.Max(values) - lo.Min(values)
+}
+
+// describe lists the number of nodes in each zone, ordered by zone
+func describe(counts map[string]int) string {
+	zones := lo.Keys(counts)
+	sort.Strings(zones)
+	return strings.Join(lo.Map(zones, func(zone string, _ int) string { return fmt.Sprintf("%s=%d", zone, counts[zone]) }), ", ")
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.skew").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
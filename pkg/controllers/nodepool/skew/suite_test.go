@@ -0,0 +1,163 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skew_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/skew"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var cluster *state.Cluster
+var nodeStateController *informer.NodeController
+var nodeClaimStateController *informer.NodeClaimController
+var skewController *skew.Controller
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Skew")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ZonalSkewThreshold: lo.ToPtr(1)}))
+
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(clock.NewFakeClock(time.Now()), env.Client, cloudProvider, test.NewEventRecorder())
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	skewController = skew.NewController(env.Client, cloudProvider, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Skew", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+	})
+	ExpectNodesInZones := func(zones ...string) {
+		GinkgoHelper()
+		var nodeClaims []*v1.NodeClaim
+		var nodes []*corev1.Node
+		for _, zone := range zones {
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:      nodePool.Name,
+						corev1.LabelTopologyZone: zone,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			nodeClaims = append(nodeClaims, nodeClaim)
+			nodes = append(nodes, node)
+		}
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+	}
+	ExpectSkew := func(value float64) {
+		GinkgoHelper()
+		metric, found := FindMetricWithLabelValues("karpenter_nodepools_zonal_skew", map[string]string{"nodepool": nodePool.Name})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", value))
+	}
+	It("should report a nodepool whose skew exceeds the threshold", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectNodesInZones("test-zone-1", "test-zone-1", "test-zone-2")
+		ExpectSingletonReconciled(ctx, skewController)
+
+		// test-zone-3 has no nodes, so the skew is 2
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeZonallySkewed).IsTrue()).To(BeTrue())
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeZonallySkewed).Message).To(ContainSubstring("test-zone-3=0"))
+		ExpectSkew(2)
+	})
+	It("should not report a nodepool whose skew is within the threshold", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectNodesInZones("test-zone-1", "test-zone-2", "test-zone-3", "test-zone-1")
+		ExpectSingletonReconciled(ctx, skewController)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeZonallySkewed).IsFalse()).To(BeTrue())
+		ExpectSkew(1)
+	})
+	It("should only consider the zones that the nodepool can launch nodes in", func() {
+		nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+				Key:      corev1.LabelTopologyZone,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"test-zone-1", "test-zone-2"},
+			},
+		}}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectNodesInZones("test-zone-1", "test-zone-2")
+		ExpectSingletonReconciled(ctx, skewController)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeZonallySkewed).IsFalse()).To(BeTrue())
+		ExpectSkew(0)
+	})
+	It("should not count nodes that are being deleted", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectNodesInZones("test-zone-1", "test-zone-1", "test-zone-1", "test-zone-2", "test-zone-3")
+		deleting, ok := lo.Find(cluster.Nodes(), func(n *state.StateNode) bool {
+			return n.Labels()[corev1.LabelTopologyZone] == "test-zone-1"
+		})
+		Expect(ok).To(BeTrue())
+		cluster.MarkForDeletion(deleting.ProviderID())
+		ExpectSingletonReconciled(ctx, skewController)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeZonallySkewed).IsFalse()).To(BeTrue())
+		ExpectSkew(1)
+	})
+	It("should compute the skew between the most and least populated zones", func() {
+		Expect(skew.Skew(map[string]int{})).To(Equal(0))
+		Expect(skew.Skew(map[string]int{"test-zone-1": 4})).To(Equal(0))
+		Expect(skew.Skew(map[string]int{"test-zone-1": 4, "test-zone-2": 1, "test-zone-3": 2})).To(Equal(3))
+	})
+})
@@ -0,0 +1,190 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utilization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+const (
+	// Window is the sliding window that utilization is averaged over
+	Window = time.Hour
+	// OversizedThreshold is the average utilization of every resource below which an instance type is considered
+	// oversized for the pods that a NodePool launches nodes for
+	OversizedThreshold = 0.5
+
+	samplingInterval = time.Minute
+)
+
+// utilizationResources are the resources that utilization is tracked for
+var utilizationResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+type key struct {
+	nodePool     string
+	instanceType string
+}
+
+// sample is the aggregate pod requests and allocatable resources of the nodes of an instance type in a NodePool
+type sample struct {
+	time        time.Time
+	requested   corev1.ResourceList
+	allocatable corev1.ResourceList
+}
+
+// Controller periodically samples the utilization of the nodes of each NodePool by instance type and publishes the
+// average utilization over a sliding window. NodePools that consistently launch instance types that their pods
+// don't utilize are notified with an event, since tightening their requirements would let them launch smaller nodes.
+type Controller struct {
+	clock       clock.Clock
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	recorder    events.Recorder
+	metricStore *metrics.Store
+	samples     map[key][]sample
+}
+
+// NewController constructs a controller instance
+func NewController(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:       clk,
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		recorder:    recorder,
+		metricStore: metrics.NewStore(),
+		samples:     map[key][]sample{},
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.utilization")
+
+	c.sample()
+	c.metricStore.ReplaceAll(lo.MapEntries(c.samples, func(k key, samples []sample) (string, []*metrics.StoreMetric) {
+		return k.nodePool + "/" + k.instanceType, buildMetrics(k, samples)
+	}))
+	if err := c.notifyOversized(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: samplingInterval}, nil
+}
+
+// sample records the current utilization of the initialized nodes of each NodePool by instance type and forgets
+// samples that have left the window
+func (c *Controller) sample() {
+	now := c.clock.Now()
+	current := map[key]sample{}
+	for _, n := range c.cluster.Nodes() {
+		if !n.Managed() || n.Node == nil || !n.Initialized() || n.MarkedForDeletion() {
+			continue
+		}
+		k := key{nodePool: n.Labels()[v1.NodePoolLabelKey], instanceType: n.Labels()[corev1.LabelInstanceTypeStable]}
+		if k.nodePool == "" || k.instanceType == "" {
+			continue
+		}
+		s := current[k]
+		s.requested = resources.Merge(s.requested, n.PodRequests())
+		s.allocatable = resources.Merge(s.allocatable, n.Allocatable())
+		current[k] = s
+	}
+	for k, s := range current {
+		s.time = now
+		c.samples[k] = append(c.samples[k], s)
+	}
+	for k, samples := range c.samples {
+		samples = lo.Filter(samples, func(s sample, _ int) bool { return now.Sub(s.time) <= Window })
+		if len(samples) == 0 {
+			delete(c.samples, k)
+			continue
+		}
+		c.samples[k] = samples
+	}
+}
+
+// notifyOversized publishes an event for each NodePool with instance types that have been below the oversized
+// threshold for every resource over the whole window
+func (c *Controller) notifyOversized(ctx context.Context) error {
+	oversized := map[string][]string{}
+	for k, samples := range c.samples {
+		if c.clock.Since(samples[0].time) < Window {
+			continue
+		}
+		if lo.EveryBy(utilizationResources, func(r corev1.ResourceName) bool {
+			u, ok := utilization(samples, r)
+			return ok && u < OversizedThreshold
+		}) {
+			oversized[k.nodePool] = append(oversized[k.nodePool], k.instanceType)
+		}
+	}
+	for name, instanceTypes := range oversized {
+		nodePool := &v1.NodePool{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("getting nodepool, %w", err)
+			}
+			continue
+		}
+		sort.Strings(instanceTypes)
+		c.recorder.Publish(OversizedNodesEvent(nodePool, instanceTypes))
+	}
+	return nil
+}
+
+// utilization returns the average utilization of a resource across the samples. Utilization is weighted by the
+// allocatable resources of each sample, so it's the fraction of all the sampled capacity that pods requested.
+func utilization(samples []sample, resourceName corev1.ResourceName) (float64, bool) {
+	var requested, allocatable float64
+	for _, s := range samples {
+		requested += quantity(s.requested, resourceName)
+		allocatable += quantity(s.allocatable, resourceName)
+	}
+	if allocatable == 0 {
+		return 0, false
+	}
+	return requested / allocatable, true
+}
+
+func quantity(resourceList corev1.ResourceList, resourceName corev1.ResourceName) float64 {
+	q := resourceList[resourceName]
+	return lo.Ternary(resourceName == corev1.ResourceCPU, float64(q.MilliValue())/float64(1000), float64(q.Value()))
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.utilization").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
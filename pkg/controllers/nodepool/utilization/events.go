@@ -0,0 +1,39 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utilization
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func OversizedNodesEvent(nodePool *v1.NodePool, instanceTypes []string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "OversizedNodes",
+		Message: fmt.Sprintf("Pods requested less than %d%% of the cpu and memory of instance types %s over the last %s, consider tightening the nodepool's requirements",
+			int(OversizedThreshold*100), strings.Join(instanceTypes, ", "), Window),
+		DedupeValues:  []string{string(nodePool.UID)},
+		DedupeTimeout: Window,
+	}
+}
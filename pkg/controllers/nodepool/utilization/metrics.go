@@ -0,0 +1,69 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utilization
+
+import (
+	"strings"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	nodePoolLabel     = "nodepool"
+	instanceTypeLabel = "instance_type"
+	resourceTypeLabel = "resource_type"
+)
+
+var (
+	InstanceTypeUtilization = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodePoolSubsystem,
+			Name:      "instance_type_utilization_percent",
+			Help:      "Average utilization of the allocatable resources of a nodepool's nodes by pod requests over the last hour. Labeled by nodepool, instance type and resource type.",
+		},
+		[]string{
+			nodePoolLabel,
+			instanceTypeLabel,
+			resourceTypeLabel,
+		},
+	)
+)
+
+func buildMetrics(k key, samples []sample) (res []*metrics.StoreMetric) {
+	for _, resourceName := range utilizationResources {
+		u, ok := utilization(samples, resourceName)
+		if !ok {
+			continue
+		}
+		res = append(res, &metrics.StoreMetric{
+			GaugeMetric: InstanceTypeUtilization,
+			Value:       100 * u,
+			Labels: map[string]string{
+				nodePoolLabel:     k.nodePool,
+				instanceTypeLabel: k.instanceType,
+				resourceTypeLabel: strings.ReplaceAll(strings.ToLower(string(resourceName)), "-", "_"),
+			},
+		})
+	}
+	return res
+}
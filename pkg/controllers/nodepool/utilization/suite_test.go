@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utilization_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/utilization"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var env *test.Environment
+var cluster *state.Cluster
+var nodeStateController *informer.NodeController
+var nodeClaimStateController *informer.NodeClaimController
+var podStateController *informer.PodController
+var utilizationController *utilization.Controller
+var cloudProvider *fake.CloudProvider
+var recorder *test.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Utilization")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = test.NewEventRecorder()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	podStateController = informer.NewPodController(env.Client, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	fakeClock.SetTime(time.Now())
+	utilizationController = utilization.NewController(fakeClock, env.Client, cluster, recorder)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+	recorder.Reset()
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Utilization", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "large-instance-type",
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+					corev1.ResourcePods:   resource.MustParse("100"),
+				},
+			},
+		})
+		pod = test.Pod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			},
+		})
+	})
+	ExpectUtilization := func(resourceType string, value float64) {
+		GinkgoHelper()
+		metric, found := FindMetricWithLabelValues("karpenter_nodepools_instance_type_utilization_percent", map[string]string{
+			"nodepool":      nodePool.Name,
+			"instance_type": "large-instance-type",
+			"resource_type": resourceType,
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("~", value, 0.01))
+	}
+	ExpectBound := func() {
+		GinkgoHelper()
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(pod))
+	}
+
+	It("should publish the utilization of each instance type of a nodepool", func() {
+		ExpectBound()
+		ExpectSingletonReconciled(ctx, utilizationController)
+
+		ExpectUtilization("cpu", 25)
+		ExpectUtilization("memory", 12.5)
+	})
+	It("should average the utilization over the window", func() {
+		ExpectBound()
+		ExpectSingletonReconciled(ctx, utilizationController)
+
+		ExpectDeleted(ctx, env.Client, pod)
+		ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(pod))
+		fakeClock.Step(time.Minute)
+		ExpectSingletonReconciled(ctx, utilizationController)
+		ExpectUtilization("cpu", 12.5)
+
+		// The sample with the pod leaves the window
+		fakeClock.Step(utilization.Window)
+		ExpectSingletonReconciled(ctx, utilizationController)
+		ExpectUtilization("cpu", 0)
+	})
+	It("should notify nodepools that consistently launch oversized nodes", func() {
+		ExpectBound()
+		ExpectSingletonReconciled(ctx, utilizationController)
+		Expect(recorder.Calls("OversizedNodes")).To(Equal(0))
+
+		fakeClock.Step(utilization.Window)
+		ExpectSingletonReconciled(ctx, utilizationController)
+		Expect(recorder.Calls("OversizedNodes")).To(Equal(1))
+	})
+	It("should not notify nodepools whose nodes are utilized", func() {
+		pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("3"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}
+		ExpectBound()
+		ExpectSingletonReconciled(ctx, utilizationController)
+		fakeClock.Step(utilization.Window)
+		ExpectSingletonReconciled(ctx, utilizationController)
+		Expect(recorder.Calls("OversizedNodes")).To(Equal(0))
+	})
+	It("should stop publishing the utilization of instance types that are no longer launched", func() {
+		ExpectBound()
+		ExpectSingletonReconciled(ctx, utilizationController)
+
+		ExpectDeleted(ctx, env.Client, pod, node, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, nodeClaimStateController, client.ObjectKeyFromObject(nodeClaim))
+		fakeClock.Step(utilization.Window + time.Minute)
+		ExpectSingletonReconciled(ctx, utilizationController)
+
+		_, found := FindMetricWithLabelValues("karpenter_nodepools_instance_type_utilization_percent", map[string]string{
+			"nodepool":      nodePool.Name,
+			"instance_type": "large-instance-type",
+		})
+		Expect(found).To(BeFalse())
+	})
+})
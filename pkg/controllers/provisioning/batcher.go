@@ -21,12 +21,36 @@ import (
 	"sync"
 	"time"
 
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
 
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
+// BatchMode is how the batcher sizes its batching windows based on the pending pod backlog
+type BatchMode string
+
+const (
+	// BatchModeBurst shortens the idle window while the backlog is at or above the high threshold, so that pods are
+	// scheduled in smaller rounds whose NodeClaims are created while the next batch is collected
+	BatchModeBurst BatchMode = "burst"
+	// BatchModeNormal uses the configured batching windows
+	BatchModeNormal BatchMode = "normal"
+	// BatchModePacking lengthens the idle window while the backlog is at or below the low threshold, so that more pods
+	// are packed onto fewer nodes
+	BatchModePacking BatchMode = "packing"
+)
+
+var batchModes = []BatchMode{BatchModeBurst, BatchModeNormal, BatchModePacking}
+
+const (
+	// burstIdleDivisor is how much shorter the idle window is in burst mode
+	burstIdleDivisor = 4
+	// packingIdleMultiplier is how much longer the idle window is in packing mode, up to the maximum batch duration
+	packingIdleMultiplier = 2
+)
+
 // Batcher separates a stream of Trigger() calls into windowed slices. The
 // window is dynamic and will be extended if additional items are added up to a
 // maximum batch duration.
@@ -36,6 +60,10 @@ type Batcher[T comparable] struct {
 
 	mu    sync.RWMutex
 	elems sets.Set[T]
+	// windowStart is when the last batching window was started
+	windowStart time.Time
+	// backlog is the number of pending pods that were seen by the last scheduling round
+	backlog int
 }
 
 // NewBatcher is a constructor for the Batcher
@@ -82,12 +110,14 @@ func (b *Batcher[T]) Wait(ctx context.Context) bool {
 	case <-b.trigger:
 		// start the batching window after the first item is received
 		timeout.Stop()
+		b.windowStart = b.clk.Now()
 	case <-timeout.C():
 		// If no pods, bail to the outer controller framework to refresh the context
 		return false
 	}
-	timeout = b.clk.NewTimer(options.FromContext(ctx).BatchMaxDuration)
-	idle := b.clk.NewTimer(options.FromContext(ctx).BatchIdleDuration)
+	idleDuration, maxDuration := b.windows(ctx)
+	timeout = b.clk.NewTimer(maxDuration)
+	idle := b.clk.NewTimer(idleDuration)
 	defer func() {
 		timeout.Stop()
 		idle.Stop()
@@ -100,7 +130,7 @@ func (b *Batcher[T]) Wait(ctx context.Context) bool {
 			if !idle.Stop() {
 				<-idle.C()
 			}
-			idle.Reset(options.FromContext(ctx).BatchIdleDuration)
+			idle.Reset(idleDuration)
 		case <-timeout.C():
 			return true
 		case <-idle.C():
@@ -108,3 +138,52 @@ func (b *Batcher[T]) Wait(ctx context.Context) bool {
 		}
 	}
 }
+
+// WindowStart returns when the last batching window was started
+func (b *Batcher[T]) WindowStart() time.Time {
+	return b.windowStart
+}
+
+// SetBacklog records the number of pending pods that were seen by the last scheduling round, which determines the mode
+// of the following batching windows
+func (b *Batcher[T]) SetBacklog(ctx context.Context, backlog int) {
+	b.mu.Lock()
+	b.backlog = backlog
+	b.mu.Unlock()
+	PendingPodBacklog.Set(float64(backlog), nil)
+	mode := b.Mode(ctx)
+	for _, m := range batchModes {
+		CurrentBatchMode.Set(lo.Ternary(m == mode, 1.0, 0.0), map[string]string{modeLabel: string(m)})
+	}
+	idleDuration, maxDuration := b.windows(ctx)
+	BatchWindowDuration.Set(idleDuration.Seconds(), map[string]string{windowLabel: "idle"})
+	BatchWindowDuration.Set(maxDuration.Seconds(), map[string]string{windowLabel: "max"})
+}
+
+// Mode returns how the batching windows are sized for the current pending pod backlog
+func (b *Batcher[T]) Mode(ctx context.Context) BatchMode {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	opts := options.FromContext(ctx)
+	switch {
+	case opts.BatchBacklogHighThreshold > 0 && b.backlog >= opts.BatchBacklogHighThreshold:
+		return BatchModeBurst
+	case opts.BatchBacklogLowThreshold > 0 && b.backlog <= opts.BatchBacklogLowThreshold:
+		return BatchModePacking
+	default:
+		return BatchModeNormal
+	}
+}
+
+// windows returns the idle and maximum durations of the next batching window
+func (b *Batcher[T]) windows(ctx context.Context) (time.Duration, time.Duration) {
+	opts := options.FromContext(ctx)
+	switch b.Mode(ctx) {
+	case BatchModeBurst:
+		return opts.BatchIdleDuration / burstIdleDivisor, opts.BatchMaxDuration
+	case BatchModePacking:
+		return lo.Min([]time.Duration{opts.BatchIdleDuration * packingIdleMultiplier, opts.BatchMaxDuration}), opts.BatchMaxDuration
+	default:
+		return opts.BatchIdleDuration, opts.BatchMaxDuration
+	}
+}
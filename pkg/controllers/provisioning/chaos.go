@@ -0,0 +1,85 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/awslabs/operatorpkg/option"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// ProvisionerOptions are the set of options that can be used to configure a Provisioner
+type ProvisionerOptions struct {
+	Chaos *rand.Rand
+}
+
+// WithChaos randomizes the composition of every scheduling round using the given source of randomness: the pods are
+// shuffled and a daemonset overhead of a random size is injected. It's only intended to fuzz the scheduler in tests
+// that check the invariants of the scheduling results, and must never be used by the operator.
+func WithChaos(r *rand.Rand) option.Function[ProvisionerOptions] {
+	return func(o *ProvisionerOptions) {
+		o.Chaos = r
+	}
+}
+
+// chaos randomizes the composition of scheduling rounds. The source of randomness is shared by the provisioning rounds
+// and the scheduling simulations of disruption, so it's guarded by a mutex.
+type chaos struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// shuffle randomizes the order of the pods of a scheduling round
+func (c *chaos) shuffle(pods []*corev1.Pod) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.r.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
+}
+
+// daemonSetPods returns the daemonset pods of a scheduling round with an additional daemonset pod of a random size,
+// which tolerates every taint so that its overhead is reserved on every node that's launched
+func (c *chaos) daemonSetPods(pods []*corev1.Pod) []*corev1.Pod {
+	if c == nil {
+		return pods
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(pods, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "chaos-daemonset-overhead", UID: uuid.NewUUID()},
+		Spec: corev1.PodSpec{
+			Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name: "overhead",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%dm", 10*c.r.Intn(50))),
+						corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dMi", 16*c.r.Intn(64))),
+					},
+				},
+			}},
+		},
+	})
+}
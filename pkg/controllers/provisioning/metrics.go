@@ -0,0 +1,80 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	maxNodesReason = "max_nodes"
+
+	provisionerSubsystem = "provisioner"
+	modeLabel            = "mode"
+	windowLabel          = "window"
+)
+
+var NodeClaimsRefusedTotal = opmetrics.NewPrometheusCounter(
+	crmetrics.Registry,
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: metrics.NodeClaimSubsystem,
+		Name:      "refused_total",
+		Help:      "Number of nodeclaims that provisioning refused to create because a cluster-wide safety limit was reached. Labeled by nodepool and reason.",
+	},
+	[]string{
+		metrics.NodePoolLabel,
+		metrics.ReasonLabel,
+	},
+)
+
+var PendingPodBacklog = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: provisionerSubsystem,
+		Name:      "pending_pod_backlog",
+		Help:      "Number of pending pods that were seen by the last scheduling round, which determines the batch mode.",
+	},
+	[]string{},
+)
+
+var CurrentBatchMode = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: provisionerSubsystem,
+		Name:      "batch_mode",
+		Help:      "Whether the batcher is in the given mode, set to 1 for the current mode and 0 otherwise. Labeled by mode.",
+	},
+	[]string{modeLabel},
+)
+
+var BatchWindowDuration = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: provisionerSubsystem,
+		Name:      "batch_window_duration_seconds",
+		Help:      "Duration of the batching windows for the current batch mode. Labeled by window, which is either idle or max.",
+	},
+	[]string{windowLabel},
+)
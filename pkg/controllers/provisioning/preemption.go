@@ -0,0 +1,110 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// advisePreemption recommends preempting lower priority pods for the pods that couldn't schedule because of the limits
+// of their NodePools. kube-scheduler preemption can't help these pods since they don't fit on any existing node, even
+// though a new node could be launched for them if the limits allowed it. A node is recommended if all of its pods have
+// a lower priority than the pending pod and a node of its size fits the pending pod: once its pods are preempted, the
+// node is consolidated as empty, which frees enough of its NodePool's limits to launch a node of the same size. The
+// recommended pods are evicted when the PreemptionAdvisorEviction feature gate is enabled.
+func (p *Provisioner) advisePreemption(ctx context.Context, nodes state.StateNodes, podErrors map[*corev1.Pod]error) {
+	if !options.FromContext(ctx).PreemptionAdvisor {
+		return
+	}
+	pending := lo.Filter(lo.Keys(podErrors), func(pod *corev1.Pod, _ int) bool {
+		return lo.FromPtr(pod.Spec.Priority) > 0 && len(scheduler.LimitedNodePools(podErrors[pod])) > 0
+	})
+	// the pods with the highest priority are advised first, so that they get the nodes that are cheapest to preempt
+	sort.SliceStable(pending, func(a, b int) bool {
+		return lo.FromPtr(pending[a].Spec.Priority) > lo.FromPtr(pending[b].Spec.Priority)
+	})
+	recommended := sets.New[string]()
+	for _, pod := range pending {
+		node, victims, ok := p.preemptionCandidate(ctx, nodes, pod, sets.New(scheduler.LimitedNodePools(podErrors[pod])...), recommended)
+		if !ok {
+			continue
+		}
+		recommended.Insert(node.Name())
+		log.FromContext(ctx).WithValues("Pod", klog.KObj(pod), "Node", klog.KObj(node.Node), "preemptible-pods", len(victims)).Info("recommending preemption of lower priority pods, nodepool limits prevent launching a node")
+		p.recorder.Publish(scheduler.PodPreemptionRecommendedEvent(pod, node.Node, victims))
+		if options.FromContext(ctx).FeatureGates.PreemptionAdvisorEviction && !options.FromContext(ctx).DryRun {
+			p.evict(ctx, victims)
+		}
+	}
+}
+
+// preemptionCandidate returns the node of one of the NodePools with the fewest pods to preempt for the pending pod,
+// skipping nodes that were already recommended for another pod
+func (p *Provisioner) preemptionCandidate(ctx context.Context, nodes state.StateNodes, pending *corev1.Pod, nodePools sets.Set[string], recommended sets.Set[string]) (*state.StateNode, []*corev1.Pod, bool) {
+	requests := resources.RequestsForPods(pending)
+	var candidate *state.StateNode
+	var candidateVictims []*corev1.Pod
+	for _, node := range nodes {
+		if node.Node == nil || !node.Managed() || node.MarkedForDeletion() || recommended.Has(node.Name()) ||
+			!nodePools.Has(node.Labels()[v1.NodePoolLabelKey]) || !resources.Fits(requests, node.Allocatable()) {
+			continue
+		}
+		victims, err := node.ReschedulablePods(ctx, p.kubeClient)
+		if err != nil {
+			log.FromContext(ctx).WithValues("Node", klog.KObj(node.Node)).Error(err, "failed listing pods")
+			continue
+		}
+		// empty nodes are already consolidated without preempting anything
+		if len(victims) == 0 || lo.ContainsBy(victims, func(v *corev1.Pod) bool {
+			return lo.FromPtr(v.Spec.Priority) >= lo.FromPtr(pending.Spec.Priority) || podutils.HasDoNotDisrupt(v)
+		}) {
+			continue
+		}
+		if candidate == nil || len(victims) < len(candidateVictims) {
+			candidate, candidateVictims = node, victims
+		}
+	}
+	return candidate, candidateVictims, candidate != nil
+}
+
+// evict creates evictions for the pods, which respect their PodDisruptionBudgets
+func (p *Provisioner) evict(ctx context.Context, pods []*corev1.Pod) {
+	for _, victim := range pods {
+		if err := p.kubeClient.SubResource("eviction").Create(ctx, victim, &policyv1.Eviction{
+			DeleteOptions: &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: lo.ToPtr(victim.UID)}},
+		}); client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).WithValues("Pod", klog.KObj(victim)).Error(err, "failed evicting pod for preemption")
+		}
+	}
+}
@@ -20,16 +20,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/awslabs/operatorpkg/option"
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
@@ -48,9 +53,13 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
@@ -80,22 +89,29 @@ type Provisioner struct {
 	recorder       events.Recorder
 	cm             *pretty.ChangeMonitor
 	clock          clock.Clock
+	// inflight tracks the NodeClaims of the last round that are still being created while the next batch is collected
+	inflight sync.WaitGroup
+	// chaos randomizes the composition of scheduling rounds when the provisioner is fuzzed in tests
+	chaos *chaos
 }
 
 func NewProvisioner(kubeClient client.Client, recorder events.Recorder,
 	cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster,
-	clock clock.Clock,
+	clock clock.Clock, opts ...option.Function[ProvisionerOptions],
 ) *Provisioner {
 	p := &Provisioner{
 		batcher:        NewBatcher[types.UID](clock),
 		cloudProvider:  cloudProvider,
 		kubeClient:     kubeClient,
-		volumeTopology: scheduler.NewVolumeTopology(kubeClient),
+		volumeTopology: scheduler.NewVolumeTopology(kubeClient, cluster),
 		cluster:        cluster,
 		recorder:       recorder,
 		cm:             pretty.NewChangeMonitor(),
 		clock:          clock,
 	}
+	if r := option.Resolve(opts...).Chaos; r != nil {
+		p.chaos = &chaos{r: r}
+	}
 	return p
 }
 
@@ -103,7 +119,12 @@ func (p *Provisioner) Trigger(uid types.UID) {
 	p.batcher.Trigger(uid)
 }
 
-func (p *Provisioner) Register(_ context.Context, m manager.Manager) error {
+func (p *Provisioner) Register(ctx context.Context, m manager.Manager) error {
+	if options.FromContext(ctx).EnableSchedulingSnapshot {
+		if err := m.AddMetricsServerExtraHandler(SnapshotPath, p.SnapshotHandler(ctx)); err != nil {
+			return fmt.Errorf("adding scheduling snapshot handler, %w", err)
+		}
+	}
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("provisioner").
 		WatchesRawSource(singleton.Source()).
@@ -117,6 +138,14 @@ func (p *Provisioner) Reconcile(ctx context.Context) (result reconcile.Result, e
 	if triggered := p.batcher.Wait(ctx); !triggered {
 		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 	}
+	// The trace of a provisioning round starts when its batching window was started
+	ctx, span := tracing.Tracer().Start(ctx, "provisioner.provision", trace.WithTimestamp(p.batcher.WindowStart()))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	_, batchSpan := tracing.Tracer().Start(ctx, "provisioner.batch", trace.WithTimestamp(p.batcher.WindowStart()))
+	batchSpan.End()
 	// We need to ensure that our internal cluster state mechanism is synced before we proceed
 	// with making any scheduling decision off of our state nodes. Otherwise, we have the potential to make
 	// a scheduling decision based on a smaller subset of nodes in our cluster state than actually exist.
@@ -125,6 +154,10 @@ func (p *Provisioner) Reconcile(ctx context.Context) (result reconcile.Result, e
 		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 	}
 
+	// The NodeClaims of the last round must be in cluster state before we schedule against it, so that the pods they
+	// were launched for aren't provisioned twice
+	p.inflight.Wait()
+
 	// Schedule pods to potential nodes, exit if nothing to do
 	results, err := p.Schedule(ctx)
 	if err != nil {
@@ -133,6 +166,19 @@ func (p *Provisioner) Reconcile(ctx context.Context) (result reconcile.Result, e
 	if len(results.NewNodeClaims) == 0 {
 		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 	}
+	// While the backlog of pending pods is large, create the NodeClaims while the next batch is collected. Only the
+	// creates are pipelined: rounds are still scheduled one at a time, since concurrent rounds would simulate against
+	// the same pending pods and cluster state, and launch capacity for the same pods twice.
+	if p.batcher.Mode(ctx) == BatchModeBurst {
+		p.inflight.Add(1)
+		go func() {
+			defer p.inflight.Done()
+			if _, err := p.CreateNodeClaims(ctx, results.NewNodeClaims, WithReason(metrics.ProvisionedReason), RecordPodNomination); err != nil {
+				log.FromContext(ctx).Error(err, "failed creating nodeclaims")
+			}
+		}()
+		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
+	}
 	if _, err = p.CreateNodeClaims(ctx, results.NewNodeClaims, WithReason(metrics.ProvisionedReason), RecordPodNomination); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -142,6 +188,8 @@ func (p *Provisioner) Reconcile(ctx context.Context) (result reconcile.Result, e
 // CreateNodeClaims launches nodes passed into the function in parallel. It returns a slice of the successfully created node
 // names as well as a multierr of any errors that occurred while launching nodes
 func (p *Provisioner) CreateNodeClaims(ctx context.Context, nodeClaims []*scheduler.NodeClaim, opts ...option.Function[LaunchOptions]) ([]string, error) {
+	ctx, span := tracing.StartSpan(ctx, "provisioner.createNodeClaims", trace.WithAttributes(attribute.Int("karpenter.nodeclaims", len(nodeClaims))))
+	defer span.End()
 	// Create capacity and bind pods
 	errs := make([]error, len(nodeClaims))
 	nodeClaimNames := make([]string, len(nodeClaims))
@@ -153,7 +201,9 @@ func (p *Provisioner) CreateNodeClaims(ctx context.Context, nodeClaims []*schedu
 			nodeClaimNames[i] = name
 		}
 	})
-	return nodeClaimNames, multierr.Combine(errs...)
+	err := multierr.Combine(errs...)
+	tracing.RecordError(span, err)
+	return nodeClaimNames, err
 }
 
 func (p *Provisioner) GetPendingPods(ctx context.Context) ([]*corev1.Pod, error) {
@@ -168,13 +218,48 @@ func (p *Provisioner) GetPendingPods(ctx context.Context) ([]*corev1.Pod, error)
 			log.FromContext(ctx).WithValues("Pod", klog.KRef(po.Namespace, po.Name)).V(1).Info(fmt.Sprintf("ignoring pod, %s", err))
 			return true
 		}
+		if podutils.IsStandaloneIgnored(ctx, po) {
+			p.recorder.Publish(scheduler.PodStandaloneIgnoredEvent(po))
+			return true
+		}
 		return false
 	})
 	scheduler.IgnoredPodCount.Set(float64(len(rejectedPods)), nil)
+	if pods, err = p.podsInShard(ctx, pods); err != nil {
+		return nil, err
+	}
+	gatedPods, err := nodeutils.GetSchedulingGatedPods(ctx, p.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("listing scheduling gated pods, %w", err)
+	}
+	scheduler.SchedulingGatedPodCount.Set(float64(len(gatedPods)), nil)
 	p.consolidationWarnings(ctx, pods)
 	return pods, nil
 }
 
+// podsInShard returns the pods that a NodePool owned by the shard of this Karpenter deployment could launch capacity
+// for. Pods that no NodePool of the shard tolerates or is compatible with are left to the other shards so that they
+// aren't provisioned for more than once.
+func (p *Provisioner) podsInShard(ctx context.Context, pods []*corev1.Pod) ([]*corev1.Pod, error) {
+	if !nodepoolutils.IsSharded(ctx) {
+		return pods, nil
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodeClaimTemplates := lo.FilterMap(nodePools, func(np *v1.NodePool, _ int) (*scheduler.NodeClaimTemplate, bool) {
+		return scheduler.NewNodeClaimTemplate(ctx, np), np.DeletionTimestamp.IsZero()
+	})
+	return lo.Filter(pods, func(po *corev1.Pod, _ int) bool {
+		return lo.ContainsBy(nodeClaimTemplates, func(nct *scheduler.NodeClaimTemplate) bool {
+			taints := lo.Reject(nct.Spec.Taints, func(t corev1.Taint, _ int) bool { return t.Effect == corev1.TaintEffectPreferNoSchedule })
+			return scheduling.Taints(taints).Tolerates(po) == nil &&
+				nct.Requirements.IsCompatible(scheduling.NewStrictPodRequirements(po), scheduling.AllowUndefinedWellKnownLabels)
+		})
+	}), nil
+}
+
 // consolidationWarnings potentially writes logs warning about possible unexpected interactions
 // between scheduling constraints and consolidation
 func (p *Provisioner) consolidationWarnings(ctx context.Context, pods []*corev1.Pod) {
@@ -211,8 +296,48 @@ func (p *Provisioner) consolidationWarnings(ctx context.Context, pods []*corev1.
 
 var ErrNodePoolsNotFound = errors.New("no nodepools found")
 
-//nolint:gocyclo
 func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stateNodes []*state.StateNode) (*scheduler.Scheduler, error) {
+	nodePools, err := p.schedulableNodePools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instanceTypes, domains := p.resolveInstanceTypes(ctx, nodePools)
+
+	// inject topology constraints
+	pods = p.injectVolumeTopologyRequirements(ctx, pods)
+
+	// Calculate cluster topology
+	topology, err := scheduler.NewTopology(ctx, p.kubeClient, p.cluster, domains, pods)
+	if err != nil {
+		return nil, fmt.Errorf("tracking topology counts, %w", err)
+	}
+	daemonSetPods, err := p.getDaemonSetPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting daemon pods, %w", err)
+	}
+	daemonSetPods = p.chaos.daemonSetPods(daemonSetPods)
+	return scheduler.NewScheduler(ctx, p.kubeClient, nodePools, p.cluster, stateNodes, topology, instanceTypes, p.resolveCapacityPools(ctx, nodePools), daemonSetPods, p.recorder, p.clock), nil
+}
+
+// resolveCapacityPools returns the capacity pools that the nodes of each NodePool consume. NodePools whose capacity
+// pools can't be resolved are scheduled as if they had none, so that launches can still be attempted.
+func (p *Provisioner) resolveCapacityPools(ctx context.Context, nodePools []*v1.NodePool) map[string][]*cloudprovider.CapacityPool {
+	capacityPools := map[string][]*cloudprovider.CapacityPool{}
+	for _, np := range nodePools {
+		pools, err := p.cloudProvider.GetCapacityPools(ctx, np)
+		if err != nil {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Error(err, "ignoring capacity pools, unable to resolve capacity pools")
+			continue
+		}
+		if len(pools) > 0 {
+			capacityPools[np.Name] = pools
+		}
+	}
+	return capacityPools
+}
+
+// schedulableNodePools returns the ready NodePools that pods can be scheduled against, ordered by weight
+func (p *Provisioner) schedulableNodePools(ctx context.Context) ([]*v1.NodePool, error) {
 	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
 	if err != nil {
 		return nil, fmt.Errorf("listing nodepools, %w", err)
@@ -232,7 +357,82 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 	// since they are stored within a slice and scheduling
 	// will always attempt to schedule on the first nodeTemplate
 	nodepoolutils.OrderByWeight(nodePools)
+	return nodePools, nil
+}
 
+// recordNotReadyNodePools publishes an event to each of the pods that couldn't be scheduled that identifies the NodePools
+// that the pods weren't scheduled against because they aren't ready, so that a missing or invalid NodeClass is
+// surfaced on the pods rather than only on the NodePool's status conditions
+func (p *Provisioner) recordNotReadyNodePools(ctx context.Context, pods ...*corev1.Pod) {
+	if len(pods) == 0 {
+		return
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed listing nodepools")
+		return
+	}
+	notReady := lo.Filter(nodePools, func(np *v1.NodePool, _ int) bool {
+		return np.DeletionTimestamp.IsZero() && !np.StatusConditions().IsTrue(status.ConditionReady)
+	})
+	if len(notReady) == 0 {
+		return
+	}
+	for _, pod := range pods {
+		p.recorder.Publish(scheduler.PodNodePoolsNotReadyEvent(pod, notReady))
+	}
+}
+
+// recordRound publishes a summary of the provisioning round to each of the NodePools that it scheduled pods against
+func (p *Provisioner) recordRound(ctx context.Context, results scheduler.Results) {
+	if results.Considered() == 0 {
+		return
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed listing nodepools")
+		return
+	}
+	for _, np := range nodePools {
+		if np.DeletionTimestamp.IsZero() && np.StatusConditions().IsTrue(status.ConditionReady) {
+			p.recorder.Publish(scheduler.ProvisioningRoundEvent(np, results))
+		}
+	}
+}
+
+// recordDegradedFallback publishes an event to each degraded NodePool that was passed over for healthy NodePools with a
+// lower weight, so that it's visible when pods are launched onto fallback capacity
+func (p *Provisioner) recordDegradedFallback(ctx context.Context, results scheduler.Results) {
+	if len(results.NewNodeClaims) == 0 {
+		return
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed listing nodepools")
+		return
+	}
+	byName := lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, *v1.NodePool) { return np.Name, np })
+	for _, degraded := range nodePools {
+		if !nodepoolutils.IsDegraded(degraded) || !degraded.DeletionTimestamp.IsZero() || !degraded.StatusConditions().IsTrue(status.ConditionReady) {
+			continue
+		}
+		fallbacks := lo.Uniq(lo.FilterMap(results.NewNodeClaims, func(n *scheduler.NodeClaim, _ int) (string, bool) {
+			np, ok := byName[n.NodePoolName]
+			return n.NodePoolName, ok && !nodepoolutils.IsDegraded(np) && nodepoolutils.HasPriority(degraded, np)
+		}))
+		if len(fallbacks) == 0 {
+			continue
+		}
+		sort.Strings(fallbacks)
+		p.recorder.Publish(scheduler.NodePoolDegradedFallbackEvent(degraded, fallbacks))
+	}
+}
+
+// resolveInstanceTypes returns the instance types of each NodePool along with the universe of topology domains that
+// the NodePools can launch nodes into
+//
+//nolint:gocyclo
+func (p *Provisioner) resolveInstanceTypes(ctx context.Context, nodePools []*v1.NodePool) (map[string][]*cloudprovider.InstanceType, map[string]sets.Set[string]) {
 	instanceTypes := map[string][]*cloudprovider.InstanceType{}
 	domains := map[string]sets.Set[string]{}
 	for _, np := range nodePools {
@@ -246,6 +446,12 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 			continue
 		}
 
+		// Instance types may support a different number of pods depending on the IP family that pods are assigned
+		// addresses from
+		ipFamily := nodepoolutils.IPFamily(ctx, np)
+		its = lo.Map(its, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+			return it.ForIPFamily(ipFamily)
+		})
 		instanceTypes[np.Name] = its
 
 		// Construct Topology Domains
@@ -282,25 +488,21 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 			}
 		}
 	}
-
-	// inject topology constraints
-	pods = p.injectVolumeTopologyRequirements(ctx, pods)
-
-	// Calculate cluster topology
-	topology, err := scheduler.NewTopology(ctx, p.kubeClient, p.cluster, domains, pods)
-	if err != nil {
-		return nil, fmt.Errorf("tracking topology counts, %w", err)
-	}
-	daemonSetPods, err := p.getDaemonSetPods(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("getting daemon pods, %w", err)
-	}
-	return scheduler.NewScheduler(ctx, p.kubeClient, nodePools, p.cluster, stateNodes, topology, instanceTypes, daemonSetPods, p.recorder, p.clock), nil
+	return instanceTypes, domains
 }
 
-func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
+func (p *Provisioner) Schedule(ctx context.Context) (results scheduler.Results, err error) {
 	defer metrics.Measure(scheduler.DurationSeconds, map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})()
 	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, "provisioner.schedule")
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("karpenter.nodeclaims.new", len(results.NewNodeClaims)),
+			attribute.Int("karpenter.pods.unschedulable", len(results.PodErrors)),
+		)
+		tracing.RecordError(span, err)
+		span.End()
+	}()
 
 	// We collect the nodes with their used capacities before we get the list of pending pods. This ensures that
 	// the node capacities we schedule against are always >= what the actual capacity is at any given instance. This
@@ -318,6 +520,7 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	if err != nil {
 		return scheduler.Results{}, err
 	}
+	p.batcher.SetBacklog(ctx, len(pendingPods))
 
 	// Get pods from nodes that are preparing for deletion
 	// We do this after getting the pending pods so that we undershoot if pods are
@@ -328,6 +531,8 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 		return scheduler.Results{}, err
 	}
 	pods := append(pendingPods, deletingNodePods...)
+	p.chaos.shuffle(pods)
+	span.SetAttributes(attribute.Int("karpenter.pods.pending", len(pendingPods)), attribute.Int("karpenter.pods.deleting_nodes", len(deletingNodePods)))
 	// nothing to schedule, so just return success
 	if len(pods) == 0 {
 		return scheduler.Results{}, nil
@@ -336,11 +541,16 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	if err != nil {
 		if errors.Is(err, ErrNodePoolsNotFound) {
 			log.FromContext(ctx).Info("no nodepools found")
+			p.recordNotReadyNodePools(ctx, pendingPods...)
 			return scheduler.Results{}, nil
 		}
 		return scheduler.Results{}, fmt.Errorf("creating scheduler, %w", err)
 	}
-	results := s.Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	// Launches that recently failed are retried with the same pods before the rest of the pods are simulated
+	results = s.Solve(ctx, s.RetryFailedLaunches(ctx, pods)).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	if results, err = p.enforceMaxNodes(ctx, results); err != nil {
+		return scheduler.Results{}, fmt.Errorf("enforcing the maximum number of nodes, %w", err)
+	}
 	scheduler.UnschedulablePodsCount.Set(float64(len(results.PodErrors)), map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})
 	if len(results.NewNodeClaims) > 0 {
 		log.FromContext(ctx).WithValues("Pods", pretty.Slice(lo.Map(pods, func(p *corev1.Pod, _ int) string { return klog.KRef(p.Namespace, p.Name).String() }), 5), "duration", time.Since(start)).Info("found provisionable pod(s)")
@@ -348,11 +558,96 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	// Mark in memory when these pods were marked as schedulable or when we made a decision on the pods
 	p.cluster.MarkPodSchedulingDecisions(results.PodErrors, pendingPods...)
 	results.Record(ctx, p.recorder, p.cluster)
+	p.recordNotReadyNodePools(ctx, lo.Keys(results.PodErrors)...)
+	p.recordRound(ctx, results)
+	p.recordDegradedFallback(ctx, results)
+	p.advisePreemption(ctx, nodes.Active(), results.PodErrors)
+	if options.FromContext(ctx).FeatureGates.NominatedNodeName {
+		p.nominateNodeNames(ctx, results.ExistingNodes)
+	}
 	return results, nil
 }
 
-func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...option.Function[LaunchOptions]) (string, error) {
+// nominateNodeNames sets the nominatedNodeName of the pending pods that are expected to schedule to a node that has
+// already registered, so that kube-scheduler prefers that node over capacity that wasn't launched for them
+func (p *Provisioner) nominateNodeNames(ctx context.Context, existingNodes []*scheduler.ExistingNode) {
+	ctx, span := tracing.StartSpan(ctx, "provisioner.nominateNodeNames")
+	defer span.End()
+	nominated := 0
+	defer func() { span.SetAttributes(attribute.Int("karpenter.pods.nominated", nominated)) }()
+	for _, existing := range existingNodes {
+		if existing.Node == nil {
+			continue
+		}
+		for _, pod := range existing.Pods {
+			if pod.Spec.NodeName != "" || pod.Status.NominatedNodeName == existing.Node.Name {
+				continue
+			}
+			stored := pod.DeepCopy()
+			pod.Status.NominatedNodeName = existing.Node.Name
+			if err := p.kubeClient.Status().Patch(ctx, pod, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+				log.FromContext(ctx).WithValues("Pod", klog.KObj(pod), "Node", klog.KObj(existing.Node)).Error(err, "failed setting nominated node name")
+				continue
+			}
+			nominated++
+		}
+	}
+}
+
+// enforceMaxNodes refuses the new NodeClaims that would grow the number of nodes that Karpenter manages across all
+// NodePools beyond the configured maximum. The pods of the refused NodeClaims are reported as unschedulable. Disruption
+// launches its replacements separately, so it isn't affected by the maximum. The maximum spans every shard, so the
+// managed NodeClaims are counted from the API server rather than from the shard's cluster state.
+func (p *Provisioner) enforceMaxNodes(ctx context.Context, results scheduler.Results) (scheduler.Results, error) {
+	maxNodes := options.FromContext(ctx).MaxNodes
+	if maxNodes <= 0 || len(results.NewNodeClaims) == 0 {
+		return results, nil
+	}
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		return results, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	managed := len(nodeClaims)
+	allowed := lo.Clamp(maxNodes-managed, 0, len(results.NewNodeClaims))
+	if allowed == len(results.NewNodeClaims) {
+		return results, nil
+	}
+	refused := results.NewNodeClaims[allowed:]
+	results.NewNodeClaims = results.NewNodeClaims[:allowed]
+	log.FromContext(ctx).WithValues("max-nodes", maxNodes, "nodes", managed, "refused-nodeclaims", len(refused)).Error(nil, "refusing to launch nodes, reached the maximum number of nodes")
+	for _, n := range refused {
+		NodeClaimsRefusedTotal.Inc(map[string]string{
+			metrics.NodePoolLabel: n.NodePoolName,
+			metrics.ReasonLabel:   maxNodesReason,
+		})
+		nodePool := &v1.NodePool{}
+		if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: n.NodePoolName}, nodePool); client.IgnoreNotFound(err) != nil {
+			return results, fmt.Errorf("getting nodepool, %w", err)
+		} else if err == nil {
+			p.recorder.Publish(scheduler.NodePoolMaxNodesReachedEvent(nodePool, maxNodes))
+		}
+		for _, pod := range n.Pods {
+			results.PodErrors[pod] = fmt.Errorf("reached the maximum of %d nodes across all nodepools", maxNodes)
+			p.recorder.Publish(scheduler.PodMaxNodesReachedEvent(pod, maxNodes))
+		}
+	}
+	return results, nil
+}
+
+func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...option.Function[LaunchOptions]) (name string, err error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", klog.KRef("", n.NodePoolName)))
+	ctx, span := tracing.StartSpan(ctx, "provisioner.createNodeClaim", trace.WithAttributes(
+		attribute.String("karpenter.nodepool", n.NodePoolName),
+		attribute.Int("karpenter.pods", len(n.Pods)),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("karpenter.nodeclaim", name))
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	dryRun := options.FromContext(ctx).DryRun
+	// The markup is computed before the NodeClaim is converted, which restricts its requirements to its instance types
+	markup, hasMarkup := n.PriceMarkup(options.FromContext(ctx).PriceMarkupThreshold)
 	options := option.Resolve(opts...)
 	latest := &v1.NodePool{}
 	if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: n.NodePoolName}, latest); err != nil {
@@ -363,6 +658,21 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	}
 	nodeClaim := n.ToNodeClaim()
 
+	// A previous leader may have created a NodeClaim for the same pods before failing over, so we adopt it rather than
+	// launching duplicate capacity
+	adopted, err := p.inFlightNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return "", err
+	}
+	if adopted != nil {
+		log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", adopted.Name)).Info("adopted in-flight nodeclaim")
+		if options.RecordPodNomination {
+			for _, pod := range n.Pods {
+				p.recorder.Publish(scheduler.NominatePodEvent(pod, nil, adopted))
+			}
+		}
+		return adopted.Name, nil
+	}
 	if err := p.kubeClient.Create(ctx, nodeClaim); err != nil {
 		return "", err
 	}
@@ -382,7 +692,13 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	// requeue. This can race with controller-runtime's internal cache as it watches events on the cluster
 	// to then trigger cluster state updates. Triggering it manually ensures that Karpenter waits for the
 	// internal cache to sync before moving onto another disruption loop.
-	p.cluster.UpdateNodeClaim(nodeClaim)
+	// In dry-run mode the NodeClaim is never persisted, so we don't track it to avoid treating it as in-flight capacity.
+	if !dryRun {
+		p.cluster.UpdateNodeClaim(nodeClaim)
+	}
+	if hasMarkup {
+		p.recordPriceMarkup(nodeClaim, markup)
+	}
 	if option.Resolve(opts...).RecordPodNomination {
 		for _, pod := range n.Pods {
 			p.recorder.Publish(scheduler.NominatePodEvent(pod, nil, nodeClaim))
@@ -391,6 +707,41 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	return nodeClaim.Name, nil
 }
 
+// inFlightNodeClaim returns the NodeClaim of the NodePool that isn't being deleted and has the same idempotency key as the
+// given NodeClaim, if one exists
+func (p *Provisioner) inFlightNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
+	key, ok := nodeClaim.Annotations[v1.NodeClaimIdempotencyKeyAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	nodeClaims := &v1.NodeClaimList{}
+	if err := p.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{v1.NodePoolLabelKey: nodeClaim.Labels[v1.NodePoolLabelKey]}); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	for i := range nodeClaims.Items {
+		if nodeClaims.Items[i].Annotations[v1.NodeClaimIdempotencyKeyAnnotationKey] == key && nodeClaims.Items[i].DeletionTimestamp.IsZero() {
+			return &nodeClaims.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// recordPriceMarkup publishes an event that explains the constraints that caused the NodeClaim's price markup, and
+// counts the markup against each of the constraints
+func (p *Provisioner) recordPriceMarkup(nodeClaim *v1.NodeClaim, markup scheduler.PriceMarkup) {
+	p.recorder.Publish(scheduler.NodeClaimPriceMarkupEvent(nodeClaim, markup))
+	constraints := lo.Map(markup.Constraints, func(c scheduler.PriceMarkupConstraint, _ int) string { return c.Name })
+	if len(constraints) == 0 {
+		constraints = []string{"combination"}
+	}
+	for _, constraint := range constraints {
+		scheduler.PriceMarkupsTotal.Inc(map[string]string{
+			metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+			scheduler.ConstraintLabel: constraint,
+		})
+	}
+}
+
 func instanceTypeList(names []string) string {
 	var itSb strings.Builder
 	for i, name := range names {
@@ -414,8 +765,10 @@ func (p *Provisioner) getDaemonSetPods(ctx context.Context) ([]*corev1.Pod, erro
 
 	return lo.Map(daemonSetList.Items, func(d appsv1.DaemonSet, _ int) *corev1.Pod {
 		pod := p.cluster.GetDaemonSetPod(&d)
+		// The pod is keyed by its daemonset so that the host ports of the pods that are made from templates don't
+		// overwrite each other
 		if pod == nil {
-			pod = &corev1.Pod{Spec: d.Spec.Template.Spec}
+			pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: d.Namespace, Name: d.Name}, Spec: d.Spec.Template.Spec}
 		}
 		// Replacing retrieved pod affinity with daemonset pod template required node affinity since this is overridden
 		// by the daemonset controller during pod creation
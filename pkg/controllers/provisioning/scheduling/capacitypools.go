@@ -0,0 +1,100 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// capacityPools tracks the remaining capacity of the CloudProvider's capacity pools as nodes and pods are simulated
+// during scheduling. The capacity that a node launched by a NodeClaim consumes is reserved in every zone that the
+// NodeClaim may launch into, since its zone isn't known until it's launched.
+type capacityPools struct {
+	pools      map[string]map[string]*cloudprovider.CapacityPool // (NodePool name) -> (zone) -> pool
+	remaining  map[string]int64                                  // (pool name) -> remaining capacity
+	daemonPods map[string]int64                                  // (NodePool name) -> daemon pods that consume from pools
+}
+
+func newCapacityPools(pools map[string][]*cloudprovider.CapacityPool, daemonPods map[string]int64) *capacityPools {
+	c := &capacityPools{
+		pools:      map[string]map[string]*cloudprovider.CapacityPool{},
+		remaining:  map[string]int64{},
+		daemonPods: daemonPods,
+	}
+	for nodePoolName, nodePoolPools := range pools {
+		c.pools[nodePoolName] = lo.SliceToMap(nodePoolPools, func(p *cloudprovider.CapacityPool) (string, *cloudprovider.CapacityPool) { return p.Zone, p })
+		for _, p := range nodePoolPools {
+			c.remaining[p.Name] = p.Remaining
+		}
+	}
+	return c
+}
+
+// exhaustedZones returns the zones that the NodePool can launch into, given the zone requirement, whose pool doesn't
+// have enough capacity remaining for the nodes and pods
+func (c *capacityPools) exhaustedZones(nodePoolName string, zones *scheduling.Requirement, nodes, pods int64) []string {
+	var exhausted []string
+	for zone, pool := range c.pools[nodePoolName] {
+		if zones.Has(zone) && c.remaining[pool.Name] < nodes*pool.PerNode+pods*pool.PerPod {
+			exhausted = append(exhausted, zone)
+		}
+	}
+	sort.Strings(exhausted)
+	return exhausted
+}
+
+// consume reserves the capacity of the nodes and pods from the pools of every zone that they may launch into
+func (c *capacityPools) consume(nodePoolName string, zones *scheduling.Requirement, nodes, pods int64) {
+	for zone, pool := range c.pools[nodePoolName] {
+		if zones.Has(zone) {
+			c.remaining[pool.Name] -= nodes*pool.PerNode + pods*pool.PerPod
+		}
+	}
+}
+
+// daemonPodsFor returns the number of daemon pods that consume from the pools for every node launched by the NodePool
+func (c *capacityPools) daemonPodsFor(nodePoolName string) int64 {
+	return c.daemonPods[nodePoolName]
+}
+
+// getDaemonCapacityPoolPods determines the number of daemon pods that consume from the capacity pools of every node
+// provisioned by each NodePool
+func getDaemonCapacityPoolPods(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*corev1.Pod) map[string]int64 {
+	return lo.SliceToMap(nodeClaimTemplates, func(nct *NodeClaimTemplate) (string, int64) {
+		return nct.NodePoolName, int64(lo.CountBy(daemonSetPods, func(p *corev1.Pod) bool { return consumesCapacityPools(p) && isDaemonPodCompatible(nct, p) }))
+	})
+}
+
+// consumesCapacityPools returns whether the pod consumes capacity from the pools of the node that it schedules to, pods
+// that use the host network share the node's capacity
+func consumesCapacityPools(pod *corev1.Pod) bool {
+	return !pod.Spec.HostNetwork
+}
+
+// zoneRequirement returns the requirement of the zone that the node is in
+func zoneRequirement(labels map[string]string) *scheduling.Requirement {
+	if zone, ok := labels[corev1.LabelTopologyZone]; ok {
+		return scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone)
+	}
+	return scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpDoesNotExist)
+}
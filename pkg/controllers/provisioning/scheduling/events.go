@@ -21,11 +21,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
 // PodNominationRateLimiter is a pointer so it rate-limits across events
@@ -49,6 +55,27 @@ func NominatePodEvent(pod *corev1.Pod, node *corev1.Node, nodeClaim *v1.NodeClai
 	}
 }
 
+// ProvisioningRoundEvent summarizes a provisioning round on a NodePool that it scheduled pods against, so that the
+// history of recent rounds is visible when describing the NodePool
+func ProvisioningRoundEvent(np *v1.NodePool, results Results) events.Event {
+	return events.Event{
+		InvolvedObject: np,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ProvisioningRound",
+		Message:        results.Summary(),
+	}
+}
+
+func NodePoolDegradedFallbackEvent(np *v1.NodePool, fallbacks []string) events.Event {
+	return events.Event{
+		InvolvedObject: np,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "DegradedFallback",
+		Message:        fmt.Sprintf("NodePool is degraded, launched nodeclaims from lower weight nodepool(s) %s", strings.Join(fallbacks, ", ")),
+		DedupeValues:   []string{string(np.UID), strings.Join(fallbacks, ",")},
+	}
+}
+
 func NoCompatibleInstanceTypes(np *v1.NodePool) events.Event {
 	return events.Event{
 		InvolvedObject: np,
@@ -60,13 +87,136 @@ func NoCompatibleInstanceTypes(np *v1.NodePool) events.Event {
 	}
 }
 
+func CapacityTypeFallback(np *v1.NodePool) events.Event {
+	return events.Event{
+		InvolvedObject: np,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "CapacityTypeFallback",
+		Message:        "Spot capacity is unavailable for all compatible instance types, falling back to on-demand",
+		DedupeValues:   []string{string(np.UID)},
+		DedupeTimeout:  1 * time.Minute,
+	}
+}
+
+// NodePoolMaxNodesReachedEvent reports that a NodeClaim of the NodePool wasn't launched because Karpenter already
+// manages the maximum number of nodes across all NodePools
+func NodePoolMaxNodesReachedEvent(np *v1.NodePool, maxNodes int) events.Event {
+	return events.Event{
+		InvolvedObject: np,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "MaxNodesReached",
+		Message:        fmt.Sprintf("Refused to launch nodeclaim, reached the maximum of %d nodes across all nodepools", maxNodes),
+		DedupeValues:   []string{string(np.UID)},
+		DedupeTimeout:  1 * time.Minute,
+	}
+}
+
+// PodMaxNodesReachedEvent reports that the NodeClaim that a pod would have scheduled to wasn't launched because Karpenter
+// already manages the maximum number of nodes across all NodePools
+func PodMaxNodesReachedEvent(pod *corev1.Pod, maxNodes int) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "MaxNodesReached",
+		Message:        fmt.Sprintf("Refused to launch a nodeclaim for pod, reached the maximum of %d nodes across all nodepools", maxNodes),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+// NodeClaimPriceMarkupEvent explains the constraints that caused the cheapest offering that a NodeClaim can launch
+// with to be pricier than the cheapest offering that fits its pods
+func NodeClaimPriceMarkupEvent(nodeClaim *v1.NodeClaim, markup PriceMarkup) events.Event {
+	cause := "a combination of its zone and capacity type requirements and the availability of offerings"
+	if len(markup.Constraints) > 0 {
+		cause = strings.Join(lo.Map(markup.Constraints, func(c PriceMarkupConstraint, _ int) string {
+			return fmt.Sprintf("%s (%.4f without it)", c.Name, c.Price)
+		}), ", ")
+	}
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "PriceMarkup",
+		Message: fmt.Sprintf("Cheapest compatible offering costs %.4f, %.0f%% more than %.4f for instance type %q, because of %s",
+			markup.Price, markup.Percentage(), markup.CheapestPrice, markup.CheapestInstanceType, cause),
+		DedupeValues: []string{string(nodeClaim.UID)},
+	}
+}
+
+// PodFailedToScheduleEvent reports why a pod failed to schedule. The keys of the pod's requirements that are incompatible
+// with the NodePools and nodes are summarized ahead of the full error.
 func PodFailedToScheduleEvent(pod *corev1.Pod, err error) events.Event {
+	message := fmt.Sprintf("Failed to schedule pod, %s", err)
+	if incompatibilities := scheduling.Incompatibilities(err); len(incompatibilities) > 0 {
+		keys := lo.Uniq(lo.Map(incompatibilities, func(i scheduling.Incompatibility, _ int) string { return fmt.Sprintf("%s (%s)", i.Key, i.Reason) }))
+		message = fmt.Sprintf("Failed to schedule pod, incompatible requirements %s, %s", strings.Join(keys, ", "), err)
+	}
 	return events.Event{
 		InvolvedObject: pod,
 		Type:           corev1.EventTypeWarning,
 		Reason:         "FailedScheduling",
-		Message:        fmt.Sprintf("Failed to schedule pod, %s", err),
+		Message:        message,
 		DedupeValues:   []string{string(pod.UID)},
 		DedupeTimeout:  5 * time.Minute,
 	}
 }
+
+func PodHostPortConflictEvent(pod *corev1.Pod, err error) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "HostPortConflict",
+		Message:        fmt.Sprintf("Pod host ports conflict with daemonset host ports on every nodepool, %s", err),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+// PodStandaloneIgnoredEvent reports that capacity isn't launched for a pod without an owner because of the
+// standalone pod policy
+func PodStandaloneIgnoredEvent(pod *corev1.Pod) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "StandalonePodIgnored",
+		Message:        "Not launching capacity for pod without an owner, standalone pods are ignored by STANDALONE_POD_POLICY=Ignore outside of STANDALONE_POD_NAMESPACES",
+		DedupeValues:   []string{string(pod.UID)},
+	}
+}
+
+// PodPreemptionRecommendedEvent recommends preempting lower priority pods from a node so that the node's capacity is
+// freed from its NodePool's limits for a pod that couldn't schedule because of them
+func PodPreemptionRecommendedEvent(pod *corev1.Pod, node *corev1.Node, victims []*corev1.Pod) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "PreemptionRecommended",
+		Message: fmt.Sprintf("NodePool limits prevent launching a node, preempting %d lower priority pod(s) from node %q would free capacity within them, pods=%s",
+			len(victims), node.Name, pretty.Slice(lo.Map(victims, func(p *corev1.Pod, _ int) string { return klog.KObj(p).String() }), 5)),
+		DedupeValues:  []string{string(pod.UID), node.Name},
+		DedupeTimeout: 5 * time.Minute,
+	}
+}
+
+// PodNodePoolsNotReadyEvent reports the NodePools that weren't considered for a pod because they aren't ready, e.g.
+// because their NodeClass is missing or invalid
+func PodNodePoolsNotReadyEvent(pod *corev1.Pod, nodePools []*v1.NodePool) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "NodePoolsNotReady",
+		Message:        fmt.Sprintf("Ignored NodePool(s) that aren't ready, %s", strings.Join(lo.Map(nodePools, func(np *v1.NodePool, _ int) string { return notReadyReason(np) }), "; ")),
+		DedupeValues:   []string{string(pod.UID)},
+	}
+}
+
+// notReadyReason describes the first of the NodePool's conditions that keeps it from being ready
+func notReadyReason(np *v1.NodePool) string {
+	condition, ok := lo.Find(np.Status.Conditions, func(c status.Condition) bool {
+		return c.Type != status.ConditionReady && c.Status != metav1.ConditionTrue
+	})
+	if !ok {
+		return fmt.Sprintf("nodepool %q is not ready", np.Name)
+	}
+	return fmt.Sprintf("nodepool %q has %s=%s, %s", np.Name, condition.Type, condition.Status, lo.Ternary(condition.Message != "", condition.Message, condition.Reason))
+}
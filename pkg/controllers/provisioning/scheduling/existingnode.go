@@ -23,6 +23,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	apisv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
@@ -33,13 +34,16 @@ type ExistingNode struct {
 	cachedAvailable v1.ResourceList // Cache so we don't have to re-subtract resources on the StateNode every time
 	cachedTaints    []v1.Taint      // Cache so we don't hae to re-construct the taints each time we attempt to schedule a pod
 
-	Pods         []*v1.Pod
-	topology     *Topology
-	requests     v1.ResourceList
-	requirements scheduling.Requirements
+	Pods            []*v1.Pod
+	topology        *Topology
+	requests        v1.ResourceList
+	podRequests     []v1.ResourceList // the requests of each pod on the node, which are packed onto the devices of shared resources and the slices of the node
+	sharedResources v1.ResourceList
+	slices          []v1.ResourceList // the capacity of each of the slices that the node is partitioned into
+	requirements    scheduling.Requirements
 }
 
-func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint, daemonResources v1.ResourceList) *ExistingNode {
+func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint, daemonResources v1.ResourceList, sharedResources v1.ResourceList, slices []v1.ResourceList) *ExistingNode {
 	// The state node passed in here must be a deep copy from cluster state as we modify it
 	// the remaining daemonResources to schedule are the total daemonResources minus what has already scheduled
 	remainingDaemonResources := resources.Subtract(daemonResources, n.DaemonSetRequests())
@@ -58,8 +62,13 @@ func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint,
 		cachedTaints:    taints,
 		topology:        topology,
 		requests:        remainingDaemonResources,
+		sharedResources: sharedResources,
+		slices:          slices,
 		requirements:    scheduling.NewLabelRequirements(n.Labels()),
 	}
+	if len(sharedResources) > 0 || len(slices) > 0 {
+		node.podRequests = n.PodRequestsByPod()
+	}
 	node.requirements.Add(scheduling.NewRequirement(v1.LabelHostname, v1.NodeSelectorOpIn, n.HostName()))
 	topology.Register(v1.LabelHostname, n.HostName())
 	return node
@@ -91,9 +100,17 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	if !resources.Fits(requests, n.cachedAvailable) {
 		return fmt.Errorf("exceeds node resources")
 	}
+	podRequestsList, ok := n.fitsDevices(podRequests)
+	if !ok {
+		return fmt.Errorf("exceeds the shared resources of a single device")
+	}
+	if !resources.FitsSlices(podRequestsList, n.slices) {
+		return fmt.Errorf("exceeds the resources of a single slice")
+	}
 
 	nodeRequirements := scheduling.NewRequirements(n.requirements.Values()...)
 	podRequirements := scheduling.NewPodRequirements(pod)
+	nodeRequirements.Add(lackedCapabilities(nodeRequirements, podRequirements)...)
 	// Check NodeClaim Affinity Requirements
 	if err = nodeRequirements.Compatible(podRequirements); err != nil {
 		return err
@@ -120,9 +137,32 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	// Update node
 	n.Pods = append(n.Pods, pod)
 	n.requests = requests
+	n.podRequests = podRequestsList
 	n.requirements = nodeRequirements
 	n.topology.Record(pod, nodeRequirements)
 	n.HostPortUsage().Add(pod, hostPorts)
 	n.VolumeUsage().Add(pod, volumes)
 	return nil
 }
+
+// fitsDevices returns the requests of each pod on the node including the pod, and whether they can be packed onto the
+// devices that provide the node's shared resources
+func (n *ExistingNode) fitsDevices(podRequests v1.ResourceList) ([]v1.ResourceList, bool) {
+	if len(n.sharedResources) == 0 && len(n.slices) == 0 {
+		return n.podRequests, true
+	}
+	podRequestsList := append(n.podRequests, podRequests)
+	return podRequestsList, resources.FitsDevices(podRequestsList, n.sharedResources, n.Capacity())
+}
+
+// lackedCapabilities returns "false" requirements for the capabilities that the pod requires but the node isn't
+// labeled with, since nodes are only labeled with the capabilities of their instance type
+func lackedCapabilities(nodeRequirements, podRequirements scheduling.Requirements) []*scheduling.Requirement {
+	var lacked []*scheduling.Requirement
+	for key := range podRequirements.Keys() {
+		if apisv1.IsCapabilityLabel(key) && !nodeRequirements.Has(key) {
+			lacked = append(lacked, scheduling.NewRequirement(key, v1.NodeSelectorOpIn, apisv1.CapabilityFalse))
+		}
+	}
+	return lacked
+}
@@ -0,0 +1,86 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// hostnameSpread is a hostname topology of a pod that limits how many of the pods that it selects can share a node.
+// Large StatefulSets and Deployments are commonly spread across hostnames, which requires a new NodeClaim for every
+// few of their pods. Since a new NodeClaim is its own hostname domain, the number of pods that it can hold is known up
+// front, so the scheduler can skip the NodeClaims that are full without simulating the topology for each of them.
+// The number of NodeClaims isn't planned from the size of the group, since each pod is still subject to its resources
+// and to its other constraints, which only the simulation accounts for. The NodeClaims that a round computes are
+// created in parallel by the provisioner.
+type hostnameSpread struct {
+	namespaces sets.Set[string]
+	selector   labels.Selector
+	// maxPods is the number of selected pods that a new NodeClaim can hold before the pod no longer fits
+	maxPods int
+}
+
+// hostnameSpreads returns the hostname topologies that the pod is currently constrained by. Preferences that haven't
+// been relaxed yet are included since the topology enforces them until they are. Anti-affinities with a namespace
+// selector are left to the topology, since resolving the namespaces requires listing them.
+func hostnameSpreads(pod *corev1.Pod) []hostnameSpread {
+	var spreads []hostnameSpread
+	for _, cs := range pod.Spec.TopologySpreadConstraints {
+		if cs.TopologyKey != corev1.LabelHostname {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cs.LabelSelector)
+		// hostname domains always have a min count of zero, so a domain is full once adding the pod to it would
+		// exceed the max skew, which only happens for pods that select themselves
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		spreads = append(spreads, hostnameSpread{namespaces: sets.New(pod.Namespace), selector: selector, maxPods: int(cs.MaxSkew) - 1})
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+		return spreads
+	}
+	terms := append(lo.Map(pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, func(t corev1.WeightedPodAffinityTerm, _ int) corev1.PodAffinityTerm {
+		return t.PodAffinityTerm
+	}), pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+	for _, term := range terms {
+		if term.TopologyKey != corev1.LabelHostname || term.NamespaceSelector != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+		if err != nil {
+			continue
+		}
+		namespaces := sets.New(term.Namespaces...)
+		if namespaces.Len() == 0 {
+			namespaces.Insert(pod.Namespace)
+		}
+		spreads = append(spreads, hostnameSpread{namespaces: namespaces, selector: selector, maxPods: 0})
+	}
+	return spreads
+}
+
+// full returns true if the NodeClaim holds as many of the selected pods as it can
+func (h hostnameSpread) full(nodeClaim *NodeClaim) bool {
+	return lo.CountBy(nodeClaim.Pods, func(p *corev1.Pod) bool {
+		return h.namespaces.Has(p.Namespace) && h.selector.Matches(labels.Set(p.Labels))
+	}) > h.maxPods
+}
@@ -26,6 +26,9 @@ import (
 
 const (
 	ControllerLabel    = "controller"
+	ConstraintLabel    = "constraint"
+	KeyLabel           = "key"
+	CacheLabel         = "cache"
 	schedulingIDLabel  = "scheduling_id"
 	schedulerSubsystem = "scheduler"
 )
@@ -79,6 +82,15 @@ var (
 		},
 		[]string{},
 	)
+	SchedulingGatedPodCount = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Name:      "scheduling_gated_pod_count",
+			Help:      "Number of pending pods that Karpenter isn't provisioning capacity for because they have scheduling gates",
+		},
+		[]string{},
+	)
 	UnschedulablePodsCount = opmetrics.NewPrometheusGauge(
 		crmetrics.Registry,
 		prometheus.GaugeOpts{
@@ -91,4 +103,43 @@ var (
 			ControllerLabel,
 		},
 	)
+	PriceMarkupsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "price_markups_total",
+			Help:      "Number of NodeClaims whose cheapest offering exceeded the price markup threshold over the cheapest offering that fits their pods, labeled by the constraint that caused the markup. The constraint is 'combination' if no single constraint caused it.",
+		},
+		[]string{
+			metrics.NodePoolLabel,
+			ConstraintLabel,
+		},
+	)
+	IncompatibleRequirementsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "incompatible_requirements_total",
+			Help:      "Number of pods that failed to schedule with a requirement that's incompatible with the requirements of a NodePool or node, labeled by the key of the requirement and the reason of the incompatibility.",
+		},
+		[]string{
+			KeyLabel,
+			metrics.ReasonLabel,
+		},
+	)
+	VolumeTopologyResolutionDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "volume_topology_resolution_duration_seconds",
+			Help:      "Duration of resolving the node requirements of a pod's persistent volume claim in seconds, labeled by whether the requirements were cached in cluster state.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{
+			CacheLabel,
+		},
+	)
 )
@@ -18,15 +18,20 @@ package scheduling
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync/atomic"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	apisv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
@@ -36,15 +41,21 @@ type NodeClaim struct {
 	NodeClaimTemplate
 
 	Pods            []*v1.Pod
+	podRequests     []v1.ResourceList // the requests of each pod, which are packed onto the devices of shared resources and the slices of the instance type
 	topology        *Topology
 	hostPortUsage   *scheduling.HostPortUsage
 	daemonResources v1.ResourceList
 	hostname        string
+	// remainingZoneResources are the remaining resources of zones with zone limits before the NodeClaim was created
+	remainingZoneResources map[string]v1.ResourceList
+	capacityPools          *capacityPools
+	// nodeSlicing packs the pods onto the slices of the instance type options
+	nodeSlicing bool
 }
 
 var nodeID int64
 
-func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daemonResources v1.ResourceList, instanceTypes []*cloudprovider.InstanceType) *NodeClaim {
+func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daemonResources v1.ResourceList, daemonHostPortUsage *scheduling.HostPortUsage, instanceTypes []*cloudprovider.InstanceType, remainingZoneResources map[string]v1.ResourceList, capacityPools *capacityPools, nodeSlicing bool) *NodeClaim {
 	// Copy the template, and add hostname
 	hostname := fmt.Sprintf("hostname-placeholder-%04d", atomic.AddInt64(&nodeID, 1))
 	topology.Register(v1.LabelHostname, hostname)
@@ -57,11 +68,28 @@ func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daem
 
 	return &NodeClaim{
 		NodeClaimTemplate: template,
-		hostPortUsage:     scheduling.NewHostPortUsage(),
+		hostPortUsage:     daemonHostPortUsage.DeepCopy(),
 		topology:          topology,
 		daemonResources:   daemonResources,
 		hostname:          hostname,
+
+		remainingZoneResources: remainingZoneResources,
+		capacityPools:          capacityPools,
+		nodeSlicing:            nodeSlicing,
+	}
+}
+
+// HasReplicaOf returns whether a pod with the same controller as the pod, e.g. a replica of the same ReplicaSet, has been
+// added to the NodeClaim
+func (n *NodeClaim) HasReplicaOf(pod *v1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
 	}
+	return lo.ContainsBy(n.Pods, func(p *v1.Pod) bool {
+		o := metav1.GetControllerOf(p)
+		return o != nil && o.UID == owner.UID
+	})
 }
 
 func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
@@ -103,24 +131,109 @@ func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
 	// Check instance type combinations
 	requests := resources.Merge(n.Spec.Resources.Requests, podRequests)
 
-	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests)
+	// The requests of each pod are only needed to pack them onto the devices of shared resources and onto slices
+	var podRequestsList []v1.ResourceList
+	if lo.SomeBy(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType) bool {
+		return len(it.SharedResources) > 0 || (n.nodeSlicing && len(it.Slices) > 0)
+	}) {
+		podRequestsList = append(n.podRequests, podRequests)
+	}
+	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests, podRequestsList, n.nodeSlicing)
 
 	if len(filtered.remaining) == 0 {
 		// log the total resources being requested (daemonset + the pod)
 		cumulativeResources := resources.Merge(n.daemonResources, podRequests)
 		return fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(cumulativeResources), nodeClaimRequirements, filtered.FailureReason())
 	}
+	remaining, err := n.fitZoneLimits(nodeClaimRequirements, filtered.remaining)
+	if err != nil {
+		return err
+	}
+	nodes, pods := n.capacityPoolUsage(pod)
+	if remaining, err = n.fitCapacityPools(nodeClaimRequirements, remaining, nodes, pods); err != nil {
+		return err
+	}
+	if remaining, err = n.fitPacking(requests, remaining); err != nil {
+		return err
+	}
 
 	// Update node
 	n.Pods = append(n.Pods, pod)
-	n.InstanceTypeOptions = filtered.remaining
+	n.podRequests = podRequestsList
+	n.InstanceTypeOptions = remaining
 	n.Spec.Resources.Requests = requests
 	n.Requirements = nodeClaimRequirements
 	n.topology.Record(pod, nodeClaimRequirements, scheduling.AllowUndefinedWellKnownLabels)
 	n.hostPortUsage.Add(pod, hostPorts)
+	n.capacityPools.consume(n.NodePoolName, nodeClaimRequirements.Get(v1.LabelTopologyZone), nodes, pods)
 	return nil
 }
 
+// fitZoneLimits ensures that the NodeClaim doesn't launch into a zone whose zone limits would be exceeded by its
+// instance types. Zones that some of the instance types don't fit into are excluded from the requirements. If that
+// excludes every zone, the instance types are instead narrowed to the ones that fit into all of the zones.
+func (n *NodeClaim) fitZoneLimits(requirements scheduling.Requirements, instanceTypes cloudprovider.InstanceTypes) (cloudprovider.InstanceTypes, error) {
+	zones := requirements.Get(v1.LabelTopologyZone)
+	var exceeded []string
+	for zone, remaining := range n.remainingZoneResources {
+		if zones.Has(zone) && len(filterByRemainingResources(instanceTypes, remaining)) != len(instanceTypes) {
+			exceeded = append(exceeded, zone)
+		}
+	}
+	if len(exceeded) == 0 {
+		return instanceTypes, nil
+	}
+	sort.Strings(exceeded)
+	if excluded := scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpNotIn, exceeded...); zones.Intersection(excluded).Len() > 0 {
+		requirements.Add(excluded)
+		instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.Offerings.Available().HasCompatible(requirements)
+		})
+	} else {
+		for _, zone := range exceeded {
+			instanceTypes = filterByRemainingResources(instanceTypes, n.remainingZoneResources[zone])
+		}
+	}
+	if len(instanceTypes) == 0 {
+		return nil, fmt.Errorf("all available instance types exceed zone limits for zones %s", strings.Join(exceeded, ", "))
+	}
+	return instanceTypes, nil
+}
+
+// capacityPoolUsage returns the number of nodes and pods that adding the pod consumes from capacity pools. The first
+// pod added to the NodeClaim also consumes the capacity of the node and its daemon pods.
+func (n *NodeClaim) capacityPoolUsage(pod *v1.Pod) (nodes int64, pods int64) {
+	if consumesCapacityPools(pod) {
+		pods++
+	}
+	if len(n.Pods) == 0 {
+		nodes++
+		pods += n.capacityPools.daemonPodsFor(n.NodePoolName)
+	}
+	return nodes, pods
+}
+
+// fitCapacityPools excludes the zones whose capacity pools would be exhausted by the nodes and pods from the requirements
+func (n *NodeClaim) fitCapacityPools(requirements scheduling.Requirements, instanceTypes cloudprovider.InstanceTypes, nodes, pods int64) (cloudprovider.InstanceTypes, error) {
+	zones := requirements.Get(v1.LabelTopologyZone)
+	exhausted := n.capacityPools.exhaustedZones(n.NodePoolName, zones, nodes, pods)
+	if len(exhausted) == 0 {
+		return instanceTypes, nil
+	}
+	excluded := scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpNotIn, exhausted...)
+	if zones.Intersection(excluded).Len() == 0 {
+		return nil, fmt.Errorf("capacity pools are exhausted in zones %s", strings.Join(exhausted, ", "))
+	}
+	requirements.Add(excluded)
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Offerings.Available().HasCompatible(requirements)
+	})
+	if len(instanceTypes) == 0 {
+		return nil, fmt.Errorf("capacity pools are exhausted in zones %s", strings.Join(exhausted, ", "))
+	}
+	return instanceTypes, nil
+}
+
 func (n *NodeClaim) Destroy() {
 	n.topology.Unregister(v1.LabelHostname, n.hostname)
 }
@@ -131,12 +244,71 @@ func (n *NodeClaim) FinalizeScheduling() {
 	// We need nodes to have hostnames for topology purposes, but we don't want to pass that node name on to consumers
 	// of the node as it will be displayed in error messages
 	delete(n.Requirements, v1.LabelHostname)
+	n.preferZones()
+}
+
+// preferZones narrows the zones that the NodeClaim can launch into to the most preferred zones that its instance types
+// are available in. Zones are added in order of their weight until the instance types satisfy the minValues of the
+// requirements. This happens once all pods have been scheduled so that zone preferences only bias the placement of
+// NodeClaims whose pods can run in several zones and never cause pods to fail to schedule.
+func (n *NodeClaim) preferZones() {
+	if len(n.ZonePreferences) == 0 {
+		return
+	}
+	available := sets.New[string]()
+	for _, it := range n.InstanceTypeOptions {
+		for _, o := range it.Offerings.Available().Compatible(n.Requirements) {
+			available.Insert(o.Requirements.Get(v1.LabelTopologyZone).Values()...)
+		}
+	}
+	// Group the available zones by weight, from the most to the least preferred
+	byWeight := lo.GroupBy(sets.List(available), func(zone string) int32 { return n.ZonePreferences[zone] })
+	weights := lo.Keys(byWeight)
+	sort.Slice(weights, func(i, j int) bool { return weights[i] > weights[j] })
+	// Preferences don't apply if only one weight is available, since every zone is equally preferred
+	if len(weights) < 2 {
+		return
+	}
+	var preferred []string
+	for _, weight := range weights[:len(weights)-1] {
+		preferred = append(preferred, byWeight[weight]...)
+		requirements := scheduling.NewRequirements(n.Requirements.Values()...)
+		requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, preferred...))
+		instanceTypes := lo.Filter(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.Offerings.Available().HasCompatible(requirements)
+		})
+		if _, err := cloudprovider.InstanceTypes(instanceTypes).SatisfiesMinValues(requirements); err == nil {
+			n.Requirements = requirements
+			n.InstanceTypeOptions = instanceTypes
+			return
+		}
+	}
+}
+
+// ToNodeClaim converts the NodeClaim into the NodeClaim that will be created, recording the pods that were nominated to it
+// so that it's possible to audit what the NodeClaim was launched for after these pods have churned. The NodeClaim is
+// also given an idempotency key that the CloudProvider can use to deduplicate launches.
+func (n *NodeClaim) ToNodeClaim() *apisv1.NodeClaim {
+	nodeClaim := n.NodeClaimTemplate.ToNodeClaim()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+		apisv1.NodeClaimIdempotencyKeyAnnotationKey: nodeclaimutils.IdempotencyKey(n.NodePoolName, n.Pods...),
+	})
+	// Marshaling a SchedulingDecision can't fail since it only contains strings and quantities
+	lo.Must0(nodeclaimutils.SetSchedulingDecision(nodeClaim, nodeclaimutils.NewSchedulingDecision(n.Pods...)))
+	return nodeClaim
 }
 
 func (n *NodeClaim) RemoveInstanceTypeOptionsByPriceAndMinValues(reqs scheduling.Requirements, maxPrice float64) (*NodeClaim, error) {
+	return n.RemoveInstanceTypeOptionsByCostAndMinValues(reqs, maxPrice, func(it *cloudprovider.InstanceType) float64 {
+		return it.Offerings.Available().WorstLaunchPrice(reqs)
+	})
+}
+
+// RemoveInstanceTypeOptionsByCostAndMinValues removes the instance type options whose cost, as computed by the given
+// function, isn't less than maxCost
+func (n *NodeClaim) RemoveInstanceTypeOptionsByCostAndMinValues(reqs scheduling.Requirements, maxCost float64, cost func(*cloudprovider.InstanceType) float64) (*NodeClaim, error) {
 	n.InstanceTypeOptions = lo.Filter(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
-		launchPrice := it.Offerings.Available().WorstLaunchPrice(reqs)
-		return launchPrice < maxPrice
+		return cost(it) < maxCost
 	})
 	if _, err := n.InstanceTypeOptions.SatisfiesMinValues(reqs); err != nil {
 		return nil, err
@@ -245,7 +417,7 @@ func (r filterResults) FailureReason() string {
 }
 
 //nolint:gocyclo
-func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests v1.ResourceList) filterResults {
+func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests v1.ResourceList, podRequests []v1.ResourceList, nodeSlicing bool) filterResults {
 	results := filterResults{
 		requests:        requests,
 		requirementsMet: false,
@@ -261,7 +433,7 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 		// the tradeoff to not short circuiting on the filtering is that we can report much better error messages
 		// about why scheduling failed
 		itCompat := compatible(it, requirements)
-		itFits := fits(it, requests)
+		itFits := fits(it, requests, podRequests, nodeSlicing)
 		itHasOffering := it.Offerings.Available().HasCompatible(requirements)
 
 		// track if any single instance type met a single criteria
@@ -293,9 +465,12 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 }
 
 func compatible(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements) bool {
-	return instanceType.Requirements.Intersects(requirements) == nil
+	return instanceType.Requirements.Intersects(requirements) == nil &&
+		instanceType.CapabilityRequirements(requirements).Intersects(requirements) == nil
 }
 
-func fits(instanceType *cloudprovider.InstanceType, requests v1.ResourceList) bool {
-	return resources.Fits(requests, instanceType.Allocatable())
+func fits(instanceType *cloudprovider.InstanceType, requests v1.ResourceList, podRequests []v1.ResourceList, nodeSlicing bool) bool {
+	return resources.Fits(requests, instanceType.Allocatable()) &&
+		resources.FitsDevices(podRequests, instanceType.SharedResources, instanceType.Capacity) &&
+		(!nodeSlicing || resources.FitsSlices(podRequests, instanceType.SliceCapacities()))
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package scheduling
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/awslabs/operatorpkg/object"
@@ -24,9 +25,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
@@ -44,15 +47,34 @@ type NodeClaimTemplate struct {
 	NodePoolUUID        types.UID
 	InstanceTypeOptions cloudprovider.InstanceTypes
 	Requirements        scheduling.Requirements
+	// ZonePreferences are the weights of the zones that the NodePool prefers to launch nodes into
+	ZonePreferences map[string]int32
+	// PriorityClassNames are the PriorityClasses of the pods that the NodePool is a capacity tier for
+	PriorityClassNames sets.Set[string]
+	// PodSelector restricts the pods that the NodePool launches capacity for by their labels
+	PodSelector *metav1.LabelSelector
+	// Packing are the targets that pods are packed onto the NodePool's new nodes up to
+	Packing v1.Packing
+
+	// instanceTypes are the NodePool's instance types before they're filtered by its requirements and the availability
+	// of their offerings
+	instanceTypes []*cloudprovider.InstanceType
 }
 
-func NewNodeClaimTemplate(nodePool *v1.NodePool) *NodeClaimTemplate {
+func NewNodeClaimTemplate(ctx context.Context, nodePool *v1.NodePool) *NodeClaimTemplate {
 	nct := &NodeClaimTemplate{
 		NodeClaim:    *nodePool.Spec.Template.ToNodeClaim(),
 		NodePoolName: nodePool.Name,
 		NodePoolUUID: nodePool.UID,
 		Requirements: scheduling.NewRequirements(),
+		ZonePreferences: lo.SliceToMap(nodePool.Spec.ZonePreferences, func(zp v1.ZonePreference) (string, int32) {
+			return zp.Zone, zp.Weight
+		}),
+		PriorityClassNames: sets.New(nodePool.Spec.PriorityClassNames...),
+		PodSelector:        nodePool.Spec.PodSelector,
+		Packing:            lo.FromPtr(nodePool.Spec.Packing),
 	}
+	applyDefaultNodeMetadata(ctx, &nct.NodeClaim)
 	nct.Annotations = lo.Assign(nct.Annotations, map[string]string{
 		v1.NodePoolHashAnnotationKey:        nodePool.Hash(),
 		v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
@@ -66,6 +88,47 @@ func NewNodeClaimTemplate(nodePool *v1.NodePool) *NodeClaimTemplate {
 	return nct
 }
 
+// applyDefaultNodeMetadata merges the default labels, annotations and taints of nodes that are configured for the
+// operator into the NodeClaim. The NodePool's metadata takes precedence, and its taints replace the default taints
+// with the same key and effect.
+func applyDefaultNodeMetadata(ctx context.Context, nodeClaim *v1.NodeClaim) {
+	opts := options.FromContext(ctx)
+	// The defaults are validated when the options are parsed
+	nodeClaim.Labels = lo.Assign(lo.Must(options.ParseNodeLabels(opts.DefaultNodeLabels)), nodeClaim.Labels)
+	nodeClaim.Annotations = lo.Assign(lo.Must(options.ParseNodeAnnotations(opts.DefaultNodeAnnotations)), nodeClaim.Annotations)
+	for _, taint := range lo.Must(options.ParseNodeTaints(opts.DefaultNodeTaints)) {
+		if !lo.ContainsBy(nodeClaim.Spec.Taints, func(t corev1.Taint) bool { return t.MatchTaint(&taint) }) {
+			nodeClaim.Spec.Taints = append(nodeClaim.Spec.Taints, taint)
+		}
+	}
+}
+
+// FallbackToOnDemand relaxes the capacity type requirement of a spot-only NodeClaimTemplate to on-demand when the
+// NodePool opted into a capacity type fallback policy. It returns false, leaving the template untouched, if the NodePool
+// didn't opt in or none of the instance types are compatible with the relaxed requirements.
+func (i *NodeClaimTemplate) FallbackToOnDemand(nodePool *v1.NodePool, instanceTypes []*cloudprovider.InstanceType) bool {
+	if nodePool.Spec.CapacityTypeFallback == nil {
+		return false
+	}
+	// Capacity types that are set through labels can't be relaxed
+	if _, ok := i.Labels[v1.CapacityTypeLabelKey]; ok {
+		return false
+	}
+	if capacityType := i.Requirements.Get(v1.CapacityTypeLabelKey); !capacityType.Has(v1.CapacityTypeSpot) || capacityType.Has(v1.CapacityTypeOnDemand) {
+		return false
+	}
+	requirements := scheduling.NewRequirements(i.Requirements.Values()...)
+	requirements[v1.CapacityTypeLabelKey] = scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeOnDemand)
+	remaining := filterInstanceTypesByRequirements(instanceTypes, requirements, corev1.ResourceList{}, nil, false).remaining
+	if len(remaining) == 0 {
+		return false
+	}
+	i.Requirements = requirements
+	i.InstanceTypeOptions = remaining
+	i.Annotations = lo.Assign(i.Annotations, map[string]string{v1.CapacityTypeFallbackAnnotationKey: string(*nodePool.Spec.CapacityTypeFallback)})
+	return true
+}
+
 func (i *NodeClaimTemplate) ToNodeClaim() *v1.NodeClaim {
 	// Order the instance types by price and only take the first 100 of them to decrease the instance type size in the requirements
 	instanceTypes := lo.Slice(i.InstanceTypeOptions.OrderByPrice(i.Requirements), 0, MaxInstanceTypes)
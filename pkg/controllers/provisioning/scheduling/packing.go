@@ -0,0 +1,65 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// fitPacking ensures that the NodeClaim doesn't exceed the packing targets of its NodePool once a pod with the requests
+// is added to it. Instance types that the requests exceed the target utilization of are excluded.
+func (n *NodeClaim) fitPacking(requests corev1.ResourceList, instanceTypes cloudprovider.InstanceTypes) (cloudprovider.InstanceTypes, error) {
+	if maxPods := n.Packing.MaxPodsPerNode; maxPods != nil && len(n.Pods) >= int(*maxPods) {
+		return nil, fmt.Errorf("exceeds max pods per node %d of nodepool %q", *maxPods, n.NodePoolName)
+	}
+	if n.Packing.TargetUtilization == nil {
+		return instanceTypes, nil
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return n.fitsTargetUtilization(it, requests)
+	})
+	if len(instanceTypes) == 0 {
+		return nil, fmt.Errorf("all available instance types exceed target utilization %d%% of nodepool %q", *n.Packing.TargetUtilization, n.NodePoolName)
+	}
+	return instanceTypes, nil
+}
+
+// fitsTargetUtilization returns true if the requests fit into the target utilization of the instance type's allocatable
+// CPU and memory. Other resources, e.g. GPUs, can't be partially used, so they're packed up to their allocatable.
+func (n *NodeClaim) fitsTargetUtilization(instanceType *cloudprovider.InstanceType, requests corev1.ResourceList) bool {
+	if n.Packing.TargetUtilization == nil {
+		return true
+	}
+	allocatable := instanceType.Allocatable()
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		quantity, ok := allocatable[name]
+		if !ok {
+			continue
+		}
+		target := resource.NewMilliQuantity(quantity.MilliValue()*int64(*n.Packing.TargetUtilization)/100, quantity.Format)
+		if request, ok := requests[name]; ok && request.Cmp(*target) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SelectsPodLabels returns true if the pod selector of a NodePool selects the labels of a pod. Unlike a label selector,
+// the values of matchLabels and of In and NotIn expressions may contain * wildcards. A nil selector selects all pods.
+func SelectsPodLabels(selector *metav1.LabelSelector, labels map[string]string) bool {
+	if selector == nil {
+		return true
+	}
+	for key, pattern := range selector.MatchLabels {
+		if value, ok := labels[key]; !ok || !matchesWildcard(pattern, value) {
+			return false
+		}
+	}
+	for _, expression := range selector.MatchExpressions {
+		value, ok := labels[expression.Key]
+		matches := ok && lo.SomeBy(expression.Values, func(pattern string) bool { return matchesWildcard(pattern, value) })
+		switch expression.Operator {
+		case metav1.LabelSelectorOpIn:
+			if !matches {
+				return false
+			}
+		case metav1.LabelSelectorOpNotIn:
+			if matches {
+				return false
+			}
+		case metav1.LabelSelectorOpExists:
+			if !ok {
+				return false
+			}
+		case metav1.LabelSelectorOpDoesNotExist:
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// matchesWildcard returns true if the value matches the pattern, where each * in the pattern matches any sequence of
+// characters, including an empty one
+func matchesWildcard(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
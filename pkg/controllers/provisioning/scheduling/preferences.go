@@ -26,18 +26,56 @@ import (
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+// Preference policies determine whether the preferences of pods are relaxed to schedule them to existing nodes before
+// new nodes are launched for them
+const (
+	// PreferencePolicyPreferenceSatisfaction launches new nodes that satisfy the preferences of pods that don't fit on
+	// existing nodes. Preferences are only relaxed if the pods can't schedule to new nodes either.
+	PreferencePolicyPreferenceSatisfaction = "PreferPreferenceSatisfaction"
+	// PreferencePolicyExistingCapacity relaxes all preferences of pods that don't fit on existing nodes, and only launches
+	// new nodes for them if they don't fit on existing nodes without their preferences either
+	PreferencePolicyExistingCapacity = "PreferExistingCapacity"
+)
+
 type Preferences struct {
 	// ToleratePreferNoSchedule controls if preference relaxation adds a toleration for PreferNoSchedule taints.  This only
 	// helps if there is a corresponding taint, so we don't always add it.
 	ToleratePreferNoSchedule bool
 }
 
+// Relax removes the first preference of the pod that can be relaxed, returning false if there's nothing left to relax.
+// Pods that disable preference relaxation are never relaxed.
 func (p *Preferences) Relax(ctx context.Context, pod *v1.Pod) bool {
+	relaxations := append([]func(*v1.Pod) *string{p.removeRequiredNodeAffinityTerm}, p.preferenceRelaxations()...)
+	// Removing a required node affinity term moves on to the next of the OR'd terms rather than relaxing a preference,
+	// so it's still done for pods that disable preference relaxation
+	if podutils.HasPreferenceRelaxationDisabled(pod) {
+		relaxations = []func(*v1.Pod) *string{p.removeRequiredNodeAffinityTerm}
+	}
+	for _, relaxFunc := range relaxations {
+		if reason := relaxFunc(pod); reason != nil {
+			log.FromContext(ctx).WithValues("Pod", klog.KRef(pod.Namespace, pod.Name)).V(1).Info(fmt.Sprintf("relaxing soft constraints for pod since it previously failed to schedule, %s", lo.FromPtr(reason)))
+			return true
+		}
+	}
+	return false
+}
+
+// Relaxable returns true if the pod has any preferences that could be relaxed, regardless of whether the pod disables
+// preference relaxation. The pod isn't modified.
+func (p *Preferences) Relaxable(pod *v1.Pod) bool {
+	pod = pod.DeepCopy()
+	return lo.ContainsBy(p.preferenceRelaxations(), func(relaxFunc func(*v1.Pod) *string) bool { return relaxFunc(pod) != nil })
+}
+
+// preferenceRelaxations are the relaxations of the pod's preferred affinities, ScheduleAnyway topology spreads and
+// PreferNoSchedule taints
+func (p *Preferences) preferenceRelaxations() []func(*v1.Pod) *string {
 	relaxations := []func(*v1.Pod) *string{
-		p.removeRequiredNodeAffinityTerm,
 		p.removePreferredPodAffinityTerm,
 		p.removePreferredPodAntiAffinityTerm,
 		p.removePreferredNodeAffinityTerm,
@@ -46,14 +84,7 @@ func (p *Preferences) Relax(ctx context.Context, pod *v1.Pod) bool {
 	if p.ToleratePreferNoSchedule {
 		relaxations = append(relaxations, p.toleratePreferNoScheduleTaints)
 	}
-
-	for _, relaxFunc := range relaxations {
-		if reason := relaxFunc(pod); reason != nil {
-			log.FromContext(ctx).WithValues("Pod", klog.KRef(pod.Namespace, pod.Name)).V(1).Info(fmt.Sprintf("relaxing soft constraints for pod since it previously failed to schedule, %s", lo.FromPtr(reason)))
-			return true
-		}
-	}
-	return false
+	return relaxations
 }
 
 func (p *Preferences) removePreferredNodeAffinityTerm(pod *v1.Pod) *string {
@@ -0,0 +1,129 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+const (
+	// PriceMarkupConstraintZone is the constraint of the NodeClaim's zone requirement
+	PriceMarkupConstraintZone = "zone"
+	// PriceMarkupConstraintCapacityType is the constraint of the NodeClaim's capacity type requirement
+	PriceMarkupConstraintCapacityType = "capacity-type"
+	// PriceMarkupConstraintAvailability is the constraint of offerings that are unavailable, e.g. due to insufficient
+	// capacity
+	PriceMarkupConstraintAvailability = "availability"
+)
+
+// PriceMarkup describes how much pricier the cheapest offering that a NodeClaim can launch with is than the cheapest
+// offering of an instance type that fits its pods regardless of their zone and capacity type, or of whether the offering
+// is available.
+type PriceMarkup struct {
+	// Price is the price of the cheapest offering that the NodeClaim can launch with
+	Price float64
+	// CheapestPrice is the price of the cheapest offering that fits the NodeClaim's pods without the constraints
+	CheapestPrice float64
+	// CheapestInstanceType is the instance type of the cheapest offering without the constraints
+	CheapestInstanceType string
+	// Constraints are the constraints that lower the price when they're relaxed on their own, with the price of the
+	// cheapest offering without each of them. The markup is caused by a combination of the constraints if it's empty.
+	Constraints []PriceMarkupConstraint
+}
+
+// PriceMarkupConstraint is a constraint that contributes to a PriceMarkup
+type PriceMarkupConstraint struct {
+	Name  string
+	Price float64
+}
+
+// Percentage is how much pricier the NodeClaim is than the cheapest offering without the constraints
+func (m PriceMarkup) Percentage() float64 {
+	return (m.Price/m.CheapestPrice - 1) * 100
+}
+
+// PriceMarkup returns the markup of the NodeClaim's cheapest offering if it exceeds the threshold percentage. It has to
+// be called before the NodeClaim is converted with ToNodeClaim, which restricts its requirements to its instance types.
+func (n *NodeClaim) PriceMarkup(threshold int) (PriceMarkup, bool) {
+	if threshold <= 0 {
+		return PriceMarkup{}, false
+	}
+	_, price, ok := cheapestOffering(n.InstanceTypeOptions, n.Requirements, true)
+	if !ok {
+		return PriceMarkup{}, false
+	}
+	// instance types that fit the pods and that satisfy the requirements other than the constraints
+	instanceTypes := lo.Filter(n.instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return compatible(it, without(n.Requirements, v1.CapacityTypeLabelKey, corev1.LabelTopologyZone)) &&
+			fits(it, n.Spec.Resources.Requests, n.podRequests, n.nodeSlicing) && n.fitsTargetUtilization(it, n.Spec.Resources.Requests)
+	})
+	cheapest, cheapestPrice, ok := cheapestOffering(instanceTypes, without(n.Requirements, v1.CapacityTypeLabelKey, corev1.LabelTopologyZone), false)
+	if !ok || cheapestPrice <= 0 || price <= cheapestPrice*(1+float64(threshold)/100) {
+		return PriceMarkup{}, false
+	}
+	markup := PriceMarkup{Price: price, CheapestPrice: cheapestPrice, CheapestInstanceType: cheapest.Name}
+	for _, constraint := range []struct {
+		name      string
+		key       string
+		available bool
+	}{
+		{name: PriceMarkupConstraintZone, key: corev1.LabelTopologyZone, available: true},
+		{name: PriceMarkupConstraintCapacityType, key: v1.CapacityTypeLabelKey, available: true},
+		{name: PriceMarkupConstraintAvailability, available: false},
+	} {
+		requirements := without(n.Requirements, constraint.key)
+		if _, relaxed, ok := cheapestOffering(lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			return compatible(it, requirements)
+		}), requirements, constraint.available); ok && relaxed < price {
+			markup.Constraints = append(markup.Constraints, PriceMarkupConstraint{Name: constraint.name, Price: relaxed})
+		}
+	}
+	return markup, true
+}
+
+// cheapestOffering returns the instance type with the cheapest offering that's compatible with the requirements, and the
+// price of the offering. Only available offerings are considered if available is true.
+func cheapestOffering(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, available bool) (*cloudprovider.InstanceType, float64, bool) {
+	var cheapest *cloudprovider.InstanceType
+	var cheapestPrice float64
+	for _, it := range instanceTypes {
+		offerings := it.Offerings
+		if available {
+			offerings = offerings.Available()
+		}
+		offerings = offerings.Compatible(requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		if price := offerings.Cheapest().Price; cheapest == nil || price < cheapestPrice {
+			cheapest, cheapestPrice = it, price
+		}
+	}
+	return cheapest, cheapestPrice, cheapest != nil
+}
+
+// without returns a copy of the requirements without the requirements of the keys
+func without(requirements scheduling.Requirements, keys ...string) scheduling.Requirements {
+	return scheduling.NewRequirements(lo.Reject(requirements.Values(), func(r *scheduling.Requirement, _ int) bool {
+		return lo.Contains(keys, r.Key)
+	})...)
+}
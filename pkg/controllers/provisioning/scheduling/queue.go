@@ -19,6 +19,7 @@ package scheduling
 import (
 	"sort"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -33,9 +34,29 @@ type Queue struct {
 	lastLen map[types.UID]int
 }
 
-// NewQueue constructs a new queue given the input pods, sorting them to optimize for bin-packing into nodes.
-func NewQueue(pods []*v1.Pod, podRequests map[types.UID]v1.ResourceList) *Queue {
-	sort.Slice(pods, byCPUAndMemoryDescending(pods, podRequests))
+// Pod ordering strategies determine which pods of a batch are scheduled first. Pods that are scheduled first have the
+// most choice of where they are packed, so ordering materially changes the nodes that are launched.
+const (
+	// PodOrderingStrategyResourceSize schedules the pods with the largest cpu and memory requests first
+	PodOrderingStrategyResourceSize = "ResourceSize"
+	// PodOrderingStrategyPriority schedules the pods with the highest priority first
+	PodOrderingStrategyPriority = "Priority"
+	// PodOrderingStrategyConstraints schedules the pods with the most scheduling constraints first
+	PodOrderingStrategyConstraints = "Constraints"
+)
+
+// NewQueue constructs a new queue given the input pods, sorting them by the ordering strategy. Pods that are ordered
+// equally by the strategy are sorted to optimize for bin-packing into nodes.
+func NewQueue(pods []*v1.Pod, podRequests map[types.UID]v1.ResourceList, strategy string) *Queue {
+	bySize := byCPUAndMemoryDescending(pods, podRequests)
+	switch strategy {
+	case PodOrderingStrategyPriority:
+		sort.Slice(pods, byKeyDescending(pods, podPriority, bySize))
+	case PodOrderingStrategyConstraints:
+		sort.Slice(pods, byKeyDescending(pods, podConstraints, bySize))
+	default:
+		sort.Slice(pods, bySize)
+	}
 	return &Queue{
 		pods:    pods,
 		lastLen: map[types.UID]int{},
@@ -110,3 +131,42 @@ func byCPUAndMemoryDescending(pods []*v1.Pod, podRequests map[types.UID]v1.Resou
 		return lhsPod.UID < rhsPod.UID
 	}
 }
+
+// byKeyDescending orders pods by the key in descending order, falling back to the next ordering when keys are equal
+func byKeyDescending(pods []*v1.Pod, key func(*v1.Pod) int, next func(i int, j int) bool) func(i int, j int) bool {
+	return func(i, j int) bool {
+		lhs, rhs := key(pods[i]), key(pods[j])
+		if lhs != rhs {
+			return lhs > rhs
+		}
+		return next(i, j)
+	}
+}
+
+func podPriority(pod *v1.Pod) int {
+	return int(lo.FromPtr(pod.Spec.Priority))
+}
+
+// podConstraints counts the scheduling constraints that restrict where a pod can be scheduled. Preferred constraints
+// are relaxed when they can't be satisfied, so only the required constraints are counted.
+func podConstraints(pod *v1.Pod) int {
+	constraints := len(pod.Spec.NodeSelector)
+	constraints += len(lo.Filter(pod.Spec.TopologySpreadConstraints, func(tsc v1.TopologySpreadConstraint, _ int) bool {
+		return tsc.WhenUnsatisfiable == v1.DoNotSchedule
+	}))
+	if pod.Spec.Affinity == nil {
+		return constraints
+	}
+	if na := pod.Spec.Affinity.NodeAffinity; na != nil && na.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			constraints += len(term.MatchExpressions) + len(term.MatchFields)
+		}
+	}
+	if pa := pod.Spec.Affinity.PodAffinity; pa != nil {
+		constraints += len(pa.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	if paa := pod.Spec.Affinity.PodAntiAffinity; paa != nil {
+		constraints += len(paa.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	return constraints
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// failedLaunchRetries is the number of times that a failed launch is retried with the same pods and requirements before
+// its pods are re-simulated
+const failedLaunchRetries = 1
+
+// RetryFailedLaunches packs the pods of launches that recently failed onto NodeClaims with the NodePool and requirements
+// of the NodeClaims that failed, so that the pods aren't packed onto NodeClaims of a different shape on every attempt.
+// Launches are only retried while all of their pods are pending, and pods that no longer fit are scheduled as usual.
+// It returns the pods that weren't retried, which should be passed to Solve.
+func (s *Scheduler) RetryFailedLaunches(ctx context.Context, pods []*corev1.Pod) []*corev1.Pod {
+	if s.cluster == nil {
+		return pods
+	}
+	pending := lo.SliceToMap(pods, func(p *corev1.Pod) (types.UID, *corev1.Pod) { return p.UID, p })
+	retried := sets.New[types.UID]()
+	for _, failed := range s.cluster.FailedLaunches() {
+		if failed.Attempts > failedLaunchRetries {
+			continue
+		}
+		group := lo.FilterMap(sets.List(failed.Pods), func(uid types.UID, _ int) (*corev1.Pod, bool) {
+			p, ok := pending[uid]
+			return p, ok && !retried.Has(uid)
+		})
+		if len(group) != failed.Pods.Len() {
+			continue
+		}
+		nodeClaim, ok := s.newRetryNodeClaim(failed)
+		if !ok {
+			continue
+		}
+		for _, pod := range group {
+			requests := withoutIgnoredResources(ctx, resources.RequestsForPods(pod))
+			if err := nodeClaim.Add(pod, requests); err != nil {
+				continue
+			}
+			s.cachedPodRequests[pod.UID] = requests
+			retried.Insert(pod.UID)
+		}
+		if len(nodeClaim.Pods) == 0 {
+			nodeClaim.Destroy()
+			continue
+		}
+		s.trackNewNodeClaim(nodeClaim)
+		log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", failed.NodeClaim.Name), "attempts", failed.Attempts, "pods", len(nodeClaim.Pods)).Info("retrying failed nodeclaim launch")
+	}
+	return lo.Reject(pods, func(p *corev1.Pod, _ int) bool { return retried.Has(p.UID) })
+}
+
+// newRetryNodeClaim creates a NodeClaim with the NodePool, instance types and requirements of the failed launch. It
+// returns false if the NodePool's template is no longer compatible with the failed NodeClaim.
+func (s *Scheduler) newRetryNodeClaim(failed *state.FailedLaunch) (*NodeClaim, bool) {
+	nodeClaimTemplate, ok := lo.Find(s.nodeClaimTemplates, func(nct *NodeClaimTemplate) bool {
+		return nct.NodePoolName == failed.NodeClaim.Labels[v1.NodePoolLabelKey]
+	})
+	if !ok {
+		return nil, false
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(failed.NodeClaim.Spec.Requirements...)
+	if nodeClaimTemplate.Requirements.Compatible(requirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+		return nil, false
+	}
+	instanceTypes := lo.Filter(nodeClaimTemplate.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+		return requirements.Get(corev1.LabelInstanceTypeStable).Has(it.Name)
+	})
+	if remaining, ok := s.remainingResources[nodeClaimTemplate.NodePoolName]; ok {
+		instanceTypes = filterByRemainingResources(instanceTypes, remaining)
+	}
+	if len(instanceTypes) == 0 {
+		return nil, false
+	}
+	nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], s.daemonHostPorts[nodeClaimTemplate], instanceTypes, lo.Assign(s.remainingZoneResources[nodeClaimTemplate.NodePoolName]), s.capacityPools, s.nodeSlicing)
+	nodeClaim.Requirements.Add(requirements.Values()...)
+	return nodeClaim, true
+}
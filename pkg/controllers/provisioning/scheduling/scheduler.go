@@ -19,14 +19,18 @@ package scheduling
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -39,19 +43,23 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
-	"sigs.k8s.io/karpenter/pkg/utils/pod"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1.NodePool,
 	cluster *state.Cluster, stateNodes []*state.StateNode, topology *Topology,
-	instanceTypes map[string][]*cloudprovider.InstanceType, daemonSetPods []*corev1.Pod,
-	recorder events.Recorder, clock clock.Clock) *Scheduler {
+	instanceTypes map[string][]*cloudprovider.InstanceType, capacityPools map[string][]*cloudprovider.CapacityPool,
+	daemonSetPods []*corev1.Pod, recorder events.Recorder, clock clock.Clock) *Scheduler {
 
 	// if any of the nodePools add a taint with a prefer no schedule effect, we add a toleration for the taint
 	// during preference relaxation
-	toleratePreferNoSchedule := false
+	toleratePreferNoSchedule := lo.ContainsBy(lo.Must(options.ParseNodeTaints(options.FromContext(ctx).DefaultNodeTaints)), func(t corev1.Taint) bool {
+		return t.Effect == corev1.TaintEffectPreferNoSchedule
+	})
 	for _, np := range nodePools {
 		for _, taint := range np.Spec.Template.Spec.Taints {
 			if taint.Effect == corev1.TaintEffectPreferNoSchedule {
@@ -61,8 +69,15 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 	}
 	// Pre-filter instance types eligible for NodePools to reduce work done during scheduling loops for pods
 	templates := lo.FilterMap(nodePools, func(np *v1.NodePool, _ int) (*NodeClaimTemplate, bool) {
-		nct := NewNodeClaimTemplate(np)
-		nct.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes[np.Name], nct.Requirements, corev1.ResourceList{}).remaining
+		nct := NewNodeClaimTemplate(ctx, np)
+		nct.instanceTypes = instanceTypes[np.Name]
+		nct.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes[np.Name], nct.Requirements, corev1.ResourceList{}, nil, false).remaining
+		// If spot capacity is unavailable for every compatible instance type, NodePools can opt into launching on-demand
+		// capacity in the same scheduling round rather than waiting for spot capacity to become available
+		if len(nct.InstanceTypeOptions) == 0 && nct.FallbackToOnDemand(np, instanceTypes[np.Name]) {
+			recorder.Publish(CapacityTypeFallback(np))
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Info("spot capacity is unavailable for all compatible instance types, falling back to on-demand")
+		}
 		if len(nct.InstanceTypeOptions) == 0 {
 			recorder.Publish(NoCompatibleInstanceTypes(np))
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Info("skipping, nodepool requirements filtered out all instance types")
@@ -76,16 +91,33 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 		nodeClaimTemplates: templates,
 		topology:           topology,
 		cluster:            cluster,
-		daemonOverhead:     getDaemonOverhead(templates, daemonSetPods),
+		daemonOverhead:     getDaemonOverhead(ctx, templates, daemonSetPods),
+		daemonHostPorts:    getDaemonHostPortUsage(templates, daemonSetPods),
 		cachedPodRequests:  map[types.UID]corev1.ResourceList{}, // cache pod requests to avoid having to continually recompute this total
 		recorder:           recorder,
 		preferences:        &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
 		remainingResources: lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, corev1.ResourceList) {
 			return np.Name, corev1.ResourceList(np.Spec.Limits)
 		}),
-		clock: clock,
+		remainingZoneResources: lo.SliceToMap(lo.Filter(nodePools, func(np *v1.NodePool, _ int) bool { return len(np.Spec.ZoneLimits) > 0 }), func(np *v1.NodePool) (string, map[string]corev1.ResourceList) {
+			return np.Name, lo.SliceToMap(np.Spec.ZoneLimits, func(zl v1.ZoneLimit) (string, corev1.ResourceList) {
+				return zl.Zone, corev1.ResourceList(zl.Limits)
+			})
+		}),
+		// pods with a PriorityClass that any NodePool lists are restricted to those NodePools, even if the NodePools
+		// that list it have no compatible instance types in this round
+		restrictedPriorityClasses: sets.New(lo.FlatMap(nodePools, func(np *v1.NodePool, _ int) []string { return np.Spec.PriorityClassNames })...),
+		clock:                     clock,
+		nodeSlicing:               options.FromContext(ctx).FeatureGates.NodeSlicing,
+		spreadOwnerReplicas:       options.FromContext(ctx).SpreadOwnerReplicas,
+		preferencePolicy:          options.FromContext(ctx).PreferencePolicy,
 	}
-	s.calculateExistingNodeClaims(stateNodes, daemonSetPods)
+	var daemonCapacityPoolPods map[string]int64
+	if len(capacityPools) > 0 {
+		daemonCapacityPoolPods = getDaemonCapacityPoolPods(templates, daemonSetPods)
+	}
+	s.capacityPools = newCapacityPools(capacityPools, daemonCapacityPoolPods)
+	s.calculateExistingNodeClaims(stateNodes, daemonSetPods, instanceTypes)
 	return s
 }
 
@@ -96,6 +128,7 @@ type Scheduler struct {
 	nodeClaimTemplates []*NodeClaimTemplate
 	remainingResources map[string]corev1.ResourceList // (NodePool name) -> remaining resources for that NodePool
 	daemonOverhead     map[*NodeClaimTemplate]corev1.ResourceList
+	daemonHostPorts    map[*NodeClaimTemplate]*scheduling.HostPortUsage
 	cachedPodRequests  map[types.UID]corev1.ResourceList // (Pod Namespace/Name) -> calculated resource requests for the pod
 	preferences        *Preferences
 	topology           *Topology
@@ -103,6 +136,20 @@ type Scheduler struct {
 	recorder           events.Recorder
 	kubeClient         client.Client
 	clock              clock.Clock
+
+	// (NodePool name) -> (zone) -> remaining resources for that NodePool in zones with zone limits
+	remainingZoneResources map[string]map[string]corev1.ResourceList
+	capacityPools          *capacityPools
+	// nodeSlicing packs the pods of each node onto the slices of its instance type
+	nodeSlicing bool
+	// spreadOwnerReplicas prefers creating a new NodeClaim over adding a pod to a new NodeClaim that already has a pod
+	// with the same owner
+	spreadOwnerReplicas bool
+	// preferencePolicy determines whether pods' preferences are relaxed to schedule them to existing nodes before new
+	// NodeClaims are created for them
+	preferencePolicy string
+	// restrictedPriorityClasses are the PriorityClasses that NodePools are capacity tiers for
+	restrictedPriorityClasses sets.Set[string]
 }
 
 // Results contains the results of the scheduling operation
@@ -120,6 +167,7 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 	for p, err := range r.PodErrors {
 		log.FromContext(ctx).WithValues("Pod", klog.KRef(p.Namespace, p.Name)).Error(err, "could not schedule pod")
 		recorder.Publish(PodFailedToScheduleEvent(p, err))
+		recordIncompatibilities(err)
 	}
 	for _, existing := range r.ExistingNodes {
 		if len(existing.Pods) > 0 {
@@ -151,19 +199,32 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 	log.FromContext(ctx).Info(fmt.Sprintf("computed %d unready node(s) will fit %d pod(s)", inflightCount, existingCount))
 }
 
+// recordIncompatibilities counts each of the keys of a pod's requirements that are incompatible for the same reason once,
+// even if they're incompatible with several NodePools
+func recordIncompatibilities(err error) {
+	for _, incompatibility := range lo.UniqBy(scheduling.Incompatibilities(err), func(i scheduling.Incompatibility) string {
+		return i.Key + "/" + string(i.Reason)
+	}) {
+		IncompatibleRequirementsTotal.Inc(map[string]string{
+			KeyLabel:            incompatibility.Key,
+			metrics.ReasonLabel: string(incompatibility.Reason),
+		})
+	}
+}
+
 // AllNonPendingPodsScheduled returns true if all pods scheduled.
 // We don't care if a pod was pending before consolidation and will still be pending after. It may be a pod that we can't
 // schedule at all and don't want it to block consolidation.
 func (r Results) AllNonPendingPodsScheduled() bool {
 	return len(lo.OmitBy(r.PodErrors, func(p *corev1.Pod, err error) bool {
-		return pod.IsProvisionable(p)
+		return podutils.IsProvisionable(p)
 	})) == 0
 }
 
 // NonPendingPodSchedulingErrors creates a string that describes why pods wouldn't schedule that is suitable for presentation
 func (r Results) NonPendingPodSchedulingErrors() string {
 	errs := lo.OmitBy(r.PodErrors, func(p *corev1.Pod, err error) bool {
-		return pod.IsProvisionable(p)
+		return podutils.IsProvisionable(p)
 	})
 	if len(errs) == 0 {
 		return "No Pod Scheduling Errors"
@@ -207,6 +268,8 @@ func (r Results) TruncateInstanceTypes(maxInstanceTypes int) Results {
 
 func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	defer metrics.Measure(DurationSeconds, map[string]string{ControllerLabel: injection.GetControllerName(ctx)})()
+	ctx, span := tracing.StartSpan(ctx, "scheduler.solve", trace.WithAttributes(attribute.Int("karpenter.pods", len(pods))))
+	defer span.End()
 	// We loop trying to schedule unschedulable pods as long as we are making progress.  This solves a few
 	// issues including pods with affinity to another pod in the batch. We could topo-sort to solve this, but it wouldn't
 	// solve the problem of scheduling pods where a particular order is needed to prevent a max-skew violation. E.g. if we
@@ -217,9 +280,14 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	UnschedulablePodsCount.DeletePartialMatch(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
 	QueueDepth.DeletePartialMatch(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
 	for _, p := range pods {
-		s.cachedPodRequests[p.UID] = resources.RequestsForPods(p)
+		s.cachedPodRequests[p.UID] = withoutIgnoredResources(ctx, resources.RequestsForPods(p))
+		// Pods may still fit on existing nodes, so we schedule them regardless, but surface host port conflicts that
+		// prevent them from ever scheduling to new capacity
+		if err := s.daemonHostPortConflicts(p); err != nil {
+			s.recorder.Publish(PodHostPortConflictEvent(p, err))
+		}
 	}
-	q := NewQueue(pods, s.cachedPodRequests)
+	q := NewQueue(pods, s.cachedPodRequests, options.FromContext(ctx).PodOrderingStrategy)
 
 	startTime := s.clock.Now()
 	lastLogTime := s.clock.Now()
@@ -239,11 +307,20 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 		}
 
 		// Schedule to existing nodes or create a new node
-		if errors[pod] = s.add(ctx, pod); errors[pod] == nil {
+		podCtx, podSpan := tracing.StartSpan(ctx, "scheduler.schedulePod", trace.WithAttributes(attribute.String("karpenter.pod", klog.KObj(pod).String())))
+		errors[pod] = s.add(podCtx, pod)
+		tracing.RecordError(podSpan, errors[pod])
+		podSpan.End()
+		if errors[pod] == nil {
 			delete(errors, pod)
 			continue
 		}
 
+		// Pods that disable preference relaxation are reported as unschedulable with the preferences that they could
+		// have been relaxed from
+		if podutils.HasPreferenceRelaxationDisabled(pod) && s.preferences.Relaxable(pod) {
+			errors[pod] = fmt.Errorf("%w, preference relaxation is disabled (%s=%s)", errors[pod], v1.PreferenceRelaxationAnnotationKey, v1.PreferenceRelaxationDisabled)
+		}
 		// If unsuccessful, relax the pod and recompute topology
 		relaxed := s.preferences.Relax(ctx, pod)
 		q.Push(pod, relaxed)
@@ -257,6 +334,10 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	for _, m := range s.newNodeClaims {
 		m.FinalizeScheduling()
 	}
+	span.SetAttributes(
+		attribute.Int("karpenter.nodeclaims.new", len(s.newNodeClaims)),
+		attribute.Int("karpenter.pods.unschedulable", len(errors)),
+	)
 
 	return Results{
 		NewNodeClaims: s.newNodeClaims,
@@ -267,38 +348,131 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 
 func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 	// first try to schedule against an in-flight real node
-	for _, node := range s.existingNodes {
-		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID]); err == nil {
-			return nil
-		}
+	existingNodes := s.existingNodes
+	if s.topology.HasRelaxedPreferredAntiAffinity(pod) {
+		existingNodes = byPreferredAntiAffinityScore(s.topology, pod, existingNodes, func(n *ExistingNode) scheduling.Requirements { return n.requirements })
+	}
+	if s.addToExistingNode(ctx, pod, existingNodes) {
+		return nil
+	}
+	if s.preferencePolicy == PreferencePolicyExistingCapacity && s.addToExistingNodeWithoutPreferences(ctx, pod) {
+		return nil
+	}
+	// NodeClaims in the scheduling round haven't been launched yet, so pods that require existing capacity can only be
+	// scheduled to existing and in-flight nodes
+	if podutils.RequiresExistingCapacity(pod) {
+		return fmt.Errorf("pod requires existing capacity (%s=%s) and doesn't fit on any existing node", v1.PreferExistingAnnotationKey, v1.PreferExistingRequired)
 	}
 
 	// Consider using https://pkg.go.dev/container/heap
 	sort.Slice(s.newNodeClaims, func(a, b int) bool { return len(s.newNodeClaims[a].Pods) < len(s.newNodeClaims[b].Pods) })
 
 	// Pick existing node that we are about to create
-	for _, nodeClaim := range s.newNodeClaims {
+	newNodeClaims := s.newNodeClaims
+	if s.topology.HasRelaxedPreferredAntiAffinity(pod) {
+		newNodeClaims = byPreferredAntiAffinityScore(s.topology, pod, newNodeClaims, func(n *NodeClaim) scheduling.Requirements { return n.Requirements })
+	}
+	var replicaNodeClaims []*NodeClaim
+	spreads := hostnameSpreads(pod)
+	for _, nodeClaim := range newNodeClaims {
+		if s.nodeClaimAdmitsPod(pod, &nodeClaim.NodeClaimTemplate) != nil {
+			continue
+		}
+		// pods that are spread across hostnames only fit on the NodeClaims that have room left in their hostname
+		// domain, so the NodeClaims that are full are skipped without simulating the pod's topology
+		if lo.ContainsBy(spreads, func(h hostnameSpread) bool { return h.full(nodeClaim) }) {
+			continue
+		}
+		if s.spreadOwnerReplicas && nodeClaim.HasReplicaOf(pod) {
+			replicaNodeClaims = append(replicaNodeClaims, nodeClaim)
+			continue
+		}
 		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err == nil {
 			return nil
 		}
 	}
 
 	// Create new node
+	err := s.addToNewNodeClaim(ctx, pod)
+	if err == nil {
+		return nil
+	}
+	// The spreading of replicas is best effort, so we fall back to packing the pod with the other replicas of its owner
+	// if we couldn't create a new NodeClaim for it
+	for _, nodeClaim := range replicaNodeClaims {
+		if nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// addToExistingNode schedules the pod to the first existing node that it fits on
+func (s *Scheduler) addToExistingNode(ctx context.Context, pod *corev1.Pod, existingNodes []*ExistingNode) bool {
+	for _, node := range existingNodes {
+		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID]); err == nil {
+			if consumesCapacityPools(pod) {
+				s.capacityPools.consume(node.Labels()[v1.NodePoolLabelKey], zoneRequirement(node.Labels()), 0, 1)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// addToExistingNodeWithoutPreferences relaxes all of the pod's preferences and tries to schedule it to an existing node.
+// If the pod doesn't fit on any existing node, its preferences are restored so that new NodeClaims that satisfy them can
+// be created for it.
+func (s *Scheduler) addToExistingNodeWithoutPreferences(ctx context.Context, pod *corev1.Pod) bool {
+	original := pod.DeepCopy()
+	relaxed := false
+	for s.preferences.Relax(ctx, pod) {
+		relaxed = true
+	}
+	if !relaxed {
+		return false
+	}
+	if err := s.topology.Update(ctx, pod); err != nil {
+		log.FromContext(ctx).Error(err, "failed updating topology")
+	} else {
+		existingNodes := s.existingNodes
+		if s.topology.HasRelaxedPreferredAntiAffinity(pod) {
+			existingNodes = byPreferredAntiAffinityScore(s.topology, pod, existingNodes, func(n *ExistingNode) scheduling.Requirements { return n.requirements })
+		}
+		if s.addToExistingNode(ctx, pod, existingNodes) {
+			return true
+		}
+	}
+	original.DeepCopyInto(pod)
+	if err := s.topology.Update(ctx, pod); err != nil {
+		log.FromContext(ctx).Error(err, "failed updating topology")
+	}
+	return false
+}
+
+// addToNewNodeClaim creates a NodeClaim from the first NodeClaimTemplate that the pod can be added to
+func (s *Scheduler) addToNewNodeClaim(ctx context.Context, pod *corev1.Pod) error {
 	var errs error
 	for _, nodeClaimTemplate := range s.nodeClaimTemplates {
+		if err := s.nodeClaimAdmitsPod(pod, nodeClaimTemplate); err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
 		instanceTypes := nodeClaimTemplate.InstanceTypeOptions
 		// if limits have been applied to the nodepool, ensure we filter instance types to avoid violating those limits
 		if remaining, ok := s.remainingResources[nodeClaimTemplate.NodePoolName]; ok {
 			instanceTypes = filterByRemainingResources(instanceTypes, remaining)
 			if len(instanceTypes) == 0 {
-				errs = multierr.Append(errs, fmt.Errorf("all available instance types exceed limits for nodepool: %q", nodeClaimTemplate.NodePoolName))
+				errs = multierr.Append(errs, LimitsExceededError{NodePoolName: nodeClaimTemplate.NodePoolName})
 				continue
 			} else if len(nodeClaimTemplate.InstanceTypeOptions) != len(instanceTypes) {
 				log.FromContext(ctx).V(1).WithValues("NodePool", klog.KRef("", nodeClaimTemplate.NodePoolName)).Info(fmt.Sprintf("%d out of %d instance types were excluded because they would breach limits",
 					len(nodeClaimTemplate.InstanceTypeOptions)-len(instanceTypes), len(nodeClaimTemplate.InstanceTypeOptions)))
 			}
 		}
-		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes)
+		// the NodeClaim keeps the remaining resources of zones with zone limits from before it's created so that it can
+		// exclude the zones that its instance types would exceed
+		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], s.daemonHostPorts[nodeClaimTemplate], instanceTypes, lo.Assign(s.remainingZoneResources[nodeClaimTemplate.NodePoolName]), s.capacityPools, s.nodeSlicing)
 		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err != nil {
 			nodeClaim.Destroy() // Ensure we cleanup any changes that we made while mocking out a NodeClaim
 			errs = multierr.Append(errs, fmt.Errorf("incompatible with nodepool %q, daemonset overhead=%s, %w",
@@ -307,15 +481,77 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 				err))
 			continue
 		}
-		// we will launch this nodeClaim and need to track its maximum possible resource usage against our remaining resources
-		s.newNodeClaims = append(s.newNodeClaims, nodeClaim)
-		s.remainingResources[nodeClaimTemplate.NodePoolName] = subtractMax(s.remainingResources[nodeClaimTemplate.NodePoolName], nodeClaim.InstanceTypeOptions)
+		s.trackNewNodeClaim(nodeClaim)
 		return nil
 	}
 	return errs
 }
 
-func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod) {
+// trackNewNodeClaim adds the NodeClaim to the NodeClaims that will be launched
+func (s *Scheduler) trackNewNodeClaim(nodeClaim *NodeClaim) {
+	// we will launch this nodeClaim and need to track its maximum possible resource usage against our remaining resources
+	s.newNodeClaims = append(s.newNodeClaims, nodeClaim)
+	s.remainingResources[nodeClaim.NodePoolName] = subtractMax(s.remainingResources[nodeClaim.NodePoolName], nodeClaim.InstanceTypeOptions)
+	// the zone the nodeClaim launches into isn't known until it's launched, so it's tracked against every zone it may launch into
+	for zone, remaining := range s.remainingZoneResources[nodeClaim.NodePoolName] {
+		if nodeClaim.Requirements.Get(corev1.LabelTopologyZone).Has(zone) {
+			s.remainingZoneResources[nodeClaim.NodePoolName][zone] = subtractMax(remaining, nodeClaim.InstanceTypeOptions)
+		}
+	}
+}
+
+// LimitsExceededError is returned for NodePools that could launch a NodeClaim for a pod if it wasn't for their limits
+type LimitsExceededError struct {
+	NodePoolName string
+}
+
+func (e LimitsExceededError) Error() string {
+	return fmt.Sprintf("all available instance types exceed limits for nodepool: %q", e.NodePoolName)
+}
+
+// LimitedNodePools returns the names of the NodePools whose limits prevented a NodeClaim from being launched for a pod
+// that failed to schedule
+func LimitedNodePools(err error) []string {
+	return lo.FilterMap(multierr.Errors(err), func(err error, _ int) (string, bool) {
+		var limitsErr LimitsExceededError
+		if !errors.As(err, &limitsErr) {
+			return "", false
+		}
+		return limitsErr.NodePoolName, true
+	})
+}
+
+// nodeClaimAdmitsPod returns an error if the NodePool of the template doesn't launch capacity for the pod, regardless
+// of whether the pod fits. It applies to both new NodeClaims and the in-flight NodeClaims of the same scheduling batch.
+func (s *Scheduler) nodeClaimAdmitsPod(pod *corev1.Pod, nodeClaimTemplate *NodeClaimTemplate) error {
+	if !SelectsPodLabels(nodeClaimTemplate.PodSelector, pod.Labels) {
+		return fmt.Errorf("incompatible with nodepool %q, pod labels aren't selected by its pod selector", nodeClaimTemplate.NodePoolName)
+	}
+	if !s.isPriorityClassAllowed(pod, nodeClaimTemplate) {
+		return fmt.Errorf("incompatible with nodepool %q, priority class %q is restricted to other nodepools", nodeClaimTemplate.NodePoolName, pod.Spec.PriorityClassName)
+	}
+	return nil
+}
+
+// isPriorityClassAllowed returns false if the pod's PriorityClass is listed by other NodePools but not by the
+// NodePool of the template. Pods are only restricted from new capacity, so they can still schedule to existing nodes.
+func (s *Scheduler) isPriorityClassAllowed(pod *corev1.Pod, nodeClaimTemplate *NodeClaimTemplate) bool {
+	if !s.restrictedPriorityClasses.Has(pod.Spec.PriorityClassName) {
+		return true
+	}
+	return nodeClaimTemplate.PriorityClassNames.Has(pod.Spec.PriorityClassName)
+}
+
+// byPreferredAntiAffinityScore returns a copy of the nodes ordered so that nodes satisfying more of the pod's relaxed
+// preferred anti-affinity terms (by weight) are tried first. Nodes with the same score keep their relative order.
+func byPreferredAntiAffinityScore[T any](topology *Topology, pod *corev1.Pod, nodes []T, requirements func(T) scheduling.Requirements) []T {
+	scores := lo.Map(nodes, func(n T, _ int) int32 { return topology.PreferredAntiAffinityScore(pod, requirements(n)) })
+	order := lo.Range(len(nodes))
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	return lo.Map(order, func(i int, _ int) T { return nodes[i] })
+}
+
+func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod, instanceTypes map[string][]*cloudprovider.InstanceType) {
 	// create our existing nodes
 	for _, node := range stateNodes {
 		// Calculate any daemonsets that should schedule to the inflight node
@@ -330,7 +566,22 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 			}
 			daemons = append(daemons, p)
 		}
-		s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...)))
+		// Shared resources are packed onto the devices of the node's instance type, and pods onto its slices
+		var sharedResources corev1.ResourceList
+		var slices []corev1.ResourceList
+		if it, ok := lo.Find(instanceTypes[node.Labels()[v1.NodePoolLabelKey]], func(it *cloudprovider.InstanceType) bool {
+			return it.Name == node.Labels()[corev1.LabelInstanceTypeStable]
+		}); ok {
+			sharedResources = it.SharedResources
+			if s.nodeSlicing {
+				slices = it.SliceCapacities()
+			}
+		}
+		// Pods aren't nominated to nodes whose kubelets stopped heartbeating, even if the node still looks Ready, since
+		// they wouldn't be started there. The capacity of these nodes still counts against their NodePool's limits.
+		if !node.HeartbeatExpired(s.clock.Now()) {
+			s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...), sharedResources, slices))
+		}
 
 		// We don't use the status field and instead recompute the remaining resources to ensure we have a consistent view
 		// of the cluster during scheduling.  Depending on how node creation falls out, this will also work for cases where
@@ -338,6 +589,9 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 		if _, ok := s.remainingResources[node.Labels()[v1.NodePoolLabelKey]]; ok {
 			s.remainingResources[node.Labels()[v1.NodePoolLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1.NodePoolLabelKey]], node.Capacity())
 		}
+		if remaining, ok := s.remainingZoneResources[node.Labels()[v1.NodePoolLabelKey]][node.Labels()[corev1.LabelTopologyZone]]; ok {
+			s.remainingZoneResources[node.Labels()[v1.NodePoolLabelKey]][node.Labels()[corev1.LabelTopologyZone]] = resources.Subtract(remaining, node.Capacity())
+		}
 	}
 	// Order the existing nodes for scheduling with initialized nodes first
 	// This is done specifically for consolidation where we want to make sure we schedule to initialized nodes
@@ -354,12 +608,54 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 }
 
 // getDaemonOverhead determines the overhead for each NodeClaimTemplate required for daemons to schedule for any node provisioned by the NodeClaimTemplate
-func getDaemonOverhead(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*corev1.Pod) map[*NodeClaimTemplate]corev1.ResourceList {
+func getDaemonOverhead(ctx context.Context, nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*corev1.Pod) map[*NodeClaimTemplate]corev1.ResourceList {
 	return lo.SliceToMap(nodeClaimTemplates, func(nct *NodeClaimTemplate) (*NodeClaimTemplate, corev1.ResourceList) {
-		return nct, resources.RequestsForPods(lo.Filter(daemonSetPods, func(p *corev1.Pod, _ int) bool { return isDaemonPodCompatible(nct, p) })...)
+		return nct, withoutIgnoredResources(ctx, resources.RequestsForPods(lo.Filter(daemonSetPods, func(p *corev1.Pod, _ int) bool { return isDaemonPodCompatible(nct, p) })...))
 	})
 }
 
+// withoutIgnoredResources removes the resources that are configured to be ignored from the requests. Some clusters
+// inject extended resources into pods that no instance type advertises until a device plugin registers them on the
+// node, and fitting pods against those resources would leave the pods unschedulable.
+func withoutIgnoredResources(ctx context.Context, requests corev1.ResourceList) corev1.ResourceList {
+	ignored := options.FromContext(ctx).IgnoredResources
+	if len(ignored) == 0 {
+		return requests
+	}
+	return lo.OmitByKeys(requests, lo.Map(ignored, func(name string, _ int) corev1.ResourceName { return corev1.ResourceName(name) }))
+}
+
+// getDaemonHostPortUsage determines the host ports for each NodeClaimTemplate that are used by daemons that schedule to any node provisioned by the NodeClaimTemplate
+func getDaemonHostPortUsage(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*corev1.Pod) map[*NodeClaimTemplate]*scheduling.HostPortUsage {
+	return lo.SliceToMap(nodeClaimTemplates, func(nct *NodeClaimTemplate) (*NodeClaimTemplate, *scheduling.HostPortUsage) {
+		usage := scheduling.NewHostPortUsage()
+		for _, p := range daemonSetPods {
+			if hostPorts := scheduling.GetHostPorts(p); len(hostPorts) > 0 && isDaemonPodCompatible(nct, p) {
+				usage.Add(p, hostPorts)
+			}
+		}
+		return nct, usage
+	})
+}
+
+// daemonHostPortConflicts returns an error if the pod's host ports conflict with the host ports of daemons on every
+// NodeClaimTemplate, which means that the pod can never schedule to a new node
+func (s *Scheduler) daemonHostPortConflicts(pod *corev1.Pod) error {
+	hostPorts := scheduling.GetHostPorts(pod)
+	if len(hostPorts) == 0 || len(s.nodeClaimTemplates) == 0 {
+		return nil
+	}
+	var errs error
+	for _, nct := range s.nodeClaimTemplates {
+		err := s.daemonHostPorts[nct].Conflicts(pod, hostPorts)
+		if err == nil {
+			return nil
+		}
+		errs = multierr.Append(errs, fmt.Errorf("incompatible with nodepool %q, %w", nct.NodePoolName, err))
+	}
+	return errs
+}
+
 // isDaemonPodCompatible determines if the daemon pod is compatible with the NodeClaimTemplate for daemon scheduling
 func isDaemonPodCompatible(nodeClaimTemplate *NodeClaimTemplate, pod *corev1.Pod) bool {
 	preferences := &Preferences{}
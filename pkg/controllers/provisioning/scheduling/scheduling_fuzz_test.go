@@ -0,0 +1,266 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	fakecr "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	pscheduling "sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+const fuzzTaintKey = "fuzz.karpenter.sh/tainted"
+
+// FuzzScheduling randomizes the composition of a scheduling batch (pod ordering, pod constraints and the daemonset
+// overhead of each NodePool) and asserts that the scheduler results never violate its invariants. The seed corpus is
+// run as part of the regular test suite, to fuzz continuously use:
+// `go test ./pkg/controllers/provisioning/scheduling -run=^$ -fuzz=FuzzScheduling -fuzztime=10m`
+// or `make fuzz`
+func FuzzScheduling(f *testing.F) {
+	for _, seed := range []int64{0, 1, 42, 1337, 2024} {
+		f.Add(seed, uint8(50), uint8(3))
+	}
+	f.Fuzz(func(t *testing.T, seed int64, podCount uint8, daemonSetCount uint8) {
+		//nolint:gosec
+		z := &schedulingFuzzer{r: rand.New(rand.NewSource(seed))}
+		z.round(t, int(podCount), int(daemonSetCount%8))
+	})
+}
+
+type schedulingFuzzer struct {
+	r *rand.Rand
+}
+
+// round solves a single randomized batch of pods and validates the results
+func (z *schedulingFuzzer) round(t *testing.T, podCount, daemonSetCount int) {
+	ctx := ctrl.IntoContext(context.Background(), operatorlogging.NopLogger)
+	ctx = options.ToContext(ctx, test.Options())
+
+	nodePools := []*v1.NodePool{
+		test.NodePool(),
+		test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Weight: lo.ToPtr[int32](10),
+				Template: v1.NodeClaimTemplate{
+					Spec: v1.NodeClaimTemplateSpec{
+						Taints: []corev1.Taint{{Key: fuzzTaintKey, Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+					},
+				},
+			},
+		}),
+	}
+	instanceTypes := fake.InstanceTypes(20)
+	fakeCloudProvider := fake.NewCloudProvider()
+	fakeCloudProvider.InstanceTypes = instanceTypes
+
+	pods := z.pods(podCount)
+	z.r.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
+	daemonSetPods := z.daemonSetPods(daemonSetCount)
+
+	kubeClient := fakecr.NewFakeClient()
+	clk := &clock.RealClock{}
+	stateCluster := state.NewCluster(clk, kubeClient, fakeCloudProvider, test.NewEventRecorder())
+	topology, err := scheduling.NewTopology(ctx, kubeClient, stateCluster, instanceTypeDomains(instanceTypes), pods)
+	if err != nil {
+		t.Fatalf("creating topology, %s", err)
+	}
+	scheduler := scheduling.NewScheduler(ctx, kubeClient, nodePools, stateCluster, nil, topology,
+		lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, []*cloudprovider.InstanceType) { return np.Name, instanceTypes }), nil,
+		daemonSetPods, events.NewRecorder(&record.FakeRecorder{}), clk)
+	results := scheduler.Solve(ctx, pods)
+
+	for _, err := range validateResults(pods, daemonSetPods, results) {
+		t.Error(err)
+	}
+}
+
+// validateResults checks the invariants that must hold for any scheduling result, regardless of the batch composition
+func validateResults(pods, daemonSetPods []*corev1.Pod, results scheduling.Results) []error {
+	var errs []error
+	scheduled := map[*corev1.Pod]int{}
+	zonalSpread := map[string]int{}
+	for _, nodeClaim := range results.NewNodeClaims {
+		if len(nodeClaim.InstanceTypeOptions) == 0 {
+			errs = append(errs, fmt.Errorf("nodeclaim with %d pods has no instance type options", len(nodeClaim.Pods)))
+		}
+		// No node may be over-allocated, including the daemonset pods that will schedule to it
+		daemons := lo.Filter(daemonSetPods, func(p *corev1.Pod, _ int) bool {
+			return pscheduling.Taints(nodeClaim.Spec.Taints).Tolerates(p) == nil
+		})
+		requests := resources.Merge(resources.RequestsForPods(daemons...), resources.RequestsForPods(nodeClaim.Pods...))
+		for _, it := range nodeClaim.InstanceTypeOptions {
+			if !resources.Fits(requests, it.Allocatable()) {
+				errs = append(errs, fmt.Errorf("instance type %s is over-allocated, requests %s, allocatable %s", it.Name, resources.String(requests), resources.String(it.Allocatable())))
+			}
+		}
+		antiAffinityPods := 0
+		for _, p := range nodeClaim.Pods {
+			scheduled[p]++
+			// All of the pod's constraints must be satisfied by the node it was scheduled to
+			if err := pscheduling.Taints(nodeClaim.Spec.Taints).Tolerates(p); err != nil {
+				errs = append(errs, fmt.Errorf("pod %s scheduled to nodeclaim with untolerated taints, %w", p.Name, err))
+			}
+			if err := nodeClaim.Requirements.Compatible(pscheduling.NewStrictPodRequirements(p), pscheduling.AllowUndefinedWellKnownLabels); err != nil {
+				errs = append(errs, fmt.Errorf("pod %s scheduled to nodeclaim with incompatible requirements, %w", p.Name, err))
+			}
+			if p.Spec.Affinity != nil && p.Spec.Affinity.PodAntiAffinity != nil {
+				antiAffinityPods++
+			}
+			if len(p.Spec.TopologySpreadConstraints) > 0 {
+				zone := nodeClaim.Requirements.Get(corev1.LabelTopologyZone)
+				if zone.Len() != 1 {
+					errs = append(errs, fmt.Errorf("pod %s with zonal topology spread scheduled to nodeclaim spanning zones %s", p.Name, zone))
+				}
+				zonalSpread[zone.Any()]++
+			}
+		}
+		if antiAffinityPods > 1 {
+			errs = append(errs, fmt.Errorf("%d pods with hostname anti-affinity scheduled to the same nodeclaim", antiAffinityPods))
+		}
+	}
+	// Every pod must be either scheduled exactly once or have a scheduling error
+	for _, p := range pods {
+		_, failed := results.PodErrors[p]
+		switch {
+		case scheduled[p] > 1:
+			errs = append(errs, fmt.Errorf("pod %s scheduled %d times", p.Name, scheduled[p]))
+		case scheduled[p] == 1 && failed:
+			errs = append(errs, fmt.Errorf("pod %s was scheduled and also has a scheduling error", p.Name))
+		case scheduled[p] == 0 && !failed:
+			errs = append(errs, fmt.Errorf("pod %s was neither scheduled nor reported as failed", p.Name))
+		}
+	}
+	if len(zonalSpread) > 0 {
+		counts := lo.Map([]string{"test-zone-1", "test-zone-2", "test-zone-3"}, func(zone string, _ int) int { return zonalSpread[zone] })
+		if lo.Max(counts)-lo.Min(counts) > 1 {
+			errs = append(errs, fmt.Errorf("zonal topology spread exceeded max skew, %v", zonalSpread))
+		}
+	}
+	return errs
+}
+
+// pods generates a batch of pods with a random mix of generic, zonally constrained, zonally spread, anti-affine and
+// taint tolerating pods
+func (z *schedulingFuzzer) pods(count int) []*corev1.Pod {
+	spreadLabels := map[string]string{"fuzz": "spread"}
+	antiAffinityLabels := map[string]string{"fuzz": "anti-affinity"}
+	var pods []*corev1.Pod
+	for i := 0; i < count; i++ {
+		opts := test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    z.cpu(),
+					corev1.ResourceMemory: z.memory(),
+				},
+			},
+		}
+		switch z.r.Intn(4) {
+		case 1:
+			opts.NodeSelector = map[string]string{corev1.LabelTopologyZone: fmt.Sprintf("test-zone-%d", z.r.Intn(3)+1)}
+		case 2:
+			opts.ObjectMeta = metav1.ObjectMeta{Labels: spreadLabels}
+			opts.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+				MaxSkew:           1,
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: spreadLabels},
+			}}
+		case 3:
+			opts.ObjectMeta = metav1.ObjectMeta{Labels: antiAffinityLabels}
+			opts.PodAntiRequirements = []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: antiAffinityLabels},
+				TopologyKey:   corev1.LabelHostname,
+			}}
+		}
+		if z.r.Intn(2) == 0 {
+			opts.Tolerations = []corev1.Toleration{{Key: fuzzTaintKey, Operator: corev1.TolerationOpExists}}
+		}
+		pods = append(pods, withUID(test.Pod(opts)))
+	}
+	return pods
+}
+
+// daemonSetPods generates daemonset pods with randomized overhead, some of which only schedule to the untainted NodePool
+func (z *schedulingFuzzer) daemonSetPods(count int) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for i := 0; i < count; i++ {
+		opts := test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    z.cpu(),
+					corev1.ResourceMemory: z.memory(),
+				},
+			},
+		}
+		if z.r.Intn(2) == 0 {
+			opts.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+		}
+		pods = append(pods, withUID(test.Pod(opts)))
+	}
+	return pods
+}
+
+// withUID assigns the UID that the API server would, since the scheduler tracks pods by their UID
+func withUID(p *corev1.Pod) *corev1.Pod {
+	p.UID = uuid.NewUUID()
+	return p
+}
+
+func (z *schedulingFuzzer) memory() resource.Quantity {
+	return resource.MustParse(fmt.Sprintf("%dMi", 64*(z.r.Intn(64)+1)))
+}
+
+func (z *schedulingFuzzer) cpu() resource.Quantity {
+	return resource.MustParse(fmt.Sprintf("%dm", 50*(z.r.Intn(40)+1)))
+}
+
+// instanceTypeDomains mirrors the provisioner's domain discovery, collecting the domains of every instance type requirement
+func instanceTypeDomains(instanceTypes []*cloudprovider.InstanceType) map[string]sets.Set[string] {
+	domains := map[string]sets.Set[string]{}
+	for _, it := range instanceTypes {
+		for key, requirement := range it.Requirements {
+			if domains[key] == nil {
+				domains[key] = sets.New[string]()
+			}
+			domains[key].Insert(requirement.Values()...)
+		}
+	}
+	return domains
+}
@@ -31,11 +31,13 @@ import (
 	. "github.com/onsi/gomega"
 	io_prometheus_client "github.com/prometheus/client_model/go"
 	"github.com/samber/lo"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	nodev1 "k8s.io/api/node/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	cloudproviderapi "k8s.io/cloud-provider/api"
@@ -89,7 +91,7 @@ var _ = BeforeSuite(func() {
 	// set these on the cloud provider, so we can manipulate them if needed
 	cloudProvider.InstanceTypes = instanceTypes
 	fakeClock = clock.NewFakeClock(time.Now())
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	podStateController = informer.NewPodController(env.Client, cluster)
@@ -190,6 +192,246 @@ var _ = Context("Scheduling", func() {
 				ExpectNotScheduled(ctx, env.Client, pod)
 			})
 		})
+		Context("Capabilities", func() {
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "small",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					}),
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name:         "capable",
+						Capabilities: sets.New("nested-virtualization"),
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("4"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					}),
+				}
+			})
+			It("should schedule pods that require a capability to an instance type with that capability", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeSelector: map[string]string{v1.CapabilityLabelKey("nested-virtualization"): v1.CapabilityTrue},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "capable"))
+				Expect(node.Labels).To(HaveKeyWithValue(v1.CapabilityLabelKey("nested-virtualization"), v1.CapabilityTrue))
+			})
+			It("should schedule pods that exclude a capability to an instance type without that capability", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []corev1.NodeSelectorRequirement{
+						{Key: v1.CapabilityLabelKey("nested-virtualization"), Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapabilityFalse}},
+						{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"small", "capable"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small"))
+			})
+			It("should not schedule pods that require a capability no instance type has", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeSelector: map[string]string{v1.CapabilityLabelKey("gpu-direct"): v1.CapabilityTrue},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should not schedule pods that require a capability to an existing node without that capability", func() {
+				node := test.Node(test.NodeOptions{
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10"),
+						corev1.ResourceMemory: resource.MustParse("10Gi"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+				})
+				ExpectApplied(ctx, env.Client, nodePool, node)
+				ExpectMakeNodesInitialized(ctx, env.Client, node)
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeSelector: map[string]string{v1.CapabilityLabelKey("nested-virtualization"): v1.CapabilityTrue},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduled := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduled.Name).ToNot(Equal(node.Name))
+				Expect(scheduled.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "capable"))
+			})
+		})
+		Context("Zone Preferences", func() {
+			It("should launch nodes into the preferred zone", func() {
+				nodePool.Spec.ZonePreferences = []v1.ZonePreference{{Zone: "test-zone-2", Weight: 50}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
+			It("should launch nodes into the zone with the highest weight", func() {
+				nodePool.Spec.ZonePreferences = []v1.ZonePreference{{Zone: "test-zone-2", Weight: 10}, {Zone: "test-zone-3", Weight: 20}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-3"))
+			})
+			It("should fall back to a less preferred zone when instance types aren't available in the preferred zone", func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "default-instance-type",
+						Offerings: []cloudprovider.Offering{
+							{
+								Requirements: pscheduling.NewLabelRequirements(map[string]string{
+									v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+									corev1.LabelTopologyZone: "test-zone-1",
+								}),
+								Price:     1.00,
+								Available: true,
+							},
+							{
+								Requirements: pscheduling.NewLabelRequirements(map[string]string{
+									v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+									corev1.LabelTopologyZone: "test-zone-2",
+								}),
+								Price:     1.00,
+								Available: true,
+							},
+							{
+								Requirements: pscheduling.NewLabelRequirements(map[string]string{
+									v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+									corev1.LabelTopologyZone: "test-zone-3",
+								}),
+								Price:     1.00,
+								Available: false,
+							},
+						},
+					}),
+				}
+				nodePool.Spec.ZonePreferences = []v1.ZonePreference{{Zone: "test-zone-2", Weight: 10}, {Zone: "test-zone-3", Weight: 20}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
+			It("should not override the zone that pods require", func() {
+				nodePool.Spec.ZonePreferences = []v1.ZonePreference{{Zone: "test-zone-2", Weight: 50}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1"))
+			})
+			It("should only narrow the zones of the NodeClaim to the preferred zones", func() {
+				nodePool.Spec.ZonePreferences = []v1.ZonePreference{{Zone: "test-zone-2", Weight: 50}, {Zone: "test-zone-3", Weight: 50}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				Expect(pscheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...).Get(corev1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-2", "test-zone-3"))
+			})
+		})
+		Context("Packing", func() {
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "small",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					}),
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "large",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("4"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					}),
+				}
+			})
+			It("should not schedule more than the max pods per node onto a new node", func() {
+				nodePool.Spec.Packing = &v1.Packing{MaxPodsPerNode: lo.ToPtr(int32(2))}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod(), test.UnschedulablePod()}
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New[string]()
+				for _, pod := range pods {
+					nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+				}
+				Expect(nodeNames).To(HaveLen(2))
+			})
+			It("should launch an instance type that the pods fit into at the target utilization", func() {
+				nodePool.Spec.Packing = &v1.Packing{TargetUtilization: lo.ToPtr(int32(50))}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "large"))
+			})
+			It("should not schedule pods that exceed the target utilization of every instance type", func() {
+				nodePool.Spec.Packing = &v1.Packing{TargetUtilization: lo.ToPtr(int32(50))}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+				}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should pack pods up to the allocatable of the instance types without packing targets", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small"))
+			})
+		})
+		Context("Ignored Resources", func() {
+			var opts test.PodOptions
+			BeforeEach(func() {
+				opts = test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:                        resource.MustParse("1"),
+						corev1.ResourceName("example.com/device"): resource.MustParse("1"),
+					},
+				}}
+			})
+			It("should not schedule pods that request resources that no instance type advertises", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(opts)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should schedule pods that request ignored resources that no instance type advertises", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{IgnoredResources: lo.ToPtr([]string{"example.com/device"})}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(opts)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				Expect(nodeClaims[0].Spec.Resources.Requests).ToNot(HaveKey(corev1.ResourceName("example.com/device")))
+			})
+			It("should still fit the other resources of pods that request ignored resources", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{IgnoredResources: lo.ToPtr([]string{"example.com/device"})}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				opts.ResourceRequirements.Requests[corev1.ResourceCPU] = resource.MustParse("10000")
+				pod := test.UnschedulablePod(opts)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+		})
 		Context("Well Known Labels", func() {
 			It("should use NodePool constraints", func() {
 				nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
@@ -1210,6 +1452,213 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Capacity Type Fallback", func() {
+		BeforeEach(func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}}},
+			}
+			// mark the spot offerings of every instance type as unavailable
+			for _, it := range cloudProvider.InstanceTypes {
+				for i := range it.Offerings {
+					if it.Offerings[i].Requirements.Get(v1.CapacityTypeLabelKey).Any() == v1.CapacityTypeSpot {
+						it.Offerings[i].Available = false
+					}
+				}
+			}
+		})
+		It("should not fall back to on-demand without a fallback policy", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should fall back to on-demand when spot is unavailable for all instance types", func() {
+			nodePool.Spec.CapacityTypeFallback = lo.ToPtr(v1.CapacityTypeFallbackOnDemand)
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeOnDemand))
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.CapacityTypeFallbackAnnotationKey, string(v1.CapacityTypeFallbackOnDemand)))
+		})
+		It("should not fall back to on-demand when spot is available for some instance types", func() {
+			nodePool.Spec.CapacityTypeFallback = lo.ToPtr(v1.CapacityTypeFallbackOnDemandUntilSpot)
+			cloudProvider.InstanceTypes = append(cloudProvider.InstanceTypes, fake.NewInstanceType(fake.InstanceTypeOptions{Name: "spot-instance-type"}))
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeSpot))
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "spot-instance-type"))
+		})
+	})
+
+	Describe("Pod Selector", func() {
+		var teamNodePool *v1.NodePool
+		BeforeEach(func() {
+			teamNodePool = test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Weight: lo.ToPtr[int32](100),
+					PodSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"data-*"}}},
+					},
+				},
+			})
+		})
+		It("should provision pods that the pod selector selects on the nodepool", func() {
+			ExpectApplied(ctx, env.Client, nodePool, teamNodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "data-platform"}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, teamNodePool.Name))
+		})
+		It("should not provision pods that the pod selector doesn't select on the nodepool", func() {
+			ExpectApplied(ctx, env.Client, nodePool, teamNodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "web"}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+		})
+		It("should not add pods that the pod selector doesn't select to nodeclaims of the same batch", func() {
+			ExpectApplied(ctx, env.Client, nodePool, teamNodePool)
+			selected := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta:           metav1.ObjectMeta{Labels: map[string]string{"team": "data-platform"}},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+			})
+			unselected := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta:           metav1.ObjectMeta{Labels: map[string]string{"team": "web"}},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, selected, unselected)
+			Expect(ExpectScheduled(ctx, env.Client, selected).Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, teamNodePool.Name))
+			Expect(ExpectScheduled(ctx, env.Client, unselected).Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+		})
+		It("should not schedule pods that no nodepool's pod selector selects", func() {
+			ExpectApplied(ctx, env.Client, teamNodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should match pod labels against wildcards and set-based expressions", func() {
+			labels := map[string]string{"team": "data-platform", "env": "prod"}
+			Expect(scheduling.SelectsPodLabels(nil, labels)).To(BeTrue())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchLabels: map[string]string{"team": "*-platform"}}, labels)).To(BeTrue())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchLabels: map[string]string{"team": "data"}}, labels)).To(BeFalse())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchLabels: map[string]string{"team": "d*t*-*"}}, labels)).To(BeTrue())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev*", "test"}},
+				{Key: "team", Operator: metav1.LabelSelectorOpExists},
+				{Key: "tier", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}}, labels)).To(BeTrue())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"pr*"}},
+			}}, labels)).To(BeFalse())
+			Expect(scheduling.SelectsPodLabels(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"*"}},
+			}}, labels)).To(BeFalse())
+		})
+	})
+
+	Describe("Priority Class Tiers", func() {
+		var spotNodePool *v1.NodePool
+		BeforeEach(func() {
+			spotNodePool = test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					PriorityClassNames: []string{"low-priority"},
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							Requirements: []v1.NodeSelectorRequirementWithMinValues{
+								{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}}},
+							},
+						},
+					},
+				},
+			})
+			nodePool.Spec.Weight = lo.ToPtr[int32](100)
+		})
+		It("should only provision pods with a listed priority class on the nodepools that list it", func() {
+			ExpectApplied(ctx, env.Client, nodePool, spotNodePool)
+			pod := test.UnschedulablePod(test.PodOptions{PriorityClassName: "low-priority"})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, spotNodePool.Name))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeSpot))
+		})
+		It("should provision pods with other priority classes on any nodepool", func() {
+			ExpectApplied(ctx, env.Client, nodePool, spotNodePool)
+			pod := test.UnschedulablePod(test.PodOptions{PriorityClassName: "high-priority"})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+		})
+		It("should not provision pods with a listed priority class when the nodepools that list it are incompatible", func() {
+			spotNodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "spot", Effect: corev1.TaintEffectNoSchedule}}
+			ExpectApplied(ctx, env.Client, nodePool, spotNodePool)
+			pod := test.UnschedulablePod(test.PodOptions{PriorityClassName: "low-priority"})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not pack pods with a listed priority class onto in-flight nodeclaims of other nodepools", func() {
+			ExpectApplied(ctx, env.Client, nodePool, spotNodePool)
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(test.PodOptions{PriorityClassName: "high-priority", NodeSelector: map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand}}),
+				test.UnschedulablePod(test.PodOptions{PriorityClassName: "low-priority"}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectScheduled(ctx, env.Client, pods[0]).Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+			Expect(ExpectScheduled(ctx, env.Client, pods[1]).Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, spotNodePool.Name))
+		})
+	})
+
+	Describe("Existing Capacity Only", func() {
+		var opts test.PodOptions
+		BeforeEach(func() {
+			opts = test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.PreferExistingAnnotationKey: v1.PreferExistingRequired}},
+				ResourceRequirements: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")},
+				},
+			}
+		})
+		It("should not launch a node for a pod that requires existing capacity", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+		})
+		It("should schedule a pod that requires existing capacity to an in-flight node", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			initialPod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: opts.ResourceRequirements})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, initialPod)
+			node := ExpectScheduled(ctx, env.Client, initialPod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			pod := test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		})
+		It("should not pack a pod that requires existing capacity onto a nodeclaim that's being launched", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(opts)
+			other := test.UnschedulablePod(test.PodOptions{ResourceRequirements: opts.ResourceRequirements})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, other, pod)
+			ExpectScheduled(ctx, env.Client, other)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should launch nodes for pods that prefer existing capacity without requiring it", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			opts.Annotations[v1.PreferExistingAnnotationKey] = "preferred"
+			pod := test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+
 	Describe("Instance Type Compatibility", func() {
 		It("should not schedule if requesting more resources than any instance type has", func() {
 			ExpectApplied(ctx, env.Client, nodePool)
@@ -1648,6 +2097,114 @@ var _ = Context("Scheduling", func() {
 			// second pod is much smaller in terms of resources and should get a smaller node
 			Expect(nodes[0].Labels[corev1.LabelInstanceTypeStable]).ToNot(Equal(nodes[1].Labels[corev1.LabelInstanceTypeStable]))
 		})
+		Context("Pod Ordering", func() {
+			var large, small *corev1.Pod
+			BeforeEach(func() {
+				// Only one node can be launched within the NodePool's limits and the pods don't fit on a node together, so
+				// only the pod that is scheduled first is able to schedule
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "default-instance-type"})}
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5")})
+				large = test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+					},
+				})
+				small = test.UnschedulablePod(test.PodOptions{
+					NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+					},
+				})
+				small.Spec.Priority = lo.ToPtr[int32](1000)
+			})
+			It("should schedule the largest pods first by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, small, large)
+				ExpectScheduled(ctx, env.Client, large)
+				ExpectNotScheduled(ctx, env.Client, small)
+			})
+			It("should schedule the highest priority pods first", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PodOrderingStrategy: lo.ToPtr(scheduling.PodOrderingStrategyPriority)}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, small, large)
+				ExpectScheduled(ctx, env.Client, small)
+				ExpectNotScheduled(ctx, env.Client, large)
+			})
+			It("should schedule the most constrained pods first", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PodOrderingStrategy: lo.ToPtr(scheduling.PodOrderingStrategyConstraints)}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, small, large)
+				ExpectScheduled(ctx, env.Client, small)
+				ExpectNotScheduled(ctx, env.Client, large)
+			})
+			It("should fall back to the largest pods first when pods have the same priority", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PodOrderingStrategy: lo.ToPtr(scheduling.PodOrderingStrategyPriority)}))
+				small.Spec.Priority = nil
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, small, large)
+				ExpectScheduled(ctx, env.Client, large)
+				ExpectNotScheduled(ctx, env.Client, small)
+			})
+		})
+		Context("Owner Replica Spreading", func() {
+			var opts test.PodOptions
+			BeforeEach(func() {
+				opts = test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion: "apps/v1",
+								Kind:       "ReplicaSet",
+								Name:       "test-replicaset",
+								UID:        types.UID(test.RandomName()),
+								Controller: lo.ToPtr(true),
+							},
+						},
+					},
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					},
+				}
+			})
+			It("should pack the replicas of an owner onto the same node by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := test.UnschedulablePods(opts, 3)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })...)
+				Expect(nodeNames).To(HaveLen(1))
+			})
+			It("should spread the replicas of an owner across new nodes", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{SpreadOwnerReplicas: lo.ToPtr(true)}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := test.UnschedulablePods(opts, 3)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })...)
+				Expect(nodeNames).To(HaveLen(3))
+			})
+			It("should pack pods of different owners onto the same node", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{SpreadOwnerReplicas: lo.ToPtr(true)}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := lo.Times(3, func(_ int) *corev1.Pod {
+					pod := test.UnschedulablePod(opts)
+					pod.OwnerReferences[0].UID = types.UID(test.RandomName())
+					return pod
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })...)
+				Expect(nodeNames).To(HaveLen(1))
+			})
+			It("should pack the replicas of an owner onto the same node when a new node can't be created", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{SpreadOwnerReplicas: lo.ToPtr(true)}))
+				// Only one node can be launched within the NodePool's limits
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{Name: "default-instance-type"})}
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := test.UnschedulablePods(opts, 3)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })...)
+				Expect(nodeNames).To(HaveLen(1))
+			})
+		})
 		It("should handle zero-quantity resource requests", func() {
 			ExpectApplied(ctx, env.Client, nodePool)
 			pod := test.UnschedulablePod(test.PodOptions{
@@ -2425,6 +2982,51 @@ var _ = Context("Scheduling", func() {
 	})
 
 	Describe("Existing Nodes", func() {
+		Context("Preference Policy", func() {
+			var node *corev1.Node
+			var pod *corev1.Pod
+			BeforeEach(func() {
+				node = test.Node(test.NodeOptions{
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+				})
+				ExpectApplied(ctx, env.Client, node)
+				ExpectMakeNodesInitialized(ctx, env.Client, node)
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+				pod = test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+					NodePreferences: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-2"}},
+					},
+				})
+			})
+			It("should launch a node that satisfies the pod's preferences by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+				Expect(scheduledNode.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
+			It("should relax the pod's preferences to schedule it to an existing node when preferring existing capacity", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PreferencePolicy: lo.ToPtr(scheduling.PreferencePolicyExistingCapacity)}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).To(Equal(node.Name))
+			})
+			It("should launch a node that satisfies the pod's preferences when it doesn't fit on existing nodes", func() {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PreferencePolicy: lo.ToPtr(scheduling.PreferencePolicyExistingCapacity)}))
+				pod.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("4")
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+				Expect(scheduledNode.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
+		})
 		It("should schedule a pod to an existing node unowned by Karpenter", func() {
 			node := test.Node(test.NodeOptions{
 				Allocatable: corev1.ResourceList{
@@ -2451,6 +3053,31 @@ var _ = Context("Scheduling", func() {
 			scheduledNode := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Name).To(Equal(scheduledNode.Name))
 		})
+		It("should not schedule a pod to an existing node whose kubelet stopped heartbeating", func() {
+			node := test.Node(test.NodeOptions{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10"),
+					corev1.ResourceMemory: resource.MustParse("10Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			cluster.UpdateLease(&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: node.Name, Namespace: corev1.NamespaceNodeLease},
+				Spec: coordinationv1.LeaseSpec{
+					RenewTime:            &metav1.MicroTime{Time: fakeClock.Now().Add(-time.Minute)},
+					LeaseDurationSeconds: lo.ToPtr[int32](40),
+				},
+			})
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+		})
 		It("should schedule multiple pods to an existing node unowned by Karpenter", func() {
 			node := test.Node(test.NodeOptions{
 				Allocatable: corev1.ResourceList{
@@ -2707,6 +3334,77 @@ var _ = Context("Scheduling", func() {
 			Expect(env.Client.List(ctx, &nodeList)).To(Succeed())
 			Expect(nodeList.Items).To(HaveLen(1))
 		})
+		It("should respect self pod affinity without pod binding (hostname)", func() {
+			affLabels := map[string]string{"security": "s2"}
+
+			pods := test.UnschedulablePods(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: affLabels,
+				},
+				PodRequirements: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: affLabels,
+					},
+					TopologyKey: corev1.LabelHostname,
+				}},
+			}, 2)
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pods[0])
+			var nodeList corev1.NodeList
+			Expect(env.Client.List(ctx, &nodeList)).To(Succeed())
+			for i := range nodeList.Items {
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(&nodeList.Items[i]))
+			}
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pods[1])
+			Expect(env.Client.List(ctx, &nodeList)).To(Succeed())
+			Expect(nodeList.Items).To(HaveLen(1))
+		})
+		It("should respect self pod affinity without pod binding (custom topology key)", func() {
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, v1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+					Key:      "example.com/rack",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"rack-1", "rack-2", "rack-3"},
+				},
+			})
+			affLabels := map[string]string{"security": "s2"}
+
+			// the pods must share a rack but can't share a node, so the in-flight node's rack has to be
+			// preferred for the second node
+			pods := test.UnschedulablePods(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: affLabels,
+				},
+				PodRequirements: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: affLabels,
+					},
+					TopologyKey: "example.com/rack",
+				}},
+				PodAntiRequirements: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: affLabels,
+					},
+					TopologyKey: corev1.LabelHostname,
+				}},
+			}, 2)
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pods[0])
+			var nodeList corev1.NodeList
+			Expect(env.Client.List(ctx, &nodeList)).To(Succeed())
+			Expect(nodeList.Items).To(HaveLen(1))
+			Expect(nodeList.Items[0].Labels).To(HaveKey("example.com/rack"))
+			rack := nodeList.Items[0].Labels["example.com/rack"]
+			for i := range nodeList.Items {
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(&nodeList.Items[i]))
+			}
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pods[1])
+			Expect(env.Client.List(ctx, &nodeList)).To(Succeed())
+			Expect(nodeList.Items).To(HaveLen(2))
+			for _, node := range nodeList.Items {
+				Expect(node.Labels).To(HaveKeyWithValue("example.com/rack", rack))
+			}
+		})
 	})
 
 	Describe("VolumeUsage", func() {
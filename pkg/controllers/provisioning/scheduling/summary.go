@@ -0,0 +1,82 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+)
+
+// maxPendingReasons is the number of reasons that pods were left pending for that a summary lists
+const maxPendingReasons = 3
+
+// Considered returns the number of pods that the scheduling round that produced the results considered
+func (r Results) Considered() int {
+	return lo.SumBy(r.ExistingNodes, func(n *ExistingNode) int { return len(n.Pods) }) +
+		lo.SumBy(r.NewNodeClaims, func(n *NodeClaim) int { return len(n.Pods) }) +
+		len(r.PodErrors)
+}
+
+// Summary describes the outcome of the scheduling round that produced the results: the pods that it considered, the
+// pods that it scheduled to existing capacity, the NodeClaims that it launched and the most common reasons that pods
+// were left pending for
+func (r Results) Summary() string {
+	summary := fmt.Sprintf("Considered %d pod(s), scheduled %d to existing capacity, launched %d nodeclaim(s) for %d",
+		r.Considered(),
+		lo.SumBy(r.ExistingNodes, func(n *ExistingNode) int { return len(n.Pods) }),
+		len(r.NewNodeClaims),
+		lo.SumBy(r.NewNodeClaims, func(n *NodeClaim) int { return len(n.Pods) }))
+	if len(r.NewNodeClaims) > 0 {
+		instanceTypes := lo.Uniq(lo.FlatMap(r.NewNodeClaims, func(n *NodeClaim, _ int) []string {
+			return lo.Map(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })
+		}))
+		summary += fmt.Sprintf(" with instance types %s", pretty.Slice(instanceTypes, 5))
+	}
+	if len(r.PodErrors) == 0 {
+		return summary
+	}
+	reasons := lo.CountValues(lo.MapToSlice(r.PodErrors, func(_ *corev1.Pod, err error) string { return pendingReason(err) }))
+	ordered := lo.Keys(reasons)
+	sort.Slice(ordered, func(i, j int) bool {
+		if reasons[ordered[i]] != reasons[ordered[j]] {
+			return reasons[ordered[i]] > reasons[ordered[j]]
+		}
+		return ordered[i] < ordered[j]
+	})
+	return fmt.Sprintf("%s, left %d pending (%s)", summary, len(r.PodErrors), strings.Join(lo.Map(lo.Slice(ordered, 0, maxPendingReasons), func(reason string, _ int) string {
+		return fmt.Sprintf("%s: %d", reason, reasons[reason])
+	}), ", "))
+}
+
+// pendingReason categorizes the error that a pod failed to schedule with
+func pendingReason(err error) string {
+	if len(LimitedNodePools(err)) > 0 {
+		return "nodepool limits"
+	}
+	if incompatibilities := scheduling.Incompatibilities(err); len(incompatibilities) > 0 {
+		return fmt.Sprintf("incompatible requirement %s", incompatibilities[0].Key)
+	}
+	return "other"
+}
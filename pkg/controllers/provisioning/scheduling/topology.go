@@ -20,8 +20,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/awslabs/operatorpkg/option"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -32,7 +34,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
@@ -54,17 +58,39 @@ type Topology struct {
 	// excludedPods are the pod UIDs of pods that are excluded from counting.  This is used so we can simulate
 	// moving pods to prevent them from being double counted.
 	excludedPods sets.Set[string]
-	cluster      *state.Cluster
+	// preferredAntiAffinities are the topologies of the preferred anti-affinity terms in each pod's original spec along
+	// with the weight of the term. These are used to score nodes against the terms once they've been relaxed.
+	preferredAntiAffinities map[types.UID][]weightedTopologyGroup
+	cluster                 *state.Cluster
+	// capacityTypeLabelAliases are the labels that the capacity type of existing nodes without the capacity type label
+	// is resolved from
+	capacityTypeLabelAliases []string
+	// normalizedLabels translate the deprecated labels of topology keys and existing nodes to their stable labels
+	normalizedLabels map[string]string
+	// namespaceLabels are the labels of each namespace in the cluster. These are listed from the informer cache the
+	// first time that a namespace selector is resolved, so that every term in the simulation selects against the same
+	// view of the cluster's namespaces.
+	namespaceLabels map[string]labels.Set
+}
+
+type weightedTopologyGroup struct {
+	*TopologyGroup
+	weight int32
 }
 
 func NewTopology(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, domains map[string]sets.Set[string], pods []*corev1.Pod) (*Topology, error) {
 	t := &Topology{
-		kubeClient:        kubeClient,
-		cluster:           cluster,
-		domains:           domains,
-		topologies:        map[uint64]*TopologyGroup{},
-		inverseTopologies: map[uint64]*TopologyGroup{},
-		excludedPods:      sets.New[string](),
+		kubeClient:               kubeClient,
+		cluster:                  cluster,
+		domains:                  domains,
+		topologies:               map[uint64]*TopologyGroup{},
+		inverseTopologies:        map[uint64]*TopologyGroup{},
+		excludedPods:             sets.New[string](),
+		preferredAntiAffinities:  map[types.UID][]weightedTopologyGroup{},
+		capacityTypeLabelAliases: options.FromContext(ctx).CapacityTypeLabelAliases,
+	}
+	if options.FromContext(ctx).NormalizeDeprecatedLabels {
+		t.normalizedLabels = v1.NormalizedLabels
 	}
 
 	// these are the pods that we intend to schedule, so if they are currently in the cluster we shouldn't count them for
@@ -130,9 +156,42 @@ func (t *Topology) Update(ctx context.Context, p *corev1.Pod) error {
 		}
 		tg.AddOwner(p.UID)
 	}
+	// The first update for a pod is made before any of its preferences are relaxed
+	if _, ok := t.preferredAntiAffinities[p.UID]; !ok {
+		preferred, err := t.newForPreferredAntiAffinities(ctx, p)
+		if err != nil {
+			return fmt.Errorf("updating preferred anti-affinities, %w", err)
+		}
+		t.preferredAntiAffinities[p.UID] = preferred
+	}
 	return nil
 }
 
+// PreferredAntiAffinityScore returns the total weight of the pod's relaxed preferred anti-affinity terms that are
+// satisfied by placing the pod on a node with the given requirements. Terms that haven't been relaxed aren't scored
+// since they're enforced on every node that the pod can schedule to.
+func (t *Topology) PreferredAntiAffinityScore(p *corev1.Pod, nodeRequirements scheduling.Requirements) int32 {
+	var score int32
+	for _, tg := range t.preferredAntiAffinities[p.UID] {
+		if tg.IsOwnedBy(p.UID) {
+			continue
+		}
+		nodeDomains := scheduling.NewRequirement(tg.Key, corev1.NodeSelectorOpExists)
+		if nodeRequirements.Has(tg.Key) {
+			nodeDomains = nodeRequirements.Get(tg.Key)
+		}
+		if tg.nextDomainAntiAffinity(scheduling.NewRequirement(tg.Key, corev1.NodeSelectorOpExists), nodeDomains).Len() > 0 {
+			score += tg.weight
+		}
+	}
+	return score
+}
+
+// HasRelaxedPreferredAntiAffinity returns true if any of the pod's preferred anti-affinity terms have been relaxed
+func (t *Topology) HasRelaxedPreferredAntiAffinity(p *corev1.Pod) bool {
+	return lo.ContainsBy(t.preferredAntiAffinities[p.UID], func(tg weightedTopologyGroup) bool { return !tg.IsOwnedBy(p.UID) })
+}
+
 // Record records the topology changes given that pod p schedule on a node with the given requirements
 func (t *Topology) Record(p *corev1.Pod, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) {
 	// once we've committed to a domain, we record the usage in every topology that cares about it
@@ -224,7 +283,7 @@ func (t *Topology) updateInverseAffinities(ctx context.Context) error {
 		if t.excludedPods.Has(string(pod.UID)) {
 			return true
 		}
-		if err := t.updateInverseAntiAffinity(ctx, pod, node.Labels); err != nil {
+		if err := t.updateInverseAntiAffinity(ctx, pod, t.nodeLabels(node)); err != nil {
 			errs = multierr.Append(errs, fmt.Errorf("tracking existing pod anti-affinity, %w", err))
 		}
 		return true
@@ -245,7 +304,8 @@ func (t *Topology) updateInverseAntiAffinity(ctx context.Context, pod *corev1.Po
 			return err
 		}
 
-		tg := NewTopologyGroup(TopologyTypePodAntiAffinity, term.TopologyKey, pod, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[term.TopologyKey])
+		key := t.topologyKey(term.TopologyKey)
+		tg := NewTopologyGroup(TopologyTypePodAntiAffinity, key, pod, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[key])
 
 		hash := tg.Hash()
 		if existing, ok := t.inverseTopologies[hash]; !ok {
@@ -298,7 +358,8 @@ func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
 			}
 			return fmt.Errorf("getting node %s, %w", p.Spec.NodeName, err)
 		}
-		domain, ok := node.Labels[tg.Key]
+		nodeLabels := t.nodeLabels(node)
+		domain, ok := nodeLabels[tg.Key]
 		// Kubelet sets the hostname label, but the node may not be ready yet so there is no label.  We fall back and just
 		// treat the node name as the label.  It probably is in most cases, but even if not we at least count the existence
 		// of the pods in some domain, even if not in the correct one.  This is needed to handle the case of pods with
@@ -312,18 +373,70 @@ func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
 		}
 		// nodes may or may not be considered for counting purposes for topology spread constraints depending on if they
 		// are selected by the pod's node selectors and required node affinities.  If these are unset, the node always counts.
-		if !tg.nodeFilter.Matches(node) {
+		if !tg.nodeFilter.MatchesRequirements(scheduling.NewLabelRequirements(nodeLabels)) {
 			continue
 		}
 		tg.Record(domain)
 	}
+	// kube-scheduler considers the domains of every node in the cluster that passes the node filter when computing skew,
+	// including nodes that aren't managed by Karpenter (e.g. static node groups or nodes launched by other autoscalers).
+	// Register these so that empty domains which we can't launch into still count towards the global minimum.
+	if tg.Type == TopologyTypeSpread {
+		t.cluster.ForEachNode(func(n *state.StateNode) bool {
+			if n.Node == nil {
+				return true
+			}
+			nodeLabels := t.nodeLabels(n.Node)
+			if domain, ok := nodeLabels[tg.Key]; ok && tg.nodeFilter.MatchesRequirements(scheduling.NewLabelRequirements(nodeLabels)) {
+				tg.Register(domain)
+			}
+			return true
+		})
+	}
 	return nil
 }
 
+// topologyKey returns the stable label of a topology key so that pods using a deprecated label, e.g.
+// failure-domain.beta.kubernetes.io/zone, are counted in the same domains as the nodes that are launched for them.
+func (t *Topology) topologyKey(key string) string {
+	if normalized, ok := t.normalizedLabels[key]; ok {
+		return normalized
+	}
+	return key
+}
+
+// nodeLabels returns the labels of an existing node. Nodes that only have the deprecated label of a stable label have
+// the stable label added. Nodes that were launched without the capacity type label, e.g. by other tooling, have their
+// capacity type resolved from the first of the capacity type label aliases that they have so that they're counted in
+// the capacity type domains.
+func (t *Topology) nodeLabels(node *corev1.Node) map[string]string {
+	nodeLabels := node.Labels
+	for deprecated, stable := range t.normalizedLabels {
+		if _, ok := nodeLabels[stable]; ok {
+			continue
+		}
+		if value, ok := nodeLabels[deprecated]; ok {
+			nodeLabels = lo.Assign(nodeLabels, map[string]string{stable: value})
+		}
+	}
+	if _, ok := nodeLabels[v1.CapacityTypeLabelKey]; ok {
+		return nodeLabels
+	}
+	for _, alias := range t.capacityTypeLabelAliases {
+		if value, ok := nodeLabels[alias]; ok {
+			return lo.Assign(nodeLabels, map[string]string{
+				v1.CapacityTypeLabelKey: strings.ReplaceAll(strings.ToLower(value), "_", "-"),
+			})
+		}
+	}
+	return nodeLabels
+}
+
 func (t *Topology) newForTopologies(p *corev1.Pod) []*TopologyGroup {
 	var topologyGroups []*TopologyGroup
 	for _, cs := range p.Spec.TopologySpreadConstraints {
-		topologyGroups = append(topologyGroups, NewTopologyGroup(TopologyTypeSpread, cs.TopologyKey, p, sets.New(p.Namespace), cs.LabelSelector, cs.MaxSkew, cs.MinDomains, t.domains[cs.TopologyKey]))
+		key := t.topologyKey(cs.TopologyKey)
+		topologyGroups = append(topologyGroups, NewTopologyGroup(TopologyTypeSpread, key, p, sets.New(p.Namespace), cs.LabelSelector, cs.MaxSkew, cs.MinDomains, t.domains[key]))
 	}
 	return topologyGroups
 }
@@ -360,12 +473,35 @@ func (t *Topology) newForAffinities(ctx context.Context, p *corev1.Pod) ([]*Topo
 			if err != nil {
 				return nil, err
 			}
-			topologyGroups = append(topologyGroups, NewTopologyGroup(topologyType, term.TopologyKey, p, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[term.TopologyKey]))
+			key := t.topologyKey(term.TopologyKey)
+			topologyGroups = append(topologyGroups, NewTopologyGroup(topologyType, key, p, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[key]))
 		}
 	}
 	return topologyGroups, nil
 }
 
+// newForPreferredAntiAffinities returns the topology groups of the pod's preferred anti-affinity terms. The groups are
+// shared with the pod's other topologies so that they continue to be counted after the terms are relaxed.
+func (t *Topology) newForPreferredAntiAffinities(ctx context.Context, p *corev1.Pod) ([]weightedTopologyGroup, error) {
+	if p.Spec.Affinity == nil || p.Spec.Affinity.PodAntiAffinity == nil {
+		return nil, nil
+	}
+	var topologyGroups []weightedTopologyGroup
+	for _, term := range p.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		namespaces, err := t.buildNamespaceList(ctx, p.Namespace, term.PodAffinityTerm.Namespaces, term.PodAffinityTerm.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		key := t.topologyKey(term.PodAffinityTerm.TopologyKey)
+		tg := NewTopologyGroup(TopologyTypePodAntiAffinity, key, p, namespaces, term.PodAffinityTerm.LabelSelector, math.MaxInt32, nil, t.domains[key])
+		if existing, ok := t.topologies[tg.Hash()]; ok {
+			tg = existing
+		}
+		topologyGroups = append(topologyGroups, weightedTopologyGroup{TopologyGroup: tg, weight: term.Weight})
+	}
+	return topologyGroups, nil
+}
+
 // buildNamespaceList constructs a unique list of namespaces consisting of the pod's namespace and the optional list of
 // namespaces and those selected by the namespace selector
 func (t *Topology) buildNamespaceList(ctx context.Context, namespace string, namespaces []string, selector *metav1.LabelSelector) (sets.Set[string], error) {
@@ -375,17 +511,26 @@ func (t *Topology) buildNamespaceList(ctx context.Context, namespace string, nam
 	if selector == nil {
 		return sets.New(namespaces...), nil
 	}
-	var namespaceList corev1.NamespaceList
 	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
 	if err != nil {
 		return nil, fmt.Errorf("parsing selector, %w", err)
 	}
-	if err := t.kubeClient.List(ctx, &namespaceList, &client.ListOptions{LabelSelector: labelSelector}); err != nil {
-		return nil, fmt.Errorf("listing namespaces, %w", err)
+	if t.namespaceLabels == nil {
+		var namespaceList corev1.NamespaceList
+		if err := t.kubeClient.List(ctx, &namespaceList); err != nil {
+			return nil, fmt.Errorf("listing namespaces, %w", err)
+		}
+		t.namespaceLabels = make(map[string]labels.Set, len(namespaceList.Items))
+		for _, namespace := range namespaceList.Items {
+			t.namespaceLabels[namespace.Name] = namespace.Labels
+		}
 	}
 	selected := sets.New[string]()
-	for _, namespace := range namespaceList.Items {
-		selected.Insert(namespace.Name)
+	for name, namespaceLabels := range t.namespaceLabels {
+		// An empty namespace selector selects every namespace
+		if labelSelector.Matches(namespaceLabels) {
+			selected.Insert(name)
+		}
 	}
 	selected.Insert(namespaces...)
 	return selected, nil
@@ -30,6 +30,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -104,6 +105,60 @@ var _ = Describe("Topology", func() {
 			)
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 2))
 		})
+		It("should balance pods across zones with the deprecated zone label as the topology key", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{NormalizeDeprecatedLabels: lo.ToPtr(true)}))
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelFailureDomainBetaZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
+			)
+			// launched nodes only have the stable zone label
+			topology[0].TopologyKey = corev1.LabelTopologyZone
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 2))
+		})
+		It("should count pods on existing nodes that only have the deprecated zone label", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{NormalizeDeprecatedLabels: lo.ToPtr(true)}))
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelFailureDomainBetaZone: "test-zone-1"}}})
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			pods := test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, append(pods,
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: node.Name}),
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: node.Name}),
+			)...)
+			// test-zone-1 already has two pods, so the pods are spread across the other zones
+			Expect(lo.Map(pods, func(p *corev1.Pod, _ int) string {
+				return ExpectScheduled(ctx, env.Client, p).Labels[corev1.LabelTopologyZone]
+			})).To(ConsistOf("test-zone-2", "test-zone-3"))
+		})
+		It("should not count pods on existing nodes that only have the deprecated zone label by default", func() {
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelFailureDomainBetaZone: "test-zone-1"}}})
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, append(
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 3),
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: node.Name}),
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: node.Name}),
+			)...)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1))
+		})
 		It("should balance pods across zones (match expressions)", func() {
 			topology := []corev1.TopologySpreadConstraint{{
 				TopologyKey:       corev1.LabelTopologyZone,
@@ -395,6 +450,39 @@ var _ = Describe("Topology", func() {
 			// test-zone-1 has 1 pods in it.
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 2, 2))
 		})
+		It("should not violate max-skew when unsat = do not schedule (discover domains from unmanaged nodes)", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			rr := corev1.ResourceRequirements{
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU: resource.MustParse("1.1"),
+				},
+			}
+			// a node that isn't managed by Karpenter in a zone that the NodePool can't launch into, which has no
+			// matching pods on it and which the pods can't schedule to
+			unmanaged := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "test-zone-3"}},
+				Taints:     []corev1.Taint{{Key: "example.com/static", Effect: corev1.TaintEffectNoSchedule}},
+			})
+			ExpectApplied(ctx, env.Client, unmanaged)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(unmanaged))
+
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					TopologySpreadConstraints: topology, ResourceRequirements: rr}, 5)...,
+			)
+
+			// test-zone-3 has zero matching pods and counts towards the global minimum, like it does for kube-scheduler,
+			// so only a single pod can schedule to each of test-zone-1/2
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1))
+		})
 		It("should only count running/scheduled pods with matching labels scheduled to nodes with a corresponding domain", func() {
 			wrongNamespace := test.RandomName()
 			firstNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}}})
@@ -554,6 +642,39 @@ var _ = Describe("Topology", func() {
 			)
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(4))
 		})
+		It("should spread a large group of pods across the minimum number of new nodes", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelHostname,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           3,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 20)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(3, 3, 3, 3, 3, 3, 2))
+		})
+		It("should pack other pods onto the nodes of a group that's spread across hostnames", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			spread := test.UnschedulablePods(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				PodAntiRequirements: []corev1.PodAffinityTerm{{
+					TopologyKey:   corev1.LabelHostname,
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+				}},
+			}, 10)
+			others := test.UnschedulablePods(test.PodOptions{}, 10)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, append(spread, others...)...)
+			nodeNames := sets.New[string]()
+			for _, p := range spread {
+				nodeNames.Insert(ExpectScheduled(ctx, env.Client, p).Name)
+			}
+			Expect(nodeNames).To(HaveLen(10))
+			for _, p := range others {
+				Expect(nodeNames.Has(ExpectScheduled(ctx, env.Client, p).Name)).To(BeTrue())
+			}
+		})
 		It("balance multiple deployments with hostname topology spread", func() {
 			// Issue #1425
 			spreadPod := func(appName string) test.PodOptions {
@@ -763,6 +884,32 @@ var _ = Describe("Topology", func() {
 			Expect(env.Client.List(ctx, &nodes)).To(Succeed())
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(2, 3))
 		})
+		It("should count pods on nodes with a capacity type label alias", func() {
+			alias := "eks.amazonaws.com/capacityType"
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{CapacityTypeLabelAliases: lo.ToPtr([]string{alias})}))
+			firstNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{alias: "SPOT"}}})
+			secondNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{alias: "ON_DEMAND"}}})
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       v1.CapacityTypeLabelKey,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, firstNode, secondNode)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(firstNode))
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(secondNode))
+			pods := test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, append(pods,
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: firstNode.Name}),
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: firstNode.Name}),
+				test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: secondNode.Name}),
+			)...)
+			// the spot domain already has two pods, so both pods are launched as on-demand
+			for _, pod := range pods {
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeOnDemand))
+			}
+		})
 		It("should match all pods when labelSelector is not specified", func() {
 			topology := []corev1.TopologySpreadConstraint{{
 				TopologyKey:       v1.CapacityTypeLabelKey,
@@ -1761,6 +1908,41 @@ var _ = Describe("Topology", func() {
 			}
 
 		})
+		It("should prefer in-flight nodes that satisfy more of the relaxed preferred pod anti-affinity terms", func() {
+			affLabels := map[string]string{"security": "s2"}
+			affPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: affLabels}})
+			otherPod := test.UnschedulablePod(test.PodOptions{PodAntiRequirements: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: affLabels},
+				TopologyKey:   corev1.LabelHostname,
+			}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, affPod)
+			affNode := ExpectScheduled(ctx, env.Client, affPod)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, otherPod)
+			otherNode := ExpectScheduled(ctx, env.Client, otherPod)
+			Expect(otherNode.Name).ToNot(Equal(affNode.Name))
+
+			// the term on an unknown topology key can't be satisfied, so both terms are relaxed before the pod schedules
+			pod := test.UnschedulablePod(test.PodOptions{PodAntiPreferences: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: affLabels},
+						TopologyKey:   corev1.LabelHostname,
+					},
+				},
+				{
+					Weight: 1,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: affLabels},
+						TopologyKey:   "unknown",
+					},
+				},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Name).To(Equal(otherNode.Name))
+		})
 		It("should separate nodes using simple pod anti-affinity on hostname", func() {
 			affLabels := map[string]string{"security": "s2"}
 			// pod affinity/anti-affinity are bidirectional, so run this a few times to ensure we handle it regardless
@@ -2424,6 +2606,55 @@ var _ = Describe("Topology", func() {
 			// should be scheduled on the same node due to the empty namespace selector
 			Expect(n1.Name).To(Equal(n2.Name))
 		})
+		It("should filter pod affinity topologies by namespace, matching namespace selector", func() {
+			ExpectApplied(ctx, env.Client,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected-ns", Labels: map[string]string{"team": "a"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unselected-ns", Labels: map[string]string{"team": "b"}}},
+			)
+			affLabels := map[string]string{"security": "s2"}
+
+			affPod1 := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: affLabels, Namespace: "selected-ns"},
+				NodeRequirements: []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-2"}}},
+			})
+			// otherPod matches the label selector, but its namespace isn't selected
+			otherPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: affLabels, Namespace: "unselected-ns"},
+				NodeRequirements: []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}},
+			})
+			// affPod2 will try to get scheduled with affPod1
+			affPod2 := test.UnschedulablePod(test.PodOptions{PodRequirements: []corev1.PodAffinityTerm{{
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: affLabels},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				TopologyKey:       corev1.LabelHostname,
+			}}})
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, otherPod, affPod1, affPod2)
+			n1 := ExpectScheduled(ctx, env.Client, affPod1)
+			n2 := ExpectScheduled(ctx, env.Client, affPod2)
+			ExpectScheduled(ctx, env.Client, otherPod)
+			// should be scheduled with the pod in the selected namespace, rather than the pod in the other namespace
+			Expect(n1.Name).To(Equal(n2.Name))
+			Expect(n2.Labels[corev1.LabelTopologyZone]).To(Equal("test-zone-2"))
+		})
+		It("should count existing pods in namespaces selected by a pod anti-affinity namespace selector", func() {
+			ExpectApplied(ctx, env.Client, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "anti-affinity-ns", Labels: map[string]string{"team": "a"}}})
+			affLabels := map[string]string{"security": "s2"}
+
+			existingPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: affLabels, Namespace: "anti-affinity-ns"}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, existingPod)
+			n1 := ExpectScheduled(ctx, env.Client, existingPod)
+
+			// antiAffPod can't schedule with the existing pod in the selected namespace
+			antiAffPod := test.UnschedulablePod(test.PodOptions{PodAntiRequirements: []corev1.PodAffinityTerm{{
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: affLabels},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				TopologyKey:       corev1.LabelHostname,
+			}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, antiAffPod)
+			n2 := ExpectScheduled(ctx, env.Client, antiAffPod)
+			Expect(n1.Name).ToNot(Equal(n2.Name))
+		})
 	})
 })
 
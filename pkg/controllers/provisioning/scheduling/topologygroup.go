@@ -19,6 +19,7 @@ package scheduling
 import (
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/awslabs/operatorpkg/option"
 	"github.com/mitchellh/hashstructure/v2"
@@ -279,15 +280,12 @@ func (t *TopologyGroup) nextDomainAffinity(pod *v1.Pod, podDomains *scheduling.R
 	if t.selects(pod) && (len(t.domains) == len(t.emptyDomains) || !t.anyCompatiblePodDomain(podDomains)) {
 		// First try to find a domain that is within the intersection of pod/node domains. In the case of an in-flight node
 		// this causes us to pick the domain that the existing in-flight node is already in if possible instead of picking
-		// a random viable domain.
-		intersected := podDomains.Intersection(nodeDomains)
-		for domain := range t.domains {
-			if intersected.Has(domain) {
-				options.Insert(domain)
-				break
-			}
+		// a random viable domain. A single domain is picked so that a new node with many viable domains (e.g. zones or
+		// custom topology keys) is constrained to one, and the pods that follow it are co-located in that domain.
+		if domain, ok := t.firstCompatibleDomain(podDomains.Intersection(nodeDomains)); ok {
+			options.Insert(domain)
+			return options
 		}
-
 		// and if there are no node domains, just return the first random domain that is viable
 		for domain := range t.domains {
 			if podDomains.Has(domain) {
@@ -299,6 +297,23 @@ func (t *TopologyGroup) nextDomainAffinity(pod *v1.Pod, podDomains *scheduling.R
 	return options
 }
 
+// firstCompatibleDomain returns a domain that is compatible with the requirement. Domains that are explicitly selected
+// are preferred, since an in-flight node may be in a domain that isn't part of the universe of domains, e.g. when its
+// labels were set by a NodePool that has since changed.
+func (t *TopologyGroup) firstCompatibleDomain(domains *scheduling.Requirement) (string, bool) {
+	if domains.Operator() == v1.NodeSelectorOpIn {
+		values := domains.Values()
+		sort.Strings(values)
+		return values[0], true
+	}
+	for domain := range t.domains {
+		if domains.Has(domain) {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
 // anyCompatiblePodDomain validates whether any t.domain is compatible with our podDomains
 // This is only useful in affinity checking because it tells us whether we can schedule the pod
 // to the current node since it is the first pod that exists in the TopologyGroup OR all other domains
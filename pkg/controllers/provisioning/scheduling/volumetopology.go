@@ -19,6 +19,7 @@ package scheduling
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -28,15 +29,24 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	volumeutil "sigs.k8s.io/karpenter/pkg/utils/volume"
 )
 
-func NewVolumeTopology(kubeClient client.Client) *VolumeTopology {
-	return &VolumeTopology{kubeClient: kubeClient}
+const (
+	volumeCacheHit  = "hit"
+	volumeCacheMiss = "miss"
+)
+
+func NewVolumeTopology(kubeClient client.Client, cluster *state.Cluster) *VolumeTopology {
+	return &VolumeTopology{kubeClient: kubeClient, cluster: cluster}
 }
 
 type VolumeTopology struct {
 	kubeClient client.Client
+	// cluster caches the requirements of volumes across scheduling rounds, so that the PersistentVolumes and
+	// StorageClasses of pending pods are only resolved again once they change
+	cluster *state.Cluster
 }
 
 func (v *VolumeTopology) Inject(ctx context.Context, pod *v1.Pod) error {
@@ -86,7 +96,21 @@ func (v *VolumeTopology) getRequirements(ctx context.Context, pod *v1.Pod, volum
 	if pvc == nil {
 		return nil, nil
 	}
+	start := time.Now()
+	if requirements, ok := v.cluster.VolumeRequirements(pvc); ok {
+		VolumeTopologyResolutionDurationSeconds.Observe(time.Since(start).Seconds(), map[string]string{CacheLabel: volumeCacheHit})
+		return requirements, nil
+	}
+	requirements, err := v.resolveRequirements(ctx, pod, pvc)
+	if err != nil {
+		return nil, err
+	}
+	VolumeTopologyResolutionDurationSeconds.Observe(time.Since(start).Seconds(), map[string]string{CacheLabel: volumeCacheMiss})
+	v.cluster.UpdateVolumeRequirements(pvc, requirements)
+	return requirements, nil
+}
 
+func (v *VolumeTopology) resolveRequirements(ctx context.Context, pod *v1.Pod, pvc *v1.PersistentVolumeClaim) ([]v1.NodeSelectorRequirement, error) {
 	// Persistent Volume Requirements
 	if pvc.Spec.VolumeName != "" {
 		requirements, err := v.getPersistentVolumeRequirements(ctx, pod, pvc.Spec.VolumeName)
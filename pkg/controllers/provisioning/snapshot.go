@@ -0,0 +1,330 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+)
+
+// SnapshotPath is the path on the metrics endpoint that serves scheduling snapshots
+const SnapshotPath = "/debug/scheduling/snapshot"
+
+// Snapshot is a point in time dump of the inputs that the provisioner makes scheduling decisions from. It's meant
+// to be attached to support bundles so that scheduling decisions can be reproduced by replaying the snapshot in tests.
+// Pods and pod templates are redacted to the fields that scheduling depends on, so that secrets passed to containers
+// through their environment or arguments aren't served.
+type Snapshot struct {
+	Time metav1.Time `json:"time"`
+	// Synced is false if cluster state hadn't caught up with the API server when the snapshot was taken
+	Synced    bool           `json:"synced"`
+	NodePools []*v1.NodePool `json:"nodePools,omitempty"`
+	// InstanceTypes are the instance types that the cloud provider resolved for each NodePool
+	InstanceTypes        map[string][]SnapshotInstanceType `json:"instanceTypes,omitempty"`
+	Nodes                []SnapshotNode                    `json:"nodes,omitempty"`
+	PendingPods          []*corev1.Pod                     `json:"pendingPods,omitempty"`
+	DaemonSets           []*appsv1.DaemonSet               `json:"daemonSets,omitempty"`
+	DaemonSetPods        []*corev1.Pod                     `json:"daemonSetPods,omitempty"`
+	PodDisruptionBudgets []*policyv1.PodDisruptionBudget   `json:"podDisruptionBudgets,omitempty"`
+	TopologyDomains      map[string][]string               `json:"topologyDomains,omitempty"`
+}
+
+// SnapshotNode is the cluster state of a Node and its NodeClaim. NodeClaims that are still launching don't have a Node.
+type SnapshotNode struct {
+	Node              *corev1.Node        `json:"node,omitempty"`
+	NodeClaim         *v1.NodeClaim       `json:"nodeClaim,omitempty"`
+	Registered        bool                `json:"registered"`
+	Initialized       bool                `json:"initialized"`
+	MarkedForDeletion bool                `json:"markedForDeletion"`
+	Nominated         bool                `json:"nominated"`
+	Capacity          corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable       corev1.ResourceList `json:"allocatable,omitempty"`
+	PodRequests       corev1.ResourceList `json:"podRequests,omitempty"`
+	DaemonSetRequests corev1.ResourceList `json:"daemonSetRequests,omitempty"`
+	Taints            []corev1.Taint      `json:"taints,omitempty"`
+	Pods              []*corev1.Pod       `json:"pods,omitempty"`
+}
+
+// SnapshotInstanceType is the serializable form of a cloudprovider.InstanceType
+type SnapshotInstanceType struct {
+	Name         string                                    `json:"name"`
+	Requirements []v1.NodeSelectorRequirementWithMinValues `json:"requirements,omitempty"`
+	Offerings    []SnapshotOffering                        `json:"offerings,omitempty"`
+	Capacity     corev1.ResourceList                       `json:"capacity,omitempty"`
+	Overhead     *SnapshotInstanceTypeOverhead             `json:"overhead,omitempty"`
+	// SharedResources is the capacity of a single device for each shared resource
+	SharedResources corev1.ResourceList `json:"sharedResources,omitempty"`
+	Slices          []SnapshotSlice     `json:"slices,omitempty"`
+}
+
+type SnapshotSlice struct {
+	Name     string              `json:"name"`
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+}
+
+type SnapshotOffering struct {
+	Requirements []v1.NodeSelectorRequirementWithMinValues `json:"requirements,omitempty"`
+	Price        float64                                   `json:"price"`
+	Available    bool                                      `json:"available"`
+}
+
+type SnapshotInstanceTypeOverhead struct {
+	KubeReserved      corev1.ResourceList `json:"kubeReserved,omitempty"`
+	SystemReserved    corev1.ResourceList `json:"systemReserved,omitempty"`
+	EvictionThreshold corev1.ResourceList `json:"evictionThreshold,omitempty"`
+}
+
+func NewSnapshotInstanceType(it *cloudprovider.InstanceType) SnapshotInstanceType {
+	snapshot := SnapshotInstanceType{
+		Name:         it.Name,
+		Requirements: it.Requirements.NodeSelectorRequirements(),
+		Offerings: lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) SnapshotOffering {
+			return SnapshotOffering{Requirements: o.Requirements.NodeSelectorRequirements(), Price: o.Price, Available: o.Available}
+		}),
+		Capacity:        it.Capacity,
+		SharedResources: it.SharedResources,
+		Slices: lo.Map(it.Slices, func(s cloudprovider.Slice, _ int) SnapshotSlice {
+			return SnapshotSlice{Name: s.Name, Capacity: s.Capacity}
+		}),
+	}
+	if it.Overhead != nil {
+		snapshot.Overhead = &SnapshotInstanceTypeOverhead{
+			KubeReserved:      it.Overhead.KubeReserved,
+			SystemReserved:    it.Overhead.SystemReserved,
+			EvictionThreshold: it.Overhead.EvictionThreshold,
+		}
+	}
+	return snapshot
+}
+
+// InstanceType converts the snapshot back into the instance type that it was taken from
+func (s SnapshotInstanceType) InstanceType() *cloudprovider.InstanceType {
+	it := &cloudprovider.InstanceType{
+		Name:         s.Name,
+		Requirements: scheduling.NewNodeSelectorRequirementsWithMinValues(s.Requirements...),
+		Offerings: lo.Map(s.Offerings, func(o SnapshotOffering, _ int) cloudprovider.Offering {
+			return cloudprovider.Offering{Requirements: scheduling.NewNodeSelectorRequirementsWithMinValues(o.Requirements...), Price: o.Price, Available: o.Available}
+		}),
+		Capacity:        s.Capacity,
+		Overhead:        &cloudprovider.InstanceTypeOverhead{},
+		SharedResources: s.SharedResources,
+		Slices: lo.Map(s.Slices, func(s SnapshotSlice, _ int) cloudprovider.Slice {
+			return cloudprovider.Slice{Name: s.Name, Capacity: s.Capacity}
+		}),
+	}
+	if s.Overhead != nil {
+		it.Overhead = &cloudprovider.InstanceTypeOverhead{
+			KubeReserved:      s.Overhead.KubeReserved,
+			SystemReserved:    s.Overhead.SystemReserved,
+			EvictionThreshold: s.Overhead.EvictionThreshold,
+		}
+	}
+	return it
+}
+
+// Snapshot captures the current cluster state and pending pods
+func (p *Provisioner) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Time:   metav1.NewTime(p.clock.Now()),
+		Synced: p.cluster.Synced(ctx),
+	}
+	nodePools, err := p.schedulableNodePools(ctx)
+	if err != nil && !errors.Is(err, ErrNodePoolsNotFound) {
+		return nil, err
+	}
+	snapshot.NodePools = nodePools
+	instanceTypes, domains := p.resolveInstanceTypes(ctx, nodePools)
+	snapshot.InstanceTypes = lo.MapValues(instanceTypes, func(its []*cloudprovider.InstanceType, _ string) []SnapshotInstanceType {
+		return lo.Map(its, func(it *cloudprovider.InstanceType, _ int) SnapshotInstanceType { return NewSnapshotInstanceType(it) })
+	})
+	snapshot.TopologyDomains = lo.MapValues(domains, func(values sets.Set[string], _ string) []string {
+		return sets.List(values)
+	})
+
+	for _, n := range p.cluster.Nodes() {
+		pods, err := n.Pods(ctx, p.kubeClient)
+		if err != nil {
+			return nil, fmt.Errorf("listing pods for node, %w", err)
+		}
+		snapshot.Nodes = append(snapshot.Nodes, SnapshotNode{
+			Node:              n.Node,
+			NodeClaim:         n.NodeClaim,
+			Registered:        n.Registered(),
+			Initialized:       n.Initialized(),
+			MarkedForDeletion: n.MarkedForDeletion(),
+			Nominated:         n.Nominated(),
+			Capacity:          n.Capacity(),
+			Allocatable:       n.Allocatable(),
+			PodRequests:       n.PodRequests(),
+			DaemonSetRequests: n.DaemonSetRequests(),
+			Taints:            n.Taints(),
+			Pods:              lo.Map(pods, func(p *corev1.Pod, _ int) *corev1.Pod { return redactPod(p) }),
+		})
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshotNodeName(snapshot.Nodes[i]) < snapshotNodeName(snapshot.Nodes[j])
+	})
+
+	// Pods that would be ignored by the provisioner are included so that the reason that they were ignored can be
+	// reproduced
+	pendingPods, err := nodeutils.GetProvisionablePods(ctx, p.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods, %w", err)
+	}
+	snapshot.PendingPods = lo.Map(pendingPods, func(p *corev1.Pod, _ int) *corev1.Pod { return redactPod(p) })
+	daemonSetList := &appsv1.DaemonSetList{}
+	if err = p.kubeClient.List(ctx, daemonSetList); err != nil {
+		return nil, fmt.Errorf("listing daemonsets, %w", err)
+	}
+	snapshot.DaemonSets = lo.Map(daemonSetList.Items, func(ds appsv1.DaemonSet, _ int) *appsv1.DaemonSet {
+		ds.Annotations = redactAnnotations(ds.Annotations)
+		ds.Spec.Template.Annotations = redactAnnotations(ds.Spec.Template.Annotations)
+		ds.Spec.Template.Spec = redactPodSpec(ds.Spec.Template.Spec)
+		return &ds
+	})
+	daemonSetPods, err := p.getDaemonSetPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting daemon pods, %w", err)
+	}
+	snapshot.DaemonSetPods = lo.Map(daemonSetPods, func(p *corev1.Pod, _ int) *corev1.Pod { return redactPod(p) })
+	pdbList := &policyv1.PodDisruptionBudgetList{}
+	if err = p.kubeClient.List(ctx, pdbList); err != nil {
+		return nil, fmt.Errorf("listing poddisruptionbudgets, %w", err)
+	}
+	snapshot.PodDisruptionBudgets = lo.ToSlicePtr(pdbList.Items)
+	return snapshot, nil
+}
+
+// redactPod returns a copy of the pod without the fields that may carry secrets and that scheduling doesn't depend on
+func redactPod(pod *corev1.Pod) *corev1.Pod {
+	pod = pod.DeepCopy()
+	pod.Annotations = redactAnnotations(pod.Annotations)
+	pod.Spec = redactPodSpec(pod.Spec)
+	return pod
+}
+
+// redactPodSpec keeps the fields of the containers that scheduling depends on, i.e. their resources, host ports and
+// whether init containers are sidecars, and the images that are needed to replay the pods
+func redactPodSpec(spec corev1.PodSpec) corev1.PodSpec {
+	redact := func(c corev1.Container, _ int) corev1.Container {
+		return corev1.Container{
+			Name:          c.Name,
+			Image:         c.Image,
+			Ports:         c.Ports,
+			Resources:     c.Resources,
+			ResizePolicy:  c.ResizePolicy,
+			RestartPolicy: c.RestartPolicy,
+		}
+	}
+	spec.Containers = lo.Map(spec.Containers, redact)
+	spec.InitContainers = lo.Map(spec.InitContainers, redact)
+	spec.EphemeralContainers = nil
+	return spec
+}
+
+// redactAnnotations drops the last applied configuration, since it contains the unredacted object
+func redactAnnotations(annotations map[string]string) map[string]string {
+	return lo.OmitByKeys(annotations, []string{corev1.LastAppliedConfigAnnotation})
+}
+
+func snapshotNodeName(n SnapshotNode) string {
+	if n.NodeClaim != nil {
+		return n.NodeClaim.Name
+	}
+	return n.Node.Name
+}
+
+// Objects returns the objects in the snapshot in the order that they should be applied to replay it. The resource
+// version is cleared so that the objects can be created in a different cluster.
+func (s *Snapshot) Objects() []client.Object {
+	var objects []client.Object
+	for _, np := range s.NodePools {
+		objects = append(objects, np)
+	}
+	for _, ds := range s.DaemonSets {
+		objects = append(objects, ds)
+	}
+	for _, pdb := range s.PodDisruptionBudgets {
+		objects = append(objects, pdb)
+	}
+	for _, n := range s.Nodes {
+		if n.NodeClaim != nil {
+			objects = append(objects, n.NodeClaim)
+		}
+		if n.Node != nil {
+			objects = append(objects, n.Node)
+		}
+		for _, pod := range n.Pods {
+			objects = append(objects, pod)
+		}
+	}
+	for _, pod := range s.PendingPods {
+		objects = append(objects, pod)
+	}
+	return lo.Map(objects, func(o client.Object, _ int) client.Object {
+		o = o.DeepCopyObject().(client.Object)
+		o.SetResourceVersion("")
+		o.SetManagedFields(nil)
+		return o
+	})
+}
+
+// LoadSnapshot decodes a snapshot that was served from the SnapshotPath
+func LoadSnapshot(r io.Reader) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("decoding scheduling snapshot, %w", err)
+	}
+	return snapshot, nil
+}
+
+// SnapshotHandler serves the current scheduling snapshot as JSON. Cluster state is only maintained by the leader, so
+// the snapshot should be requested from the leader.
+func (p *Provisioner) SnapshotHandler(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := p.Snapshot(ctx)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed taking scheduling snapshot")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="scheduling-snapshot.json"`)
+		if err = json.NewEncoder(w).Encode(snapshot); err != nil {
+			log.FromContext(ctx).Error(err, "failed writing scheduling snapshot")
+		}
+	})
+}
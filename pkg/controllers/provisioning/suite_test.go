@@ -19,6 +19,9 @@ package provisioning_test
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -28,6 +31,13 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -46,11 +56,16 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
 	. "sigs.k8s.io/karpenter/pkg/utils/testing"
 )
 
@@ -77,7 +92,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	cloudProvider = fake.NewCloudProvider()
 	fakeClock = clock.NewFakeClock(time.Now())
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
 	nodeController = informer.NewNodeController(env.Client, cluster)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock)
 	daemonsetController = informer.NewDaemonSetController(env.Client, cluster)
@@ -108,6 +123,7 @@ var _ = AfterEach(func() {
 	cloudProvider.Reset()
 	cluster.Reset()
 	pscheduling.IgnoredPodCount.Set(0, nil)
+	pscheduling.SchedulingGatedPodCount.Set(0, nil)
 })
 
 var _ = Describe("Provisioning", func() {
@@ -203,6 +219,81 @@ var _ = Describe("Provisioning", func() {
 			ExpectSingletonReconciled(ctx, prov)
 			wg.Wait()
 		})
+		Context("Backlog", func() {
+			BeforeEach(func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					BatchMaxDuration:          lo.ToPtr(10 * time.Second),
+					BatchIdleDuration:         lo.ToPtr(4 * time.Second),
+					BatchBacklogHighThreshold: lo.ToPtr(100),
+					BatchBacklogLowThreshold:  lo.ToPtr(10),
+				}))
+			})
+			It("should shorten the idle window when the backlog is at or above the high threshold", func() {
+				batcher := provisioning.NewBatcher[types.UID](fakeClock)
+				batcher.SetBacklog(ctx, 100)
+				Expect(batcher.Mode(ctx)).To(Equal(provisioning.BatchModeBurst))
+				ExpectMetricGaugeValue(provisioning.PendingPodBacklog, 100, nil)
+				ExpectMetricGaugeValue(provisioning.CurrentBatchMode, 1, map[string]string{"mode": "burst"})
+				ExpectMetricGaugeValue(provisioning.CurrentBatchMode, 0, map[string]string{"mode": "normal"})
+				ExpectMetricGaugeValue(provisioning.BatchWindowDuration, 1, map[string]string{"window": "idle"})
+				ExpectMetricGaugeValue(provisioning.BatchWindowDuration, 10, map[string]string{"window": "max"})
+			})
+			It("should lengthen the idle window when the backlog is at or below the low threshold", func() {
+				batcher := provisioning.NewBatcher[types.UID](fakeClock)
+				batcher.SetBacklog(ctx, 10)
+				Expect(batcher.Mode(ctx)).To(Equal(provisioning.BatchModePacking))
+				ExpectMetricGaugeValue(provisioning.CurrentBatchMode, 1, map[string]string{"mode": "packing"})
+				ExpectMetricGaugeValue(provisioning.BatchWindowDuration, 8, map[string]string{"window": "idle"})
+			})
+			It("should not lengthen the idle window past the maximum batch duration", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					BatchMaxDuration:         lo.ToPtr(6 * time.Second),
+					BatchIdleDuration:        lo.ToPtr(4 * time.Second),
+					BatchBacklogLowThreshold: lo.ToPtr(10),
+				}))
+				batcher := provisioning.NewBatcher[types.UID](fakeClock)
+				batcher.SetBacklog(ctx, 1)
+				ExpectMetricGaugeValue(provisioning.BatchWindowDuration, 6, map[string]string{"window": "idle"})
+			})
+			It("should use the configured windows between the thresholds", func() {
+				batcher := provisioning.NewBatcher[types.UID](fakeClock)
+				batcher.SetBacklog(ctx, 50)
+				Expect(batcher.Mode(ctx)).To(Equal(provisioning.BatchModeNormal))
+				ExpectMetricGaugeValue(provisioning.BatchWindowDuration, 4, map[string]string{"window": "idle"})
+			})
+			It("should use the configured windows when the thresholds are disabled", func() {
+				ctx = options.ToContext(ctx, test.Options())
+				batcher := provisioning.NewBatcher[types.UID](fakeClock)
+				batcher.SetBacklog(ctx, 0)
+				Expect(batcher.Mode(ctx)).To(Equal(provisioning.BatchModeNormal))
+			})
+			It("should record the backlog of pending pods when scheduling", func() {
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				pods := test.UnschedulablePods(test.PodOptions{}, 3)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				ExpectMetricGaugeValue(provisioning.PendingPodBacklog, 3, nil)
+				ExpectMetricGaugeValue(provisioning.CurrentBatchMode, 1, map[string]string{"mode": "packing"})
+			})
+			It("should create the NodeClaims of a round while the next batch is collected", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					BatchBacklogHighThreshold: lo.ToPtr(2),
+				}))
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				pods := test.UnschedulablePods(test.PodOptions{}, 2)
+				for _, p := range pods {
+					ExpectApplied(ctx, env.Client, p)
+					prov.Trigger(p.UID)
+				}
+				// Record the backlog so that the round is created in burst mode
+				_, err := prov.Schedule(ctx)
+				Expect(err).ToNot(HaveOccurred())
+
+				wg := sync.WaitGroup{}
+				ExpectToWait(fakeClock, &wg)
+				ExpectSingletonReconciled(ctx, prov)
+				Eventually(func() []*v1.NodeClaim { return ExpectNodeClaims(ctx, env.Client) }).ShouldNot(BeEmpty())
+			})
+		})
 	})
 	It("should provision nodes", func() {
 		ExpectApplied(ctx, env.Client, test.NodePool())
@@ -224,6 +315,461 @@ var _ = Describe("Provisioning", func() {
 		Expect(len(nodes.Items)).To(Equal(0))
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
+	It("should report NodePools that aren't ready on the pods that can't schedule", func() {
+		recorder := test.NewEventRecorder()
+		prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+		nodePool := test.NodePool()
+		nodePool.StatusConditions().SetFalse(v1.ConditionTypeNodeClassReady, "NodeClassNotFound", fmt.Sprintf("NodeClass %q not found on cluster", nodePool.Spec.Template.Spec.NodeClassRef.Name))
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(recorder.Calls("NodePoolsNotReady")).To(Equal(1))
+		Expect(recorder.DetectedEvent(fmt.Sprintf(`Ignored NodePool(s) that aren't ready, nodepool %q has NodeClassReady=False, NodeClass %q not found on cluster`, nodePool.Name, nodePool.Spec.Template.Spec.NodeClassRef.Name))).To(BeTrue())
+	})
+	It("should not provision nodes for pods with scheduling gates", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		gated := test.UnschedulablePod(test.PodOptions{SchedulingGates: []corev1.PodSchedulingGate{{Name: "example.com/gate"}}})
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gated, pod)
+		ExpectNotScheduled(ctx, env.Client, gated)
+		ExpectScheduled(ctx, env.Client, pod)
+		ExpectMetricGaugeValue(pscheduling.SchedulingGatedPodCount, 1, nil)
+
+		// Once the gates are removed, the pod is provisioned for
+		gated.Spec.SchedulingGates = nil
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gated)
+		ExpectScheduled(ctx, env.Client, gated)
+		ExpectMetricGaugeValue(pscheduling.SchedulingGatedPodCount, 0, nil)
+	})
+	It("should not provision nodes for the pods of lifecycle hooks", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		hook := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.HookLabelKey: "scanner"}}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, hook)
+		ExpectNotScheduled(ctx, env.Client, hook)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+	})
+	Context("Incompatible Requirements", func() {
+		var recorder *test.EventRecorder
+		var prov *provisioning.Provisioner
+		BeforeEach(func() {
+			recorder = test.NewEventRecorder()
+			prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			pscheduling.IncompatibleRequirementsTotal.Reset()
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.NodePool())
+		})
+		It("should report the incompatible requirements of pods that fail to schedule", func() {
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+				corev1.LabelTopologyZone: "unknown",
+				"example.com/team":       "a",
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+
+			evts := lo.Filter(recorder.Events(), func(e events.Event, _ int) bool { return e.Reason == "FailedScheduling" })
+			Expect(evts).To(HaveLen(1))
+			Expect(evts[0].Message).To(ContainSubstring(fmt.Sprintf("incompatible requirements %s (%s)", corev1.LabelTopologyZone, scheduling.IncompatibilityReasonNoIntersection)))
+			Expect(evts[0].Message).To(ContainSubstring(fmt.Sprintf("example.com/team (%s)", scheduling.IncompatibilityReasonUndefinedLabel)))
+			// the requirements are incompatible with both nodepools, but are only counted once for the pod
+			ExpectMetricCounterValue(pscheduling.IncompatibleRequirementsTotal, 1, map[string]string{
+				pscheduling.KeyLabel: corev1.LabelTopologyZone,
+				metrics.ReasonLabel:  string(scheduling.IncompatibilityReasonNoIntersection),
+			})
+			ExpectMetricCounterValue(pscheduling.IncompatibleRequirementsTotal, 1, map[string]string{
+				pscheduling.KeyLabel: "example.com/team",
+				metrics.ReasonLabel:  string(scheduling.IncompatibilityReasonUndefinedLabel),
+			})
+		})
+	})
+	Context("Provisioning Round Summary", func() {
+		var recorder *test.EventRecorder
+		var prov *provisioning.Provisioner
+		BeforeEach(func() {
+			recorder = test.NewEventRecorder()
+			prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+		})
+		It("should summarize the round on the nodepool", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(),
+				test.UnschedulablePod(),
+				test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "unknown"}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			evts := lo.Filter(recorder.Events(), func(e events.Event, _ int) bool { return e.Reason == "ProvisioningRound" })
+			Expect(evts).To(HaveLen(1))
+			Expect(evts[0].Message).To(HavePrefix("Considered 3 pod(s), scheduled 0 to existing capacity, launched 1 nodeclaim(s) for 2 with instance types "))
+			Expect(evts[0].Message).To(HaveSuffix(fmt.Sprintf("left 1 pending (incompatible requirement %s: 1)", corev1.LabelTopologyZone)))
+		})
+		It("should summarize the round on each of the nodepools", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, test.UnschedulablePod())
+			Expect(recorder.Calls("ProvisioningRound")).To(Equal(2))
+		})
+		It("should not summarize rounds without pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+			Expect(recorder.Calls("ProvisioningRound")).To(Equal(0))
+		})
+	})
+	Context("Failed Launch Retries", func() {
+		var nodePool *v1.NodePool
+		var pods []*corev1.Pod
+		failedNodeClaim := func(name string, pods ...*corev1.Pod) *v1.NodeClaim {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Labels:            map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations:       map[string]string{v1.NodeClaimTerminationReasonAnnotationKey: string(v1.TerminationReasonLaunchFailed)},
+					DeletionTimestamp: lo.ToPtr(metav1.Now()),
+				},
+				Spec: v1.NodeClaimSpec{
+					Requirements: []v1.NodeSelectorRequirementWithMinValues{
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.NodePoolLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{nodePool.Name}}},
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"default-instance-type"}}},
+					},
+				},
+			})
+			Expect(nodeclaimutils.SetSchedulingDecision(nodeClaim, nodeclaimutils.NewSchedulingDecision(pods...))).To(Succeed())
+			return nodeClaim
+		}
+		instanceTypes := func(nodeClaim *v1.NodeClaim) []string {
+			return scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(corev1.LabelInstanceTypeStable).Values()
+		}
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			pods = test.UnschedulablePods(test.PodOptions{}, 2)
+			ExpectApplied(ctx, env.Client, nodePool, pods[0], pods[1])
+		})
+		It("should retry a failed launch with the same pods and requirements", func() {
+			cluster.UpdateNodeClaim(failedNodeClaim("failed", pods...))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(instanceTypes(nodeClaims[0])).To(ConsistOf("default-instance-type"))
+			decision, err := nodeclaimutils.GetSchedulingDecision(nodeClaims[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(decision.Pods, func(p nodeclaimutils.NominatedPod, _ int) types.UID { return p.UID })).To(ConsistOf(pods[0].UID, pods[1].UID))
+		})
+		It("should re-simulate the pods once the retried launch fails", func() {
+			cluster.UpdateNodeClaim(failedNodeClaim("failed", pods...))
+			cluster.UpdateNodeClaim(failedNodeClaim("failed-retry", pods...))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(len(instanceTypes(nodeClaims[0]))).To(BeNumerically(">", 1))
+		})
+		It("should re-simulate the pods when some of the failed launch's pods aren't pending", func() {
+			cluster.UpdateNodeClaim(failedNodeClaim("failed", append(pods, test.Pod())...))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(len(instanceTypes(nodeClaims[0]))).To(BeNumerically(">", 1))
+		})
+		It("should ignore NodeClaims that weren't deleted due to a failed launch", func() {
+			nodeClaim := failedNodeClaim("failed", pods...)
+			nodeClaim.Annotations[v1.NodeClaimTerminationReasonAnnotationKey] = string(v1.TerminationReasonConsolidation)
+			cluster.UpdateNodeClaim(nodeClaim)
+			Expect(cluster.FailedLaunches()).To(BeEmpty())
+		})
+	})
+	Context("IP Family", func() {
+		BeforeEach(func() {
+			it := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "ipv6-dense",
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("16"),
+					corev1.ResourceMemory: resource.MustParse("64Gi"),
+					corev1.ResourcePods:   resource.MustParse("1"),
+				},
+			})
+			it.PodsByIPFamily = map[string]resource.Quantity{v1.IPFamilyIPv6: resource.MustParse("3")}
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{it}
+		})
+		It("should use the pods capacity of instance types for IPv4 by default", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := test.UnschedulablePods(test.PodOptions{}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+		})
+		It("should use the pods capacity of instance types for the IP family of the cluster", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{IPFamily: lo.ToPtr(v1.IPFamilyIPv6)}))
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := test.UnschedulablePods(test.PodOptions{}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should use the pods capacity of instance types for the IP family of the nodepool", func() {
+			nodePool := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1.IPFamilyAnnotationKey: v1.IPFamilyIPv6}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should use the pods capacity of instance types when there isn't one for the IP family", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{IPFamily: lo.ToPtr(v1.IPFamilyDualStack)}))
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := test.UnschedulablePods(test.PodOptions{}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+		})
+	})
+	Context("Standalone Pods", func() {
+		var recorder *test.EventRecorder
+		var prov *provisioning.Provisioner
+		BeforeEach(func() {
+			recorder = test.NewEventRecorder()
+			prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			ExpectApplied(ctx, env.Client, test.NodePool())
+		})
+		It("should provision nodes for standalone pods by default", func() {
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(recorder.Calls("StandalonePodIgnored")).To(Equal(0))
+		})
+		It("should not provision nodes for standalone pods when they're ignored", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{StandalonePodPolicy: lo.ToPtr(podutils.StandalonePodPolicyIgnore)}))
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			owned := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs.Name, UID: rs.UID}},
+			}})
+			standalone := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, owned, standalone)
+			ExpectScheduled(ctx, env.Client, owned)
+			ExpectNotScheduled(ctx, env.Client, standalone)
+			Expect(recorder.Calls("StandalonePodIgnored")).To(Equal(1))
+			ExpectMetricGaugeValue(pscheduling.IgnoredPodCount, 1, nil)
+		})
+		It("should provision nodes for standalone pods in the allowed namespaces when they're ignored", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				StandalonePodPolicy:     lo.ToPtr(podutils.StandalonePodPolicyIgnore),
+				StandalonePodNamespaces: lo.ToPtr([]string{"debug"}),
+			}))
+			ExpectApplied(ctx, env.Client, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "debug"}})
+			allowed := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: "debug"}})
+			ignored := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, allowed, ignored)
+			ExpectScheduled(ctx, env.Client, allowed)
+			ExpectNotScheduled(ctx, env.Client, ignored)
+			Expect(recorder.Calls("StandalonePodIgnored")).To(Equal(1))
+		})
+	})
+	Context("Sharding", func() {
+		It("should only consider the pods that a NodePool of the shard could launch capacity for", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{ShardNodePoolSelector: lo.ToPtr("karpenter.sh/shard=a")}))
+			owned := test.NodePool(v1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "a"}},
+				Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+					Taints: []corev1.Taint{{Key: "team", Value: "a", Effect: corev1.TaintEffectNoSchedule}},
+				}}},
+			})
+			other := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "b"}}})
+			tolerating := test.UnschedulablePod(test.PodOptions{
+				Tolerations: []corev1.Toleration{{Key: "team", Operator: corev1.TolerationOpEqual, Value: "a", Effect: corev1.TaintEffectNoSchedule}},
+			})
+			selecting := test.UnschedulablePod(test.PodOptions{
+				Tolerations:  []corev1.Toleration{{Key: "team", Operator: corev1.TolerationOpExists}},
+				NodeSelector: map[string]string{v1.NodePoolLabelKey: other.Name},
+			})
+			intolerant := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, owned, other, tolerating, selecting, intolerant)
+			pods, err := prov.GetPendingPods(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(pods, func(p *corev1.Pod, _ int) string { return p.Name })).To(ConsistOf(tolerating.Name))
+		})
+		It("should consider every pending pod when NodePools aren't sharded", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.UnschedulablePod(), test.UnschedulablePod())
+			pods, err := prov.GetPendingPods(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pods).To(HaveLen(2))
+		})
+	})
+	Context("Default Node Metadata", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				DefaultNodeLabels:      lo.ToPtr([]string{"example.com/compliance=pci", "example.com/team=platform"}),
+				DefaultNodeAnnotations: lo.ToPtr([]string{"example.com/owner=platform"}),
+				DefaultNodeTaints:      lo.ToPtr([]string{"example.com/dedicated=platform:NoSchedule", "example.com/isolated:NoExecute"}),
+			}))
+		})
+		It("should add the default metadata to launched nodes", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue("example.com/compliance", "pci"))
+			Expect(node.Labels).To(HaveKeyWithValue("example.com/team", "platform"))
+			Expect(node.Annotations).To(HaveKeyWithValue("example.com/owner", "platform"))
+			Expect(node.Spec.Taints).To(ContainElements(
+				corev1.Taint{Key: "example.com/dedicated", Value: "platform", Effect: corev1.TaintEffectNoSchedule},
+				corev1.Taint{Key: "example.com/isolated", Effect: corev1.TaintEffectNoExecute},
+			))
+		})
+		It("should prefer the metadata of the NodePool over the default metadata", func() {
+			nodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{
+				ObjectMeta: v1.ObjectMeta{
+					Labels:      map[string]string{"example.com/team": "storage"},
+					Annotations: map[string]string{"example.com/owner": "storage"},
+				},
+				Spec: v1.NodeClaimTemplateSpec{
+					Taints: []corev1.Taint{{Key: "example.com/dedicated", Value: "storage", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue("example.com/compliance", "pci"))
+			Expect(node.Labels).To(HaveKeyWithValue("example.com/team", "storage"))
+			Expect(node.Annotations).To(HaveKeyWithValue("example.com/owner", "storage"))
+			Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: "example.com/dedicated", Value: "storage", Effect: corev1.TaintEffectNoSchedule}))
+			Expect(node.Spec.Taints).ToNot(ContainElement(corev1.Taint{Key: "example.com/dedicated", Value: "platform", Effect: corev1.TaintEffectNoSchedule}))
+		})
+		It("should not schedule pods that don't tolerate the default taints", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should schedule pods that select the default labels", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{"example.com/compliance": "pci"},
+				Tolerations:  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Price Markup", func() {
+		var recorder *test.EventRecorder
+		var prov *provisioning.Provisioner
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			recorder = test.NewEventRecorder()
+			prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			offering := func(zone string, price float64) cloudprovider.Offering {
+				return cloudprovider.Offering{
+					Requirements: scheduling.NewLabelRequirements(map[string]string{
+						v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone: zone,
+					}),
+					Price:     price,
+					Available: true,
+				}
+			}
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "cheap",
+					Offerings: []cloudprovider.Offering{offering("test-zone-2", 1.0)},
+				}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "expensive",
+					Offerings: []cloudprovider.Offering{offering("test-zone-1", 2.0)},
+				}),
+			}
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+		})
+		It("should explain a markup that exceeds the threshold", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PriceMarkupThreshold: lo.ToPtr(25)}))
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "expensive"))
+			Expect(recorder.Calls("PriceMarkup")).To(Equal(1))
+			Expect(recorder.DetectedEvent(`Cheapest compatible offering costs 2.0000, 100% more than 1.0000 for instance type "cheap", because of zone (1.0000 without it)`)).To(BeTrue())
+			ExpectMetricCounterValue(pscheduling.PriceMarkupsTotal, 1, map[string]string{
+				metrics.NodePoolLabel:       nodePool.Name,
+				pscheduling.ConstraintLabel: pscheduling.PriceMarkupConstraintZone,
+			})
+		})
+		It("should not explain a markup that's within the threshold", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PriceMarkupThreshold: lo.ToPtr(150)}))
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(recorder.Calls("PriceMarkup")).To(Equal(0))
+		})
+		It("should not explain markups by default", func() {
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(recorder.Calls("PriceMarkup")).To(Equal(0))
+		})
+	})
+	Context("Preemption Advisor", func() {
+		var recorder *test.EventRecorder
+		var prov *provisioning.Provisioner
+		var victim, pending *corev1.Pod
+		BeforeEach(func() {
+			recorder = test.NewEventRecorder()
+			prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}),
+				},
+			})
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+				Status: v1.NodeClaimStatus{
+					Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+					Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+				},
+			})
+			victim = test.Pod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+			}})
+			// the pending pod doesn't fit next to the victim, and the node uses up the limits of its nodepool
+			pending = test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			}})
+			pending.Spec.Priority = lo.ToPtr[int32](1000)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, victim)
+			ExpectManualBinding(ctx, env.Client, victim, node)
+			cluster.UpdateNodeClaim(nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		})
+		It("should not recommend preemption by default", func() {
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pending)
+			ExpectNotScheduled(ctx, env.Client, pending)
+			Expect(recorder.Calls("PreemptionRecommended")).To(Equal(0))
+		})
+		It("should recommend preempting lower priority pods when limits prevent launching a node", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PreemptionAdvisor: lo.ToPtr(true)}))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pending)
+			ExpectNotScheduled(ctx, env.Client, pending)
+			Expect(recorder.Calls("PreemptionRecommended")).To(Equal(1))
+			ExpectExists(ctx, env.Client, victim)
+		})
+		It("should evict the lower priority pods when the feature gate is enabled", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				PreemptionAdvisor: lo.ToPtr(true),
+				FeatureGates:      test.FeatureGates{PreemptionAdvisorEviction: lo.ToPtr(true)},
+			}))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pending)
+			Expect(recorder.Calls("PreemptionRecommended")).To(Equal(1))
+			ExpectNotFound(ctx, env.Client, victim)
+		})
+		It("should not recommend preempting pods with an equal or higher priority", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PreemptionAdvisor: lo.ToPtr(true)}))
+			pending.Spec.Priority = nil
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pending)
+			ExpectNotScheduled(ctx, env.Client, pending)
+			Expect(recorder.Calls("PreemptionRecommended")).To(Equal(0))
+		})
+		It("should not recommend preempting pods that can't be disrupted", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{PreemptionAdvisor: lo.ToPtr(true)}))
+			victim.Annotations = lo.Assign(victim.Annotations, map[string]string{v1.DoNotDisruptAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, victim)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pending)
+			ExpectNotScheduled(ctx, env.Client, pending)
+			Expect(recorder.Calls("PreemptionRecommended")).To(Equal(0))
+		})
+	})
 	It("should provision nodes for pods with supported node selectors", func() {
 		nodePool := test.NodePool()
 		schedulable := []*corev1.Pod{
@@ -319,6 +865,105 @@ var _ = Describe("Provisioning", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 		}
 	})
+	It("should pack pods onto the devices of shared resources", func() {
+		gpuMemory := corev1.ResourceName("example.com/gpu-memory")
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "shared-gpu",
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("16"),
+					corev1.ResourceMemory: resource.MustParse("64Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+					gpuMemory:             resource.MustParse("16Gi"),
+				},
+				// Two devices with 8Gi of memory each
+				SharedResources: corev1.ResourceList{gpuMemory: resource.MustParse("8Gi")},
+			}),
+		}
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		gpuPod := func(memory string) *corev1.Pod {
+			return test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{gpuMemory: resource.MustParse(memory)},
+					Limits:   corev1.ResourceList{gpuMemory: resource.MustParse(memory)},
+				},
+			})
+		}
+		// Only two of the pods fit onto the devices of a node, even though all three fit into its total capacity
+		pods := []*corev1.Pod{gpuPod("5Gi"), gpuPod("5Gi"), gpuPod("5Gi")}
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+		nodeNames := sets.New[string]()
+		for _, pod := range pods {
+			nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+		}
+		Expect(nodeNames).To(HaveLen(2))
+
+		// The node with a single pod still has a free device
+		pod := gpuPod("4Gi")
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		Expect(nodeNames.Has(ExpectScheduled(ctx, env.Client, pod).Name)).To(BeTrue())
+
+		// No device can satisfy the request
+		pod = gpuPod("9Gi")
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	Context("Node Slicing", func() {
+		var slicePod func(cpu string) *corev1.Pod
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "sliced",
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("16"),
+						corev1.ResourceMemory: resource.MustParse("64Gi"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+					// Two dedicated CPU pools with 6 CPUs each
+					Slices: []cloudprovider.Slice{
+						{Name: "pool-a", Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("6")}},
+						{Name: "pool-b", Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("6")}},
+					},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			slicePod = func(cpu string) *corev1.Pod {
+				return test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+				})
+			}
+		})
+		It("should pack pods onto the slices of a node", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{NodeSlicing: lo.ToPtr(true)}}))
+			// Only two of the pods fit onto the slices of a node, even though all three fit into its total capacity
+			pods := []*corev1.Pod{slicePod("4"), slicePod("4"), slicePod("4")}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeNames := sets.New[string]()
+			for _, pod := range pods {
+				nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+			}
+			Expect(nodeNames).To(HaveLen(2))
+
+			// The node with a single pod still has a free slice
+			pod := slicePod("2")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			Expect(nodeNames.Has(ExpectScheduled(ctx, env.Client, pod).Name)).To(BeTrue())
+
+			// No slice can satisfy the request
+			pod = slicePod("8")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should ignore slices when node slicing is disabled", func() {
+			pods := []*corev1.Pod{slicePod("4"), slicePod("4"), slicePod("4")}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeNames := sets.New[string]()
+			for _, pod := range pods {
+				nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+			}
+			Expect(nodeNames).To(HaveLen(1))
+		})
+	})
 	It("should provision multiple nodes when maxPods is set", func() {
 		// Kubelet is actually not observed here, the scheduler is relying on the
 		// pods resource value which is statically set in the fake cloudprovider
@@ -421,6 +1066,56 @@ var _ = Describe("Provisioning", func() {
 			Expect(n.Node.Name).ToNot(Equal(node.Name))
 		}
 	})
+	It("should set the nominated node name of pods that will schedule to a registered node", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{NominatedNodeName: lo.ToPtr(true)}}))
+		nodePool := test.NodePool()
+		its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).To(BeNil())
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: its[0].Name,
+				},
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("10"),
+				corev1.ResourcePods: resource.MustParse("110"),
+			},
+		})
+		ExpectApplied(ctx, env.Client, node, nodePool)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		pod := test.UnschedulablePod()
+		bindings := ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		Expect(bindings).To(BeEmpty())
+		pod = ExpectExists(ctx, env.Client, pod)
+		Expect(pod.Status.NominatedNodeName).To(Equal(node.Name))
+	})
+	It("should not set the nominated node name of pods when the feature gate is disabled", func() {
+		nodePool := test.NodePool()
+		its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).To(BeNil())
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: its[0].Name,
+				},
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("10"),
+				corev1.ResourcePods: resource.MustParse("110"),
+			},
+		})
+		ExpectApplied(ctx, env.Client, node, nodePool)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		pod := test.UnschedulablePod()
+		ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		pod = ExpectExists(ctx, env.Client, pod)
+		Expect(pod.Status.NominatedNodeName).To(BeEmpty())
+	})
 	It("should schedule based on the max resource requests of containers and initContainers with sidecar containers when initcontainer comes first", func() {
 		if env.Version.Minor() < 29 {
 			Skip("Native Sidecar containers is only on by default starting in K8s version >= 1.29.x")
@@ -718,6 +1413,246 @@ var _ = Describe("Provisioning", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 	})
+	Context("Zone Limits", func() {
+		It("should not launch into a zone whose limits would be exceeded", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					ZoneLimits: []v1.ZoneLimit{{Zone: "test-zone-1", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("0")}}},
+				},
+			}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelTopologyZone]).ToNot(Equal("test-zone-1"))
+		})
+		It("should not schedule if the only zone that the pod can schedule to would exceed its limits", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					ZoneLimits: []v1.ZoneLimit{{Zone: "test-zone-1", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("1")}}},
+				},
+			}))
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.75")},
+				},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only launch instance types that fit into the limits of the zone that the pod requires", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					ZoneLimits: []v1.ZoneLimit{{Zone: "test-zone-1", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("2")}}},
+				},
+			}))
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelTopologyZone]).To(Equal("test-zone-1"))
+			Expect(node.Status.Capacity.Cpu().Cmp(resource.MustParse("2"))).To(BeNumerically("<=", 0))
+		})
+		It("should not schedule to a zone after a scheduling round if its limits would be exceeded", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					ZoneLimits: []v1.ZoneLimit{{Zone: "test-zone-1", Limits: v1.Limits{corev1.ResourceCPU: resource.MustParse("2")}}},
+				},
+			}))
+			opts := test.PodOptions{
+				NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+				ResourceRequirements: corev1.ResourceRequirements{
+					// requires a 2 CPU node, but leaves room for overhead
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.75")},
+				},
+			}
+			pod := test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			pod = test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Capacity Pools", func() {
+		BeforeEach(func() {
+			cloudProvider.CapacityPools = []*cloudprovider.CapacityPool{
+				{Name: "subnet-1", Zone: "test-zone-1", Remaining: 0, PerNode: 1, PerPod: 1},
+				{Name: "subnet-2", Zone: "test-zone-2", Remaining: 3, PerNode: 1, PerPod: 1},
+				{Name: "subnet-3", Zone: "test-zone-3", Remaining: 0, PerNode: 1, PerPod: 1},
+			}
+		})
+		It("should not launch into zones whose capacity pools are exhausted", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelTopologyZone]).To(Equal("test-zone-2"))
+		})
+		It("should not schedule pods once the capacity pools of every zone would be exhausted", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod(), test.UnschedulablePod()}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			// the node and two of the pods consume all of the remaining capacity of the pool
+			Expect(lo.CountBy(pods, func(p *corev1.Pod) bool {
+				return ExpectPodExists(ctx, env.Client, p.Name, p.Namespace).Spec.NodeName != ""
+			})).To(Equal(2))
+		})
+		It("should not count pods that use the host network against capacity pools", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			hostNetworkPod := test.UnschedulablePod()
+			hostNetworkPod.Spec.HostNetwork = true
+			pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod(), hostNetworkPod}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			for _, p := range pods {
+				ExpectScheduled(ctx, env.Client, p)
+			}
+		})
+		It("should not schedule pods that require a zone whose capacity pool is exhausted", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Max Nodes", func() {
+		var opts test.PodOptions
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxNodes: lo.ToPtr(2)}))
+			provisioning.NodeClaimsRefusedTotal.Reset()
+			// prevent these pods from scheduling on the same node
+			opts = test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "foo"},
+				},
+				PodAntiRequirements: []corev1.PodAffinityTerm{
+					{
+						TopologyKey: corev1.LabelHostname,
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": "foo",
+							},
+						},
+					},
+				},
+			}
+		})
+		It("should not launch more nodes than the maximum across all nodepools", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.NodePool())
+			pods := []*corev1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			scheduled := lo.CountBy(pods, func(p *corev1.Pod) bool {
+				return ExpectPodExists(ctx, env.Client, p.Name, p.Namespace).Spec.NodeName != ""
+			})
+			Expect(scheduled).To(Equal(2))
+		})
+		It("should count existing nodes towards the maximum", func() {
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			cluster.UpdateNodeClaim(nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+			pods := []*corev1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			ExpectMetricCounterValue(provisioning.NodeClaimsRefusedTotal, 1, map[string]string{
+				metrics.NodePoolLabel: nodePool.Name,
+				metrics.ReasonLabel:   "max_nodes",
+			})
+		})
+		It("should count the nodeclaims of other shards towards the maximum", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{MaxNodes: lo.ToPtr(2), ShardNodePoolSelector: lo.ToPtr("karpenter.sh/shard=a")}))
+			owned := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "a"}}})
+			other := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "b"}}})
+			ExpectApplied(ctx, env.Client, owned, other)
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: other.Name}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			pods := []*corev1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			ExpectMetricCounterValue(provisioning.NodeClaimsRefusedTotal, 1, map[string]string{
+				metrics.NodePoolLabel: owned.Name,
+				metrics.ReasonLabel:   "max_nodes",
+			})
+		})
+		It("should publish warning events on the nodepool and the pods of refused nodeclaims", func() {
+			recorder := test.NewEventRecorder()
+			prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := []*corev1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			evts := lo.Filter(recorder.Events(), func(e events.Event, _ int) bool { return e.Reason == "MaxNodesReached" })
+			Expect(evts).To(HaveLen(2))
+			Expect(lo.Map(evts, func(e events.Event, _ int) string { return e.Type })).To(ConsistOf(corev1.EventTypeWarning, corev1.EventTypeWarning))
+			Expect(lo.Map(evts, func(e events.Event, _ int) string { return e.InvolvedObject.(client.Object).GetName() })).To(ContainElement(nodePool.Name))
+			Expect(recorder.DetectedEvent("Refused to launch a nodeclaim for pod, reached the maximum of 2 nodes across all nodepools")).To(BeTrue())
+		})
+		It("should not limit the number of nodes when the maximum is zero", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxNodes: lo.ToPtr(0)}))
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+		})
+	})
+	Context("Chaos", func() {
+		It("should not over-allocate nodes or violate pod constraints when the scheduling rounds are randomized", func() {
+			// The seed is randomized on every run, and can be reproduced with --seed
+			r := rand.New(rand.NewSource(GinkgoRandomSeed()))
+			prov := provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, provisioning.WithChaos(rand.New(rand.NewSource(GinkgoRandomSeed()))))
+			daemonSet := test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")}},
+			}})
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "chaos", Effect: corev1.TaintEffectNoSchedule}},
+			}}}}), daemonSet)
+			for round := 0; round < 5; round++ {
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, lo.Times(10, func(_ int) *corev1.Pod {
+					opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(fmt.Sprintf("%dm", 50*(r.Intn(20)+1))),
+						corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dMi", 64*(r.Intn(16)+1))),
+					}}}
+					if r.Intn(2) == 0 {
+						opts.NodeSelector = map[string]string{corev1.LabelTopologyZone: fmt.Sprintf("test-zone-%d", r.Intn(3)+1)}
+					}
+					if r.Intn(2) == 0 {
+						opts.Tolerations = []corev1.Toleration{{Key: "chaos", Operator: corev1.TolerationOpExists}}
+					}
+					return test.UnschedulablePod(opts)
+				})...)
+			}
+			podList := &corev1.PodList{}
+			Expect(env.Client.List(ctx, podList)).To(Succeed())
+			nodes := ExpectNodes(ctx, env.Client)
+			Expect(nodes).ToNot(BeEmpty())
+			for _, node := range nodes {
+				pods := lo.FilterMap(podList.Items, func(p corev1.Pod, _ int) (*corev1.Pod, bool) { return &p, p.Spec.NodeName == node.Name })
+				// No node may be over-allocated, including the daemonset that schedules to it
+				requests := resources.RequestsForPods(pods...)
+				if scheduling.Taints(node.Spec.Taints).Tolerates(&corev1.Pod{Spec: daemonSet.Spec.Template.Spec}) == nil {
+					requests = resources.Merge(requests, resources.RequestsForPods(&corev1.Pod{Spec: daemonSet.Spec.Template.Spec}))
+				}
+				Expect(resources.Fits(requests, instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Allocatable())).To(BeTrue(), "node %s is over-allocated", node.Name)
+				// All of the constraints of the pods must be satisfied by their node
+				for _, pod := range pods {
+					Expect(scheduling.Taints(node.Spec.Taints).Tolerates(pod)).To(Succeed())
+					Expect(scheduling.NewLabelRequirements(node.Labels).Compatible(scheduling.NewStrictPodRequirements(pod), scheduling.AllowUndefinedWellKnownLabels)).To(Succeed())
+				}
+			}
+		})
+	})
 	Context("Daemonsets", func() {
 		It("should account for daemonsets", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
@@ -780,6 +1715,48 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should not schedule pods with host ports that conflict with daemonsets to new nodes", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{HostPorts: []int32{8080}}},
+			))
+			pod := test.UnschedulablePod(test.PodOptions{HostPorts: []int32{8080}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not schedule pods with host ports that conflict with any of several daemonsets to new nodes", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(),
+				test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{HostPorts: []int32{8080}}}),
+				test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{HostPorts: []int32{9090}}}),
+			)
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(test.PodOptions{HostPorts: []int32{8080}}),
+				test.UnschedulablePod(test.PodOptions{HostPorts: []int32{9090}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			for _, pod := range pods {
+				ExpectNotScheduled(ctx, env.Client, pod)
+			}
+		})
+		It("should schedule pods with host ports that conflict with daemonsets which don't schedule to the nodepool", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							Taints: []corev1.Taint{{Key: "foo.com/taint", Effect: corev1.TaintEffectNoSchedule}},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{HostPorts: []int32{8080}}},
+			))
+			pod := test.UnschedulablePod(test.PodOptions{
+				HostPorts:   []int32{8080},
+				Tolerations: []corev1.Toleration{{Key: "foo.com/taint", Operator: corev1.TolerationOpExists}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
 		It("should account for overhead using daemonset pod spec instead of daemonset spec", func() {
 			nodePool := test.NodePool()
 			// Create a daemonset with large resource requests
@@ -1530,6 +2507,91 @@ var _ = Describe("Provisioning", func() {
 			))
 			ExpectScheduled(ctx, env.Client, pod)
 		})
+		It("should create a nodeclaim request recording the pods that it was launched for", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}}),
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			decision, err := nodeclaimutils.GetSchedulingDecision(cloudProvider.CreateCalls[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decision).ToNot(BeNil())
+			Expect(decision.TotalPods).To(Equal(2))
+			Expect(lo.Map(decision.Pods, func(p nodeclaimutils.NominatedPod, _ int) types.UID { return p.UID })).To(ConsistOf(pods[0].UID, pods[1].UID))
+			for _, p := range decision.Pods {
+				Expect(p.Requests.Cpu().String()).To(Equal("1"))
+			}
+		})
+		It("should create nodeclaim requests with distinct idempotency keys", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}}),
+				test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-2"}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			Expect(cloudProvider.CreateCalls).To(HaveLen(2))
+			keys := lo.Map(cloudProvider.CreateCalls, func(nc *v1.NodeClaim, _ int) string {
+				return nc.Annotations[v1.NodeClaimIdempotencyKeyAnnotationKey]
+			})
+			Expect(keys).ToNot(ContainElement(BeEmpty()))
+			Expect(keys[0]).ToNot(Equal(keys[1]))
+		})
+		It("should derive the idempotency key from the nodepool and the pods", func() {
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(cloudProvider.CreateCalls[0].Annotations).To(HaveKeyWithValue(v1.NodeClaimIdempotencyKeyAnnotationKey, nodeclaimutils.IdempotencyKey(nodePool.Name, pod)))
+		})
+		It("should adopt an in-flight nodeclaim with the same idempotency key", func() {
+			nodePool := test.NodePool()
+			pod := test.UnschedulablePod()
+			// Simulate a previous leader creating the NodeClaim for the pod before failing over
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey: nodePool.Name,
+					},
+					Annotations: map[string]string{
+						v1.NodeClaimIdempotencyKeyAnnotationKey: nodeclaimutils.IdempotencyKey(nodePool.Name, pod),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Name).To(Equal(nodeClaim.Name))
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should not adopt a deleting nodeclaim with the same idempotency key", func() {
+			nodePool := test.NodePool()
+			pod := test.UnschedulablePod()
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey: nodePool.Name,
+					},
+					Annotations: map[string]string{
+						v1.NodeClaimIdempotencyKeyAnnotationKey: nodeclaimutils.IdempotencyKey(nodePool.Name, pod),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectDeletionTimestampSet(ctx, env.Client, nodeClaim)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(2))
+			ExpectScheduled(ctx, env.Client, pod)
+		})
 		It("should create a nodeclaim request propagating the nodeClass reference", func() {
 			nodePool := test.NodePool(v1.NodePool{
 				Spec: v1.NodePoolSpec{
@@ -1965,6 +3027,50 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should cache volume requirements across scheduling rounds", func() {
+			pscheduling.VolumeTopologyResolutionDurationSeconds.Reset()
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			ExpectApplied(ctx, env.Client, test.NodePool(), storageClass, persistentVolumeClaim, persistentVolume)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			requirements, ok := cluster.VolumeRequirements(persistentVolumeClaim)
+			Expect(ok).To(BeTrue())
+			Expect(requirements).To(ConsistOf(corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-3"}}))
+			ExpectMetricHistogramSampleCountValue("karpenter_scheduler_volume_topology_resolution_duration_seconds", 1, map[string]string{"cache": "miss"})
+
+			// The volume is only resolved once, even though the pod is scheduled again
+			pod = test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-3"))
+			ExpectMetricHistogramSampleCountValue("karpenter_scheduler_volume_topology_resolution_duration_seconds", 1, map[string]string{"cache": "miss"})
+		})
+		It("should resolve volume requirements again once the pvc is bound", func() {
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: &storageClass.Name})
+			ExpectApplied(ctx, env.Client, test.NodePool(), storageClass, persistentVolumeClaim)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-2"}})
+			persistentVolumeClaim.Spec.VolumeName = persistentVolume.Name
+			ExpectApplied(ctx, env.Client, persistentVolumeClaim, persistentVolume)
+			pod = test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+		})
 		It("should not relax an added volume topology zone node-selector away", func() {
 			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
 			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
@@ -2128,6 +3234,62 @@ var _ = Describe("Provisioning", func() {
 				node := ExpectScheduled(ctx, env.Client, pod)
 				Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: "foo", Value: "bar", Effect: corev1.TaintEffectPreferNoSchedule}))
 			})
+			It("should not relax preferences of pods that disable preference relaxation", func() {
+				recorder := test.NewEventRecorder()
+				prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+				pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					v1.PreferenceRelaxationAnnotationKey: v1.PreferenceRelaxationDisabled,
+				}}})
+				pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+					{
+						Weight: 1, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"invalid"}},
+						}},
+					},
+				}}}
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+
+				evts := lo.Filter(recorder.Events(), func(e events.Event, _ int) bool { return e.Reason == "FailedScheduling" })
+				Expect(evts).To(HaveLen(1))
+				Expect(evts[0].Message).To(ContainSubstring(fmt.Sprintf("preference relaxation is disabled (%s=%s)", v1.PreferenceRelaxationAnnotationKey, v1.PreferenceRelaxationDisabled)))
+			})
+			It("should try each required node affinity term of pods that disable preference relaxation", func() {
+				pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					v1.PreferenceRelaxationAnnotationKey: v1.PreferenceRelaxationDisabled,
+				}}})
+				pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"invalid"}},
+						}},
+						{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-2"}},
+						}},
+					},
+				}}}
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
+			It("should schedule pods that disable preference relaxation if their preferences can be satisfied", func() {
+				pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					v1.PreferenceRelaxationAnnotationKey: v1.PreferenceRelaxationDisabled,
+				}}})
+				pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+					{
+						Weight: 1, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-2"}},
+						}},
+					},
+				}}}
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			})
 		})
 	})
 	Context("Multiple NodePools", func() {
@@ -2201,6 +3363,186 @@ var _ = Describe("Provisioning", func() {
 				node := ExpectScheduled(ctx, env.Client, pod)
 				Expect(node.Labels[v1.NodePoolLabelKey]).To(Equal(targetedNodePool.Name))
 			})
+			Context("Degraded", func() {
+				var recorder *test.EventRecorder
+				var prov *provisioning.Provisioner
+				BeforeEach(func() {
+					recorder = test.NewEventRecorder()
+					prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+				})
+				It("should schedule to healthy nodepools before degraded nodepools with a higher priority", func() {
+					degraded := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(100))}})
+					degraded.StatusConditions().SetTrueWithReason(v1.ConditionTypeDegraded, "RecentLaunchFailures", "launches failed")
+					healthy := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(20))}})
+					ExpectApplied(ctx, env.Client, degraded, healthy, test.NodePool())
+					pod := test.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					node := ExpectScheduled(ctx, env.Client, pod)
+					Expect(node.Labels[v1.NodePoolLabelKey]).To(Equal(healthy.Name))
+
+					evts := lo.Filter(recorder.Events(), func(e events.Event, _ int) bool { return e.Reason == "DegradedFallback" })
+					Expect(evts).To(HaveLen(1))
+					Expect(evts[0].InvolvedObject.(*v1.NodePool).Name).To(Equal(degraded.Name))
+					Expect(evts[0].Message).To(ContainSubstring(healthy.Name))
+				})
+				It("should schedule to degraded nodepools when pods don't fit healthy nodepools", func() {
+					degraded := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(100))}})
+					degraded.StatusConditions().SetTrueWithReason(v1.ConditionTypeDegraded, "RecentLaunchFailures", "launches failed")
+					ExpectApplied(ctx, env.Client, degraded, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(20))}}))
+					pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.NodePoolLabelKey: degraded.Name}})
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					node := ExpectScheduled(ctx, env.Client, pod)
+					Expect(node.Labels[v1.NodePoolLabelKey]).To(Equal(degraded.Name))
+					Expect(recorder.Calls("DegradedFallback")).To(Equal(0))
+				})
+				It("should not publish a fallback event when the degraded nodepool has a lower priority", func() {
+					degraded := test.NodePool()
+					degraded.StatusConditions().SetTrueWithReason(v1.ConditionTypeDegraded, "RecentLaunchFailures", "launches failed")
+					ExpectApplied(ctx, env.Client, degraded, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(20))}}))
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, test.UnschedulablePod())
+					Expect(recorder.Calls("DegradedFallback")).To(Equal(0))
+				})
+			})
+		})
+	})
+	Context("Tracing", func() {
+		var spans *tracetest.SpanRecorder
+		BeforeEach(func() {
+			spans = tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		})
+		AfterEach(func() {
+			otel.SetTracerProvider(noop.NewTracerProvider())
+		})
+		It("should trace the scheduling and creation of nodeclaims within a provisioning round", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{test.UnschedulablePod(), test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"invalid-key": "invalid-value"}})}
+			ctx, span := tracing.Tracer().Start(ctx, "provisioner.provision")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			span.End()
+
+			ended := lo.SliceToMap(spans.Ended(), func(s sdktrace.ReadOnlySpan) (string, sdktrace.ReadOnlySpan) { return s.Name(), s })
+			Expect(ended).To(HaveKey("provisioner.schedule"))
+			Expect(ended["provisioner.schedule"].Parent().SpanID()).To(Equal(span.SpanContext().SpanID()))
+			Expect(ended["provisioner.schedule"].Attributes()).To(ContainElements(
+				attribute.Int("karpenter.pods.pending", 2),
+				attribute.Int("karpenter.nodeclaims.new", 1),
+				attribute.Int("karpenter.pods.unschedulable", 1),
+			))
+			Expect(ended).To(HaveKey("scheduler.solve"))
+			Expect(ended).To(HaveKey("provisioner.createNodeClaim"))
+			Expect(lo.CountBy(spans.Ended(), func(s sdktrace.ReadOnlySpan) bool {
+				return s.Name() == "scheduler.schedulePod" && s.Status().Code == codes.Error
+			})).To(BeNumerically(">", 0))
+		})
+		It("should not start traces for scheduling outside of a provisioning round", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, test.UnschedulablePod())
+			Expect(spans.Ended()).To(BeEmpty())
+		})
+	})
+	Context("Scheduling Snapshot", func() {
+		var nodePool *v1.NodePool
+		var nodeClaim *v1.NodeClaim
+		var node *corev1.Node
+		var daemonset *appsv1.DaemonSet
+		var boundPod, pendingPod *corev1.Pod
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+				Status: v1.NodeClaimStatus{
+					Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+					Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+				},
+			})
+			node.Spec.Taints = []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectPreferNoSchedule}}
+			daemonset = test.DaemonSet()
+			boundPod = test.Pod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}})
+			pendingPod = test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, daemonset, boundPod, pendingPod)
+			ExpectManualBinding(ctx, env.Client, boundPod, node)
+			cluster.UpdateNodeClaim(nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			ExpectReconcileSucceeded(ctx, daemonsetController, client.ObjectKeyFromObject(daemonset))
+		})
+		It("should capture the cluster state and pending pods", func() {
+			snapshot, err := prov.Snapshot(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(snapshot.NodePools).To(HaveLen(1))
+			Expect(snapshot.NodePools[0].Name).To(Equal(nodePool.Name))
+			Expect(snapshot.InstanceTypes).To(HaveKeyWithValue(nodePool.Name, Not(BeEmpty())))
+			Expect(snapshot.TopologyDomains).To(HaveKeyWithValue(corev1.LabelTopologyZone, ContainElements("test-zone-1", "test-zone-2", "test-zone-3")))
+
+			Expect(snapshot.Nodes).To(HaveLen(1))
+			n := snapshot.Nodes[0]
+			Expect(n.Node.Name).To(Equal(node.Name))
+			Expect(n.NodeClaim.Name).To(Equal(nodeClaim.Name))
+			Expect(n.Registered).To(BeTrue())
+			Expect(n.Taints).To(ContainElement(node.Spec.Taints[0]))
+			Expect(n.Allocatable.Cpu().String()).To(Equal("4"))
+			Expect(n.PodRequests.Cpu().String()).To(Equal("1"))
+			Expect(n.Pods).To(HaveLen(1))
+			Expect(n.Pods[0].Name).To(Equal(boundPod.Name))
+
+			Expect(lo.Map(snapshot.PendingPods, func(p *corev1.Pod, _ int) string { return p.Name })).To(ConsistOf(pendingPod.Name))
+			Expect(snapshot.DaemonSets).To(HaveLen(1))
+			Expect(snapshot.DaemonSetPods).To(HaveLen(1))
+		})
+		It("should redact the fields of pods that may carry secrets", func() {
+			secretPod := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta:           metav1.ObjectMeta{Annotations: map[string]string{corev1.LastAppliedConfigAnnotation: "secret"}},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			})
+			secretPod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "PASSWORD", Value: "secret"}}
+			secretPod.Spec.Containers[0].Args = []string{"--password=secret"}
+			ExpectApplied(ctx, env.Client, secretPod)
+
+			snapshot, err := prov.Snapshot(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			pod, ok := lo.Find(snapshot.PendingPods, func(p *corev1.Pod) bool { return p.Name == secretPod.Name })
+			Expect(ok).To(BeTrue())
+			Expect(pod.Annotations).ToNot(HaveKey(corev1.LastAppliedConfigAnnotation))
+			Expect(pod.Spec.Containers[0].Env).To(BeEmpty())
+			Expect(pod.Spec.Containers[0].Args).To(BeEmpty())
+			Expect(pod.Spec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("1"))
+		})
+		It("should include NodeClaims that haven't launched a node yet", func() {
+			inflight := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, inflight)
+			cluster.UpdateNodeClaim(inflight)
+
+			snapshot, err := prov.Snapshot(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			n, ok := lo.Find(snapshot.Nodes, func(n provisioning.SnapshotNode) bool { return n.NodeClaim.Name == inflight.Name })
+			Expect(ok).To(BeTrue())
+			Expect(n.Node).To(BeNil())
+			Expect(n.Registered).To(BeFalse())
+		})
+		It("should serve a snapshot that can be replayed", func() {
+			recorder := httptest.NewRecorder()
+			prov.SnapshotHandler(ctx).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, provisioning.SnapshotPath, nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			snapshot, err := provisioning.LoadSnapshot(recorder.Body)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Replay the snapshot against an empty cluster
+			ExpectCleanedUp(ctx, env.Client)
+			cluster.Reset()
+			objects := snapshot.Objects()
+			ExpectApplied(ctx, env.Client, objects...)
+			for _, o := range objects {
+				Expect(o.GetUID()).ToNot(BeEmpty())
+			}
+			pods := lo.FilterMap(objects, func(o client.Object, _ int) (*corev1.Pod, bool) {
+				p, ok := o.(*corev1.Pod)
+				return p, ok && p.Name == pendingPod.Name
+			})
+			Expect(pods).To(HaveLen(1))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			ExpectScheduled(ctx, env.Client, pods[0])
 		})
 	})
 })
@@ -26,6 +26,7 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -39,6 +40,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
@@ -48,12 +50,15 @@ import (
 type Cluster struct {
 	kubeClient                client.Client
 	cloudProvider             cloudprovider.CloudProvider
+	recorder                  events.Recorder
 	clock                     clock.Clock
 	mu                        sync.RWMutex
 	nodes                     map[string]*StateNode           // provider id -> cached node
 	bindings                  map[types.NamespacedName]string // pod namespaced named -> node name
 	nodeNameToProviderID      map[string]string               // node name -> provider id
 	nodeClaimNameToProviderID map[string]string               // node claim name -> provider id
+	providerIDAliases         map[string]string               // reported provider id -> provider id that it's linked to
+	normalizedProviderIDs     map[string]string               // normalized provider id -> provider id that it's tracked under
 	daemonSetPods             sync.Map                        // daemonSet -> existing pod
 
 	podAcks                 sync.Map // pod namespaced name -> time when Karpenter first saw the pod as pending
@@ -69,21 +74,44 @@ type Cluster struct {
 	clusterState      time.Time
 	unsyncedStartTime time.Time
 	antiAffinityPods  sync.Map // pod namespaced name -> *corev1.Pod of pods that have required anti affinities
+
+	volumeMu           sync.RWMutex                       // Separate mutex since volumes are resolved while scheduling, independently of nodes
+	volumeRequirements map[types.UID]*volumeRequirements  // pvc uid -> node requirements of the pvc's volume
+	pvcUIDs            map[types.NamespacedName]types.UID // pvc namespaced name -> pvc uid
+	volumePVCs         map[string]types.UID               // persistent volume name -> uid of the pvc that's bound to it
+
+	failedLaunchMu sync.Mutex
+	failedLaunches map[string]*FailedLaunch // sorted pod uids -> failed launch of the pods
 }
 
-func NewCluster(clk clock.Clock, client client.Client, cloudProvider cloudprovider.CloudProvider) *Cluster {
+// volumeRequirements are the node requirements that the volume of a PVC restricts pods to. They're resolved from the
+// PersistentVolume that the PVC is bound to, or from the StorageClass of the PVC if it's unbound.
+type volumeRequirements struct {
+	volumeName       string
+	storageClassName string
+	requirements     []corev1.NodeSelectorRequirement
+}
+
+func NewCluster(clk clock.Clock, client client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Cluster {
 	return &Cluster{
 		clock:                     clk,
 		kubeClient:                client,
 		cloudProvider:             cloudProvider,
+		recorder:                  recorder,
 		nodes:                     map[string]*StateNode{},
 		bindings:                  map[types.NamespacedName]string{},
 		daemonSetPods:             sync.Map{},
 		nodeNameToProviderID:      map[string]string{},
 		nodeClaimNameToProviderID: map[string]string{},
+		providerIDAliases:         map[string]string{},
+		normalizedProviderIDs:     map[string]string{},
 		podAcks:                   sync.Map{},
 		podsSchedulableTimes:      sync.Map{},
 		podsSchedulingAttempted:   sync.Map{},
+		volumeRequirements:        map[types.UID]*volumeRequirements{},
+		pvcUIDs:                   map[types.NamespacedName]types.UID{},
+		volumePVCs:                map[string]types.UID{},
+		failedLaunches:            map[string]*FailedLaunch{},
 	}
 }
 
@@ -194,13 +222,45 @@ func (c *Cluster) Nodes() StateNodes {
 	})
 }
 
+// NodeClaimProviderIDs returns a copy of the provider IDs of the NodeClaims that are tracked, by NodeClaim name. The
+// provider ID is empty for NodeClaims that haven't launched yet.
+func (c *Cluster) NodeClaimProviderIDs() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// NodeClaims that are linked to a Node with a different provider ID are tracked under the Node's provider ID, but
+	// their own provider ID is returned so that it can be compared to the NodeClaim
+	return lo.MapValues(c.nodeClaimNameToProviderID, func(id string, _ string) string {
+		if n, ok := c.nodes[id]; ok && n.NodeClaim != nil {
+			return n.NodeClaim.Status.ProviderID
+		}
+		return id
+	})
+}
+
+// NodeProviderIDs returns a copy of the provider IDs of the Nodes that are tracked, by Node name.
+func (c *Cluster) NodeProviderIDs() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return lo.Assign(c.nodeNameToProviderID)
+}
+
+// Bindings returns a copy of the names of the Nodes that tracked pods are bound to, by pod key.
+func (c *Cluster) Bindings() map[types.NamespacedName]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return lo.Assign(c.bindings)
+}
+
 // IsNodeNominated returns true if the given node was expected to have a pod bound to it during a recent scheduling
 // batch
 func (c *Cluster) IsNodeNominated(providerID string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if n, ok := c.nodes[providerID]; ok {
+	if n, ok := c.nodes[c.trackedProviderID(providerID)]; ok {
 		return n.Nominated()
 	}
 	return false
@@ -211,7 +271,7 @@ func (c *Cluster) NominateNodeForPod(ctx context.Context, providerID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if n, ok := c.nodes[providerID]; ok {
+	if n, ok := c.nodes[c.trackedProviderID(providerID)]; ok {
 		n.Nominate(ctx) // extends nomination window if already nominated
 	}
 }
@@ -224,7 +284,7 @@ func (c *Cluster) UnmarkForDeletion(providerIDs ...string) {
 	defer c.mu.Unlock()
 
 	for _, id := range providerIDs {
-		if n, ok := c.nodes[id]; ok {
+		if n, ok := c.nodes[c.trackedProviderID(id)]; ok {
 			n.markedForDeletion = false
 		}
 	}
@@ -238,7 +298,7 @@ func (c *Cluster) MarkForDeletion(providerIDs ...string) {
 	defer c.mu.Unlock()
 
 	for _, id := range providerIDs {
-		if n, ok := c.nodes[id]; ok {
+		if n, ok := c.nodes[c.trackedProviderID(id)]; ok {
 			n.markedForDeletion = true
 		}
 	}
@@ -251,14 +311,17 @@ func (c *Cluster) UpdateNodeClaim(nodeClaim *v1.NodeClaim) {
 	// If the nodeclaim has a providerID, create a StateNode for it, and populate the data.
 	// We only need to do this for a nodeclaim with a providerID as nodeclaims without provider IDs haven't
 	// been launched yet.
-	if nodeClaim.Status.ProviderID != "" {
-		n := c.newStateFromNodeClaim(nodeClaim, c.nodes[nodeClaim.Status.ProviderID])
-		c.nodes[nodeClaim.Status.ProviderID] = n
+	providerID := nodeClaim.Status.ProviderID
+	if providerID != "" {
+		providerID = c.linkProviderID(nodeClaim)
+		n := c.newStateFromNodeClaim(nodeClaim, providerID, c.nodes[providerID])
+		c.nodes[providerID] = n
 	}
 	// If the nodeclaim hasn't launched yet, we want to add it into cluster state to ensure
 	// that we're not racing with the internal cache for the cluster, assuming the node doesn't exist.
-	c.nodeClaimNameToProviderID[nodeClaim.Name] = nodeClaim.Status.ProviderID
+	c.nodeClaimNameToProviderID[nodeClaim.Name] = providerID
 	ClusterStateNodesCount.Set(float64(len(c.nodes)), nil)
+	c.recordFailedLaunch(nodeClaim)
 }
 
 func (c *Cluster) DeleteNodeClaim(name string) {
@@ -287,6 +350,7 @@ func (c *Cluster) UpdateNode(ctx context.Context, node *corev1.Node) error {
 	if managed && node.Labels[corev1.LabelInstanceTypeStable] == "" && !initialized {
 		return nil
 	}
+	c.linkProviderID(node)
 	n, err := c.newStateFromNode(ctx, node, c.nodes[node.Spec.ProviderID])
 	if err != nil {
 		return err
@@ -304,6 +368,31 @@ func (c *Cluster) DeleteNode(name string) {
 	ClusterStateNodesCount.Set(float64(len(c.nodes)), nil)
 }
 
+// UpdateLease records the last renewal of a kubelet's Lease as the heartbeat of its node. Leases are named after
+// their nodes, so Leases of nodes that aren't tracked yet are ignored until they're renewed.
+func (c *Cluster) UpdateLease(lease *coordinationv1.Lease) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n, ok := c.nodes[c.nodeNameToProviderID[lease.Name]]
+	if !ok || lease.Spec.RenewTime == nil {
+		return
+	}
+	n.lastHeartbeat = metav1.NewTime(lease.Spec.RenewTime.Time)
+	n.heartbeatTimeout = time.Duration(lo.FromPtr(lease.Spec.LeaseDurationSeconds)) * time.Second
+}
+
+// DeleteLease forgets the heartbeat of the node that the Lease was named after
+func (c *Cluster) DeleteLease(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n, ok := c.nodes[c.nodeNameToProviderID[name]]; ok {
+		n.lastHeartbeat = metav1.Time{}
+		n.heartbeatTimeout = 0
+	}
+}
+
 func (c *Cluster) UpdatePod(ctx context.Context, pod *corev1.Pod) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -429,9 +518,19 @@ func (c *Cluster) Reset() {
 	c.nodes = map[string]*StateNode{}
 	c.nodeNameToProviderID = map[string]string{}
 	c.nodeClaimNameToProviderID = map[string]string{}
+	c.providerIDAliases = map[string]string{}
+	c.normalizedProviderIDs = map[string]string{}
 	c.bindings = map[types.NamespacedName]string{}
 	c.antiAffinityPods = sync.Map{}
 	c.daemonSetPods = sync.Map{}
+	c.failedLaunchMu.Lock()
+	c.failedLaunches = map[string]*FailedLaunch{}
+	c.failedLaunchMu.Unlock()
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	c.volumeRequirements = map[types.UID]*volumeRequirements{}
+	c.pvcUIDs = map[types.NamespacedName]types.UID{}
+	c.volumePVCs = map[string]types.UID{}
 }
 
 func (c *Cluster) GetDaemonSetPod(daemonset *appsv1.DaemonSet) *corev1.Pod {
@@ -467,12 +566,86 @@ func (c *Cluster) DeleteDaemonSet(key types.NamespacedName) {
 	c.daemonSetPods.Delete(key)
 }
 
+// VolumeRequirements returns the cached node requirements of the PVC's volume. Requirements that were resolved before
+// the PVC was bound, or before its storage class changed, are never returned.
+func (c *Cluster) VolumeRequirements(pvc *corev1.PersistentVolumeClaim) ([]corev1.NodeSelectorRequirement, bool) {
+	c.volumeMu.RLock()
+	defer c.volumeMu.RUnlock()
+	cached, ok := c.volumeRequirements[pvc.UID]
+	if !ok || cached.volumeName != pvc.Spec.VolumeName || cached.storageClassName != lo.FromPtr(pvc.Spec.StorageClassName) {
+		return nil, false
+	}
+	return cached.requirements, true
+}
+
+// UpdateVolumeRequirements caches the node requirements that were resolved for the PVC's volume
+func (c *Cluster) UpdateVolumeRequirements(pvc *corev1.PersistentVolumeClaim, requirements []corev1.NodeSelectorRequirement) {
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	c.deleteVolumeRequirements(client.ObjectKeyFromObject(pvc))
+	c.volumeRequirements[pvc.UID] = &volumeRequirements{
+		volumeName:       pvc.Spec.VolumeName,
+		storageClassName: lo.FromPtr(pvc.Spec.StorageClassName),
+		requirements:     requirements,
+	}
+	c.pvcUIDs[client.ObjectKeyFromObject(pvc)] = pvc.UID
+	if pvc.Spec.VolumeName != "" {
+		c.volumePVCs[pvc.Spec.VolumeName] = pvc.UID
+	}
+}
+
+// UpdatePersistentVolumeClaim invalidates the cached node requirements of the PVC's volume if they were resolved for
+// a previous incarnation of the PVC, before it was bound, or before its storage class changed
+func (c *Cluster) UpdatePersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) {
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	key := client.ObjectKeyFromObject(pvc)
+	uid, ok := c.pvcUIDs[key]
+	if !ok {
+		return
+	}
+	if cached := c.volumeRequirements[uid]; uid != pvc.UID || cached.volumeName != pvc.Spec.VolumeName || cached.storageClassName != lo.FromPtr(pvc.Spec.StorageClassName) {
+		c.deleteVolumeRequirements(key)
+	}
+}
+
+// DeletePersistentVolumeClaim invalidates the cached node requirements of the PVC's volume
+func (c *Cluster) DeletePersistentVolumeClaim(key types.NamespacedName) {
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	c.deleteVolumeRequirements(key)
+}
+
+// DeletePersistentVolume invalidates the cached node requirements of the PVC that's bound to the PersistentVolume
+func (c *Cluster) DeletePersistentVolume(name string) {
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	uid, ok := c.volumePVCs[name]
+	if !ok {
+		return
+	}
+	if key, ok := lo.FindKey(c.pvcUIDs, uid); ok {
+		c.deleteVolumeRequirements(key)
+	}
+}
+
+// DeleteStorageClass invalidates the cached node requirements of the unbound PVCs of the StorageClass
+func (c *Cluster) DeleteStorageClass(name string) {
+	c.volumeMu.Lock()
+	defer c.volumeMu.Unlock()
+	for key, uid := range c.pvcUIDs {
+		if cached := c.volumeRequirements[uid]; cached.volumeName == "" && cached.storageClassName == name {
+			c.deleteVolumeRequirements(key)
+		}
+	}
+}
+
 // WARNING
 // Everything under this section of code assumes that you have already held a lock when you are calling into these functions
 // and explicitly modifying the cluster state. If you do not hold the cluster state lock before calling any of these helpers
 // you will hit race conditions and data corruption
 
-func (c *Cluster) newStateFromNodeClaim(nodeClaim *v1.NodeClaim, oldNode *StateNode) *StateNode {
+func (c *Cluster) newStateFromNodeClaim(nodeClaim *v1.NodeClaim, providerID string, oldNode *StateNode) *StateNode {
 	if oldNode == nil {
 		oldNode = NewNode()
 	}
@@ -487,11 +660,13 @@ func (c *Cluster) newStateFromNodeClaim(nodeClaim *v1.NodeClaim, oldNode *StateN
 		volumeUsage:       oldNode.volumeUsage,
 		markedForDeletion: oldNode.markedForDeletion,
 		nominatedUntil:    oldNode.nominatedUntil,
+		lastHeartbeat:     oldNode.lastHeartbeat,
+		heartbeatTimeout:  oldNode.heartbeatTimeout,
 	}
 	// Cleanup the old nodeClaim with its old providerID if its providerID changes
 	// This can happen since nodes don't get created with providerIDs. Rather, CCM picks up the
 	// created node and injects the providerID into the spec.providerID
-	if id, ok := c.nodeClaimNameToProviderID[nodeClaim.Name]; ok && id != nodeClaim.Status.ProviderID {
+	if id, ok := c.nodeClaimNameToProviderID[nodeClaim.Name]; ok && id != providerID {
 		c.cleanupNodeClaim(nodeClaim.Name)
 	}
 	c.triggerConsolidationOnChange(oldNode, n)
@@ -502,6 +677,7 @@ func (c *Cluster) cleanupNodeClaim(name string) {
 	if id := c.nodeClaimNameToProviderID[name]; id != "" {
 		if c.nodes[id].Node == nil {
 			delete(c.nodes, id)
+			c.forgetProviderID(id)
 		} else {
 			c.nodes[id].NodeClaim = nil
 		}
@@ -513,6 +689,76 @@ func (c *Cluster) cleanupNodeClaim(name string) {
 	delete(c.nodeClaimNameToProviderID, name)
 }
 
+// linkProviderID returns the provider ID that the NodeClaim or Node is tracked under. CloudProviders that report the
+// provider ID of an instance in different formats for its NodeClaim and its Node opt in to linking them by implementing
+// a ProviderIDNormalizer. A provider ID that isn't tracked yet is then linked to the NodeClaim or Node of the same
+// instance that isn't linked to its counterpart yet, which is looked up by its normalized provider ID. Only Nodes of
+// NodePools are linked, since other Nodes never have a NodeClaim. Linked NodeClaims and Nodes are tracked under the
+// provider ID of the Node, since that's the provider ID of the StateNode.
+//
+//nolint:gocyclo
+func (c *Cluster) linkProviderID(obj client.Object) string {
+	node, isNode := obj.(*corev1.Node)
+	providerID := lo.TernaryF(isNode, func() string { return node.Spec.ProviderID }, func() string { return obj.(*v1.NodeClaim).Status.ProviderID })
+	if id := c.trackedProviderID(providerID); id != providerID {
+		return id
+	}
+	if _, ok := c.nodes[providerID]; ok {
+		return providerID
+	}
+	normalizer, ok := cloudprovider.GetProviderIDNormalizer(c.cloudProvider)
+	if !ok || isNode && node.Labels[v1.NodePoolLabelKey] == "" {
+		return providerID
+	}
+	normalized := normalizer.NormalizeProviderID(providerID)
+	id := c.normalizedProviderIDs[normalized]
+	n, ok := c.nodes[id]
+	// Only NodeClaims without a Node and Nodes of NodePools without a NodeClaim are linked
+	if !ok || id == providerID ||
+		isNode && (n.Node != nil || n.NodeClaim == nil) ||
+		!isNode && (n.NodeClaim != nil || n.Node == nil || n.Node.Labels[v1.NodePoolLabelKey] == "") {
+		c.normalizedProviderIDs[normalized] = providerID
+		return providerID
+	}
+	delete(c.normalizedProviderIDs, normalized)
+	nodeClaim, nodeProviderID := n.NodeClaim, providerID
+	if isNode {
+		// Move the NodeClaim to the provider ID of the Node
+		c.nodes[providerID] = n
+		delete(c.nodes, id)
+		c.nodeClaimNameToProviderID[n.NodeClaim.Name] = providerID
+		c.providerIDAliases[id] = providerID
+	} else {
+		nodeClaim, nodeProviderID = obj.(*v1.NodeClaim), id
+		c.providerIDAliases[providerID] = id
+	}
+	c.recorder.Publish(ProviderIDLinked(nodeClaim, nodeProviderID))
+	return nodeProviderID
+}
+
+// trackedProviderID returns the provider ID that the reported provider ID is tracked under
+func (c *Cluster) trackedProviderID(providerID string) string {
+	if id, ok := c.providerIDAliases[providerID]; ok {
+		return id
+	}
+	return providerID
+}
+
+// forgetProviderID removes the provider IDs that are linked to the provider ID, and the provider ID from the index of
+// normalized provider IDs
+func (c *Cluster) forgetProviderID(providerID string) {
+	for alias, id := range c.providerIDAliases {
+		if id == providerID {
+			delete(c.providerIDAliases, alias)
+		}
+	}
+	if normalizer, ok := cloudprovider.GetProviderIDNormalizer(c.cloudProvider); ok {
+		if normalized := normalizer.NormalizeProviderID(providerID); c.normalizedProviderIDs[normalized] == providerID {
+			delete(c.normalizedProviderIDs, normalized)
+		}
+	}
+}
+
 func (c *Cluster) newStateFromNode(ctx context.Context, node *corev1.Node, oldNode *StateNode) (*StateNode, error) {
 	if oldNode == nil {
 		oldNode = NewNode()
@@ -528,6 +774,8 @@ func (c *Cluster) newStateFromNode(ctx context.Context, node *corev1.Node, oldNo
 		volumeUsage:       scheduling.NewVolumeUsage(),
 		markedForDeletion: oldNode.markedForDeletion,
 		nominatedUntil:    oldNode.nominatedUntil,
+		lastHeartbeat:     oldNode.lastHeartbeat,
+		heartbeatTimeout:  oldNode.heartbeatTimeout,
 	}
 	if err := multierr.Combine(
 		c.populateResourceRequests(ctx, n),
@@ -549,6 +797,7 @@ func (c *Cluster) cleanupNode(name string) {
 	if id := c.nodeNameToProviderID[name]; id != "" {
 		if c.nodes[id].NodeClaim == nil {
 			delete(c.nodes, id)
+			c.forgetProviderID(id)
 		} else {
 			c.nodes[id].Node = nil
 		}
@@ -676,3 +925,15 @@ func (c *Cluster) triggerConsolidationOnChange(old, new *StateNode) {
 		return
 	}
 }
+
+func (c *Cluster) deleteVolumeRequirements(key types.NamespacedName) {
+	uid, ok := c.pvcUIDs[key]
+	if !ok {
+		return
+	}
+	if cached, ok := c.volumeRequirements[uid]; ok && cached.volumeName != "" {
+		delete(c.volumePVCs, cached.volumeName)
+	}
+	delete(c.volumeRequirements, uid)
+	delete(c.pvcUIDs, key)
+}
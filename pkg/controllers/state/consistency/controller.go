@@ -0,0 +1,287 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// checkPeriod is how often cluster state is compared against the APIServer
+var checkPeriod = 10 * time.Minute
+
+// pageSize is the number of objects that are listed from the APIServer at a time
+const pageSize = 500
+
+const (
+	// ReasonMissing is the reason of divergences where an object in the APIServer isn't represented in cluster state
+	ReasonMissing = "missing"
+	// ReasonStale is the reason of divergences where cluster state has a representation of an object that was deleted
+	// or that no longer matches the APIServer
+	ReasonStale = "stale"
+)
+
+// Controller periodically re-lists the NodeClaims, Nodes and pods and compares them against cluster state. Cluster
+// state is kept up to date by the state informers, so any divergence means that an event was missed or failed to be
+// reconciled. Divergences are logged, counted and repaired by refreshing the diverged entries of cluster state. Objects
+// are listed from the APIServer rather than the informer cache, since the state informers are fed by the same cache
+// and would miss the same events. Objects are only added back to cluster state if they're still in the informer cache,
+// since the informers remove the objects that are deleted after they're listed.
+type Controller struct {
+	kubeClient    client.Client
+	apiReader     client.Reader
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+}
+
+func NewController(kubeClient client.Client, apiReader client.Reader, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		apiReader:     apiReader,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+	}
+}
+
+// Reconcile checks the NodeClaims before the Nodes and the Nodes before the pods, since the representation of a Node
+// is built from its NodeClaim and pods can only be tracked on Nodes that are in cluster state.
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "state.consistency")
+
+	if err := c.checkNodeClaims(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("checking nodeclaims, %w", err)
+	}
+	if err := c.checkNodes(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("checking nodes, %w", err)
+	}
+	if err := c.checkPods(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("checking pods, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: checkPeriod}, nil
+}
+
+// checkNodeClaims repairs the NodeClaims that are missing from cluster state or whose provider ID is out of date, and
+// removes the NodeClaims that have been deleted. Cluster state is read before listing so that an object that's
+// created after the list isn't mistaken as deleted.
+func (c *Controller) checkNodeClaims(ctx context.Context) error {
+	tracked := c.cluster.NodeClaimProviderIDs()
+	nodeClaimList := &v1.NodeClaimList{}
+	exists := map[string]struct{}{}
+	if err := c.forEachPage(ctx, nodeClaimList, func() error {
+		for i := range nodeClaimList.Items {
+			nodeClaim := &nodeClaimList.Items[i]
+			if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) || !nodepoolutils.IsOwnerInShard(ctx, c.kubeClient, nodeClaim) {
+				continue
+			}
+			exists[nodeClaim.Name] = struct{}{}
+			providerID, ok := tracked[nodeClaim.Name]
+			if ok && providerID == nodeClaim.Status.ProviderID {
+				continue
+			}
+			if cached, err := c.isCached(ctx, nodeClaim); err != nil {
+				return err
+			} else if !cached {
+				continue
+			}
+			diverged(ctx, "NodeClaim", klog.KObj(nodeClaim), reason(ok))
+			c.cluster.UpdateNodeClaim(nodeClaim)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for name := range tracked {
+		if _, ok := exists[name]; ok {
+			continue
+		}
+		diverged(ctx, "NodeClaim", klog.KRef("", name), ReasonStale)
+		c.cluster.DeleteNodeClaim(name)
+	}
+	return nil
+}
+
+// checkNodes repairs the Nodes that are missing from cluster state or whose provider ID is out of date, and removes
+// the Nodes that have been deleted.
+func (c *Controller) checkNodes(ctx context.Context) error {
+	tracked := c.cluster.NodeProviderIDs()
+	nodeList := &corev1.NodeList{}
+	exists := map[string]struct{}{}
+	if err := c.forEachPage(ctx, nodeList, func() error {
+		for i := range nodeList.Items {
+			node := &nodeList.Items[i]
+			if !nodepoolutils.IsOwnerInShard(ctx, c.kubeClient, node) {
+				continue
+			}
+			exists[node.Name] = struct{}{}
+			if !isTrackable(node) {
+				continue
+			}
+			providerID, ok := tracked[node.Name]
+			if ok && providerID == lo.Ternary(node.Spec.ProviderID == "", node.Name, node.Spec.ProviderID) {
+				continue
+			}
+			if cached, err := c.isCached(ctx, node); err != nil {
+				return err
+			} else if !cached {
+				continue
+			}
+			diverged(ctx, "Node", klog.KObj(node), reason(ok))
+			if err := c.cluster.UpdateNode(ctx, node); err != nil {
+				return fmt.Errorf("updating node %q, %w", node.Name, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for name := range tracked {
+		if _, ok := exists[name]; ok {
+			continue
+		}
+		diverged(ctx, "Node", klog.KRef("", name), ReasonStale)
+		c.cluster.DeleteNode(name)
+	}
+	return nil
+}
+
+// checkPods repairs the bindings of the pods that are bound to Nodes in cluster state, and removes the bindings of the
+// pods that have been deleted, have completed or have been recreated without being bound.
+func (c *Controller) checkPods(ctx context.Context) error {
+	bindings := c.cluster.Bindings()
+	nodes := c.cluster.NodeProviderIDs()
+	podList := &corev1.PodList{}
+	exists := map[client.ObjectKey]struct{}{}
+	if err := c.forEachPage(ctx, podList, func() error {
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.Spec.NodeName == "" || podutils.IsTerminal(pod) {
+				continue
+			}
+			key := client.ObjectKeyFromObject(pod)
+			exists[key] = struct{}{}
+			if _, ok := nodes[pod.Spec.NodeName]; !ok {
+				continue
+			}
+			nodeName, ok := bindings[key]
+			if ok && nodeName == pod.Spec.NodeName {
+				continue
+			}
+			if cached, err := c.isCached(ctx, pod); err != nil {
+				return err
+			} else if !cached {
+				continue
+			}
+			diverged(ctx, "Pod", klog.KObj(pod), reason(ok))
+			// The node may have been deleted since cluster state was read, in which case the pod will be removed with it
+			if err := c.cluster.UpdatePod(ctx, pod); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("updating pod %q, %w", key, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for key := range bindings {
+		if _, ok := exists[key]; ok {
+			continue
+		}
+		diverged(ctx, "Pod", klog.KRef(key.Namespace, key.Name), ReasonStale)
+		c.cluster.DeletePod(key)
+	}
+	return nil
+}
+
+// forEachPage lists the objects from the APIServer a page at a time, calling f once each page has been listed into list
+func (c *Controller) forEachPage(ctx context.Context, list client.ObjectList, f func() error) error {
+	for continueToken := ""; ; {
+		if err := c.apiReader.List(ctx, list, client.Limit(pageSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		if err := f(); err != nil {
+			return err
+		}
+		if continueToken = list.GetContinue(); continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// isCached returns false if the object was deleted from the informer cache after it was listed, or was deleted and
+// recreated, in which case the informers have already updated cluster state and it shouldn't be repaired from the list
+func (c *Controller) isCached(ctx context.Context, obj client.Object) (bool, error) {
+	cached := obj.DeepCopyObject().(client.Object)
+	if err := c.kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), cached); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting %s from cache, %w", client.ObjectKeyFromObject(obj), err)
+	}
+	return cached.GetUID() == obj.GetUID(), nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("state.consistency").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
+
+// isTrackable returns true if cluster state tracks the node. Managed nodes aren't tracked until their provider ID is
+// set, and until they have an instance type label or are initialized.
+func isTrackable(node *corev1.Node) bool {
+	if node.Labels[v1.NodePoolLabelKey] == "" {
+		return true
+	}
+	return node.Spec.ProviderID != "" && (node.Labels[corev1.LabelInstanceTypeStable] != "" || node.Labels[v1.NodeInitializedLabelKey] != "")
+}
+
+// reason returns the reason of a divergence based on whether the object is tracked in cluster state
+func reason(tracked bool) string {
+	if tracked {
+		return ReasonStale
+	}
+	return ReasonMissing
+}
+
+func diverged(ctx context.Context, kind string, ref klog.ObjectRef, reason string) {
+	log.FromContext(ctx).WithValues(kind, ref, "reason", reason).Info("repairing divergence of cluster state")
+	state.ClusterStateDivergencesTotal.Inc(map[string]string{
+		state.KindLabel:     kind,
+		metrics.ReasonLabel: reason,
+	})
+}
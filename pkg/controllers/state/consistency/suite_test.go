@@ -0,0 +1,256 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistency_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/consistency"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var env *test.Environment
+var cluster *state.Cluster
+var cloudProvider *fake.CloudProvider
+var nodeClaimController *informer.NodeClaimController
+var nodeController *informer.NodeController
+var podController *informer.PodController
+var consistencyController *consistency.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StateConsistency")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
+	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	podController = informer.NewPodController(env.Client, cluster)
+	consistencyController = consistency.NewController(env.Client, env.Client, cloudProvider, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cluster.Reset()
+	state.ClusterStateDivergencesTotal.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+// emptyCacheClient is a client whose cache hasn't observed any objects
+type emptyCacheClient struct {
+	client.Client
+}
+
+func (emptyCacheClient) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return nil
+}
+
+// deletedCacheClient is a client whose cache has observed the deletion of every object
+type deletedCacheClient struct {
+	client.Client
+}
+
+func (deletedCacheClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	return errors.NewNotFound(schema.GroupResource{Resource: fmt.Sprintf("%T", obj)}, key.Name)
+}
+
+// pagingReader lists a single object at a time from the APIServer
+type pagingReader struct {
+	client.Reader
+}
+
+func (r pagingReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.Reader.List(ctx, list, append(opts, client.Limit(1))...)
+}
+
+var _ = Describe("State Consistency", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+				},
+			},
+		})
+	})
+	It("should requeue after the check period", func() {
+		result := ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+	})
+	It("should not report a divergence when cluster state is consistent", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 0, map[string]string{state.KindLabel: "NodeClaim", metrics.ReasonLabel: consistency.ReasonMissing})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 0, map[string]string{state.KindLabel: "Node", metrics.ReasonLabel: consistency.ReasonMissing})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 0, map[string]string{state.KindLabel: "Pod", metrics.ReasonLabel: consistency.ReasonMissing})
+	})
+	It("should list from the APIServer when the cache disagrees with it", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+		ExpectSingletonReconciled(ctx, consistency.NewController(emptyCacheClient{Client: env.Client}, env.Client, cloudProvider, cluster))
+		Expect(cluster.NodeClaimProviderIDs()).To(HaveKeyWithValue(nodeClaim.Name, nodeClaim.Status.ProviderID))
+		Expect(cluster.NodeProviderIDs()).To(HaveKeyWithValue(node.Name, node.Spec.ProviderID))
+		Expect(cluster.Bindings()).To(HaveKeyWithValue(client.ObjectKeyFromObject(pod), node.Name))
+	})
+	It("should list every page from the APIServer", func() {
+		otherNodeClaim, otherNode := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+				},
+			},
+		})
+		pods := []*corev1.Pod{test.Pod(test.PodOptions{NodeName: node.Name}), test.Pod(test.PodOptions{NodeName: otherNode.Name})}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, otherNodeClaim, otherNode, pods[0], pods[1])
+
+		ExpectSingletonReconciled(ctx, consistency.NewController(env.Client, pagingReader{Reader: env.Client}, cloudProvider, cluster))
+		Expect(cluster.NodeClaimProviderIDs()).To(HaveLen(2))
+		Expect(cluster.NodeProviderIDs()).To(HaveLen(2))
+		Expect(cluster.Bindings()).To(HaveLen(2))
+	})
+	It("should not add objects that were deleted from the cache after they were listed", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+		ExpectSingletonReconciled(ctx, consistency.NewController(deletedCacheClient{Client: env.Client}, env.Client, cloudProvider, cluster))
+		Expect(cluster.NodeClaimProviderIDs()).To(BeEmpty())
+		Expect(cluster.NodeProviderIDs()).To(BeEmpty())
+		Expect(cluster.Bindings()).To(BeEmpty())
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 0, map[string]string{state.KindLabel: "NodeClaim", metrics.ReasonLabel: consistency.ReasonMissing})
+	})
+	It("should add NodeClaims, Nodes and pod bindings that are missing from cluster state", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(cluster.NodeClaimProviderIDs()).To(HaveKeyWithValue(nodeClaim.Name, nodeClaim.Status.ProviderID))
+		Expect(cluster.NodeProviderIDs()).To(HaveKeyWithValue(node.Name, node.Spec.ProviderID))
+		Expect(cluster.Bindings()).To(HaveKeyWithValue(client.ObjectKeyFromObject(pod), node.Name))
+		ExpectStateNodeExists(cluster, node)
+
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "NodeClaim", metrics.ReasonLabel: consistency.ReasonMissing})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "Node", metrics.ReasonLabel: consistency.ReasonMissing})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "Pod", metrics.ReasonLabel: consistency.ReasonMissing})
+	})
+	It("should remove NodeClaims, Nodes and pod bindings that were deleted from cluster state", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+
+		// Delete the objects without notifying cluster state
+		ExpectDeleted(ctx, env.Client, pod, node, nodeClaim)
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(cluster.NodeClaimProviderIDs()).To(BeEmpty())
+		Expect(cluster.NodeProviderIDs()).To(BeEmpty())
+		Expect(cluster.Bindings()).To(BeEmpty())
+		Expect(cluster.Nodes()).To(BeEmpty())
+
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "NodeClaim", metrics.ReasonLabel: consistency.ReasonStale})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "Node", metrics.ReasonLabel: consistency.ReasonStale})
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "Pod", metrics.ReasonLabel: consistency.ReasonStale})
+	})
+	It("should remove the bindings of pods that have completed", func() {
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+
+		pod.Status.Phase = corev1.PodSucceeded
+		ExpectApplied(ctx, env.Client, pod)
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(cluster.Bindings()).ToNot(HaveKey(client.ObjectKeyFromObject(pod)))
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "Pod", metrics.ReasonLabel: consistency.ReasonStale})
+	})
+	It("should refresh NodeClaims whose provider ID is out of date", func() {
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(cluster.NodeClaimProviderIDs()).To(HaveKeyWithValue(nodeClaim.Name, ""))
+
+		// Launch the NodeClaim without notifying cluster state
+		nodeClaim.Status.ProviderID = node.Spec.ProviderID
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(cluster.NodeClaimProviderIDs()).To(HaveKeyWithValue(nodeClaim.Name, node.Spec.ProviderID))
+		ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim)
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 1, map[string]string{state.KindLabel: "NodeClaim", metrics.ReasonLabel: consistency.ReasonStale})
+	})
+	It("should ignore managed Nodes that cluster state doesn't track yet", func() {
+		node.Spec.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodePool, node)
+
+		ExpectSingletonReconciled(ctx, consistencyController)
+		Expect(cluster.NodeProviderIDs()).To(BeEmpty())
+		ExpectMetricCounterValue(state.ClusterStateDivergencesTotal, 0, map[string]string{state.KindLabel: "Node", metrics.ReasonLabel: consistency.ReasonMissing})
+	})
+})
@@ -0,0 +1,37 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+// ProviderIDLinked is published when a NodeClaim is linked to a Node whose provider ID is reported in a different format
+func ProviderIDLinked(nodeClaim *v1.NodeClaim, nodeProviderID string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "ProviderIDLinked",
+		Message:        fmt.Sprintf("Linked provider ID %q to node provider ID %q by normalized provider ID", nodeClaim.Status.ProviderID, nodeProviderID),
+		DedupeValues:   []string{string(nodeClaim.UID), nodeProviderID},
+	}
+}
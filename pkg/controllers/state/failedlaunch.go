@@ -0,0 +1,105 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+)
+
+// failedLaunchTTL is how long a failed launch is remembered for after its last attempt
+const failedLaunchTTL = 10 * time.Minute
+
+// FailedLaunch is a group of pods whose NodeClaim was deleted after its launch failed. The provisioner retries the launch
+// with the same pods and requirements before it re-simulates the pods, which could pack them onto NodeClaims of a
+// different shape on every attempt.
+type FailedLaunch struct {
+	// NodeClaim is the NodeClaim of the last launch for the pods that failed
+	NodeClaim *v1.NodeClaim
+	// Pods are the UIDs of the pods that the NodeClaim was launched for
+	Pods sets.Set[types.UID]
+	// Attempts is the number of launches for the pods that have failed
+	Attempts int
+
+	nodeClaimNames sets.Set[string]
+	lastFailure    time.Time
+}
+
+// recordFailedLaunch tracks the pods of a NodeClaim that's being deleted since its launch failed. NodeClaims whose
+// scheduling decision doesn't include all of their pods are ignored, since their launch can't be reproduced.
+func (c *Cluster) recordFailedLaunch(nodeClaim *v1.NodeClaim) {
+	if nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Annotations[v1.NodeClaimTerminationReasonAnnotationKey] != string(v1.TerminationReasonLaunchFailed) {
+		return
+	}
+	decision, err := nodeclaimutils.GetSchedulingDecision(nodeClaim)
+	if err != nil || decision == nil || len(decision.Pods) == 0 || len(decision.Pods) != decision.TotalPods {
+		return
+	}
+	pods := sets.New(lo.Map(decision.Pods, func(p nodeclaimutils.NominatedPod, _ int) types.UID { return p.UID })...)
+	key := failedLaunchKey(pods)
+
+	c.failedLaunchMu.Lock()
+	defer c.failedLaunchMu.Unlock()
+	failed, ok := c.failedLaunches[key]
+	if !ok {
+		failed = &FailedLaunch{Pods: pods, nodeClaimNames: sets.New[string]()}
+		c.failedLaunches[key] = failed
+	}
+	if failed.nodeClaimNames.Has(nodeClaim.Name) {
+		return
+	}
+	failed.nodeClaimNames.Insert(nodeClaim.Name)
+	failed.NodeClaim = nodeClaim.DeepCopy()
+	failed.Attempts = failed.nodeClaimNames.Len()
+	failed.lastFailure = c.clock.Now()
+}
+
+// FailedLaunches returns the groups of pods whose launches recently failed
+func (c *Cluster) FailedLaunches() []*FailedLaunch {
+	c.failedLaunchMu.Lock()
+	defer c.failedLaunchMu.Unlock()
+	var failedLaunches []*FailedLaunch
+	for key, failed := range c.failedLaunches {
+		if c.clock.Since(failed.lastFailure) > failedLaunchTTL {
+			delete(c.failedLaunches, key)
+			continue
+		}
+		failedLaunches = append(failedLaunches, &FailedLaunch{
+			NodeClaim: failed.NodeClaim.DeepCopy(),
+			Pods:      failed.Pods.Clone(),
+			Attempts:  failed.Attempts,
+		})
+	}
+	// Failed launches are returned in the order that they failed in so that retries are deterministic
+	sort.Slice(failedLaunches, func(i, j int) bool {
+		return failedLaunches[i].NodeClaim.CreationTimestamp.Before(&failedLaunches[j].NodeClaim.CreationTimestamp) ||
+			(failedLaunches[i].NodeClaim.CreationTimestamp.Equal(&failedLaunches[j].NodeClaim.CreationTimestamp) && failedLaunches[i].NodeClaim.Name < failedLaunches[j].NodeClaim.Name)
+	})
+	return failedLaunches
+}
+
+func failedLaunchKey(pods sets.Set[types.UID]) string {
+	return strings.Join(lo.Map(sets.List(pods), func(uid types.UID, _ int) string { return string(uid) }), ",")
+}
@@ -18,26 +18,47 @@ package informer
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
 var stateRetryPeriod = 1 * time.Minute
 
+// podUpdateCoalesceWindow is how long updates to a pod are coalesced for before the pod is reconciled with its latest
+// state
+var podUpdateCoalesceWindow = 1 * time.Second
+
+const (
+	// SuppressedReasonUntracked is the reason of updates that don't change any of the fields that cluster state tracks
+	SuppressedReasonUntracked = "untracked"
+	// SuppressedReasonCoalesced is the reason of updates that are coalesced with a pending update of the same pod
+	SuppressedReasonCoalesced = "coalesced"
+)
+
 // PodController reconciles pods for the purpose of maintaining state regarding pods that is expensive to compute.
 type PodController struct {
 	kubeClient client.Client
 	cluster    *state.Cluster
+	// pending are the pods with updates that are waiting for the coalescing window to elapse
+	pending sync.Map
 }
 
 func NewPodController(kubeClient client.Client, cluster *state.Cluster) *PodController {
@@ -50,6 +71,8 @@ func NewPodController(kubeClient client.Client, cluster *state.Cluster) *PodCont
 func (c *PodController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	ctx = injection.WithControllerName(ctx, "state.pod")
 
+	// The pod is read after it's no longer pending, so updates that are coalesced are always reflected in the read
+	c.pending.Delete(req.NamespacedName)
 	pod := &v1.Pod{}
 	if err := c.kubeClient.Get(ctx, req.NamespacedName, pod); err != nil {
 		if errors.IsNotFound(err) {
@@ -68,10 +91,59 @@ func (c *PodController) Reconcile(ctx context.Context, req reconcile.Request) (r
 	return reconcile.Result{RequeueAfter: stateRetryPeriod}, nil
 }
 
+// OnUpdate enqueues the pod for an update. Updates that don't change any of the fields that cluster state tracks, e.g.
+// the high churn updates of container statuses, are suppressed. Pods are enqueued immediately when they're bound or
+// go terminal, while other updates are coalesced so that a pod is reconciled at most once per coalescing window.
+func (c *PodController) OnUpdate(oldPod, newPod *v1.Pod, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(newPod)}
+	switch {
+	case oldPod.Spec.NodeName != newPod.Spec.NodeName || podutils.IsTerminal(oldPod) != podutils.IsTerminal(newPod):
+		q.Add(req)
+	case !trackedFieldsChanged(oldPod, newPod):
+		state.PodUpdatesSuppressedTotal.Inc(map[string]string{metrics.ReasonLabel: SuppressedReasonUntracked})
+	default:
+		if _, pending := c.pending.LoadOrStore(req.NamespacedName, struct{}{}); pending {
+			state.PodUpdatesSuppressedTotal.Inc(map[string]string{metrics.ReasonLabel: SuppressedReasonCoalesced})
+			return
+		}
+		q.AddAfter(req, podUpdateCoalesceWindow)
+	}
+}
+
+// trackedFieldsChanged returns true if the update changes any of the fields of the pod that cluster state tracks. The
+// requests, host ports, volumes and affinities of the pod are all part of its spec, while the requests of pods that
+// are resized in-place also depend on the resize status and the actuated resources of their containers.
+func trackedFieldsChanged(oldPod, newPod *v1.Pod) bool {
+	return oldPod.Status.Phase != newPod.Status.Phase ||
+		oldPod.Status.Resize != newPod.Status.Resize ||
+		!oldPod.DeletionTimestamp.Equal(newPod.DeletionTimestamp) ||
+		!equality.Semantic.DeepEqual(oldPod.Labels, newPod.Labels) ||
+		!equality.Semantic.DeepEqual(oldPod.OwnerReferences, newPod.OwnerReferences) ||
+		!equality.Semantic.DeepEqual(oldPod.Spec, newPod.Spec) ||
+		!equality.Semantic.DeepEqual(containerResources(oldPod), containerResources(newPod))
+}
+
+// containerResources returns the actuated resources of the pod's containers by container name
+func containerResources(pod *v1.Pod) map[string]*v1.ResourceRequirements {
+	return lo.SliceToMap(pod.Status.ContainerStatuses, func(s v1.ContainerStatus) (string, *v1.ResourceRequirements) {
+		return s.Name, s.Resources
+	})
+}
+
 func (c *PodController) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("state.pod").
-		For(&v1.Pod{}).
+		Watches(&v1.Pod{}, handler.Funcs{
+			CreateFunc: func(_ context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				q.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(e.Object)})
+			},
+			UpdateFunc: func(_ context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				c.OnUpdate(e.ObjectOld.(*v1.Pod), e.ObjectNew.(*v1.Pod), q)
+			},
+			DeleteFunc: func(_ context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				q.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(e.Object)})
+			},
+		}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
 		Complete(c)
 }
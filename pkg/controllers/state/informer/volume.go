@@ -0,0 +1,90 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+)
+
+// VolumeController invalidates the node requirements of volumes that cluster state caches for scheduling. The
+// requirements of a PVC are resolved from the PersistentVolume that it's bound to or from its StorageClass, so they're
+// invalidated whenever any of the three changes.
+type VolumeController struct {
+	kubeClient client.Client
+	cluster    *state.Cluster
+}
+
+func NewVolumeController(kubeClient client.Client, cluster *state.Cluster) *VolumeController {
+	return &VolumeController{
+		kubeClient: kubeClient,
+		cluster:    cluster,
+	}
+}
+
+func (c *VolumeController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "state.volume")
+
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := c.kubeClient.Get(ctx, req.NamespacedName, pvc); err != nil {
+		if errors.IsNotFound(err) {
+			// notify cluster state of the pvc deletion
+			c.cluster.DeletePersistentVolumeClaim(req.NamespacedName)
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	c.cluster.UpdatePersistentVolumeClaim(pvc)
+	return reconcile.Result{}, nil
+}
+
+func (c *VolumeController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("state.volume").
+		For(&v1.PersistentVolumeClaim{}).
+		Watches(&v1.PersistentVolume{}, handler.Funcs{
+			UpdateFunc: func(_ context.Context, e event.UpdateEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				c.cluster.DeletePersistentVolume(e.ObjectNew.GetName())
+			},
+			DeleteFunc: func(_ context.Context, e event.DeleteEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				c.cluster.DeletePersistentVolume(e.Object.GetName())
+			},
+		}).
+		Watches(&storagev1.StorageClass{}, handler.Funcs{
+			UpdateFunc: func(_ context.Context, e event.UpdateEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				c.cluster.DeleteStorageClass(e.ObjectNew.GetName())
+			},
+			DeleteFunc: func(_ context.Context, e event.DeleteEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				c.cluster.DeleteStorageClass(e.Object.GetName())
+			},
+		}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(c)
+}
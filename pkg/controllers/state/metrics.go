@@ -26,6 +26,7 @@ import (
 
 const (
 	stateSubsystem = "cluster_state"
+	KindLabel      = "kind"
 )
 
 var (
@@ -59,6 +60,26 @@ var (
 		},
 		[]string{},
 	)
+	PodUpdatesSuppressedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: stateSubsystem,
+			Name:      "pod_updates_suppressed_total",
+			Help:      "The number of pod updates that weren't reconciled into cluster state, either because they didn't change any of the fields that cluster state tracks or because they were coalesced with a pending update of the same pod. Labeled by the reason that the update was suppressed.",
+		},
+		[]string{metrics.ReasonLabel},
+	)
+	ClusterStateDivergencesTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: stateSubsystem,
+			Name:      "divergences_total",
+			Help:      "The number of objects whose representation in cluster state diverged from the APIServer and was repaired. Labeled by the kind of the object and the reason of the divergence.",
+		},
+		[]string{KindLabel, metrics.ReasonLabel},
+	)
 	PodSchedulingDecisionSeconds = opmetrics.NewPrometheusHistogram(
 		crmetrics.Registry,
 		prometheus.HistogramOpts{
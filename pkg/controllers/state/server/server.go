@@ -0,0 +1,85 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server serves a read-only gRPC API of cluster state summaries, so that external schedulers can coordinate
+// with Karpenter (e.g. delay submitting jobs until capacity is imminent) without watching the API server themselves.
+// The service is described by state.proto, and the messages and stubs are generated from it with go generate.
+package server
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative state.proto
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Server serves the ClusterState service from cluster state. Cluster state is only maintained by the leader, so the
+// server is only started once the operator is elected.
+type Server struct {
+	UnimplementedClusterStateServer
+
+	cluster *state.Cluster
+	address string
+}
+
+func NewServer(cluster *state.Cluster) *Server {
+	return &Server{cluster: cluster}
+}
+
+func (s *Server) Register(ctx context.Context, m manager.Manager) error {
+	s.address = net.JoinHostPort(options.FromContext(ctx).StateServerAddress, strconv.Itoa(options.FromContext(ctx).StateServerPort))
+	return m.Add(s)
+}
+
+// Start serves the ClusterState service until the context is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("listening on state server address, %w", err)
+	}
+	srv := grpc.NewServer()
+	RegisterClusterStateServer(srv, s)
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	log.FromContext(ctx).WithValues("address", s.address).Info("serving cluster state")
+	if err := srv.Serve(listener); err != nil {
+		return fmt.Errorf("serving cluster state, %w", err)
+	}
+	return nil
+}
+
+func (s *Server) ListNodes(_ context.Context, _ *ListNodesRequest) (*ListNodesResponse, error) {
+	return &ListNodesResponse{Nodes: Nodes(s.cluster)}, nil
+}
+
+func (s *Server) ListInFlightNodeClaims(_ context.Context, _ *ListInFlightNodeClaimsRequest) (*ListInFlightNodeClaimsResponse, error) {
+	return &ListInFlightNodeClaimsResponse{NodeClaims: InFlightNodeClaims(s.cluster)}, nil
+}
+
+func (s *Server) ListTopologyDomains(_ context.Context, _ *ListTopologyDomainsRequest) (*ListTopologyDomainsResponse, error) {
+	return &ListTopologyDomainsResponse{TopologyDomains: TopologyDomains(s.cluster)}, nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/server"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+var nodeClaimController *informer.NodeClaimController
+var nodeController *informer.NodeController
+var cloudProvider *fake.CloudProvider
+var stateServer *server.Server
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers/State/Server")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, test.NewEventRecorder())
+	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	stateServer = server.NewServer(cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Server", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim, inFlight *v1.NodeClaim
+	var node *corev1.Node
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+					corev1.LabelTopologyZone:       "test-zone-1",
+					v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			},
+		})
+		inFlight = test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+					corev1.LabelTopologyZone:       "test-zone-2",
+					v1.CapacityTypeLabelKey:        v1.CapacityTypeSpot,
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				ProviderID:  test.RandomProviderID(),
+				Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, inFlight)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(inFlight))
+	})
+	It("should implement the ClusterState service", func() {
+		Expect(func() { server.RegisterClusterStateServer(grpc.NewServer(), stateServer) }).ToNot(Panic())
+	})
+	It("should summarize initialized nodes", func() {
+		nodes := server.Nodes(cluster)
+		Expect(nodes).To(HaveLen(1))
+		Expect(nodes[0].Name).To(Equal(node.Name))
+		Expect(nodes[0].NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(nodes[0].NodePool).To(Equal(nodePool.Name))
+		Expect(nodes[0].Zone).To(Equal("test-zone-1"))
+		Expect(nodes[0].CapacityType).To(Equal(v1.CapacityTypeOnDemand))
+		Expect(nodes[0].MarkedForDeletion).To(BeFalse())
+	})
+	It("should summarize in-flight NodeClaims", func() {
+		nodeClaims := server.InFlightNodeClaims(cluster)
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Name).To(Equal(inFlight.Name))
+		Expect(nodeClaims[0].Zone).To(Equal("test-zone-2"))
+		Expect(nodeClaims[0].Registered).To(BeFalse())
+	})
+	It("should summarize nodes and in-flight NodeClaims by topology domain", func() {
+		domains := server.TopologyDomains(cluster)
+		Expect(domains[corev1.LabelTopologyZone].Domains).To(HaveKeyWithValue("test-zone-1", HaveField("Nodes", BeEquivalentTo(1))))
+		Expect(domains[corev1.LabelTopologyZone].Domains).To(HaveKeyWithValue("test-zone-2", HaveField("InFlight", BeEquivalentTo(1))))
+		Expect(domains[v1.NodePoolLabelKey].Domains).To(HaveKeyWithValue(nodePool.Name, And(HaveField("Nodes", BeEquivalentTo(1)), HaveField("InFlight", BeEquivalentTo(1)))))
+	})
+	It("should not count nodes that are marked for deletion in topology domains", func() {
+		cluster.MarkForDeletion(nodeClaim.Status.ProviderID)
+		domains := server.TopologyDomains(cluster)
+		Expect(domains[corev1.LabelTopologyZone].Domains).ToNot(HaveKey("test-zone-1"))
+		Expect(server.Nodes(cluster)[0].MarkedForDeletion).To(BeTrue())
+	})
+	It("should serve summaries as typed messages", func() {
+		nodes, err := stateServer.ListNodes(ctx, &server.ListNodesRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodes.GetNodes()).To(HaveLen(1))
+		Expect(nodes.GetNodes()[0].GetName()).To(Equal(node.Name))
+		Expect(nodes.GetNodes()[0].GetAllocatable()).To(HaveKeyWithValue(string(corev1.ResourceCPU), node.Status.Allocatable.Cpu().String()))
+
+		nodeClaims, err := stateServer.ListInFlightNodeClaims(ctx, &server.ListInFlightNodeClaimsRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaims.GetNodeClaims()).To(HaveLen(1))
+		Expect(nodeClaims.GetNodeClaims()[0].GetCreationTimestamp().AsTime()).To(BeTemporally("==", inFlight.CreationTimestamp.Time))
+
+		domains, err := stateServer.ListTopologyDomains(ctx, &server.ListTopologyDomainsRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(domains.GetTopologyDomains()[corev1.LabelTopologyZone].GetDomains()["test-zone-2"].GetInFlight()).To(BeEquivalentTo(1))
+	})
+})
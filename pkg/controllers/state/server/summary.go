@@ -0,0 +1,148 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// TopologyKeys are the labels that topology domains are summarized for
+var TopologyKeys = []string{
+	corev1.LabelTopologyZone,
+	v1.CapacityTypeLabelKey,
+	corev1.LabelInstanceTypeStable,
+	v1.NodePoolLabelKey,
+}
+
+// Nodes returns the summaries of the initialized nodes in cluster state
+func Nodes(cluster *state.Cluster) []*Node {
+	var nodes []*Node
+	cluster.ForEachNode(func(n *state.StateNode) bool {
+		if !n.Initialized() || n.Node == nil {
+			return true
+		}
+		labels := n.Labels()
+		nodes = append(nodes, &Node{
+			Name:              n.Name(),
+			NodeClaim:         nodeClaimName(n),
+			ProviderId:        n.ProviderID(),
+			NodePool:          labels[v1.NodePoolLabelKey],
+			InstanceType:      labels[corev1.LabelInstanceTypeStable],
+			Zone:              labels[corev1.LabelTopologyZone],
+			CapacityType:      labels[v1.CapacityTypeLabelKey],
+			MarkedForDeletion: n.MarkedForDeletion(),
+			Nominated:         n.Nominated(),
+			Allocatable:       toStrings(n.Allocatable()),
+			Available:         toStrings(n.Available()),
+		})
+		return true
+	})
+	return nodes
+}
+
+// InFlightNodeClaims returns the summaries of the NodeClaims in cluster state that are launching
+func InFlightNodeClaims(cluster *state.Cluster) []*NodeClaim {
+	var nodeClaims []*NodeClaim
+	cluster.ForEachNode(func(n *state.StateNode) bool {
+		if !isInFlight(n) {
+			return true
+		}
+		labels := n.Labels()
+		nodeClaims = append(nodeClaims, &NodeClaim{
+			Name:              n.NodeClaim.Name,
+			ProviderId:        n.ProviderID(),
+			NodePool:          labels[v1.NodePoolLabelKey],
+			InstanceType:      labels[corev1.LabelInstanceTypeStable],
+			Zone:              labels[corev1.LabelTopologyZone],
+			CapacityType:      labels[v1.CapacityTypeLabelKey],
+			Registered:        n.Registered(),
+			Nominated:         n.Nominated(),
+			Available:         toStrings(n.Available()),
+			CreationTimestamp: timestamppb.New(n.NodeClaim.CreationTimestamp.Time),
+		})
+		return true
+	})
+	return nodeClaims
+}
+
+// TopologyDomains returns the summaries of the capacity in each domain of the TopologyKeys, by key. Nodes that are
+// marked for deletion aren't counted, since their capacity is going away.
+func TopologyDomains(cluster *state.Cluster) map[string]*TopologyKeyDomains {
+	counts := map[string]map[string]*TopologyDomain{}
+	available := map[string]map[string]corev1.ResourceList{}
+	for _, key := range TopologyKeys {
+		counts[key] = map[string]*TopologyDomain{}
+		available[key] = map[string]corev1.ResourceList{}
+	}
+	cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.MarkedForDeletion() || (!n.Initialized() && !isInFlight(n)) {
+			return true
+		}
+		for _, key := range TopologyKeys {
+			value, ok := n.Labels()[key]
+			if !ok {
+				continue
+			}
+			domain, ok := counts[key][value]
+			if !ok {
+				domain = &TopologyDomain{}
+				counts[key][value] = domain
+			}
+			if isInFlight(n) {
+				domain.InFlight++
+			} else {
+				domain.Nodes++
+			}
+			available[key][value] = resources.Merge(available[key][value], n.Available())
+		}
+		return true
+	})
+	domains := map[string]*TopologyKeyDomains{}
+	for key, values := range counts {
+		domains[key] = &TopologyKeyDomains{Domains: values}
+		for value, domain := range values {
+			domain.Available = toStrings(available[key][value])
+		}
+	}
+	return domains
+}
+
+// isInFlight returns true if the state node is a NodeClaim that is launching
+func isInFlight(n *state.StateNode) bool {
+	return n.NodeClaim != nil && !n.Initialized() && !n.MarkedForDeletion()
+}
+
+func nodeClaimName(n *state.StateNode) string {
+	if n.NodeClaim == nil {
+		return ""
+	}
+	return n.NodeClaim.Name
+}
+
+// toStrings encodes the resource quantities as strings, e.g. "1500m"
+func toStrings(list corev1.ResourceList) map[string]string {
+	out := map[string]string{}
+	for name, quantity := range list {
+		out[string(name)] = quantity.String()
+	}
+	return out
+}
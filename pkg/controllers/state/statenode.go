@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -37,6 +38,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
+	volumeutil "sigs.k8s.io/karpenter/pkg/utils/volume"
 )
 
 type PodBlockEvictionError struct {
@@ -62,6 +64,44 @@ func IgnorePodBlockEvictionError(err error) error {
 	return err
 }
 
+func (e *PodBlockEvictionError) Unwrap() error {
+	return e.error
+}
+
+// DisruptionBlockedCause is a cause that users control which blocks a node from being disrupted
+type DisruptionBlockedCause string
+
+const (
+	DisruptionBlockedByPDB          DisruptionBlockedCause = "pdb"
+	DisruptionBlockedByDoNotDisrupt DisruptionBlockedCause = "do_not_disrupt"
+	DisruptionBlockedByNomination   DisruptionBlockedCause = "nomination"
+	DisruptionBlockedByLocalVolume  DisruptionBlockedCause = "local_volume"
+	DisruptionBlockedByJob          DisruptionBlockedCause = "job"
+)
+
+// DisruptionBlockedError is returned when a node can't be disrupted because of a DisruptionBlockedCause
+type DisruptionBlockedError struct {
+	error
+	Cause DisruptionBlockedCause
+}
+
+func NewDisruptionBlockedError(cause DisruptionBlockedCause, err error) *DisruptionBlockedError {
+	return &DisruptionBlockedError{error: err, Cause: cause}
+}
+
+func (e *DisruptionBlockedError) Unwrap() error {
+	return e.error
+}
+
+// GetDisruptionBlockedCause returns the cause that blocks disruption if the error is a DisruptionBlockedError
+func GetDisruptionBlockedCause(err error) (DisruptionBlockedCause, bool) {
+	var disruptionBlockedError *DisruptionBlockedError
+	if !stderrors.As(err, &disruptionBlockedError) {
+		return "", false
+	}
+	return disruptionBlockedError.Cause, true
+}
+
 //go:generate controller-gen object:headerFile="../../../hack/boilerplate.go.txt" paths="."
 
 // StateNodes is a typed version of a list of *Node
@@ -131,6 +171,11 @@ type StateNode struct {
 	// of the karpenter.sh/disruption taint to know when a node is marked for deletion.
 	markedForDeletion bool
 	nominatedUntil    metav1.Time
+
+	// lastHeartbeat is the last time that the kubelet renewed the node's Lease, and heartbeatTimeout is the duration
+	// of the Lease, after which the kubelet is considered to have stopped heartbeating
+	lastHeartbeat    metav1.Time
+	heartbeatTimeout time.Duration
 }
 
 func NewNode() *StateNode {
@@ -195,10 +240,10 @@ func (in *StateNode) ValidateNodeDisruptable() error {
 	}
 	// skip the node if it is nominated by a recent provisioning pass to be the target of a pending pod.
 	if in.Nominated() {
-		return fmt.Errorf("node is nominated for a pending pod")
+		return NewDisruptionBlockedError(DisruptionBlockedByNomination, fmt.Errorf("node is nominated for a pending pod"))
 	}
 	if in.Annotations()[v1.DoNotDisruptAnnotationKey] == "true" {
-		return fmt.Errorf("disruption is blocked through the %q annotation", v1.DoNotDisruptAnnotationKey)
+		return NewDisruptionBlockedError(DisruptionBlockedByDoNotDisrupt, fmt.Errorf("disruption is blocked through the %q annotation", v1.DoNotDisruptAnnotationKey))
 	}
 	// check whether the node has the NodePool label
 	if _, ok := in.Labels()[v1.NodePoolLabelKey]; !ok {
@@ -208,24 +253,50 @@ func (in *StateNode) ValidateNodeDisruptable() error {
 }
 
 // ValidatePodDisruptable returns an error if the StateNode contains a pod that cannot be disrupted
-// This checks associated PDBs and do-not-disrupt annotations for each pod on the node.
+// This checks associated PDBs, do-not-disrupt and emptydir-protection annotations for each pod on the node, as well as
+// local volumes when they are protected. Pods in force-evict namespaces are ignored.
 // ValidatePodDisruptable takes in a recorder to emit events on the nodeclaims when the state node is not a candidate
 //
 //nolint:gocyclo
-func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, kubeClient client.Client, pdbs pdb.Limits) ([]*corev1.Pod, error) {
+func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, kubeClient client.Client, pdbs pdb.Limits, clk clock.Clock) ([]*corev1.Pod, error) {
 	pods, err := in.Pods(ctx, kubeClient)
 	if err != nil {
 		return nil, fmt.Errorf("getting pods from node, %w", err)
 	}
 	for _, po := range pods {
+		// Pods in force-evict namespaces never block disruption
+		if podutils.IsForceEvicted(ctx, po) {
+			continue
+		}
 		// We only consider pods that are actively running for "karpenter.sh/do-not-disrupt"
 		// This means that we will allow Mirror Pods and DaemonSets to block disruption using this annotation
 		if !podutils.IsDisruptable(po) {
-			return pods, NewPodBlockEvictionError(fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po)))
+			return pods, NewPodBlockEvictionError(NewDisruptionBlockedError(DisruptionBlockedByDoNotDisrupt, fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po))))
+		}
+		// "karpenter.sh/emptydir-protection" acts as a do-not-disrupt that expires after the configured duration
+		if podutils.IsActive(po) && podutils.HasEmptyDirProtection(po, clk) {
+			return pods, NewPodBlockEvictionError(NewDisruptionBlockedError(DisruptionBlockedByDoNotDisrupt, fmt.Errorf(`pod %q has an unexpired "karpenter.sh/emptydir-protection" annotation`, client.ObjectKeyFromObject(po))))
+		}
+		// Job pods that are about to finish block disruption for at most the job completion window, so that their work
+		// isn't wasted
+		if nearlyFinished, err := podutils.IsNearlyFinishedJob(ctx, kubeClient, clk, po, options.FromContext(ctx).JobCompletionWindow); err != nil {
+			return pods, fmt.Errorf("checking job completion, %w", err)
+		} else if nearlyFinished {
+			return pods, NewPodBlockEvictionError(NewDisruptionBlockedError(DisruptionBlockedByJob, fmt.Errorf("pod %q is expected to finish its job within %s", client.ObjectKeyFromObject(po), options.FromContext(ctx).JobCompletionWindow)))
+		}
+		// The data on local volumes is lost with the node, so they block disruption unless their claim is replaceable
+		if options.FromContext(ctx).ProtectLocalVolumes && podutils.IsActive(po) {
+			localVolumes, err := volumeutil.GetLocalVolumes(ctx, kubeClient, po)
+			if err != nil {
+				return pods, fmt.Errorf("getting local volumes, %w", err)
+			}
+			if lv, ok := lo.Find(localVolumes, func(lv volumeutil.LocalVolume) bool { return !lv.Replaceable() }); ok {
+				return pods, NewPodBlockEvictionError(NewDisruptionBlockedError(DisruptionBlockedByLocalVolume, fmt.Errorf(`pod %q uses local volume %s without the "%s=true" annotation`, client.ObjectKeyFromObject(po), lv, v1.ReplaceableAnnotationKey)))
+			}
 		}
 	}
-	if pdbKey, ok := pdbs.CanEvictPods(pods); !ok {
-		return pods, NewPodBlockEvictionError(fmt.Errorf("pdb %q prevents pod evictions", pdbKey))
+	if pdbKey, ok := pdbs.CanEvictPods(lo.Reject(pods, func(p *corev1.Pod, _ int) bool { return podutils.IsForceEvicted(ctx, p) })); !ok {
+		return pods, NewPodBlockEvictionError(NewDisruptionBlockedError(DisruptionBlockedByPDB, fmt.Errorf("pdb %q prevents pod evictions", pdbKey)))
 	}
 
 	return pods, nil
@@ -286,6 +357,16 @@ func (in *StateNode) Taints() []corev1.Taint {
 	} else {
 		taints = in.Node.Spec.Taints
 	}
+	if in.Managed() {
+		// We reject temporary taints since Karpenter removes them from the node once they expire, in the same way
+		// that startup taints are removed once the node starts up
+		taints = lo.Reject(taints, func(taint corev1.Taint, _ int) bool {
+			_, found := lo.Find(in.NodeClaim.Spec.TemporaryTaints, func(t v1.TemporaryTaint) bool {
+				return t.MatchTaint(&taint)
+			})
+			return found
+		})
+	}
 	if !in.Initialized() && in.Managed() {
 		// We reject any well-known ephemeral taints and startup taints attached to this node until
 		// the node is initialized. Without this, if the taint is generic and re-appears on the node for a
@@ -389,6 +470,11 @@ func (in *StateNode) PodRequests() corev1.ResourceList {
 	return totalRequests
 }
 
+// PodRequestsByPod returns the requests of each pod that is bound to the node
+func (in *StateNode) PodRequestsByPod() []corev1.ResourceList {
+	return lo.Values(in.podRequests)
+}
+
 func (in *StateNode) PodLimits() corev1.ResourceList {
 	return resources.Merge(lo.Values(in.podLimits)...)
 }
@@ -414,6 +500,21 @@ func (in *StateNode) Nominated() bool {
 	return in.nominatedUntil.After(time.Now())
 }
 
+// LastHeartbeat returns the last time that the kubelet renewed the node's Lease, or the zero time if the Lease
+// hasn't been seen
+func (in *StateNode) LastHeartbeat() time.Time {
+	return in.lastHeartbeat.Time
+}
+
+// HeartbeatExpired returns true if the kubelet hasn't renewed the node's Lease within the Lease's duration. Nodes
+// whose Leases haven't been seen aren't considered expired, since their liveness is unknown.
+func (in *StateNode) HeartbeatExpired(now time.Time) bool {
+	if in.lastHeartbeat.IsZero() || in.heartbeatTimeout == 0 {
+		return false
+	}
+	return now.Sub(in.lastHeartbeat.Time) > in.heartbeatTimeout
+}
+
 func (in *StateNode) Managed() bool {
 	return in.NodeClaim != nil
 }
@@ -527,3 +628,47 @@ func ClearNodeClaimsCondition(ctx context.Context, kubeClient client.Client, con
 		return nil
 	})...)
 }
+
+// RequireDisruptionLock locks or unlocks the nodes for disruption by annotating their Node and NodeClaim with the ID of
+// the disruption command that holds the lock and the time that the lock expires at. This makes the disruption commands
+// that are in flight visible to users and to other controllers. The nodes are unlocked if the commandID is empty.
+func RequireDisruptionLock(ctx context.Context, kubeClient client.Client, commandID string, expiration time.Time, nodes ...*StateNode) error {
+	var multiErr error
+	for _, n := range nodes {
+		var objs []client.Object
+		if n.Node != nil {
+			objs = append(objs, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: n.Node.Name}})
+		}
+		if n.NodeClaim != nil {
+			objs = append(objs, &v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: n.NodeClaim.Name}})
+		}
+		for _, obj := range objs {
+			if err := requireDisruptionLock(ctx, kubeClient, obj, commandID, expiration); err != nil {
+				multiErr = multierr.Append(multiErr, err)
+			}
+		}
+	}
+	return multiErr
+}
+
+func requireDisruptionLock(ctx context.Context, kubeClient client.Client, obj client.Object, commandID string, expiration time.Time) error {
+	if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	stored := obj.DeepCopyObject().(client.Object)
+	annotations := lo.OmitByKeys(obj.GetAnnotations(), []string{v1.DisruptionLockAnnotationKey, v1.DisruptionLockExpirationAnnotationKey})
+	if commandID != "" {
+		annotations = lo.Assign(annotations, map[string]string{
+			v1.DisruptionLockAnnotationKey:           commandID,
+			v1.DisruptionLockExpirationAnnotationKey: expiration.UTC().Format(time.RFC3339),
+		})
+	}
+	obj.SetAnnotations(annotations)
+	if equality.Semantic.DeepEqual(stored, obj) {
+		return nil
+	}
+	if err := kubeClient.Patch(ctx, obj, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("patching %s, %w", obj.GetName(), err)
+	}
+	return nil
+}
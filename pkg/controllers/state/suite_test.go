@@ -27,14 +27,17 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
 	cloudproviderapi "k8s.io/cloud-provider/api"
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -58,7 +61,9 @@ var nodeController *informer.NodeController
 var podController *informer.PodController
 var nodePoolController *informer.NodePoolController
 var daemonsetController *informer.DaemonSetController
+var volumeController *informer.VolumeController
 var cloudProvider *fake.CloudProvider
+var recorder *test.EventRecorder
 var nodePool *v1.NodePool
 
 const csiProvider = "fake.csi.provider"
@@ -74,12 +79,14 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	cloudProvider = fake.NewCloudProvider()
 	fakeClock = clock.NewFakeClock(time.Now())
-	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	recorder = test.NewEventRecorder()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider, recorder)
 	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	nodeController = informer.NewNodeController(env.Client, cluster)
 	podController = informer.NewPodController(env.Client, cluster)
 	nodePoolController = informer.NewNodePoolController(env.Client, cloudProvider, cluster)
 	daemonsetController = informer.NewDaemonSetController(env.Client, cluster)
+	volumeController = informer.NewVolumeController(env.Client, cluster)
 })
 
 var _ = AfterSuite(func() {
@@ -88,6 +95,7 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	fakeClock.SetTime(time.Now())
+	recorder.Reset()
 	state.ClusterStateUnsyncedTimeSeconds.Reset()
 	cloudProvider.InstanceTypes = fake.InstanceTypesAssorted()
 	nodePool = test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
@@ -117,6 +125,77 @@ var _ = Describe("Pod Ack", func() {
 	})
 })
 
+var _ = Describe("Pod Updates", func() {
+	var q workqueue.TypedRateLimitingInterface[reconcile.Request]
+	var pod *corev1.Pod
+	BeforeEach(func() {
+		state.PodUpdatesSuppressedTotal.Reset()
+		q = workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		pod = test.Pod()
+	})
+	AfterEach(func() {
+		q.ShutDown()
+	})
+	It("should suppress updates that don't change the fields that cluster state tracks", func() {
+		updated := pod.DeepCopy()
+		updated.Status.Conditions = append(updated.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue})
+		updated.Annotations = map[string]string{"foo": "bar"}
+		podController.OnUpdate(pod, updated, q)
+		Expect(q.Len()).To(Equal(0))
+		ExpectMetricCounterValue(state.PodUpdatesSuppressedTotal, 1, map[string]string{"reason": informer.SuppressedReasonUntracked})
+	})
+	It("should enqueue updates to the resize status and actuated resources of containers", func() {
+		resizing := pod.DeepCopy()
+		resizing.Status.Resize = corev1.PodResizeStatusInProgress
+		podController.OnUpdate(pod, resizing, q)
+		Eventually(q.Len).Should(Equal(1))
+		req, _ := q.Get()
+		q.Done(req)
+		// Reconciling the pod ends the coalescing of its updates
+		ExpectReconcileSucceeded(ctx, podController, req.NamespacedName)
+
+		resized := resizing.DeepCopy()
+		resized.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:      resized.Spec.Containers[0].Name,
+			Resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+		}}
+		podController.OnUpdate(resizing, resized, q)
+		Eventually(q.Len).Should(Equal(1))
+	})
+	It("should immediately enqueue pods that are bound or go terminal", func() {
+		bound := pod.DeepCopy()
+		bound.Spec.NodeName = "node"
+		podController.OnUpdate(pod, bound, q)
+		Expect(q.Len()).To(Equal(1))
+		req, _ := q.Get()
+		q.Done(req)
+		Expect(req.NamespacedName).To(Equal(client.ObjectKeyFromObject(pod)))
+
+		terminal := bound.DeepCopy()
+		terminal.Status.Phase = corev1.PodSucceeded
+		podController.OnUpdate(bound, terminal, q)
+		Expect(q.Len()).To(Equal(1))
+	})
+	It("should coalesce updates to the same pod until it's reconciled", func() {
+		first := pod.DeepCopy()
+		first.Labels = lo.Assign(first.Labels, map[string]string{"foo": "bar"})
+		second := first.DeepCopy()
+		second.Labels = lo.Assign(second.Labels, map[string]string{"foo": "baz"})
+		podController.OnUpdate(pod, first, q)
+		podController.OnUpdate(first, second, q)
+		ExpectMetricCounterValue(state.PodUpdatesSuppressedTotal, 1, map[string]string{"reason": informer.SuppressedReasonCoalesced})
+
+		// Coalesced updates are enqueued once the coalescing window elapses
+		Eventually(q.Len).Should(Equal(1))
+
+		// Updates are no longer coalesced once the pod is reconciled
+		ExpectApplied(ctx, env.Client, second)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(second))
+		podController.OnUpdate(first, second, q)
+		ExpectMetricCounterValue(state.PodUpdatesSuppressedTotal, 1, map[string]string{"reason": informer.SuppressedReasonCoalesced})
+	})
+})
+
 var _ = Describe("Volume Usage/Limits", func() {
 	var nodeClaim *v1.NodeClaim
 	var node *corev1.Node
@@ -436,6 +515,41 @@ var _ = Describe("Node Resource Level", func() {
 		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod2))
 		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3.5")}, ExpectStateNodeExists(cluster, node).PodRequests())
 	})
+	It("should update the pod requests of nodes when pods are resized in-place", func() {
+		pod := test.UnschedulablePod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU: resource.MustParse("1.5"),
+				}},
+		})
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: cloudProvider.InstanceTypes[0].Name,
+			}},
+			Allocatable: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceCPU: resource.MustParse("4"),
+			},
+			ProviderID: test.RandomProviderID(),
+		})
+		ExpectApplied(ctx, env.Client, pod, node)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")}, ExpectStateNodeExists(cluster, node).PodRequests())
+
+		// The pod's requests are lowered, but the node reserves the actuated requests until the resize is actuated
+		pod = ExpectExists(ctx, env.Client, pod)
+		pod.Status.Resize = corev1.PodResizeStatusInProgress
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:      pod.Spec.Containers[0].Name,
+			Resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}},
+		}}
+		ExpectApplied(ctx, env.Client, pod)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}, ExpectStateNodeExists(cluster, node).PodRequests())
+		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, ExpectStateNodeExists(cluster, node).Available())
+	})
 	It("should count existing pods bound to nodes", func() {
 		pod1 := test.UnschedulablePod(test.PodOptions{
 			ResourceRequirements: corev1.ResourceRequirements{
@@ -1463,6 +1577,211 @@ var _ = Describe("DaemonSet Controller", func() {
 	})
 })
 
+var _ = Describe("Volume Controller", func() {
+	var requirements []corev1.NodeSelectorRequirement
+	BeforeEach(func() {
+		requirements = []corev1.NodeSelectorRequirement{{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}
+	})
+	It("should return cached volume requirements", func() {
+		pvc := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: lo.ToPtr("test-storage-class")})
+		ExpectApplied(ctx, env.Client, pvc)
+		cluster.UpdateVolumeRequirements(pvc, requirements)
+		ExpectReconcileSucceeded(ctx, volumeController, client.ObjectKeyFromObject(pvc))
+
+		cached, ok := cluster.VolumeRequirements(pvc)
+		Expect(ok).To(BeTrue())
+		Expect(cached).To(Equal(requirements))
+	})
+	It("should invalidate volume requirements when the pvc is bound", func() {
+		pvc := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: lo.ToPtr("test-storage-class")})
+		ExpectApplied(ctx, env.Client, pvc)
+		cluster.UpdateVolumeRequirements(pvc, requirements)
+
+		pvc.Spec.VolumeName = "test-volume"
+		ExpectApplied(ctx, env.Client, pvc)
+		ExpectReconcileSucceeded(ctx, volumeController, client.ObjectKeyFromObject(pvc))
+		_, ok := cluster.VolumeRequirements(pvc)
+		Expect(ok).To(BeFalse())
+	})
+	It("should invalidate volume requirements when the pvc is deleted", func() {
+		pvc := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: lo.ToPtr("test-storage-class")})
+		ExpectApplied(ctx, env.Client, pvc)
+		cluster.UpdateVolumeRequirements(pvc, requirements)
+
+		ExpectDeleted(ctx, env.Client, pvc)
+		ExpectReconcileSucceeded(ctx, volumeController, client.ObjectKeyFromObject(pvc))
+		_, ok := cluster.VolumeRequirements(pvc)
+		Expect(ok).To(BeFalse())
+	})
+	It("should invalidate volume requirements when the persistent volume changes", func() {
+		pvc := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: "test-volume", StorageClassName: lo.ToPtr("test-storage-class")})
+		cluster.UpdateVolumeRequirements(pvc, requirements)
+
+		cluster.DeletePersistentVolume("test-volume")
+		_, ok := cluster.VolumeRequirements(pvc)
+		Expect(ok).To(BeFalse())
+	})
+	It("should invalidate volume requirements of unbound pvcs when the storage class changes", func() {
+		unbound := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: lo.ToPtr("test-storage-class")})
+		bound := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: "test-volume", StorageClassName: lo.ToPtr("test-storage-class")})
+		cluster.UpdateVolumeRequirements(unbound, requirements)
+		cluster.UpdateVolumeRequirements(bound, requirements)
+
+		cluster.DeleteStorageClass("test-storage-class")
+		_, ok := cluster.VolumeRequirements(unbound)
+		Expect(ok).To(BeFalse())
+		_, ok = cluster.VolumeRequirements(bound)
+		Expect(ok).To(BeTrue())
+	})
+})
+
+var _ = Describe("Lease Controller", func() {
+	var node *corev1.Node
+	var lease *coordinationv1.Lease
+	BeforeEach(func() {
+		node = test.Node()
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: node.Name, Namespace: corev1.NamespaceNodeLease},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       lo.ToPtr(node.Name),
+				RenewTime:            &metav1.MicroTime{Time: fakeClock.Now().Truncate(time.Second)},
+				LeaseDurationSeconds: lo.ToPtr[int32](40),
+			},
+		}
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+	})
+	It("should track the last heartbeat of a node", func() {
+		cluster.UpdateLease(lease)
+		Expect(ExpectStateNodeExists(cluster, node).LastHeartbeat()).To(Equal(lease.Spec.RenewTime.Time))
+		Expect(ExpectStateNodeExists(cluster, node).HeartbeatExpired(fakeClock.Now())).To(BeFalse())
+	})
+	It("should keep the last heartbeat when the node is updated", func() {
+		cluster.UpdateLease(lease)
+		node.Labels["test-label"] = "test-value"
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		Expect(ExpectStateNodeExists(cluster, node).LastHeartbeat()).To(Equal(lease.Spec.RenewTime.Time))
+	})
+	It("should consider the heartbeat expired once the lease duration has elapsed", func() {
+		cluster.UpdateLease(lease)
+		fakeClock.Step(time.Minute)
+		Expect(ExpectStateNodeExists(cluster, node).HeartbeatExpired(fakeClock.Now())).To(BeTrue())
+	})
+	It("should not consider the heartbeat of a node without a lease expired", func() {
+		Expect(ExpectStateNodeExists(cluster, node).LastHeartbeat().IsZero()).To(BeTrue())
+		Expect(ExpectStateNodeExists(cluster, node).HeartbeatExpired(fakeClock.Now())).To(BeFalse())
+	})
+	It("should forget the heartbeat when the lease is deleted", func() {
+		cluster.UpdateLease(lease)
+		ExpectReconcileSucceeded(ctx, informer.NewLeaseController(env.Client, cluster), client.ObjectKeyFromObject(lease))
+		Expect(ExpectStateNodeExists(cluster, node).LastHeartbeat().IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Provider ID Linking", func() {
+	var linkingCluster *state.Cluster
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	BeforeEach(func() {
+		linkingCluster = state.NewCluster(fakeClock, env.Client, fake.NewInstanceIDNormalizerCloudProvider(cloudProvider), recorder)
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: cloudProvider.InstanceTypes[0].Name,
+			}},
+			Status: v1.NodeClaimStatus{ProviderID: "fake:///test-zone-1/I-0123456789"},
+		})
+		node.Spec.ProviderID = "fake:///i-0123456789"
+	})
+	stateNodeCount := func() int {
+		return len(linkingCluster.Nodes())
+	}
+	It("should link a nodeclaim to a node with the same instance id", func() {
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		Expect(stateNodeCount()).To(Equal(1))
+		stateNode := ExpectStateNodeExists(linkingCluster, node)
+		Expect(stateNode.NodeClaim).ToNot(BeNil())
+		Expect(stateNode.NodeClaim.Name).To(Equal(nodeClaim.Name))
+		Expect(recorder.DetectedEvent(fmt.Sprintf("Linked provider ID %q to node provider ID %q by normalized provider ID", nodeClaim.Status.ProviderID, node.Spec.ProviderID))).To(BeTrue())
+	})
+	It("should link a node to a nodeclaim with the same instance id", func() {
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		// Updating again keeps the nodeclaim linked
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+
+		Expect(stateNodeCount()).To(Equal(1))
+		stateNode := ExpectStateNodeExists(linkingCluster, node)
+		Expect(stateNode.NodeClaim).ToNot(BeNil())
+		Expect(stateNode.NodeClaim.Name).To(Equal(nodeClaim.Name))
+		Expect(recorder.Calls("ProviderIDLinked")).To(Equal(1))
+		Expect(linkingCluster.NodeClaimProviderIDs()).To(HaveKeyWithValue(nodeClaim.Name, nodeClaim.Status.ProviderID))
+	})
+	It("should mark a linked nodeclaim for deletion by its own provider id", func() {
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+
+		linkingCluster.MarkForDeletion(nodeClaim.Status.ProviderID)
+		Expect(ExpectStateNodeExists(linkingCluster, node).MarkedForDeletion()).To(BeTrue())
+	})
+	It("should remove the linked nodeclaim and node once both are deleted", func() {
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		linkingCluster.DeleteNode(node.Name)
+		Expect(stateNodeCount()).To(Equal(1))
+		linkingCluster.DeleteNodeClaim(nodeClaim.Name)
+		Expect(stateNodeCount()).To(Equal(0))
+	})
+	It("should not link nodeclaims and nodes of different instances", func() {
+		node.Spec.ProviderID = "fake:///i-9876543210"
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		Expect(stateNodeCount()).To(Equal(2))
+		Expect(recorder.Calls("ProviderIDLinked")).To(Equal(0))
+	})
+	It("should not link nodes that don't belong to a nodepool", func() {
+		delete(node.Labels, v1.NodePoolLabelKey)
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		Expect(stateNodeCount()).To(Equal(2))
+		Expect(recorder.Calls("ProviderIDLinked")).To(Equal(0))
+	})
+	It("should not link provider ids when the cloud provider doesn't normalize them", func() {
+		cluster.UpdateNodeClaim(nodeClaim)
+		Expect(cluster.UpdateNode(ctx, node)).To(Succeed())
+
+		ExpectStateNodeCount("==", 2)
+		Expect(recorder.Calls("ProviderIDLinked")).To(Equal(0))
+	})
+	It("should link provider ids that the cloud provider normalizes to the same provider id", func() {
+		linkingCluster = state.NewCluster(fakeClock, env.Client, fake.NewProviderIDNormalizerCloudProvider(cloudProvider), recorder)
+		node.Spec.ProviderID = "FAKE:///TEST-ZONE-1/I-0123456789"
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		stateNode := ExpectStateNodeExists(linkingCluster, node)
+		Expect(stateNode.NodeClaim).ToNot(BeNil())
+		Expect(recorder.DetectedEvent(fmt.Sprintf("Linked provider ID %q to node provider ID %q by normalized provider ID", nodeClaim.Status.ProviderID, node.Spec.ProviderID))).To(BeTrue())
+	})
+	It("should link a replacement nodeclaim once the previous one is deleted", func() {
+		linkingCluster.UpdateNodeClaim(nodeClaim)
+		linkingCluster.DeleteNodeClaim(nodeClaim.Name)
+		replacement := nodeClaim.DeepCopy()
+		replacement.Name = "replacement"
+		linkingCluster.UpdateNodeClaim(replacement)
+		Expect(linkingCluster.UpdateNode(ctx, node)).To(Succeed())
+
+		Expect(stateNodeCount()).To(Equal(1))
+		Expect(ExpectStateNodeExists(linkingCluster, node).NodeClaim.Name).To(Equal("replacement"))
+	})
+})
+
 var _ = Describe("Consolidated State", func() {
 	It("should update the consolidated value when setting consolidation", func() {
 		state := cluster.ConsolidationState()
@@ -1642,6 +1961,26 @@ var _ = Describe("Taints", func() {
 				corev1.Taint{Key: "taint-key2", Value: "taint-value2", Effect: corev1.TaintEffectNoExecute},
 			))
 		})
+		It("should not consider temporary taints on a managed node after the node is initialized", func() {
+			nodeClaim.Spec.TemporaryTaints = []v1.TemporaryTaint{
+				{Taint: corev1.Taint{Key: "taint-key", Value: "taint-value", Effect: corev1.TaintEffectNoSchedule}, ExpireAfter: &metav1.Duration{Duration: time.Hour}},
+			}
+			node.Spec.Taints = []corev1.Taint{
+				{Key: "taint-key", Value: "taint-value", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "taint-key2", Value: "taint-value2", Effect: corev1.TaintEffectNoExecute},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+			stateNode := ExpectStateNodeExists(cluster, node)
+			Expect(stateNode.Taints()).To(ConsistOf(
+				corev1.Taint{Key: "taint-key2", Value: "taint-value2", Effect: corev1.TaintEffectNoExecute},
+			))
+		})
 	})
 	Context("Unmanaged", func() {
 		It("should consider ephemeral taints on an unmanaged node that isn't initialized", func() {
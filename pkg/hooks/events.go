@@ -0,0 +1,38 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func HookFailed(object client.Object, hook string, point Point, err error) events.Event {
+	return events.Event{
+		InvolvedObject: object,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "LifecycleHookFailed",
+		Message:        fmt.Sprintf("%s hook %q failed, %s", point, hook, err),
+		DedupeValues:   []string{string(object.GetUID()), hook, string(point)},
+		DedupeTimeout:  time.Minute,
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Point is a point in the lifecycle of a NodeClaim and its Node at which hooks are run
+type Point string
+
+const (
+	// PreLaunch hooks run before the CloudProvider is called to launch the NodeClaim
+	PreLaunch Point = "PreLaunch"
+	// PostInitialization hooks run once the Node is ready to be initialized, before the NodeClaim is marked as initialized
+	PostInitialization Point = "PostInitialization"
+	// PreDrain hooks run after the Node is tainted for termination, before its pods are drained
+	PreDrain Point = "PreDrain"
+	// PostTermination hooks run after the instance is terminated, before the NodeClaim's finalizer is removed
+	PostTermination Point = "PostTermination"
+)
+
+var Points = []Point{PreLaunch, PostInitialization, PreDrain, PostTermination}
+
+// FailurePolicy defines how the lifecycle of a NodeClaim proceeds when a hook fails
+type FailurePolicy string
+
+const (
+	// FailurePolicyFail blocks the lifecycle step and retries the hook until it succeeds
+	FailurePolicyFail FailurePolicy = "Fail"
+	// FailurePolicyIgnore records the failure and proceeds with the lifecycle step
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+)
+
+const (
+	DefaultHTTPTimeout = 10 * time.Second
+	DefaultJobTimeout  = 10 * time.Minute
+)
+
+// Config is the configuration file of the lifecycle hooks
+type Config struct {
+	Hooks []Hook `json:"hooks"`
+}
+
+// Hook calls out to an HTTP endpoint or runs a Job in the cluster at the given lifecycle points. Hooks can be
+// retried, so the actions that they take must be idempotent.
+type Hook struct {
+	// Name uniquely identifies the hook
+	Name string `json:"name"`
+	// Points are the lifecycle points that the hook runs at
+	Points []Point `json:"points"`
+	// FailurePolicy defaults to Fail
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+	// Timeout bounds how long the hook can take. It defaults to DefaultHTTPTimeout for HTTP hooks and to
+	// DefaultJobTimeout for Job hooks.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// HTTP calls out to an HTTP endpoint
+	HTTP *HTTPAction `json:"http,omitempty"`
+	// Job runs a Job in the cluster
+	Job *JobAction `json:"job,omitempty"`
+}
+
+// HTTPAction POSTs a JSON encoded Request to the URL. Any status code other than 2xx fails the hook.
+type HTTPAction struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// JobAction creates a Job from the template. The details of the lifecycle point are passed to the Job's containers
+// through environment variables. The hook succeeds once the Job completes.
+type JobAction struct {
+	Namespace string          `json:"namespace"`
+	Template  batchv1.JobSpec `json:"template"`
+}
+
+func (h Hook) timeout() time.Duration {
+	if h.Timeout != nil {
+		return h.Timeout.Duration
+	}
+	if h.Job != nil {
+		return DefaultJobTimeout
+	}
+	return DefaultHTTPTimeout
+}
+
+func (h Hook) failurePolicy() FailurePolicy {
+	return lo.Ternary(h.FailurePolicy == "", FailurePolicyFail, h.FailurePolicy)
+}
+
+// Load reads the hooks from the configuration file at the given path. No hooks are returned if the path is empty.
+func Load(path string) ([]Hook, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading lifecycle hooks config, %w", err)
+	}
+	config := Config{}
+	if err = yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing lifecycle hooks config, %w", err)
+	}
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("validating lifecycle hooks config, %w", err)
+	}
+	return config.Hooks, nil
+}
+
+func (c Config) Validate() error {
+	var errs error
+	names := map[string]struct{}{}
+	for _, hook := range c.Hooks {
+		if _, ok := names[hook.Name]; ok {
+			errs = multierr.Append(errs, fmt.Errorf("duplicate hook name %q", hook.Name))
+		}
+		names[hook.Name] = struct{}{}
+		if err := hook.Validate(); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("hook %q, %w", hook.Name, err))
+		}
+	}
+	return errs
+}
+
+func (h Hook) Validate() error {
+	var errs error
+	for _, msg := range validation.IsDNS1123Label(h.Name) {
+		errs = multierr.Append(errs, fmt.Errorf("invalid name, %s", msg))
+	}
+	if len(h.Points) == 0 {
+		errs = multierr.Append(errs, fmt.Errorf("at least one point is required"))
+	}
+	for _, point := range h.Points {
+		if !lo.Contains(Points, point) {
+			errs = multierr.Append(errs, fmt.Errorf("invalid point %q, must be one of %v", point, Points))
+		}
+	}
+	if h.FailurePolicy != "" && h.FailurePolicy != FailurePolicyFail && h.FailurePolicy != FailurePolicyIgnore {
+		errs = multierr.Append(errs, fmt.Errorf("invalid failure policy %q, must be one of %v", h.FailurePolicy, []FailurePolicy{FailurePolicyFail, FailurePolicyIgnore}))
+	}
+	if h.Timeout != nil && h.Timeout.Duration <= 0 {
+		errs = multierr.Append(errs, fmt.Errorf("timeout must be positive"))
+	}
+	switch {
+	case (h.HTTP == nil) == (h.Job == nil):
+		errs = multierr.Append(errs, fmt.Errorf("exactly one of http or job must be set"))
+	case h.HTTP != nil:
+		if u, err := url.Parse(h.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("invalid url %q", h.HTTP.URL))
+		}
+	case h.Job != nil:
+		if h.Job.Namespace == "" {
+			errs = multierr.Append(errs, fmt.Errorf("job namespace is required"))
+		}
+		if len(h.Job.Template.Template.Spec.Containers) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("job template must have at least one container"))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,309 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+const (
+	HookLabelKey  = v1.HookLabelKey
+	PointLabelKey = apis.Group + "/hook-point"
+	// CompletedAnnotationKey lists the hooks that completed for a NodeClaim, or for a Node without a NodeClaim, as
+	// comma-separated "<hook>/<point>" entries
+	CompletedAnnotationKey = apis.Group + "/completed-hooks"
+)
+
+// PendingRequeueInterval is how often the lifecycle step is retried while a hook is pending
+const PendingRequeueInterval = 5 * time.Second
+
+// JobTTL is how long a hook's Job is kept after it finishes, unless its template sets a TTL. Completion is recorded on
+// the NodeClaim, so the Job isn't needed once the lifecycle step observed it.
+const JobTTL = time.Hour
+
+// PendingError is returned when a hook hasn't completed yet
+type PendingError struct {
+	error
+}
+
+func NewPendingError(err error) *PendingError {
+	return &PendingError{error: err}
+}
+
+func (e *PendingError) Unwrap() error {
+	return e.error
+}
+
+func IsPendingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pendingErr *PendingError
+	return errors.As(err, &pendingErr)
+}
+
+// Request describes the lifecycle point that a hook is run for. It is the body of the requests made by HTTP hooks.
+type Request struct {
+	Point      Point             `json:"point"`
+	NodeClaim  string            `json:"nodeClaim,omitempty"`
+	NodePool   string            `json:"nodePool,omitempty"`
+	Node       string            `json:"node,omitempty"`
+	ProviderID string            `json:"providerID,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+func NewRequest(point Point, nodeClaim *v1.NodeClaim, node *corev1.Node) Request {
+	request := Request{Point: point}
+	if node != nil {
+		request.Node = node.Name
+		request.ProviderID = node.Spec.ProviderID
+		request.NodePool = node.Labels[v1.NodePoolLabelKey]
+		request.Labels = node.Labels
+	}
+	if nodeClaim != nil {
+		request.NodeClaim = nodeClaim.Name
+		request.NodePool = nodeClaim.Labels[v1.NodePoolLabelKey]
+		request.Node = lo.Ternary(request.Node == "", nodeClaim.Status.NodeName, request.Node)
+		request.ProviderID = lo.Ternary(request.ProviderID == "", nodeClaim.Status.ProviderID, request.ProviderID)
+		request.Labels = lo.Ternary(request.Labels == nil, nodeClaim.Labels, request.Labels)
+	}
+	return request
+}
+
+// Runner runs the lifecycle hooks for NodeClaims and their Nodes. Hooks that completed for an object are recorded on it
+// so that they aren't re-run when the lifecycle step is retried, including after a restart.
+type Runner struct {
+	clock      clock.Clock
+	kubeClient client.Client
+	recorder   events.Recorder
+	httpClient *http.Client
+	hooks      []Hook
+	completed  *cache.Cache // (hook, point, object UID) -> completed
+}
+
+func NewRunner(clk clock.Clock, kubeClient client.Client, recorder events.Recorder, hooks ...Hook) *Runner {
+	return &Runner{
+		clock:      clk,
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		httpClient: &http.Client{},
+		hooks:      hooks,
+		completed:  cache.New(time.Hour, time.Minute),
+	}
+}
+
+// Run runs the hooks for the lifecycle point in order. Either the NodeClaim or the Node may be nil. A PendingError is
+// returned if a hook hasn't completed yet, and an error is returned if a hook with the Fail policy failed.
+func (r *Runner) Run(ctx context.Context, point Point, nodeClaim *v1.NodeClaim, node *corev1.Node) error {
+	hooks := lo.Filter(r.hooks, func(h Hook, _ int) bool { return lo.Contains(h.Points, point) })
+	if len(hooks) == 0 {
+		return nil
+	}
+	var object client.Object = node
+	if nodeClaim != nil {
+		object = nodeClaim
+	}
+	request := NewRequest(point, nodeClaim, node)
+	for _, hook := range hooks {
+		key := fmt.Sprintf("%s/%s/%s", hook.Name, point, object.GetUID())
+		if _, ok := r.completed.Get(key); ok || IsCompleted(object, hook.Name, point) {
+			continue
+		}
+		ctx := log.IntoContext(ctx, log.FromContext(ctx).WithValues("hook", hook.Name, "point", point))
+		err := r.run(ctx, hook, request, object)
+		if IsPendingError(err) {
+			return err
+		}
+		if err != nil {
+			HooksTotal.Inc(map[string]string{hookLabel: hook.Name, pointLabel: string(point), resultLabel: resultFailure})
+			r.recorder.Publish(HookFailed(object, hook.Name, point, err))
+			if hook.failurePolicy() != FailurePolicyIgnore {
+				return fmt.Errorf("running %s hook %q, %w", point, hook.Name, err)
+			}
+			log.FromContext(ctx).Error(err, "ignoring failed hook")
+		} else {
+			HooksTotal.Inc(map[string]string{hookLabel: hook.Name, pointLabel: string(point), resultLabel: resultSuccess})
+			log.FromContext(ctx).V(1).Info("completed hook")
+		}
+		if err = r.recordCompleted(ctx, object, hook.Name, point); err != nil {
+			return err
+		}
+		r.completed.SetDefault(key, struct{}{})
+	}
+	return nil
+}
+
+// IsCompleted returns true if the hook's completion at the lifecycle point is recorded on the object
+func IsCompleted(object client.Object, hook string, point Point) bool {
+	return lo.Contains(strings.Split(object.GetAnnotations()[CompletedAnnotationKey], ","), fmt.Sprintf("%s/%s", hook, point))
+}
+
+// recordCompleted records the hook's completion on the object. A copy of the object is patched so that changes that the
+// caller hasn't persisted yet aren't overwritten, and the annotation is then mirrored onto the caller's object.
+func (r *Runner) recordCompleted(ctx context.Context, object client.Object, hook string, point Point) error {
+	completed := lo.Compact(strings.Split(object.GetAnnotations()[CompletedAnnotationKey], ","))
+	value := strings.Join(append(completed, fmt.Sprintf("%s/%s", hook, point)), ",")
+	stored := object.DeepCopyObject().(client.Object)
+	patched := object.DeepCopyObject().(client.Object)
+	patched.SetAnnotations(lo.Assign(patched.GetAnnotations(), map[string]string{CompletedAnnotationKey: value}))
+	if err := r.kubeClient.Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+		// The object is gone, so there's nothing left to run the hook for again
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("recording completed hook, %w", err)
+	}
+	object.SetAnnotations(lo.Assign(object.GetAnnotations(), map[string]string{CompletedAnnotationKey: value}))
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, hook Hook, request Request, owner client.Object) error {
+	if hook.HTTP != nil {
+		return r.runHTTP(ctx, hook, request)
+	}
+	return r.runJob(ctx, hook, request, owner)
+}
+
+func (r *Runner) runHTTP(ctx context.Context, hook Hook, request Request) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshaling request, %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.HTTP.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hook.HTTP.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s, %w", hook.HTTP.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("calling %s, got status code %d, %s", hook.HTTP.URL, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (r *Runner) runJob(ctx context.Context, hook Hook, request Request, owner client.Object) error {
+	job := &batchv1.Job{}
+	name := JobName(hook.Name, request.Point, owner.GetUID())
+	if err := r.kubeClient.Get(ctx, types.NamespacedName{Namespace: hook.Job.Namespace, Name: name}, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("getting job, %w", err)
+		}
+		if err = r.kubeClient.Create(ctx, newJob(name, hook, request, owner)); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("creating job, %w", err)
+		}
+		log.FromContext(ctx).WithValues("Job", client.ObjectKey{Namespace: hook.Job.Namespace, Name: name}).V(1).Info("created hook job")
+		return NewPendingError(fmt.Errorf("waiting for job %q to complete", name))
+	}
+	if job.Status.Succeeded > 0 {
+		return nil
+	}
+	if _, failed := lo.Find(job.Status.Conditions, func(c batchv1.JobCondition) bool {
+		return c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue
+	}); failed {
+		return fmt.Errorf("job %q failed", name)
+	}
+	if r.clock.Since(job.CreationTimestamp.Time) > hook.timeout() {
+		return fmt.Errorf("job %q didn't complete within %s", name, hook.timeout())
+	}
+	return NewPendingError(fmt.Errorf("waiting for job %q to complete", name))
+}
+
+// JobName returns a deterministic name for the Job of a hook so that the Job isn't recreated when the hook is retried
+func JobName(hook string, point Point, uid types.UID) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", hook, point, uid)))
+	// Job names can't exceed 63 characters, so the hook name is truncated to leave room for the hash
+	return fmt.Sprintf("%s-%x", lo.Substring(hook, 0, 52), hash[:5])
+}
+
+// newJob returns the Job for a hook. The Job is owned by the NodeClaim, or the Node without a NodeClaim, so that it's
+// garbage collected with it, and its pods are labeled so that they aren't provisioned for.
+func newJob(name string, hook Hook, request Request, owner client.Object) *batchv1.Job {
+	labels := map[string]string{
+		HookLabelKey:  hook.Name,
+		PointLabelKey: string(request.Point),
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: hook.Job.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: object.GVK(owner).GroupVersion().String(),
+					Kind:       object.GVK(owner).Kind,
+					Name:       owner.GetName(),
+					UID:        owner.GetUID(),
+				},
+			},
+		},
+		Spec: *hook.Job.Template.DeepCopy(),
+	}
+	job.Spec.Template.Labels = lo.Assign(job.Spec.Template.Labels, labels)
+	if job.Spec.TTLSecondsAfterFinished == nil {
+		job.Spec.TTLSecondsAfterFinished = lo.ToPtr(int32(JobTTL.Seconds()))
+	}
+	env := []corev1.EnvVar{
+		{Name: "KARPENTER_HOOK_POINT", Value: string(request.Point)},
+		{Name: "KARPENTER_NODECLAIM_NAME", Value: request.NodeClaim},
+		{Name: "KARPENTER_NODEPOOL_NAME", Value: request.NodePool},
+		{Name: "KARPENTER_NODE_NAME", Value: request.Node},
+		{Name: "KARPENTER_PROVIDER_ID", Value: request.ProviderID},
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, env...)
+	}
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return job
+}
@@ -0,0 +1,320 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/hooks"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var recorder *test.EventRecorder
+
+func TestHooks(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Hooks")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = test.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	recorder.Reset()
+})
+
+var _ = Describe("Config", func() {
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+	load := func(config string) ([]hooks.Hook, error) {
+		path := filepath.Join(dir, "hooks.yaml")
+		Expect(os.WriteFile(path, []byte(config), 0600)).To(Succeed())
+		return hooks.Load(path)
+	}
+
+	It("should not load any hooks when no config is set", func() {
+		loaded, err := hooks.Load("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(BeEmpty())
+	})
+	It("should load hooks", func() {
+		loaded, err := load(`
+hooks:
+- name: cmdb
+  points: [PreLaunch, PostTermination]
+  failurePolicy: Ignore
+  timeout: 5s
+  http:
+    url: https://cmdb.example.com/nodes
+    headers:
+      Authorization: Bearer token
+- name: scanner
+  points: [PostInitialization]
+  job:
+    namespace: security
+    template:
+      template:
+        spec:
+          containers:
+          - name: enroll
+            image: scanner:latest
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(HaveLen(2))
+		Expect(loaded[0].Points).To(ConsistOf(hooks.PreLaunch, hooks.PostTermination))
+		Expect(loaded[0].Timeout.Duration).To(Equal(5 * time.Second))
+		Expect(loaded[0].HTTP.Headers).To(HaveKeyWithValue("Authorization", "Bearer token"))
+		Expect(loaded[1].Job.Template.Template.Spec.Containers).To(HaveLen(1))
+	})
+	It("should fail to load hooks with unknown fields", func() {
+		_, err := load(`
+hooks:
+- name: cmdb
+  points: [PreLaunch]
+  http:
+    uri: https://cmdb.example.com/nodes
+`)
+		Expect(err).To(HaveOccurred())
+	})
+	DescribeTable("should fail to load invalid hooks",
+		func(hook hooks.Hook) {
+			Expect(hooks.Config{Hooks: []hooks.Hook{hook}}.Validate()).ToNot(Succeed())
+		},
+		Entry("invalid name", hooks.Hook{Name: "Invalid_Name", Points: []hooks.Point{hooks.PreLaunch}, HTTP: &hooks.HTTPAction{URL: "https://example.com"}}),
+		Entry("no points", hooks.Hook{Name: "test", HTTP: &hooks.HTTPAction{URL: "https://example.com"}}),
+		Entry("invalid point", hooks.Hook{Name: "test", Points: []hooks.Point{"PreFlight"}, HTTP: &hooks.HTTPAction{URL: "https://example.com"}}),
+		Entry("invalid failure policy", hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}, FailurePolicy: "Retry", HTTP: &hooks.HTTPAction{URL: "https://example.com"}}),
+		Entry("no action", hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}}),
+		Entry("multiple actions", hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}, HTTP: &hooks.HTTPAction{URL: "https://example.com"}, Job: &hooks.JobAction{Namespace: "default"}}),
+		Entry("invalid url", hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}, HTTP: &hooks.HTTPAction{URL: "example.com"}}),
+		Entry("job without containers", hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}, Job: &hooks.JobAction{Namespace: "default"}}),
+	)
+	It("should fail to load hooks with duplicate names", func() {
+		hook := hooks.Hook{Name: "test", Points: []hooks.Point{hooks.PreLaunch}, HTTP: &hooks.HTTPAction{URL: "https://example.com"}}
+		Expect(hooks.Config{Hooks: []hooks.Hook{hook, hook}}.Validate()).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Runner", func() {
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	BeforeEach(func() {
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: "default"}}})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+	})
+
+	Context("HTTP", func() {
+		var server *httptest.Server
+		var requests []hooks.Request
+		var statusCode int
+		var hook hooks.Hook
+		BeforeEach(func() {
+			requests = nil
+			statusCode = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("X-Test")).To(Equal("value"))
+				request := hooks.Request{}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				requests = append(requests, request)
+				w.WriteHeader(statusCode)
+			}))
+			hook = hooks.Hook{
+				Name:   "test",
+				Points: []hooks.Point{hooks.PreDrain},
+				HTTP:   &hooks.HTTPAction{URL: server.URL, Headers: map[string]string{"X-Test": "value"}},
+			}
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should call the hook with the details of the lifecycle point", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(runner.Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0]).To(Equal(hooks.Request{
+				Point:      hooks.PreDrain,
+				NodeClaim:  nodeClaim.Name,
+				NodePool:   "default",
+				Node:       node.Name,
+				ProviderID: node.Spec.ProviderID,
+				Labels:     node.Labels,
+			}))
+		})
+		It("should not call the hook again once it completed", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(runner.Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(runner.Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+		})
+		It("should record the completion of the hook on the NodeClaim", func() {
+			Expect(hooks.NewRunner(fakeClock, env.Client, recorder, hook).Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(hooks.CompletedAnnotationKey, "test/PreDrain"))
+			Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(hooks.CompletedAnnotationKey, "test/PreDrain"))
+
+			// A runner that didn't see the hook complete, e.g. after a restart, doesn't call it again
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(hooks.NewRunner(fakeClock, env.Client, recorder, hook).Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+		})
+		It("should record the completion of the hook on the Node when there's no NodeClaim", func() {
+			Expect(hooks.NewRunner(fakeClock, env.Client, recorder, hook).Run(ctx, hooks.PreDrain, nil, node)).To(Succeed())
+			Expect(ExpectExists(ctx, env.Client, node).Annotations).To(HaveKeyWithValue(hooks.CompletedAnnotationKey, "test/PreDrain"))
+		})
+		It("should only call hooks for their lifecycle points", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(runner.Run(ctx, hooks.PreLaunch, nodeClaim, nil)).To(Succeed())
+			Expect(requests).To(BeEmpty())
+		})
+		It("should return an error and retry the hook when it fails", func() {
+			statusCode = http.StatusServiceUnavailable
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			err := runner.Run(ctx, hooks.PreDrain, nodeClaim, node)
+			Expect(err).To(HaveOccurred())
+			Expect(hooks.IsPendingError(err)).To(BeFalse())
+			Expect(recorder.Calls("LifecycleHookFailed")).To(Equal(1))
+
+			statusCode = http.StatusOK
+			Expect(runner.Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(requests).To(HaveLen(2))
+		})
+		It("should not return an error when a hook that ignores failures fails", func() {
+			statusCode = http.StatusServiceUnavailable
+			hook.FailurePolicy = hooks.FailurePolicyIgnore
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(runner.Run(ctx, hooks.PreDrain, nodeClaim, node)).To(Succeed())
+			Expect(recorder.Calls("LifecycleHookFailed")).To(Equal(1))
+		})
+	})
+	Context("Job", func() {
+		var hook hooks.Hook
+		BeforeEach(func() {
+			hook = hooks.Hook{
+				Name:   "test",
+				Points: []hooks.Point{hooks.PostInitialization},
+				Job: &hooks.JobAction{
+					Namespace: "default",
+					Template: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "hook", Image: "hook:latest"}},
+							},
+						},
+					},
+				},
+			}
+		})
+		getJob := func() *batchv1.Job {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: hooks.JobName(hook.Name, hooks.PostInitialization, nodeClaim.UID)}}
+			return ExpectExists(ctx, env.Client, job)
+		}
+
+		It("should create a job and wait for it to complete", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+
+			job := getJob()
+			Expect(job.Labels).To(HaveKeyWithValue(hooks.HookLabelKey, hook.Name))
+			Expect(job.Spec.Template.Labels).To(HaveKeyWithValue(hooks.HookLabelKey, hook.Name))
+			Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElements(
+				corev1.EnvVar{Name: "KARPENTER_HOOK_POINT", Value: string(hooks.PostInitialization)},
+				corev1.EnvVar{Name: "KARPENTER_NODECLAIM_NAME", Value: nodeClaim.Name},
+				corev1.EnvVar{Name: "KARPENTER_NODE_NAME", Value: node.Name},
+			))
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+
+			job.Status.Succeeded = 1
+			ExpectApplied(ctx, env.Client, job)
+			Expect(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node)).To(Succeed())
+			Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(hooks.CompletedAnnotationKey, "test/PostInitialization"))
+		})
+		It("should create a job that's owned by the NodeClaim and cleaned up after it finishes", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+
+			job := getJob()
+			Expect(job.OwnerReferences).To(HaveLen(1))
+			Expect(job.OwnerReferences[0].Kind).To(Equal("NodeClaim"))
+			Expect(job.OwnerReferences[0].UID).To(Equal(nodeClaim.UID))
+			Expect(job.Spec.TTLSecondsAfterFinished).To(Equal(lo.ToPtr(int32(hooks.JobTTL.Seconds()))))
+		})
+		It("should keep the TTL of the job template", func() {
+			hook.Job.Template.TTLSecondsAfterFinished = lo.ToPtr[int32](60)
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+			Expect(getJob().Spec.TTLSecondsAfterFinished).To(Equal(lo.ToPtr[int32](60)))
+		})
+		It("should return an error when the job fails", func() {
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+
+			job := getJob()
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue})
+			ExpectApplied(ctx, env.Client, job)
+			err := runner.Run(ctx, hooks.PostInitialization, nodeClaim, node)
+			Expect(err).To(HaveOccurred())
+			Expect(hooks.IsPendingError(err)).To(BeFalse())
+		})
+		It("should return an error when the job doesn't complete within the timeout", func() {
+			hook.Timeout = &metav1.Duration{Duration: time.Minute}
+			runner := hooks.NewRunner(fakeClock, env.Client, recorder, hook)
+			Expect(hooks.IsPendingError(runner.Run(ctx, hooks.PostInitialization, nodeClaim, node))).To(BeTrue())
+
+			fakeClock.SetTime(getJob().CreationTimestamp.Add(2 * time.Minute))
+			err := runner.Run(ctx, hooks.PostInitialization, nodeClaim, node)
+			Expect(err).To(HaveOccurred())
+			Expect(hooks.IsPendingError(err)).To(BeFalse())
+		})
+	})
+})
@@ -50,10 +50,12 @@ var (
 			Namespace: Namespace,
 			Subsystem: NodeClaimSubsystem,
 			Name:      "terminated_total",
-			Help:      "Number of nodeclaims terminated in total by Karpenter. Labeled by the owning nodepool.",
+			Help:      "Number of nodeclaims terminated in total by Karpenter. Labeled by the owning nodepool and the reason the nodeclaim was terminated.",
 		},
 		[]string{
 			NodePoolLabel,
+			ReasonLabel,
+			VoluntaryLabel,
 			CapacityTypeLabel,
 		},
 	)
@@ -89,10 +91,12 @@ var (
 			Namespace: Namespace,
 			Subsystem: NodeSubsystem,
 			Name:      "terminated_total",
-			Help:      "Number of nodes terminated in total by Karpenter. Labeled by owning nodepool.",
+			Help:      "Number of nodes terminated in total by Karpenter. Labeled by owning nodepool and the reason the node was terminated.",
 		},
 		[]string{
 			NodePoolLabel,
+			ReasonLabel,
+			VoluntaryLabel,
 		},
 	)
 )
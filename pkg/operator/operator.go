@@ -58,6 +58,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	"sigs.k8s.io/karpenter/pkg/operator/logging"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
 
@@ -118,6 +119,9 @@ func NewOperator() (context.Context, *Operator) {
 
 	// Logging
 	logger := zapr.NewLogger(logging.NewLogger(ctx, component))
+	if options.FromContext(ctx).DryRun {
+		logger = logger.WithValues("dry-run", true)
+	}
 	log.SetLogger(logger)
 	klog.SetLogger(logger)
 
@@ -135,7 +139,7 @@ func NewOperator() (context.Context, *Operator) {
 	mgrOpts := ctrl.Options{
 		Logger:                        logging.IgnoreDebugEvents(logger),
 		LeaderElection:                !options.FromContext(ctx).DisableLeaderElection,
-		LeaderElectionID:              options.FromContext(ctx).LeaderElectionName,
+		LeaderElectionID:              leaderElectionID(ctx),
 		LeaderElectionNamespace:       options.FromContext(ctx).LeaderElectionNamespace,
 		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
 		LeaderElectionReleaseOnCancel: true,
@@ -156,6 +160,14 @@ func NewOperator() (context.Context, *Operator) {
 			},
 		},
 	}
+	// In dry-run mode, all writes are submitted to the API server with dryRun=All so that they're validated but never
+	// persisted. Leader election is disabled since acquiring the lease would require writes and since a dry-run replica
+	// shouldn't contend for leadership with the replicas that are actually managing the cluster.
+	if options.FromContext(ctx).DryRun {
+		log.FromContext(ctx).Info("running in dry-run mode, the cluster will not be mutated")
+		mgrOpts.LeaderElection = false
+		mgrOpts.Client = client.Options{DryRun: lo.ToPtr(true)}
+	}
 	if options.FromContext(ctx).EnableProfiling {
 		// TODO @joinnis: Investigate the mgrOpts.PprofBindAddress that would allow native support for pprof
 		// On initial look, it seems like this native pprof doesn't support some of the routes that we have here
@@ -178,6 +190,16 @@ func NewOperator() (context.Context, *Operator) {
 
 	setupIndexers(ctx, mgr)
 
+	// Tracing
+	if endpoint := options.FromContext(ctx).OTLPTracesEndpoint; endpoint != "" {
+		provider := lo.Must(tracing.NewTracerProvider(ctx, endpoint, appName, Version))
+		// Flush the spans that haven't been exported yet once the manager stops
+		lo.Must0(mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return provider.Shutdown(context.Background())
+		})))
+	}
+
 	lo.Must0(mgr.AddReadyzCheck("manager", func(req *http.Request) error {
 		return lo.Ternary(mgr.GetCache().WaitForCacheSync(req.Context()), nil, fmt.Errorf("failed to sync caches"))
 	}))
@@ -266,3 +288,12 @@ func setupIndexers(ctx context.Context, mgr manager.Manager) {
 		return []string{o.(*v1.NodePool).Spec.Template.Spec.NodeClassRef.Name}
 	}), "failed to setup nodepool nodeclassref name indexer")
 }
+
+// leaderElectionID returns the name of the lease for leader election. Each shard of NodePools elects its own leader so
+// that the replicas of every shard are active at the same time.
+func leaderElectionID(ctx context.Context) string {
+	if options.FromContext(ctx).ShardCount > 1 {
+		return fmt.Sprintf("%s-%d", options.FromContext(ctx).LeaderElectionName, options.FromContext(ctx).ShardIndex)
+	}
+	return options.FromContext(ctx).LeaderElectionName
+}
@@ -0,0 +1,91 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var validTaintEffects = []corev1.TaintEffect{corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute}
+
+// ParseNodeLabels parses the default labels of nodes, each of which is formatted as key=value. Well known labels and
+// labels of restricted domains can't be defaulted since they're set by Karpenter or the cloud provider.
+func ParseNodeLabels(values []string) (map[string]string, error) {
+	labels, err := parseKeyValues(values)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range labels {
+		if v1.IsRestrictedNodeLabel(key) {
+			return nil, fmt.Errorf("label %q is restricted", key)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %q of label %q, %s", value, key, strings.Join(errs, ", "))
+		}
+	}
+	return labels, nil
+}
+
+// ParseNodeAnnotations parses the default annotations of nodes, each of which is formatted as key=value
+func ParseNodeAnnotations(values []string) (map[string]string, error) {
+	return parseKeyValues(values)
+}
+
+// ParseNodeTaints parses the default taints of nodes, each of which is formatted as key=value:Effect or key:Effect
+func ParseNodeTaints(values []string) ([]corev1.Taint, error) {
+	var taints []corev1.Taint
+	for _, value := range values {
+		keyValue, effect, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid taint %q, must be formatted as key=value:Effect", value)
+		}
+		if !lo.Contains(validTaintEffects, corev1.TaintEffect(effect)) {
+			return nil, fmt.Errorf("invalid effect %q of taint %q, must be one of %v", effect, value, validTaintEffects)
+		}
+		key, val, _ := strings.Cut(keyValue, "=")
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key of taint %q, %s", value, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(val); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value of taint %q, %s", value, strings.Join(errs, ", "))
+		}
+		taints = append(taints, corev1.Taint{Key: key, Value: val, Effect: corev1.TaintEffect(effect)})
+	}
+	return taints, nil
+}
+
+func parseKeyValues(values []string) (map[string]string, error) {
+	keyValues := map[string]string{}
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid %q, must be formatted as key=value", value)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key %q, %s", key, strings.Join(errs, ", "))
+		}
+		keyValues[key] = val
+	}
+	return keyValues, nil
+}
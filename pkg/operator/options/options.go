@@ -22,16 +22,23 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
 
 var (
-	validLogLevels = []string{"", "debug", "info", "error"}
+	validLogLevels             = []string{"", "debug", "info", "error"}
+	validPodOrderingStrategies = []string{"ResourceSize", "Priority", "Constraints"}
+	validPreferencePolicies    = []string{"PreferPreferenceSatisfaction", "PreferExistingCapacity"}
+	validStandalonePodPolicies = []string{"Provision", "Ignore"}
+	validIPFamilies            = []string{"IPv4", "IPv6", "DualStack"}
 
 	Injectables = []Injectable{&Options{}}
 )
@@ -41,28 +48,71 @@ type optionsKey struct{}
 type FeatureGates struct {
 	inputStr string
 
-	SpotToSpotConsolidation bool
-	NodeRepair              bool
+	SpotToSpotConsolidation   bool
+	NodeRepair                bool
+	NodePoolImpact            bool
+	NominatedNodeName         bool
+	NodeSlicing               bool
+	PreemptionAdvisorEviction bool
 }
 
 // Options contains all CLI flags / env vars for karpenter-core. It adheres to the options.Injectable interface.
 type Options struct {
-	ServiceName             string
-	MetricsPort             int
-	HealthProbePort         int
-	KubeClientQPS           int
-	KubeClientBurst         int
-	EnableProfiling         bool
-	DisableLeaderElection   bool
-	LeaderElectionName      string
-	LeaderElectionNamespace string
-	MemoryLimit             int64
-	LogLevel                string
-	LogOutputPaths          string
-	LogErrorOutputPaths     string
-	BatchMaxDuration        time.Duration
-	BatchIdleDuration       time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                  string
+	MetricsPort                  int
+	HealthProbePort              int
+	KubeClientQPS                int
+	KubeClientBurst              int
+	EnableProfiling              bool
+	EnableSchedulingSnapshot     bool
+	DisableLeaderElection        bool
+	LeaderElectionName           string
+	LeaderElectionNamespace      string
+	MemoryLimit                  int64
+	LogLevel                     string
+	LogOutputPaths               string
+	LogErrorOutputPaths          string
+	BatchMaxDuration             time.Duration
+	BatchIdleDuration            time.Duration
+	DryRun                       bool
+	InstanceTypeCacheTTL         time.Duration
+	LifecycleHooksConfig         string
+	NodeProvenanceRetention      time.Duration
+	NodeOverhead                 string
+	ProtectLocalVolumes          bool
+	MaxNodes                     int
+	FailedLaunchRetention        time.Duration
+	FailedLaunchHistoryLimit     int
+	ForceEvictNamespaces         []string
+	CapacityTypeLabelAliases     []string
+	NormalizeDeprecatedLabels    bool
+	ShardCount                   int
+	ShardIndex                   int
+	ShardNodePoolSelector        string
+	PodOrderingStrategy          string
+	SpreadOwnerReplicas          bool
+	PreferencePolicy             string
+	OTLPTracesEndpoint           string
+	JobCompletionWindow          time.Duration
+	StandalonePodPolicy          string
+	StandalonePodNamespaces      []string
+	PriceMarkupThreshold         int
+	PreemptionAdvisor            bool
+	DefaultNodeLabels            []string
+	DefaultNodeAnnotations       []string
+	DefaultNodeTaints            []string
+	DisruptionDampeningThreshold int
+	DisruptionDampeningWindow    time.Duration
+	ConsolidationScaleUpWindow   time.Duration
+	IgnoredResources             []string
+	IPFamily                     string
+	BatchBacklogHighThreshold    int
+	BatchBacklogLowThreshold     int
+	StateServerPort              int
+	StateServerAddress           string
+	ZonalSkewThreshold           int
+	DeleteInsteadOfEvictOwners   []string
+	FeatureGates                 FeatureGates
 }
 
 type FlagSet struct {
@@ -82,6 +132,29 @@ func (fs *FlagSet) BoolVarWithEnv(p *bool, name string, envVar string, val bool,
 	})
 }
 
+// StringSliceVarWithEnv defines a comma separated string slice flag with a specified name, default value, usage string,
+// and fallback environment variable.
+func (fs *FlagSet) StringSliceVarWithEnv(p *[]string, name string, envVar string, val []string, usage string) {
+	*p = val
+	if envVal := env.WithDefaultString(envVar, ""); envVal != "" {
+		*p = splitCommaSeparated(envVal)
+	}
+	fs.Func(name, usage, func(val string) error {
+		*p = splitCommaSeparated(val)
+		return nil
+	})
+}
+
+func splitCommaSeparated(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func (o *Options) AddFlags(fs *FlagSet) {
 	fs.StringVar(&o.ServiceName, "karpenter-service", env.WithDefaultString("KARPENTER_SERVICE", ""), "The Karpenter Service name for the dynamic webhook certificate")
 	fs.IntVar(&o.MetricsPort, "metrics-port", env.WithDefaultInt("METRICS_PORT", 8080), "The port the metric endpoint binds to for operating metrics about the controller itself")
@@ -89,6 +162,7 @@ func (o *Options) AddFlags(fs *FlagSet) {
 	fs.IntVar(&o.KubeClientQPS, "kube-client-qps", env.WithDefaultInt("KUBE_CLIENT_QPS", 200), "The smoothed rate of qps to kube-apiserver")
 	fs.IntVar(&o.KubeClientBurst, "kube-client-burst", env.WithDefaultInt("KUBE_CLIENT_BURST", 300), "The maximum allowed burst of queries to the kube-apiserver")
 	fs.BoolVarWithEnv(&o.EnableProfiling, "enable-profiling", "ENABLE_PROFILING", false, "Enable the profiling on the metric endpoint")
+	fs.BoolVarWithEnv(&o.EnableSchedulingSnapshot, "enable-scheduling-snapshot", "ENABLE_SCHEDULING_SNAPSHOT", false, "Enable the endpoint on the metric endpoint that dumps a snapshot of the cluster state and pending pods that are used to make scheduling decisions. Container environments and arguments are redacted from the snapshot, but it still exposes the names, labels and resources of all pods to anyone who can reach the metric endpoint.")
 	fs.BoolVarWithEnv(&o.DisableLeaderElection, "disable-leader-election", "DISABLE_LEADER_ELECTION", false, "Disable the leader election client before executing the main loop. Disable when running replicated components for high availability is not desired.")
 	fs.StringVar(&o.LeaderElectionName, "leader-election-name", env.WithDefaultString("LEADER_ELECTION_NAME", "karpenter-leader-election"), "Leader election name to create and monitor the lease if running outside the cluster")
 	fs.StringVar(&o.LeaderElectionNamespace, "leader-election-namespace", env.WithDefaultString("LEADER_ELECTION_NAMESPACE", ""), "Leader election namespace to create and monitor the lease if running outside the cluster")
@@ -98,7 +172,45 @@ func (o *Options) AddFlags(fs *FlagSet) {
 	fs.StringVar(&o.LogErrorOutputPaths, "log-error-output-paths", env.WithDefaultString("LOG_ERROR_OUTPUT_PATHS", "stderr"), "Optional comma separated paths for logging error output")
 	fs.DurationVar(&o.BatchMaxDuration, "batch-max-duration", env.WithDefaultDuration("BATCH_MAX_DURATION", 10*time.Second), "The maximum length of a batch window. The longer this is, the more pods we can consider for provisioning at one time which usually results in fewer but larger nodes.")
 	fs.DurationVar(&o.BatchIdleDuration, "batch-idle-duration", env.WithDefaultDuration("BATCH_IDLE_DURATION", time.Second), "The maximum amount of time with no new pending pods that if exceeded ends the current batching window. If pods arrive faster than this time, the batching window will be extended up to the maxDuration. If they arrive slower, the pods will be batched separately.")
-	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation")
+	fs.BoolVarWithEnv(&o.DryRun, "dry-run", "DRY_RUN", false, "Run all controllers without mutating the cluster or launching and terminating instances. Controllers will emit events, metrics and logs for the actions they would have taken. Leader election is disabled in this mode.")
+	fs.DurationVar(&o.InstanceTypeCacheTTL, "instance-type-cache-ttl", env.WithDefaultDuration("INSTANCE_TYPE_CACHE_TTL", 0), "The duration that the instance types returned by the cloud provider for a NodePool are cached for. Cached instance types are invalidated when the NodePool changes. Set to 0 to disable caching.")
+	fs.StringVar(&o.LifecycleHooksConfig, "lifecycle-hooks-config", env.WithDefaultString("LIFECYCLE_HOOKS_CONFIG", ""), "Optional path to a file that configures the hooks that are called at points in the lifecycle of nodes, e.g. before a node is launched or drained. Hooks are disabled if not set.")
+	fs.DurationVar(&o.NodeProvenanceRetention, "node-provenance-retention", env.WithDefaultDuration("NODE_PROVENANCE_RETENTION", 0), "The duration that NodeProvenance records of launched nodes are retained for after the node is terminated. Set to 0 to disable recording node provenance.")
+	fs.StringVar(&o.NodeOverhead, "node-overhead", env.WithDefaultString("NODE_OVERHEAD", ""), "Optional resources that are reserved on every node for kubelet and system daemons when computing the allocatable resources of nodes, e.g. 'cpu=max(500m,6%),memory=1Gi'. Reservations are quantities, percentages of the node's capacity, or the max of several of these. They replace the kube and system reserved overhead that is reported by the cloud provider for the configured resources.")
+	fs.BoolVarWithEnv(&o.ProtectLocalVolumes, "protect-local-volumes", "PROTECT_LOCAL_VOLUMES", false, "Prevent disruption from voluntarily terminating nodes that host pods with local persistent volumes. Claims annotated with karpenter.sh/replaceable=true do not block disruption.")
+	fs.IntVar(&o.MaxNodes, "max-nodes", env.WithDefaultInt("MAX_NODES", 0), "The maximum number of nodes that Karpenter launches across all NodePools. Once reached, provisioning stops launching nodes while disruption continues. Zero means no limit.")
+	fs.DurationVar(&o.FailedLaunchRetention, "failed-launch-retention", env.WithDefaultDuration("FAILED_LAUNCH_RETENTION", 0), "The duration after which NodeClaims whose launch keeps failing are deleted, rather than waiting for the registration TTL. Set to 0 to disable.")
+	fs.IntVar(&o.FailedLaunchHistoryLimit, "failed-launch-history-limit", env.WithDefaultInt("FAILED_LAUNCH_HISTORY_LIMIT", 3), "The number of most recently failed NodeClaims that are kept past the failed launch retention for debugging. They are still deleted once they exceed the registration TTL.")
+	fs.StringSliceVarWithEnv(&o.ForceEvictNamespaces, "force-evict-namespaces", "FORCE_EVICT_NAMESPACES", nil, "Optional comma separated namespaces whose pods never block the deletion of nodes, e.g. the namespaces of logging and monitoring agents. Their PDBs and karpenter.sh/do-not-disrupt annotations are ignored, and they are deleted once all other pods on the node have been evicted.")
+	fs.StringSliceVarWithEnv(&o.CapacityTypeLabelAliases, "capacity-type-label-aliases", "CAPACITY_TYPE_LABEL_ALIASES", nil, "Optional comma separated labels that are used as the capacity type of nodes without the karpenter.sh/capacity-type label when computing the skew of topology spread constraints, e.g. nodes launched by other tooling with eks.amazonaws.com/capacityType. Values are normalized to lowercase with dashes, e.g. ON_DEMAND is counted as on-demand.")
+	fs.BoolVarWithEnv(&o.NormalizeDeprecatedLabels, "normalize-deprecated-labels", "NORMALIZE_DEPRECATED_LABELS", false, "Translate deprecated labels, e.g. failure-domain.beta.kubernetes.io/zone, to their stable labels in the topology keys of pods and the labels of existing nodes when computing topology spread and pod affinity. Enabling this changes how existing nodes that only have deprecated labels are counted.")
+	fs.IntVar(&o.ShardCount, "shard-count", env.WithDefaultInt("SHARD_COUNT", 1), "The number of shards that NodePools are partitioned into by the hash of their name, each of which is owned by a separate Karpenter deployment with its own --shard-index. Each shard only provisions, disrupts and tracks the NodePools it owns and their NodeClaims and nodes, and only provisions for the pending pods that one of its NodePools tolerates and is compatible with. NodePools of different shards should not select the same pods.")
+	fs.IntVar(&o.ShardIndex, "shard-index", env.WithDefaultInt("SHARD_INDEX", 0), "The index of the shard of NodePools that this Karpenter deployment owns, from 0 to --shard-count - 1. The leader election name is suffixed with the index when there is more than one shard.")
+	fs.StringVar(&o.ShardNodePoolSelector, "shard-nodepool-selector", env.WithDefaultString("SHARD_NODEPOOL_SELECTOR", ""), "Optional label selector for the NodePools that this Karpenter deployment owns, e.g. karpenter.sh/shard=a. Deployments with different selectors must use different leader election names.")
+	fs.StringVar(&o.PodOrderingStrategy, "pod-ordering-strategy", env.WithDefaultString("POD_ORDERING_STRATEGY", "ResourceSize"), "The order in which the pending pods of each provisioning batch are scheduled, which changes how pods are packed onto new nodes. Can be one of 'ResourceSize' to schedule the pods with the largest cpu and memory requests first, 'Priority' to schedule the pods with the highest priority first, or 'Constraints' to schedule the pods with the most scheduling constraints first.")
+	fs.BoolVarWithEnv(&o.SpreadOwnerReplicas, "spread-owner-replicas", "SPREAD_OWNER_REPLICAS", false, "Spread the pending pods of the same owner, e.g. a ReplicaSet, across the new nodes that are created in a provisioning batch rather than packing them onto the same node. Pods are still packed together when a new node can not be created for them.")
+	fs.StringVar(&o.PreferencePolicy, "preference-policy", env.WithDefaultString("PREFERENCE_POLICY", "PreferPreferenceSatisfaction"), "How the scheduling preferences of pods, e.g. preferred node affinities and ScheduleAnyway topology spread constraints, are weighed against existing capacity. Can be one of 'PreferPreferenceSatisfaction' to launch new nodes that satisfy the preferences of pods that don't fit on existing nodes, or 'PreferExistingCapacity' to relax the preferences of pods to schedule them to existing nodes before launching new nodes.")
+	fs.StringVar(&o.OTLPTracesEndpoint, "otlp-traces-endpoint", env.WithDefaultString("OTLP_TRACES_ENDPOINT", ""), "Optional OTLP/HTTP endpoint that traces of provisioning rounds are exported to, e.g. http://otel-collector:4318/v1/traces. The exporter and sampler can be further configured with the standard OTEL_EXPORTER_OTLP_* and OTEL_TRACES_SAMPLER environment variables. Tracing is disabled if not set.")
+	fs.DurationVar(&o.JobCompletionWindow, "job-completion-window", env.WithDefaultDuration("JOB_COMPLETION_WINDOW", 0), "The window before a Job pod is expected to finish during which the pod blocks the disruption of its node, so that batch work that is about to complete isn't wasted. A Job pod is expected to finish when its active deadline elapses or, once its Job is running its final completions, after the average run time of its completed pods. Set to 0 to disable.")
+	fs.StringVar(&o.StandalonePodPolicy, "standalone-pod-policy", env.WithDefaultString("STANDALONE_POD_POLICY", "Provision"), "How pending pods without an owner, e.g. one-off debug pods, are provisioned for. Can be one of 'Provision' to launch capacity for them like any other pod, or 'Ignore' to not launch capacity for them, leaving them to schedule to existing nodes, except in the namespaces of STANDALONE_POD_NAMESPACES.")
+	fs.StringSliceVarWithEnv(&o.StandalonePodNamespaces, "standalone-pod-namespaces", "STANDALONE_POD_NAMESPACES", nil, "Optional comma separated namespaces whose pods without an owner are provisioned for even when STANDALONE_POD_POLICY is 'Ignore'.")
+	fs.IntVar(&o.PriceMarkupThreshold, "price-markup-threshold", env.WithDefaultInt("PRICE_MARKUP_THRESHOLD", 0), "The percentage by which the cheapest instance type that a NodeClaim can launch as may exceed the cheapest instance type that would be compatible without zone, capacity type and offering availability constraints before an event is published explaining the constraints that caused the markup. Set to 0 to disable.")
+	fs.BoolVarWithEnv(&o.PreemptionAdvisor, "preemption-advisor", "PREEMPTION_ADVISOR", false, "Publish events that recommend preempting lower priority pods when the limits of NodePools prevent nodes from being launched for higher priority pods. Pods are only evicted when the PreemptionAdvisorEviction feature gate is enabled.")
+	fs.StringSliceVarWithEnv(&o.DefaultNodeLabels, "default-node-labels", "DEFAULT_NODE_LABELS", nil, "Optional comma separated labels, formatted as key=value, that are added to every node that Karpenter launches, e.g. to guarantee that security or compliance labels are set on all autoscaled capacity. Labels of NodePools take precedence over the defaults.")
+	fs.StringSliceVarWithEnv(&o.DefaultNodeAnnotations, "default-node-annotations", "DEFAULT_NODE_ANNOTATIONS", nil, "Optional comma separated annotations, formatted as key=value, that are added to every node that Karpenter launches. Annotations of NodePools take precedence over the defaults.")
+	fs.StringSliceVarWithEnv(&o.DefaultNodeTaints, "default-node-taints", "DEFAULT_NODE_TAINTS", nil, "Optional comma separated taints, formatted as key=value:Effect, that are added to every node that Karpenter launches. Taints of NodePools with the same key and effect take precedence over the defaults.")
+	fs.IntVar(&o.DisruptionDampeningThreshold, "disruption-dampening-threshold", env.WithDefaultInt("DISRUPTION_DAMPENING_THRESHOLD", 0), "The percentage of nodes that may be voluntarily disrupted within the DISRUPTION_DAMPENING_WINDOW before further voluntary disruption is paused until the churn drops, e.g. to stop consolidation from feeding back into workload autoscalers. Set to 0 to disable.")
+	fs.DurationVar(&o.DisruptionDampeningWindow, "disruption-dampening-window", env.WithDefaultDuration("DISRUPTION_DAMPENING_WINDOW", 10*time.Minute), "The window over which voluntarily disrupted nodes are counted towards the DISRUPTION_DAMPENING_THRESHOLD.")
+	fs.DurationVar(&o.ConsolidationScaleUpWindow, "consolidation-scale-up-window", env.WithDefaultDuration("CONSOLIDATION_SCALE_UP_WINDOW", 0), "The stabilization window after a workload scales up during which the nodes of the pods that it created are not consolidated, so that consolidation does not remove capacity that workload autoscalers are likely to demand again. Set to 0 to disable.")
+	fs.StringSliceVarWithEnv(&o.IgnoredResources, "ignored-resources", "IGNORED_RESOURCES", nil, "Optional comma separated resource names that are ignored when fitting pods to nodes, e.g. extended resources that are injected into pods by webhooks and that no instance type advertises until a device plugin registers them.")
+	fs.StringVar(&o.IPFamily, "ip-family", env.WithDefaultString("IP_FAMILY", "IPv4"), "The IP family that pods are assigned addresses from, which determines the pods capacity of instance types that support a different number of pods per IP family. Can be one of 'IPv4', 'IPv6' or 'DualStack'. NodePools can override it with the karpenter.sh/ip-family annotation.")
+	fs.IntVar(&o.BatchBacklogHighThreshold, "batch-backlog-high-threshold", env.WithDefaultInt("BATCH_BACKLOG_HIGH_THRESHOLD", 0), "The number of pending pods at or above which batching windows are shortened and NodeClaims are created while the next batch is collected. Set to 0 to disable.")
+	fs.IntVar(&o.BatchBacklogLowThreshold, "batch-backlog-low-threshold", env.WithDefaultInt("BATCH_BACKLOG_LOW_THRESHOLD", 0), "The number of pending pods at or below which batching windows are lengthened to pack pods onto fewer nodes. Set to 0 to disable.")
+	fs.IntVar(&o.StateServerPort, "state-server-port", env.WithDefaultInt("STATE_SERVER_PORT", 0), "The port of the read-only gRPC API that serves summaries of cluster state to external schedulers. The API is served by the leader. Set to 0 to disable.")
+	fs.StringVar(&o.StateServerAddress, "state-server-address", env.WithDefaultString("STATE_SERVER_ADDRESS", "127.0.0.1"), "The address that the state server listens on. The API is unauthenticated, so it only listens on the loopback interface by default, e.g. for a sidecar. Set to 0.0.0.0 to serve it to the rest of the cluster.")
+	fs.IntVar(&o.ZonalSkewThreshold, "zonal-skew-threshold", env.WithDefaultInt("ZONAL_SKEW_THRESHOLD", 0), "The largest difference between the number of nodes of a NodePool in any two of its zones before the NodePool is reported as zonally skewed. Set to 0 to disable.")
+	fs.StringSliceVarWithEnv(&o.DeleteInsteadOfEvictOwners, "delete-instead-of-evict-owners", "DELETE_INSTEAD_OF_EVICT_OWNERS", nil, "Optional comma separated group kinds of pod controllers, e.g. Workflow.argoproj.io, whose pods are deleted rather than evicted when nodes are drained, since eviction conflicts with the retry logic of the controllers.")
+	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false,NodePoolImpact=false,NominatedNodeName=false,NodeSlicing=false,PreemptionAdvisorEviction=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation, NodeRepair, NodePoolImpact, NominatedNodeName, NodeSlicing, PreemptionAdvisorEviction")
 }
 
 func (o *Options) Parse(fs *FlagSet, args ...string) error {
@@ -112,6 +224,74 @@ func (o *Options) Parse(fs *FlagSet, args ...string) error {
 	if !lo.Contains(validLogLevels, o.LogLevel) {
 		return fmt.Errorf("validating cli flags / env vars, invalid LOG_LEVEL %q", o.LogLevel)
 	}
+	if o.MaxNodes < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid MAX_NODES %d, must be non-negative", o.MaxNodes)
+	}
+	if o.FailedLaunchHistoryLimit < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid FAILED_LAUNCH_HISTORY_LIMIT %d, must be non-negative", o.FailedLaunchHistoryLimit)
+	}
+	if o.ShardCount < 1 {
+		return fmt.Errorf("validating cli flags / env vars, invalid SHARD_COUNT %d, must be positive", o.ShardCount)
+	}
+	if o.ShardIndex < 0 || o.ShardIndex >= o.ShardCount {
+		return fmt.Errorf("validating cli flags / env vars, invalid SHARD_INDEX %d, must be less than SHARD_COUNT %d and non-negative", o.ShardIndex, o.ShardCount)
+	}
+	if _, err := labels.Parse(o.ShardNodePoolSelector); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, invalid SHARD_NODEPOOL_SELECTOR %q, %w", o.ShardNodePoolSelector, err)
+	}
+	if !lo.Contains(validPodOrderingStrategies, o.PodOrderingStrategy) {
+		return fmt.Errorf("validating cli flags / env vars, invalid POD_ORDERING_STRATEGY %q", o.PodOrderingStrategy)
+	}
+	if !lo.Contains(validPreferencePolicies, o.PreferencePolicy) {
+		return fmt.Errorf("validating cli flags / env vars, invalid PREFERENCE_POLICY %q", o.PreferencePolicy)
+	}
+	if !lo.Contains(validStandalonePodPolicies, o.StandalonePodPolicy) {
+		return fmt.Errorf("validating cli flags / env vars, invalid STANDALONE_POD_POLICY %q", o.StandalonePodPolicy)
+	}
+	if o.PriceMarkupThreshold < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid PRICE_MARKUP_THRESHOLD %d, must be non-negative", o.PriceMarkupThreshold)
+	}
+	if o.DisruptionDampeningThreshold < 0 || o.DisruptionDampeningThreshold > 100 {
+		return fmt.Errorf("validating cli flags / env vars, invalid DISRUPTION_DAMPENING_THRESHOLD %d, must be between 0 and 100", o.DisruptionDampeningThreshold)
+	}
+	if o.DisruptionDampeningWindow <= 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid DISRUPTION_DAMPENING_WINDOW %s, must be positive", o.DisruptionDampeningWindow)
+	}
+	if o.ConsolidationScaleUpWindow < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid CONSOLIDATION_SCALE_UP_WINDOW %s, must be non-negative", o.ConsolidationScaleUpWindow)
+	}
+	if !lo.Contains(validIPFamilies, o.IPFamily) {
+		return fmt.Errorf("validating cli flags / env vars, invalid IP_FAMILY %q", o.IPFamily)
+	}
+	if o.BatchBacklogHighThreshold < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid BATCH_BACKLOG_HIGH_THRESHOLD %d, must be non-negative", o.BatchBacklogHighThreshold)
+	}
+	if o.BatchBacklogLowThreshold < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid BATCH_BACKLOG_LOW_THRESHOLD %d, must be non-negative", o.BatchBacklogLowThreshold)
+	}
+	if o.StateServerPort < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid STATE_SERVER_PORT %d, must be non-negative", o.StateServerPort)
+	}
+	if o.ZonalSkewThreshold < 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid ZONAL_SKEW_THRESHOLD %d, must be non-negative", o.ZonalSkewThreshold)
+	}
+	for _, owner := range o.DeleteInsteadOfEvictOwners {
+		if schema.ParseGroupKind(owner).Kind == "" {
+			return fmt.Errorf("validating cli flags / env vars, invalid DELETE_INSTEAD_OF_EVICT_OWNERS %q, must be a group kind", owner)
+		}
+	}
+	if o.BatchBacklogHighThreshold > 0 && o.BatchBacklogLowThreshold >= o.BatchBacklogHighThreshold {
+		return fmt.Errorf("validating cli flags / env vars, invalid BATCH_BACKLOG_LOW_THRESHOLD %d, must be less than BATCH_BACKLOG_HIGH_THRESHOLD", o.BatchBacklogLowThreshold)
+	}
+	if _, err := ParseNodeLabels(o.DefaultNodeLabels); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, invalid DEFAULT_NODE_LABELS, %w", err)
+	}
+	if _, err := ParseNodeAnnotations(o.DefaultNodeAnnotations); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, invalid DEFAULT_NODE_ANNOTATIONS, %w", err)
+	}
+	if _, err := ParseNodeTaints(o.DefaultNodeTaints); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, invalid DEFAULT_NODE_TAINTS, %w", err)
+	}
 	gates, err := ParseFeatureGates(o.FeatureGates.inputStr)
 	if err != nil {
 		return fmt.Errorf("parsing feature gates, %w", err)
@@ -139,6 +319,18 @@ func ParseFeatureGates(gateStr string) (FeatureGates, error) {
 	if val, ok := gateMap["SpotToSpotConsolidation"]; ok {
 		gates.SpotToSpotConsolidation = val
 	}
+	if val, ok := gateMap["NodePoolImpact"]; ok {
+		gates.NodePoolImpact = val
+	}
+	if val, ok := gateMap["NominatedNodeName"]; ok {
+		gates.NominatedNodeName = val
+	}
+	if val, ok := gateMap["NodeSlicing"]; ok {
+		gates.NodeSlicing = val
+	}
+	if val, ok := gateMap["PreemptionAdvisorEviction"]; ok {
+		gates.PreemptionAdvisorEviction = val
+	}
 
 	return gates, nil
 }
@@ -50,6 +50,7 @@ var _ = Describe("Options", func() {
 		"KUBE_CLIENT_QPS",
 		"KUBE_CLIENT_BURST",
 		"ENABLE_PROFILING",
+		"ENABLE_SCHEDULING_SNAPSHOT",
 		"DISABLE_LEADER_ELECTION",
 		"LEADER_ELECTION_NAMESPACE",
 		"MEMORY_LIMIT",
@@ -58,6 +59,44 @@ var _ = Describe("Options", func() {
 		"LOG_ERROR_OUTPUT_PATHS",
 		"BATCH_MAX_DURATION",
 		"BATCH_IDLE_DURATION",
+		"DRY_RUN",
+		"INSTANCE_TYPE_CACHE_TTL",
+		"LIFECYCLE_HOOKS_CONFIG",
+		"NODE_PROVENANCE_RETENTION",
+		"NODE_OVERHEAD",
+		"PROTECT_LOCAL_VOLUMES",
+		"MAX_NODES",
+		"FAILED_LAUNCH_RETENTION",
+		"FAILED_LAUNCH_HISTORY_LIMIT",
+		"FORCE_EVICT_NAMESPACES",
+		"CAPACITY_TYPE_LABEL_ALIASES",
+		"NORMALIZE_DEPRECATED_LABELS",
+		"SHARD_COUNT",
+		"SHARD_INDEX",
+		"SHARD_NODEPOOL_SELECTOR",
+		"POD_ORDERING_STRATEGY",
+		"SPREAD_OWNER_REPLICAS",
+		"PREFERENCE_POLICY",
+		"OTLP_TRACES_ENDPOINT",
+		"JOB_COMPLETION_WINDOW",
+		"STANDALONE_POD_POLICY",
+		"STANDALONE_POD_NAMESPACES",
+		"PRICE_MARKUP_THRESHOLD",
+		"PREEMPTION_ADVISOR",
+		"DEFAULT_NODE_LABELS",
+		"DEFAULT_NODE_ANNOTATIONS",
+		"DEFAULT_NODE_TAINTS",
+		"DISRUPTION_DAMPENING_THRESHOLD",
+		"DISRUPTION_DAMPENING_WINDOW",
+		"CONSOLIDATION_SCALE_UP_WINDOW",
+		"IGNORED_RESOURCES",
+		"IP_FAMILY",
+		"BATCH_BACKLOG_HIGH_THRESHOLD",
+		"BATCH_BACKLOG_LOW_THRESHOLD",
+		"STATE_SERVER_PORT",
+		"STATE_SERVER_ADDRESS",
+		"ZONAL_SKEW_THRESHOLD",
+		"DELETE_INSTEAD_OF_EVICT_OWNERS",
 		"FEATURE_GATES",
 	}
 
@@ -95,24 +134,67 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr(""),
-				MetricsPort:             lo.ToPtr(8080),
-				HealthProbePort:         lo.ToPtr(8081),
-				KubeClientQPS:           lo.ToPtr(200),
-				KubeClientBurst:         lo.ToPtr(300),
-				EnableProfiling:         lo.ToPtr(false),
-				DisableLeaderElection:   lo.ToPtr(false),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](-1),
-				LogLevel:                lo.ToPtr("info"),
-				LogOutputPaths:          lo.ToPtr("stdout"),
-				LogErrorOutputPaths:     lo.ToPtr("stderr"),
-				BatchMaxDuration:        lo.ToPtr(10 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(time.Second),
+				ServiceName:                  lo.ToPtr(""),
+				MetricsPort:                  lo.ToPtr(8080),
+				HealthProbePort:              lo.ToPtr(8081),
+				KubeClientQPS:                lo.ToPtr(200),
+				KubeClientBurst:              lo.ToPtr(300),
+				EnableProfiling:              lo.ToPtr(false),
+				EnableSchedulingSnapshot:     lo.ToPtr(false),
+				DisableLeaderElection:        lo.ToPtr(false),
+				LeaderElectionName:           lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:      lo.ToPtr(""),
+				MemoryLimit:                  lo.ToPtr[int64](-1),
+				LogLevel:                     lo.ToPtr("info"),
+				LogOutputPaths:               lo.ToPtr("stdout"),
+				LogErrorOutputPaths:          lo.ToPtr("stderr"),
+				BatchMaxDuration:             lo.ToPtr(10 * time.Second),
+				BatchIdleDuration:            lo.ToPtr(time.Second),
+				DryRun:                       lo.ToPtr(false),
+				InstanceTypeCacheTTL:         lo.ToPtr(time.Duration(0)),
+				LifecycleHooksConfig:         lo.ToPtr(""),
+				NodeProvenanceRetention:      lo.ToPtr(time.Duration(0)),
+				NodeOverhead:                 lo.ToPtr(""),
+				ProtectLocalVolumes:          lo.ToPtr(false),
+				MaxNodes:                     lo.ToPtr(0),
+				FailedLaunchRetention:        lo.ToPtr(time.Duration(0)),
+				FailedLaunchHistoryLimit:     lo.ToPtr(3),
+				ForceEvictNamespaces:         lo.ToPtr([]string(nil)),
+				CapacityTypeLabelAliases:     lo.ToPtr([]string(nil)),
+				NormalizeDeprecatedLabels:    lo.ToPtr(false),
+				ShardCount:                   lo.ToPtr(1),
+				ShardIndex:                   lo.ToPtr(0),
+				ShardNodePoolSelector:        lo.ToPtr(""),
+				PodOrderingStrategy:          lo.ToPtr("ResourceSize"),
+				SpreadOwnerReplicas:          lo.ToPtr(false),
+				PreferencePolicy:             lo.ToPtr("PreferPreferenceSatisfaction"),
+				OTLPTracesEndpoint:           lo.ToPtr(""),
+				JobCompletionWindow:          lo.ToPtr(time.Duration(0)),
+				StandalonePodPolicy:          lo.ToPtr("Provision"),
+				StandalonePodNamespaces:      lo.ToPtr([]string(nil)),
+				PriceMarkupThreshold:         lo.ToPtr(0),
+				PreemptionAdvisor:            lo.ToPtr(false),
+				DefaultNodeLabels:            lo.ToPtr([]string(nil)),
+				DefaultNodeAnnotations:       lo.ToPtr([]string(nil)),
+				DefaultNodeTaints:            lo.ToPtr([]string(nil)),
+				DisruptionDampeningThreshold: lo.ToPtr(0),
+				DisruptionDampeningWindow:    lo.ToPtr(10 * time.Minute),
+				ConsolidationScaleUpWindow:   lo.ToPtr(time.Duration(0)),
+				IgnoredResources:             lo.ToPtr([]string(nil)),
+				IPFamily:                     lo.ToPtr("IPv4"),
+				BatchBacklogHighThreshold:    lo.ToPtr(0),
+				BatchBacklogLowThreshold:     lo.ToPtr(0),
+				StateServerPort:              lo.ToPtr(0),
+				StateServerAddress:           lo.ToPtr("127.0.0.1"),
+				ZonalSkewThreshold:           lo.ToPtr(0),
+				DeleteInsteadOfEvictOwners:   lo.ToPtr([]string(nil)),
 				FeatureGates: test.FeatureGates{
-					NodeRepair:              lo.ToPtr(false),
-					SpotToSpotConsolidation: lo.ToPtr(false),
+					NodeRepair:                lo.ToPtr(false),
+					SpotToSpotConsolidation:   lo.ToPtr(false),
+					NodePoolImpact:            lo.ToPtr(false),
+					NominatedNodeName:         lo.ToPtr(false),
+					NodeSlicing:               lo.ToPtr(false),
+					PreemptionAdvisorEviction: lo.ToPtr(false),
 				},
 			}))
 		})
@@ -128,6 +210,7 @@ var _ = Describe("Options", func() {
 				"--kube-client-qps", "0",
 				"--kube-client-burst", "0",
 				"--enable-profiling",
+				"--enable-scheduling-snapshot",
 				"--disable-leader-election=true",
 				"--leader-election-name=karpenter-controller",
 				"--leader-election-namespace=karpenter",
@@ -137,28 +220,109 @@ var _ = Describe("Options", func() {
 				"--log-error-output-paths", "/etc/k8s/testerror",
 				"--batch-max-duration", "5s",
 				"--batch-idle-duration", "5s",
-				"--feature-gates", "SpotToSpotConsolidation=true,NodeRepair=true",
+				"--dry-run=true",
+				"--instance-type-cache-ttl", "5m",
+				"--lifecycle-hooks-config", "/etc/karpenter/hooks.yaml",
+				"--node-provenance-retention", "720h",
+				"--node-overhead", "cpu=max(500m,6%),memory=1Gi",
+				"--protect-local-volumes=true",
+				"--max-nodes", "100",
+				"--failed-launch-retention", "5m",
+				"--failed-launch-history-limit", "5",
+				"--force-evict-namespaces", "logging,monitoring",
+				"--capacity-type-label-aliases", "eks.amazonaws.com/capacityType",
+				"--normalize-deprecated-labels",
+				"--shard-count", "3",
+				"--shard-index", "1",
+				"--shard-nodepool-selector", "karpenter.sh/shard=a",
+				"--pod-ordering-strategy", "Priority",
+				"--spread-owner-replicas=true",
+				"--preference-policy", "PreferExistingCapacity",
+				"--otlp-traces-endpoint", "http://otel-collector:4318/v1/traces",
+				"--job-completion-window", "5m",
+				"--standalone-pod-policy", "Ignore",
+				"--standalone-pod-namespaces", "debug,batch",
+				"--price-markup-threshold", "25",
+				"--preemption-advisor",
+				"--default-node-labels", "example.com/team=platform",
+				"--default-node-annotations", "example.com/owner=platform",
+				"--default-node-taints", "example.com/dedicated=platform:NoSchedule",
+				"--disruption-dampening-threshold", "25",
+				"--disruption-dampening-window", "5m",
+				"--consolidation-scale-up-window", "3m",
+				"--ignored-resources", "example.com/device",
+				"--ip-family", "IPv6",
+				"--batch-backlog-high-threshold", "500",
+				"--batch-backlog-low-threshold", "10",
+				"--state-server-port", "9090",
+				"--state-server-address", "0.0.0.0",
+				"--zonal-skew-threshold", "3",
+				"--delete-instead-of-evict-owners", "Workflow.argoproj.io",
+				"--feature-gates", "SpotToSpotConsolidation=true,NodeRepair=true,NodePoolImpact=true,NominatedNodeName=true,NodeSlicing=true,PreemptionAdvisorEviction=true",
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                  lo.ToPtr("cli"),
+				MetricsPort:                  lo.ToPtr(0),
+				HealthProbePort:              lo.ToPtr(0),
+				KubeClientQPS:                lo.ToPtr(0),
+				KubeClientBurst:              lo.ToPtr(0),
+				EnableProfiling:              lo.ToPtr(true),
+				EnableSchedulingSnapshot:     lo.ToPtr(true),
+				DisableLeaderElection:        lo.ToPtr(true),
+				LeaderElectionName:           lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:      lo.ToPtr("karpenter"),
+				MemoryLimit:                  lo.ToPtr[int64](0),
+				LogLevel:                     lo.ToPtr("debug"),
+				LogOutputPaths:               lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:          lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:             lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:            lo.ToPtr(5 * time.Second),
+				DryRun:                       lo.ToPtr(true),
+				InstanceTypeCacheTTL:         lo.ToPtr(5 * time.Minute),
+				LifecycleHooksConfig:         lo.ToPtr("/etc/karpenter/hooks.yaml"),
+				NodeProvenanceRetention:      lo.ToPtr(720 * time.Hour),
+				NodeOverhead:                 lo.ToPtr("cpu=max(500m,6%),memory=1Gi"),
+				ProtectLocalVolumes:          lo.ToPtr(true),
+				MaxNodes:                     lo.ToPtr(100),
+				FailedLaunchRetention:        lo.ToPtr(5 * time.Minute),
+				FailedLaunchHistoryLimit:     lo.ToPtr(5),
+				ForceEvictNamespaces:         lo.ToPtr([]string{"logging", "monitoring"}),
+				CapacityTypeLabelAliases:     lo.ToPtr([]string{"eks.amazonaws.com/capacityType"}),
+				NormalizeDeprecatedLabels:    lo.ToPtr(true),
+				ShardCount:                   lo.ToPtr(3),
+				ShardIndex:                   lo.ToPtr(1),
+				ShardNodePoolSelector:        lo.ToPtr("karpenter.sh/shard=a"),
+				PodOrderingStrategy:          lo.ToPtr("Priority"),
+				SpreadOwnerReplicas:          lo.ToPtr(true),
+				PreferencePolicy:             lo.ToPtr("PreferExistingCapacity"),
+				OTLPTracesEndpoint:           lo.ToPtr("http://otel-collector:4318/v1/traces"),
+				JobCompletionWindow:          lo.ToPtr(5 * time.Minute),
+				StandalonePodPolicy:          lo.ToPtr("Ignore"),
+				StandalonePodNamespaces:      lo.ToPtr([]string{"debug", "batch"}),
+				PriceMarkupThreshold:         lo.ToPtr(25),
+				PreemptionAdvisor:            lo.ToPtr(true),
+				DefaultNodeLabels:            lo.ToPtr([]string{"example.com/team=platform"}),
+				DefaultNodeAnnotations:       lo.ToPtr([]string{"example.com/owner=platform"}),
+				DefaultNodeTaints:            lo.ToPtr([]string{"example.com/dedicated=platform:NoSchedule"}),
+				DisruptionDampeningThreshold: lo.ToPtr(25),
+				DisruptionDampeningWindow:    lo.ToPtr(5 * time.Minute),
+				ConsolidationScaleUpWindow:   lo.ToPtr(3 * time.Minute),
+				IgnoredResources:             lo.ToPtr([]string{"example.com/device"}),
+				IPFamily:                     lo.ToPtr("IPv6"),
+				BatchBacklogHighThreshold:    lo.ToPtr(500),
+				BatchBacklogLowThreshold:     lo.ToPtr(10),
+				StateServerPort:              lo.ToPtr(9090),
+				StateServerAddress:           lo.ToPtr("0.0.0.0"),
+				ZonalSkewThreshold:           lo.ToPtr(3),
+				DeleteInsteadOfEvictOwners:   lo.ToPtr([]string{"Workflow.argoproj.io"}),
 				FeatureGates: test.FeatureGates{
-					NodeRepair:              lo.ToPtr(true),
-					SpotToSpotConsolidation: lo.ToPtr(true),
+					NodeRepair:                lo.ToPtr(true),
+					SpotToSpotConsolidation:   lo.ToPtr(true),
+					NodePoolImpact:            lo.ToPtr(true),
+					NominatedNodeName:         lo.ToPtr(true),
+					NodeSlicing:               lo.ToPtr(true),
+					PreemptionAdvisorEviction: lo.ToPtr(true),
 				},
 			}))
 		})
@@ -170,6 +334,7 @@ var _ = Describe("Options", func() {
 			os.Setenv("KUBE_CLIENT_QPS", "0")
 			os.Setenv("KUBE_CLIENT_BURST", "0")
 			os.Setenv("ENABLE_PROFILING", "true")
+			os.Setenv("ENABLE_SCHEDULING_SNAPSHOT", "true")
 			os.Setenv("DISABLE_LEADER_ELECTION", "true")
 			os.Setenv("LEADER_ELECTION_NAME", "karpenter-controller")
 			os.Setenv("LEADER_ELECTION_NAMESPACE", "karpenter")
@@ -179,7 +344,45 @@ var _ = Describe("Options", func() {
 			os.Setenv("LOG_ERROR_OUTPUT_PATHS", "/etc/k8s/testerror")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
-			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
+			os.Setenv("DRY_RUN", "true")
+			os.Setenv("INSTANCE_TYPE_CACHE_TTL", "5m")
+			os.Setenv("LIFECYCLE_HOOKS_CONFIG", "/etc/karpenter/hooks.yaml")
+			os.Setenv("NODE_PROVENANCE_RETENTION", "720h")
+			os.Setenv("NODE_OVERHEAD", "cpu=max(500m,6%),memory=1Gi")
+			os.Setenv("PROTECT_LOCAL_VOLUMES", "true")
+			os.Setenv("MAX_NODES", "100")
+			os.Setenv("FAILED_LAUNCH_RETENTION", "5m")
+			os.Setenv("FAILED_LAUNCH_HISTORY_LIMIT", "5")
+			os.Setenv("FORCE_EVICT_NAMESPACES", "logging,monitoring")
+			os.Setenv("CAPACITY_TYPE_LABEL_ALIASES", "eks.amazonaws.com/capacityType")
+			os.Setenv("NORMALIZE_DEPRECATED_LABELS", "true")
+			os.Setenv("SHARD_COUNT", "3")
+			os.Setenv("SHARD_INDEX", "1")
+			os.Setenv("SHARD_NODEPOOL_SELECTOR", "karpenter.sh/shard=a")
+			os.Setenv("POD_ORDERING_STRATEGY", "Priority")
+			os.Setenv("SPREAD_OWNER_REPLICAS", "true")
+			os.Setenv("PREFERENCE_POLICY", "PreferExistingCapacity")
+			os.Setenv("OTLP_TRACES_ENDPOINT", "http://otel-collector:4318/v1/traces")
+			os.Setenv("JOB_COMPLETION_WINDOW", "5m")
+			os.Setenv("STANDALONE_POD_POLICY", "Ignore")
+			os.Setenv("STANDALONE_POD_NAMESPACES", "debug,batch")
+			os.Setenv("PRICE_MARKUP_THRESHOLD", "25")
+			os.Setenv("PREEMPTION_ADVISOR", "true")
+			os.Setenv("DEFAULT_NODE_LABELS", "example.com/team=platform")
+			os.Setenv("DEFAULT_NODE_ANNOTATIONS", "example.com/owner=platform")
+			os.Setenv("DEFAULT_NODE_TAINTS", "example.com/dedicated=platform:NoSchedule")
+			os.Setenv("DISRUPTION_DAMPENING_THRESHOLD", "25")
+			os.Setenv("DISRUPTION_DAMPENING_WINDOW", "5m")
+			os.Setenv("CONSOLIDATION_SCALE_UP_WINDOW", "3m")
+			os.Setenv("IGNORED_RESOURCES", "example.com/device")
+			os.Setenv("IP_FAMILY", "IPv6")
+			os.Setenv("BATCH_BACKLOG_HIGH_THRESHOLD", "500")
+			os.Setenv("BATCH_BACKLOG_LOW_THRESHOLD", "10")
+			os.Setenv("STATE_SERVER_PORT", "9090")
+			os.Setenv("STATE_SERVER_ADDRESS", "0.0.0.0")
+			os.Setenv("ZONAL_SKEW_THRESHOLD", "3")
+			os.Setenv("DELETE_INSTEAD_OF_EVICT_OWNERS", "Workflow.argoproj.io")
+			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true,NodePoolImpact=true,NominatedNodeName=true,NodeSlicing=true,PreemptionAdvisorEviction=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 			}
@@ -187,24 +390,67 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("env"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                  lo.ToPtr("env"),
+				MetricsPort:                  lo.ToPtr(0),
+				HealthProbePort:              lo.ToPtr(0),
+				KubeClientQPS:                lo.ToPtr(0),
+				KubeClientBurst:              lo.ToPtr(0),
+				EnableProfiling:              lo.ToPtr(true),
+				EnableSchedulingSnapshot:     lo.ToPtr(true),
+				DisableLeaderElection:        lo.ToPtr(true),
+				LeaderElectionName:           lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:      lo.ToPtr("karpenter"),
+				MemoryLimit:                  lo.ToPtr[int64](0),
+				LogLevel:                     lo.ToPtr("debug"),
+				LogOutputPaths:               lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:          lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:             lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:            lo.ToPtr(5 * time.Second),
+				DryRun:                       lo.ToPtr(true),
+				InstanceTypeCacheTTL:         lo.ToPtr(5 * time.Minute),
+				LifecycleHooksConfig:         lo.ToPtr("/etc/karpenter/hooks.yaml"),
+				NodeProvenanceRetention:      lo.ToPtr(720 * time.Hour),
+				NodeOverhead:                 lo.ToPtr("cpu=max(500m,6%),memory=1Gi"),
+				ProtectLocalVolumes:          lo.ToPtr(true),
+				MaxNodes:                     lo.ToPtr(100),
+				FailedLaunchRetention:        lo.ToPtr(5 * time.Minute),
+				FailedLaunchHistoryLimit:     lo.ToPtr(5),
+				ForceEvictNamespaces:         lo.ToPtr([]string{"logging", "monitoring"}),
+				CapacityTypeLabelAliases:     lo.ToPtr([]string{"eks.amazonaws.com/capacityType"}),
+				NormalizeDeprecatedLabels:    lo.ToPtr(true),
+				ShardCount:                   lo.ToPtr(3),
+				ShardIndex:                   lo.ToPtr(1),
+				ShardNodePoolSelector:        lo.ToPtr("karpenter.sh/shard=a"),
+				PodOrderingStrategy:          lo.ToPtr("Priority"),
+				SpreadOwnerReplicas:          lo.ToPtr(true),
+				PreferencePolicy:             lo.ToPtr("PreferExistingCapacity"),
+				OTLPTracesEndpoint:           lo.ToPtr("http://otel-collector:4318/v1/traces"),
+				JobCompletionWindow:          lo.ToPtr(5 * time.Minute),
+				StandalonePodPolicy:          lo.ToPtr("Ignore"),
+				StandalonePodNamespaces:      lo.ToPtr([]string{"debug", "batch"}),
+				PriceMarkupThreshold:         lo.ToPtr(25),
+				PreemptionAdvisor:            lo.ToPtr(true),
+				DefaultNodeLabels:            lo.ToPtr([]string{"example.com/team=platform"}),
+				DefaultNodeAnnotations:       lo.ToPtr([]string{"example.com/owner=platform"}),
+				DefaultNodeTaints:            lo.ToPtr([]string{"example.com/dedicated=platform:NoSchedule"}),
+				DisruptionDampeningThreshold: lo.ToPtr(25),
+				DisruptionDampeningWindow:    lo.ToPtr(5 * time.Minute),
+				ConsolidationScaleUpWindow:   lo.ToPtr(3 * time.Minute),
+				IgnoredResources:             lo.ToPtr([]string{"example.com/device"}),
+				IPFamily:                     lo.ToPtr("IPv6"),
+				BatchBacklogHighThreshold:    lo.ToPtr(500),
+				BatchBacklogLowThreshold:     lo.ToPtr(10),
+				StateServerPort:              lo.ToPtr(9090),
+				StateServerAddress:           lo.ToPtr("0.0.0.0"),
+				ZonalSkewThreshold:           lo.ToPtr(3),
+				DeleteInsteadOfEvictOwners:   lo.ToPtr([]string{"Workflow.argoproj.io"}),
 				FeatureGates: test.FeatureGates{
-					NodeRepair:              lo.ToPtr(true),
-					SpotToSpotConsolidation: lo.ToPtr(true),
+					NodeRepair:                lo.ToPtr(true),
+					SpotToSpotConsolidation:   lo.ToPtr(true),
+					NodePoolImpact:            lo.ToPtr(true),
+					NominatedNodeName:         lo.ToPtr(true),
+					NodeSlicing:               lo.ToPtr(true),
+					PreemptionAdvisorEviction: lo.ToPtr(true),
 				},
 			}))
 		})
@@ -215,12 +461,51 @@ var _ = Describe("Options", func() {
 			os.Setenv("KUBE_CLIENT_QPS", "0")
 			os.Setenv("KUBE_CLIENT_BURST", "0")
 			os.Setenv("ENABLE_PROFILING", "true")
+			os.Setenv("ENABLE_SCHEDULING_SNAPSHOT", "true")
 			os.Setenv("DISABLE_LEADER_ELECTION", "true")
 			os.Setenv("MEMORY_LIMIT", "0")
 			os.Setenv("LOG_LEVEL", "debug")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
-			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
+			os.Setenv("DRY_RUN", "true")
+			os.Setenv("INSTANCE_TYPE_CACHE_TTL", "5m")
+			os.Setenv("LIFECYCLE_HOOKS_CONFIG", "/etc/karpenter/hooks.yaml")
+			os.Setenv("NODE_PROVENANCE_RETENTION", "720h")
+			os.Setenv("NODE_OVERHEAD", "cpu=max(500m,6%),memory=1Gi")
+			os.Setenv("PROTECT_LOCAL_VOLUMES", "true")
+			os.Setenv("MAX_NODES", "100")
+			os.Setenv("FAILED_LAUNCH_RETENTION", "5m")
+			os.Setenv("FAILED_LAUNCH_HISTORY_LIMIT", "5")
+			os.Setenv("FORCE_EVICT_NAMESPACES", "logging,monitoring")
+			os.Setenv("CAPACITY_TYPE_LABEL_ALIASES", "eks.amazonaws.com/capacityType")
+			os.Setenv("NORMALIZE_DEPRECATED_LABELS", "true")
+			os.Setenv("SHARD_COUNT", "3")
+			os.Setenv("SHARD_INDEX", "1")
+			os.Setenv("SHARD_NODEPOOL_SELECTOR", "karpenter.sh/shard=a")
+			os.Setenv("POD_ORDERING_STRATEGY", "Priority")
+			os.Setenv("SPREAD_OWNER_REPLICAS", "true")
+			os.Setenv("PREFERENCE_POLICY", "PreferExistingCapacity")
+			os.Setenv("OTLP_TRACES_ENDPOINT", "http://otel-collector:4318/v1/traces")
+			os.Setenv("JOB_COMPLETION_WINDOW", "5m")
+			os.Setenv("STANDALONE_POD_POLICY", "Ignore")
+			os.Setenv("STANDALONE_POD_NAMESPACES", "debug,batch")
+			os.Setenv("PRICE_MARKUP_THRESHOLD", "25")
+			os.Setenv("PREEMPTION_ADVISOR", "true")
+			os.Setenv("DEFAULT_NODE_LABELS", "example.com/team=platform")
+			os.Setenv("DEFAULT_NODE_ANNOTATIONS", "example.com/owner=platform")
+			os.Setenv("DEFAULT_NODE_TAINTS", "example.com/dedicated=platform:NoSchedule")
+			os.Setenv("DISRUPTION_DAMPENING_THRESHOLD", "25")
+			os.Setenv("DISRUPTION_DAMPENING_WINDOW", "5m")
+			os.Setenv("CONSOLIDATION_SCALE_UP_WINDOW", "3m")
+			os.Setenv("IGNORED_RESOURCES", "example.com/device")
+			os.Setenv("IP_FAMILY", "IPv6")
+			os.Setenv("BATCH_BACKLOG_HIGH_THRESHOLD", "500")
+			os.Setenv("BATCH_BACKLOG_LOW_THRESHOLD", "10")
+			os.Setenv("STATE_SERVER_PORT", "9090")
+			os.Setenv("STATE_SERVER_ADDRESS", "0.0.0.0")
+			os.Setenv("ZONAL_SKEW_THRESHOLD", "3")
+			os.Setenv("DELETE_INSTEAD_OF_EVICT_OWNERS", "Workflow.argoproj.io")
+			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true,NodePoolImpact=true,NominatedNodeName=true,NodeSlicing=true,PreemptionAdvisorEviction=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 			}
@@ -233,24 +518,67 @@ var _ = Describe("Options", func() {
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                  lo.ToPtr("cli"),
+				MetricsPort:                  lo.ToPtr(0),
+				HealthProbePort:              lo.ToPtr(0),
+				KubeClientQPS:                lo.ToPtr(0),
+				KubeClientBurst:              lo.ToPtr(0),
+				EnableProfiling:              lo.ToPtr(true),
+				EnableSchedulingSnapshot:     lo.ToPtr(true),
+				DisableLeaderElection:        lo.ToPtr(true),
+				LeaderElectionName:           lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:      lo.ToPtr(""),
+				MemoryLimit:                  lo.ToPtr[int64](0),
+				LogLevel:                     lo.ToPtr("debug"),
+				LogOutputPaths:               lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:          lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:             lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:            lo.ToPtr(5 * time.Second),
+				DryRun:                       lo.ToPtr(true),
+				InstanceTypeCacheTTL:         lo.ToPtr(5 * time.Minute),
+				LifecycleHooksConfig:         lo.ToPtr("/etc/karpenter/hooks.yaml"),
+				NodeProvenanceRetention:      lo.ToPtr(720 * time.Hour),
+				NodeOverhead:                 lo.ToPtr("cpu=max(500m,6%),memory=1Gi"),
+				ProtectLocalVolumes:          lo.ToPtr(true),
+				MaxNodes:                     lo.ToPtr(100),
+				FailedLaunchRetention:        lo.ToPtr(5 * time.Minute),
+				FailedLaunchHistoryLimit:     lo.ToPtr(5),
+				ForceEvictNamespaces:         lo.ToPtr([]string{"logging", "monitoring"}),
+				CapacityTypeLabelAliases:     lo.ToPtr([]string{"eks.amazonaws.com/capacityType"}),
+				NormalizeDeprecatedLabels:    lo.ToPtr(true),
+				ShardCount:                   lo.ToPtr(3),
+				ShardIndex:                   lo.ToPtr(1),
+				ShardNodePoolSelector:        lo.ToPtr("karpenter.sh/shard=a"),
+				PodOrderingStrategy:          lo.ToPtr("Priority"),
+				SpreadOwnerReplicas:          lo.ToPtr(true),
+				PreferencePolicy:             lo.ToPtr("PreferExistingCapacity"),
+				OTLPTracesEndpoint:           lo.ToPtr("http://otel-collector:4318/v1/traces"),
+				JobCompletionWindow:          lo.ToPtr(5 * time.Minute),
+				StandalonePodPolicy:          lo.ToPtr("Ignore"),
+				StandalonePodNamespaces:      lo.ToPtr([]string{"debug", "batch"}),
+				PriceMarkupThreshold:         lo.ToPtr(25),
+				PreemptionAdvisor:            lo.ToPtr(true),
+				DefaultNodeLabels:            lo.ToPtr([]string{"example.com/team=platform"}),
+				DefaultNodeAnnotations:       lo.ToPtr([]string{"example.com/owner=platform"}),
+				DefaultNodeTaints:            lo.ToPtr([]string{"example.com/dedicated=platform:NoSchedule"}),
+				DisruptionDampeningThreshold: lo.ToPtr(25),
+				DisruptionDampeningWindow:    lo.ToPtr(5 * time.Minute),
+				ConsolidationScaleUpWindow:   lo.ToPtr(3 * time.Minute),
+				IgnoredResources:             lo.ToPtr([]string{"example.com/device"}),
+				IPFamily:                     lo.ToPtr("IPv6"),
+				BatchBacklogHighThreshold:    lo.ToPtr(500),
+				BatchBacklogLowThreshold:     lo.ToPtr(10),
+				StateServerPort:              lo.ToPtr(9090),
+				StateServerAddress:           lo.ToPtr("0.0.0.0"),
+				ZonalSkewThreshold:           lo.ToPtr(3),
+				DeleteInsteadOfEvictOwners:   lo.ToPtr([]string{"Workflow.argoproj.io"}),
 				FeatureGates: test.FeatureGates{
-					NodeRepair:              lo.ToPtr(true),
-					SpotToSpotConsolidation: lo.ToPtr(true),
+					NodeRepair:                lo.ToPtr(true),
+					SpotToSpotConsolidation:   lo.ToPtr(true),
+					NodePoolImpact:            lo.ToPtr(true),
+					NominatedNodeName:         lo.ToPtr(true),
+					NodeSlicing:               lo.ToPtr(true),
+					PreemptionAdvisorEviction: lo.ToPtr(true),
 				},
 			}))
 		})
@@ -281,6 +609,87 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--log-level", "hello")
 			Expect(err).ToNot(BeNil())
 		})
+		It("should error with a negative max nodes", func() {
+			err := opts.Parse(fs, "--max-nodes", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a shard count that isn't positive", func() {
+			err := opts.Parse(fs, "--shard-count", "0")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a shard index that isn't less than the shard count", func() {
+			err := opts.Parse(fs, "--shard-count", "2", "--shard-index", "2")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid shard nodepool selector", func() {
+			err := opts.Parse(fs, "--shard-nodepool-selector", "karpenter.sh/shard in (a")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid ip family", func() {
+			err := opts.Parse(fs, "--ip-family", "IPv5")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative state server port", func() {
+			err := opts.Parse(fs, "--state-server-port", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative zonal skew threshold", func() {
+			err := opts.Parse(fs, "--zonal-skew-threshold", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an owner that isn't a group kind", func() {
+			err := opts.Parse(fs, "--delete-instead-of-evict-owners", ".argoproj.io")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative batch backlog threshold", func() {
+			err := opts.Parse(fs, "--batch-backlog-high-threshold", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error when the low batch backlog threshold isn't less than the high threshold", func() {
+			err := opts.Parse(fs, "--batch-backlog-high-threshold", "10", "--batch-backlog-low-threshold", "10")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid pod ordering strategy", func() {
+			err := opts.Parse(fs, "--pod-ordering-strategy", "Random")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid preference policy", func() {
+			err := opts.Parse(fs, "--preference-policy", "PreferCost")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid standalone pod policy", func() {
+			err := opts.Parse(fs, "--standalone-pod-policy", "Skip")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative price markup threshold", func() {
+			err := opts.Parse(fs, "--price-markup-threshold", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a disruption dampening threshold that isn't a percentage", func() {
+			err := opts.Parse(fs, "--disruption-dampening-threshold", "101")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a disruption dampening window that isn't positive", func() {
+			err := opts.Parse(fs, "--disruption-dampening-window", "0s")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative consolidation scale up window", func() {
+			err := opts.Parse(fs, "--consolidation-scale-up-window", "-1m")
+			Expect(err).ToNot(BeNil())
+		})
+		DescribeTable("should error with invalid default node metadata",
+			func(args ...string) {
+				err := opts.Parse(fs, args...)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("label without a value", "--default-node-labels", "example.com/team"),
+			Entry("restricted label", "--default-node-labels", "karpenter.sh/nodepool=default"),
+			Entry("well known label", "--default-node-labels", "topology.kubernetes.io/zone=test-zone-1"),
+			Entry("invalid label value", "--default-node-labels", "example.com/team=not a value"),
+			Entry("invalid annotation key", "--default-node-annotations", "not a key=value"),
+			Entry("taint without an effect", "--default-node-taints", "example.com/dedicated=platform"),
+			Entry("taint with an invalid effect", "--default-node-taints", "example.com/dedicated=platform:Never"),
+		)
 	})
 })
 
@@ -297,6 +706,7 @@ func expectOptionsMatch(optsA, optsB *options.Options) {
 	Expect(optsA.KubeClientQPS).To(Equal(optsB.KubeClientQPS))
 	Expect(optsA.KubeClientBurst).To(Equal(optsB.KubeClientBurst))
 	Expect(optsA.EnableProfiling).To(Equal(optsB.EnableProfiling))
+	Expect(optsA.EnableSchedulingSnapshot).To(Equal(optsB.EnableSchedulingSnapshot))
 	Expect(optsA.DisableLeaderElection).To(Equal(optsB.DisableLeaderElection))
 	Expect(optsA.MemoryLimit).To(Equal(optsB.MemoryLimit))
 	Expect(optsA.LogLevel).To(Equal(optsB.LogLevel))
@@ -304,5 +714,47 @@ func expectOptionsMatch(optsA, optsB *options.Options) {
 	Expect(optsA.LogErrorOutputPaths).To(Equal(optsB.LogErrorOutputPaths))
 	Expect(optsA.BatchMaxDuration).To(Equal(optsB.BatchMaxDuration))
 	Expect(optsA.BatchIdleDuration).To(Equal(optsB.BatchIdleDuration))
+	Expect(optsA.DryRun).To(Equal(optsB.DryRun))
+	Expect(optsA.InstanceTypeCacheTTL).To(Equal(optsB.InstanceTypeCacheTTL))
+	Expect(optsA.LifecycleHooksConfig).To(Equal(optsB.LifecycleHooksConfig))
+	Expect(optsA.NodeProvenanceRetention).To(Equal(optsB.NodeProvenanceRetention))
+	Expect(optsA.NodeOverhead).To(Equal(optsB.NodeOverhead))
+	Expect(optsA.ProtectLocalVolumes).To(Equal(optsB.ProtectLocalVolumes))
+	Expect(optsA.MaxNodes).To(Equal(optsB.MaxNodes))
+	Expect(optsA.FailedLaunchRetention).To(Equal(optsB.FailedLaunchRetention))
+	Expect(optsA.FailedLaunchHistoryLimit).To(Equal(optsB.FailedLaunchHistoryLimit))
+	Expect(optsA.ForceEvictNamespaces).To(Equal(optsB.ForceEvictNamespaces))
+	Expect(optsA.CapacityTypeLabelAliases).To(Equal(optsB.CapacityTypeLabelAliases))
+	Expect(optsA.NormalizeDeprecatedLabels).To(Equal(optsB.NormalizeDeprecatedLabels))
+	Expect(optsA.ShardCount).To(Equal(optsB.ShardCount))
+	Expect(optsA.ShardIndex).To(Equal(optsB.ShardIndex))
+	Expect(optsA.ShardNodePoolSelector).To(Equal(optsB.ShardNodePoolSelector))
+	Expect(optsA.PodOrderingStrategy).To(Equal(optsB.PodOrderingStrategy))
+	Expect(optsA.SpreadOwnerReplicas).To(Equal(optsB.SpreadOwnerReplicas))
+	Expect(optsA.PreferencePolicy).To(Equal(optsB.PreferencePolicy))
+	Expect(optsA.OTLPTracesEndpoint).To(Equal(optsB.OTLPTracesEndpoint))
+	Expect(optsA.JobCompletionWindow).To(Equal(optsB.JobCompletionWindow))
+	Expect(optsA.StandalonePodPolicy).To(Equal(optsB.StandalonePodPolicy))
+	Expect(optsA.StandalonePodNamespaces).To(Equal(optsB.StandalonePodNamespaces))
+	Expect(optsA.PriceMarkupThreshold).To(Equal(optsB.PriceMarkupThreshold))
+	Expect(optsA.PreemptionAdvisor).To(Equal(optsB.PreemptionAdvisor))
+	Expect(optsA.DefaultNodeLabels).To(Equal(optsB.DefaultNodeLabels))
+	Expect(optsA.DefaultNodeAnnotations).To(Equal(optsB.DefaultNodeAnnotations))
+	Expect(optsA.DefaultNodeTaints).To(Equal(optsB.DefaultNodeTaints))
+	Expect(optsA.DisruptionDampeningThreshold).To(Equal(optsB.DisruptionDampeningThreshold))
+	Expect(optsA.DisruptionDampeningWindow).To(Equal(optsB.DisruptionDampeningWindow))
+	Expect(optsA.ConsolidationScaleUpWindow).To(Equal(optsB.ConsolidationScaleUpWindow))
+	Expect(optsA.IgnoredResources).To(Equal(optsB.IgnoredResources))
+	Expect(optsA.IPFamily).To(Equal(optsB.IPFamily))
+	Expect(optsA.BatchBacklogHighThreshold).To(Equal(optsB.BatchBacklogHighThreshold))
+	Expect(optsA.BatchBacklogLowThreshold).To(Equal(optsB.BatchBacklogLowThreshold))
+	Expect(optsA.StateServerPort).To(Equal(optsB.StateServerPort))
+	Expect(optsA.StateServerAddress).To(Equal(optsB.StateServerAddress))
+	Expect(optsA.ZonalSkewThreshold).To(Equal(optsB.ZonalSkewThreshold))
+	Expect(optsA.DeleteInsteadOfEvictOwners).To(Equal(optsB.DeleteInsteadOfEvictOwners))
 	Expect(optsA.FeatureGates.SpotToSpotConsolidation).To(Equal(optsB.FeatureGates.SpotToSpotConsolidation))
+	Expect(optsA.FeatureGates.NodePoolImpact).To(Equal(optsB.FeatureGates.NodePoolImpact))
+	Expect(optsA.FeatureGates.NominatedNodeName).To(Equal(optsB.FeatureGates.NominatedNodeName))
+	Expect(optsA.FeatureGates.NodeSlicing).To(Equal(optsB.FeatureGates.NodeSlicing))
+	Expect(optsA.FeatureGates.PreemptionAdvisorEviction).To(Equal(optsB.FeatureGates.PreemptionAdvisorEviction))
 }
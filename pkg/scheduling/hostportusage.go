@@ -89,26 +89,46 @@ func (u *HostPortUsage) DeletePod(key types.NamespacedName) {
 	delete(u.reserved, key)
 }
 
+// GetHostPorts returns the host ports that are used by the pod's containers, init containers and ephemeral containers.
+// Init containers hold their host ports while they run, so they can't share a node with a conflicting pod either.
 func GetHostPorts(pod *v1.Pod) []HostPort {
 	var usage []HostPort
+	for _, c := range pod.Spec.InitContainers {
+		usage = append(usage, getContainerHostPorts(c.Ports)...)
+	}
 	for _, c := range pod.Spec.Containers {
-		for _, p := range c.Ports {
-			if p.HostPort == 0 {
-				continue
-			}
-			// Per the K8s docs, "If you don't specify the hostIP and Protocol explicitly, Kubernetes will use 0.0.0.0
-			// as the default hostIP and TCP as the default Protocol." In testing, and looking at the code the Protocol
-			// is defaulted to TCP, but it leaves the IP empty.
-			hostIP := p.HostIP
-			if hostIP == "" {
-				hostIP = "0.0.0.0"
-			}
-			usage = append(usage, HostPort{
-				IP:       net.ParseIP(hostIP),
-				Port:     p.HostPort,
-				Protocol: p.Protocol,
-			})
+		usage = append(usage, getContainerHostPorts(c.Ports)...)
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		usage = append(usage, getContainerHostPorts(c.Ports)...)
+	}
+	return usage
+}
+
+func getContainerHostPorts(ports []v1.ContainerPort) []HostPort {
+	var usage []HostPort
+	for _, p := range ports {
+		if p.HostPort == 0 {
+			continue
+		}
+		// Per the K8s docs, "If you don't specify the hostIP and Protocol explicitly, Kubernetes will use 0.0.0.0
+		// as the default hostIP and TCP as the default Protocol." In testing, and looking at the code the Protocol
+		// is defaulted to TCP, but it leaves the IP empty.
+		hostIP := p.HostIP
+		if hostIP == "" {
+			hostIP = "0.0.0.0"
+		}
+		// Pods that haven't been through API server defaulting, e.g. in simulations, may not have a protocol. Only
+		// ports with the same protocol conflict, so a TCP and an SCTP port with the same number can share a node.
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = v1.ProtocolTCP
 		}
+		usage = append(usage, HostPort{
+			IP:       net.ParseIP(hostIP),
+			Port:     p.HostPort,
+			Protocol: protocol,
+		})
 	}
 	return usage
 }
@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("HostPortUsage", func() {
@@ -100,4 +101,38 @@ var _ = Describe("HostPortUsage", func() {
 			Expect(e2.Matches(e1)).To(BeFalse())
 		})
 	})
+	Context("GetHostPorts", func() {
+		It("should include the host ports of init, ephemeral and regular containers", func() {
+			pod := &v1.Pod{Spec: v1.PodSpec{
+				InitContainers: []v1.Container{{Ports: []v1.ContainerPort{{HostPort: 80, Protocol: v1.ProtocolTCP}}}},
+				Containers: []v1.Container{
+					{Ports: []v1.ContainerPort{{HostPort: 443, Protocol: v1.ProtocolTCP}, {ContainerPort: 8080}}},
+					{Ports: []v1.ContainerPort{{HostPort: 9000, HostIP: "10.0.0.1", Protocol: v1.ProtocolSCTP}}},
+				},
+				EphemeralContainers: []v1.EphemeralContainer{{EphemeralContainerCommon: v1.EphemeralContainerCommon{
+					Ports: []v1.ContainerPort{{HostPort: 5000, Protocol: v1.ProtocolUDP}},
+				}}},
+			}}
+			Expect(GetHostPorts(pod)).To(Equal([]HostPort{
+				{IP: net.ParseIP("0.0.0.0"), Port: 80, Protocol: v1.ProtocolTCP},
+				{IP: net.ParseIP("0.0.0.0"), Port: 443, Protocol: v1.ProtocolTCP},
+				{IP: net.ParseIP("10.0.0.1"), Port: 9000, Protocol: v1.ProtocolSCTP},
+				{IP: net.ParseIP("0.0.0.0"), Port: 5000, Protocol: v1.ProtocolUDP},
+			}))
+		})
+		It("should default the protocol to TCP", func() {
+			pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Ports: []v1.ContainerPort{{HostPort: 80}}}}}}
+			Expect(GetHostPorts(pod)).To(Equal([]HostPort{{IP: net.ParseIP("0.0.0.0"), Port: 80, Protocol: v1.ProtocolTCP}}))
+		})
+		It("should only detect conflicts between ports with the same protocol", func() {
+			usage := NewHostPortUsage()
+			daemon := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "daemon"}, Spec: v1.PodSpec{Containers: []v1.Container{{Ports: []v1.ContainerPort{{HostPort: 3868, Protocol: v1.ProtocolSCTP}}}}}}
+			usage.Add(daemon, GetHostPorts(daemon))
+
+			tcp := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "tcp"}, Spec: v1.PodSpec{Containers: []v1.Container{{Ports: []v1.ContainerPort{{HostPort: 3868}}}}}}
+			Expect(usage.Conflicts(tcp, GetHostPorts(tcp))).To(Succeed())
+			sctp := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sctp"}, Spec: v1.PodSpec{InitContainers: []v1.Container{{Ports: []v1.ContainerPort{{HostPort: 3868, Protocol: v1.ProtocolSCTP}}}}}}
+			Expect(usage.Conflicts(sctp, GetHostPorts(sctp))).ToNot(Succeed())
+		})
+	})
 })
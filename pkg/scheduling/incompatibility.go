@@ -0,0 +1,76 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+)
+
+// IncompatibilityReason is the reason that an incoming requirement conflicts with the existing requirements
+type IncompatibilityReason string
+
+const (
+	// IncompatibilityReasonNoIntersection is the reason of requirements that don't have overlapping values
+	IncompatibilityReasonNoIntersection IncompatibilityReason = "NoIntersection"
+	// IncompatibilityReasonUndefinedLabel is the reason of requirements on custom labels that the existing requirements
+	// don't define any values for
+	IncompatibilityReasonUndefinedLabel IncompatibilityReason = "UndefinedLabel"
+)
+
+// Incompatibility describes why an incoming requirement, e.g. of a pod, conflicts with the existing requirements, e.g. of
+// a NodePool. It's returned as an error from Requirements.Compatible and Requirements.Intersects, and the string is only
+// generated when the error is presented since the reason that requirements fail to match is rarely needed.
+type Incompatibility struct {
+	Key    string
+	Reason IncompatibilityReason
+	// Incoming is the requirement that conflicts with the existing requirements, whose operator and values are those of
+	// e.g. the pod
+	Incoming *Requirement
+	// Existing is the requirement that the incoming requirement conflicts with, whose operator and values are those of
+	// e.g. the NodePool. It's nil for undefined labels.
+	Existing *Requirement
+
+	// hint suggests a label that an undefined label may be a typo of
+	hint string
+}
+
+func (i Incompatibility) Error() string {
+	if i.Reason == IncompatibilityReasonUndefinedLabel {
+		return fmt.Sprintf("label %q does not have known values%s", i.Key, i.hint)
+	}
+	return fmt.Sprintf("key %s, %s not in %s", i.Key, i.Incoming, i.Existing)
+}
+
+// Incompatibilities returns all of the incompatibilities in the error, including those that are wrapped or combined
+// with other errors
+func Incompatibilities(err error) []Incompatibility {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case Incompatibility:
+		return []Incompatibility{e}
+	case interface{ Unwrap() []error }:
+		var incompatibilities []Incompatibility
+		for _, err := range e.Unwrap() {
+			incompatibilities = append(incompatibilities, Incompatibilities(err)...)
+		}
+		return incompatibilities
+	case interface{ Unwrap() error }:
+		return Incompatibilities(e.Unwrap())
+	}
+	return nil
+}
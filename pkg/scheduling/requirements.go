@@ -161,10 +161,13 @@ func (r Requirements) Get(key string) *Requirement {
 
 type CompatibilityOptions struct {
 	AllowUndefined sets.Set[string]
+	// AllowUndefinedDomains allows the undefined labels of the domains, in addition to the AllowUndefined labels
+	AllowUndefinedDomains sets.Set[string]
 }
 
 var AllowUndefinedWellKnownLabels = func(options *CompatibilityOptions) {
 	options.AllowUndefined = v1.WellKnownLabels
+	options.AllowUndefinedDomains = v1.WellKnownLabelDomains
 }
 
 func (r Requirements) IsCompatible(requirements Requirements, options ...option.Function[CompatibilityOptions]) bool {
@@ -180,7 +183,15 @@ func (r Requirements) Compatible(requirements Requirements, options ...option.Fu
 		if operator := requirements.Get(key).Operator(); r.Has(key) || operator == corev1.NodeSelectorOpNotIn || operator == corev1.NodeSelectorOpDoesNotExist {
 			continue
 		}
-		errs = multierr.Append(errs, fmt.Errorf("label %q does not have known values%s", key, labelHint(r, key, opts.AllowUndefined)))
+		if opts.AllowUndefinedDomains.Has(v1.GetLabelDomain(key)) {
+			continue
+		}
+		errs = multierr.Append(errs, Incompatibility{
+			Key:      key,
+			Reason:   IncompatibilityReasonUndefinedLabel,
+			Incoming: requirements.Get(key),
+			hint:     labelHint(r, key, opts.AllowUndefined),
+		})
 	}
 	// Well Known Labels must intersect, but if not defined, are allowed.
 	return multierr.Append(errs, r.Intersects(requirements))
@@ -250,18 +261,6 @@ func labelHint(r Requirements, key string, allowedUndefined sets.Set[string]) st
 	return ""
 }
 
-// badKeyError allows lazily generating the error string in the case of a bad key error. When requirements fail
-// to match, we are most often interested in the failure and not why it fails.
-type badKeyError struct {
-	key      string
-	incoming *Requirement
-	existing *Requirement
-}
-
-func (b badKeyError) Error() string {
-	return fmt.Sprintf("key %s, %s not in %s", b.key, b.incoming, b.existing)
-}
-
 // intersectKeys is much faster and allocates less han getting the two key sets separately and intersecting them
 func (r Requirements) intersectKeys(rhs Requirements) sets.Set[string] {
 	smallest := r
@@ -293,10 +292,11 @@ func (r Requirements) Intersects(requirements Requirements) (errs error) {
 					continue
 				}
 			}
-			errs = multierr.Append(errs, badKeyError{
-				key:      key,
-				incoming: incoming,
-				existing: existing,
+			errs = multierr.Append(errs, Incompatibility{
+				Key:      key,
+				Reason:   IncompatibilityReasonNoIntersection,
+				Incoming: incoming,
+				Existing: existing,
 			})
 		}
 	}
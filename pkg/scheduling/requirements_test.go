@@ -17,6 +17,7 @@ limitations under the License.
 package scheduling
 
 import (
+	"fmt"
 	"os"
 	"runtime/pprof"
 	"testing"
@@ -573,6 +574,32 @@ var _ = Describe("Requirements", func() {
 			Expect(unconstrained.Compatible(req).Error()).To(Equal(`label "deployment" does not have known values`))
 		})
 	})
+	Context("Incompatibilities", func() {
+		It("should describe each of the incompatible requirements", func() {
+			existing := NewRequirements(NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "test-zone-1"))
+			incoming := NewRequirements(
+				NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "test-zone-2"),
+				NewRequirement("deployment", corev1.NodeSelectorOpExists),
+			)
+			incompatibilities := Incompatibilities(fmt.Errorf("incompatible requirements, %w", existing.Compatible(incoming)))
+			Expect(incompatibilities).To(ConsistOf(
+				Incompatibility{
+					Key:      corev1.LabelTopologyZone,
+					Reason:   IncompatibilityReasonNoIntersection,
+					Incoming: incoming.Get(corev1.LabelTopologyZone),
+					Existing: existing.Get(corev1.LabelTopologyZone),
+				},
+				HaveField("Reason", IncompatibilityReasonUndefinedLabel),
+			))
+			undefined, _ := lo.Find(incompatibilities, func(i Incompatibility) bool { return i.Key == "deployment" })
+			Expect(undefined.Incoming).To(Equal(incoming.Get("deployment")))
+			Expect(undefined.Existing).To(BeNil())
+		})
+		It("should not describe compatible requirements", func() {
+			existing := NewRequirements(NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "test-zone-1"))
+			Expect(Incompatibilities(existing.Compatible(existing))).To(BeEmpty())
+		})
+	})
 	Context("NodeSelectorRequirements Conversion", func() {
 		It("should convert combinations of labels to expected NodeSelectorRequirements", func() {
 			exists := NewRequirement("exists", corev1.NodeSelectorOpExists)
@@ -55,6 +55,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	karpv1alpha1 "sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
@@ -253,6 +254,9 @@ func ExpectCleanedUp(ctx context.Context, c client.Client) {
 		&v1.NodePool{},
 		&v1alpha1.TestNodeClass{},
 		&v1.NodeClaim{},
+		&karpv1alpha1.NodeProvenance{},
+		&karpv1alpha1.DisruptionPlan{},
+		&karpv1alpha1.ReadinessGate{},
 	} {
 		for _, namespace := range namespaces.Items {
 			wg.Add(1)
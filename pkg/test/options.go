@@ -28,27 +28,70 @@ import (
 
 type OptionsFields struct {
 	// Vendor Neutral
-	ServiceName             *string
-	MetricsPort             *int
-	HealthProbePort         *int
-	KubeClientQPS           *int
-	KubeClientBurst         *int
-	EnableProfiling         *bool
-	DisableLeaderElection   *bool
-	LeaderElectionName      *string
-	LeaderElectionNamespace *string
-	MemoryLimit             *int64
-	LogLevel                *string
-	LogOutputPaths          *string
-	LogErrorOutputPaths     *string
-	BatchMaxDuration        *time.Duration
-	BatchIdleDuration       *time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                  *string
+	MetricsPort                  *int
+	HealthProbePort              *int
+	KubeClientQPS                *int
+	KubeClientBurst              *int
+	EnableProfiling              *bool
+	EnableSchedulingSnapshot     *bool
+	DisableLeaderElection        *bool
+	LeaderElectionName           *string
+	LeaderElectionNamespace      *string
+	MemoryLimit                  *int64
+	LogLevel                     *string
+	LogOutputPaths               *string
+	LogErrorOutputPaths          *string
+	BatchMaxDuration             *time.Duration
+	BatchIdleDuration            *time.Duration
+	DryRun                       *bool
+	InstanceTypeCacheTTL         *time.Duration
+	LifecycleHooksConfig         *string
+	NodeProvenanceRetention      *time.Duration
+	NodeOverhead                 *string
+	ProtectLocalVolumes          *bool
+	MaxNodes                     *int
+	FailedLaunchRetention        *time.Duration
+	FailedLaunchHistoryLimit     *int
+	ForceEvictNamespaces         *[]string
+	CapacityTypeLabelAliases     *[]string
+	NormalizeDeprecatedLabels    *bool
+	ShardCount                   *int
+	ShardIndex                   *int
+	ShardNodePoolSelector        *string
+	PodOrderingStrategy          *string
+	SpreadOwnerReplicas          *bool
+	PreferencePolicy             *string
+	OTLPTracesEndpoint           *string
+	JobCompletionWindow          *time.Duration
+	StandalonePodPolicy          *string
+	StandalonePodNamespaces      *[]string
+	PriceMarkupThreshold         *int
+	PreemptionAdvisor            *bool
+	DefaultNodeLabels            *[]string
+	DefaultNodeAnnotations       *[]string
+	DefaultNodeTaints            *[]string
+	DisruptionDampeningThreshold *int
+	DisruptionDampeningWindow    *time.Duration
+	ConsolidationScaleUpWindow   *time.Duration
+	IgnoredResources             *[]string
+	IPFamily                     *string
+	BatchBacklogHighThreshold    *int
+	BatchBacklogLowThreshold     *int
+	StateServerPort              *int
+	StateServerAddress           *string
+	ZonalSkewThreshold           *int
+	DeleteInsteadOfEvictOwners   *[]string
+	FeatureGates                 FeatureGates
 }
 
 type FeatureGates struct {
-	NodeRepair              *bool
-	SpotToSpotConsolidation *bool
+	NodeRepair                *bool
+	SpotToSpotConsolidation   *bool
+	NodePoolImpact            *bool
+	NominatedNodeName         *bool
+	NodeSlicing               *bool
+	PreemptionAdvisorEviction *bool
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -60,22 +103,65 @@ func Options(overrides ...OptionsFields) *options.Options {
 	}
 
 	return &options.Options{
-		ServiceName:           lo.FromPtrOr(opts.ServiceName, ""),
-		MetricsPort:           lo.FromPtrOr(opts.MetricsPort, 8080),
-		HealthProbePort:       lo.FromPtrOr(opts.HealthProbePort, 8081),
-		KubeClientQPS:         lo.FromPtrOr(opts.KubeClientQPS, 200),
-		KubeClientBurst:       lo.FromPtrOr(opts.KubeClientBurst, 300),
-		EnableProfiling:       lo.FromPtrOr(opts.EnableProfiling, false),
-		DisableLeaderElection: lo.FromPtrOr(opts.DisableLeaderElection, false),
-		MemoryLimit:           lo.FromPtrOr(opts.MemoryLimit, -1),
-		LogLevel:              lo.FromPtrOr(opts.LogLevel, ""),
-		LogOutputPaths:        lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
-		LogErrorOutputPaths:   lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
-		BatchMaxDuration:      lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
-		BatchIdleDuration:     lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ServiceName:                  lo.FromPtrOr(opts.ServiceName, ""),
+		MetricsPort:                  lo.FromPtrOr(opts.MetricsPort, 8080),
+		HealthProbePort:              lo.FromPtrOr(opts.HealthProbePort, 8081),
+		KubeClientQPS:                lo.FromPtrOr(opts.KubeClientQPS, 200),
+		KubeClientBurst:              lo.FromPtrOr(opts.KubeClientBurst, 300),
+		EnableProfiling:              lo.FromPtrOr(opts.EnableProfiling, false),
+		EnableSchedulingSnapshot:     lo.FromPtrOr(opts.EnableSchedulingSnapshot, false),
+		DisableLeaderElection:        lo.FromPtrOr(opts.DisableLeaderElection, false),
+		MemoryLimit:                  lo.FromPtrOr(opts.MemoryLimit, -1),
+		LogLevel:                     lo.FromPtrOr(opts.LogLevel, ""),
+		LogOutputPaths:               lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
+		LogErrorOutputPaths:          lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
+		BatchMaxDuration:             lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
+		BatchIdleDuration:            lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		DryRun:                       lo.FromPtrOr(opts.DryRun, false),
+		InstanceTypeCacheTTL:         lo.FromPtrOr(opts.InstanceTypeCacheTTL, 0),
+		LifecycleHooksConfig:         lo.FromPtrOr(opts.LifecycleHooksConfig, ""),
+		NodeProvenanceRetention:      lo.FromPtrOr(opts.NodeProvenanceRetention, 0),
+		NodeOverhead:                 lo.FromPtrOr(opts.NodeOverhead, ""),
+		ProtectLocalVolumes:          lo.FromPtrOr(opts.ProtectLocalVolumes, false),
+		MaxNodes:                     lo.FromPtrOr(opts.MaxNodes, 0),
+		FailedLaunchRetention:        lo.FromPtrOr(opts.FailedLaunchRetention, time.Duration(0)),
+		FailedLaunchHistoryLimit:     lo.FromPtrOr(opts.FailedLaunchHistoryLimit, 3),
+		ForceEvictNamespaces:         lo.FromPtrOr(opts.ForceEvictNamespaces, []string(nil)),
+		CapacityTypeLabelAliases:     lo.FromPtrOr(opts.CapacityTypeLabelAliases, []string(nil)),
+		NormalizeDeprecatedLabels:    lo.FromPtrOr(opts.NormalizeDeprecatedLabels, false),
+		ShardCount:                   lo.FromPtrOr(opts.ShardCount, 1),
+		ShardIndex:                   lo.FromPtrOr(opts.ShardIndex, 0),
+		ShardNodePoolSelector:        lo.FromPtrOr(opts.ShardNodePoolSelector, ""),
+		PodOrderingStrategy:          lo.FromPtrOr(opts.PodOrderingStrategy, "ResourceSize"),
+		SpreadOwnerReplicas:          lo.FromPtrOr(opts.SpreadOwnerReplicas, false),
+		PreferencePolicy:             lo.FromPtrOr(opts.PreferencePolicy, "PreferPreferenceSatisfaction"),
+		OTLPTracesEndpoint:           lo.FromPtrOr(opts.OTLPTracesEndpoint, ""),
+		JobCompletionWindow:          lo.FromPtrOr(opts.JobCompletionWindow, 0),
+		StandalonePodPolicy:          lo.FromPtrOr(opts.StandalonePodPolicy, "Provision"),
+		StandalonePodNamespaces:      lo.FromPtrOr(opts.StandalonePodNamespaces, []string(nil)),
+		PriceMarkupThreshold:         lo.FromPtrOr(opts.PriceMarkupThreshold, 0),
+		PreemptionAdvisor:            lo.FromPtrOr(opts.PreemptionAdvisor, false),
+		DefaultNodeLabels:            lo.FromPtrOr(opts.DefaultNodeLabels, []string(nil)),
+		DefaultNodeAnnotations:       lo.FromPtrOr(opts.DefaultNodeAnnotations, []string(nil)),
+		DefaultNodeTaints:            lo.FromPtrOr(opts.DefaultNodeTaints, []string(nil)),
+		DisruptionDampeningThreshold: lo.FromPtrOr(opts.DisruptionDampeningThreshold, 0),
+		DisruptionDampeningWindow:    lo.FromPtrOr(opts.DisruptionDampeningWindow, 10*time.Minute),
+		ConsolidationScaleUpWindow:   lo.FromPtrOr(opts.ConsolidationScaleUpWindow, 0),
+		IgnoredResources:             lo.FromPtrOr(opts.IgnoredResources, []string(nil)),
+		IPFamily:                     lo.FromPtrOr(opts.IPFamily, "IPv4"),
+		BatchBacklogHighThreshold:    lo.FromPtrOr(opts.BatchBacklogHighThreshold, 0),
+		BatchBacklogLowThreshold:     lo.FromPtrOr(opts.BatchBacklogLowThreshold, 0),
+		StateServerPort:              lo.FromPtrOr(opts.StateServerPort, 0),
+		StateServerAddress:           lo.FromPtrOr(opts.StateServerAddress, "127.0.0.1"),
+		ZonalSkewThreshold:           lo.FromPtrOr(opts.ZonalSkewThreshold, 0),
+		DeleteInsteadOfEvictOwners:   lo.FromPtrOr(opts.DeleteInsteadOfEvictOwners, []string(nil)),
 		FeatureGates: options.FeatureGates{
-			NodeRepair:              lo.FromPtrOr(opts.FeatureGates.NodeRepair, false),
-			SpotToSpotConsolidation: lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			NodeRepair:                lo.FromPtrOr(opts.FeatureGates.NodeRepair, false),
+			SpotToSpotConsolidation:   lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			NodePoolImpact:            lo.FromPtrOr(opts.FeatureGates.NodePoolImpact, false),
+			NominatedNodeName:         lo.FromPtrOr(opts.FeatureGates.NominatedNodeName, false),
+			NodeSlicing:               lo.FromPtrOr(opts.FeatureGates.NodeSlicing, false),
+			PreemptionAdvisorEviction: lo.FromPtrOr(opts.FeatureGates.PreemptionAdvisorEviction, false),
 		},
 	}
 }
@@ -47,6 +47,7 @@ type PodOptions struct {
 	PodAntiPreferences            []v1.WeightedPodAffinityTerm
 	TopologySpreadConstraints     []v1.TopologySpreadConstraint
 	Tolerations                   []v1.Toleration
+	SchedulingGates               []v1.PodSchedulingGate
 	PersistentVolumeClaims        []string
 	EphemeralVolumeTemplates      []EphemeralVolumeTemplateOptions
 	HostPorts                     []int32
@@ -126,6 +127,7 @@ func Pod(overrides ...PodOptions) *v1.Pod {
 			Affinity:                  buildAffinity(options),
 			TopologySpreadConstraints: options.TopologySpreadConstraints,
 			Tolerations:               options.Tolerations,
+			SchedulingGates:           options.SchedulingGates,
 			Containers: []v1.Container{{
 				Name:      RandomName(),
 				Image:     options.Image,
@@ -0,0 +1,222 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replay hydrates a scheduling simulation from a scheduling snapshot so that scheduling and disruption
+// decisions that were reported from a cluster can be reproduced in regression tests.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakecr "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+// TerminatingFinalizer is added to terminating objects in the snapshot that don't have a finalizer, since the fake
+// client refuses to create objects that are being deleted otherwise
+const TerminatingFinalizer = "test.karpenter.sh/replay"
+
+// Environment is a scheduling simulation that is backed by a fake client, a fake cloud provider and cluster state that
+// are hydrated from a scheduling snapshot. The context that is passed to the environment must contain the operator
+// options, e.g. from test.Options().
+type Environment struct {
+	Snapshot      *provisioning.Snapshot
+	Client        client.Client
+	Clock         *clock.FakeClock
+	CloudProvider *fake.CloudProvider
+	Recorder      *test.EventRecorder
+	Cluster       *state.Cluster
+	Provisioner   *provisioning.Provisioner
+
+	queue *orchestration.Queue
+}
+
+// Load hydrates an environment from the scheduling snapshot file at the given path
+func Load(ctx context.Context, path string) (*Environment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening scheduling snapshot, %w", err)
+	}
+	defer f.Close()
+	snapshot, err := provisioning.LoadSnapshot(f)
+	if err != nil {
+		return nil, err
+	}
+	return New(ctx, snapshot)
+}
+
+// New hydrates an environment from the scheduling snapshot. The clock of the environment is set to the time that the
+// snapshot was taken at.
+func New(ctx context.Context, snapshot *provisioning.Snapshot) (*Environment, error) {
+	objects := lo.Map(snapshot.Objects(), func(o client.Object, _ int) client.Object {
+		if o.GetDeletionTimestamp() != nil && len(o.GetFinalizers()) == 0 {
+			o.SetFinalizers([]string{TerminatingFinalizer})
+		}
+		return o
+	})
+	kubeClient := fakecr.NewClientBuilder().
+		WithObjects(objects...).
+		// These mirror the field indexers that are set up by the operator
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+			return []string{o.(*corev1.Pod).Spec.NodeName}
+		}).
+		WithIndex(&corev1.Node{}, "spec.providerID", func(o client.Object) []string {
+			return []string{o.(*corev1.Node).Spec.ProviderID}
+		}).
+		WithIndex(&v1.NodeClaim{}, "status.providerID", func(o client.Object) []string {
+			return []string{o.(*v1.NodeClaim).Status.ProviderID}
+		}).
+		WithIndex(&storagev1.VolumeAttachment{}, "spec.nodeName", func(o client.Object) []string {
+			return []string{o.(*storagev1.VolumeAttachment).Spec.NodeName}
+		}).
+		Build()
+
+	clk := clock.NewFakeClock(lo.Ternary(snapshot.Time.IsZero(), time.Now(), snapshot.Time.Time))
+	cloudProvider := fake.NewCloudProvider()
+	for nodePool, its := range snapshot.InstanceTypes {
+		cloudProvider.InstanceTypesForNodePool[nodePool] = lo.Map(its, func(it provisioning.SnapshotInstanceType, _ int) *cloudprovider.InstanceType {
+			return it.InstanceType()
+		})
+	}
+	recorder := test.NewEventRecorder()
+	cluster := state.NewCluster(clk, kubeClient, cloudProvider, recorder)
+	prov := provisioning.NewProvisioner(kubeClient, recorder, cloudProvider, cluster, clk)
+	env := &Environment{
+		Snapshot:      snapshot,
+		Client:        kubeClient,
+		Clock:         clk,
+		CloudProvider: cloudProvider,
+		Recorder:      recorder,
+		Cluster:       cluster,
+		Provisioner:   prov,
+		queue:         orchestration.NewQueue(kubeClient, recorder, cluster, clk, prov),
+	}
+	if err := env.hydrate(ctx); err != nil {
+		return nil, fmt.Errorf("hydrating cluster state, %w", err)
+	}
+	return env, nil
+}
+
+// hydrate populates cluster state in the same way that the informers would from the objects in the fake client
+func (e *Environment) hydrate(ctx context.Context) error {
+	for _, n := range e.Snapshot.Nodes {
+		if n.NodeClaim != nil {
+			nodeClaim := &v1.NodeClaim{}
+			if err := e.Client.Get(ctx, client.ObjectKeyFromObject(n.NodeClaim), nodeClaim); err != nil {
+				return fmt.Errorf("getting nodeclaim, %w", err)
+			}
+			e.CloudProvider.CreatedNodeClaims[nodeClaim.Status.ProviderID] = nodeClaim.DeepCopy()
+			e.Cluster.UpdateNodeClaim(nodeClaim)
+		}
+		if n.Node != nil {
+			node := &corev1.Node{}
+			if err := e.Client.Get(ctx, client.ObjectKeyFromObject(n.Node), node); err != nil {
+				return fmt.Errorf("getting node, %w", err)
+			}
+			if err := e.Cluster.UpdateNode(ctx, node); err != nil {
+				return fmt.Errorf("updating node, %w", err)
+			}
+		}
+	}
+	pods := &corev1.PodList{}
+	if err := e.Client.List(ctx, pods); err != nil {
+		return fmt.Errorf("listing pods, %w", err)
+	}
+	for i := range pods.Items {
+		if err := e.Cluster.UpdatePod(ctx, &pods.Items[i]); err != nil {
+			return fmt.Errorf("updating pod, %w", err)
+		}
+	}
+	for _, ds := range e.Snapshot.DaemonSets {
+		if err := e.Cluster.UpdateDaemonSet(ctx, ds); err != nil {
+			return fmt.Errorf("updating daemonset, %w", err)
+		}
+	}
+	for _, n := range e.Snapshot.Nodes {
+		providerID := lo.Ternary(n.NodeClaim != nil, lo.FromPtr(n.NodeClaim).Status.ProviderID, lo.FromPtr(n.Node).Spec.ProviderID)
+		if n.MarkedForDeletion {
+			e.Cluster.MarkForDeletion(providerID)
+		}
+		if n.Nominated {
+			e.Cluster.NominateNodeForPod(ctx, providerID)
+		}
+	}
+	return nil
+}
+
+// Provision runs a provisioning round for the pending pods and returns the scheduling decisions without launching any
+// NodeClaims
+func (e *Environment) Provision(ctx context.Context) (scheduling.Results, error) {
+	return e.Provisioner.Schedule(ctx)
+}
+
+// Disrupt runs a disruption round and returns the command of the first disruption method that decided to disrupt
+// nodes, without executing it. A command with a NoOp decision is returned if no method decided to disrupt nodes.
+func (e *Environment) Disrupt(ctx context.Context) (disruption.Command, error) {
+	// Consolidation waits before validating its commands, so the clock is stepped whenever something is waiting on it
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				if e.Clock.HasWaiters() {
+					e.Clock.Step(time.Minute)
+				}
+			}
+		}
+	}()
+	for _, m := range disruption.NewMethods(e.Clock, e.Cluster, e.Client, e.Provisioner, e.CloudProvider, e.Recorder, e.queue) {
+		candidates, err := disruption.GetCandidates(ctx, e.Cluster, e.Client, e.Recorder, e.Clock, e.CloudProvider, m.ShouldDisrupt, m.Class(), e.queue)
+		if err != nil {
+			return disruption.Command{}, fmt.Errorf("determining candidates, %w", err)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		budgets, err := disruption.BuildDisruptionBudgetMapping(ctx, e.Cluster, e.Clock, e.Client, e.CloudProvider, e.Recorder, m.Reason())
+		if err != nil {
+			return disruption.Command{}, fmt.Errorf("building disruption budgets, %w", err)
+		}
+		cmd, _, err := m.ComputeCommand(ctx, budgets, candidates...)
+		if err != nil {
+			return disruption.Command{}, fmt.Errorf("computing disruption decision, %w", err)
+		}
+		if cmd.Decision() != disruption.NoOpDecision {
+			return cmd, nil
+		}
+	}
+	return disruption.Command{}, nil
+}
@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/replay"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestReplay(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Replay")
+}
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+})
+
+var _ = Describe("Replay", func() {
+	var nodePool *v1.NodePool
+	var instanceType *cloudprovider.InstanceType
+	var snapshot *provisioning.Snapshot
+
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Disruption: v1.Disruption{
+			ConsolidateAfter:    v1.MustParseNillableDuration("0s"),
+			ConsolidationPolicy: v1.ConsolidationPolicyWhenEmptyOrUnderutilized,
+			Budgets:             []v1.Budget{{Nodes: "100%"}},
+		}}})
+		instanceType = fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name: "test-instance-type",
+			Resources: corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("4"),
+				corev1.ResourcePods: resource.MustParse("10"),
+			},
+		})
+		snapshot = &provisioning.Snapshot{
+			Time:      metav1.Now(),
+			Synced:    true,
+			NodePools: []*v1.NodePool{nodePool},
+			InstanceTypes: map[string][]provisioning.SnapshotInstanceType{
+				nodePool.Name: {provisioning.NewSnapshotInstanceType(instanceType)},
+			},
+		}
+	})
+
+	// existingNode adds an initialized node of the instance type to the snapshot
+	existingNode := func(pods ...*corev1.Pod) *corev1.Node {
+		offering := instanceType.Offerings[0]
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: instanceType.Name,
+					v1.CapacityTypeLabelKey:        offering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       offering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					v1.NodeRegisteredLabelKey:      "true",
+					v1.NodeInitializedLabelKey:     "true",
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Capacity:    instanceType.Capacity,
+				Allocatable: instanceType.Allocatable(),
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeLaunched)
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeRegistered)
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+		node.Spec.Taints = nil
+		node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		for _, p := range pods {
+			p.Spec.NodeName = node.Name
+		}
+		snapshot.Nodes = append(snapshot.Nodes, provisioning.SnapshotNode{Node: node, NodeClaim: nodeClaim, Registered: true, Initialized: true, Pods: pods})
+		return node
+	}
+
+	It("should round trip snapshots through JSON", func() {
+		existingNode(test.Pod())
+		snapshot.PendingPods = []*corev1.Pod{test.UnschedulablePod()}
+		path := filepath.Join(GinkgoT().TempDir(), "snapshot.json")
+		Expect(os.WriteFile(path, lo.Must(json.Marshal(snapshot)), 0600)).To(Succeed())
+
+		env, err := replay.Load(ctx, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(env.Clock.Now().Unix()).To(Equal(snapshot.Time.Unix()))
+		its, err := env.CloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(its).To(HaveLen(1))
+		Expect(its[0].Name).To(Equal(instanceType.Name))
+		Expect(its[0].Offerings).To(HaveLen(len(instanceType.Offerings)))
+		Expect(its[0].Allocatable()).To(Equal(instanceType.Allocatable()))
+		Expect(env.Cluster.Nodes()).To(HaveLen(1))
+		Expect(env.Cluster.Synced(ctx)).To(BeTrue())
+	})
+	It("should launch a new node for pending pods", func() {
+		pod := test.UnschedulablePod()
+		snapshot.PendingPods = []*corev1.Pod{pod}
+		env, err := replay.New(ctx, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := env.Provision(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.PodErrors).To(BeEmpty())
+		Expect(results.NewNodeClaims).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].Pods).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].Pods[0].Name).To(Equal(pod.Name))
+	})
+	It("should schedule pending pods against existing nodes", func() {
+		node := existingNode()
+		snapshot.PendingPods = []*corev1.Pod{test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		}})}
+		env, err := replay.New(ctx, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := env.Provision(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(results.ExistingNodes).To(HaveLen(1))
+		Expect(results.ExistingNodes[0].Name()).To(Equal(node.Name))
+		Expect(results.ExistingNodes[0].Pods).To(HaveLen(1))
+	})
+	It("should report pods that can't be scheduled", func() {
+		snapshot.PendingPods = []*corev1.Pod{test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100")},
+		}})}
+		env, err := replay.New(ctx, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := env.Provision(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(results.PodErrors).To(HaveLen(1))
+	})
+	It("should consolidate empty nodes", func() {
+		node := existingNode()
+		env, err := replay.New(ctx, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		env.Clock.Step(time.Minute)
+
+		cmd, err := env.Disrupt(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.Decision()).To(Equal(disruption.DeleteDecision))
+		Expect(cmd.Candidates()).To(HaveLen(1))
+		Expect(cmd.Candidates()[0].Name()).To(Equal(node.Name))
+	})
+	It("should not disrupt nodes with pods that can't be rescheduled", func() {
+		existingNode(test.Pod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		}}))
+		env, err := replay.New(ctx, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		env.Clock.Step(time.Minute)
+
+		cmd, err := env.Disrupt(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.Decision()).To(Equal(disruption.NoOpDecision))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
 // lifetimeRemaining calculates the fraction of node lifetime remaining in the range [0.0, 1.0].  If the ExpireAfter
@@ -76,3 +78,34 @@ func ReschedulingCost(ctx context.Context, pods []*corev1.Pod) float64 {
 	}
 	return cost
 }
+
+// IsLocked returns true if the object is locked by an in-flight disruption command. Locks that have expired are
+// ignored, since the command that held the lock has timed out.
+func IsLocked(clk clock.Clock, o client.Object) bool {
+	if o.GetAnnotations()[v1.DisruptionLockAnnotationKey] == "" {
+		return false
+	}
+	expiration, err := time.Parse(time.RFC3339, o.GetAnnotations()[v1.DisruptionLockExpirationAnnotationKey])
+	if err != nil {
+		return false
+	}
+	return clk.Now().Before(expiration)
+}
+
+// LongRunningPods returns the reschedulable pods that have been running for longer than the NodePool's
+// protectLongRunningPods duration
+func LongRunningPods(clk clock.Clock, nodePool *v1.NodePool, pods []*corev1.Pod) []*corev1.Pod {
+	threshold := nodePool.Spec.Disruption.ProtectLongRunningPods
+	if threshold == nil {
+		return nil
+	}
+	return lo.Filter(pods, func(p *corev1.Pod, _ int) bool {
+		return podutils.IsReschedulable(p) && p.Status.StartTime != nil && clk.Since(p.Status.StartTime.Time) >= threshold.Duration
+	})
+}
+
+// IsAwaitingApproval returns true if the node hosts pods that are protected by the NodePool's protectLongRunningPods
+// duration and hasn't been approved for disruption with the karpenter.sh/disruption-confirmed annotation
+func IsAwaitingApproval(clk clock.Clock, nodePool *v1.NodePool, annotations map[string]string, pods []*corev1.Pod) bool {
+	return annotations[v1.DisruptionConfirmedAnnotationKey] != "true" && len(LongRunningPods(clk, nodePool, pods)) > 0
+}
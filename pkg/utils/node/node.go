@@ -24,8 +24,11 @@ import (
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -149,6 +152,17 @@ func GetProvisionablePods(ctx context.Context, kubeClient client.Client) ([]*cor
 	}), nil
 }
 
+// GetSchedulingGatedPods grabs all pods that haven't been bound to a node and have scheduling gates
+func GetSchedulingGatedPods(ctx context.Context, kubeClient client.Client) ([]*corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := kubeClient.List(ctx, &podList, client.MatchingFields{"spec.nodeName": ""}); err != nil {
+		return nil, fmt.Errorf("listing pods, %w", err)
+	}
+	return lo.FilterMap(podList.Items, func(p corev1.Pod, _ int) (*corev1.Pod, bool) {
+		return &p, pod.IsSchedulingGated(&p) && pod.IsActive(&p)
+	}), nil
+}
+
 // GetVolumeAttachments grabs all volumeAttachments associated with the passed node
 func GetVolumeAttachments(ctx context.Context, kubeClient client.Client, node *corev1.Node) ([]*storagev1.VolumeAttachment, error) {
 	var volumeAttachmentList storagev1.VolumeAttachmentList
@@ -181,6 +195,43 @@ func IsManagedPredicateFuncs(cp cloudprovider.CloudProvider) predicate.Funcs {
 	})
 }
 
+// IsAwaitingDisruptionConfirmation returns true if the node hosts pods with disruption protection and hasn't been
+// confirmed for disruption with the karpenter.sh/disruption-confirmed annotation
+func IsAwaitingDisruptionConfirmation(node *corev1.Node) bool {
+	return lo.Contains(node.Finalizers, v1.DisruptionProtectionFinalizer) && node.Annotations[v1.DisruptionConfirmedAnnotationKey] != "true"
+}
+
+// PodEventHandler is a watcher on corev1.Pods that enqueues reconcile.Requests for the nodes that the pods are bound to
+func PodEventHandler() handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+		nodeName := o.(*corev1.Pod).Spec.NodeName
+		if nodeName == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: nodeName}}}
+	})
+}
+
+// StatefulSetEventHandler is a watcher on appsv1.StatefulSets that enqueues reconcile.Requests for the nodes that the
+// pods of the StatefulSets are bound to
+func StatefulSetEventHandler(c client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+		statefulSet := o.(*appsv1.StatefulSet)
+		selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+		if err != nil {
+			return nil
+		}
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, client.InNamespace(statefulSet.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil
+		}
+		return lo.Uniq(lo.FilterMap(pods.Items, func(p corev1.Pod, _ int) (reconcile.Request, bool) {
+			owner := metav1.GetControllerOf(&p)
+			return reconcile.Request{NamespacedName: types.NamespacedName{Name: p.Spec.NodeName}}, p.Spec.NodeName != "" && owner != nil && owner.UID == statefulSet.UID
+		}))
+	})
+}
+
 func NodeClaimEventHandler(c client.Client) handler.EventHandler {
 	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
 		providerID := o.(*v1.NodeClaim).Status.ProviderID
@@ -18,11 +18,13 @@ package nodeclaim
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
+	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,6 +36,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 func IsManaged(nodeClaim *v1.NodeClaim, cp cloudprovider.CloudProvider) bool {
@@ -65,7 +68,7 @@ func ForNodeClass(nodeClass status.Object) client.ListOption {
 	}
 }
 
-func ListManaged(ctx context.Context, c client.Client, cloudProvider cloudprovider.CloudProvider, opts ...client.ListOption) ([]*v1.NodeClaim, error) {
+func ListManaged(ctx context.Context, c client.Reader, cloudProvider cloudprovider.CloudProvider, opts ...client.ListOption) ([]*v1.NodeClaim, error) {
 	nodeClaimList := &v1.NodeClaimList{}
 	if err := c.List(ctx, nodeClaimList, opts...); err != nil {
 		return nil, err
@@ -225,6 +228,22 @@ func AllNodesForNodeClaim(ctx context.Context, c client.Client, nodeClaim *v1.No
 	return lo.ToSlicePtr(nodeList.Items), nil
 }
 
+// Delete records the reason for the termination of the NodeClaim with the karpenter.sh/termination-reason annotation
+// and then deletes the NodeClaim. The termination controllers label their metrics and events for the NodeClaim and its
+// Node with the reason. An existing reason isn't overwritten, so the controller that first deleted the NodeClaim is
+// the one that it's attributed to.
+func Delete(ctx context.Context, c client.Client, nodeClaim *v1.NodeClaim, reason v1.TerminationReason) error {
+	nodeClaim = nodeClaim.DeepCopy()
+	if _, ok := nodeClaim.Annotations[v1.NodeClaimTerminationReasonAnnotationKey]; !ok && nodeClaim.DeletionTimestamp.IsZero() {
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimTerminationReasonAnnotationKey: string(reason)})
+		if err := c.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return fmt.Errorf("annotating nodeclaim with termination reason, %w", err)
+		}
+	}
+	return c.Delete(ctx, nodeClaim)
+}
+
 func UpdateNodeOwnerReferences(nodeClaim *v1.NodeClaim, node *corev1.Node) *corev1.Node {
 	gvk := object.GVK(nodeClaim)
 	node.OwnerReferences = append(node.OwnerReferences, metav1.OwnerReference{
@@ -236,3 +255,76 @@ func UpdateNodeOwnerReferences(nodeClaim *v1.NodeClaim, node *corev1.Node) *core
 	})
 	return node
 }
+
+// MaxSchedulingDecisionPods bounds the number of pods that are recorded in the scheduling decision annotation so that
+// NodeClaims that were launched for a large number of small pods don't exceed the annotation size limit.
+const MaxSchedulingDecisionPods = 100
+
+// SchedulingDecision records the set of pods that justified the launch of a NodeClaim. It is persisted on the NodeClaim
+// so that it's possible to determine what a node was launched for long after the original pods have churned.
+type SchedulingDecision struct {
+	// Pods are the pods that were nominated to the NodeClaim when it was launched
+	Pods []NominatedPod `json:"pods"`
+	// TotalPods is the total number of pods that were nominated, which may exceed len(Pods) when the list is truncated
+	TotalPods int `json:"totalPods"`
+}
+
+// NominatedPod is a pod that was nominated to a NodeClaim along with the requests used to schedule it
+type NominatedPod struct {
+	UID       types.UID           `json:"uid"`
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Requests  corev1.ResourceList `json:"requests,omitempty"`
+}
+
+// NewSchedulingDecision constructs a SchedulingDecision from the pods nominated to a NodeClaim
+func NewSchedulingDecision(pods ...*corev1.Pod) SchedulingDecision {
+	return SchedulingDecision{
+		Pods: lo.Map(lo.Slice(pods, 0, MaxSchedulingDecisionPods), func(p *corev1.Pod, _ int) NominatedPod {
+			return NominatedPod{
+				UID:       p.UID,
+				Namespace: p.Namespace,
+				Name:      p.Name,
+				Requests:  resources.RequestsForPods(p),
+			}
+		}),
+		TotalPods: len(pods),
+	}
+}
+
+// SetSchedulingDecision stores the SchedulingDecision on the NodeClaim as an annotation
+func SetSchedulingDecision(nodeClaim *v1.NodeClaim, decision SchedulingDecision) error {
+	raw, err := json.Marshal(decision)
+	if err != nil {
+		return fmt.Errorf("marshaling scheduling decision, %w", err)
+	}
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimSchedulingDecisionAnnotationKey: string(raw)})
+	return nil
+}
+
+// GetSchedulingDecision returns the SchedulingDecision that was stored on the NodeClaim at launch. It returns nil if the
+// NodeClaim wasn't launched with a recorded decision (e.g. it was created manually).
+func GetSchedulingDecision(nodeClaim *v1.NodeClaim) (*SchedulingDecision, error) {
+	raw, ok := nodeClaim.Annotations[v1.NodeClaimSchedulingDecisionAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	decision := &SchedulingDecision{}
+	if err := json.Unmarshal([]byte(raw), decision); err != nil {
+		return nil, fmt.Errorf("unmarshaling scheduling decision, %w", err)
+	}
+	return decision, nil
+}
+
+// IdempotencyKey derives a key for the launch of a NodeClaim from the NodePool that it belongs to and the set of pods that
+// it was launched for. The key only depends on inputs that a newly elected leader recomputes identically, so that the
+// NodeClaim that a previous leader created for the same pods can be adopted rather than launched a second time.
+func IdempotencyKey(nodePoolName string, pods ...*corev1.Pod) string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash(struct {
+		NodePool string
+		Pods     []types.UID
+	}{
+		NodePool: nodePoolName,
+		Pods:     lo.Map(pods, func(p *corev1.Pod, _ int) types.UID { return p.UID }),
+	}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})))
+}
@@ -222,4 +222,31 @@ var _ = Describe("NodeClaimUtils", func() {
 			Expect(res[0].Name).To(Equal(managed.Name))
 		})
 	})
+	Context("Delete", func() {
+		It("should record the termination reason before deleting the nodeclaim", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{v1.TerminationFinalizer}}})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			Expect(nodeclaimutils.Delete(ctx, env.Client, nodeClaim, v1.TerminationReasonDrift)).To(Succeed())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+			Expect(nodeClaim.TerminationReason()).To(Equal(v1.TerminationReasonDrift))
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+		})
+		It("should not overwrite the termination reason of a nodeclaim", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+				Finalizers:  []string{v1.TerminationFinalizer},
+				Annotations: map[string]string{v1.NodeClaimTerminationReasonAnnotationKey: string(v1.TerminationReasonExpiration)},
+			}})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			Expect(nodeclaimutils.Delete(ctx, env.Client, nodeClaim, v1.TerminationReasonDrift)).To(Succeed())
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.TerminationReason()).To(Equal(v1.TerminationReasonExpiration))
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+		})
+		It("should report nodeclaims without a termination reason as manually terminated", func() {
+			Expect(test.NodeClaim().TerminationReason()).To(Equal(v1.TerminationReasonManual))
+		})
+	})
 })
@@ -18,11 +18,16 @@ package nodepool
 
 import (
 	"context"
+	"hash/fnv"
 	"sort"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -31,6 +36,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 func IsManaged(nodePool *v1.NodePool, cp cloudprovider.CloudProvider) bool {
@@ -46,6 +52,72 @@ func IsManagedPredicateFuncs(cp cloudprovider.CloudProvider) predicate.Funcs {
 	})
 }
 
+// IsInShard returns true if the NodePool is owned by the shard of this Karpenter deployment. NodePools are partitioned
+// across shards by the hash of their name and can be further narrowed to the NodePools matching a label selector.
+func IsInShard(ctx context.Context, nodePool *v1.NodePool) bool {
+	if !isNameInShard(ctx, nodePool.Name) {
+		return false
+	}
+	selector, err := labels.Parse(options.FromContext(ctx).ShardNodePoolSelector)
+	if err != nil {
+		// The selector is validated when the options are parsed
+		return false
+	}
+	return selector.Matches(labels.Set(nodePool.Labels))
+}
+
+// IsSharded returns true if this Karpenter deployment only owns a subset of the NodePools
+func IsSharded(ctx context.Context) bool {
+	return options.FromContext(ctx).ShardCount > 1 || options.FromContext(ctx).ShardNodePoolSelector != ""
+}
+
+// IsOwnerInShard returns true if the NodePool that owns the object through the karpenter.sh/nodepool label is owned by
+// the shard of this Karpenter deployment. Objects that aren't owned by a NodePool are in every shard, and objects of
+// NodePools that no longer exist are only sharded by the hash of the NodePool's name so that they're still cleaned up.
+func IsOwnerInShard(ctx context.Context, kubeClient client.Client, o client.Object) bool {
+	name, ok := o.GetLabels()[v1.NodePoolLabelKey]
+	if !ok {
+		return true
+	}
+	if !isNameInShard(ctx, name) {
+		return false
+	}
+	if options.FromContext(ctx).ShardNodePoolSelector == "" {
+		return true
+	}
+	nodePool := &v1.NodePool{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+		return errors.IsNotFound(err)
+	}
+	return IsInShard(ctx, nodePool)
+}
+
+// IsInShardPredicateFuncs is used to filter controller-runtime NodePool watches to NodePools owned by the shard of this
+// Karpenter deployment.
+func IsInShardPredicateFuncs(ctx context.Context) predicate.Funcs {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return IsInShard(ctx, o.(*v1.NodePool))
+	})
+}
+
+// IsOwnerInShardPredicateFuncs is used to filter controller-runtime NodeClaim and Node watches to the objects whose
+// NodePool is owned by the shard of this Karpenter deployment.
+func IsOwnerInShardPredicateFuncs(ctx context.Context, kubeClient client.Client) predicate.Funcs {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return IsOwnerInShard(ctx, kubeClient, o)
+	})
+}
+
+func isNameInShard(ctx context.Context, name string) bool {
+	count := options.FromContext(ctx).ShardCount
+	if count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32()%uint32(count)) == options.FromContext(ctx).ShardIndex
+}
+
 func ForNodeClass(nc status.Object) client.ListOption {
 	return client.MatchingFields{
 		"spec.template.spec.nodeClassRef.group": object.GVK(nc).Group,
@@ -60,7 +132,7 @@ func ListManaged(ctx context.Context, c client.Client, cloudProvider cloudprovid
 		return nil, err
 	}
 	return lo.FilterMap(nodePoolList.Items, func(np v1.NodePool, _ int) (*v1.NodePool, bool) {
-		return &np, IsManaged(&np, cloudProvider)
+		return &np, IsManaged(&np, cloudProvider) && IsInShard(ctx, &np)
 	}), nil
 }
 
@@ -104,16 +176,52 @@ func NodeClassEventHandler(c client.Client) handler.EventHandler {
 
 // OrderByWeight orders the NodePools in the provided slice by their priority weight in-place. This priority evaluates
 // the following things in precedence order:
-//  1. NodePools that have a larger weight are ordered first
-//  2. If two NodePools have the same weight, then the NodePool with the name later in the alphabet will come first
+//  1. NodePools that aren't degraded are ordered before NodePools that are degraded
+//  2. NodePools that have a larger weight are ordered first
+//  3. If two NodePools have the same weight, then the NodePool with the name later in the alphabet will come first
 func OrderByWeight(nps []*v1.NodePool) {
 	sort.Slice(nps, func(a, b int) bool {
-		weightA := lo.FromPtr(nps[a].Spec.Weight)
-		weightB := lo.FromPtr(nps[b].Spec.Weight)
-		if weightA == weightB {
-			// Order NodePools by name for a consistent ordering when sorting equal weight
-			return nps[a].Name > nps[b].Name
+		if IsDegraded(nps[a]) != IsDegraded(nps[b]) {
+			return !IsDegraded(nps[a])
+		}
+		return HasPriority(nps[a], nps[b])
+	})
+}
+
+// HasPriority returns true if NodePool a is weighted ahead of NodePool b, regardless of whether either is degraded
+func HasPriority(a, b *v1.NodePool) bool {
+	weightA := lo.FromPtr(a.Spec.Weight)
+	weightB := lo.FromPtr(b.Spec.Weight)
+	if weightA == weightB {
+		// Order NodePools by name for a consistent ordering when sorting equal weight
+		return a.Name > b.Name
+	}
+	return weightA > weightB
+}
+
+// IsDegraded returns true if the NodePool is marked as degraded, in which case it's only scheduled against once pods
+// don't fit any of the healthy NodePools
+func IsDegraded(nodePool *v1.NodePool) bool {
+	return nodePool.StatusConditions().Get(v1.ConditionTypeDegraded).IsTrue()
+}
+
+// IPFamily returns the IP family that the pods on the NodePool's nodes are assigned addresses from, which is the IP family
+// of the cluster unless the NodePool overrides it with the karpenter.sh/ip-family annotation
+func IPFamily(ctx context.Context, nodePool *v1.NodePool) string {
+	if ipFamily, ok := nodePool.Annotations[v1.IPFamilyAnnotationKey]; ok && lo.Contains([]string{v1.IPFamilyIPv4, v1.IPFamilyIPv6, v1.IPFamilyDualStack}, ipFamily) {
+		return ipFamily
+	}
+	return options.FromContext(ctx).IPFamily
+}
+
+// LimitUsage returns the fraction of each of the NodePool's limits that is consumed by the resources in its status.
+// A limit of zero is fully consumed, and usage exceeds one when nodes were provisioned before a limit was lowered.
+func LimitUsage(nodePool *v1.NodePool) map[corev1.ResourceName]float64 {
+	return lo.MapValues(nodePool.Spec.Limits, func(limit resource.Quantity, name corev1.ResourceName) float64 {
+		used := nodePool.Status.Resources[name]
+		if limit.IsZero() {
+			return 1
 		}
-		return weightA > weightB
+		return used.AsApproximateFloat64() / limit.AsApproximateFloat64()
 	})
 }
@@ -24,9 +24,11 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	"golang.org/x/exp/rand"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
@@ -94,4 +96,38 @@ var _ = Describe("NodePoolUtils", func() {
 			}
 		})
 	})
+	Context("Sharding", func() {
+		It("should partition the NodePools across the shards", func() {
+			nps := lo.Times(30, func(_ int) *v1.NodePool { return test.NodePool() })
+			for _, np := range nps {
+				owners := lo.Filter(lo.Range(3), func(index int, _ int) bool {
+					return nodepoolutils.IsInShard(options.ToContext(ctx, test.Options(test.OptionsFields{ShardCount: lo.ToPtr(3), ShardIndex: lo.ToPtr(index)})), np)
+				})
+				Expect(owners).To(HaveLen(1))
+			}
+		})
+		It("should own every NodePool when there is a single shard", func() {
+			shardCtx := options.ToContext(ctx, test.Options())
+			for _, np := range lo.Times(10, func(_ int) *v1.NodePool { return test.NodePool() }) {
+				Expect(nodepoolutils.IsInShard(shardCtx, np)).To(BeTrue())
+			}
+		})
+		It("should only own the NodePools that match the shard's selector", func() {
+			shardCtx := options.ToContext(ctx, test.Options(test.OptionsFields{ShardNodePoolSelector: lo.ToPtr("karpenter.sh/shard=a")}))
+			Expect(nodepoolutils.IsInShard(shardCtx, test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "a"}}}))).To(BeTrue())
+			Expect(nodepoolutils.IsInShard(shardCtx, test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "b"}}}))).To(BeFalse())
+			Expect(nodepoolutils.IsInShard(shardCtx, test.NodePool())).To(BeFalse())
+		})
+		It("should resolve the shard of NodeClaims from their NodePool", func() {
+			shardCtx := options.ToContext(ctx, test.Options(test.OptionsFields{ShardNodePoolSelector: lo.ToPtr("karpenter.sh/shard=a")}))
+			owned := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"karpenter.sh/shard": "a"}}})
+			other := test.NodePool()
+			ExpectApplied(ctx, env.Client, owned, other)
+			Expect(nodepoolutils.IsOwnerInShard(shardCtx, env.Client, test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: owned.Name}}}))).To(BeTrue())
+			Expect(nodepoolutils.IsOwnerInShard(shardCtx, env.Client, test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: other.Name}}}))).To(BeFalse())
+			// NodeClaims of NodePools that no longer exist and nodes that aren't owned by a NodePool are in every shard
+			Expect(nodepoolutils.IsOwnerInShard(shardCtx, env.Client, test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: "deleted"}}}))).To(BeTrue())
+			Expect(nodepoolutils.IsOwnerInShard(shardCtx, env.Client, test.Node())).To(BeTrue())
+		})
+	})
 })
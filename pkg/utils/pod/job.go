@@ -0,0 +1,108 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func IsOwnedByJob(pod *corev1.Pod) bool {
+	return IsOwnedBy(pod, []schema.GroupVersionKind{
+		batchv1.SchemeGroupVersion.WithKind("Job"),
+	})
+}
+
+// IsNearlyFinishedJob returns true if the pod is owned by a Job and is expected to finish within the window. A Job pod
+// is expected to finish when its own or its Job's active deadline elapses, or, once its Job is running its final
+// completions, after the average run time of the Job's pods that have already succeeded. Pods that have overrun their
+// expected finish time aren't considered nearly finished, so that a pod is never considered nearly finished for longer
+// than the window.
+func IsNearlyFinishedJob(ctx context.Context, kubeClient client.Client, clk clock.Clock, pod *corev1.Pod, window time.Duration) (bool, error) {
+	if window <= 0 || !IsActive(pod) || pod.Status.StartTime == nil || !IsOwnedByJob(pod) {
+		return false, nil
+	}
+	owner, _ := lo.Find(pod.OwnerReferences, func(o metav1.OwnerReference) bool { return o.Kind == "Job" })
+	job := &batchv1.Job{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, job); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	running := clk.Since(pod.Status.StartTime.Time)
+	var remaining []time.Duration
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		remaining = append(remaining, time.Duration(*pod.Spec.ActiveDeadlineSeconds)*time.Second-running)
+	}
+	if job.Spec.ActiveDeadlineSeconds != nil && job.Status.StartTime != nil {
+		remaining = append(remaining, time.Duration(*job.Spec.ActiveDeadlineSeconds)*time.Second-clk.Since(job.Status.StartTime.Time))
+	}
+	if isRunningFinalCompletions(job) {
+		runTime, ok, err := averageRunTime(ctx, kubeClient, job)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			remaining = append(remaining, runTime-running)
+		}
+	}
+	if len(remaining) == 0 {
+		return false, nil
+	}
+	r := lo.Min(remaining)
+	return r > 0 && r <= window, nil
+}
+
+// isRunningFinalCompletions returns true if the Job's active pods are all that's left to meet its completions
+func isRunningFinalCompletions(job *batchv1.Job) bool {
+	return job.Spec.Completions != nil && job.Status.Succeeded > 0 && *job.Spec.Completions-job.Status.Succeeded <= job.Status.Active
+}
+
+// averageRunTime returns the average time that the Job's succeeded pods ran for. False is returned if none of the
+// succeeded pods are left to estimate the run time from.
+func averageRunTime(ctx context.Context, kubeClient client.Client, job *batchv1.Job) (time.Duration, bool, error) {
+	podList := &corev1.PodList{}
+	if err := kubeClient.List(ctx, podList, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.ControllerUidLabel: string(job.UID)}); err != nil {
+		return 0, false, fmt.Errorf("listing job pods, %w", err)
+	}
+	var runTimes []time.Duration
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded || pod.Status.StartTime == nil {
+			continue
+		}
+		finishedAt := lo.MaxBy(pod.Status.ContainerStatuses, func(a, b corev1.ContainerStatus) bool {
+			return a.State.Terminated != nil && (b.State.Terminated == nil || a.State.Terminated.FinishedAt.After(b.State.Terminated.FinishedAt.Time))
+		})
+		if finishedAt.State.Terminated == nil {
+			continue
+		}
+		runTimes = append(runTimes, finishedAt.State.Terminated.FinishedAt.Sub(pod.Status.StartTime.Time))
+	}
+	if len(runTimes) == 0 {
+		return 0, false, nil
+	}
+	return lo.Sum(runTimes) / time.Duration(len(runTimes)), true, nil
+}
@@ -17,13 +17,17 @@ limitations under the License.
 package pod
 
 import (
+	"context"
+	"strconv"
 	"time"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
@@ -86,14 +90,25 @@ func IsDrainable(pod *corev1.Pod, clk clock.Clock) bool {
 // - Has been marked as "Unschedulable" in the PodScheduled reason by the kube-scheduler
 // - Has not been bound to a node
 // - Isn't currently preempting other pods on the cluster and about to schedule
+// - Doesn't have any scheduling gates (https://kubernetes.io/docs/concepts/scheduling-eviction/pod-scheduling-readiness/)
 // - Isn't owned by a DaemonSet
 // - Isn't a mirror pod (https://kubernetes.io/docs/tasks/configure-pod-container/static-pod/)
+// - Isn't running a Karpenter lifecycle hook
 func IsProvisionable(pod *corev1.Pod) bool {
 	return FailedToSchedule(pod) &&
 		!IsScheduled(pod) &&
 		!IsPreempting(pod) &&
+		!IsSchedulingGated(pod) &&
 		!IsOwnedByDaemonSet(pod) &&
-		!IsOwnedByNode(pod)
+		!IsOwnedByNode(pod) &&
+		!IsLifecycleHook(pod)
+}
+
+// IsLifecycleHook returns true if the pod was created by Karpenter to run a lifecycle hook. These pods must be able
+// to schedule to the existing nodes that their hooks run for.
+func IsLifecycleHook(pod *corev1.Pod) bool {
+	_, ok := pod.Labels[v1.HookLabelKey]
+	return ok
 }
 
 // IsDisruptable checks if a pod can be disrupted based on validating the `karpenter.sh/do-not-disrupt` annotation on the pod.
@@ -127,6 +142,12 @@ func IsPreempting(pod *corev1.Pod) bool {
 	return pod.Status.NominatedNodeName != ""
 }
 
+// IsSchedulingGated checks if the pod has scheduling gates. The kube-scheduler won't schedule the pod until all of its
+// gates are removed, so we shouldn't launch capacity for it either.
+func IsSchedulingGated(pod *corev1.Pod) bool {
+	return len(pod.Spec.SchedulingGates) > 0
+}
+
 func IsTerminal(pod *corev1.Pod) bool {
 	return pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded
 }
@@ -162,6 +183,25 @@ func IsOwnedByNode(pod *corev1.Pod) bool {
 	})
 }
 
+// Standalone pod policies that determine whether capacity is launched for pods without an owner
+const (
+	StandalonePodPolicyProvision = "Provision"
+	StandalonePodPolicyIgnore    = "Ignore"
+)
+
+// IsStandalone returns true if the pod isn't owned by any controller, e.g. a one-off debug pod
+func IsStandalone(pod *corev1.Pod) bool {
+	return len(pod.OwnerReferences) == 0
+}
+
+// IsStandaloneIgnored returns true if capacity shouldn't be launched for the pod because it's standalone and the
+// standalone pod policy ignores standalone pods in its namespace
+func IsStandaloneIgnored(ctx context.Context, pod *corev1.Pod) bool {
+	return IsStandalone(pod) &&
+		options.FromContext(ctx).StandalonePodPolicy == StandalonePodPolicyIgnore &&
+		!lo.Contains(options.FromContext(ctx).StandalonePodNamespaces, pod.Namespace)
+}
+
 func IsOwnedBy(pod *corev1.Pod, gvks []schema.GroupVersionKind) bool {
 	for _, ignoredOwner := range gvks {
 		for _, owner := range pod.ObjectMeta.OwnerReferences {
@@ -173,6 +213,35 @@ func IsOwnedBy(pod *corev1.Pod, gvks []schema.GroupVersionKind) bool {
 	return false
 }
 
+// HasEmptyDirProtection returns true if the pod mounts an emptyDir volume and is still within the protection window
+// that was set through the `karpenter.sh/emptydir-protection` annotation. The protection window is measured from the
+// pod's start time, which allows batch jobs with scratch data to avoid being disrupted mid-run without permanently
+// blocking disruption of the node.
+func HasEmptyDirProtection(pod *corev1.Pod, clk clock.Clock) bool {
+	value, ok := pod.Annotations[v1.EmptyDirProtectionAnnotationKey]
+	if !ok {
+		return false
+	}
+	protection, err := time.ParseDuration(value)
+	if err != nil {
+		return false
+	}
+	if !lo.ContainsBy(pod.Spec.Volumes, func(v corev1.Volume) bool { return v.EmptyDir != nil }) {
+		return false
+	}
+	startTime := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		startTime = pod.Status.StartTime.Time
+	}
+	return clk.Since(startTime) < protection
+}
+
+// IsForceEvicted returns true if the pod is in one of the force-evict namespaces. These pods never block the deletion
+// of nodes: their PDBs and do-not-disrupt annotations are ignored, and they're deleted once all other pods are evicted.
+func IsForceEvicted(ctx context.Context, pod *corev1.Pod) bool {
+	return lo.Contains(options.FromContext(ctx).ForceEvictNamespaces, pod.Namespace)
+}
+
 func HasDoNotDisrupt(pod *corev1.Pod) bool {
 	if pod.Annotations == nil {
 		return false
@@ -180,6 +249,34 @@ func HasDoNotDisrupt(pod *corev1.Pod) bool {
 	return pod.Annotations[v1.DoNotDisruptAnnotationKey] == "true"
 }
 
+// DeletionCost returns the value of the pod's controller.kubernetes.io/pod-deletion-cost annotation. Pods without the
+// annotation or with an invalid value have a deletion cost of 0, matching the ReplicaSet controller.
+func DeletionCost(pod *corev1.Pod) int32 {
+	cost, err := strconv.ParseInt(pod.Annotations[corev1.PodDeletionCost], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(cost)
+}
+
+// RequiresExistingCapacity returns true if the pod has the karpenter.sh/prefer-existing: required annotation, which
+// restricts it to existing and in-flight capacity so that Karpenter never launches a node for it
+func RequiresExistingCapacity(pod *corev1.Pod) bool {
+	return pod.Annotations[v1.PreferExistingAnnotationKey] == v1.PreferExistingRequired
+}
+
+// HasPreferenceRelaxationDisabled returns true if the pod has the karpenter.sh/preference-relaxation: disabled
+// annotation, which prevents its preferences from being relaxed when it fails to schedule
+func HasPreferenceRelaxationDisabled(pod *corev1.Pod) bool {
+	return pod.Annotations[v1.PreferenceRelaxationAnnotationKey] == v1.PreferenceRelaxationDisabled
+}
+
+// HasDisruptionProtection returns true if the pod has the karpenter.sh/disruption-protection annotation, which requires
+// voluntary terminations of its node to be confirmed before the node is drained
+func HasDisruptionProtection(pod *corev1.Pod) bool {
+	return pod.Annotations[v1.DisruptionProtectionAnnotationKey] == "true"
+}
+
 // ToleratesDisruptedNoScheduleTaint returns true if the pod tolerates karpenter.sh/disrupted:NoSchedule taint
 func ToleratesDisruptedNoScheduleTaint(pod *corev1.Pod) bool {
 	return scheduling.Taints([]corev1.Taint{v1.DisruptedNoScheduleTaint}).Tolerates(pod) == nil
@@ -17,6 +17,9 @@ limitations under the License.
 package resources
 
 import (
+	"slices"
+	"sort"
+
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -102,7 +105,7 @@ func podRequests(pod *v1.Pod) v1.ResourceList {
 	maxInitContainerReqs := v1.ResourceList{}
 
 	for _, container := range pod.Spec.Containers {
-		MergeInto(requests, MergeResourceLimitsIntoRequests(container))
+		MergeInto(requests, containerRequests(pod, container))
 	}
 
 	for _, container := range pod.Spec.InitContainers {
@@ -128,6 +131,22 @@ func podRequests(pod *v1.Pod) v1.ResourceList {
 	return requests
 }
 
+// containerRequests returns the requests of a container, accounting for in-place resizes of the pod's resources.
+// Until a resize is actuated, the container reserves the max of its desired and actuated requests on the node. If the
+// node can't accommodate the resize, the container keeps its actuated requests.
+// inspired from https://github.com/kubernetes/kubernetes/blob/v1.32.0/staging/src/k8s.io/component-helpers/resource/helpers.go#L163
+func containerRequests(pod *v1.Pod, container v1.Container) v1.ResourceList {
+	requests := MergeResourceLimitsIntoRequests(container)
+	status, ok := lo.Find(pod.Status.ContainerStatuses, func(s v1.ContainerStatus) bool { return s.Name == container.Name })
+	if !ok || status.Resources == nil {
+		return requests
+	}
+	if pod.Status.Resize == v1.PodResizeStatusInfeasible {
+		return status.Resources.Requests.DeepCopy()
+	}
+	return MaxResources(requests, status.Resources.Requests)
+}
+
 // podLimits calculates the max between the sum of container resources and max of initContainers along with sidecar feature consideration
 // inspired from https://github.com/kubernetes/kubernetes/blob/e2afa175e4077d767745246662170acd86affeaf/pkg/api/v1/resource/helpers.go#L96
 // https://kubernetes.io/blog/2023/08/25/native-sidecar-containers/
@@ -229,6 +248,76 @@ func Fits(candidate, total v1.ResourceList) bool {
 	return true
 }
 
+// FitsDevices returns true if the requests can be packed onto the devices that provide shared resources, where each
+// request for a shared resource must be satisfied by a single device. device is the capacity of a single device for
+// each shared resource and total is the capacity of all devices.
+func FitsDevices(requests []v1.ResourceList, device, total v1.ResourceList) bool {
+	for resourceName, size := range device {
+		if size.IsZero() {
+			continue
+		}
+		capacity := total[resourceName]
+		free := make([]int64, capacity.MilliValue()/size.MilliValue())
+		for i := range free {
+			free[i] = size.MilliValue()
+		}
+		var needed []int64
+		for _, r := range requests {
+			if q, ok := r[resourceName]; ok && !q.IsZero() {
+				needed = append(needed, q.MilliValue())
+			}
+		}
+		// First fit decreasing packs the largest requests first, which is optimal for the small number of devices on a node
+		sort.Slice(needed, func(i, j int) bool { return needed[i] > needed[j] })
+		for _, n := range needed {
+			i := slices.IndexFunc(free, func(f int64) bool { return f >= n })
+			if i < 0 {
+				return false
+			}
+			free[i] -= n
+		}
+	}
+	return true
+}
+
+// FitsSlices returns true if the requests can be packed onto the slices that a node is partitioned into, where each
+// request for the resources of the slices must be satisfied by a single slice. capacities is the capacity of each
+// slice, and resources that aren't in the capacity of any slice are ignored.
+func FitsSlices(requests []v1.ResourceList, capacities []v1.ResourceList) bool {
+	if len(capacities) == 0 {
+		return true
+	}
+	largest := MaxResources(capacities...)
+	var needed []v1.ResourceList
+	for _, r := range requests {
+		if n := lo.PickBy(r, func(k v1.ResourceName, q resource.Quantity) bool {
+			size, ok := largest[k]
+			return ok && !q.IsZero() && !size.IsZero()
+		}); len(n) > 0 {
+			needed = append(needed, n)
+		}
+	}
+	// First fit decreasing packs the requests that need the largest share of a slice first
+	share := func(r v1.ResourceList) float64 {
+		total := 0.0
+		for k, q := range r {
+			size := largest[k]
+			total += q.AsApproximateFloat64() / size.AsApproximateFloat64()
+		}
+		return total
+	}
+	sort.SliceStable(needed, func(i, j int) bool { return share(needed[i]) > share(needed[j]) })
+	free := lo.Map(capacities, func(c v1.ResourceList, _ int) v1.ResourceList { return c.DeepCopy() })
+	for _, n := range needed {
+		i := slices.IndexFunc(free, func(f v1.ResourceList) bool { return Fits(n, f) })
+		if i < 0 {
+			return false
+		}
+		free[i] = Subtract(free[i], n)
+	}
+	return true
+}
+
 // String returns a string version of the resource list suitable for presenting in a log
 func String(list v1.ResourceList) string {
 	if len(list) == 0 {
@@ -566,6 +566,55 @@ var _ = Describe("Resources", func() {
 			})
 		})
 	})
+	Context("In-Place Resizes", func() {
+		var pod *v1.Pod
+		BeforeEach(func() {
+			pod = test.Pod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			})
+		})
+		It("should reserve the actuated requests until a resize that lowers requests is actuated", func() {
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+				Name:      pod.Spec.Containers[0].Name,
+				Resources: &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+			}}
+			ExpectResources(resources.Ceiling(pod).Requests, v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("3"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+			})
+		})
+		It("should use the max of the desired and actuated requests while a resize is in progress", func() {
+			pod.Status.Resize = v1.PodResizeStatusInProgress
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+				Name:      pod.Spec.Containers[0].Name,
+				Resources: &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("2Gi")}},
+			}}
+			ExpectResources(resources.Ceiling(pod).Requests, v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("2Gi"),
+			})
+		})
+		It("should use the actuated requests when a resize is infeasible", func() {
+			pod.Status.Resize = v1.PodResizeStatusInfeasible
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+				Name:      pod.Spec.Containers[0].Name,
+				Resources: &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("512Mi")}},
+			}}
+			ExpectResources(resources.Ceiling(pod).Requests, v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("512Mi"),
+			})
+		})
+		It("should use the desired requests of containers without actuated resources", func() {
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: pod.Spec.Containers[0].Name}}
+			ExpectResources(resources.Ceiling(pod).Requests, v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+			})
+		})
+	})
 	Context("Resource Merging", func() {
 		It("should merge resource limits into requests if no request exists for the given container", func() {
 			container := v1.Container{
@@ -599,4 +648,67 @@ var _ = Describe("Resources", func() {
 			})
 		})
 	})
+	Context("Device Fitting", func() {
+		gpuMemory := v1.ResourceName("example.com/gpu-memory")
+		device := v1.ResourceList{gpuMemory: resource.MustParse("8Gi")}
+		total := v1.ResourceList{gpuMemory: resource.MustParse("16Gi")}
+		It("should pack requests onto separate devices", func() {
+			Expect(resources.FitsDevices([]v1.ResourceList{
+				{gpuMemory: resource.MustParse("6Gi")},
+				{gpuMemory: resource.MustParse("6Gi")},
+			}, device, total)).To(BeTrue())
+		})
+		It("should pack requests that fit onto a device in total", func() {
+			Expect(resources.FitsDevices([]v1.ResourceList{
+				{gpuMemory: resource.MustParse("2Gi")},
+				{gpuMemory: resource.MustParse("6Gi")},
+				{gpuMemory: resource.MustParse("5Gi")},
+				{gpuMemory: resource.MustParse("3Gi")},
+			}, device, total)).To(BeTrue())
+		})
+		It("should not split a request across devices", func() {
+			Expect(resources.FitsDevices([]v1.ResourceList{{gpuMemory: resource.MustParse("10Gi")}}, device, total)).To(BeFalse())
+			Expect(resources.FitsDevices([]v1.ResourceList{
+				{gpuMemory: resource.MustParse("5Gi")},
+				{gpuMemory: resource.MustParse("5Gi")},
+				{gpuMemory: resource.MustParse("5Gi")},
+			}, device, total)).To(BeFalse())
+		})
+		It("should ignore resources that aren't shared", func() {
+			Expect(resources.FitsDevices([]v1.ResourceList{{v1.ResourceCPU: resource.MustParse("100")}}, device, total)).To(BeTrue())
+			Expect(resources.FitsDevices([]v1.ResourceList{{gpuMemory: resource.MustParse("100Gi")}}, v1.ResourceList{}, total)).To(BeTrue())
+		})
+	})
+	Context("Slice Fitting", func() {
+		gpu := v1.ResourceName("example.com/gpu")
+		slices := []v1.ResourceList{
+			{v1.ResourceCPU: resource.MustParse("8"), gpu: resource.MustParse("3")},
+			{v1.ResourceCPU: resource.MustParse("4"), gpu: resource.MustParse("2")},
+		}
+		It("should pack requests onto separate slices", func() {
+			Expect(resources.FitsSlices([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("2"), gpu: resource.MustParse("2")},
+				{v1.ResourceCPU: resource.MustParse("6"), gpu: resource.MustParse("3")},
+			}, slices)).To(BeTrue())
+		})
+		It("should pack the requests that need the largest share of a slice first", func() {
+			Expect(resources.FitsSlices([]v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("4")},
+				{v1.ResourceCPU: resource.MustParse("4")},
+				{v1.ResourceCPU: resource.MustParse("2")},
+				{v1.ResourceCPU: resource.MustParse("2")},
+			}, slices)).To(BeTrue())
+		})
+		It("should not split a request across slices", func() {
+			Expect(resources.FitsSlices([]v1.ResourceList{{gpu: resource.MustParse("4")}}, slices)).To(BeFalse())
+			Expect(resources.FitsSlices([]v1.ResourceList{{v1.ResourceCPU: resource.MustParse("6"), gpu: resource.MustParse("1")}}, []v1.ResourceList{
+				{v1.ResourceCPU: resource.MustParse("8"), gpu: resource.MustParse("0")},
+				{v1.ResourceCPU: resource.MustParse("4"), gpu: resource.MustParse("2")},
+			})).To(BeFalse())
+		})
+		It("should ignore resources that aren't sliced", func() {
+			Expect(resources.FitsSlices([]v1.ResourceList{{v1.ResourceMemory: resource.MustParse("100Gi")}}, slices)).To(BeTrue())
+			Expect(resources.FitsSlices([]v1.ResourceList{{v1.ResourceCPU: resource.MustParse("100")}}, nil)).To(BeTrue())
+		})
+	})
 })
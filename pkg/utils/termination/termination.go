@@ -19,11 +19,14 @@ package termination
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
 // EnsureTerminated is a helper function that takes a v1.NodeClaim and calls cloudProvider.Delete() if status condition
@@ -79,3 +82,12 @@ func updateStatusConditionsForDeleting(nc *v1.NodeClaim) {
 	}
 	nc.StatusConditions().SetTrue(v1.ConditionTypeInstanceTerminating)
 }
+
+// ReasonLabels returns the metric labels that attribute the termination of a NodeClaim or Node to the reason that it
+// was deleted and whether that termination was voluntary
+func ReasonLabels(reason v1.TerminationReason) map[string]string {
+	return map[string]string{
+		metrics.ReasonLabel:    pretty.ToSnakeCase(string(reason)),
+		metrics.VoluntaryLabel: strconv.FormatBool(reason.IsVoluntary()),
+	}
+}
@@ -21,8 +21,11 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
 func GetPersistentVolumeClaim(ctx context.Context, kubeClient client.Client, pod *v1.Pod, volume v1.Volume) (*v1.PersistentVolumeClaim, error) {
@@ -43,3 +46,48 @@ func GetPersistentVolumeClaim(ctx context.Context, kubeClient client.Client, pod
 	}
 	return pvc, nil
 }
+
+// LocalVolume is a persistent volume that is backed by storage on the node, along with the claim that it is bound to.
+// The data on a local volume is lost when its node is terminated.
+type LocalVolume struct {
+	Claim  *v1.PersistentVolumeClaim
+	Volume *v1.PersistentVolume
+}
+
+func (l LocalVolume) String() string {
+	return fmt.Sprintf("%s (%s/%s)", l.Volume.Name, l.Claim.Namespace, l.Claim.Name)
+}
+
+// Replaceable returns true if the claim is annotated to signal that its data can be recreated, e.g. a cache
+func (l LocalVolume) Replaceable() bool {
+	return l.Claim.Annotations[karpv1.ReplaceableAnnotationKey] == "true"
+}
+
+// GetLocalVolumes returns the local persistent volumes that are bound to the pod's claims. Claims that don't exist or
+// aren't bound yet are ignored.
+func GetLocalVolumes(ctx context.Context, kubeClient client.Client, pod *v1.Pod) ([]LocalVolume, error) {
+	var localVolumes []LocalVolume
+	for _, volume := range pod.Spec.Volumes {
+		pvc, err := GetPersistentVolumeClaim(ctx, kubeClient, pod, volume)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if pvc == nil || pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv := &v1.PersistentVolume{}
+		if err = kubeClient.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getting persistent volume %q, %w", pvc.Spec.VolumeName, err)
+		}
+		if pv.Spec.Local != nil {
+			localVolumes = append(localVolumes, LocalVolume{Claim: pvc, Volume: pv})
+		}
+	}
+	return localVolumes, nil
+}
@@ -44,6 +44,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
@@ -860,7 +861,7 @@ func (env *Environment) GetDaemonSetCount(np *v1.NodePool) int {
 
 	return lo.CountBy(daemonSetList.Items, func(d appsv1.DaemonSet) bool {
 		p := &corev1.Pod{Spec: d.Spec.Template.Spec}
-		nodeClaimTemplate := pscheduling.NewNodeClaimTemplate(np)
+		nodeClaimTemplate := pscheduling.NewNodeClaimTemplate(options.ToContext(env.Context, test.Options()), np)
 		if err := scheduling.Taints(nodeClaimTemplate.Spec.Taints).Tolerates(p); err != nil {
 			return false
 		}
@@ -881,7 +882,7 @@ func (env *Environment) GetDaemonSetOverhead(np *v1.NodePool) corev1.ResourceLis
 
 	return resources.RequestsForPods(lo.FilterMap(daemonSetList.Items, func(ds appsv1.DaemonSet, _ int) (*corev1.Pod, bool) {
 		p := &corev1.Pod{Spec: ds.Spec.Template.Spec}
-		nodeClaimTemplate := pscheduling.NewNodeClaimTemplate(np)
+		nodeClaimTemplate := pscheduling.NewNodeClaimTemplate(options.ToContext(env.Context, test.Options()), np)
 		if err := scheduling.Taints(nodeClaimTemplate.Spec.Taints).Tolerates(p); err != nil {
 			return nil, false
 		}